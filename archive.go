@@ -0,0 +1,396 @@
+package gohelpertools
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ArchiveOptions limits what Unzip and Untar extract, and reports the progress of the archive helpers.
+// The limits protect against archives which expand to far more than their own size (zip bombs); sizes are
+// counted as the files are written, rather than taken from the archive's headers, which can lie.
+type ArchiveOptions struct {
+	MaxFiles    int                                      // maximum number of files and directories extracted; defaults to 10,000
+	MaxSize     int64                                    // maximum total size of the extracted files; defaults to 1GB
+	MaxFileSize int64                                    // maximum size of each extracted file; defaults to MaxSize
+	Progress    func(name string, done, total int64)     // if set, called after each file with the bytes processed so far and the total expected (-1 if unknown)
+	Filter      func(name string, info fs.FileInfo) bool // if set, only files for which it returns true are archived by ZipDirectory and TarDirectory
+}
+
+func archiveDefaults(opts []ArchiveOptions) ArchiveOptions {
+	var o ArchiveOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	if o.MaxFiles <= 0 {
+		o.MaxFiles = 10000
+	}
+	if o.MaxSize <= 0 {
+		o.MaxSize = 1 << 30
+	}
+	if o.MaxFileSize <= 0 || o.MaxFileSize > o.MaxSize {
+		o.MaxFileSize = o.MaxSize
+	}
+	return o
+}
+
+// archiveEntry is a file or directory to be added to an archive.
+type archiveEntry struct {
+	name string // slash-separated path in the archive, with a trailing slash for directories
+	path string // path on disk
+	info fs.FileInfo
+}
+
+// ZipDirectory writes the contents of the directory src to a new ZIP archive at dest. Paths in the
+// archive are relative to src. Symbolic links and other special files are skipped, as is dest itself if
+// it's inside src.
+func (t *Tools) ZipDirectory(src, dest string, opts ...ArchiveOptions) error {
+	o := archiveDefaults(opts)
+	entries, total, err := archiveEntries(src, dest, o.Filter)
+	if err != nil {
+		return err
+	}
+
+	return writeArchiveFile(dest, func(out io.Writer) error {
+		zw := zip.NewWriter(out)
+		var done int64
+		for _, entry := range entries {
+			header, err := zip.FileInfoHeader(entry.info)
+			if err != nil {
+				return err
+			}
+			header.Name = entry.name
+			if entry.info.IsDir() {
+				header.Method = zip.Store
+			} else {
+				header.Method = zip.Deflate
+			}
+			w, err := zw.CreateHeader(header)
+			if err != nil {
+				return err
+			}
+			if !entry.info.IsDir() {
+				n, err := copyFile(w, entry.path)
+				if err != nil {
+					return err
+				}
+				done += n
+			}
+			if o.Progress != nil {
+				o.Progress(entry.name, done, total)
+			}
+		}
+		return zw.Close()
+	})
+}
+
+// TarDirectory writes the contents of the directory src to a new tar archive at dest, which is compressed
+// with gzip if dest ends with .gz or .tgz. It is otherwise like ZipDirectory.
+func (t *Tools) TarDirectory(src, dest string, opts ...ArchiveOptions) error {
+	o := archiveDefaults(opts)
+	entries, total, err := archiveEntries(src, dest, o.Filter)
+	if err != nil {
+		return err
+	}
+
+	return writeArchiveFile(dest, func(out io.Writer) error {
+		var gz *gzip.Writer
+		if isGzipName(dest) {
+			gz = gzip.NewWriter(out)
+			out = gz
+		}
+		tw := tar.NewWriter(out)
+		var done int64
+		for _, entry := range entries {
+			header, err := tar.FileInfoHeader(entry.info, "")
+			if err != nil {
+				return err
+			}
+			header.Name = entry.name
+			if err := tw.WriteHeader(header); err != nil {
+				return err
+			}
+			if !entry.info.IsDir() {
+				n, err := copyFile(tw, entry.path)
+				if err != nil {
+					return err
+				}
+				done += n
+			}
+			if o.Progress != nil {
+				o.Progress(entry.name, done, total)
+			}
+		}
+		if err := tw.Close(); err != nil {
+			return err
+		}
+		if gz != nil {
+			return gz.Close()
+		}
+		return nil
+	})
+}
+
+// Unzip extracts the ZIP archive src into the directory dest, which is created if it doesn't exist. Entries
+// which would be written outside dest (zip slip), symbolic links and archives beyond the limits in opts are
+// rejected with an error; files extracted before the error are left in place.
+func (t *Tools) Unzip(src, dest string, opts ...ArchiveOptions) error {
+	o := archiveDefaults(opts)
+	zr, err := zip.OpenReader(src)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+
+	var total int64
+	for _, f := range zr.File {
+		total += int64(f.UncompressedSize64)
+	}
+
+	x := &extractor{dest: dest, opts: o, total: total}
+	for _, f := range zr.File {
+		if err := x.add(f.Name, f.Mode(), func() (io.ReadCloser, error) { return f.Open() }); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Untar extracts the tar archive src, compressed with gzip or not, into the directory dest, with the same
+// protections as Unzip.
+func (t *Tools) Untar(src, dest string, opts ...ArchiveOptions) error {
+	o := archiveDefaults(opts)
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var in io.Reader = f
+	if gz, err := gzip.NewReader(f); err == nil {
+		defer gz.Close()
+		in = gz
+	} else if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	x := &extractor{dest: dest, opts: o, total: -1}
+	tr := tar.NewReader(in)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if header.Typeflag == tar.TypeXGlobalHeader {
+			continue
+		}
+		if err := x.add(header.Name, header.FileInfo().Mode(), func() (io.ReadCloser, error) { return io.NopCloser(tr), nil }); err != nil {
+			return err
+		}
+	}
+}
+
+// WriteZipResponse streams a ZIP archive of the files names in fsys to the client, as an attachment called
+// fileName. The archive is written as it is built, so nothing is buffered in memory or on disk; since the
+// headers have been sent by then, an error part way through can only be reported by cutting the response
+// short, and is returned for logging.
+func (t *Tools) WriteZipResponse(w http.ResponseWriter, r *http.Request, fileName string, fsys FileSystem, names []string) error {
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": fileName}))
+	w.WriteHeader(http.StatusOK)
+
+	zw := zip.NewWriter(w)
+	for _, name := range names {
+		if err := addZipFile(r, zw, fsys, name); err != nil {
+			return fmt.Errorf("unable to add %s to the archive: %w", name, err)
+		}
+	}
+	return zw.Close()
+}
+
+func addZipFile(r *http.Request, zw *zip.Writer, fsys FileSystem, name string) error {
+	f, err := fsys.Get(r.Context(), name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: time.Now()})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// extractor writes the entries of an archive below dest, within the limits in opts.
+type extractor struct {
+	dest  string
+	opts  ArchiveOptions
+	total int64 // expected total size, or -1 if unknown
+	files int
+	done  int64
+}
+
+func (x *extractor) add(name string, mode fs.FileMode, open func() (io.ReadCloser, error)) error {
+	x.files++
+	if x.files > x.opts.MaxFiles {
+		return fmt.Errorf("the archive has more than %d files", x.opts.MaxFiles)
+	}
+
+	target, err := extractPath(x.dest, name)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case mode.IsDir():
+		return os.MkdirAll(target, 0o755)
+	case !mode.IsRegular():
+		return fmt.Errorf("the archive entry %s is not a regular file", name)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	rc, err := open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	out, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode.Perm()|0o600)
+	if err != nil {
+		return err
+	}
+	limit := min(x.opts.MaxFileSize, x.opts.MaxSize-x.done)
+	n, err := io.Copy(out, io.LimitReader(rc, limit+1))
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && n > limit {
+		err = fmt.Errorf("the archive expands to more than the size limit (at %s)", name)
+	}
+	if err != nil {
+		_ = os.Remove(target)
+		return err
+	}
+
+	x.done += n
+	if x.opts.Progress != nil {
+		x.opts.Progress(name, x.done, x.total)
+	}
+	return nil
+}
+
+// extractPath returns where the archive entry name goes below dest, or an error if it would go elsewhere.
+func extractPath(dest, name string) (string, error) {
+	clean := path.Clean("/" + strings.ReplaceAll(name, `\`, "/"))
+	if clean == "/" || strings.Contains(name, "\x00") || path.IsAbs(name) || strings.HasPrefix(name, `\`) ||
+		filepath.VolumeName(name) != "" || hasDotDot(name) {
+		return "", fmt.Errorf("invalid path %q in archive", name)
+	}
+	return filepath.Join(dest, filepath.FromSlash(clean[1:])), nil
+}
+
+// hasDotDot reports whether any element of name is "..".
+func hasDotDot(name string) bool {
+	for _, part := range strings.FieldsFunc(name, func(r rune) bool { return r == '/' || r == '\\' }) {
+		if part == ".." {
+			return true
+		}
+	}
+	return false
+}
+
+// archiveEntries lists the directories and regular files below src, skipping dest, and returns the total
+// size of the files.
+func archiveEntries(src, dest string, filter func(string, fs.FileInfo) bool) ([]archiveEntry, int64, error) {
+	absDest, err := filepath.Abs(dest)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var entries []archiveEntry
+	var total int64
+	err = filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil || rel == "." {
+			return err
+		}
+		if abs, _ := filepath.Abs(p); abs == absDest {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && !info.Mode().IsRegular() {
+			return nil
+		}
+
+		name := filepath.ToSlash(rel)
+		if filter != nil && !filter(name, info) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() {
+			name += "/"
+		} else {
+			total += info.Size()
+		}
+		entries = append(entries, archiveEntry{name: name, path: p, info: info})
+		return nil
+	})
+	return entries, total, err
+}
+
+// writeArchiveFile creates dest and writes it with write, removing it again if write fails.
+func writeArchiveFile(dest string, write func(io.Writer) error) error {
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	err = write(out)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(dest)
+	}
+	return err
+}
+
+func copyFile(w io.Writer, name string) (int64, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	return io.Copy(w, f)
+}
+
+func isGzipName(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	return ext == ".gz" || ext == ".tgz"
+}
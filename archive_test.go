@@ -0,0 +1,171 @@
+package gohelpertools
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTree creates the files in files below dir.
+func writeTree(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// checkTree fails unless the files in files exist below dir with the given content.
+func checkTree(t *testing.T, name, dir string, files map[string]string) {
+	for file, content := range files {
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(file)))
+		if err != nil || string(data) != content {
+			t.Errorf("%s: expected %s to be %q, got %q (%v)", name, file, content, data, err)
+		}
+	}
+}
+
+var archiveFiles = map[string]string{
+	"a.txt":          "alpha",
+	"docs/b.txt":     "bravo",
+	"docs/sub/c.txt": strings.Repeat("charlie ", 100),
+}
+
+func TestTools_ZipDirectoryAndUnzip(t *testing.T) {
+	var tools Tools
+	src, out := t.TempDir(), t.TempDir()
+	writeTree(t, src, archiveFiles)
+
+	var lastDone, lastTotal int64
+	progress := func(name string, done, total int64) { lastDone, lastTotal = done, total }
+
+	// The archive is written inside the directory being archived, and must not include itself.
+	dest := filepath.Join(src, "archive.zip")
+	if err := tools.ZipDirectory(src, dest, ArchiveOptions{Progress: progress}); err != nil {
+		t.Fatal(err)
+	}
+	if lastDone != 810 || lastTotal != 810 {
+		t.Errorf("unexpected progress %d/%d", lastDone, lastTotal)
+	}
+
+	if err := tools.Unzip(dest, out); err != nil {
+		t.Fatal(err)
+	}
+	checkTree(t, "unzip", out, archiveFiles)
+	if _, err := os.Stat(filepath.Join(out, "archive.zip")); err == nil {
+		t.Error("expected the archive not to contain itself")
+	}
+}
+
+func TestTools_TarDirectoryAndUntar(t *testing.T) {
+	var tools Tools
+	for _, name := range []string{"archive.tar", "archive.tar.gz"} {
+		src, out := t.TempDir(), t.TempDir()
+		writeTree(t, src, archiveFiles)
+		dest := filepath.Join(t.TempDir(), name)
+		if err := tools.TarDirectory(src, dest); err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		if err := tools.Untar(dest, out); err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		checkTree(t, name, out, archiveFiles)
+	}
+}
+
+// zipFile writes a ZIP archive holding files to a temporary file, and returns its path.
+func zipFile(t *testing.T, files map[string]string) string {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = io.WriteString(w, content)
+	}
+	_ = zw.Close()
+
+	p := filepath.Join(t.TempDir(), "test.zip")
+	if err := os.WriteFile(p, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+var unzipTests = []struct {
+	name          string
+	files         map[string]string
+	opts          ArchiveOptions
+	errorExpected bool
+}{
+	{name: "valid", files: map[string]string{"a/b.txt": "ok"}},
+	{name: "zip slip", files: map[string]string{"../evil.txt": "x"}, errorExpected: true},
+	{name: "nested zip slip", files: map[string]string{"a/../../evil.txt": "x"}, errorExpected: true},
+	{name: "absolute path", files: map[string]string{"/etc/evil": "x"}, errorExpected: true},
+	{name: "backslash slip", files: map[string]string{`..\evil.txt`: "x"}, errorExpected: true},
+	{name: "file too big", files: map[string]string{"big.txt": strings.Repeat("x", 100)}, opts: ArchiveOptions{MaxFileSize: 99}, errorExpected: true},
+	{name: "total too big", files: map[string]string{"a.txt": strings.Repeat("x", 60), "b.txt": strings.Repeat("x", 60)}, opts: ArchiveOptions{MaxSize: 100}, errorExpected: true},
+	{name: "too many files", files: map[string]string{"a.txt": "a", "b.txt": "b"}, opts: ArchiveOptions{MaxFiles: 1}, errorExpected: true},
+}
+
+func TestTools_Unzip(t *testing.T) {
+	var tools Tools
+	for _, e := range unzipTests {
+		root := t.TempDir()
+		dest := filepath.Join(root, "out")
+		err := tools.Unzip(zipFile(t, e.files), dest, e.opts)
+		if e.errorExpected {
+			if err == nil {
+				t.Errorf("%s: error expected, but none received", e.name)
+			}
+			if _, err := os.Stat(filepath.Join(root, "evil.txt")); err == nil {
+				t.Errorf("%s: file written outside the destination", e.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: error not expected, but one received: %s", e.name, err)
+			continue
+		}
+		checkTree(t, e.name, dest, e.files)
+	}
+}
+
+func TestTools_WriteZipResponse(t *testing.T) {
+	var tools Tools
+	fsys := &MemoryFileSystem{}
+	req := httptest.NewRequest(http.MethodGet, "/export", nil)
+	_ = fsys.Put(req.Context(), "reports/a.csv", strings.NewReader("a,b\n"))
+	_ = fsys.Put(req.Context(), "reports/b.csv", strings.NewReader("c,d\n"))
+
+	rr := httptest.NewRecorder()
+	if err := tools.WriteZipResponse(rr, req, "reports.zip", fsys, []string{"reports/a.csv", "reports/b.csv"}); err != nil {
+		t.Fatal(err)
+	}
+	if rr.Header().Get("Content-Type") != "application/zip" || rr.Header().Get("Content-Disposition") != `attachment; filename=reports.zip` {
+		t.Errorf("unexpected headers %v", rr.Header())
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(rr.Body.Bytes()), int64(rr.Body.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(zr.File) != 2 || zr.File[1].Name != "reports/b.csv" {
+		t.Errorf("unexpected archive entries %v", zr.File)
+	}
+
+	if err := tools.WriteZipResponse(httptest.NewRecorder(), req, "x.zip", fsys, []string{"missing.csv"}); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
@@ -0,0 +1,50 @@
+package gohelpertools
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// BasicAuth returns middleware which requires HTTP Basic authentication. Credentials are checked with
+// validate; requests without valid credentials receive a 401 JSON response and a WWW-Authenticate header
+// naming realm, so browsers know to prompt for a username and password.
+func (t *Tools) BasicAuth(realm string, validate func(user, pass string) bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, pass, ok := r.BasicAuth()
+			if !ok || !validate(user, pass) {
+				w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Basic realm=%q, charset="UTF-8"`, realm))
+				_ = t.ErrorJSON(w, errors.New("unauthorized"), http.StatusUnauthorized)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// BasicAuthUsers returns a validate function for BasicAuth which accepts the usernames and passwords in
+// users, comparing them in constant time.
+func (t *Tools) BasicAuthUsers(users map[string]string) func(user, pass string) bool {
+	return func(user, pass string) bool {
+		// Look at every entry, so that the time taken doesn't reveal whether the username exists.
+		match := false
+		for u, p := range users {
+			if t.SecureCompare(user, u) && t.SecureCompare(pass, p) {
+				match = true
+			}
+		}
+		return match
+	}
+}
+
+// SecureCompare reports whether a and b are equal, taking the same time regardless of where they differ.
+// Both values are hashed first, so the comparison doesn't leak their lengths either.
+func (t *Tools) SecureCompare(a, b string) bool {
+	hashA := sha256.Sum256([]byte(a))
+	hashB := sha256.Sum256([]byte(b))
+	return subtle.ConstantTimeCompare(hashA[:], hashB[:]) == 1
+}
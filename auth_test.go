@@ -0,0 +1,56 @@
+package gohelpertools
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+var basicAuthTests = []struct {
+	name         string
+	user         string
+	pass         string
+	setAuth      bool
+	expectedCode int
+}{
+	{name: "valid credentials", user: "admin", pass: "secret", setAuth: true, expectedCode: http.StatusOK},
+	{name: "wrong password", user: "admin", pass: "wrong", setAuth: true, expectedCode: http.StatusUnauthorized},
+	{name: "unknown user", user: "nobody", pass: "secret", setAuth: true, expectedCode: http.StatusUnauthorized},
+	{name: "no credentials", setAuth: false, expectedCode: http.StatusUnauthorized},
+}
+
+func TestTools_BasicAuth(t *testing.T) {
+	var testTools Tools
+
+	validate := testTools.BasicAuthUsers(map[string]string{"admin": "secret", "other": "password"})
+	handler := testTools.BasicAuth("test", validate)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for _, e := range basicAuthTests {
+		req, _ := http.NewRequest("GET", "/", nil)
+		if e.setAuth {
+			req.SetBasicAuth(e.user, e.pass)
+		}
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		if rr.Code != e.expectedCode {
+			t.Errorf("%s: expected status %d but got %d", e.name, e.expectedCode, rr.Code)
+		}
+
+		if e.expectedCode == http.StatusUnauthorized && rr.Header().Get("WWW-Authenticate") != `Basic realm="test", charset="UTF-8"` {
+			t.Errorf("%s: wrong WWW-Authenticate header: %s", e.name, rr.Header().Get("WWW-Authenticate"))
+		}
+	}
+}
+
+func TestTools_SecureCompare(t *testing.T) {
+	var testTools Tools
+
+	if !testTools.SecureCompare("abc", "abc") {
+		t.Error("equal strings reported as different")
+	}
+	if testTools.SecureCompare("abc", "abcd") {
+		t.Error("different strings reported as equal")
+	}
+}
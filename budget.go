@@ -0,0 +1,118 @@
+package gohelpertools
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrBudgetExhausted is returned by DeadlineBudget when too little of the request's time remains to make
+// a call.
+var ErrBudgetExhausted = errors.New("deadline budget exhausted")
+
+const defaultBudgetFraction = 0.8
+
+// DeadlineBudget derives contexts for database and remote calls whose timeouts are a share of the time
+// remaining before the request's deadline, so that a call gives up in time for the handler to respond,
+// rather than outliving the request. It counts, per call name, how often calls were skipped or ran out of
+// time. A DeadlineBudget is safe for concurrent use; the zero value is ready to use.
+type DeadlineBudget struct {
+	Fraction    float64           // share of the remaining time given to each call; defaults to 0.8
+	Fallback    time.Duration     // timeout for calls when the parent context has no deadline; none if zero
+	MinTimeout  time.Duration     // if a call's budget would be less than this, ErrBudgetExhausted is returned instead
+	OnExhausted func(name string) // if set, called whenever a call is skipped or exceeds its budget
+
+	mu    sync.Mutex
+	stats map[string]*BudgetStats
+}
+
+// BudgetStats counts the calls made under one name.
+type BudgetStats struct {
+	Calls    uint64 // calls given a context, including those which went on to exceed it
+	Skipped  uint64 // calls refused with ErrBudgetExhausted
+	Exceeded uint64 // calls which ran until their budgeted deadline
+}
+
+// Derive returns a child of ctx for the call name, with a timeout of Fraction of the time remaining before
+// ctx's deadline (or Fallback, if ctx has none). It returns ErrBudgetExhausted if the timeout would be
+// below MinTimeout, or the deadline has passed. The CancelFunc must be called when the call is done.
+func (b *DeadlineBudget) Derive(ctx context.Context, name string) (context.Context, context.CancelFunc, error) {
+	timeout, ok := b.timeout(ctx)
+	if ok && (timeout <= 0 || timeout < b.MinTimeout) {
+		b.record(name, func(s *BudgetStats) { s.Skipped++ })
+		b.exhausted(name)
+		return ctx, func() {}, ErrBudgetExhausted
+	}
+
+	b.record(name, func(s *BudgetStats) { s.Calls++ })
+	if !ok {
+		ctx, cancel := context.WithCancel(ctx)
+		return ctx, cancel, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	return ctx, cancel, nil
+}
+
+// Do calls fn with a context from Derive, and records whether it exceeded its budget.
+func (b *DeadlineBudget) Do(ctx context.Context, name string, fn func(ctx context.Context) error) error {
+	child, cancel, err := b.Derive(ctx, name)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	err = fn(child)
+	if errors.Is(child.Err(), context.DeadlineExceeded) {
+		b.record(name, func(s *BudgetStats) { s.Exceeded++ })
+		b.exhausted(name)
+	}
+	return err
+}
+
+// Stats returns a copy of the counts for every call name seen so far.
+func (b *DeadlineBudget) Stats() map[string]BudgetStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	stats := make(map[string]BudgetStats, len(b.stats))
+	for name, s := range b.stats {
+		stats[name] = *s
+	}
+	return stats
+}
+
+// timeout returns the budget for a call under ctx, and false if there is no deadline to budget against.
+func (b *DeadlineBudget) timeout(ctx context.Context) (time.Duration, bool) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return b.Fallback, b.Fallback > 0
+	}
+
+	fraction := b.Fraction
+	if fraction <= 0 || fraction > 1 {
+		fraction = defaultBudgetFraction
+	}
+	return time.Duration(float64(time.Until(deadline)) * fraction), true
+}
+
+func (b *DeadlineBudget) record(name string, update func(*BudgetStats)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.stats == nil {
+		b.stats = make(map[string]*BudgetStats)
+	}
+	s, ok := b.stats[name]
+	if !ok {
+		s = &BudgetStats{}
+		b.stats[name] = s
+	}
+	update(s)
+}
+
+func (b *DeadlineBudget) exhausted(name string) {
+	if b.OnExhausted != nil {
+		b.OnExhausted(name)
+	}
+}
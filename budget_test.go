@@ -0,0 +1,86 @@
+package gohelpertools
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDeadlineBudget_Derive(t *testing.T) {
+	var b DeadlineBudget
+
+	parent, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	child, cancelChild, err := b.Derive(parent, "db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cancelChild()
+
+	deadline, ok := child.Deadline()
+	if !ok {
+		t.Fatal("expected the child to have a deadline")
+	}
+	if remaining := time.Until(deadline); remaining > 810*time.Millisecond || remaining < 700*time.Millisecond {
+		t.Errorf("expected about 80%% of the remaining second, got %s", remaining)
+	}
+
+	// Without a parent deadline or a fallback, there is no timeout.
+	child, cancelChild, err = b.Derive(context.Background(), "db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cancelChild()
+	if _, ok := child.Deadline(); ok {
+		t.Error("expected no deadline without a parent deadline or fallback")
+	}
+
+	b.Fallback = time.Minute
+	child, cancelChild, _ = b.Derive(context.Background(), "db")
+	defer cancelChild()
+	if _, ok := child.Deadline(); !ok {
+		t.Error("expected the fallback timeout to be used")
+	}
+}
+
+func TestDeadlineBudget_Exhausted(t *testing.T) {
+	var exhausted []string
+	b := DeadlineBudget{
+		MinTimeout:  50 * time.Millisecond,
+		OnExhausted: func(name string) { exhausted = append(exhausted, name) },
+	}
+
+	short, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	called := false
+	err := b.Do(short, "search", func(ctx context.Context) error {
+		called = true
+		return nil
+	})
+	if !errors.Is(err, ErrBudgetExhausted) || called {
+		t.Errorf("expected the call to be skipped, got %v (called %v)", err, called)
+	}
+
+	long, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	err = b.Do(long, "payments", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the call to time out, got %v", err)
+	}
+	if long.Err() != nil {
+		t.Error("the call's budget should end before the request's deadline")
+	}
+
+	stats := b.Stats()
+	if stats["search"].Skipped != 1 || stats["payments"].Calls != 1 || stats["payments"].Exceeded != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+	if len(exhausted) != 2 {
+		t.Errorf("expected OnExhausted to be called twice, got %v", exhausted)
+	}
+}
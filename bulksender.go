@@ -0,0 +1,278 @@
+package gohelpertools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// maxBulkBackoff is the longest BulkSender pauses between attempts at a batch.
+const maxBulkBackoff = time.Minute
+
+// BulkSender batches records and posts them, as a JSON array, to a third-party ingestion API such as an
+// analytics or event collector. A batch is sent when it reaches BatchSize or has waited FlushInterval.
+// When the target pushes back with 429 Too Many Requests or 503 Service Unavailable, the sender pauses
+// for the Retry-After the target asks for (or an exponential backoff) before trying again; meanwhile
+// records queue up, and once the queue is full Add fails, which passes the back-pressure on to the caller.
+type BulkSender struct {
+	URL           string                                     // where batches are posted; required
+	BatchSize     int                                        // records per batch; defaults to 100
+	FlushInterval time.Duration                              // longest a record waits for its batch to fill; defaults to 1 second
+	QueueSize     int                                        // records which may be waiting to be sent; defaults to 10000
+	MaxAttempts   int                                        // attempts per batch before it is dropped; defaults to 5
+	Headers       http.Header                                // extra headers, such as an API key, sent with every batch
+	Client        *http.Client                               // Tools.HTTPClient is used if nil
+	OnDrop        func(records []json.RawMessage, err error) // if set, called with batches which couldn't be delivered
+	Tools         *Tools                                     // used to log dropped batches; a zero Tools is used if nil
+
+	startOnce sync.Once
+	queue     chan json.RawMessage
+	done      chan struct{}
+	ctx       context.Context // cancelled when Close gives up waiting, to interrupt posts and pauses
+	cancel    context.CancelFunc
+	mu        sync.Mutex
+	closed    bool
+
+	sent      int64
+	batches   int64
+	dropped   int64
+	throttled int64
+	retries   int64
+}
+
+// BulkStats are the delivery metrics of a BulkSender.
+type BulkStats struct {
+	Queued    int   // records waiting to be sent
+	Sent      int64 // records delivered
+	Batches   int64 // batches delivered
+	Dropped   int64 // records dropped after MaxAttempts
+	Throttled int64 // responses asking the sender to slow down (429 or 503)
+	Retries   int64 // attempts which were retries
+}
+
+// Add marshals record to JSON and queues it. It never blocks: it fails if the queue is full, because the
+// target is slower than the records are arriving, or if the sender has been closed.
+func (b *BulkSender) Add(record any) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	b.start()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return ErrSenderClosed
+	}
+
+	select {
+	case b.queue <- data:
+		return nil
+	default:
+		return errors.New("bulk queue is full")
+	}
+}
+
+// Stats returns the delivery metrics so far.
+func (b *BulkSender) Stats() BulkStats {
+	b.start()
+	return BulkStats{
+		Queued:    len(b.queue),
+		Sent:      atomic.LoadInt64(&b.sent),
+		Batches:   atomic.LoadInt64(&b.batches),
+		Dropped:   atomic.LoadInt64(&b.dropped),
+		Throttled: atomic.LoadInt64(&b.throttled),
+		Retries:   atomic.LoadInt64(&b.retries),
+	}
+}
+
+// Close stops accepting records, sends everything queued, and waits for it to be delivered or dropped, or
+// until ctx is done. When ctx is done, the batch being sent is interrupted, and it and everything still
+// queued are dropped.
+func (b *BulkSender) Close(ctx context.Context) error {
+	b.start()
+
+	b.mu.Lock()
+	if !b.closed {
+		b.closed = true
+		close(b.queue)
+	}
+	b.mu.Unlock()
+
+	select {
+	case <-b.done:
+		b.cancel()
+		return nil
+	case <-ctx.Done():
+		b.cancel()
+		return ctx.Err()
+	}
+}
+
+func (b *BulkSender) start() {
+	b.startOnce.Do(func() {
+		queueSize := b.QueueSize
+		if queueSize <= 0 {
+			queueSize = 10000
+		}
+		b.queue = make(chan json.RawMessage, queueSize)
+		b.done = make(chan struct{})
+		b.ctx, b.cancel = context.WithCancel(context.Background())
+		go b.run()
+	})
+}
+
+// run collects records into batches and sends them, until the queue is closed and drained.
+func (b *BulkSender) run() {
+	defer close(b.done)
+
+	batchSize := b.BatchSize
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+	interval := b.FlushInterval
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	batch := make([]json.RawMessage, 0, batchSize)
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case record, ok := <-b.queue:
+			if !ok {
+				if len(batch) > 0 {
+					b.send(batch)
+				}
+				return
+			}
+			batch = append(batch, record)
+			if len(batch) < batchSize {
+				continue
+			}
+		case <-timer.C:
+			if len(batch) == 0 {
+				timer.Reset(interval)
+				continue
+			}
+		}
+
+		b.send(batch)
+		batch = make([]json.RawMessage, 0, batchSize)
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(interval)
+	}
+}
+
+// send posts batch, retrying and pausing as the target asks, and drops it after MaxAttempts. Pauses are
+// at most a minute, whatever Retry-After the target sends, and end early if Close gives up waiting.
+func (b *BulkSender) send(batch []json.RawMessage) {
+	maxAttempts := b.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 5
+	}
+
+	body, err := json.Marshal(batch)
+	if err != nil {
+		b.drop(batch, err)
+		return
+	}
+
+	for attempt := 1; ; attempt++ {
+		if attempt > 1 {
+			atomic.AddInt64(&b.retries, 1)
+		}
+
+		wait, err := b.post(body)
+		if err == nil {
+			atomic.AddInt64(&b.sent, int64(len(batch)))
+			atomic.AddInt64(&b.batches, 1)
+			return
+		}
+		if attempt >= maxAttempts || b.ctx.Err() != nil {
+			b.drop(batch, err)
+			return
+		}
+
+		if wait <= 0 {
+			wait = time.Duration(100<<(attempt-1)) * time.Millisecond
+		}
+		if wait > maxBulkBackoff || wait <= 0 {
+			wait = maxBulkBackoff
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-b.ctx.Done():
+			timer.Stop()
+			b.drop(batch, b.ctx.Err())
+			return
+		}
+	}
+}
+
+// post sends body once. On failure, it returns how long the target asked the sender to wait, if it did.
+func (b *BulkSender) post(body []byte) (time.Duration, error) {
+	client := b.Client
+	if client == nil {
+		client = toolsOrDefault(b.Tools).HTTPClient()
+	}
+
+	resp, err := postJSON(b.ctx, client, b.URL, body, b.Headers)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode <= 299:
+		return 0, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable:
+		atomic.AddInt64(&b.throttled, 1)
+		wait, _ := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+		return wait, fmt.Errorf("target is throttling: status %d", resp.StatusCode)
+	default:
+		return 0, fmt.Errorf("target returned status %d", resp.StatusCode)
+	}
+}
+
+func (b *BulkSender) drop(batch []json.RawMessage, err error) {
+	atomic.AddInt64(&b.dropped, int64(len(batch)))
+	toolsOrDefault(b.Tools).logWarn(b.ctx, "bulk batch dropped", "url", b.URL,
+		"records", len(batch), "error", err.Error())
+	if b.OnDrop != nil {
+		b.OnDrop(batch, err)
+	}
+}
+
+// parseRetryAfter parses a Retry-After header, which is either a number of seconds or an HTTP date.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := date.Sub(now); wait > 0 {
+			return wait, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
@@ -0,0 +1,157 @@
+package gohelpertools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBulkSender(t *testing.T) {
+	var calls int32
+	var mu sync.Mutex
+	var batches [][]map[string]int
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Api-Key") != "key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		// Throttle the first request.
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+
+		var batch []map[string]int
+		_ = json.NewDecoder(r.Body).Decode(&batch)
+		mu.Lock()
+		batches = append(batches, batch)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	sender := &BulkSender{
+		URL:           srv.URL,
+		BatchSize:     3,
+		FlushInterval: 20 * time.Millisecond,
+		Headers:       http.Header{"X-Api-Key": {"key"}},
+	}
+
+	for i := 0; i < 7; i++ {
+		if err := sender.Add(map[string]int{"n": i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := sender.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := sender.Add(map[string]int{"n": 8}); err != ErrSenderClosed {
+		t.Errorf("expected ErrSenderClosed after closing, got %v", err)
+	}
+
+	stats := sender.Stats()
+	if stats.Sent != 7 || stats.Batches != 3 || stats.Throttled != 1 || stats.Retries != 1 || stats.Dropped != 0 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+
+	total := 0
+	for _, batch := range batches {
+		if len(batch) > 3 {
+			t.Errorf("batch of %d exceeds the batch size", len(batch))
+		}
+		total += len(batch)
+	}
+	if total != 7 {
+		t.Errorf("expected 7 records to be delivered, got %d", total)
+	}
+}
+
+func TestBulkSender_Drop(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	var dropped int
+	sender := &BulkSender{
+		URL:         srv.URL,
+		MaxAttempts: 2,
+		QueueSize:   2,
+		OnDrop:      func(records []json.RawMessage, err error) { dropped += len(records) },
+	}
+
+	_ = sender.Add(1)
+	_ = sender.Add(2)
+	_ = sender.Close(context.Background())
+
+	if dropped != 2 || sender.Stats().Dropped != 2 {
+		t.Errorf("expected 2 dropped records, got %d", dropped)
+	}
+}
+
+func TestBulkSender_CloseInterruptsRetryAfter(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "999999")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	dropped := make(chan error, 1)
+	sender := &BulkSender{
+		URL:           srv.URL,
+		FlushInterval: time.Millisecond,
+		Tools:         &Tools{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))},
+		OnDrop:        func(records []json.RawMessage, err error) { dropped <- err },
+	}
+	_ = sender.Add(1)
+	for sender.Stats().Throttled == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := sender.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected Close to give up waiting, got %v", err)
+	}
+
+	select {
+	case err := <-dropped:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected the batch to be dropped as cancelled, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Error("expected the paused batch to be dropped once Close gave up")
+	}
+}
+
+var retryAfterTests = []struct {
+	name  string
+	value string
+	want  time.Duration
+	ok    bool
+}{
+	{name: "seconds", value: "120", want: 2 * time.Minute, ok: true},
+	{name: "date", value: "Mon, 01 Jan 2024 00:00:30 GMT", want: 30 * time.Second, ok: true},
+	{name: "past date", value: "Sun, 31 Dec 2023 00:00:00 GMT", want: 0, ok: true},
+	{name: "garbage", value: "soon", want: 0, ok: false},
+	{name: "empty", value: "", want: 0, ok: false},
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, e := range retryAfterTests {
+		got, ok := parseRetryAfter(e.value, now)
+		if got != e.want || ok != e.ok {
+			t.Errorf("%s: expected %s %v but got %s %v", e.name, e.want, e.ok, got, ok)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package gohelpertools
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Cache is an in-memory key-value cache, safe for concurrent use. Entries expire after a TTL, and once
+// the cache holds MaxEntries, the least recently used entry is evicted to make room for a new one.
+type Cache[K comparable, V any] struct {
+	maxEntries int
+	ttl        time.Duration
+	now        func() time.Time
+
+	mu      sync.Mutex
+	entries map[K]*list.Element
+	order   *list.List // most recently used at the front
+	loading map[K]*cacheCall[V]
+}
+
+// cacheEntry is the value stored in each list element.
+type cacheEntry[K comparable, V any] struct {
+	key     K
+	value   V
+	expires time.Time // zero means the entry never expires
+}
+
+// cacheCall is a load in progress, shared by every caller of GetOrLoad for the same key.
+type cacheCall[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+// NewCache returns an empty Cache holding at most maxEntries entries (no limit if maxEntries is zero or
+// less), which expire after ttl unless Set is given a different TTL (never, if ttl is zero or less).
+func NewCache[K comparable, V any](maxEntries int, ttl time.Duration) *Cache[K, V] {
+	return &Cache[K, V]{
+		maxEntries: maxEntries,
+		ttl:        ttl,
+		now:        time.Now,
+		entries:    make(map[K]*list.Element),
+		order:      list.New(),
+		loading:    make(map[K]*cacheCall[V]),
+	}
+}
+
+// Get returns the value for key, and whether it was found and has not expired.
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.get(key)
+}
+
+// Set stores value for key. An optional ttl overrides the cache's default for this entry.
+func (c *Cache[K, V]) Set(key K, value V, ttl ...time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	d := c.ttl
+	if len(ttl) > 0 {
+		d = ttl[0]
+	}
+	c.set(key, value, d)
+}
+
+// Delete removes key from the cache.
+func (c *Cache[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[key]; ok {
+		c.remove(element)
+	}
+}
+
+// Len returns the number of entries in the cache, including any which have expired but not yet been
+// removed.
+func (c *Cache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// GetOrLoad returns the value for key if it is cached; otherwise it calls load, caches the result and
+// returns it. Concurrent calls for the same key share a single call to load, so a popular entry expiring
+// doesn't send a stampede of requests to the backend. Errors are returned but not cached, and a panic in
+// load is returned as an error.
+//
+// Since the result is shared, load is given a context which isn't cancelled with ctx: a caller whose ctx
+// is cancelled stops waiting and returns ctx.Err(), while the load carries on for the others.
+func (c *Cache[K, V]) GetOrLoad(ctx context.Context, key K, load func(ctx context.Context, key K) (V, error)) (V, error) {
+	c.mu.Lock()
+	if value, ok := c.get(key); ok {
+		c.mu.Unlock()
+		return value, nil
+	}
+
+	call, ok := c.loading[key]
+	if !ok {
+		call = &cacheCall[V]{done: make(chan struct{})}
+		c.loading[key] = call
+		go c.load(context.WithoutCancel(ctx), key, call, load)
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.value, call.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+// load runs a load shared by GetOrLoad callers, storing its result in call.
+func (c *Cache[K, V]) load(ctx context.Context, key K, call *cacheCall[V], load func(ctx context.Context, key K) (V, error)) {
+	defer close(call.done)
+	defer func() {
+		if p := recover(); p != nil {
+			call.err = fmt.Errorf("cache load panicked: %v", p)
+		}
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		delete(c.loading, key)
+		if call.err == nil {
+			c.set(key, call.value, c.ttl)
+		}
+	}()
+
+	call.value, call.err = load(ctx, key)
+}
+
+func (c *Cache[K, V]) get(key K) (V, bool) {
+	element, ok := c.entries[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+
+	entry := element.Value.(*cacheEntry[K, V])
+	if !entry.expires.IsZero() && c.now().After(entry.expires) {
+		c.remove(element)
+		var zero V
+		return zero, false
+	}
+
+	c.order.MoveToFront(element)
+	return entry.value, true
+}
+
+func (c *Cache[K, V]) set(key K, value V, ttl time.Duration) {
+	var expires time.Time
+	if ttl > 0 {
+		expires = c.now().Add(ttl)
+	}
+
+	if element, ok := c.entries[key]; ok {
+		element.Value = &cacheEntry[K, V]{key: key, value: value, expires: expires}
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&cacheEntry[K, V]{key: key, value: value, expires: expires})
+	if c.maxEntries > 0 && c.order.Len() > c.maxEntries {
+		c.remove(c.order.Back())
+	}
+}
+
+func (c *Cache[K, V]) remove(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.entries, element.Value.(*cacheEntry[K, V]).key)
+}
@@ -0,0 +1,147 @@
+package gohelpertools
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCache_TTLAndLRU(t *testing.T) {
+	clock := &fakeClock{t: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	cache := NewCache[string, int](2, time.Minute)
+	cache.now = clock.now
+
+	cache.Set("a", 1)
+	cache.Set("b", 2, time.Hour)
+	if value, ok := cache.Get("a"); !ok || value != 1 {
+		t.Errorf("expected a=1, got %d (%v)", value, ok)
+	}
+
+	// "b" is now the least recently used, so adding "c" evicts it.
+	cache.Set("c", 3)
+	if _, ok := cache.Get("b"); ok {
+		t.Error("expected b to be evicted")
+	}
+	if cache.Len() != 2 {
+		t.Errorf("expected 2 entries but got %d", cache.Len())
+	}
+
+	clock.advance(2 * time.Minute)
+	if _, ok := cache.Get("a"); ok {
+		t.Error("expected a to have expired")
+	}
+
+	cache.Set("d", 4, 0)
+	clock.advance(24 * time.Hour)
+	if _, ok := cache.Get("d"); !ok {
+		t.Error("expected entry without a TTL to be kept")
+	}
+	cache.Delete("d")
+	if _, ok := cache.Get("d"); ok {
+		t.Error("expected d to be deleted")
+	}
+}
+
+func TestCache_GetOrLoad(t *testing.T) {
+	cache := NewCache[string, string](0, time.Minute)
+
+	var calls int32
+	release := make(chan struct{})
+	load := func(ctx context.Context, key string) (string, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return "value for " + key, nil
+	}
+
+	var wg sync.WaitGroup
+	results := make([]string, 10)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], _ = cache.GetOrLoad(context.Background(), "k", load)
+		}(i)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if calls != 1 {
+		t.Errorf("expected a single load, but got %d", calls)
+	}
+	for _, result := range results {
+		if result != "value for k" {
+			t.Errorf("unexpected result %q", result)
+		}
+	}
+
+	_, err := cache.GetOrLoad(context.Background(), "bad", func(context.Context, string) (string, error) {
+		return "", errors.New("backend down")
+	})
+	if err == nil {
+		t.Error("loader error: error expected, but none received")
+	}
+	if _, ok := cache.Get("bad"); ok {
+		t.Error("expected errors not to be cached")
+	}
+}
+
+func TestCache_GetOrLoadPanic(t *testing.T) {
+	cache := NewCache[string, string](0, time.Minute)
+
+	_, err := cache.GetOrLoad(context.Background(), "k", func(context.Context, string) (string, error) {
+		panic("boom")
+	})
+	if err == nil {
+		t.Error("panicking loader: error expected, but none received")
+	}
+
+	value, err := cache.GetOrLoad(context.Background(), "k", func(context.Context, string) (string, error) {
+		return "ok", nil
+	})
+	if err != nil || value != "ok" {
+		t.Errorf("expected the key to load again after a panic, got %q: %v", value, err)
+	}
+}
+
+func TestCache_GetOrLoadCancelled(t *testing.T) {
+	cache := NewCache[string, string](0, time.Minute)
+
+	started, release := make(chan struct{}), make(chan struct{})
+	load := func(ctx context.Context, key string) (string, error) {
+		close(started)
+		select {
+		case <-release:
+			return "value", nil
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		_, err := cache.GetOrLoad(ctx, "k", load)
+		first <- err
+	}()
+	<-started
+
+	second := make(chan string, 1)
+	go func() {
+		value, _ := cache.GetOrLoad(context.Background(), "k", load)
+		second <- value
+	}()
+
+	cancel()
+	if err := <-first; !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the cancelled caller to get context.Canceled, got %v", err)
+	}
+	close(release)
+	if value := <-second; value != "value" {
+		t.Errorf("expected the other caller to get the loaded value, got %q", value)
+	}
+}
@@ -0,0 +1,112 @@
+package gohelpertools
+
+import (
+	"strings"
+	"unicode"
+)
+
+// ToSnakeCase converts s to snake_case: "HTTPServerError" becomes "http_server_error".
+func (t *Tools) ToSnakeCase(s string) string {
+	return joinWords(splitWords(s), "_", strings.ToLower)
+}
+
+// ToKebabCase converts s to kebab-case: "HTTPServerError" becomes "http-server-error".
+func (t *Tools) ToKebabCase(s string) string {
+	return joinWords(splitWords(s), "-", strings.ToLower)
+}
+
+// ToPascalCase converts s to PascalCase: "http_server_error" becomes "HttpServerError".
+func (t *Tools) ToPascalCase(s string) string {
+	return joinWords(splitWords(s), "", titleWord)
+}
+
+// ToCamelCase converts s to camelCase: "http_server_error" becomes "httpServerError".
+func (t *Tools) ToCamelCase(s string) string {
+	words := splitWords(s)
+	if len(words) == 0 {
+		return ""
+	}
+	return strings.ToLower(words[0]) + joinWords(words[1:], "", titleWord)
+}
+
+// Humanize converts an identifier into words suitable for display: "createdAt" and "created_at" both
+// become "Created at". Acronyms written in capitals are kept, so "HTTPServer" becomes "HTTP server".
+func (t *Tools) Humanize(s string) string {
+	words := splitWords(s)
+	for i, word := range words {
+		if !isAcronym(word) {
+			words[i] = strings.ToLower(word)
+		}
+	}
+	if len(words) > 0 && !isAcronym(words[0]) {
+		words[0] = titleWord(words[0])
+	}
+	return strings.Join(words, " ")
+}
+
+// splitWords breaks an identifier into words, at separators (anything other than a letter or digit),
+// at lower-to-upper case changes ("userName"), before the last capital of an acronym which is followed
+// by a word ("HTTPServer"), and between a digit and a capital ("v2Api"). Digits otherwise stay with the
+// word they are part of ("utf8", "2fa").
+func splitWords(s string) []string {
+	var words []string
+	var current []rune
+
+	runes := []rune(s)
+	flush := func() {
+		if len(current) > 0 {
+			words = append(words, string(current))
+			current = nil
+		}
+	}
+
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush()
+			continue
+		}
+
+		if len(current) > 0 && unicode.IsUpper(r) {
+			prev := current[len(current)-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextIsLower) {
+				flush()
+			}
+		}
+		current = append(current, r)
+	}
+	flush()
+	return words
+}
+
+func joinWords(words []string, separator string, transform func(string) string) string {
+	out := make([]string, len(words))
+	for i, word := range words {
+		out[i] = transform(word)
+	}
+	return strings.Join(out, separator)
+}
+
+// titleWord upper-cases the first letter of word and lower-cases the rest.
+func titleWord(word string) string {
+	runes := []rune(strings.ToLower(word))
+	if len(runes) == 0 {
+		return ""
+	}
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
+
+// isAcronym reports whether word has at least two letters, all of them capitals.
+func isAcronym(word string) bool {
+	letters := 0
+	for _, r := range word {
+		if unicode.IsLower(r) {
+			return false
+		}
+		if unicode.IsLetter(r) {
+			letters++
+		}
+	}
+	return letters > 1
+}
@@ -0,0 +1,44 @@
+package gohelpertools
+
+import "testing"
+
+var caseTests = []struct {
+	input  string
+	snake  string
+	kebab  string
+	pascal string
+	camel  string
+	human  string
+}{
+	{input: "hello world", snake: "hello_world", kebab: "hello-world", pascal: "HelloWorld", camel: "helloWorld", human: "Hello world"},
+	{input: "createdAt", snake: "created_at", kebab: "created-at", pascal: "CreatedAt", camel: "createdAt", human: "Created at"},
+	{input: "user_id", snake: "user_id", kebab: "user-id", pascal: "UserId", camel: "userId", human: "User id"},
+	{input: "HTTPServerError", snake: "http_server_error", kebab: "http-server-error", pascal: "HttpServerError", camel: "httpServerError", human: "HTTP server error"},
+	{input: "userID", snake: "user_id", kebab: "user-id", pascal: "UserId", camel: "userId", human: "User ID"},
+	{input: "utf8Encoding", snake: "utf8_encoding", kebab: "utf8-encoding", pascal: "Utf8Encoding", camel: "utf8Encoding", human: "Utf8 encoding"},
+	{input: "v2Api", snake: "v2_api", kebab: "v2-api", pascal: "V2Api", camel: "v2Api", human: "V2 api"},
+	{input: "--Already-Kebab--", snake: "already_kebab", kebab: "already-kebab", pascal: "AlreadyKebab", camel: "alreadyKebab", human: "Already kebab"},
+	{input: "", snake: "", kebab: "", pascal: "", camel: "", human: ""},
+}
+
+func TestTools_CaseConversion(t *testing.T) {
+	var tools Tools
+
+	for _, e := range caseTests {
+		if result := tools.ToSnakeCase(e.input); result != e.snake {
+			t.Errorf("ToSnakeCase(%q): expected %q but got %q", e.input, e.snake, result)
+		}
+		if result := tools.ToKebabCase(e.input); result != e.kebab {
+			t.Errorf("ToKebabCase(%q): expected %q but got %q", e.input, e.kebab, result)
+		}
+		if result := tools.ToPascalCase(e.input); result != e.pascal {
+			t.Errorf("ToPascalCase(%q): expected %q but got %q", e.input, e.pascal, result)
+		}
+		if result := tools.ToCamelCase(e.input); result != e.camel {
+			t.Errorf("ToCamelCase(%q): expected %q but got %q", e.input, e.camel, result)
+		}
+		if result := tools.Humanize(e.input); result != e.human {
+			t.Errorf("Humanize(%q): expected %q but got %q", e.input, e.human, result)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package gohelpertools
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+// ChecksumAlgorithm names a hash function used by FileChecksum and StreamChecksum.
+type ChecksumAlgorithm string
+
+const (
+	ChecksumMD5    ChecksumAlgorithm = "md5"
+	ChecksumSHA1   ChecksumAlgorithm = "sha1"
+	ChecksumSHA256 ChecksumAlgorithm = "sha256"
+	ChecksumXXHash ChecksumAlgorithm = "xxhash" // 64-bit xxHash (XXH64), which is fast but not cryptographic
+)
+
+// checksumHeaders are the headers UploadFiles reads checksums from, by algorithm.
+var checksumHeaders = []struct {
+	header    string
+	algorithm ChecksumAlgorithm
+}{
+	{"Content-Md5", ChecksumMD5},
+	{"X-Checksum-Sha1", ChecksumSHA1},
+	{"X-Checksum-Sha256", ChecksumSHA256},
+	{"X-Checksum-Xxhash", ChecksumXXHash},
+}
+
+// Checksum is the digest of some content, in the forms it is usually sent in.
+type Checksum struct {
+	Algorithm ChecksumAlgorithm
+	Hex       string // lowercase hex, as in sha256sum's output
+	Base64    string // standard base64, as in a Content-MD5 header
+	Size      int64  // number of bytes hashed
+}
+
+// ETag returns the checksum as a quoted, strong entity tag.
+func (c Checksum) ETag() string {
+	return `"` + c.Hex + `"`
+}
+
+// FileChecksum returns the checksum of the file at path, computed with algo.
+func (t *Tools) FileChecksum(path string, algo ChecksumAlgorithm) (Checksum, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Checksum{}, err
+	}
+	defer f.Close()
+	return t.StreamChecksum(f, algo)
+}
+
+// StreamChecksum reads r to the end and returns its checksum, computed with algo.
+func (t *Tools) StreamChecksum(r io.Reader, algo ChecksumAlgorithm) (Checksum, error) {
+	algo = ChecksumAlgorithm(strings.ToLower(string(algo)))
+	h, err := newChecksumHash(algo)
+	if err != nil {
+		return Checksum{}, err
+	}
+	n, err := io.Copy(h, r)
+	if err != nil {
+		return Checksum{}, err
+	}
+	return checksumOf(algo, h, n), nil
+}
+
+// newChecksumHash returns a new hash for algo.
+func newChecksumHash(algo ChecksumAlgorithm) (hash.Hash, error) {
+	switch algo {
+	case ChecksumMD5:
+		return md5.New(), nil
+	case ChecksumSHA1:
+		return sha1.New(), nil
+	case ChecksumSHA256:
+		return sha256.New(), nil
+	case ChecksumXXHash:
+		return xxhash.New(), nil
+	default:
+		return nil, fmt.Errorf("unsupported checksum algorithm %q", algo)
+	}
+}
+
+func checksumOf(algo ChecksumAlgorithm, h hash.Hash, size int64) Checksum {
+	sum := h.Sum(nil)
+	return Checksum{
+		Algorithm: algo,
+		Hex:       hex.EncodeToString(sum),
+		Base64:    base64.StdEncoding.EncodeToString(sum),
+		Size:      size,
+	}
+}
@@ -0,0 +1,92 @@
+package gohelpertools
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var checksumTests = []struct {
+	name          string
+	algorithm     ChecksumAlgorithm
+	hex           string
+	base64        string
+	errorExpected bool
+}{
+	{name: "md5", algorithm: ChecksumMD5, hex: "5d41402abc4b2a76b9719d911017c592", base64: "XUFAKrxLKna5cZ2REBfFkg=="},
+	{name: "sha1", algorithm: ChecksumSHA1, hex: "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d", base64: "qvTGHdzF6KLavt4PO0gs2a6pQ00="},
+	{name: "sha256", algorithm: "SHA256", hex: "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824", base64: "LPJNul+wow4m6DsqxbninhsWHlwfp0JecwQzYpOLmCQ="},
+	{name: "xxhash", algorithm: ChecksumXXHash, hex: "26c7827d889f6da3", base64: "JseCfYifbaM="},
+	{name: "unsupported", algorithm: "crc32", errorExpected: true},
+}
+
+func TestTools_StreamChecksum(t *testing.T) {
+	var tools Tools
+	for _, e := range checksumTests {
+		sum, err := tools.StreamChecksum(strings.NewReader("hello"), e.algorithm)
+		if e.errorExpected {
+			if err == nil {
+				t.Errorf("%s: error expected, but none received", e.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: error not expected, but one received: %s", e.name, err)
+			continue
+		}
+		if sum.Hex != e.hex || sum.Base64 != e.base64 || sum.Size != 5 {
+			t.Errorf("%s: unexpected checksum %+v", e.name, sum)
+		}
+	}
+}
+
+func TestTools_FileChecksum(t *testing.T) {
+	var tools Tools
+	path := filepath.Join(t.TempDir(), "hello.txt")
+	if err := os.WriteFile(path, []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	sum, err := tools.FileChecksum(path, ChecksumSHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum.Algorithm != ChecksumSHA256 || sum.ETag() != `"2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"` {
+		t.Errorf("unexpected checksum %+v", sum)
+	}
+
+	if _, err := tools.FileChecksum(filepath.Join(t.TempDir(), "missing"), ChecksumMD5); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
+
+func TestTools_DownloadFileETag(t *testing.T) {
+	var tools Tools
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "hello.txt"), []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	fsys := NewLocalFileSystem(dir, "")
+
+	rr := httptest.NewRecorder()
+	if err := tools.DownloadFile(rr, httptest.NewRequest(http.MethodGet, "/", nil), fsys, "hello.txt", ""); err != nil {
+		t.Fatal(err)
+	}
+	etag := rr.Header().Get("ETag")
+	if etag != `"26c7827d889f6da3"` || rr.Body.String() != "hello" {
+		t.Errorf("unexpected ETag %s or body %q", etag, rr.Body.String())
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("If-None-Match", etag)
+	rr = httptest.NewRecorder()
+	if err := tools.DownloadFile(rr, req, fsys, "hello.txt", ""); err != nil {
+		t.Fatal(err)
+	}
+	if rr.Code != http.StatusNotModified {
+		t.Errorf("expected 304 for a matching ETag, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,320 @@
+package gohelpertools
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"go/token"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+var clientPathParamRegex = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// ClientRoute describes one endpoint for GenerateClient.
+type ClientRoute struct {
+	Name      string // name of the generated method, such as "GetUser"
+	Method    string // HTTP method, such as "GET"
+	Path      string // path with {placeholders} for parameters, such as "/users/{id}"
+	Request   string // Go type of the request body, if any, such as "CreateUserRequest"
+	Response  string // Go type of the response data, if any, such as "User"; for a paginated route, the type of each item
+	Paginated bool   // if set to true, the route returns pages of Response items, and the method returns a Pager
+}
+
+// GenerateClient writes the source code of a small typed Go client for routes to w, in package pkg. The
+// generated client understands this package's JSONResponse envelope (and raw responses written without
+// it), and returns error responses as *APIError values, so internal consumers don't have to hand-write
+// HTTP calls. Request and response types are referred to by name and must be declared in pkg.
+//
+// The method of a paginated route returns a Pager, which fetches one page at a time:
+//
+//	users := client.ListUsers()
+//	for users.Next(ctx) {
+//		for _, user := range users.Page() { ... }
+//	}
+//	if err := users.Err(); err != nil { ... }
+//
+// Each page is requested with the cursor of the one before it in the "cursor" query parameter. The cursor
+// of the next page is read from "next_cursor" in the meta of the response envelope, or from the
+// X-Next-Cursor header of a raw response; there are no more pages once it's empty.
+func (t *Tools) GenerateClient(w io.Writer, pkg string, routes []ClientRoute) error {
+	if pkg == "" {
+		return errors.New("a package name is required")
+	}
+
+	type param struct{ Name, Placeholder string }
+	type method struct {
+		ClientRoute
+		Params []param
+	}
+
+	var methods []method
+	needsURL := false
+	for _, route := range routes {
+		if route.Name == "" || route.Path == "" {
+			return errors.New("every route needs a name and a path")
+		}
+		if !token.IsExported(route.Name) || !token.IsIdentifier(route.Name) {
+			return fmt.Errorf("route name %q is not an exported Go identifier", route.Name)
+		}
+		if route.Paginated && route.Response == "" {
+			return fmt.Errorf("paginated route %s needs a response type", route.Name)
+		}
+		route.Method = strings.ToUpper(valueOrDefault(route.Method, http.MethodGet))
+
+		m := method{ClientRoute: route}
+		used := make(map[string]bool)
+		for _, match := range clientPathParamRegex.FindAllStringSubmatch(route.Path, -1) {
+			m.Params = append(m.Params, param{Name: clientParamName(match[1], used), Placeholder: match[0]})
+			needsURL = true
+		}
+		needsURL = needsURL || route.Paginated
+		methods = append(methods, m)
+	}
+
+	var buf bytes.Buffer
+	needsPager := false
+	for _, m := range methods {
+		needsPager = needsPager || m.Paginated
+	}
+	if err := clientTemplate.Execute(&buf, struct {
+		Package    string
+		Methods    []method
+		NeedsURL   bool
+		NeedsPager bool
+	}{pkg, methods, needsURL, needsPager}); err != nil {
+		return err
+	}
+
+	out, err := format.Source(buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("generated client is not valid Go: %w", err)
+	}
+
+	_, err = w.Write(out)
+	return err
+}
+
+// unexportedGoName converts a path parameter such as "user_id" into a Go parameter name such as "userId".
+func unexportedGoName(s string) string {
+	name := exportedGoName(s)
+	return strings.ToLower(name[:1]) + name[1:]
+}
+
+// clientReservedNames are the names a parameter of a generated method mustn't have, besides Go keywords:
+// the method's receiver and local variables, and the packages the client imports.
+var clientReservedNames = []string{
+	"c", "ctx", "req", "resp", "path", "out", "err", "next", "cursor",
+	"bytes", "context", "json", "fmt", "io", "http", "url", "strings", "gohelpertools",
+}
+
+// clientParamName returns the Go parameter name for the path parameter s, with "Param" added to names
+// which are Go keywords, clientReservedNames or already used, which is updated with the name returned.
+func clientParamName(s string, used map[string]bool) string {
+	name := unexportedGoName(s)
+	for token.IsKeyword(name) || contains(clientReservedNames, name) || used[name] {
+		name += "Param"
+	}
+	used[name] = true
+	return name
+}
+
+var clientTemplate = template.Must(template.New("client").Parse(`// Code generated by gohelpertools.GenerateClient. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+{{- if .NeedsURL}}
+	"net/url"
+{{- end}}
+	"strings"
+
+	gohelpertools "github.com/oluwaferanmiadetunji/go-helper-tools"
+)
+
+// Client calls the API at BaseURL.
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+	Header     http.Header // sent with every request, for example for authorization
+}
+
+// NewClient returns a Client for the API at baseURL.
+func NewClient(baseURL string) *Client {
+	return &Client{BaseURL: strings.TrimRight(baseURL, "/"), HTTPClient: http.DefaultClient}
+}
+
+// APIError is returned when the API responds with an error status.
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("api error %d: %s", e.StatusCode, e.Message)
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body, out any) error {
+	_, err := c.send(ctx, method, path, body, out)
+	return err
+}
+
+// send makes a request like do, and returns the cursor of the next page, if the response has one.
+func (c *Client) send(ctx context.Context, method, path string, body, out any) (string, error) {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return "", err
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, reader)
+	if err != nil {
+		return "", err
+	}
+	for key, value := range c.Header {
+		req.Header[key] = value
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	if resp.StatusCode >= 400 {
+		var e struct {
+			Message string ` + "`json:\"message\"`" + `
+		}
+		_ = json.Unmarshal(respBody, &e)
+		if e.Message == "" {
+			e.Message = http.StatusText(resp.StatusCode)
+		}
+		return "", &APIError{StatusCode: resp.StatusCode, Message: e.Message}
+	}
+
+	next := resp.Header.Get("X-Next-Cursor")
+	var envelope struct {
+		Meta struct {
+			NextCursor string ` + "`json:\"next_cursor\"`" + `
+		} ` + "`json:\"meta\"`" + `
+	}
+	if json.Unmarshal(respBody, &envelope) == nil && envelope.Meta.NextCursor != "" {
+		next = envelope.Meta.NextCursor
+	}
+
+	if out == nil || len(bytes.TrimSpace(respBody)) == 0 {
+		return next, nil
+	}
+
+	tools := gohelpertools.Tools{AllowUnknownFields: true}
+	return next, tools.UnwrapJSON(respBody, out)
+}
+{{if .NeedsPager}}
+// Pager fetches the pages of a paginated route one at a time.
+type Pager[T any] struct {
+	fetch  func(ctx context.Context, cursor string) ([]T, string, error)
+	cursor string
+	page   []T
+	done   bool
+	err    error
+}
+
+// Next fetches the next page, and reports whether there was one. It returns false once the last page has
+// been fetched, or when a request fails, which Err then returns.
+func (p *Pager[T]) Next(ctx context.Context) bool {
+	if p.done || p.err != nil {
+		return false
+	}
+	p.page, p.cursor, p.err = p.fetch(ctx, p.cursor)
+	if p.err != nil {
+		p.page = nil
+		return false
+	}
+	p.done = p.cursor == ""
+	return true
+}
+
+// Page returns the items of the page fetched by the last call to Next.
+func (p *Pager[T]) Page() []T {
+	return p.page
+}
+
+// Err returns the error which stopped Next, if any.
+func (p *Pager[T]) Err() error {
+	return p.err
+}
+
+// All fetches the remaining pages, and returns their items.
+func (p *Pager[T]) All(ctx context.Context) ([]T, error) {
+	var all []T
+	for p.Next(ctx) {
+		all = append(all, p.page...)
+	}
+	return all, p.err
+}
+
+// withCursor adds the cursor of a page to path.
+func withCursor(path, cursor string) string {
+	if cursor == "" {
+		return path
+	}
+	if strings.Contains(path, "?") {
+		return path + "&cursor=" + url.QueryEscape(cursor)
+	}
+	return path + "?cursor=" + url.QueryEscape(cursor)
+}
+{{end}}{{range .Methods}}
+{{- if .Paginated}}
+// {{.Name}} returns a Pager over the pages of {{.Method}} {{.Path}}.
+func (c *Client) {{.Name}}({{range $i, $p := .Params}}{{if $i}}, {{end}}{{$p.Name}} string{{end}}{{if .Request}}{{if .Params}}, {{end}}req {{.Request}}{{end}}) *Pager[{{.Response}}] {
+	path := {{printf "%q" .Path}}
+	{{- range .Params}}
+	path = strings.Replace(path, {{printf "%q" .Placeholder}}, url.PathEscape({{.Name}}), 1)
+	{{- end}}
+	return &Pager[{{.Response}}]{fetch: func(ctx context.Context, cursor string) ([]{{.Response}}, string, error) {
+		var out []{{.Response}}
+		next, err := c.send(ctx, {{printf "%q" .Method}}, withCursor(path, cursor), {{if .Request}}req{{else}}nil{{end}}, &out)
+		return out, next, err
+	}}
+}
+{{else}}
+// {{.Name}} calls {{.Method}} {{.Path}}.
+func (c *Client) {{.Name}}(ctx context.Context{{range .Params}}, {{.Name}} string{{end}}{{if .Request}}, req {{.Request}}{{end}}) ({{if .Response}}*{{.Response}}, {{end}}error) {
+	path := {{printf "%q" .Path}}
+	{{- range .Params}}
+	path = strings.Replace(path, {{printf "%q" .Placeholder}}, url.PathEscape({{.Name}}), 1)
+	{{- end}}
+	{{if .Response}}
+	var out {{.Response}}
+	if err := c.do(ctx, {{printf "%q" .Method}}, path, {{if .Request}}req{{else}}nil{{end}}, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+	{{- else}}
+	return c.do(ctx, {{printf "%q" .Method}}, path, {{if .Request}}req{{else}}nil{{end}}, nil)
+	{{- end}}
+}
+{{end}}
+{{- end}}`))
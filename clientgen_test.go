@@ -0,0 +1,54 @@
+package gohelpertools
+
+import (
+	"bytes"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+func TestTools_GenerateClient(t *testing.T) {
+	var testTools Tools
+
+	routes := []ClientRoute{
+		{Name: "GetUser", Path: "/users/{user_id}", Response: "User"},
+		{Name: "CreateUser", Method: "post", Path: "/users", Request: "CreateUserRequest", Response: "User"},
+		{Name: "DeleteUser", Method: "DELETE", Path: "/users/{user_id}"},
+		{Name: "ListOrders", Path: "/users/{user_id}/orders", Response: "Order", Paginated: true},
+		{Name: "GetThing", Path: "/things/{type}/{ctx}/{req}", Response: "Thing"},
+	}
+
+	var buf bytes.Buffer
+	if err := testTools.GenerateClient(&buf, "api", routes); err != nil {
+		t.Fatal(err)
+	}
+	code := buf.String()
+
+	if _, err := parser.ParseFile(token.NewFileSet(), "client.go", code, 0); err != nil {
+		t.Fatalf("generated client does not parse: %s\n%s", err, code)
+	}
+
+	for _, expected := range []string{
+		"package api",
+		"func (c *Client) GetUser(ctx context.Context, userId string) (*User, error)",
+		"func (c *Client) CreateUser(ctx context.Context, req CreateUserRequest) (*User, error)",
+		"func (c *Client) DeleteUser(ctx context.Context, userId string) error",
+		`c.do(ctx, "POST", path, req, &out)`,
+		`strings.Replace(path, "{user_id}", url.PathEscape(userId), 1)`,
+		"func (c *Client) ListOrders(userId string) *Pager[Order]",
+		"withCursor(path, cursor)",
+		"func (c *Client) GetThing(ctx context.Context, typeParam string, ctxParam string, reqParam string) (*Thing, error)",
+	} {
+		if !strings.Contains(code, expected) {
+			t.Errorf("generated client is missing %q", expected)
+		}
+	}
+
+	if err := testTools.GenerateClient(&buf, "api", []ClientRoute{{Name: "Broken"}}); err == nil {
+		t.Error("expected error for a route without a path, but none received")
+	}
+	if err := testTools.GenerateClient(&buf, "api", []ClientRoute{{Name: "list-users", Path: "/users"}}); err == nil {
+		t.Error("expected error for a route name which isn't a Go identifier, but none received")
+	}
+}
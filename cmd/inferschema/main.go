@@ -0,0 +1,71 @@
+// Command inferschema generates a Go struct (or a JSON Schema) from example JSON payloads. It is meant
+// to be run with go:generate, for example:
+//
+//	//go:generate go run github.com/oluwaferanmiadetunji/go-helper-tools/cmd/inferschema -name Payload -pkg webhooks -o payload_gen.go testdata/payload*.json
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	gohelpertools "github.com/oluwaferanmiadetunji/go-helper-tools"
+)
+
+func main() {
+	name := flag.String("name", "Payload", "name of the generated struct type")
+	pkg := flag.String("pkg", "main", "package name for the generated file")
+	out := flag.String("o", "", "file to write to (defaults to standard output)")
+	schemaOnly := flag.Bool("schema", false, "write a JSON Schema instead of Go code")
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: inferschema [flags] sample.json...")
+		flag.PrintDefaults()
+		os.Exit(2)
+	}
+
+	if err := run(*name, *pkg, *out, *schemaOnly, flag.Args()); err != nil {
+		fmt.Fprintln(os.Stderr, "inferschema:", err)
+		os.Exit(1)
+	}
+}
+
+func run(name, pkg, out string, schemaOnly bool, files []string) error {
+	var samples [][]byte
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		samples = append(samples, data)
+	}
+
+	var tools gohelpertools.Tools
+	schema, err := tools.InferSchema(samples...)
+	if err != nil {
+		return err
+	}
+
+	var output []byte
+	if schemaOnly {
+		output, err = json.MarshalIndent(schema, "", "  ")
+		if err != nil {
+			return err
+		}
+		output = append(output, '\n')
+	} else {
+		code, err := schema.GoStruct(name)
+		if err != nil {
+			return err
+		}
+		output = []byte(fmt.Sprintf("// Code generated by inferschema. DO NOT EDIT.\n\npackage %s\n\n%s", pkg, code))
+	}
+
+	if out == "" {
+		_, err = os.Stdout.Write(output)
+		return err
+	}
+	return os.WriteFile(out, output, 0644)
+}
@@ -0,0 +1,133 @@
+package gohelpertools
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Codec encodes and decodes one wire format for WriteResponse and ReadBody.
+type Codec struct {
+	MediaTypes []string                       // media types of the format; the first is the Content-Type of responses
+	Marshal    func(v any) ([]byte, error)    // encodes a response
+	Unmarshal  func(data []byte, v any) error // decodes a request body
+}
+
+// XMLCodec reads and writes application/xml (or text/xml) with encoding/xml.
+var XMLCodec = Codec{
+	MediaTypes: []string{"application/xml", "text/xml"},
+	Marshal:    xml.Marshal,
+	Unmarshal:  xml.Unmarshal,
+}
+
+// MsgpackCodec reads and writes MessagePack. Struct fields are named by their json tags, so the same types
+// can be used for JSON and MessagePack clients.
+var MsgpackCodec = Codec{
+	MediaTypes: []string{"application/msgpack", "application/x-msgpack", "application/vnd.msgpack"},
+	Marshal: func(v any) ([]byte, error) {
+		var buf bytes.Buffer
+		enc := msgpack.NewEncoder(&buf)
+		enc.SetCustomStructTag("json")
+		if err := enc.Encode(v); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	},
+	Unmarshal: func(data []byte, v any) error {
+		dec := msgpack.NewDecoder(bytes.NewReader(data))
+		dec.SetCustomStructTag("json")
+		return dec.Decode(v)
+	},
+}
+
+// CBORCodec reads and writes CBOR (RFC 8949). Struct fields are named by their cbor tags, or their json
+// tags if they have none.
+var CBORCodec = Codec{
+	MediaTypes: []string{"application/cbor"},
+	Marshal:    cbor.Marshal,
+	Unmarshal:  cbor.Unmarshal,
+}
+
+// WriteResponse writes data in the format the client's Accept header prefers, among JSON and the codecs in
+// Codecs. JSON is written with WriteJSON, and is used when the client has no preference, or prefers a
+// format which isn't registered. Custom headers can be set with the WithHeaders option.
+func (t *Tools) WriteResponse(w http.ResponseWriter, r *http.Request, status int, data any, opts ...JSONOption) error {
+	w.Header().Add("Vary", "Accept")
+
+	accept := r.Header.Get("Accept")
+	if accept == "" {
+		return t.WriteJSONWith(w, status, data, opts...)
+	}
+
+	best, bestQuality := (*Codec)(nil), acceptQuality(accept, "application/json")
+	for i := range t.Codecs {
+		for _, mediaType := range t.Codecs[i].MediaTypes {
+			if q := acceptQuality(accept, mediaType); q > bestQuality {
+				best, bestQuality = &t.Codecs[i], q
+			}
+		}
+	}
+	if best == nil {
+		return t.WriteJSONWith(w, status, data, opts...)
+	}
+
+	out, err := best.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	t.jsonOptions(opts).setHeaders(w)
+	w.Header().Set("Content-Type", best.MediaTypes[0])
+	w.WriteHeader(status)
+	_, _ = w.Write(out)
+
+	return nil
+}
+
+// ReadBody reads the body of a request into data, which must be a pointer, choosing the format by the
+// Content-Type header among JSON and the codecs in Codecs. JSON, or a body without a Content-Type, is
+// read with ReadJSON. The size limit set by MaxJSONSize or WithMaxSize applies to every format.
+func (t *Tools) ReadBody(w http.ResponseWriter, r *http.Request, data any, opts ...JSONOption) error {
+	contentType := r.Header.Get("Content-Type")
+	if contentType == "" {
+		return t.ReadJSON(w, r, data, opts...)
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return fmt.Errorf("invalid Content-Type header %q", contentType)
+	}
+	codec := t.codecFor(mediaType)
+	if codec == nil {
+		return t.ReadJSON(w, r, data, opts...)
+	}
+
+	body, err := t.readLimitedBody(w, r, opts)
+	if err != nil {
+		return err
+	}
+	if len(body) == 0 {
+		return errors.New("body must not be empty")
+	}
+
+	if err := codec.Unmarshal(body, data); err != nil {
+		return fmt.Errorf("body contains badly-formed %s: %s", mediaType, err)
+	}
+	return nil
+}
+
+// codecFor returns the codec in Codecs for mediaType, or nil if there is none.
+func (t *Tools) codecFor(mediaType string) *Codec {
+	for i := range t.Codecs {
+		if contains(t.Codecs[i].MediaTypes, mediaType) {
+			return &t.Codecs[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package gohelpertools
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type codecPayload struct {
+	Name  string `json:"name" xml:"name"`
+	Count int    `json:"count" xml:"count"`
+}
+
+var writeResponseTests = []struct {
+	name     string
+	accept   string
+	expected string
+}{
+	{name: "no accept", accept: "", expected: "application/json"},
+	{name: "json", accept: "application/json", expected: "application/json"},
+	{name: "msgpack", accept: "application/msgpack", expected: "application/msgpack"},
+	{name: "msgpack alias", accept: "application/x-msgpack", expected: "application/msgpack"},
+	{name: "cbor", accept: "application/cbor", expected: "application/cbor"},
+	{name: "xml", accept: "text/xml", expected: "application/xml"},
+	{name: "json preferred", accept: "application/cbor;q=0.5, application/json", expected: "application/json"},
+	{name: "cbor preferred", accept: "application/cbor, */*;q=0.1", expected: "application/cbor"},
+	{name: "not registered", accept: "application/yaml", expected: "application/json"},
+}
+
+func TestTools_WriteResponseAndReadBody(t *testing.T) {
+	tools := New(WithCodecs(MsgpackCodec, CBORCodec, XMLCodec))
+	payload := codecPayload{Name: "sensor", Count: 3}
+
+	for _, e := range writeResponseTests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if e.accept != "" {
+			req.Header.Set("Accept", e.accept)
+		}
+		rr := httptest.NewRecorder()
+
+		if err := tools.WriteResponse(rr, req, http.StatusOK, payload); err != nil {
+			t.Errorf("%s: %s", e.name, err)
+			continue
+		}
+		contentType := rr.Header().Get("Content-Type")
+		if contentType != e.expected {
+			t.Errorf("%s: expected %s, got %s", e.name, e.expected, contentType)
+			continue
+		}
+
+		// Whatever was written can be read back.
+		req = httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(rr.Body.Bytes()))
+		req.Header.Set("Content-Type", contentType)
+		var got codecPayload
+		if err := tools.ReadBody(httptest.NewRecorder(), req, &got); err != nil {
+			t.Errorf("%s: error reading body: %s", e.name, err)
+		} else if got != payload {
+			t.Errorf("%s: expected %+v, got %+v", e.name, payload, got)
+		}
+	}
+}
+
+var readBodyErrorTests = []struct {
+	name        string
+	contentType string
+	body        string
+}{
+	{name: "malformed msgpack", contentType: "application/msgpack", body: "\xc1"},
+	{name: "empty cbor", contentType: "application/cbor", body: ""},
+	{name: "not registered", contentType: "application/yaml", body: "name: sensor"},
+	{name: "invalid content type", contentType: "application/", body: "{}"},
+}
+
+func TestTools_ReadBodyErrors(t *testing.T) {
+	tools := New(WithCodecs(MsgpackCodec, CBORCodec))
+
+	for _, e := range readBodyErrorTests {
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(e.body)))
+		req.Header.Set("Content-Type", e.contentType)
+		var got codecPayload
+		if err := tools.ReadBody(httptest.NewRecorder(), req, &got); err == nil {
+			t.Errorf("%s: error expected, but none received", e.name)
+		}
+	}
+}
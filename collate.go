@@ -0,0 +1,120 @@
+package gohelpertools
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+// collators holds a pool of collators for each supported locale, since a collate.Collator isn't safe for
+// concurrent use and is relatively costly to create.
+var collators sync.Map // language.Tag to *sync.Pool
+
+// collationLocales are the locales the collate package has rules for, by their string form. The matcher
+// only considers their default orderings, such as "de" rather than "de-u-co-phonebk"; an alternative is
+// used when it's asked for explicitly. Either way, the number of pools stays bounded whatever locales
+// clients send.
+var (
+	collationLocales, collationDefaults = supportedCollations()
+	collationMatcher                    = language.NewMatcher(collationDefaults)
+)
+
+func supportedCollations() (map[string]language.Tag, []language.Tag) {
+	all := make(map[string]language.Tag)
+	var defaults []language.Tag
+	for _, tag := range collate.Supported() {
+		all[tag.String()] = tag
+		if tag.TypeForKey("co") == "" {
+			defaults = append(defaults, tag)
+		}
+	}
+	return all, defaults
+}
+
+func collatorPool(locale string) *sync.Pool {
+	tag := language.Und
+	if parsed, err := language.Parse(locale); err == nil {
+		if _, index, confidence := collationMatcher.Match(parsed); confidence != language.No {
+			tag = collationDefaults[index]
+		}
+		if co := parsed.TypeForKey("co"); co != "" {
+			if alternative, err := tag.SetTypeForKey("co", co); err == nil {
+				if supported, ok := collationLocales[alternative.String()]; ok {
+					tag = supported
+				}
+			}
+		}
+	}
+
+	if pool, ok := collators.Load(tag); ok {
+		return pool.(*sync.Pool)
+	}
+	pool, _ := collators.LoadOrStore(tag, &sync.Pool{
+		New: func() any { return collate.New(tag) },
+	})
+	return pool.(*sync.Pool)
+}
+
+// CompareCollated compares a and b in the order used by locale, such as "de", "sv" or "fr-CA", and returns
+// -1, 0 or 1. Unlike comparing bytes, it puts "Émile" with the other names starting with E, and follows
+// each language's rules, so "ö" sorts with "o" in German but after "z" in Swedish. A locale which can't be
+// parsed is treated as the root locale, whose order suits most languages.
+func (t *Tools) CompareCollated(locale, a, b string) int {
+	pool := collatorPool(locale)
+	c := pool.Get().(*collate.Collator)
+	defer pool.Put(c)
+	return c.CompareString(a, b)
+}
+
+// SortStrings sorts items in place in the order used by locale, as CompareCollated compares them.
+func (t *Tools) SortStrings(locale string, items []string) {
+	SortCollated(locale, items, func(s string) string { return s })
+}
+
+// SortCollated sorts items in place by the strings key returns for them, in the order used by locale, for
+// example to order a page of users by name. The sort is stable, so items with equal keys keep their order.
+func SortCollated[T any](locale string, items []T, key func(T) string) {
+	pool := collatorPool(locale)
+	c := pool.Get().(*collate.Collator)
+	defer pool.Put(c)
+
+	// Compute each sort key once, rather than on every comparison.
+	var buf collate.Buffer
+	keys := make([][]byte, len(items))
+	for i, item := range items {
+		keys[i] = append([]byte(nil), c.KeyFromString(&buf, key(item))...)
+		buf.Reset()
+	}
+	sort.Stable(collatedItems[T]{items: items, keys: keys})
+}
+
+type collatedItems[T any] struct {
+	items []T
+	keys  [][]byte
+}
+
+func (s collatedItems[T]) Len() int { return len(s.items) }
+
+func (s collatedItems[T]) Less(i, j int) bool { return string(s.keys[i]) < string(s.keys[j]) }
+
+func (s collatedItems[T]) Swap(i, j int) {
+	s.items[i], s.items[j] = s.items[j], s.items[i]
+	s.keys[i], s.keys[j] = s.keys[j], s.keys[i]
+}
+
+// RequestLocale returns the locale stored in the request context by WithLocale, such as a user's saved
+// preference, or else the language the client prefers most in its Accept-Language header, such as "de-CH",
+// or "" if there is neither, for use with SortStrings and CompareCollated.
+func (t *Tools) RequestLocale(r *http.Request) string {
+	if locale := LocaleFromContext(r.Context()); locale != "" {
+		return locale
+	}
+	tags, _, err := language.ParseAcceptLanguage(r.Header.Get("Accept-Language"))
+	if err != nil || len(tags) == 0 {
+		return ""
+	}
+	return tags[0].String()
+}
@@ -0,0 +1,71 @@
+package gohelpertools
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+var sortStringsTests = []struct {
+	name     string
+	locale   string
+	items    []string
+	expected []string
+}{
+	{"accents", "en", []string{"Zoë", "Émile", "eve", "Adam"}, []string{"Adam", "Émile", "eve", "Zoë"}},
+	{"german", "de", []string{"zebra", "öl", "ofen"}, []string{"ofen", "öl", "zebra"}},
+	{"german phone book", "de-u-co-phonebk", []string{"zebra", "öl", "ofen"}, []string{"öl", "ofen", "zebra"}},
+	{"swiss german", "de-CH", []string{"zebra", "öl", "ofen"}, []string{"ofen", "öl", "zebra"}},
+	{"swedish", "sv", []string{"zebra", "öl", "ofen"}, []string{"ofen", "zebra", "öl"}},
+	{"spanish", "es", []string{"ñu", "nube", "oso"}, []string{"nube", "ñu", "oso"}},
+	{"invalid locale", "not a locale!", []string{"b", "A", "a"}, []string{"a", "A", "b"}},
+}
+
+func TestTools_SortStrings(t *testing.T) {
+	var tools Tools
+	for _, e := range sortStringsTests {
+		items := append([]string(nil), e.items...)
+		tools.SortStrings(e.locale, items)
+		if !reflect.DeepEqual(items, e.expected) {
+			t.Errorf("%s: expected %v, but got %v", e.name, e.expected, items)
+		}
+	}
+}
+
+func TestTools_CompareCollated(t *testing.T) {
+	var tools Tools
+	if tools.CompareCollated("sv", "öl", "zebra") != 1 || tools.CompareCollated("de", "öl", "zebra") != -1 {
+		t.Error("expected ö to sort after z in Swedish and before it in German")
+	}
+	if tools.CompareCollated("en", "resume", "resume") != 0 {
+		t.Error("expected equal strings to compare equal")
+	}
+}
+
+func TestSortCollated(t *testing.T) {
+	type user struct {
+		Name string
+		ID   int
+	}
+	users := []user{{"Ödön", 1}, {"Oscar", 2}, {"Zsófia", 3}, {"Oscar", 4}}
+	SortCollated("hu", users, func(u user) string { return u.Name })
+
+	expected := []user{{"Oscar", 2}, {"Oscar", 4}, {"Ödön", 1}, {"Zsófia", 3}}
+	if !reflect.DeepEqual(users, expected) {
+		t.Errorf("expected %v, but got %v", expected, users)
+	}
+}
+
+func TestTools_RequestLocale(t *testing.T) {
+	var tools Tools
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Language", "fr;q=0.8, de-CH, en;q=0.5")
+	if locale := tools.RequestLocale(req); locale != "de-CH" {
+		t.Errorf("expected de-CH, but got %s", locale)
+	}
+	req.Header.Del("Accept-Language")
+	if locale := tools.RequestLocale(req); locale != "" {
+		t.Errorf("expected no locale, but got %s", locale)
+	}
+}
@@ -0,0 +1,208 @@
+package gohelpertools
+
+import (
+	"bufio"
+	"encoding"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LoadConfig populates the struct pointed to by dest from environment variables, named by env tags:
+//
+//	type Config struct {
+//		Port     int           `env:"PORT" default:"8080"`
+//		DBURL    string        `env:"DATABASE_URL,required"`
+//		Timeout  time.Duration `env:"TIMEOUT" default:"5s"`
+//		Origins  []string      `env:"ALLOWED_ORIGINS"` // comma-separated
+//		Database DBConfig      // nested structs are loaded too
+//	}
+//
+// Strings, bools, integers, floats, durations, slices of these (comma-separated), and types implementing
+// encoding.TextUnmarshaler are supported. Variables are also read from envFiles, in .env format, for any
+// not set in the environment; a missing file is ignored. Every problem found (missing required variables,
+// values which can't be converted) is reported in one error, so they can all be fixed at once.
+func (t *Tools) LoadConfig(dest any, envFiles ...string) error {
+	rv := reflect.ValueOf(dest)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("config destination must be a non-nil pointer to a struct")
+	}
+
+	fileVars := make(map[string]string)
+	for _, name := range envFiles {
+		vars, err := parseEnvFile(name)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		for key, value := range vars {
+			if _, ok := fileVars[key]; !ok {
+				fileVars[key] = value
+			}
+		}
+	}
+
+	lookup := func(key string) (string, bool) {
+		if value, ok := os.LookupEnv(key); ok {
+			return value, true
+		}
+		value, ok := fileVars[key]
+		return value, ok
+	}
+
+	var problems []error
+	loadConfigStruct(rv.Elem(), lookup, &problems)
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid configuration: %w", errors.Join(problems...))
+	}
+	return nil
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+func loadConfigStruct(v reflect.Value, lookup func(string) (string, bool), problems *[]error) {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		tag, ok := field.Tag.Lookup("env")
+		if !ok {
+			if field.Type.Kind() == reflect.Struct && !reflect.PointerTo(field.Type).Implements(textUnmarshalerType) {
+				loadConfigStruct(v.Field(i), lookup, problems)
+			}
+			continue
+		}
+
+		name, flags, _ := strings.Cut(tag, ",")
+		required := flags == "required"
+
+		value, found := lookup(name)
+		if !found || value == "" {
+			def, hasDefault := field.Tag.Lookup("default")
+			switch {
+			case hasDefault:
+				value = def
+			case required:
+				*problems = append(*problems, fmt.Errorf("%s is required", name))
+				continue
+			default:
+				continue
+			}
+		}
+
+		if err := setConfigValue(v.Field(i), value); err != nil {
+			*problems = append(*problems, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+}
+
+func setConfigValue(v reflect.Value, value string) error {
+	if v.CanAddr() && v.Addr().Type().Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value))
+	}
+
+	if v.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a valid duration", value)
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a valid boolean", value)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%q is not a valid integer", value)
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%q is not a valid unsigned integer", value)
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%q is not a valid number", value)
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		parts := strings.Split(value, ",")
+		slice := reflect.MakeSlice(v.Type(), 0, len(parts))
+		for _, part := range parts {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if err := setConfigValue(elem, part); err != nil {
+				return err
+			}
+			slice = reflect.Append(slice, elem)
+		}
+		v.Set(slice)
+	default:
+		return fmt.Errorf("unsupported field type %s", v.Type())
+	}
+	return nil
+}
+
+// parseEnvFile reads KEY=VALUE lines from a .env file. Blank lines and lines starting with # are
+// skipped, an "export " prefix is allowed, and values may be quoted; double-quoted values may contain
+// \n escapes.
+func parseEnvFile(name string) (map[string]string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	vars := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("%s:%d: expected KEY=VALUE", name, lineNo)
+		}
+		value = strings.TrimSpace(value)
+
+		switch {
+		case len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"':
+			value = strings.ReplaceAll(value[1:len(value)-1], `\n`, "\n")
+		case len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'':
+			value = value[1 : len(value)-1]
+		default:
+			if i := strings.Index(value, " #"); i >= 0 {
+				value = strings.TrimSpace(value[:i])
+			}
+		}
+		vars[key] = value
+	}
+	return vars, scanner.Err()
+}
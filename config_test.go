@@ -0,0 +1,83 @@
+package gohelpertools
+
+import (
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testDBConfig struct {
+	URL      string `env:"TEST_CFG_DB_URL,required"`
+	MaxConns int    `env:"TEST_CFG_DB_MAX_CONNS" default:"10"`
+}
+
+type testConfig struct {
+	Port     int           `env:"TEST_CFG_PORT" default:"8080"`
+	Debug    bool          `env:"TEST_CFG_DEBUG"`
+	Timeout  time.Duration `env:"TEST_CFG_TIMEOUT" default:"5s"`
+	Origins  []string      `env:"TEST_CFG_ORIGINS"`
+	Ratio    float64       `env:"TEST_CFG_RATIO"`
+	Addr     netip.Addr    `env:"TEST_CFG_ADDR" default:"127.0.0.1"`
+	Greeting string        `env:"TEST_CFG_GREETING"`
+	Database testDBConfig
+	ignored  string
+}
+
+func TestTools_LoadConfig(t *testing.T) {
+	var tools Tools
+
+	dir := t.TempDir()
+	envFile := filepath.Join(dir, ".env")
+	_ = os.WriteFile(envFile, []byte(`# local settings
+export TEST_CFG_DB_URL="postgres://localhost/app"
+TEST_CFG_PORT=9000 # overridden below
+TEST_CFG_GREETING='hello # world'
+`), 0o600)
+
+	t.Setenv("TEST_CFG_PORT", "3000")
+	t.Setenv("TEST_CFG_DEBUG", "true")
+	t.Setenv("TEST_CFG_ORIGINS", "https://a.example, https://b.example")
+	t.Setenv("TEST_CFG_RATIO", "0.25")
+
+	var cfg testConfig
+	if err := tools.LoadConfig(&cfg, envFile, filepath.Join(dir, "missing.env")); err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Port != 3000 || !cfg.Debug || cfg.Timeout != 5*time.Second || cfg.Ratio != 0.25 {
+		t.Errorf("unexpected config %+v", cfg)
+	}
+	if len(cfg.Origins) != 2 || cfg.Origins[1] != "https://b.example" {
+		t.Errorf("unexpected origins %v", cfg.Origins)
+	}
+	if cfg.Addr.String() != "127.0.0.1" || cfg.Greeting != "hello # world" {
+		t.Errorf("unexpected config %+v", cfg)
+	}
+	if cfg.Database.URL != "postgres://localhost/app" || cfg.Database.MaxConns != 10 {
+		t.Errorf("unexpected database config %+v", cfg.Database)
+	}
+}
+
+func TestTools_LoadConfigErrors(t *testing.T) {
+	var tools Tools
+	t.Setenv("TEST_CFG_PORT", "eighty")
+	t.Setenv("TEST_CFG_TIMEOUT", "soon")
+
+	var cfg testConfig
+	err := tools.LoadConfig(&cfg)
+	if err == nil {
+		t.Fatal("error expected, but none received")
+	}
+	for _, want := range []string{"TEST_CFG_PORT", "TEST_CFG_TIMEOUT", "TEST_CFG_DB_URL is required"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected the error to mention %q, got %q", want, err)
+		}
+	}
+
+	if err := tools.LoadConfig(cfg); err == nil {
+		t.Error("non-pointer: error expected, but none received")
+	}
+}
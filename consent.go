@@ -0,0 +1,218 @@
+package gohelpertools
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const consentContextKey contextKey = "consent"
+
+const defaultConsentMaxAge = 365 * 24 * time.Hour
+
+// The consent categories known by default. Necessary cookies don't need consent, so ConsentNecessary is
+// always allowed.
+const (
+	ConsentNecessary   = "necessary"
+	ConsentPreferences = "preferences"
+	ConsentAnalytics   = "analytics"
+	ConsentMarketing   = "marketing"
+)
+
+// Consent is a user's or visitor's choice of which optional features may be used.
+type Consent struct {
+	Categories map[string]bool `json:"categories"`
+	Updated    time.Time       `json:"updated"`
+}
+
+// Allows reports whether category has been consented to. Without a recorded choice, nothing optional is
+// allowed.
+func (c Consent) Allows(category string) bool {
+	return category == ConsentNecessary || c.Categories[category]
+}
+
+// ConsentStore keeps consent on the server, so that a logged-in user's choice follows them across devices.
+type ConsentStore interface {
+	// Load returns the consent recorded for subject; found is false if there is none.
+	Load(ctx context.Context, subject string) (consent Consent, found bool, err error)
+	// Save records consent for subject.
+	Save(ctx context.Context, subject string, consent Consent) error
+}
+
+// ConsentManager records cookie and tracking preferences, and makes them available to handlers and
+// templates, so that analytics and marketing features are gated the same way everywhere. Consent is always
+// kept in a cookie, readable by client-side scripts such as a consent banner; with a Store and a Subject,
+// a logged-in user's consent is also kept on the server, and takes precedence.
+type ConsentManager struct {
+	Store      ConsentStore                 // if set, consent is also saved here for identified users
+	Subject    func(r *http.Request) string // identifies the user, for the Store; if nil or empty, only the cookie is used
+	Categories []string                     // categories which may be set; defaults to preferences, analytics and marketing
+	CookieName string                       // defaults to "consent"
+	MaxAge     time.Duration                // how long the cookie lasts; defaults to one year
+	Insecure   bool                         // if set to true, the cookie is also sent over plain HTTP (for local development)
+	Tools      *Tools                       // used to read and write JSON; a zero Tools is used if nil
+}
+
+// Middleware loads the consent for each request and makes it available through ConsentFromContext.
+func (m *ConsentManager) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		consent := m.load(r)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), consentContextKey, consent)))
+	})
+}
+
+// ConsentFromContext returns the consent loaded by ConsentManager.Middleware. Without one, the zero
+// Consent is returned, which allows nothing optional.
+func ConsentFromContext(ctx context.Context) Consent {
+	consent, _ := ctx.Value(consentContextKey).(Consent)
+	return consent
+}
+
+// PreferencesHandler returns a handler for the preferences endpoint. GET responds with the current
+// consent; POST or PUT takes a JSON object of categories, such as {"analytics": true, "marketing": false},
+// records it, and responds with the new consent.
+func (m *ConsentManager) PreferencesHandler() http.Handler {
+	t := toolsOrDefault(m.Tools)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+			_ = t.WriteJSON(w, http.StatusOK, m.load(r))
+
+		case http.MethodPost, http.MethodPut:
+			var choices map[string]bool
+			if err := t.ReadJSON(w, r, &choices); err != nil {
+				_ = t.ErrorJSON(w, err)
+				return
+			}
+
+			consent, err := m.Save(w, r, choices)
+			if err != nil {
+				_ = t.ErrorJSON(w, err)
+				return
+			}
+			_ = t.WriteJSON(w, http.StatusOK, consent)
+
+		default:
+			w.Header().Set("Allow", "GET, HEAD, POST, PUT")
+			_ = t.ErrorJSON(w, errors.New("method not allowed"), http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+// Save records choices, by category, in the cookie and, for an identified user, the Store. Categories
+// which aren't known are rejected.
+func (m *ConsentManager) Save(w http.ResponseWriter, r *http.Request, choices map[string]bool) (Consent, error) {
+	consent := Consent{Categories: make(map[string]bool), Updated: time.Now().UTC().Truncate(time.Second)}
+	for category, allowed := range choices {
+		if category == ConsentNecessary {
+			continue
+		}
+		if !contains(m.categories(), category) {
+			return Consent{}, fmt.Errorf("unknown consent category %q", category)
+		}
+		consent.Categories[category] = allowed
+	}
+
+	if subject := m.subject(r); subject != "" && m.Store != nil {
+		if err := m.Store.Save(r.Context(), subject, consent); err != nil {
+			return Consent{}, err
+		}
+	}
+
+	data, err := json.Marshal(consent)
+	if err != nil {
+		return Consent{}, err
+	}
+	maxAge := m.MaxAge
+	if maxAge <= 0 {
+		maxAge = defaultConsentMaxAge
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     valueOrDefault(m.CookieName, "consent"),
+		Value:    base64.RawURLEncoding.EncodeToString(data),
+		Path:     "/",
+		MaxAge:   int(maxAge.Seconds()),
+		Secure:   !m.Insecure,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return consent, nil
+}
+
+// load returns the consent for r, from the Store for an identified user, or else the cookie.
+func (m *ConsentManager) load(r *http.Request) Consent {
+	if subject := m.subject(r); subject != "" && m.Store != nil {
+		consent, found, err := m.Store.Load(r.Context(), subject)
+		if err != nil {
+			toolsOrDefault(m.Tools).LogError(r.Context(), "unable to load consent", err)
+		}
+		if found {
+			return consent
+		}
+	}
+
+	var consent Consent
+	cookie, err := r.Cookie(valueOrDefault(m.CookieName, "consent"))
+	if err != nil {
+		return consent
+	}
+	data, err := base64.RawURLEncoding.DecodeString(cookie.Value)
+	if err != nil || json.Unmarshal(data, &consent) != nil {
+		return Consent{}
+	}
+
+	// Ignore categories which are no longer offered.
+	for category := range consent.Categories {
+		if !contains(m.categories(), category) {
+			delete(consent.Categories, category)
+		}
+	}
+	return consent
+}
+
+func (m *ConsentManager) subject(r *http.Request) string {
+	if m.Subject == nil {
+		return ""
+	}
+	return m.Subject(r)
+}
+
+func (m *ConsentManager) categories() []string {
+	if len(m.Categories) > 0 {
+		return m.Categories
+	}
+	return []string{ConsentPreferences, ConsentAnalytics, ConsentMarketing}
+}
+
+// MemoryConsentStore is a ConsentStore which keeps consent in memory, for tests and single-instance
+// services.
+type MemoryConsentStore struct {
+	mu       sync.RWMutex
+	consents map[string]Consent
+}
+
+// NewMemoryConsentStore returns an empty MemoryConsentStore.
+func NewMemoryConsentStore() *MemoryConsentStore {
+	return &MemoryConsentStore{consents: make(map[string]Consent)}
+}
+
+// Load returns the consent recorded for subject.
+func (s *MemoryConsentStore) Load(ctx context.Context, subject string) (Consent, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	consent, ok := s.consents[subject]
+	return consent, ok, nil
+}
+
+// Save records consent for subject.
+func (s *MemoryConsentStore) Save(ctx context.Context, subject string, consent Consent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.consents[subject] = consent
+	return nil
+}
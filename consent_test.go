@@ -0,0 +1,82 @@
+package gohelpertools
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestConsentManager(t *testing.T) {
+	store := NewMemoryConsentStore()
+	m := &ConsentManager{
+		Store:   store,
+		Subject: func(r *http.Request) string { return r.Header.Get("X-User") },
+	}
+
+	// A visitor records their choice, which is kept in a cookie.
+	req := httptest.NewRequest(http.MethodPost, "/consent", strings.NewReader(`{"analytics": true, "marketing": false}`))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	m.PreferencesHandler().ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200 but got %d: %s", rr.Code, rr.Body.String())
+	}
+	cookies := rr.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != "consent" || cookies[0].HttpOnly {
+		t.Fatalf("expected a script-readable consent cookie, got %v", cookies)
+	}
+
+	var seen Consent
+	handler := m.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = ConsentFromContext(r.Context())
+	}))
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(cookies[0])
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if !seen.Allows(ConsentAnalytics) || seen.Allows(ConsentMarketing) || !seen.Allows(ConsentNecessary) {
+		t.Errorf("unexpected consent from cookie %+v", seen)
+	}
+
+	// A logged-in user's stored consent takes precedence over the cookie.
+	req = httptest.NewRequest(http.MethodPut, "/consent", strings.NewReader(`{"marketing": true}`))
+	req.Header.Set("X-User", "user-1")
+	m.PreferencesHandler().ServeHTTP(httptest.NewRecorder(), req)
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-User", "user-1")
+	req.AddCookie(cookies[0])
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if seen.Allows(ConsentAnalytics) || !seen.Allows(ConsentMarketing) {
+		t.Errorf("expected the stored consent, got %+v", seen)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/consent", nil)
+	req.Header.Set("X-User", "user-1")
+	rr = httptest.NewRecorder()
+	m.PreferencesHandler().ServeHTTP(rr, req)
+	var got Consent
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil || !got.Categories[ConsentMarketing] {
+		t.Errorf("unexpected preferences %s (%v)", rr.Body.String(), err)
+	}
+}
+
+func TestConsentManager_UnknownCategory(t *testing.T) {
+	var m ConsentManager
+	req := httptest.NewRequest(http.MethodPost, "/consent", strings.NewReader(`{"telemetry": true}`))
+	rr := httptest.NewRecorder()
+	m.PreferencesHandler().ServeHTTP(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 but got %d", rr.Code)
+	}
+
+	var seen Consent
+	m.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = ConsentFromContext(r.Context())
+	})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if seen.Allows(ConsentAnalytics) {
+		t.Error("expected nothing optional to be allowed without consent")
+	}
+}
@@ -0,0 +1,98 @@
+package gohelpertools
+
+import (
+	"context"
+	"log/slog"
+)
+
+// contextKey is the type used for values this package stores in a request context.
+type contextKey string
+
+const (
+	claimsContextKey    contextKey = "claims"
+	requestIDContextKey contextKey = "request_id"
+	userIDContextKey    contextKey = "user_id"
+	localeContextKey    contextKey = "locale"
+	tenantContextKey    contextKey = "tenant"
+	loggerContextKey    contextKey = "logger"
+)
+
+// The functions below store and read the request-scoped values which the middleware in this package share,
+// so that, for example, a handler can find the tenant whichever middleware resolved it, and an
+// application's own authentication middleware can set the user ID that others read. Each value has a
+// setter, With..., and a getter, ...FromContext; the getters return the zero value when it isn't set.
+
+// WithUserID returns a copy of ctx carrying the ID of the authenticated user.
+func WithUserID(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userIDContextKey, userID)
+}
+
+// UserIDFromContext returns the user ID stored by WithUserID or, if there isn't one, the subject of the
+// claims stored by RequireJWT. It returns "" if there is neither.
+func UserIDFromContext(ctx context.Context) string {
+	if id, _ := ctx.Value(userIDContextKey).(string); id != "" {
+		return id
+	}
+	claims, _ := ClaimsFromContext(ctx)
+	return claims.Subject()
+}
+
+// WithClaims returns a copy of ctx carrying the claims of a validated token, as RequireJWT does.
+func WithClaims(ctx context.Context, claims Claims) context.Context {
+	return context.WithValue(ctx, claimsContextKey, claims)
+}
+
+// ClaimsFromContext returns the claims stored in ctx by RequireJWT or WithClaims.
+func ClaimsFromContext(ctx context.Context) (Claims, bool) {
+	claims, ok := ctx.Value(claimsContextKey).(Claims)
+	return claims, ok
+}
+
+// WithRequestID returns a copy of ctx carrying a request ID, as the RequestID middleware does. It's useful
+// in background jobs, so that their logs can be tied to the request which started them.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey, id)
+}
+
+// RequestIDFromContext returns the request ID stored by the RequestID middleware, or "" if there isn't
+// one.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDContextKey).(string)
+	return id
+}
+
+// WithLocale returns a copy of ctx carrying the locale to use for the request, such as "fr-CA", which
+// RequestLocale then prefers to the Accept-Language header.
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeContextKey, locale)
+}
+
+// LocaleFromContext returns the locale stored by WithLocale, or "" if there isn't one.
+func LocaleFromContext(ctx context.Context) string {
+	locale, _ := ctx.Value(localeContextKey).(string)
+	return locale
+}
+
+// WithTenant returns a copy of ctx carrying the ID of the tenant the request belongs to, as
+// HostRouter.Route does.
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantContextKey, tenant)
+}
+
+// TenantFromContext returns the tenant ID added to ctx by HostRouter.Route or WithTenant.
+func TenantFromContext(ctx context.Context) (string, bool) {
+	tenant, ok := ctx.Value(tenantContextKey).(string)
+	return tenant, ok
+}
+
+// WithContextLogger returns a copy of ctx carrying a logger which LogError and LogInfo use instead of
+// Tools.Logger, such as a tenant's own logger set by Tenancy.
+func WithContextLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerContextKey, logger)
+}
+
+// LoggerFromContext returns the logger stored by WithContextLogger, or nil if there isn't one.
+func LoggerFromContext(ctx context.Context) *slog.Logger {
+	logger, _ := ctx.Value(loggerContextKey).(*slog.Logger)
+	return logger
+}
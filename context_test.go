@@ -0,0 +1,48 @@
+package gohelpertools
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestContextValues(t *testing.T) {
+	ctx := context.Background()
+	if UserIDFromContext(ctx) != "" || RequestIDFromContext(ctx) != "" || LocaleFromContext(ctx) != "" {
+		t.Error("expected empty values from an empty context")
+	}
+	if _, ok := TenantFromContext(ctx); ok {
+		t.Error("expected no tenant in an empty context")
+	}
+
+	ctx = WithClaims(ctx, Claims{"sub": "user-1"})
+	if UserIDFromContext(ctx) != "user-1" {
+		t.Errorf("expected the user ID to fall back to the token subject, got %q", UserIDFromContext(ctx))
+	}
+
+	ctx = WithUserID(ctx, "user-2")
+	ctx = WithRequestID(ctx, "req-1")
+	ctx = WithLocale(ctx, "fr-CA")
+	ctx = WithTenant(ctx, "acme")
+	if UserIDFromContext(ctx) != "user-2" || RequestIDFromContext(ctx) != "req-1" || LocaleFromContext(ctx) != "fr-CA" {
+		t.Errorf("unexpected values %q, %q, %q", UserIDFromContext(ctx), RequestIDFromContext(ctx), LocaleFromContext(ctx))
+	}
+	if tenant, ok := TenantFromContext(ctx); !ok || tenant != "acme" {
+		t.Errorf("expected tenant acme, got %q", tenant)
+	}
+	if claims, ok := ClaimsFromContext(ctx); !ok || claims.Subject() != "user-1" {
+		t.Errorf("expected the claims to be kept, got %v", claims)
+	}
+
+	// Values set with the same keys as the middleware are seen by them too.
+	var tools Tools
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Language", "de-CH")
+	if locale := tools.RequestLocale(req.WithContext(ctx)); locale != "fr-CA" {
+		t.Errorf("expected the context locale to be preferred, got %q", locale)
+	}
+	if locale := tools.RequestLocale(req); locale != "de-CH" {
+		t.Errorf("expected the Accept-Language locale, got %q", locale)
+	}
+}
@@ -0,0 +1,258 @@
+package gohelpertools
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ArrayMode controls how Flatten handles arrays.
+type ArrayMode int
+
+const (
+	// ArrayIndex flattens each array element into its own column, using its index as the key (tags.0, tags.1).
+	ArrayIndex ArrayMode = iota
+	// ArrayJoin joins arrays of scalar values into a single column, separated by a semicolon. Arrays
+	// containing objects or arrays fall back to ArrayIndex.
+	ArrayJoin
+	// ArrayJSON keeps each array in a single column, encoded as JSON.
+	ArrayJSON
+)
+
+// FlattenOptions configures Flatten.
+type FlattenOptions struct {
+	Separator string    // placed between nested keys; defaults to "."
+	Arrays    ArrayMode // how arrays are handled; defaults to ArrayIndex
+}
+
+// Flatten converts a nested JSON value into a single-level map with dotted keys, so that
+// {"user": {"name": "x"}} becomes {"user.name": "x"}. The data parameter may be raw JSON ([]byte,
+// json.RawMessage or string) or any value which can be marshalled to JSON.
+func (t *Tools) Flatten(data any, opts ...FlattenOptions) (map[string]any, error) {
+	var options FlattenOptions
+	if len(opts) > 0 {
+		options = opts[0]
+	}
+	if options.Separator == "" {
+		options.Separator = "."
+	}
+
+	value, err := toGenericJSON(data)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(map[string]any)
+	flattenValue("", value, options, out)
+	return out, nil
+}
+
+// WriteCSV writes data to w as CSV. The data parameter may be a single object or an array of objects (as
+// raw JSON or any value which can be marshalled to JSON); each object is flattened with Flatten and becomes
+// one row. The header row contains every key found, sorted alphabetically. Cells which a spreadsheet
+// would run as a formula are prefixed with a single quote (see csvSafe).
+func (t *Tools) WriteCSV(w io.Writer, data any, opts ...FlattenOptions) error {
+	value, err := toGenericJSON(data)
+	if err != nil {
+		return err
+	}
+
+	items, ok := value.([]any)
+	if !ok {
+		items = []any{value}
+	}
+
+	var rows []map[string]any
+	columns := make(map[string]bool)
+	for _, item := range items {
+		row, err := t.Flatten(item, opts...)
+		if err != nil {
+			return err
+		}
+		for key := range row {
+			columns[key] = true
+		}
+		rows = append(rows, row)
+	}
+
+	header := make([]string, 0, len(columns))
+	for key := range columns {
+		header = append(header, key)
+	}
+	sort.Strings(header)
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvSafeRecord(header)); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		record := make([]string, len(header))
+		for i, key := range header {
+			if v, ok := row[key]; ok && v != nil {
+				record[i] = csvSafe(csvValue(v))
+			}
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteJSONOrCSV behaves like WriteJSON, except that when the client sends an Accept header asking for
+// text/csv, data is written as CSV instead. If data is a JSONResponse, only its Data field is exported,
+// since the envelope makes no sense as a table.
+func (t *Tools) WriteJSONOrCSV(w http.ResponseWriter, r *http.Request, status int, data any, headers ...http.Header) error {
+	if !strings.Contains(strings.ToLower(r.Header.Get("Accept")), "text/csv") {
+		return t.WriteJSON(w, status, data, headers...)
+	}
+
+	switch payload := data.(type) {
+	case JSONResponse:
+		data = payload.Data
+	case *JSONResponse:
+		data = payload.Data
+	}
+
+	// Build the CSV first, so that an error can still be reported to the client as JSON.
+	var out strings.Builder
+	if err := t.WriteCSV(&out, data); err != nil {
+		return err
+	}
+
+	if len(headers) > 0 {
+		for key, value := range headers[0] {
+			w.Header()[key] = value
+		}
+	}
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.WriteHeader(status)
+	_, _ = io.WriteString(w, out.String())
+
+	return nil
+}
+
+// toGenericJSON converts data to generic JSON values: maps, slices, strings, bools and json.Number.
+func toGenericJSON(data any) (any, error) {
+	var raw []byte
+	switch v := data.(type) {
+	case []byte:
+		raw = v
+	case json.RawMessage:
+		raw = v
+	case string:
+		raw = []byte(v)
+	case map[string]any, []any:
+		return v, nil
+	default:
+		var err error
+		raw, err = json.Marshal(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Numbers are kept as json.Number, so that large IDs aren't mangled into floating point notation.
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+
+	var value any
+	if err := dec.Decode(&value); err != nil {
+		return nil, fmt.Errorf("error unmarshalling json: %s", err.Error())
+	}
+	return value, nil
+}
+
+// flattenValue writes value into out under prefix, recursing into objects and arrays.
+func flattenValue(prefix string, value any, options FlattenOptions, out map[string]any) {
+	join := func(key string) string {
+		if prefix == "" {
+			return key
+		}
+		return prefix + options.Separator + key
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		if len(v) == 0 && prefix != "" {
+			out[prefix] = nil
+		}
+		for key, child := range v {
+			flattenValue(join(key), child, options, out)
+		}
+
+	case []any:
+		switch {
+		case options.Arrays == ArrayJSON:
+			encoded, _ := json.Marshal(v)
+			out[prefix] = string(encoded)
+			return
+
+		case options.Arrays == ArrayJoin && isScalarArray(v):
+			parts := make([]string, len(v))
+			for i, item := range v {
+				parts[i] = csvValue(item)
+			}
+			out[prefix] = strings.Join(parts, ";")
+			return
+		}
+
+		if len(v) == 0 && prefix != "" {
+			out[prefix] = nil
+		}
+		for i, child := range v {
+			flattenValue(join(fmt.Sprint(i)), child, options, out)
+		}
+
+	default:
+		out[prefix] = v
+	}
+}
+
+// isScalarArray reports whether none of the elements of v are objects or arrays.
+func isScalarArray(v []any) bool {
+	for _, item := range v {
+		switch item.(type) {
+		case map[string]any, []any:
+			return false
+		}
+	}
+	return true
+}
+
+// csvValue formats a flattened value for a CSV cell.
+func csvValue(v any) string {
+	if f, ok := v.(float64); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+// csvSafe prefixes cell with a single quote if it starts with a character which makes Excel or Google
+// Sheets treat it as a formula, so that an export containing user data can't run formulas when it's
+// opened. Numbers, such as -5, are left alone.
+func csvSafe(cell string) string {
+	if cell == "" || !strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+		return cell
+	}
+	if _, err := strconv.ParseFloat(cell, 64); err == nil {
+		return cell
+	}
+	return "'" + cell
+}
+
+// csvSafeRecord returns a copy of record with csvSafe applied to every cell.
+func csvSafeRecord(record []string) []string {
+	out := make([]string, len(record))
+	for i, cell := range record {
+		out[i] = csvSafe(cell)
+	}
+	return out
+}
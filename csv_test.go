@@ -0,0 +1,124 @@
+package gohelpertools
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var flattenTests = []struct {
+	name     string
+	json     string
+	opts     FlattenOptions
+	expected map[string]string
+}{
+	{
+		name:     "nested objects",
+		json:     `{"user": {"name": "jack", "address": {"city": "Lagos"}}, "id": 1234567890}`,
+		expected: map[string]string{"user.name": "jack", "user.address.city": "Lagos", "id": "1234567890"},
+	},
+	{
+		name:     "array index",
+		json:     `{"tags": ["a", "b"], "items": [{"sku": "x"}]}`,
+		expected: map[string]string{"tags.0": "a", "tags.1": "b", "items.0.sku": "x"},
+	},
+	{
+		name:     "array join",
+		json:     `{"tags": ["a", "b"], "items": [{"sku": "x"}]}`,
+		opts:     FlattenOptions{Arrays: ArrayJoin},
+		expected: map[string]string{"tags": "a;b", "items.0.sku": "x"},
+	},
+	{
+		name:     "array json with separator",
+		json:     `{"a": {"tags": [1, 2]}}`,
+		opts:     FlattenOptions{Arrays: ArrayJSON, Separator: "_"},
+		expected: map[string]string{"a_tags": "[1,2]"},
+	},
+}
+
+func TestTools_Flatten(t *testing.T) {
+	var testTools Tools
+
+	for _, e := range flattenTests {
+		flat, err := testTools.Flatten([]byte(e.json), e.opts)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", e.name, err)
+			continue
+		}
+
+		got := make(map[string]string)
+		for key, value := range flat {
+			got[key] = csvValue(value)
+		}
+		if !reflect.DeepEqual(got, e.expected) {
+			t.Errorf("%s: expected %v but got %v", e.name, e.expected, got)
+		}
+	}
+
+	_, err := testTools.Flatten([]byte(`{"bad"`))
+	if err == nil {
+		t.Error("expected error for invalid json, but none received")
+	}
+}
+
+func TestTools_WriteCSV(t *testing.T) {
+	var testTools Tools
+
+	data := []map[string]any{
+		{"name": "jack", "address": map[string]any{"city": "Lagos"}},
+		{"name": "jill, jr", "age": 30},
+	}
+
+	var out strings.Builder
+	if err := testTools.WriteCSV(&out, data); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "address.city,age,name\nLagos,,jack\n,30,\"jill, jr\"\n"
+	if out.String() != expected {
+		t.Errorf("wrong csv; expected %q but got %q", expected, out.String())
+	}
+}
+
+func TestTools_WriteCSVFormulas(t *testing.T) {
+	var testTools Tools
+
+	data := []map[string]any{
+		{"=cmd": "=1+2", "b": "+SUM(A1)", "c": "@x", "d": -5, "e": "-x"},
+	}
+
+	var out strings.Builder
+	if err := testTools.WriteCSV(&out, data); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "'=cmd,b,c,d,e\n'=1+2,'+SUM(A1),'@x,-5,'-x\n"
+	if out.String() != expected {
+		t.Errorf("wrong csv; expected %q but got %q", expected, out.String())
+	}
+}
+
+func TestTools_WriteJSONOrCSV(t *testing.T) {
+	var testTools Tools
+
+	payload := JSONResponse{Message: "ok", Data: []map[string]any{{"id": 1}, {"id": 2}}}
+
+	for _, accept := range []string{"application/json", "text/csv"} {
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.Header.Set("Accept", accept)
+
+		rr := httptest.NewRecorder()
+		if err := testTools.WriteJSONOrCSV(rr, req, http.StatusOK, payload); err != nil {
+			t.Fatal(err)
+		}
+
+		if !strings.HasPrefix(rr.Header().Get("Content-Type"), strings.Split(accept, ";")[0]) {
+			t.Errorf("%s: wrong content type %s", accept, rr.Header().Get("Content-Type"))
+		}
+		if accept == "text/csv" && rr.Body.String() != "id\n1\n2\n" {
+			t.Errorf("%s: wrong body %q", accept, rr.Body.String())
+		}
+	}
+}
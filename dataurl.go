@@ -0,0 +1,157 @@
+package gohelpertools
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DataURL is a file sent inline, as in data:image/png;base64,iVBORw0KGgo...
+type DataURL struct {
+	MediaType string            // such as "image/png"; "text/plain" if the URL doesn't say
+	Params    map[string]string // parameters of the media type, such as charset
+	Data      []byte
+}
+
+// ParseDataURL decodes a data URL (RFC 2397), whose data is either base64 or percent-encoded.
+func (t *Tools) ParseDataURL(s string) (*DataURL, error) {
+	rest, ok := cutPrefixFold(strings.TrimSpace(s), "data:")
+	if !ok {
+		return nil, errors.New("not a data URL")
+	}
+	header, data, ok := strings.Cut(rest, ",")
+	if !ok {
+		return nil, errors.New("data URL has no data")
+	}
+
+	header, isBase64 := cutSuffixFold(header, ";base64")
+	d := &DataURL{MediaType: "text/plain", Params: map[string]string{}}
+	if header != "" {
+		if strings.HasPrefix(header, ";") {
+			header = "text/plain" + header
+		}
+		mediaType, params, err := mime.ParseMediaType(header)
+		if err != nil {
+			return nil, fmt.Errorf("data URL has an invalid media type: %w", err)
+		}
+		d.MediaType, d.Params = mediaType, params
+	}
+
+	if isBase64 {
+		decoded, err := decodeBase64(data)
+		if err != nil {
+			return nil, err
+		}
+		d.Data = decoded
+		return d, nil
+	}
+	decoded, err := url.PathUnescape(data)
+	if err != nil {
+		return nil, errors.New("data URL has invalid percent-encoding")
+	}
+	d.Data = []byte(decoded)
+	return d, nil
+}
+
+// ReadBase64File reads the file in field of the JSON object in the body of r, sent either as a data URL
+// or as plain base64, and checks it as UploadFiles checks uploads: it must be at most MaxFileSize bytes
+// and, if AllowedFileTypes is set, of one of those types. The MediaType returned is detected from the
+// content, whatever a data URL claims. The body is put back afterwards, so the rest of the object can
+// still be read with ReadJSON.
+func (t *Tools) ReadBase64File(field string, r *http.Request) (*DataURL, error) {
+	maxSize := int64(t.MaxFileSize)
+	if maxSize <= 0 {
+		maxSize = defaultMaxUpload
+	}
+
+	// Base64 takes four bytes for every three, and the rest of the object needs some room too.
+	maxBody := maxSize/3*4 + 4 + maxUploadFieldSize
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBody+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > maxBody {
+		return nil, fmt.Errorf("body must not be larger than %d bytes", maxBody)
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(bytes.TrimPrefix(body, utf8BOM), &object); err != nil {
+		return nil, errors.New("body must be a JSON object")
+	}
+	var value string
+	if raw, ok := object[field]; !ok || string(raw) == "null" {
+		return nil, fmt.Errorf("%s is required", field)
+	} else if err := json.Unmarshal(raw, &value); err != nil {
+		return nil, fmt.Errorf("%s must be a string", field)
+	}
+
+	var file *DataURL
+	if _, ok := cutPrefixFold(value, "data:"); ok {
+		file, err = t.ParseDataURL(value)
+	} else {
+		var data []byte
+		data, err = decodeBase64(value)
+		file = &DataURL{Params: map[string]string{}, Data: data}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", field, err)
+	}
+
+	if int64(len(file.Data)) > maxSize {
+		return nil, fmt.Errorf("the file in %s is too big; the maximum is %d bytes", field, maxSize)
+	}
+	contentType, err := t.DetectContentType(bytes.NewReader(file.Data))
+	if err != nil {
+		return nil, err
+	}
+	if !t.allowedFileType(contentType) {
+		return nil, fmt.Errorf("the file type %s is not permitted", contentType)
+	}
+	mediaType, params, _ := mime.ParseMediaType(contentType)
+	file.MediaType = mediaType
+	for key, value := range params {
+		file.Params[key] = value
+	}
+	return file, nil
+}
+
+// decodeBase64 decodes standard or URL-safe base64, padded or not, ignoring line breaks and spaces.
+func decodeBase64(s string) ([]byte, error) {
+	s = strings.Map(func(r rune) rune {
+		if r == ' ' || r == '\t' || r == '\r' || r == '\n' {
+			return -1
+		}
+		return r
+	}, s)
+	s = strings.TrimRight(s, "=")
+	if strings.ContainsAny(s, "-_") {
+		if data, err := base64.RawURLEncoding.DecodeString(s); err == nil {
+			return data, nil
+		}
+	} else if data, err := base64.RawStdEncoding.DecodeString(s); err == nil {
+		return data, nil
+	}
+	return nil, errors.New("invalid base64 data")
+}
+
+func cutPrefixFold(s, prefix string) (string, bool) {
+	if len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix) {
+		return s[len(prefix):], true
+	}
+	return s, false
+}
+
+func cutSuffixFold(s, suffix string) (string, bool) {
+	if len(s) >= len(suffix) && strings.EqualFold(s[len(s)-len(suffix):], suffix) {
+		return s[:len(s)-len(suffix)], true
+	}
+	return s, false
+}
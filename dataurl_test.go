@@ -0,0 +1,94 @@
+package gohelpertools
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var dataURLTests = []struct {
+	name          string
+	url           string
+	mediaType     string
+	charset       string
+	data          string
+	errorExpected bool
+}{
+	{name: "base64", url: "data:image/png;base64," + base64.StdEncoding.EncodeToString(pngHeader), mediaType: "image/png", data: string(pngHeader)},
+	{name: "percent-encoded", url: "data:,Hello%2C%20World%21", mediaType: "text/plain", data: "Hello, World!"},
+	{name: "charset", url: "data:text/plain;charset=UTF-8;base64,aMOpbGxv", mediaType: "text/plain", charset: "UTF-8", data: "héllo"},
+	{name: "parameters only", url: "data:;charset=utf-8,x", mediaType: "text/plain", charset: "utf-8", data: "x"},
+	{name: "unpadded with line breaks", url: "DATA:text/plain;BASE64,aGVs\r\nbG8", mediaType: "text/plain", data: "hello"},
+	{name: "not a data URL", url: "https://example.com/a.png", errorExpected: true},
+	{name: "no data", url: "data:text/plain;base64", errorExpected: true},
+	{name: "bad base64", url: "data:text/plain;base64,!!!", errorExpected: true},
+}
+
+func TestTools_ParseDataURL(t *testing.T) {
+	var tools Tools
+	for _, e := range dataURLTests {
+		d, err := tools.ParseDataURL(e.url)
+		if e.errorExpected {
+			if err == nil {
+				t.Errorf("%s: error expected, but none received", e.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: error not expected, but one received: %s", e.name, err)
+			continue
+		}
+		if d.MediaType != e.mediaType || d.Params["charset"] != e.charset || string(d.Data) != e.data {
+			t.Errorf("%s: unexpected result %+v", e.name, d)
+		}
+	}
+}
+
+func TestTools_ReadBase64File(t *testing.T) {
+	png := base64.StdEncoding.EncodeToString(append(pngHeader, "data"...))
+	pdf := base64.URLEncoding.EncodeToString([]byte("%PDF-1.7\n"))
+
+	tests := []struct {
+		name          string
+		body          string
+		mediaType     string
+		errorExpected bool
+	}{
+		{name: "data URL", body: `{"avatar": "data:image/png;base64,` + png + `", "name": "me"}`, mediaType: "image/png"},
+		{name: "plain base64", body: `{"avatar": "` + png + `"}`, mediaType: "image/png"},
+		{name: "disguised", body: `{"avatar": "data:image/png;base64,` + pdf + `"}`, errorExpected: true},
+		{name: "missing", body: `{"name": "me"}`, errorExpected: true},
+		{name: "not a string", body: `{"avatar": 12}`, errorExpected: true},
+		{name: "too big", body: `{"avatar": "` + base64.StdEncoding.EncodeToString(append(pngHeader, strings.Repeat("x", 100)...)) + `"}`, errorExpected: true},
+	}
+
+	tools := Tools{MaxFileSize: 64, AllowedFileTypes: []string{"image/png"}}
+	for _, e := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(e.body))
+		file, err := tools.ReadBase64File("avatar", req)
+		if e.errorExpected {
+			if err == nil {
+				t.Errorf("%s: error expected, but none received", e.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: error not expected, but one received: %s", e.name, err)
+			continue
+		}
+		if file.MediaType != e.mediaType || !strings.HasPrefix(string(file.Data), string(pngHeader)) {
+			t.Errorf("%s: unexpected file %s %q", e.name, file.MediaType, file.Data)
+		}
+
+		// The body can still be read afterwards.
+		var payload struct {
+			Avatar string `json:"avatar"`
+			Name   string `json:"name"`
+		}
+		if err := tools.ReadJSON(httptest.NewRecorder(), req, &payload); err != nil || payload.Avatar == "" {
+			t.Errorf("%s: body not put back: %v", e.name, err)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package gohelpertools
+
+import (
+	"net/http"
+	"time"
+)
+
+// ResponseDecorator adds cross-cutting fields to a response before WriteJSON writes it, typically to its
+// Meta, or headers to w. resp is the JSONResponse envelope being written, or nil when a response is written
+// without one (see Tools.DisableEnvelope and the Tools.WithoutEnvelope middleware), in which case only
+// headers can be added. r is the request, if the handler is wrapped in DecorateResponses, and nil
+// otherwise.
+type ResponseDecorator func(w http.ResponseWriter, r *http.Request, resp *JSONResponse)
+
+// decoratedWriter carries the request, and the time it started, to the ResponseDecorators.
+type decoratedWriter struct {
+	http.ResponseWriter
+	r     *http.Request
+	start time.Time
+}
+
+// Unwrap returns the original http.ResponseWriter, for use by http.ResponseController.
+func (dw *decoratedWriter) Unwrap() http.ResponseWriter {
+	return dw.ResponseWriter
+}
+
+// DecorateResponses is middleware which makes the request available to the Tools ResponseDecorators, and
+// records when it started for DecorateTiming. Wrap it inside RequestID, so that the request it records
+// carries the request ID:
+//
+//	handler := t.RequestID(t.DecorateResponses(mux))
+func (t *Tools) DecorateResponses(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(&decoratedWriter{ResponseWriter: w, r: r, start: time.Now()}, r)
+	})
+}
+
+// findDecoratedWriter returns the decoratedWriter w wraps, if any.
+func findDecoratedWriter(w http.ResponseWriter) *decoratedWriter {
+	for {
+		switch rw := w.(type) {
+		case *decoratedWriter:
+			return rw
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = rw.Unwrap()
+		default:
+			return nil
+		}
+	}
+}
+
+// decorate runs the ResponseDecorators for data, returning a decorated copy if it's a JSONResponse, so the
+// caller's value isn't changed.
+func (t *Tools) decorate(w http.ResponseWriter, data any) any {
+	if len(t.ResponseDecorators) == 0 {
+		return data
+	}
+
+	var r *http.Request
+	if dw := findDecoratedWriter(w); dw != nil {
+		r = dw.r
+	}
+
+	var resp *JSONResponse
+	switch v := data.(type) {
+	case JSONResponse:
+		resp = &v
+	case *JSONResponse:
+		if v != nil {
+			copied := *v
+			resp = &copied
+		}
+	}
+	if resp != nil {
+		meta := make(map[string]any, len(resp.Meta))
+		for key, value := range resp.Meta {
+			meta[key] = value
+		}
+		resp.Meta = meta
+	}
+
+	for _, decorator := range t.ResponseDecorators {
+		decorator(w, r, resp)
+	}
+
+	if resp == nil {
+		return data
+	}
+	if len(resp.Meta) == 0 {
+		resp.Meta = nil
+	}
+	return *resp
+}
+
+// DecorateRequestID adds the request ID, from the RequestID middleware or the X-Request-Id header, to the
+// meta of every response as "request_id".
+func DecorateRequestID(w http.ResponseWriter, r *http.Request, resp *JSONResponse) {
+	if r == nil || resp == nil {
+		return
+	}
+	id := RequestIDFromContext(r.Context())
+	if id == "" {
+		id = r.Header.Get("X-Request-Id")
+	}
+	if id != "" {
+		resp.Meta["request_id"] = id
+	}
+}
+
+// DecorateTiming adds how long the request has taken so far, in milliseconds, to the meta of every
+// response as "duration_ms". It needs the DecorateResponses middleware.
+func DecorateTiming(w http.ResponseWriter, r *http.Request, resp *JSONResponse) {
+	dw := findDecoratedWriter(w)
+	if dw == nil || resp == nil {
+		return
+	}
+	resp.Meta["duration_ms"] = float64(time.Since(dw.start).Microseconds()) / 1000
+}
+
+// DecorateVersion returns a decorator which adds version to the meta of every response as "api_version".
+func DecorateVersion(version string) ResponseDecorator {
+	return func(w http.ResponseWriter, r *http.Request, resp *JSONResponse) {
+		if resp != nil {
+			resp.Meta["api_version"] = version
+		}
+	}
+}
+
+// DecorateDeprecation returns a decorator which marks responses to requests for which deprecated returns
+// true as deprecated: it sets the Deprecation header, and the Sunset header (RFC 8594) if sunset isn't
+// zero, and adds message to the meta as "deprecation". With a nil deprecated, every response is marked.
+func DecorateDeprecation(deprecated func(r *http.Request) bool, message string, sunset time.Time) ResponseDecorator {
+	return func(w http.ResponseWriter, r *http.Request, resp *JSONResponse) {
+		if deprecated != nil && (r == nil || !deprecated(r)) {
+			return
+		}
+		w.Header().Set("Deprecation", "true")
+		if !sunset.IsZero() {
+			w.Header().Set("Sunset", sunset.UTC().Format(http.TimeFormat))
+		}
+		if resp != nil && message != "" {
+			resp.Meta["deprecation"] = message
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package gohelpertools
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTools_ResponseDecorators(t *testing.T) {
+	sunset := time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+	tools := New(WithResponseDecorators(
+		DecorateRequestID,
+		DecorateTiming,
+		DecorateVersion("2026-10-01"),
+		DecorateDeprecation(func(r *http.Request) bool { return r.URL.Path == "/v1" }, "use /v2", sunset),
+	))
+
+	original := &JSONResponse{Message: "ok", Meta: map[string]any{"page": 1}}
+	handler := tools.RequestID(tools.DecorateResponses(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("fail") != "" {
+			_ = tools.ErrorJSON(w, errors.New("failed"))
+			return
+		}
+		_ = tools.WriteJSON(w, http.StatusOK, original)
+	})))
+
+	tests := []struct {
+		target     string
+		deprecated bool
+	}{
+		{"/v1", true},
+		{"/v2", false},
+		{"/v2?fail=1", false},
+	}
+	for _, e := range tests {
+		req := httptest.NewRequest(http.MethodGet, e.target, nil)
+		req.Header.Set("X-Request-Id", "req-123")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		var resp JSONResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.Meta["request_id"] != "req-123" || resp.Meta["api_version"] != "2026-10-01" {
+			t.Errorf("%s: expected the request ID and version in the meta, got %s", e.target, rr.Body.String())
+		}
+		if _, ok := resp.Meta["duration_ms"].(float64); !ok {
+			t.Errorf("%s: expected the duration in the meta, got %s", e.target, rr.Body.String())
+		}
+		if deprecated := rr.Header().Get("Deprecation") == "true"; deprecated != e.deprecated {
+			t.Errorf("%s: expected deprecated to be %v, got headers %v", e.target, e.deprecated, rr.Header())
+		}
+		if e.deprecated && (resp.Meta["deprecation"] != "use /v2" || rr.Header().Get("Sunset") != "Fri, 01 Jan 2027 00:00:00 GMT") {
+			t.Errorf("%s: expected the deprecation message and sunset, got %s %v", e.target, rr.Body.String(), rr.Header())
+		}
+	}
+
+	if len(original.Meta) != 1 {
+		t.Errorf("expected the handler's response not to be changed, got %v", original.Meta)
+	}
+
+	// Without the envelope, only headers can be added.
+	rr := httptest.NewRecorder()
+	raw := &Tools{DisableEnvelope: true, ResponseDecorators: []ResponseDecorator{DecorateDeprecation(nil, "gone soon", time.Time{})}}
+	_ = raw.WriteData(rr, http.StatusOK, "ok", map[string]int{"a": 1})
+	if rr.Body.String() != `{"a":1}` || rr.Header().Get("Deprecation") != "true" {
+		t.Errorf("unexpected raw response %s %v", rr.Body.String(), rr.Header())
+	}
+}
+
+func TestTools_UnwrapJSONWithMeta(t *testing.T) {
+	var tools Tools
+	var data map[string]int
+	if err := tools.UnwrapJSON([]byte(`{"error":false,"message":"ok","data":{"a":1},"meta":{"request_id":"x"}}`), &data); err != nil || data["a"] != 1 {
+		t.Errorf("expected an envelope with meta to be unwrapped, got %v: %v", data, err)
+	}
+}
@@ -0,0 +1,171 @@
+package gohelpertools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultDNSCache is used by ResolveWithCache when Tools.DNSCache is nil.
+var defaultDNSCache = &DNSCache{}
+
+// Resolver performs DNS lookups. *net.Resolver satisfies it; tests can use a StaticResolver instead.
+type Resolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+	LookupMX(ctx context.Context, name string) ([]*net.MX, error)
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+// DNSCache stores host lookups for ResolveWithCache. The zero value is ready to use.
+type DNSCache struct {
+	NegativeTTL time.Duration // how long a failed lookup is remembered; zero means failures aren't cached
+
+	mu      sync.Mutex
+	entries map[string]dnsEntry
+}
+
+// dnsEntry is the cached result of one lookup.
+type dnsEntry struct {
+	addrs   []string
+	err     error
+	expires time.Time
+}
+
+// ResolveWithCache looks up the addresses of host, keeping the answer in the cache for ttl.
+func (t *Tools) ResolveWithCache(ctx context.Context, host string, ttl time.Duration) ([]string, error) {
+	cache := t.DNSCache
+	if cache == nil {
+		cache = defaultDNSCache
+	}
+
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if entry, ok := cache.get(host); ok {
+		return entry.addrs, entry.err
+	}
+
+	addrs, err := t.resolver().LookupHost(ctx, host)
+	if err != nil {
+		// Only failures the server told us about are worth remembering; a timeout might not happen again.
+		var dnsErr *net.DNSError
+		if cache.NegativeTTL > 0 && errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			cache.set(host, dnsEntry{err: err, expires: time.Now().Add(cache.NegativeTTL)})
+		}
+		return nil, err
+	}
+
+	cache.set(host, dnsEntry{addrs: addrs, expires: time.Now().Add(ttl)})
+	return addrs, nil
+}
+
+// LookupMX returns the mail servers for domain, sorted by preference.
+func (t *Tools) LookupMX(ctx context.Context, domain string) ([]*net.MX, error) {
+	return t.resolver().LookupMX(ctx, domain)
+}
+
+// LookupTXT returns the TXT records for name.
+func (t *Tools) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	return t.resolver().LookupTXT(ctx, name)
+}
+
+// CheckEmailDomain reports an error if the domain of email can't receive mail: it must have an MX record
+// or, failing that, an address record (the implicit MX of RFC 5321). A "null MX" record, which is how a
+// domain says it accepts no mail, fails the check.
+func (t *Tools) CheckEmailDomain(ctx context.Context, email string) error {
+	at := strings.LastIndex(email, "@")
+	if at < 0 || at == len(email)-1 {
+		return errors.New("email address has no domain")
+	}
+	domain := email[at+1:]
+
+	records, err := t.LookupMX(ctx, domain)
+	if err == nil && len(records) > 0 {
+		if len(records) == 1 && records[0].Host == "." {
+			return fmt.Errorf("domain %s does not accept email", domain)
+		}
+		return nil
+	}
+
+	var dnsErr *net.DNSError
+	if err != nil && !(errors.As(err, &dnsErr) && dnsErr.IsNotFound) {
+		return err
+	}
+
+	if _, err := t.resolver().LookupHost(ctx, domain); err != nil {
+		return fmt.Errorf("domain %s does not accept email", domain)
+	}
+	return nil
+}
+
+// Delete removes host from the cache, so that the next lookup goes to the resolver.
+func (c *DNSCache) Delete(host string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, strings.ToLower(strings.TrimSuffix(host, ".")))
+}
+
+func (c *DNSCache) get(host string) (dnsEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[host]
+	if !ok || time.Now().After(entry.expires) {
+		return dnsEntry{}, false
+	}
+	return entry, true
+}
+
+func (c *DNSCache) set(host string, entry dnsEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = make(map[string]dnsEntry)
+	}
+	c.entries[host] = entry
+}
+
+func (t *Tools) resolver() Resolver {
+	if t.Resolver != nil {
+		return t.Resolver
+	}
+	return net.DefaultResolver
+}
+
+// StaticResolver is a Resolver which answers from fixed records, for tests. Names it has no records for
+// fail with a "not found" *net.DNSError, as a real resolver would.
+type StaticResolver struct {
+	Hosts map[string][]string
+	MX    map[string][]*net.MX
+	TXT   map[string][]string
+}
+
+// LookupHost returns the addresses for host.
+func (s StaticResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	if addrs, ok := s.Hosts[host]; ok {
+		return addrs, nil
+	}
+	return nil, notFound(host)
+}
+
+// LookupMX returns the mail servers for name.
+func (s StaticResolver) LookupMX(ctx context.Context, name string) ([]*net.MX, error) {
+	if records, ok := s.MX[name]; ok {
+		return records, nil
+	}
+	return nil, notFound(name)
+}
+
+// LookupTXT returns the TXT records for name.
+func (s StaticResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	if records, ok := s.TXT[name]; ok {
+		return records, nil
+	}
+	return nil, notFound(name)
+}
+
+func notFound(name string) error {
+	return &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+}
@@ -0,0 +1,81 @@
+package gohelpertools
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+// countingResolver wraps a StaticResolver and counts host lookups.
+type countingResolver struct {
+	StaticResolver
+	lookups int
+}
+
+func (c *countingResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	c.lookups++
+	return c.StaticResolver.LookupHost(ctx, host)
+}
+
+func TestTools_ResolveWithCache(t *testing.T) {
+	resolver := &countingResolver{StaticResolver: StaticResolver{Hosts: map[string][]string{"example.com": {"192.0.2.1"}}}}
+	tools := Tools{Resolver: resolver, DNSCache: &DNSCache{NegativeTTL: time.Minute}}
+
+	for i := 0; i < 3; i++ {
+		addrs, err := tools.ResolveWithCache(context.Background(), "Example.com.", time.Minute)
+		if err != nil || len(addrs) != 1 || addrs[0] != "192.0.2.1" {
+			t.Fatalf("unexpected result %v, %v", addrs, err)
+		}
+	}
+	if resolver.lookups != 1 {
+		t.Errorf("expected 1 lookup, but got %d", resolver.lookups)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := tools.ResolveWithCache(context.Background(), "missing.example", time.Minute); err == nil {
+			t.Error("missing host: error expected, but none received")
+		}
+	}
+	if resolver.lookups != 2 {
+		t.Errorf("expected failed lookup to be cached, but got %d lookups", resolver.lookups)
+	}
+
+	tools.DNSCache.Delete("example.com")
+	_, _ = tools.ResolveWithCache(context.Background(), "example.com", time.Minute)
+	if resolver.lookups != 3 {
+		t.Errorf("expected lookup after Delete, but got %d lookups", resolver.lookups)
+	}
+}
+
+var emailDomainTests = []struct {
+	name          string
+	email         string
+	errorExpected bool
+}{
+	{name: "mx", email: "jane@mail.example", errorExpected: false},
+	{name: "implicit mx", email: "jane@host.example", errorExpected: false},
+	{name: "null mx", email: "jane@nomail.example", errorExpected: true},
+	{name: "unknown domain", email: "jane@missing.example", errorExpected: true},
+	{name: "no domain", email: "jane@", errorExpected: true},
+}
+
+func TestTools_CheckEmailDomain(t *testing.T) {
+	tools := Tools{Resolver: StaticResolver{
+		Hosts: map[string][]string{"host.example": {"192.0.2.1"}},
+		MX: map[string][]*net.MX{
+			"mail.example":   {{Host: "mx.mail.example.", Pref: 10}},
+			"nomail.example": {{Host: ".", Pref: 0}},
+		},
+	}}
+
+	for _, e := range emailDomainTests {
+		err := tools.CheckEmailDomain(context.Background(), e.email)
+		if e.errorExpected && err == nil {
+			t.Errorf("%s: error expected, but none received", e.name)
+		}
+		if !e.errorExpected && err != nil {
+			t.Errorf("%s: error not expected, but one received: %s", e.name, err)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package gohelpertools
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+var docxPlaceholderRegex = regexp.MustCompile(`\{\{\s*([\w.\-]+)\s*\}\}`)
+var docxPartRegex = regexp.MustCompile(`^word/(document|header\d*|footer\d*|footnotes|endnotes)\.xml$`)
+
+// FillDocx reads a DOCX template of the given size from template, replaces {{placeholder}} tags in the
+// document body, headers, footers and notes with the matching values in data, and writes the resulting
+// document to w. Word often splits a placeholder over several runs when it is edited; this is handled, and
+// the formatting of the run where the placeholder starts is kept. Placeholders without a value in data are
+// left as they are.
+func (t *Tools) FillDocx(w io.Writer, template io.ReaderAt, size int64, data map[string]string) error {
+	zr, err := zip.NewReader(template, size)
+	if err != nil {
+		return fmt.Errorf("template is not a valid docx file: %w", err)
+	}
+
+	zw := zip.NewWriter(w)
+	for _, f := range zr.File {
+		if !docxPartRegex.MatchString(f.Name) {
+			// Everything else (styles, images, relationships) is copied across untouched.
+			if err := zw.Copy(f); err != nil {
+				return err
+			}
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+
+		out, err := zw.CreateHeader(&zip.FileHeader{Name: f.Name, Method: zip.Deflate, Modified: f.Modified})
+		if err != nil {
+			return err
+		}
+		if _, err := out.Write(fillDocxXML(content, data)); err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+// WriteDocx fills a DOCX template with FillDocx and streams the result to the client as an attachment
+// named fileName.
+func (t *Tools) WriteDocx(w http.ResponseWriter, template io.ReaderAt, size int64, data map[string]string, fileName string) error {
+	w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.wordprocessingml.document")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName))
+	return t.FillDocx(w, template, size, data)
+}
+
+// docxSegment is a piece of WordprocessingML: either a tag, or the text between two tags.
+type docxSegment struct {
+	text  string
+	isTag bool
+}
+
+// fillDocxXML replaces placeholders in the text of an XML part. The text outside of tags is joined
+// together, so that placeholders split across runs can be found, and the replacement is then mapped back
+// onto the individual text segments, leaving every tag in place so the XML stays well-formed.
+func fillDocxXML(content []byte, data map[string]string) []byte {
+	var segments []docxSegment
+	for s := string(content); len(s) > 0; {
+		if s[0] == '<' {
+			end := strings.IndexByte(s, '>')
+			if end < 0 {
+				end = len(s) - 1
+			}
+			segments = append(segments, docxSegment{text: s[:end+1], isTag: true})
+			s = s[end+1:]
+			continue
+		}
+
+		end := strings.IndexByte(s, '<')
+		if end < 0 {
+			end = len(s)
+		}
+		segments = append(segments, docxSegment{text: s[:end]})
+		s = s[end:]
+	}
+
+	// Build the plain text, remembering which segment each byte came from.
+	var text strings.Builder
+	var owner, offset []int
+	for i, seg := range segments {
+		if seg.isTag {
+			continue
+		}
+		for j := 0; j < len(seg.text); j++ {
+			owner = append(owner, i)
+			offset = append(offset, j)
+		}
+		text.WriteString(seg.text)
+	}
+
+	matches := docxPlaceholderRegex.FindAllStringSubmatchIndex(text.String(), -1)
+
+	// Work backwards, so that earlier offsets stay valid as segments change length.
+	for m := len(matches) - 1; m >= 0; m-- {
+		start, end := matches[m][0], matches[m][1]
+		value, ok := data[text.String()[matches[m][2]:matches[m][3]]]
+		if !ok {
+			continue
+		}
+
+		var escaped bytes.Buffer
+		_ = xml.EscapeText(&escaped, []byte(value))
+
+		first, last := owner[start], owner[end-1]
+		for i := last; i >= first; i-- {
+			if segments[i].isTag {
+				continue
+			}
+
+			from, to := 0, len(segments[i].text)
+			if i == first {
+				from = offset[start]
+			}
+			if i == last {
+				to = offset[end-1] + 1
+			}
+
+			replacement := ""
+			if i == first {
+				replacement = escaped.String()
+			}
+			segments[i].text = segments[i].text[:from] + replacement + segments[i].text[to:]
+		}
+	}
+
+	var out bytes.Buffer
+	for _, seg := range segments {
+		out.WriteString(seg.text)
+	}
+	return out.Bytes()
+}
@@ -0,0 +1,91 @@
+package gohelpertools
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"net/http/httptest"
+	"testing"
+)
+
+// buildTestDocx returns a zip archive containing the given files.
+func buildTestDocx(files map[string]string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		f, _ := zw.Create(name)
+		_, _ = f.Write([]byte(content))
+	}
+	_ = zw.Close()
+	return buf.Bytes()
+}
+
+// readZipFile returns the contents of name in the zip archive data.
+func readZipFile(t *testing.T, data []byte, name string) string {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rc, err := zr.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	content, _ := io.ReadAll(rc)
+	return string(content)
+}
+
+var fillDocxTests = []struct {
+	name     string
+	xml      string
+	expected string
+}{
+	{name: "simple", xml: `<w:p><w:r><w:t>Dear {{name}},</w:t></w:r></w:p>`, expected: `<w:p><w:r><w:t>Dear Jane &amp; John,</w:t></w:r></w:p>`},
+	{name: "split across runs", xml: `<w:r><w:t>{{</w:t></w:r><w:r><w:t>na</w:t></w:r><w:r><w:t>me}} ok</w:t></w:r>`, expected: `<w:r><w:t>Jane &amp; John</w:t></w:r><w:r><w:t></w:t></w:r><w:r><w:t> ok</w:t></w:r>`},
+	{name: "missing value", xml: `<w:t>{{unknown}} {{ name }}</w:t>`, expected: `<w:t>{{unknown}} Jane &amp; John</w:t>`},
+}
+
+func TestTools_FillDocx(t *testing.T) {
+	var testTools Tools
+
+	for _, e := range fillDocxTests {
+		template := buildTestDocx(map[string]string{
+			"word/document.xml": e.xml,
+			"word/styles.xml":   "{{name}}",
+		})
+
+		var out bytes.Buffer
+		err := testTools.FillDocx(&out, bytes.NewReader(template), int64(len(template)), map[string]string{"name": "Jane & John"})
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", e.name, err)
+			continue
+		}
+
+		if doc := readZipFile(t, out.Bytes(), "word/document.xml"); doc != e.expected {
+			t.Errorf("%s: wrong document; expected %s but got %s", e.name, e.expected, doc)
+		}
+		if styles := readZipFile(t, out.Bytes(), "word/styles.xml"); styles != "{{name}}" {
+			t.Errorf("%s: parts other than the document should not be changed", e.name)
+		}
+	}
+}
+
+func TestTools_WriteDocx(t *testing.T) {
+	var testTools Tools
+
+	template := buildTestDocx(map[string]string{"word/document.xml": "<w:t>{{a}}</w:t>"})
+	rr := httptest.NewRecorder()
+	err := testTools.WriteDocx(rr, bytes.NewReader(template), int64(len(template)), map[string]string{"a": "b"}, "letter.docx")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rr.Header().Get("Content-Disposition") != `attachment; filename="letter.docx"` {
+		t.Errorf("wrong content disposition: %s", rr.Header().Get("Content-Disposition"))
+	}
+
+	err = testTools.WriteDocx(httptest.NewRecorder(), bytes.NewReader([]byte("nope")), 4, nil, "x.docx")
+	if err == nil {
+		t.Error("expected error for an invalid template, but none received")
+	}
+}
@@ -0,0 +1,240 @@
+package gohelpertools
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// VerificationMethod is a way of proving ownership of a domain.
+type VerificationMethod string
+
+const (
+	VerifyDNS  VerificationMethod = "dns"  // a TXT record on a subdomain of the domain
+	VerifyFile VerificationMethod = "file" // a file under /.well-known/ on the domain's website
+	VerifyMeta VerificationMethod = "meta" // a meta tag on the domain's home page
+)
+
+// defaultVerificationSchedule is how long to wait before each check, for DomainVerifier.NextCheck.
+var defaultVerificationSchedule = []time.Duration{
+	time.Minute, 5 * time.Minute, 15 * time.Minute, time.Hour, 6 * time.Hour, 24 * time.Hour,
+}
+
+var metaTagRegex = regexp.MustCompile(`(?is)<meta\s[^>]*>`)
+var metaAttrRegex = regexp.MustCompile(`(?is)(name|content)\s*=\s*("[^"]*"|'[^']*'|[^\s>]+)`)
+
+// DomainVerifier issues verification tokens for domains and checks that the owner has published them,
+// which is the usual onboarding step for custom domains. Tokens are derived from the domain and account
+// with an HMAC, so nothing needs to be stored between issuing and checking them.
+type DomainVerifier struct {
+	Secret   []byte          // key used to derive tokens; required
+	Name     string          // identifies the verification; defaults to "domain-verification"
+	Schedule []time.Duration // delays between checks, used by NextCheck; defaults to 1m, 5m, 15m, 1h, 6h, 24h
+	Scheme   string          // scheme used for the file and meta methods; defaults to "https"
+	Client   *http.Client    // used for the file and meta methods; Tools.PublicHTTPClient is used if nil
+	Tools    *Tools          // provides the DNS resolver and HTTP client; a zero Tools is used if nil
+}
+
+// DomainVerification describes what a domain owner needs to publish for each method.
+type DomainVerification struct {
+	Domain    string `json:"domain"`
+	Account   string `json:"account"`    // the account the domain is being verified for
+	Token     string `json:"token"`      // the value which must be published
+	DNSRecord string `json:"dns_record"` // name of the TXT record whose value must be Token
+	FileURL   string `json:"file_url"`   // URL of the file whose content must be Token
+	MetaTag   string `json:"meta_tag"`   // tag to add to the head of the home page
+	HomePage  string `json:"home_page"`  // URL of the page which must carry the meta tag
+}
+
+// Issue returns the token for account to verify domain, and the instructions for publishing it. The domain
+// must be a DNS host name, not an IP address, since the file and meta methods fetch from it.
+func (v *DomainVerifier) Issue(domain, account string) (DomainVerification, error) {
+	if len(v.Secret) == 0 {
+		return DomainVerification{}, errors.New("domain verification requires a secret")
+	}
+
+	domain = normalizeDomain(domain)
+	if domain == "" {
+		return DomainVerification{}, errors.New("domain is required")
+	}
+	if !validHostname(domain) {
+		return DomainVerification{}, fmt.Errorf("%q is not a valid domain name", domain)
+	}
+
+	token := v.Token(domain, account)
+	name := v.name()
+	return DomainVerification{
+		Domain:    domain,
+		Account:   account,
+		Token:     token,
+		DNSRecord: "_" + name + "." + domain,
+		FileURL:   v.scheme() + "://" + domain + "/.well-known/" + name + ".txt",
+		MetaTag:   fmt.Sprintf(`<meta name="%s" content="%s">`, name, token),
+		HomePage:  v.scheme() + "://" + domain + "/",
+	}, nil
+}
+
+// Token returns the verification token for account and domain.
+func (v *DomainVerifier) Token(domain, account string) string {
+	mac := hmac.New(sha256.New, v.Secret)
+	mac.Write([]byte(normalizeDomain(domain) + "\x00" + account))
+	return v.name() + "-" + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:20])
+}
+
+// Check looks for the token for account on domain using method, and returns nil if it is found.
+func (v *DomainVerifier) Check(ctx context.Context, domain, account string, method VerificationMethod) error {
+	instructions, err := v.Issue(domain, account)
+	if err != nil {
+		return err
+	}
+
+	switch method {
+	case VerifyDNS:
+		records, err := toolsOrDefault(v.Tools).LookupTXT(ctx, instructions.DNSRecord)
+		if err != nil {
+			return fmt.Errorf("unable to look up %s: %w", instructions.DNSRecord, err)
+		}
+		for _, record := range records {
+			if hmac.Equal([]byte(strings.TrimSpace(record)), []byte(instructions.Token)) {
+				return nil
+			}
+		}
+		return fmt.Errorf("no TXT record on %s contains the verification token", instructions.DNSRecord)
+
+	case VerifyFile:
+		body, err := v.fetch(ctx, instructions.FileURL)
+		if err != nil {
+			return err
+		}
+		if !hmac.Equal([]byte(strings.TrimSpace(body)), []byte(instructions.Token)) {
+			return fmt.Errorf("%s does not contain the verification token", instructions.FileURL)
+		}
+		return nil
+
+	case VerifyMeta:
+		body, err := v.fetch(ctx, instructions.HomePage)
+		if err != nil {
+			return err
+		}
+		for _, tag := range metaTagRegex.FindAllString(body, -1) {
+			attrs := make(map[string]string)
+			for _, m := range metaAttrRegex.FindAllStringSubmatch(tag, -1) {
+				attrs[strings.ToLower(m[1])] = html.UnescapeString(strings.Trim(m[2], `"'`))
+			}
+			if attrs["name"] == v.name() && hmac.Equal([]byte(attrs["content"]), []byte(instructions.Token)) {
+				return nil
+			}
+		}
+		return fmt.Errorf("%s has no meta tag with the verification token", instructions.HomePage)
+
+	default:
+		return fmt.Errorf("unknown verification method %q", method)
+	}
+}
+
+// CheckAny tries every method in turn, and returns the first one which succeeds. If none do, the error
+// from each method is returned.
+func (v *DomainVerifier) CheckAny(ctx context.Context, domain, account string) (VerificationMethod, error) {
+	var errs []string
+	for _, method := range []VerificationMethod{VerifyDNS, VerifyFile, VerifyMeta} {
+		err := v.Check(ctx, domain, account, method)
+		if err == nil {
+			return method, nil
+		}
+		errs = append(errs, fmt.Sprintf("%s: %s", method, err))
+	}
+	return "", errors.New(strings.Join(errs, "; "))
+}
+
+// NextCheck returns how long to wait before check number attempt (counting from zero), so that a job
+// queue or scheduler can retry verification while DNS changes propagate. The second return value is false
+// once the schedule is exhausted and verification should be abandoned.
+func (v *DomainVerifier) NextCheck(attempt int) (time.Duration, bool) {
+	schedule := v.Schedule
+	if schedule == nil {
+		schedule = defaultVerificationSchedule
+	}
+	if attempt < 0 || attempt >= len(schedule) {
+		return 0, false
+	}
+	return schedule[attempt], true
+}
+
+// fetch returns up to 1MB of the body at uri, which must be served with status 200. Since the domain is
+// chosen by the user, the default client only connects to public addresses, including after redirects.
+func (v *DomainVerifier) fetch(ctx context.Context, uri string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return "", err
+	}
+
+	client := v.Client
+	if client == nil {
+		client = toolsOrDefault(v.Tools).PublicHTTPClient()
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("fetching %s returned status %d", uri, resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", err
+	}
+	return string(body), nil
+}
+
+func (v *DomainVerifier) name() string {
+	return valueOrDefault(v.Name, "domain-verification")
+}
+
+func (v *DomainVerifier) scheme() string {
+	return valueOrDefault(v.Scheme, "https")
+}
+
+// normalizeDomain lower-cases domain and removes any trailing dot.
+func normalizeDomain(domain string) string {
+	return strings.ToLower(strings.TrimSuffix(strings.TrimSpace(domain), "."))
+}
+
+// validHostname reports whether domain, already normalised, is a DNS host name with at least two labels of
+// letters, digits and hyphens, such as example.com. IP addresses, ports and single labels like localhost
+// are rejected.
+func validHostname(domain string) bool {
+	if len(domain) > 253 {
+		return false
+	}
+	labels := strings.Split(domain, ".")
+	if len(labels) < 2 {
+		return false
+	}
+	for _, label := range labels {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for i := 0; i < len(label); i++ {
+			c := label[i]
+			if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '-' {
+				return false
+			}
+		}
+	}
+	// A numeric top-level label means an IPv4 address, or something which resolvers may treat as one.
+	tld := labels[len(labels)-1]
+	return strings.Trim(tld, "0123456789") != ""
+}
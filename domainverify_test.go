@@ -0,0 +1,114 @@
+package gohelpertools
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDomainVerifier_Check(t *testing.T) {
+	verifier := &DomainVerifier{Secret: []byte("secret"), Scheme: "http"}
+
+	var page, file string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			_, _ = w.Write([]byte(page))
+		case "/.well-known/domain-verification.txt":
+			if file == "" {
+				http.NotFound(w, r)
+				return
+			}
+			_, _ = w.Write([]byte(file))
+		}
+	}))
+	defer srv.Close()
+
+	// The test server is on a loopback address, which the default client refuses, so connect to it directly.
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = func(ctx context.Context, network, _ string) (net.Conn, error) {
+		return (&net.Dialer{}).DialContext(ctx, network, srv.Listener.Addr().String())
+	}
+	verifier.Client = &http.Client{Transport: transport}
+
+	domain := "shop.example.com"
+	instructions, err := verifier.Issue(domain, "acct-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verifier.Tools = &Tools{Resolver: StaticResolver{TXT: map[string][]string{instructions.DNSRecord: {"other", instructions.Token}}}}
+	if err := verifier.Check(context.Background(), domain, "acct-1", VerifyDNS); err != nil {
+		t.Errorf("dns: error not expected, but one received: %s", err)
+	}
+	if err := verifier.Check(context.Background(), domain, "acct-2", VerifyDNS); err == nil {
+		t.Error("dns for another account: error expected, but none received")
+	}
+
+	if err := verifier.Check(context.Background(), domain, "acct-1", VerifyFile); err == nil {
+		t.Error("missing file: error expected, but none received")
+	}
+	file = instructions.Token + "\n"
+	if err := verifier.Check(context.Background(), domain, "acct-1", VerifyFile); err != nil {
+		t.Errorf("file: error not expected, but one received: %s", err)
+	}
+
+	page = `<html><head><meta charset="utf-8"><meta content='` + instructions.Token + `' name="domain-verification"></head></html>`
+	if err := verifier.Check(context.Background(), domain, "acct-1", VerifyMeta); err != nil {
+		t.Errorf("meta: error not expected, but one received: %s", err)
+	}
+
+	verifier.Tools = &Tools{Resolver: StaticResolver{}}
+	file = ""
+	page = ""
+	if method, err := verifier.CheckAny(context.Background(), domain+".", "acct-1"); err == nil {
+		t.Errorf("expected every method to fail, but %s succeeded", method)
+	}
+}
+
+func TestDomainVerifier_Issue(t *testing.T) {
+	verifier := &DomainVerifier{Secret: []byte("secret")}
+	tests := []struct {
+		domain        string
+		errorExpected bool
+	}{
+		{domain: "Example.COM."},
+		{domain: "xn--bcher-kva.example"},
+		{domain: "", errorExpected: true},
+		{domain: "localhost", errorExpected: true},
+		{domain: "127.0.0.1", errorExpected: true},
+		{domain: "169.254.169.254", errorExpected: true},
+		{domain: "[::1]", errorExpected: true},
+		{domain: "example.com:8080", errorExpected: true},
+		{domain: "example.com/path", errorExpected: true},
+		{domain: "user@example.com", errorExpected: true},
+		{domain: "-bad.example.com", errorExpected: true},
+		{domain: "a..example.com", errorExpected: true},
+	}
+	for _, e := range tests {
+		_, err := verifier.Issue(e.domain, "acct-1")
+		if e.errorExpected && err == nil {
+			t.Errorf("%q: error expected, but none received", e.domain)
+		}
+		if !e.errorExpected && err != nil {
+			t.Errorf("%q: error not expected, but one received: %s", e.domain, err)
+		}
+	}
+
+	// The default client refuses to fetch from addresses which aren't public.
+	if _, err := verifier.fetch(context.Background(), "http://127.0.0.1/"); err == nil {
+		t.Error("expected a loopback fetch to be refused")
+	}
+}
+
+func TestDomainVerifier_NextCheck(t *testing.T) {
+	verifier := &DomainVerifier{}
+	if delay, ok := verifier.NextCheck(0); !ok || delay <= 0 {
+		t.Errorf("expected a delay for the first check, got %s", delay)
+	}
+	if _, ok := verifier.NextCheck(len(defaultVerificationSchedule)); ok {
+		t.Error("expected schedule to be exhausted")
+	}
+}
@@ -0,0 +1,189 @@
+package gohelpertools
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const defaultDownloadGrantTTL = 5 * time.Minute
+const defaultDownloadGrantCookie = "download_grant"
+
+// DownloadGrants issues and verifies short-lived, signed tokens which authorise one user to download one
+// file. Grants use the double-submit pattern: the token is set as a cookie when it is issued, and must also
+// be sent back in the "grant" query parameter of the download URL, so a leaked link alone is not enough.
+// Each grant has its own cookie, named after the grant's ID, so a user can hold several grants at once.
+type DownloadGrants struct {
+	Secret     []byte              // key used to sign grants; required
+	TTL        time.Duration       // how long a grant is valid; defaults to 5 minutes
+	CookieName string              // prefix of the double-submit cookie names; defaults to "download_grant"
+	Audit      func(DownloadEvent) // if set, called once for every download attempt, allowed or not
+	Tools      *Tools              // used to write JSON error responses and find the client's IP; a zero Tools is used if nil
+}
+
+// DownloadEvent is the audit record produced for each download attempt.
+type DownloadEvent struct {
+	UserID     string    `json:"user_id"`
+	File       string    `json:"file"`
+	RemoteAddr string    `json:"remote_addr"`
+	Time       time.Time `json:"time"`
+	Allowed    bool      `json:"allowed"`
+	Reason     string    `json:"reason,omitempty"`
+}
+
+// downloadGrant is the signed payload of a grant token.
+type downloadGrant struct {
+	ID      string `json:"i"`
+	UserID  string `json:"u"`
+	File    string `json:"f"`
+	Expires int64  `json:"e"`
+}
+
+// Issue creates a grant for userID to download file, sets the double-submit cookie on w, and returns the
+// token, which should be added to the download URL as the "grant" query parameter.
+func (g *DownloadGrants) Issue(w http.ResponseWriter, userID, file string) (string, error) {
+	if len(g.Secret) == 0 {
+		return "", errors.New("download grants require a secret")
+	}
+
+	ttl := g.TTL
+	if ttl == 0 {
+		ttl = defaultDownloadGrantTTL
+	}
+
+	id, err := toolsOrDefault(g.Tools).RandomBase64URL(12)
+	if err != nil {
+		return "", err
+	}
+
+	payload, err := json.Marshal(downloadGrant{ID: id, UserID: userID, File: file, Expires: time.Now().Add(ttl).Unix()})
+	if err != nil {
+		return "", err
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	token := encoded + "." + base64.RawURLEncoding.EncodeToString(g.sign(encoded))
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     g.cookieName(id),
+		Value:    token,
+		Path:     "/",
+		MaxAge:   int(ttl.Seconds()),
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteStrictMode,
+	})
+
+	return token, nil
+}
+
+// Verify checks that r carries a valid grant for userID to download file, in both the "grant" query
+// parameter and the grant's double-submit cookie.
+func (g *DownloadGrants) Verify(r *http.Request, userID, file string) error {
+	if len(g.Secret) == 0 {
+		return errors.New("download grants require a secret")
+	}
+
+	token := r.URL.Query().Get("grant")
+	if token == "" {
+		return errors.New("download grant is missing")
+	}
+
+	encoded, signature, found := strings.Cut(token, ".")
+	if !found {
+		return errors.New("download grant is malformed")
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(sig, g.sign(encoded)) {
+		return errors.New("download grant has an invalid signature")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return errors.New("download grant is malformed")
+	}
+
+	var grant downloadGrant
+	if err := json.Unmarshal(payload, &grant); err != nil {
+		return errors.New("download grant is malformed")
+	}
+
+	// The cookie is looked up by the ID in the signed token, so it can only be checked once the token is.
+	cookie, err := r.Cookie(g.cookieName(grant.ID))
+	if err != nil || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(token)) != 1 {
+		return errors.New("download grant does not match cookie")
+	}
+
+	switch {
+	case time.Now().Unix() > grant.Expires:
+		return errors.New("download grant has expired")
+	case grant.UserID != userID:
+		return errors.New("download grant was issued to a different user")
+	case grant.File != file:
+		return errors.New("download grant was issued for a different file")
+	}
+
+	return nil
+}
+
+// Protect returns middleware which only lets a request through to the wrapped download handler if it
+// carries a valid grant. The userID and file functions identify the current user and the requested file.
+// Rejected requests receive a 403 JSON response, and every attempt is passed to Audit, with the client's
+// address as given by Tools.RealIP.
+func (g *DownloadGrants) Protect(userID, file func(r *http.Request) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			event := DownloadEvent{
+				UserID:     userID(r),
+				File:       file(r),
+				RemoteAddr: toolsOrDefault(g.Tools).RealIP(r),
+				Time:       time.Now(),
+			}
+
+			err := g.Verify(r, event.UserID, event.File)
+			if err != nil {
+				event.Reason = err.Error()
+			} else {
+				event.Allowed = true
+			}
+
+			if g.Audit != nil {
+				g.Audit(event)
+			}
+
+			if err != nil {
+				_ = toolsOrDefault(g.Tools).ErrorJSON(w, err, http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func (g *DownloadGrants) sign(s string) []byte {
+	mac := hmac.New(sha256.New, g.Secret)
+	mac.Write([]byte(s))
+	return mac.Sum(nil)
+}
+
+// cookieName returns the name of the double-submit cookie for the grant with the given ID.
+func (g *DownloadGrants) cookieName(id string) string {
+	return valueOrDefault(g.CookieName, defaultDownloadGrantCookie) + "_" + id
+}
+
+// toolsOrDefault returns t, or a zero Tools if t is nil, so that components holding an optional *Tools
+// can always write responses.
+func toolsOrDefault(t *Tools) *Tools {
+	if t == nil {
+		return &Tools{}
+	}
+	return t
+}
@@ -0,0 +1,140 @@
+package gohelpertools
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var downloadGrantTests = []struct {
+	name     string
+	user     string
+	file     string
+	ttl      time.Duration
+	tamper   bool
+	noCookie bool
+	allowed  bool
+}{
+	{name: "valid grant", user: "alice", file: "/files/report.pdf", allowed: true},
+	{name: "wrong user", user: "bob", file: "/files/report.pdf", allowed: false},
+	{name: "wrong file", user: "alice", file: "/files/other.pdf", allowed: false},
+	{name: "expired grant", user: "alice", file: "/files/report.pdf", ttl: -time.Minute, allowed: false},
+	{name: "tampered token", user: "alice", file: "/files/report.pdf", tamper: true, allowed: false},
+	{name: "missing cookie", user: "alice", file: "/files/report.pdf", noCookie: true, allowed: false},
+}
+
+func TestDownloadGrants_Protect(t *testing.T) {
+	for _, e := range downloadGrantTests {
+		var events []DownloadEvent
+		grants := DownloadGrants{
+			Secret: []byte("secret"),
+			TTL:    e.ttl,
+			Audit:  func(ev DownloadEvent) { events = append(events, ev) },
+		}
+
+		issued := httptest.NewRecorder()
+		token, err := grants.Issue(issued, "alice", "/files/report.pdf")
+		if err != nil {
+			t.Fatalf("%s: unexpected error issuing grant: %s", e.name, err)
+		}
+		if e.tamper {
+			token += "x"
+		}
+
+		req, _ := http.NewRequest("GET", e.file+"?grant="+token, nil)
+		if !e.noCookie {
+			req.AddCookie(&http.Cookie{Name: issued.Result().Cookies()[0].Name, Value: token})
+		}
+
+		handler := grants.Protect(
+			func(r *http.Request) string { return e.user },
+			func(r *http.Request) string { return r.URL.Path },
+		)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		if e.allowed && rr.Code != http.StatusOK {
+			t.Errorf("%s: expected download to be allowed, but got status %d", e.name, rr.Code)
+		}
+		if !e.allowed && rr.Code != http.StatusForbidden {
+			t.Errorf("%s: expected download to be forbidden, but got status %d", e.name, rr.Code)
+		}
+		if len(events) != 1 || events[0].Allowed != e.allowed {
+			t.Errorf("%s: expected exactly one audit event with allowed=%t, got %+v", e.name, e.allowed, events)
+		}
+	}
+}
+
+func TestDownloadGrants_IssueWithoutSecret(t *testing.T) {
+	var grants DownloadGrants
+
+	_, err := grants.Issue(httptest.NewRecorder(), "alice", "file")
+	if err == nil {
+		t.Error("expected error issuing a grant without a secret, but none received")
+	}
+
+	// A grant signed with an empty key must not be accepted either.
+	forger := DownloadGrants{Secret: []byte("x")}
+	token, _ := forger.Issue(httptest.NewRecorder(), "alice", "file")
+	req, _ := http.NewRequest("GET", "/file?grant="+token, nil)
+	if err := grants.Verify(req, "alice", "file"); err == nil {
+		t.Error("expected error verifying a grant without a secret, but none received")
+	}
+}
+
+func TestDownloadGrants_AuditRealIP(t *testing.T) {
+	var events []DownloadEvent
+	grants := DownloadGrants{
+		Secret: []byte("secret"),
+		Audit:  func(ev DownloadEvent) { events = append(events, ev) },
+		Tools:  &Tools{TrustedProxies: []string{"10.0.0.1"}},
+	}
+
+	req := httptest.NewRequest("GET", "/file", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("X-Forwarded-For", "203.0.113.7")
+
+	handler := grants.Protect(
+		func(r *http.Request) string { return "alice" },
+		func(r *http.Request) string { return r.URL.Path },
+	)(http.NotFoundHandler())
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if len(events) != 1 || events[0].RemoteAddr != "203.0.113.7" {
+		t.Errorf("expected the audit event to record the client's real IP, got %+v", events)
+	}
+}
+
+func TestDownloadGrants_SeveralGrants(t *testing.T) {
+	grants := DownloadGrants{Secret: []byte("secret")}
+
+	// A second grant must not replace the first one's cookie.
+	issued := httptest.NewRecorder()
+	first, err := grants.Issue(issued, "alice", "/files/a.pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := grants.Issue(issued, "alice", "/files/b.pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cookies := issued.Result().Cookies()
+	if len(cookies) != 2 || cookies[0].Name == cookies[1].Name {
+		t.Fatalf("expected two cookies with different names, got %+v", cookies)
+	}
+
+	for file, token := range map[string]string{"/files/a.pdf": first, "/files/b.pdf": second} {
+		req, _ := http.NewRequest("GET", file+"?grant="+token, nil)
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		if err := grants.Verify(req, "alice", file); err != nil {
+			t.Errorf("%s: error not expected, but one received: %s", file, err)
+		}
+	}
+}
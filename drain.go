@@ -0,0 +1,125 @@
+package gohelpertools
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DrainNotice is the data of the event sent to streaming clients when the server shuts down.
+type DrainNotice struct {
+	Type             string `json:"type"`               // always "server_going_away"
+	ReconnectAfterMS int64  `json:"reconnect_after_ms"` // how long the client should wait before reconnecting
+}
+
+// Drainer tells clients to go elsewhere when the server is shutting down. Once Drain is called, every
+// Stream created with this Drainer is sent a "server going away" event and closed, long-poll handlers
+// watching Draining return early, and responses passing through Middleware carry "Connection: close", so
+// that clients promptly reconnect to a healthy instance instead of timing out. (For HTTP/2, the GOAWAY
+// frame is sent by http.Server.Shutdown itself.)
+type Drainer struct {
+	Event      string        // SSE event name of the notice; defaults to "server-going-away"
+	RetryAfter time.Duration // suggested reconnection delay sent to clients; defaults to 1 second
+
+	once     sync.Once
+	mu       sync.Mutex
+	draining chan struct{}
+	streams  map[*Stream]struct{}
+}
+
+// RegisterOnShutdown arranges for Drain to be called when srv.Shutdown is called.
+func (d *Drainer) RegisterOnShutdown(srv *http.Server) {
+	srv.RegisterOnShutdown(d.Drain)
+}
+
+// Drain notifies and closes every tracked stream, and closes the Draining channel. It is safe to call more
+// than once.
+func (d *Drainer) Drain() {
+	d.init()
+
+	d.mu.Lock()
+	select {
+	case <-d.draining:
+		d.mu.Unlock()
+		return
+	default:
+	}
+	close(d.draining)
+	streams := d.streams
+	d.streams = nil
+	d.mu.Unlock()
+
+	for stream := range streams {
+		stream.goAway(d.notice())
+	}
+}
+
+// Draining returns a channel which is closed once Drain has been called. Long-poll handlers should select
+// on it, and respond (for example with 503 and a Retry-After header) when it closes.
+func (d *Drainer) Draining() <-chan struct{} {
+	d.init()
+	return d.draining
+}
+
+// IsDraining reports whether Drain has been called.
+func (d *Drainer) IsDraining() bool {
+	select {
+	case <-d.Draining():
+		return true
+	default:
+		return false
+	}
+}
+
+// Middleware sets "Connection: close" on responses once the server is draining, so HTTP/1.1 clients
+// don't reuse the connection.
+func (d *Drainer) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if d.IsDraining() {
+			w.Header().Set("Connection", "close")
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// track starts sending the drain notice to s. If the server is already draining, s is notified at once.
+func (d *Drainer) track(s *Stream) {
+	d.init()
+
+	d.mu.Lock()
+	select {
+	case <-d.draining:
+		d.mu.Unlock()
+		s.goAway(d.notice())
+		return
+	default:
+	}
+	d.streams[s] = struct{}{}
+	d.mu.Unlock()
+}
+
+// untrack stops tracking s, once it has finished.
+func (d *Drainer) untrack(s *Stream) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.streams, s)
+}
+
+func (d *Drainer) init() {
+	d.once.Do(func() {
+		d.draining = make(chan struct{})
+		d.streams = make(map[*Stream]struct{})
+	})
+}
+
+func (d *Drainer) notice() StreamEvent {
+	retry := d.RetryAfter
+	if retry == 0 {
+		retry = time.Second
+	}
+	return StreamEvent{
+		Event: valueOrDefault(d.Event, "server-going-away"),
+		Retry: retry,
+		Data:  DrainNotice{Type: "server_going_away", ReconnectAfterMS: retry.Milliseconds()},
+	}
+}
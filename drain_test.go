@@ -0,0 +1,70 @@
+package gohelpertools
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDrainer_Streams(t *testing.T) {
+	var tools Tools
+	drainer := &Drainer{RetryAfter: 2 * time.Second}
+
+	rr := httptest.NewRecorder()
+	stream, err := tools.NewStream(rr, httptest.NewRequest("GET", "/events", nil), StreamOptions{Drainer: drainer, QueueSize: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = stream.Send(StreamEvent{Data: "hello"})
+
+	drainer.Drain()
+	drainer.Drain()
+	if err := stream.Send(StreamEvent{Data: "too late"}); err != ErrStreamClosed {
+		t.Errorf("expected ErrStreamClosed after drain, got %v", err)
+	}
+	_ = stream.Serve()
+
+	body := rr.Body.String()
+	expected := "event: server-going-away\nretry: 2000\ndata: {\"type\":\"server_going_away\",\"reconnect_after_ms\":2000}\n\n"
+	if !strings.HasPrefix(body, "data: \"hello\"\n\n") || !strings.HasSuffix(body, expected) {
+		t.Errorf("expected queued event followed by drain notice, got %q", body)
+	}
+
+	// Streams opened while draining are told to go away at once.
+	rr = httptest.NewRecorder()
+	stream, _ = tools.NewStream(rr, httptest.NewRequest("GET", "/events", nil), StreamOptions{Drainer: drainer})
+	_ = stream.Serve()
+	if !strings.Contains(rr.Body.String(), "server_going_away") {
+		t.Errorf("expected drain notice for new stream, got %q", rr.Body.String())
+	}
+}
+
+func TestDrainer_Middleware(t *testing.T) {
+	drainer := &Drainer{}
+	srv := &http.Server{}
+	drainer.RegisterOnShutdown(srv)
+
+	handler := drainer.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+	if rr.Header().Get("Connection") != "" || drainer.IsDraining() {
+		t.Error("expected no Connection header before draining")
+	}
+
+	_ = srv.Shutdown(context.Background())
+	select {
+	case <-drainer.Draining():
+	case <-time.After(time.Second):
+		t.Fatal("expected Shutdown to start draining")
+	}
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+	if rr.Header().Get("Connection") != "close" {
+		t.Error("expected Connection: close while draining")
+	}
+}
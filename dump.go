@@ -0,0 +1,181 @@
+package gohelpertools
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+const maxDumpBody = 64 << 10
+
+// redactedHeaders are never included in dumps, since they carry credentials.
+var redactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key", "X-Auth-Token"}
+
+// DumpRequest returns a readable dump of r: the request line, the headers in sorted order (with
+// Authorization, Cookie and other credentials replaced by [REDACTED]), and, if includeBody is true, up to
+// 64KB of the body. A JSON body is pretty-printed, with the values of DefaultRedactKeys, such as passwords
+// and tokens, masked by RedactJSON. The values of form fields named in DefaultRedactKeys are masked too,
+// in URL-encoded and multipart bodies; multipart bodies are listed field by field, with only the name and
+// size of uploaded files. The body is restored, so r can still be handled.
+func (t *Tools) DumpRequest(r *http.Request, includeBody bool) (string, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s %s\n", r.Method, r.URL.RequestURI(), r.Proto)
+	fmt.Fprintf(&b, "Host: %s\n", r.Host)
+
+	names := make([]string, 0, len(r.Header))
+	for name := range r.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range r.Header[name] {
+			if isRedactedHeader(name) {
+				value = redacted
+			}
+			fmt.Fprintf(&b, "%s: %s\n", name, value)
+		}
+	}
+
+	if !includeBody || r.Body == nil || r.Body == http.NoBody {
+		return b.String(), nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxDumpBody+1))
+	if err != nil {
+		return "", err
+	}
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+
+	truncated := len(body) > maxDumpBody
+	if truncated {
+		body = body[:maxDumpBody]
+	}
+
+	b.WriteString("\n")
+	mediaType, params, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	isJSON := mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+	switch {
+	case isJSON && truncated:
+		// A truncated JSON body can't be parsed, so its secrets can't be masked.
+		b.WriteString("[JSON body too large to redact]")
+	case isJSON:
+		var pretty bytes.Buffer
+		if clean, err := t.RedactJSON(body, nil); err == nil && json.Indent(&pretty, clean, "", "  ") == nil {
+			b.Write(pretty.Bytes())
+		} else {
+			b.WriteString("[malformed JSON body omitted]")
+		}
+	case mediaType == "application/x-www-form-urlencoded":
+		b.WriteString(redactForm(string(body)))
+		if truncated {
+			b.WriteString("\n[body truncated]")
+		}
+	case mediaType == "multipart/form-data":
+		if err := dumpMultipart(&b, body, params["boundary"]); err != nil {
+			if truncated {
+				b.WriteString("[body truncated]")
+			} else {
+				b.WriteString("[malformed multipart body omitted]")
+			}
+		}
+	case truncated:
+		b.Write(body)
+		b.WriteString("\n[body truncated]")
+	default:
+		b.Write(body)
+	}
+	return b.String(), nil
+}
+
+// redactForm masks the values of the fields of a URL-encoded form which are named in DefaultRedactKeys,
+// keeping the fields in their order.
+func redactForm(body string) string {
+	pairs := strings.Split(body, "&")
+	for i, pair := range pairs {
+		key, _, _ := strings.Cut(pair, "=")
+		if name, err := url.QueryUnescape(key); err == nil && isRedactedField(name) {
+			pairs[i] = key + "=" + redacted
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// dumpMultipart writes each part of a multipart form to b: name=value for fields, with the values of those
+// named in DefaultRedactKeys masked, and the file name and size for files.
+func dumpMultipart(b *strings.Builder, body []byte, boundary string) error {
+	mr := multipart.NewReader(bytes.NewReader(body), boundary)
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		value, err := io.ReadAll(part)
+		if err != nil {
+			return err
+		}
+		switch {
+		case part.FileName() != "":
+			fmt.Fprintf(b, "%s: file %q (%d bytes)\n", part.FormName(), part.FileName(), len(value))
+		case isRedactedField(part.FormName()):
+			fmt.Fprintf(b, "%s=%s\n", part.FormName(), redacted)
+		default:
+			fmt.Fprintf(b, "%s=%s\n", part.FormName(), value)
+		}
+	}
+}
+
+// isRedactedField reports whether a form field's value should be masked, as RedactJSON would mask the
+// value of a JSON key of the same name.
+func isRedactedField(name string) bool {
+	name = normalizeRedactKey(name)
+	for _, key := range DefaultRedactKeys {
+		if normalizeRedactKey(key) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// DebugRequests returns middleware which logs a DumpRequest of every request, including the body, with
+// LogInfo. It is for troubleshooting clients during development: unless dev is true, it returns the handler
+// unchanged, so it can be left in place with dev tied to the environment.
+func (t *Tools) DebugRequests(dev bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if !dev {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			dump, err := t.DumpRequest(r, true)
+			if err != nil {
+				t.LogError(r.Context(), "unable to dump request", err)
+			} else {
+				t.LogInfo(r.Context(), "request", "dump", dump)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func isRedactedHeader(name string) bool {
+	for _, redacted := range redactedHeaders {
+		if strings.EqualFold(name, redacted) {
+			return true
+		}
+	}
+	return false
+}
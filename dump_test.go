@@ -0,0 +1,113 @@
+package gohelpertools
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTools_DumpRequest(t *testing.T) {
+	var tools Tools
+
+	req := httptest.NewRequest(http.MethodPost, "/orders?debug=1", strings.NewReader(`{"item":"book","qty":2}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer secret-token")
+	req.Header.Set("Cookie", "session=secret")
+	req.Header.Set("X-Trace", "abc")
+
+	dump, err := tools.DumpRequest(req, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"POST /orders?debug=1 HTTP/1.1\n",
+		"Authorization: [REDACTED]\n",
+		"Cookie: [REDACTED]\n",
+		"X-Trace: abc\n",
+		"{\n  \"item\": \"book\",\n  \"qty\": 2\n}",
+	} {
+		if !strings.Contains(dump, want) {
+			t.Errorf("expected dump to contain %q, got:\n%s", want, dump)
+		}
+	}
+	if strings.Contains(dump, "secret") {
+		t.Errorf("expected credentials to be redacted, got:\n%s", dump)
+	}
+
+	body, _ := io.ReadAll(req.Body)
+	if string(body) != `{"item":"book","qty":2}` {
+		t.Errorf("expected the body to be restored, got %q", body)
+	}
+
+	withoutBody, _ := tools.DumpRequest(req, false)
+	if strings.Contains(withoutBody, "item") {
+		t.Error("expected no body when includeBody is false")
+	}
+}
+
+func TestTools_DebugRequests(t *testing.T) {
+	var logs bytes.Buffer
+	tools := New(WithLogger(slog.New(slog.NewTextHandler(&logs, nil))))
+
+	var received string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		received = string(b)
+	})
+
+	tools.DebugRequests(false)(next).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", strings.NewReader("hi")))
+	if logs.Len() != 0 {
+		t.Errorf("expected nothing to be logged outside dev mode, got %q", logs.String())
+	}
+
+	tools.DebugRequests(true)(next).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", strings.NewReader("hello")))
+	if !strings.Contains(logs.String(), "hello") {
+		t.Errorf("expected the request to be logged, got %q", logs.String())
+	}
+	if received != "hello" {
+		t.Errorf("expected the handler to receive the body, got %q", received)
+	}
+}
+
+func TestTools_DumpRequestForms(t *testing.T) {
+	var tools Tools
+
+	req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader("user=jack&password=hunter2&api_key=abc123"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	dump, err := tools.DumpRequest(req, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(dump, "user=jack&password=[REDACTED]&api_key=[REDACTED]") {
+		t.Errorf("expected form secrets to be redacted, got:\n%s", dump)
+	}
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	_ = mw.WriteField("user", "jack")
+	_ = mw.WriteField("token", "s3cr3t")
+	fw, _ := mw.CreateFormFile("avatar", "me.png")
+	_, _ = fw.Write([]byte("png data"))
+	_ = mw.Close()
+
+	req = httptest.NewRequest(http.MethodPost, "/profile", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	dump, err = tools.DumpRequest(req, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"user=jack\n", "token=[REDACTED]\n", "avatar: file \"me.png\" (8 bytes)\n"} {
+		if !strings.Contains(dump, want) {
+			t.Errorf("expected dump to contain %q, got:\n%s", want, dump)
+		}
+	}
+	if strings.Contains(dump, "s3cr3t") || strings.Contains(dump, "png data") {
+		t.Errorf("expected secrets and file contents to be left out, got:\n%s", dump)
+	}
+}
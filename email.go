@@ -0,0 +1,174 @@
+package gohelpertools
+
+import (
+	"bufio"
+	_ "embed"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+)
+
+//go:embed data/disposable_domains.txt
+var disposableDomainList string
+
+// defaultDisposableDomains is the embedded list, used by IsDisposableEmail when Tools.DisposableDomains
+// is nil.
+var defaultDisposableDomains = mustDomainSet(disposableDomainList)
+
+// gmailDomains are the domains for which Gmail's address rules apply.
+var gmailDomains = map[string]bool{"gmail.com": true, "googlemail.com": true}
+
+// EmailOptions controls how NormalizeEmail canonicalises addresses.
+type EmailOptions struct {
+	StripPlus      bool // if set to true, remove "+tag" suffixes from the local part, for every domain
+	GmailDots      bool // if set to true, remove dots from the local part of Gmail addresses, which Gmail ignores
+	GmailPlus      bool // if set to true, remove "+tag" suffixes from Gmail addresses only
+	KeepLocalCase  bool // if set to true, keep the case of the local part (only the domain is lower-cased)
+	UnifyGmailHost bool // if set to true, rewrite googlemail.com to gmail.com
+}
+
+// NormalizeEmail trims and lower-cases email and applies the rules in opts, so that addresses which reach
+// the same mailbox compare equal. This is useful for spotting duplicate sign-ups; store the address the
+// user typed for sending mail, and the normalized one for lookups.
+func (t *Tools) NormalizeEmail(email string, opts ...EmailOptions) (string, error) {
+	var o EmailOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+
+	email = strings.TrimSpace(email)
+	at := strings.LastIndex(email, "@")
+	if at < 1 || at == len(email)-1 {
+		return "", errors.New("invalid email address")
+	}
+
+	local, domain := email[:at], normalizeDomain(email[at+1:])
+	if !o.KeepLocalCase {
+		local = strings.ToLower(local)
+	}
+
+	gmail := gmailDomains[domain]
+	if gmail && o.UnifyGmailHost {
+		domain = "gmail.com"
+	}
+	if o.StripPlus || (gmail && o.GmailPlus) {
+		if plus := strings.Index(local, "+"); plus > 0 {
+			local = local[:plus]
+		}
+	}
+	if gmail && o.GmailDots {
+		local = strings.ReplaceAll(local, ".", "")
+	}
+
+	if local == "" {
+		return "", errors.New("invalid email address")
+	}
+	return local + "@" + domain, nil
+}
+
+// IsDisposableEmail reports whether email uses a disposable (throwaway) email domain, or a subdomain of
+// one, according to Tools.DisposableDomains or the embedded list.
+func (t *Tools) IsDisposableEmail(email string) bool {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return false
+	}
+
+	domains := t.DisposableDomains
+	if domains == nil {
+		domains = defaultDisposableDomains
+	}
+	return domains.Contains(email[at+1:])
+}
+
+// DomainSet is a set of domains which also matches their subdomains. It is safe for concurrent use, so
+// it can be updated while in use.
+type DomainSet struct {
+	mu      sync.RWMutex
+	domains map[string]bool
+}
+
+// NewDomainSet reads a DomainSet from r, which has one domain per line. Blank lines and lines starting
+// with # are ignored.
+func NewDomainSet(r io.Reader) (*DomainSet, error) {
+	s := &DomainSet{domains: make(map[string]bool)}
+	if err := s.Load(r); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// DisposableDomains returns a copy of the embedded list of disposable email domains, which can be
+// extended with Add or Load and assigned to Tools.DisposableDomains.
+func DisposableDomains() *DomainSet {
+	return mustDomainSet(disposableDomainList)
+}
+
+// Load adds the domains listed in r, in the format read by NewDomainSet.
+func (s *DomainSet) Load(r io.Reader) error {
+	var domains []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		domains = append(domains, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	s.Add(domains...)
+	return nil
+}
+
+// Add adds domains to the set.
+func (s *DomainSet) Add(domains ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.domains == nil {
+		s.domains = make(map[string]bool)
+	}
+	for _, domain := range domains {
+		s.domains[normalizeDomain(domain)] = true
+	}
+}
+
+// Remove removes domains from the set.
+func (s *DomainSet) Remove(domains ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, domain := range domains {
+		delete(s.domains, normalizeDomain(domain))
+	}
+}
+
+// Contains reports whether domain, or any parent domain of it, is in the set.
+func (s *DomainSet) Contains(domain string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	domain = normalizeDomain(domain)
+	for domain != "" {
+		if s.domains[domain] {
+			return true
+		}
+		_, parent, found := strings.Cut(domain, ".")
+		if !found {
+			break
+		}
+		domain = parent
+	}
+	return false
+}
+
+// mustDomainSet parses an embedded domain list, which can't fail to read.
+func mustDomainSet(list string) *DomainSet {
+	s, err := NewDomainSet(strings.NewReader(list))
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
@@ -0,0 +1,71 @@
+package gohelpertools
+
+import (
+	"strings"
+	"testing"
+)
+
+var normalizeEmailTests = []struct {
+	name          string
+	email         string
+	opts          EmailOptions
+	expected      string
+	errorExpected bool
+}{
+	{name: "case and space", email: "  Jane.Doe@Example.COM ", expected: "jane.doe@example.com"},
+	{name: "keep local case", email: "Jane@Example.com", opts: EmailOptions{KeepLocalCase: true}, expected: "Jane@example.com"},
+	{name: "gmail untouched by default", email: "jane.doe+news@gmail.com", expected: "jane.doe+news@gmail.com"},
+	{name: "gmail dots and plus", email: "Jane.Doe+news@gmail.com", opts: EmailOptions{GmailDots: true, GmailPlus: true}, expected: "janedoe@gmail.com"},
+	{name: "googlemail", email: "jane@googlemail.com", opts: EmailOptions{UnifyGmailHost: true}, expected: "jane@gmail.com"},
+	{name: "gmail rules on other domains", email: "jane.doe+news@example.com", opts: EmailOptions{GmailDots: true, GmailPlus: true}, expected: "jane.doe+news@example.com"},
+	{name: "strip plus everywhere", email: "jane+news@example.com", opts: EmailOptions{StripPlus: true}, expected: "jane@example.com"},
+	{name: "no domain", email: "jane@", errorExpected: true},
+	{name: "no local part", email: "@example.com", errorExpected: true},
+}
+
+func TestTools_NormalizeEmail(t *testing.T) {
+	var tools Tools
+
+	for _, e := range normalizeEmailTests {
+		result, err := tools.NormalizeEmail(e.email, e.opts)
+		if e.errorExpected && err == nil {
+			t.Errorf("%s: error expected, but none received", e.name)
+		}
+		if !e.errorExpected && err != nil {
+			t.Errorf("%s: error not expected, but one received: %s", e.name, err)
+		}
+		if result != e.expected {
+			t.Errorf("%s: expected %q but got %q", e.name, e.expected, result)
+		}
+	}
+}
+
+func TestTools_IsDisposableEmail(t *testing.T) {
+	var tools Tools
+
+	for email, expected := range map[string]bool{
+		"jane@mailinator.com":    true,
+		"jane@eu.Mailinator.com": true,
+		"jane@example.com":       false,
+		"jane@notmailinator.com": false,
+		"not an email":           false,
+	} {
+		if result := tools.IsDisposableEmail(email); result != expected {
+			t.Errorf("%s: expected %v but got %v", email, expected, result)
+		}
+	}
+
+	domains := DisposableDomains()
+	if err := domains.Load(strings.NewReader("# custom\nburner.example\n")); err != nil {
+		t.Fatal(err)
+	}
+	domains.Remove("mailinator.com")
+	tools.DisposableDomains = domains
+
+	if !tools.IsDisposableEmail("jane@burner.example") || tools.IsDisposableEmail("jane@mailinator.com") {
+		t.Error("expected custom domain list to be used")
+	}
+	if (&Tools{}).IsDisposableEmail("jane@burner.example") {
+		t.Error("expected embedded list to be unchanged")
+	}
+}
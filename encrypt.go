@@ -0,0 +1,92 @@
+package gohelpertools
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// encryptionVersion is the first byte of everything Encrypt produces, so that the format (currently
+// AES-GCM with a 12 byte random nonce) can change without breaking existing ciphertexts.
+const encryptionVersion byte = 1
+
+// Encrypt encrypts and authenticates plaintext with AES-GCM, using key, which must be 16, 24 or 32 bytes
+// long (32 selects AES-256). A random nonce is used for every call, so encrypting the same value twice
+// gives different results. The output is a version byte, followed by the nonce and the ciphertext.
+func (t *Tools) Encrypt(plaintext, key []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	out := append([]byte{encryptionVersion}, nonce...)
+	return gcm.Seal(out, nonce, plaintext, nil), nil
+}
+
+// Decrypt reverses Encrypt. It returns an error if ciphertext was not produced by Encrypt with key, or has
+// been modified.
+func (t *Tools) Decrypt(ciphertext, key []byte) ([]byte, error) {
+	if len(ciphertext) == 0 {
+		return nil, errors.New("ciphertext is empty")
+	}
+	if ciphertext[0] != encryptionVersion {
+		return nil, fmt.Errorf("unsupported ciphertext version %d", ciphertext[0])
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	data := ciphertext[1:]
+	if len(data) < gcm.NonceSize()+gcm.Overhead() {
+		return nil, errors.New("ciphertext is too short")
+	}
+
+	plaintext, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, errors.New("unable to decrypt: wrong key or modified ciphertext")
+	}
+	return plaintext, nil
+}
+
+// EncryptString encrypts plaintext with Encrypt, and returns the result as URL-safe base64, which can be
+// stored in a text column or sent in a cookie.
+func (t *Tools) EncryptString(plaintext string, key []byte) (string, error) {
+	out, err := t.Encrypt([]byte(plaintext), key)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(out), nil
+}
+
+// DecryptString reverses EncryptString.
+func (t *Tools) DecryptString(ciphertext string, key []byte) (string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", errors.New("ciphertext is not valid base64")
+	}
+
+	plaintext, err := t.Decrypt(data, key)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// newGCM returns an AES-GCM cipher for key.
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,59 @@
+package gohelpertools
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestTools_EncryptDecrypt(t *testing.T) {
+	var tools Tools
+	key := []byte("0123456789abcdef0123456789abcdef")
+
+	first, err := tools.Encrypt([]byte("secret"), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _ := tools.Encrypt([]byte("secret"), key)
+	if bytes.Equal(first, second) {
+		t.Error("expected different ciphertexts for the same plaintext")
+	}
+
+	plaintext, err := tools.Decrypt(first, key)
+	if err != nil || string(plaintext) != "secret" {
+		t.Errorf("expected secret, got %q (%v)", plaintext, err)
+	}
+
+	tampered := append([]byte{}, first...)
+	tampered[len(tampered)-1] ^= 1
+	if _, err := tools.Decrypt(tampered, key); err == nil {
+		t.Error("tampered ciphertext: error expected, but none received")
+	}
+	if _, err := tools.Decrypt(first, []byte("fedcba9876543210fedcba9876543210")); err == nil {
+		t.Error("wrong key: error expected, but none received")
+	}
+	if _, err := tools.Decrypt(append([]byte{2}, first[1:]...), key); err == nil {
+		t.Error("unknown version: error expected, but none received")
+	}
+	if _, err := tools.Encrypt([]byte("secret"), []byte("short")); err == nil {
+		t.Error("invalid key: error expected, but none received")
+	}
+}
+
+func TestTools_EncryptString(t *testing.T) {
+	var tools Tools
+	key := []byte("0123456789abcdef")
+
+	encrypted, err := tools.EncryptString("token value", key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decrypted, err := tools.DecryptString(encrypted, key)
+	if err != nil || decrypted != "token value" {
+		t.Errorf("expected round trip, got %q (%v)", decrypted, err)
+	}
+
+	if _, err := tools.DecryptString("not base64!", key); err == nil {
+		t.Error("invalid base64: error expected, but none received")
+	}
+}
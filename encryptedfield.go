@@ -0,0 +1,198 @@
+package gohelpertools
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// FieldEncryption configures EncryptedString and EncryptedBytes.
+type FieldEncryption struct {
+	Keyring     *Keyring // keys used to encrypt and decrypt fields; required
+	EncryptJSON bool     // if set to true, fields are encrypted in JSON too; otherwise JSON holds the plaintext
+}
+
+var (
+	fieldEncryptionMu sync.RWMutex
+	fieldEncryption   FieldEncryption
+)
+
+// SetFieldEncryption sets the configuration used by every EncryptedString and EncryptedBytes. It is
+// usually called once, at start-up.
+func SetFieldEncryption(config FieldEncryption) {
+	fieldEncryptionMu.Lock()
+	defer fieldEncryptionMu.Unlock()
+	fieldEncryption = config
+}
+
+func fieldKeyring() (*Keyring, bool, error) {
+	fieldEncryptionMu.RLock()
+	defer fieldEncryptionMu.RUnlock()
+	if fieldEncryption.Keyring == nil {
+		return nil, false, errors.New("field encryption is not configured; call SetFieldEncryption")
+	}
+	return fieldEncryption.Keyring, fieldEncryption.EncryptJSON, nil
+}
+
+// EncryptedString is a string which is encrypted at rest. It is stored in the database as ciphertext
+// (it implements driver.Valuer and sql.Scanner), and optionally in JSON too, but is a plain string in
+// code, so PII columns can be encrypted without changing business logic. An empty string is stored as
+// an empty string, and NULL scans as an empty string.
+type EncryptedString string
+
+// Value encrypts s for the database.
+func (s EncryptedString) Value() (driver.Value, error) {
+	if s == "" {
+		return "", nil
+	}
+	keyring, _, err := fieldKeyring()
+	if err != nil {
+		return nil, err
+	}
+	return keyring.EncryptString(string(s))
+}
+
+// Scan decrypts a value read from the database.
+func (s *EncryptedString) Scan(src any) error {
+	ciphertext, err := scanText(src)
+	if err != nil || ciphertext == "" {
+		*s = ""
+		return err
+	}
+
+	keyring, _, err := fieldKeyring()
+	if err != nil {
+		return err
+	}
+	plaintext, err := keyring.DecryptString(ciphertext)
+	if err != nil {
+		return err
+	}
+	*s = EncryptedString(plaintext)
+	return nil
+}
+
+// MarshalJSON writes s as a JSON string, encrypted if FieldEncryption.EncryptJSON is set.
+func (s EncryptedString) MarshalJSON() ([]byte, error) {
+	keyring, encrypt, err := fieldKeyring()
+	if err != nil || !encrypt || s == "" {
+		return json.Marshal(string(s))
+	}
+
+	ciphertext, err := keyring.EncryptString(string(s))
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(ciphertext)
+}
+
+// UnmarshalJSON reads s from a JSON string, decrypting it if FieldEncryption.EncryptJSON is set.
+func (s *EncryptedString) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return err
+	}
+
+	keyring, encrypt, err := fieldKeyring()
+	if err != nil || !encrypt || text == "" {
+		*s = EncryptedString(text)
+		return nil
+	}
+
+	plaintext, err := keyring.DecryptString(text)
+	if err != nil {
+		return err
+	}
+	*s = EncryptedString(plaintext)
+	return nil
+}
+
+// EncryptedBytes is the []byte equivalent of EncryptedString. It is stored in the database as binary
+// ciphertext, and in JSON as base64.
+type EncryptedBytes []byte
+
+// Value encrypts b for the database.
+func (b EncryptedBytes) Value() (driver.Value, error) {
+	if b == nil {
+		return nil, nil
+	}
+	keyring, _, err := fieldKeyring()
+	if err != nil {
+		return nil, err
+	}
+	return keyring.Encrypt(b)
+}
+
+// Scan decrypts a value read from the database.
+func (b *EncryptedBytes) Scan(src any) error {
+	if src == nil {
+		*b = nil
+		return nil
+	}
+	ciphertext, err := scanText(src)
+	if err != nil {
+		return err
+	}
+
+	keyring, _, err := fieldKeyring()
+	if err != nil {
+		return err
+	}
+	plaintext, err := keyring.Decrypt([]byte(ciphertext))
+	if err != nil {
+		return err
+	}
+	*b = plaintext
+	return nil
+}
+
+// MarshalJSON writes b as a base64 JSON string, encrypted if FieldEncryption.EncryptJSON is set.
+func (b EncryptedBytes) MarshalJSON() ([]byte, error) {
+	keyring, encrypt, err := fieldKeyring()
+	if err != nil || !encrypt || b == nil {
+		return json.Marshal([]byte(b))
+	}
+
+	ciphertext, err := keyring.Encrypt(b)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(ciphertext)
+}
+
+// UnmarshalJSON reads b from a base64 JSON string, decrypting it if FieldEncryption.EncryptJSON is set.
+func (b *EncryptedBytes) UnmarshalJSON(data []byte) error {
+	var raw []byte
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	keyring, encrypt, err := fieldKeyring()
+	if err != nil || !encrypt || raw == nil {
+		*b = raw
+		return nil
+	}
+
+	plaintext, err := keyring.Decrypt(raw)
+	if err != nil {
+		return err
+	}
+	*b = plaintext
+	return nil
+}
+
+// scanText converts a database value to a string.
+func scanText(src any) (string, error) {
+	switch v := src.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	default:
+		return "", fmt.Errorf("cannot scan %T into an encrypted field", src)
+	}
+}
@@ -0,0 +1,94 @@
+package gohelpertools
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func withFieldEncryption(t *testing.T, encryptJSON bool) {
+	keyring, err := NewKeyring("k1", map[string][]byte{"k1": bytes.Repeat([]byte{7}, 32)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetFieldEncryption(FieldEncryption{Keyring: keyring, EncryptJSON: encryptJSON})
+	t.Cleanup(func() { SetFieldEncryption(FieldEncryption{}) })
+}
+
+func TestEncryptedString_Database(t *testing.T) {
+	withFieldEncryption(t, false)
+
+	value, err := EncryptedString("123-45-6789").Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, ok := value.(string)
+	if !ok || stored == "" || strings.Contains(stored, "6789") {
+		t.Fatalf("expected ciphertext to be stored, got %v", value)
+	}
+
+	var s EncryptedString
+	if err := s.Scan([]byte(stored)); err != nil {
+		t.Fatal(err)
+	}
+	if s != "123-45-6789" {
+		t.Errorf("expected the plaintext back, got %q", s)
+	}
+
+	if err := s.Scan(nil); err != nil || s != "" {
+		t.Errorf("expected NULL to scan as empty, got %q (%v)", s, err)
+	}
+	if err := s.Scan(42); err == nil {
+		t.Error("scanning an int: error expected, but none received")
+	}
+}
+
+func TestEncryptedString_JSON(t *testing.T) {
+	type user struct {
+		SSN EncryptedString `json:"ssn"`
+	}
+
+	for _, encrypt := range []bool{false, true} {
+		withFieldEncryption(t, encrypt)
+
+		out, err := json.Marshal(user{SSN: "123-45-6789"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(out), "6789") == encrypt {
+			t.Errorf("encrypt %v: unexpected JSON %s", encrypt, out)
+		}
+
+		var back user
+		if err := json.Unmarshal(out, &back); err != nil || back.SSN != "123-45-6789" {
+			t.Errorf("encrypt %v: expected a round trip, got %q (%v)", encrypt, back.SSN, err)
+		}
+	}
+}
+
+func TestEncryptedBytes(t *testing.T) {
+	withFieldEncryption(t, true)
+
+	value, err := EncryptedBytes("card").Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b EncryptedBytes
+	if err := b.Scan(value); err != nil || string(b) != "card" {
+		t.Errorf("expected a database round trip, got %q (%v)", b, err)
+	}
+
+	out, _ := json.Marshal(EncryptedBytes("card"))
+	var back EncryptedBytes
+	if err := json.Unmarshal(out, &back); err != nil || string(back) != "card" {
+		t.Errorf("expected a JSON round trip, got %q (%v)", back, err)
+	}
+}
+
+func TestEncryptedString_NotConfigured(t *testing.T) {
+	SetFieldEncryption(FieldEncryption{})
+	if _, err := EncryptedString("x").Value(); err == nil {
+		t.Error("error expected, but none received")
+	}
+}
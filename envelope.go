@@ -0,0 +1,132 @@
+package gohelpertools
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// rawError is the body ErrorJSON sends when the envelope is disabled.
+type rawError struct {
+	Message string       `json:"message"`
+	Errors  SchemaErrors `json:"errors,omitempty"`
+}
+
+// envelopeWriter marks a response as one which should be written without the JSONResponse envelope.
+type envelopeWriter struct {
+	http.ResponseWriter
+}
+
+// Unwrap returns the original http.ResponseWriter, for use by http.ResponseController.
+func (ew *envelopeWriter) Unwrap() http.ResponseWriter {
+	return ew.ResponseWriter
+}
+
+// WithoutEnvelope is middleware which switches off the JSONResponse envelope for the routes it wraps, for
+// example where a partner's API specification forbids wrapping. WriteData then writes data on its own, and
+// ErrorJSON sends just {"message": "..."}.
+func (t *Tools) WithoutEnvelope(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(&envelopeWriter{ResponseWriter: w}, r)
+	})
+}
+
+// WriteData writes data to the client wrapped in a JSONResponse envelope with the given message, unless
+// the envelope has been disabled with DisableEnvelope or the WithoutEnvelope middleware, in which case
+// data is written on its own.
+func (t *Tools) WriteData(w http.ResponseWriter, status int, message string, data any, headers ...http.Header) error {
+	if t.rawMode(w) {
+		return t.WriteJSON(w, status, data, headers...)
+	}
+	return t.WriteJSON(w, status, JSONResponse{Message: message, Data: data}, headers...)
+}
+
+// ReadJSONData reads a JSON request body like ReadJSON, but also accepts a body wrapped in a JSONResponse
+// envelope, as sent by other services using this package. If an envelope is found, its data is decoded
+// into data, and an envelope with error set to true is returned as an error.
+func (t *Tools) ReadJSONData(w http.ResponseWriter, r *http.Request, data any, opts ...JSONOption) error {
+	var body json.RawMessage
+	if err := t.ReadJSON(w, r, &body, opts...); err != nil {
+		return err
+	}
+	return t.UnwrapJSON(body, data, opts...)
+}
+
+// UnwrapJSON decodes body into data, first removing the JSONResponse envelope if body has one. This is
+// useful when calling internal services, some of which wrap their responses while others don't. An
+// envelope with error set to true is returned as an error containing its message. Options such as
+// WithAllowUnknown and WithUseNumber override the Tools settings for this call only.
+func (t *Tools) UnwrapJSON(body []byte, data any, opts ...JSONOption) error {
+	o := t.jsonOptions(opts)
+	if envelope, ok := detectEnvelope(body); ok {
+		if envelope.Error {
+			return errors.New(envelope.Message)
+		}
+		if len(envelope.Data) == 0 {
+			return nil
+		}
+		body = envelope.Data
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	if !o.allowUnknown {
+		dec.DisallowUnknownFields()
+	}
+	if o.useNumber {
+		dec.UseNumber()
+	}
+	return dec.Decode(data)
+}
+
+// rawEnvelope mirrors JSONResponse, keeping the data undecoded.
+type rawEnvelope struct {
+	Error   bool            `json:"error"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// detectEnvelope reports whether body is a JSONResponse envelope: an object with a boolean "error" key, a
+// string "message" key, and no keys other than those, "data" and "meta".
+func detectEnvelope(body []byte) (rawEnvelope, bool) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return rawEnvelope{}, false
+	}
+
+	for key := range fields {
+		if key != "error" && key != "message" && key != "data" && key != "meta" {
+			return rawEnvelope{}, false
+		}
+	}
+
+	var envelope rawEnvelope
+	if _, ok := fields["error"]; !ok {
+		return rawEnvelope{}, false
+	}
+	if _, ok := fields["message"]; !ok {
+		return rawEnvelope{}, false
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return rawEnvelope{}, false
+	}
+	return envelope, true
+}
+
+// rawMode reports whether responses written to w should skip the JSONResponse envelope.
+func (t *Tools) rawMode(w http.ResponseWriter) bool {
+	if t.DisableEnvelope {
+		return true
+	}
+
+	for {
+		switch rw := w.(type) {
+		case *envelopeWriter:
+			return true
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = rw.Unwrap()
+		default:
+			return false
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package gohelpertools
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTools_WriteData(t *testing.T) {
+	data := map[string]string{"id": "1"}
+
+	var enveloped Tools
+	rr := httptest.NewRecorder()
+	_ = enveloped.WriteData(rr, http.StatusOK, "ok", data)
+	if strings.TrimSpace(rr.Body.String()) != `{"error":false,"message":"ok","data":{"id":"1"}}` {
+		t.Errorf("expected enveloped response, got %s", rr.Body.String())
+	}
+
+	raw := Tools{DisableEnvelope: true}
+	rr = httptest.NewRecorder()
+	_ = raw.WriteData(rr, http.StatusOK, "ok", data)
+	if strings.TrimSpace(rr.Body.String()) != `{"id":"1"}` {
+		t.Errorf("expected raw response, got %s", rr.Body.String())
+	}
+}
+
+func TestTools_WithoutEnvelope(t *testing.T) {
+	var testTools Tools
+
+	handler := testTools.WithoutEnvelope(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = testTools.ErrorJSON(w, errors.New("nope"), http.StatusConflict)
+	}))
+
+	rr := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusConflict || strings.TrimSpace(rr.Body.String()) != `{"message":"nope"}` {
+		t.Errorf("expected raw error response, got %d %s", rr.Code, rr.Body.String())
+	}
+}
+
+var unwrapJSONTests = []struct {
+	name          string
+	body          string
+	expected      string
+	errorExpected bool
+}{
+	{name: "raw", body: `{"foo": "bar"}`, expected: "bar"},
+	{name: "envelope", body: `{"error": false, "message": "ok", "data": {"foo": "bar"}}`, expected: "bar"},
+	{name: "error envelope", body: `{"error": true, "message": "failed"}`, errorExpected: true},
+	{name: "raw with message field", body: `{"foo": "bar", "message": "x", "error": false}`, errorExpected: true},
+}
+
+func TestTools_ReadJSONData(t *testing.T) {
+	var testTools Tools
+
+	for _, e := range unwrapJSONTests {
+		var decoded struct {
+			Foo string `json:"foo"`
+		}
+
+		req, _ := http.NewRequest("POST", "/", bytes.NewReader([]byte(e.body)))
+		err := testTools.ReadJSONData(httptest.NewRecorder(), req, &decoded)
+
+		if e.errorExpected && err == nil {
+			t.Errorf("%s: error expected, but none received", e.name)
+		}
+		if !e.errorExpected && (err != nil || decoded.Foo != e.expected) {
+			t.Errorf("%s: expected foo=%s, got foo=%s and error %v", e.name, e.expected, decoded.Foo, err)
+		}
+	}
+}
+
+func TestTools_ReadJSONDataOptions(t *testing.T) {
+	var testTools Tools
+
+	var decoded struct {
+		Foo any `json:"foo"`
+	}
+	body := `{"error": false, "message": "ok", "data": {"foo": 9007199254740993, "bar": 1}}`
+	req, _ := http.NewRequest("POST", "/", strings.NewReader(body))
+	if err := testTools.ReadJSONData(httptest.NewRecorder(), req, &decoded, WithAllowUnknown(), WithUseNumber()); err != nil {
+		t.Fatalf("error not expected, but one received: %s", err)
+	}
+	if n, ok := decoded.Foo.(json.Number); !ok || n.String() != "9007199254740993" {
+		t.Errorf("expected foo to be decoded as a json.Number, got %#v", decoded.Foo)
+	}
+
+	req, _ = http.NewRequest("POST", "/", strings.NewReader(body))
+	if err := testTools.ReadJSONData(httptest.NewRecorder(), req, &decoded); err == nil {
+		t.Error("error expected for the unknown field, but none received")
+	}
+}
@@ -0,0 +1,130 @@
+package gohelpertools
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"strings"
+)
+
+// ErrorPageFunc writes an HTML error page with the given status and message.
+type ErrorPageFunc func(w http.ResponseWriter, r *http.Request, status int, message string)
+
+// NotFoundHandler returns a handler which responds 404 Not Found with the JSONResponse envelope, or with an
+// HTML page if the client prefers HTML (see WriteError). Mount it as the fallback of any mux, for example
+// with mux.Handle("/", tools.NotFoundHandler()) or chi's r.NotFound, in place of the plain-text default.
+func (t *Tools) NotFoundHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.WriteError(w, r, http.StatusNotFound, errors.New("the requested resource could not be found"))
+	})
+}
+
+// InternalErrorHandler returns a handler which responds 500 Internal Server Error, as JSON or HTML like
+// NotFoundHandler. It is what Recover sends after a panic.
+func (t *Tools) InternalErrorHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.WriteError(w, r, http.StatusInternalServerError, errors.New("an internal error occurred"))
+	})
+}
+
+// Recover is middleware which recovers from a panic in next, logs it with a stack trace, and responds with
+// InternalErrorHandler. The details of the panic are never sent to the client. http.ErrAbortHandler is
+// re-panicked, since it is used to abort a response deliberately.
+func (t *Tools) Recover(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			p := recover()
+			if p == nil {
+				return
+			}
+			if p == http.ErrAbortHandler {
+				panic(p)
+			}
+			t.LogError(r.Context(), "panic serving request", fmt.Errorf("%v", p), "method", r.Method, "path", r.URL.Path, "stack", string(debug.Stack()))
+			t.InternalErrorHandler().ServeHTTP(w, r)
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// WriteError sends err with status as an HTML page if the request's Accept header prefers text/html over
+// application/json, and with ErrorJSON otherwise. HTML pages are written by ErrorPage, or by a minimal
+// built-in page if it is nil.
+func (t *Tools) WriteError(w http.ResponseWriter, r *http.Request, status int, err error) {
+	if !prefersHTML(r) {
+		_ = t.ErrorJSON(w, err, status)
+		return
+	}
+
+	if t.ErrorPage != nil {
+		t.ErrorPage(w, r, status, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	_ = errorPageTemplate.Execute(w, struct {
+		Status  int
+		Title   string
+		Message string
+	}{status, http.StatusText(status), err.Error()})
+}
+
+var errorPageTemplate = template.Must(template.New("error").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head><meta charset="utf-8"><title>{{.Status}} {{.Title}}</title></head>
+<body><h1>{{.Status}} {{.Title}}</h1><p>{{.Message}}</p></body>
+</html>
+`))
+
+// prefersHTML reports whether the Accept header of r gives text/html a higher quality than
+// application/json. Browsers do; API clients, and requests without an Accept header, don't.
+func prefersHTML(r *http.Request) bool {
+	accept := r.Header.Get("Accept")
+	if accept == "" {
+		return false
+	}
+	return acceptQuality(accept, "text/html") > acceptQuality(accept, "application/json")
+}
+
+// acceptQuality returns the quality (0 to 1) which accept, the value of an Accept header, gives
+// mediaType. The most specific matching range wins, so "text/*" applies before "*/*".
+func acceptQuality(accept, mediaType string) float64 {
+	typ, _, _ := strings.Cut(mediaType, "/")
+	best, specificity := 0.0, -1
+
+	for _, part := range strings.Split(accept, ",") {
+		fields := strings.Split(part, ";")
+		mediaRange := strings.ToLower(strings.TrimSpace(fields[0]))
+
+		var s int
+		switch mediaRange {
+		case mediaType:
+			s = 2
+		case typ + "/*":
+			s = 1
+		case "*/*":
+			s = 0
+		default:
+			continue
+		}
+		if s < specificity {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range fields[1:] {
+			key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.EqualFold(key, "q") {
+				if f, err := strconv.ParseFloat(value, 64); err == nil {
+					q = f
+				}
+			}
+		}
+		best, specificity = q, s
+	}
+	return best
+}
@@ -0,0 +1,83 @@
+package gohelpertools
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var notFoundTests = []struct {
+	name     string
+	accept   string
+	wantHTML bool
+}{
+	{name: "no accept header", accept: "", wantHTML: false},
+	{name: "api client", accept: "application/json", wantHTML: false},
+	{name: "browser", accept: "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", wantHTML: true},
+	{name: "json preferred", accept: "text/html;q=0.5, application/json", wantHTML: false},
+	{name: "anything", accept: "*/*", wantHTML: false},
+}
+
+func TestTools_NotFoundHandler(t *testing.T) {
+	var tools Tools
+	for _, e := range notFoundTests {
+		req := httptest.NewRequest(http.MethodGet, "/missing", nil)
+		if e.accept != "" {
+			req.Header.Set("Accept", e.accept)
+		}
+		rr := httptest.NewRecorder()
+		tools.NotFoundHandler().ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("%s: expected status 404 but got %d", e.name, rr.Code)
+		}
+		isHTML := strings.HasPrefix(rr.Header().Get("Content-Type"), "text/html")
+		if isHTML != e.wantHTML {
+			t.Errorf("%s: expected HTML to be %v, got Content-Type %q", e.name, e.wantHTML, rr.Header().Get("Content-Type"))
+		}
+		if !e.wantHTML && !strings.Contains(rr.Body.String(), `"error":true`) {
+			t.Errorf("%s: expected the JSON envelope but got %q", e.name, rr.Body.String())
+		}
+	}
+}
+
+func TestTools_ErrorPage(t *testing.T) {
+	tools := New(WithErrorPage(func(w http.ResponseWriter, r *http.Request, status int, message string) {
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte("custom: " + message))
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept", "text/html")
+	rr := httptest.NewRecorder()
+	tools.InternalErrorHandler().ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusInternalServerError || !strings.HasPrefix(rr.Body.String(), "custom: ") {
+		t.Errorf("expected the custom error page, got %d %q", rr.Code, rr.Body.String())
+	}
+}
+
+func TestTools_Recover(t *testing.T) {
+	var logged bytes.Buffer
+	tools := New(WithLogger(slog.New(slog.NewTextHandler(&logged, nil))))
+
+	handler := tools.Recover(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("secret details")
+	}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/boom", nil))
+
+	if rr.Code != http.StatusInternalServerError {
+		t.Errorf("expected status 500 but got %d", rr.Code)
+	}
+	if strings.Contains(rr.Body.String(), "secret details") {
+		t.Error("panic details should not be sent to the client")
+	}
+	if !strings.Contains(logged.String(), "secret details") {
+		t.Errorf("expected the panic to be logged, got %q", logged.String())
+	}
+}
@@ -0,0 +1,96 @@
+package gohelpertools
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// ETag returns a strong entity tag for data: a quoted hash of data as WriteJSON would marshal it.
+func (t *Tools) ETag(data any) (string, error) {
+	out, err := t.encodeJSON(data, t.jsonOptions(nil))
+	if err != nil {
+		return "", err
+	}
+	return etagOf(out), nil
+}
+
+// ETagJSON writes data as JSON like WriteJSON, with a strong ETag header. If the request is a GET or HEAD
+// and its If-None-Match header matches the ETag, a 304 Not Modified response without a body is sent
+// instead, so clients can revalidate their cached copy cheaply.
+func (t *Tools) ETagJSON(w http.ResponseWriter, r *http.Request, status int, data any, opts ...JSONOption) error {
+	o := t.jsonOptions(opts)
+	out, err := t.encodeJSON(data, o)
+	if err != nil {
+		return err
+	}
+
+	o.setHeaders(w)
+
+	etag := etagOf(out)
+	w.Header().Set("ETag", etag)
+
+	if (r.Method == http.MethodGet || r.Method == http.MethodHead) && etagMatches(r.Header.Get("If-None-Match"), etag, false) {
+		w.Header().Del("Content-Length")
+		w.WriteHeader(http.StatusNotModified)
+		return nil
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(out)
+
+	return nil
+}
+
+// CheckIfMatch checks the If-Match precondition of a write, such as a PUT or PATCH, against current, the
+// resource as it is now (nil if it doesn't exist). It returns true if the write may go ahead: either there
+// is no If-Match header, or it matches the ETag of current. Otherwise a 412 Precondition Failed JSON error
+// is sent and false is returned, and the handler should stop, since the client's copy is out of date.
+func (t *Tools) CheckIfMatch(w http.ResponseWriter, r *http.Request, current any) (bool, error) {
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch == "" {
+		return true, nil
+	}
+
+	if current != nil {
+		etag, err := t.ETag(current)
+		if err != nil {
+			return false, err
+		}
+		if etagMatches(ifMatch, etag, true) {
+			return true, nil
+		}
+	}
+
+	return false, t.ErrorJSON(w, errors.New("the resource has been modified"), http.StatusPreconditionFailed)
+}
+
+func etagOf(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + base64.RawURLEncoding.EncodeToString(sum[:18]) + `"`
+}
+
+// etagMatches reports whether header, an If-Match or If-None-Match list of entity tags, matches etag. "*"
+// matches any entity tag. Weak tags (W/"...") never match when strong comparison is required (RFC 9110,
+// section 8.8.3.2).
+func etagMatches(header, etag string, strong bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if strings.HasPrefix(candidate, "W/") {
+			if strong {
+				continue
+			}
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+		if candidate == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package gohelpertools
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTools_ETagJSON(t *testing.T) {
+	var tools Tools
+	data := map[string]string{"name": "widget"}
+
+	first := httptest.NewRecorder()
+	if err := tools.ETagJSON(first, httptest.NewRequest(http.MethodGet, "/", nil), http.StatusOK, data); err != nil {
+		t.Fatal(err)
+	}
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" || first.Body.Len() == 0 {
+		t.Fatalf("expected 200 with an ETag and a body, got %d %q %q", first.Code, etag, first.Body.String())
+	}
+
+	want, _ := tools.ETag(data)
+	if etag != want {
+		t.Errorf("expected ETag %s but got %s", want, etag)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("If-None-Match", `"other", W/`+etag)
+	second := httptest.NewRecorder()
+	_ = tools.ETagJSON(second, req, http.StatusOK, data)
+	if second.Code != http.StatusNotModified || second.Body.Len() != 0 {
+		t.Errorf("expected 304 without a body, got %d %q", second.Code, second.Body.String())
+	}
+
+	req.Header.Set("If-None-Match", `"other"`)
+	third := httptest.NewRecorder()
+	_ = tools.ETagJSON(third, req, http.StatusOK, data)
+	if third.Code != http.StatusOK {
+		t.Errorf("expected 200 for a stale ETag, got %d", third.Code)
+	}
+}
+
+var ifMatchTests = []struct {
+	name    string
+	ifMatch string
+	current any
+	wantOK  bool
+}{
+	{name: "no header", ifMatch: "", current: map[string]int{"v": 1}, wantOK: true},
+	{name: "matching", ifMatch: "current", current: map[string]int{"v": 1}, wantOK: true},
+	{name: "star", ifMatch: "*", current: map[string]int{"v": 1}, wantOK: true},
+	{name: "star without resource", ifMatch: "*", current: nil, wantOK: false},
+	{name: "stale", ifMatch: `"stale"`, current: map[string]int{"v": 1}, wantOK: false},
+	{name: "weak", ifMatch: "weak", current: map[string]int{"v": 1}, wantOK: false},
+}
+
+func TestTools_CheckIfMatch(t *testing.T) {
+	var tools Tools
+	for _, e := range ifMatchTests {
+		req := httptest.NewRequest(http.MethodPut, "/", nil)
+		etag, _ := tools.ETag(e.current)
+		switch e.ifMatch {
+		case "current":
+			req.Header.Set("If-Match", etag)
+		case "weak":
+			req.Header.Set("If-Match", "W/"+etag)
+		case "":
+		default:
+			req.Header.Set("If-Match", e.ifMatch)
+		}
+
+		rr := httptest.NewRecorder()
+		ok, err := tools.CheckIfMatch(rr, req, e.current)
+		if err != nil {
+			t.Errorf("%s: error not expected, but one received: %s", e.name, err)
+		}
+		if ok != e.wantOK {
+			t.Errorf("%s: expected %v but got %v", e.name, e.wantOK, ok)
+		}
+		if !ok && rr.Code != http.StatusPreconditionFailed {
+			t.Errorf("%s: expected status 412 but got %d", e.name, rr.Code)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package gohelpertools
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// EventEnvelope is the wire form of an event: its name, the schema version of its data, and the data.
+type EventEnvelope struct {
+	Name    string          `json:"name"`
+	Version int             `json:"version"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// EventUpcaster converts the data of one version of an event into the next version.
+type EventUpcaster func(data json.RawMessage) (json.RawMessage, error)
+
+// EventRegistry declares the payload schema of each version of each event, so that producers and
+// consumers agree on them. Encode refuses payloads which don't match a registered schema, and Decode
+// validates incoming data against the schema of its version, then upcasts it, one version at a time, to
+// the latest version, so consumers only ever handle the current shape. An EventRegistry is safe for
+// concurrent use; the zero value is ready to use.
+type EventRegistry struct {
+	mu        sync.RWMutex
+	schemas   map[string]map[int]reflect.Type
+	latest    map[string]int
+	upcasters map[string]map[int]EventUpcaster
+}
+
+// Register declares that version of the event name has data shaped like example, a struct or pointer to
+// a struct.
+func (reg *EventRegistry) Register(name string, version int, example any) error {
+	typ := reflect.TypeOf(example)
+	if typ != nil && typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if typ == nil || typ.Kind() != reflect.Struct {
+		return fmt.Errorf("event %s v%d: example must be a struct", name, version)
+	}
+	if version < 1 {
+		return fmt.Errorf("event %s: versions start at 1", name)
+	}
+
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	if reg.schemas == nil {
+		reg.schemas = make(map[string]map[int]reflect.Type)
+		reg.latest = make(map[string]int)
+		reg.upcasters = make(map[string]map[int]EventUpcaster)
+	}
+	if reg.schemas[name] == nil {
+		reg.schemas[name] = make(map[int]reflect.Type)
+		reg.upcasters[name] = make(map[int]EventUpcaster)
+	}
+	if _, ok := reg.schemas[name][version]; ok {
+		return fmt.Errorf("event %s v%d is already registered", name, version)
+	}
+
+	reg.schemas[name][version] = typ
+	if version > reg.latest[name] {
+		reg.latest[name] = version
+	}
+	return nil
+}
+
+// Upcast registers fn to convert data of version from of the event name to version from+1. The event
+// must already be registered.
+func (reg *EventRegistry) Upcast(name string, from int, fn EventUpcaster) error {
+	if fn == nil {
+		return fmt.Errorf("event %s v%d: upcaster must not be nil", name, from)
+	}
+
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	if reg.upcasters == nil || reg.upcasters[name] == nil {
+		return fmt.Errorf("event %s must be registered before its upcasters", name)
+	}
+	reg.upcasters[name][from] = fn
+	return nil
+}
+
+// Latest returns the latest registered version of the event name, or 0 if it isn't registered.
+func (reg *EventRegistry) Latest(name string) int {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	return reg.latest[name]
+}
+
+// Encode returns the envelope for publishing payload as the event name. The payload's type must be the
+// registered type of the latest version, so that producers can't publish stale or ad hoc shapes.
+func (reg *EventRegistry) Encode(name string, payload any) (EventEnvelope, error) {
+	version := reg.Latest(name)
+	if version == 0 {
+		return EventEnvelope{}, fmt.Errorf("event %s is not registered", name)
+	}
+
+	typ := reflect.TypeOf(payload)
+	if typ != nil && typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if want := reg.schema(name, version); typ != want {
+		return EventEnvelope{}, fmt.Errorf("event %s v%d has data of type %s, not %v", name, version, want, typ)
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return EventEnvelope{}, err
+	}
+	return EventEnvelope{Name: name, Version: version, Data: data}, nil
+}
+
+// Decode validates env against the schema of its version, upcasts it to the latest version, and decodes
+// the data into dst, which must be a pointer to the latest version's type.
+func (reg *EventRegistry) Decode(env EventEnvelope, dst any) error {
+	latest := reg.Latest(env.Name)
+	if latest == 0 {
+		return fmt.Errorf("event %s is not registered", env.Name)
+	}
+	if env.Version < 1 || env.Version > latest {
+		return fmt.Errorf("event %s has unknown version %d", env.Name, env.Version)
+	}
+
+	typ := reflect.TypeOf(dst)
+	if typ == nil || typ.Kind() != reflect.Pointer || typ.Elem() != reg.schema(env.Name, latest) {
+		return fmt.Errorf("event %s must be decoded into *%s", env.Name, reg.schema(env.Name, latest))
+	}
+
+	data := env.Data
+	for version := env.Version; ; version++ {
+		if err := reg.validate(env.Name, version, data); err != nil {
+			return err
+		}
+		if version == latest {
+			break
+		}
+
+		reg.mu.RLock()
+		upcast := reg.upcasters[env.Name][version]
+		reg.mu.RUnlock()
+		if upcast == nil {
+			return fmt.Errorf("event %s has no upcaster from v%d to v%d", env.Name, version, version+1)
+		}
+
+		var err error
+		if data, err = upcast(data); err != nil {
+			return fmt.Errorf("upcasting event %s from v%d: %w", env.Name, version, err)
+		}
+	}
+
+	return json.Unmarshal(data, dst)
+}
+
+// DecodeJSON decodes an EventEnvelope from body, as sent by a WebhookSender with Events set, and then
+// decodes it as Decode does.
+func (reg *EventRegistry) DecodeJSON(body []byte, dst any) (EventEnvelope, error) {
+	var env EventEnvelope
+	if err := json.Unmarshal(body, &env); err != nil {
+		return env, errors.New("body is not an event envelope")
+	}
+	return env, reg.Decode(env, dst)
+}
+
+// validate checks that data decodes into the schema of version, with no unknown fields.
+func (reg *EventRegistry) validate(name string, version int, data json.RawMessage) error {
+	typ := reg.schema(name, version)
+	if typ == nil {
+		return fmt.Errorf("event %s v%d is not registered", name, version)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(reflect.New(typ).Interface()); err != nil {
+		return fmt.Errorf("event %s v%d does not match its schema: %w", name, version, err)
+	}
+	return nil
+}
+
+func (reg *EventRegistry) schema(name string, version int) reflect.Type {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	return reg.schemas[name][version]
+}
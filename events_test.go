@@ -0,0 +1,122 @@
+package gohelpertools
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type orderPlacedV1 struct {
+	OrderID string `json:"order_id"`
+	Total   int    `json:"total"` // in cents
+}
+
+type orderPlacedV2 struct {
+	OrderID  string `json:"order_id"`
+	Amount   int    `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+func newOrderRegistry(t *testing.T) *EventRegistry {
+	var reg EventRegistry
+	if err := reg.Register("order.placed", 1, orderPlacedV1{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := reg.Register("order.placed", 2, &orderPlacedV2{}); err != nil {
+		t.Fatal(err)
+	}
+	err := reg.Upcast("order.placed", 1, func(data json.RawMessage) (json.RawMessage, error) {
+		var old orderPlacedV1
+		if err := json.Unmarshal(data, &old); err != nil {
+			return nil, err
+		}
+		return json.Marshal(orderPlacedV2{OrderID: old.OrderID, Amount: old.Total, Currency: "USD"})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &reg
+}
+
+func TestEventRegistry(t *testing.T) {
+	reg := newOrderRegistry(t)
+
+	if err := reg.Register("order.placed", 2, orderPlacedV2{}); err == nil {
+		t.Error("duplicate version: error expected, but none received")
+	}
+	if err := reg.Upcast("order.shipped", 1, func(data json.RawMessage) (json.RawMessage, error) { return data, nil }); err == nil {
+		t.Error("upcaster for an unregistered event: error expected, but none received")
+	}
+	if _, err := reg.Encode("order.placed", orderPlacedV1{OrderID: "o1"}); err == nil {
+		t.Error("stale payload type: error expected, but none received")
+	}
+	if _, err := reg.Encode("order.shipped", orderPlacedV2{}); err == nil {
+		t.Error("unregistered event: error expected, but none received")
+	}
+
+	env, err := reg.Encode("order.placed", orderPlacedV2{OrderID: "o2", Amount: 500, Currency: "EUR"})
+	if err != nil || env.Version != 2 {
+		t.Fatalf("unexpected envelope %+v (%v)", env, err)
+	}
+
+	var got orderPlacedV2
+	if err := reg.Decode(env, &got); err != nil || got.Currency != "EUR" {
+		t.Errorf("unexpected decoded event %+v (%v)", got, err)
+	}
+
+	old := EventEnvelope{Name: "order.placed", Version: 1, Data: json.RawMessage(`{"order_id":"o1","total":250}`)}
+	if err := reg.Decode(old, &got); err != nil || got != (orderPlacedV2{OrderID: "o1", Amount: 250, Currency: "USD"}) {
+		t.Errorf("expected v1 to be upcast, got %+v (%v)", got, err)
+	}
+
+	var decodeTests = []struct {
+		name string
+		env  EventEnvelope
+	}{
+		{name: "unknown field", env: EventEnvelope{Name: "order.placed", Version: 1, Data: json.RawMessage(`{"order_id":"o1","totl":250}`)}},
+		{name: "wrong type", env: EventEnvelope{Name: "order.placed", Version: 2, Data: json.RawMessage(`{"order_id":1}`)}},
+		{name: "future version", env: EventEnvelope{Name: "order.placed", Version: 3, Data: json.RawMessage(`{}`)}},
+	}
+	for _, e := range decodeTests {
+		if err := reg.Decode(e.env, &got); err == nil {
+			t.Errorf("%s: error expected, but none received", e.name)
+		}
+	}
+
+	var wrongDst orderPlacedV1
+	if err := reg.Decode(env, &wrongDst); err == nil {
+		t.Error("stale destination: error expected, but none received")
+	}
+}
+
+func TestWebhookSender_Events(t *testing.T) {
+	reg := newOrderRegistry(t)
+
+	received := make(chan []byte, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- body
+	}))
+	defer srv.Close()
+
+	sender := &WebhookSender{Secret: "secret", Events: reg, Client: srv.Client()}
+	defer sender.Close(context.Background())
+
+	if _, err := sender.Send(srv.URL, "order.placed", map[string]string{"order_id": "o1"}); err == nil || !strings.Contains(err.Error(), "order.placed") {
+		t.Errorf("expected an unregistered payload shape to be refused, got %v", err)
+	}
+
+	if _, err := sender.Send(srv.URL, "order.placed", orderPlacedV2{OrderID: "o3", Amount: 1, Currency: "GBP"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var got orderPlacedV2
+	env, err := reg.DecodeJSON(<-received, &got)
+	if err != nil || env.Version != 2 || got.OrderID != "o3" {
+		t.Errorf("unexpected delivery %+v %+v (%v)", env, got, err)
+	}
+}
@@ -0,0 +1,134 @@
+package gohelpertools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// defaultFetchCache is used by CachedFetchJSON when Tools.FetchCache is nil.
+var defaultFetchCache = &FetchCache{}
+
+// FetchCache stores remote JSON responses for CachedFetchJSON. The zero value is ready to use.
+type FetchCache struct {
+	StaleIfError time.Duration // how long past expiry a response may still be used if refreshing fails; stale responses are never used if zero
+
+	mu      sync.Mutex
+	entries map[string]*fetchEntry
+}
+
+// fetchEntry is a cached response body and the validators needed to revalidate it.
+type fetchEntry struct {
+	body         []byte
+	etag         string
+	lastModified string
+	expires      time.Time
+}
+
+// CachedFetchJSON fetches JSON from uri and decodes it into dst, keeping the response in the cache for
+// ttl. Once the cached copy has expired, it is revalidated using the ETag and Last-Modified headers, so an
+// unchanged resource costs only a 304 response. If the remote server can't be reached or returns an
+// error, a stale cached copy is used instead for up to FetchCache.StaleIfError, which keeps handlers
+// working while a configuration or third-party data source is down.
+func (t *Tools) CachedFetchJSON(ctx context.Context, uri string, dst any, ttl time.Duration) error {
+	cache := t.FetchCache
+	if cache == nil {
+		cache = defaultFetchCache
+	}
+
+	entry := cache.get(uri)
+	if entry != nil && time.Now().Before(entry.expires) {
+		return json.Unmarshal(entry.body, dst)
+	}
+
+	body, err := cache.refresh(ctx, t.HTTPClient(), uri, entry, ttl)
+	if err != nil {
+		if entry != nil && time.Now().Before(entry.expires.Add(cache.StaleIfError)) {
+			t.logWarn(ctx, "serving stale copy after fetch failed", "url", uri, "error", err.Error())
+			return json.Unmarshal(entry.body, dst)
+		}
+		return err
+	}
+
+	return json.Unmarshal(body, dst)
+}
+
+// Delete removes uri from the cache, so that the next fetch goes to the remote server.
+func (c *FetchCache) Delete(uri string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, uri)
+}
+
+func (c *FetchCache) get(uri string) *fetchEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.entries[uri]
+}
+
+func (c *FetchCache) set(uri string, entry *fetchEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = make(map[string]*fetchEntry)
+	}
+	c.entries[uri] = entry
+}
+
+// refresh fetches uri, revalidating entry if there is one, and returns the current body.
+func (c *FetchCache) refresh(ctx context.Context, client *http.Client, uri string, entry *fetchEntry, ttl time.Duration) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if entry != nil {
+		if entry.etag != "" {
+			req.Header.Set("If-None-Match", entry.etag)
+		}
+		if entry.lastModified != "" {
+			req.Header.Set("If-Modified-Since", entry.lastModified)
+		}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && entry != nil {
+		c.set(uri, &fetchEntry{
+			body:         entry.body,
+			etag:         valueOrDefault(resp.Header.Get("ETag"), entry.etag),
+			lastModified: valueOrDefault(resp.Header.Get("Last-Modified"), entry.lastModified),
+			expires:      time.Now().Add(ttl),
+		})
+		return entry.body, nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s returned status %d", uri, resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, defaultMaxUpload))
+	if err != nil {
+		return nil, err
+	}
+	if !json.Valid(bytes.TrimSpace(body)) {
+		return nil, fmt.Errorf("response from %s is not valid json", uri)
+	}
+
+	c.set(uri, &fetchEntry{
+		body:         body,
+		etag:         resp.Header.Get("ETag"),
+		lastModified: resp.Header.Get("Last-Modified"),
+		expires:      time.Now().Add(ttl),
+	})
+	return body, nil
+}
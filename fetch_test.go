@@ -0,0 +1,75 @@
+package gohelpertools
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTools_CachedFetchJSON(t *testing.T) {
+	var requests, revalidations int32
+	var failing atomic.Bool
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if failing.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			atomic.AddInt32(&revalidations, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write([]byte(`{"foo": "bar"}`))
+	}))
+	defer server.Close()
+
+	testTools := Tools{FetchCache: &FetchCache{}}
+	ctx := context.Background()
+
+	var out struct {
+		Foo string `json:"foo"`
+	}
+
+	// The first fetch hits the server; the second is served from the cache.
+	for i := 0; i < 2; i++ {
+		if err := testTools.CachedFetchJSON(ctx, server.URL, &out, time.Minute); err != nil || out.Foo != "bar" {
+			t.Fatalf("fetch %d: unexpected result %q, %v", i, out.Foo, err)
+		}
+	}
+	if atomic.LoadInt32(&requests) != 1 {
+		t.Errorf("expected 1 request to the server, got %d", requests)
+	}
+
+	// An expired entry is revalidated with its ETag.
+	testTools.FetchCache.entries[server.URL].expires = time.Now().Add(-time.Second)
+	out.Foo = ""
+	if err := testTools.CachedFetchJSON(ctx, server.URL, &out, 0); err != nil || out.Foo != "bar" {
+		t.Fatalf("revalidation: unexpected result %q, %v", out.Foo, err)
+	}
+	if atomic.LoadInt32(&revalidations) != 1 {
+		t.Errorf("expected a conditional request, got %d", revalidations)
+	}
+
+	// When the server fails, the stale copy is used only if StaleIfError allows it.
+	failing.Store(true)
+	if err := testTools.CachedFetchJSON(ctx, server.URL, &out, 0); err == nil {
+		t.Error("stale fallback without StaleIfError: error expected, but none received")
+	}
+	testTools.FetchCache.StaleIfError = time.Minute
+	out.Foo = ""
+	if err := testTools.CachedFetchJSON(ctx, server.URL, &out, 0); err != nil || out.Foo != "bar" {
+		t.Errorf("stale fallback: unexpected result %q, %v", out.Foo, err)
+	}
+
+	// Without a cached copy, the error is returned.
+	testTools.FetchCache.Delete(server.URL)
+	if err := testTools.CachedFetchJSON(ctx, server.URL, &out, 0); err == nil {
+		t.Error("expected error with a failing server and an empty cache, but none received")
+	}
+}
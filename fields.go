@@ -0,0 +1,145 @@
+package gohelpertools
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// fieldsWriter carries the fields a client asked for, for WriteJSON.
+type fieldsWriter struct {
+	http.ResponseWriter
+	fields []string
+}
+
+// Unwrap returns the original http.ResponseWriter, for use by http.ResponseController.
+func (fw *fieldsWriter) Unwrap() http.ResponseWriter {
+	return fw.ResponseWriter
+}
+
+// SparseFields is middleware which lets clients ask for only some fields of a response with a "fields"
+// query parameter, such as ?fields=id,name,author.name, to save bandwidth on mobile connections. WriteJSON
+// then filters the data it writes with FilterFields; error responses are left alone.
+func (t *Tools) SparseFields(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var fields []string
+		for _, value := range r.URL.Query()["fields"] {
+			for _, field := range strings.Split(value, ",") {
+				if field = strings.TrimSpace(field); field != "" {
+					fields = append(fields, field)
+				}
+			}
+		}
+		if len(fields) > 0 {
+			w = &fieldsWriter{ResponseWriter: w, fields: fields}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requestedFields returns the fields set on w by SparseFields, if any.
+func requestedFields(w http.ResponseWriter) []string {
+	for {
+		switch rw := w.(type) {
+		case *fieldsWriter:
+			return rw.fields
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = rw.Unwrap()
+		default:
+			return nil
+		}
+	}
+}
+
+// FilterFields returns data, marshalled to JSON and back, with only the listed fields of each object kept.
+// Nested fields are separated by dots, so "author.name" keeps only the name of the author object, while
+// "author" keeps all of it. Arrays are filtered item by item, and fields which don't exist are ignored.
+// With no fields, data is returned as it is.
+func (t *Tools) FilterFields(data any, fields []string) (any, error) {
+	if len(fields) == 0 {
+		return data, nil
+	}
+
+	raw, err := t.encode(data)
+	if err != nil {
+		return nil, err
+	}
+	value, err := decodeJSONValue(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	tree := fieldTree{}
+	for _, field := range fields {
+		node := tree
+		names := strings.Split(field, ".")
+		for i, name := range names {
+			child, ok := node[name]
+			if ok && child == nil {
+				break // the whole field is already kept
+			}
+			if i == len(names)-1 {
+				node[name] = nil
+				break
+			}
+			if !ok {
+				child = fieldTree{}
+				node[name] = child
+			}
+			node = child
+		}
+	}
+	return tree.filter(value), nil
+}
+
+// fieldTree holds the requested fields, by name; a nil subtree keeps the whole value.
+type fieldTree map[string]fieldTree
+
+func (tree fieldTree) filter(value any) any {
+	if len(tree) == 0 {
+		return value
+	}
+	switch v := value.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(tree))
+		for name, child := range tree {
+			if field, ok := v[name]; ok {
+				out[name] = child.filter(field)
+			}
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = tree.filter(item)
+		}
+		return out
+	}
+	return value
+}
+
+// filterResponse filters data for WriteJSON. Only the data of a JSONResponse envelope is filtered, and
+// error responses aren't filtered at all.
+func (t *Tools) filterResponse(data any, fields []string) (any, error) {
+	switch v := data.(type) {
+	case rawError, json.RawMessage, []byte:
+		return data, nil
+	case JSONResponse:
+		return t.filterEnvelope(v, fields)
+	case *JSONResponse:
+		if v == nil {
+			return data, nil
+		}
+		return t.filterEnvelope(*v, fields)
+	}
+	return t.FilterFields(data, fields)
+}
+
+func (t *Tools) filterEnvelope(payload JSONResponse, fields []string) (any, error) {
+	if payload.Error || payload.Data == nil {
+		return payload, nil
+	}
+	var err error
+	payload.Data, err = t.FilterFields(payload.Data, fields)
+	return payload, err
+}
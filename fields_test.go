@@ -0,0 +1,94 @@
+package gohelpertools
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type fieldsArticle struct {
+	ID     int            `json:"id"`
+	Title  string         `json:"title"`
+	Body   string         `json:"body"`
+	Author map[string]any `json:"author"`
+}
+
+var fieldsArticles = []fieldsArticle{
+	{ID: 1, Title: "One", Body: "long", Author: map[string]any{"name": "Ada", "email": "ada@example.com"}},
+	{ID: 2, Title: "Two", Body: "longer"},
+}
+
+func TestTools_FilterFields(t *testing.T) {
+	var tools Tools
+
+	tests := []struct {
+		name     string
+		data     any
+		fields   []string
+		expected string
+	}{
+		{name: "no fields", data: fieldsArticles[1], expected: `{"id":2,"title":"Two","body":"longer","author":null}`},
+		{name: "object", data: fieldsArticles[0], fields: []string{"id", "title"}, expected: `{"id":1,"title":"One"}`},
+		{name: "array", data: fieldsArticles, fields: []string{"id"}, expected: `[{"id":1},{"id":2}]`},
+		{name: "nested", data: fieldsArticles, fields: []string{"id", "author.name"}, expected: `[{"author":{"name":"Ada"},"id":1},{"author":null,"id":2}]`},
+		{name: "whole and nested", data: fieldsArticles[0], fields: []string{"author.name", "author"}, expected: `{"author":{"email":"ada@example.com","name":"Ada"}}`},
+		{name: "unknown", data: fieldsArticles[0], fields: []string{"missing"}, expected: `{}`},
+		{name: "scalar", data: 42, fields: []string{"id"}, expected: `42`},
+	}
+	for _, e := range tests {
+		filtered, err := tools.FilterFields(e.data, e.fields)
+		if err != nil {
+			t.Errorf("%s: error not expected, but one received: %s", e.name, err)
+			continue
+		}
+		if out, _ := json.Marshal(filtered); string(out) != e.expected {
+			t.Errorf("%s: expected %s, got %s", e.name, e.expected, out)
+		}
+	}
+}
+
+func TestTools_SparseFields(t *testing.T) {
+	var tools Tools
+	var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("fail") != "" {
+			_ = tools.ErrorJSON(w, errors.New("no such article"), http.StatusNotFound)
+			return
+		}
+		_ = tools.WriteData(w, http.StatusOK, "articles", fieldsArticles)
+	})
+	handler = tools.SparseFields(handler)
+
+	tests := map[string]string{
+		"/":                        `{"error":false,"message":"articles","data":[{"id":1,"title":"One","body":"long","author":{"email":"ada@example.com","name":"Ada"}},{"id":2,"title":"Two","body":"longer","author":null}]}`,
+		"/?fields=id,title":        `{"error":false,"message":"articles","data":[{"id":1,"title":"One"},{"id":2,"title":"Two"}]}`,
+		"/?fields=id&fields=title": `{"error":false,"message":"articles","data":[{"id":1,"title":"One"},{"id":2,"title":"Two"}]}`,
+		"/?fields=id&fail=1":       `{"error":true,"message":"no such article"}`,
+	}
+	for target, expected := range tests {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, target, nil))
+		if rr.Body.String() != expected {
+			t.Errorf("%s: expected %s, got %s", target, expected, rr.Body.String())
+		}
+	}
+
+	rr := httptest.NewRecorder()
+	_ = tools.WriteJSONWith(rr, http.StatusOK, fieldsArticles[0], WithFields("title"))
+	if rr.Body.String() != `{"title":"One"}` {
+		t.Errorf("expected WithFields to filter, got %s", rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	_ = tools.WriteJSONStream(rr, http.StatusOK, func(yield func(any) bool) {
+		for _, article := range fieldsArticles {
+			if !yield(article) {
+				return
+			}
+		}
+	}, WithFields("id"))
+	if rr.Body.String() != `[{"id":1},{"id":2}]` {
+		t.Errorf("expected streamed items to be filtered, got %s", rr.Body.String())
+	}
+}
@@ -0,0 +1,136 @@
+package gohelpertools
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// zeroDecimalCurrencies have no minor unit, so amounts in them are whole numbers.
+var zeroDecimalCurrencies = []string{"BIF", "CLP", "DJF", "GNF", "ISK", "JPY", "KMF", "KRW", "PYG", "RWF", "UGX", "VND", "VUV", "XAF", "XOF", "XPF"}
+
+// currencySymbols are the symbols FormatMoney uses; other currencies are written with their code.
+var currencySymbols = map[string]string{
+	"USD": "$", "EUR": "€", "GBP": "£", "JPY": "¥", "NGN": "₦", "INR": "₹", "KRW": "₩", "CNY": "¥",
+}
+
+// namedTimeLayouts are the layout names FormatTime accepts besides Go layouts.
+var namedTimeLayouts = map[string]string{
+	"date":     "2 Jan 2006",
+	"datetime": "2 Jan 2006 15:04",
+	"time":     "15:04",
+	"iso":      time.RFC3339,
+	"rfc3339":  time.RFC3339,
+}
+
+// FormatMoney formats an amount given in the currency's minor unit (such as cents), so 123456 USD becomes
+// "$1,234.56" and -500 JPY becomes "-¥500". Currencies without a known symbol are written with their
+// code, as in "1,234.56 CHF".
+func (t *Tools) FormatMoney(minorUnits int64, currency string) string {
+	return formatMoney(minorUnits, currency)
+}
+
+func formatMoney(minorUnits int64, currency string) string {
+	currency = strings.ToUpper(currency)
+
+	sign := ""
+	if minorUnits < 0 {
+		sign = "-"
+	}
+	units := uint64(minorUnits)
+	if minorUnits < 0 {
+		units = uint64(-(minorUnits + 1)) + 1
+	}
+
+	amount := groupThousands(strconv.FormatUint(units, 10))
+	if digits := minorUnitDigits(currency); digits > 0 {
+		scale := uint64(math.Pow10(digits))
+		amount = groupThousands(strconv.FormatUint(units/scale, 10)) + fmt.Sprintf(".%0*d", digits, units%scale)
+	}
+
+	if symbol, ok := currencySymbols[currency]; ok {
+		return sign + symbol + amount
+	}
+	return sign + amount + " " + currency
+}
+
+// FormatTime formats v with layout, which is a Go layout or one of "date" (2 Jan 2006), "datetime"
+// (2 Jan 2006 15:04), "time" (15:04) or "iso" (RFC 3339). The zero time formats as the empty string.
+func (t *Tools) FormatTime(v time.Time, layout string) string {
+	if v.IsZero() {
+		return ""
+	}
+	if named, ok := namedTimeLayouts[layout]; ok {
+		layout = named
+	}
+	return v.Format(layout)
+}
+
+// TimeAgo describes v relative to now in words, such as "just now", "5 minutes ago", "yesterday" or
+// "in 3 days".
+func (t *Tools) TimeAgo(v time.Time) string {
+	return timeAgo(v, time.Now())
+}
+
+func timeAgo(v, now time.Time) string {
+	d := now.Sub(v)
+	future := d < 0
+	if future {
+		d = -d
+	}
+	if d < time.Minute {
+		return "just now"
+	}
+
+	var n int64
+	var unit string
+	switch {
+	case d < time.Hour:
+		n, unit = int64(d/time.Minute), "minute"
+	case d < 24*time.Hour:
+		n, unit = int64(d/time.Hour), "hour"
+	case d < 30*24*time.Hour:
+		n, unit = int64(d/(24*time.Hour)), "day"
+	case d < 365*24*time.Hour:
+		n, unit = int64(d/(30*24*time.Hour)), "month"
+	default:
+		n, unit = int64(math.Floor(d.Hours()/(365*24))), "year"
+	}
+
+	if n == 1 && unit == "day" {
+		if future {
+			return "tomorrow"
+		}
+		return "yesterday"
+	}
+
+	phrase := strconv.FormatInt(n, 10) + " " + unit
+	if n != 1 {
+		phrase += "s"
+	}
+	if future {
+		return "in " + phrase
+	}
+	return phrase + " ago"
+}
+
+// groupThousands puts commas between groups of three digits.
+func groupThousands(digits string) string {
+	if len(digits) <= 3 {
+		return digits
+	}
+	var b strings.Builder
+	first := len(digits) % 3
+	if first > 0 {
+		b.WriteString(digits[:first])
+	}
+	for i := first; i < len(digits); i += 3 {
+		if b.Len() > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(digits[i : i+3])
+	}
+	return b.String()
+}
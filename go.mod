@@ -1,3 +1,20 @@
 module github.com/oluwaferanmiadetunji/go-helper-tools
 
-go 1.19
+go 1.21
+
+require (
+	github.com/cespare/xxhash/v2 v2.3.0
+	github.com/fxamacker/cbor/v2 v2.7.0
+	github.com/vmihailenco/msgpack/v5 v5.4.1
+	golang.org/x/crypto v0.24.0
+	golang.org/x/image v0.18.0
+	golang.org/x/text v0.16.0
+	google.golang.org/protobuf v1.34.2
+)
+
+require (
+	github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
+	github.com/x448/float16 v0.8.4 // indirect
+	golang.org/x/net v0.21.0 // indirect
+	golang.org/x/sys v0.21.0 // indirect
+)
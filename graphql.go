@@ -0,0 +1,144 @@
+package gohelpertools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// GraphQLError is one entry of the "errors" array of a GraphQL response.
+type GraphQLError struct {
+	Message    string            `json:"message"`
+	Path       []any             `json:"path,omitempty"` // field names and list indexes leading to the failed field
+	Locations  []GraphQLLocation `json:"locations,omitempty"`
+	Extensions map[string]any    `json:"extensions,omitempty"` // server-specific details, often with a "code"
+}
+
+// GraphQLLocation is a position in a GraphQL query.
+type GraphQLLocation struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// Error returns the message, prefixed with the path if there is one.
+func (e GraphQLError) Error() string {
+	if len(e.Path) == 0 {
+		return e.Message
+	}
+	path := make([]string, len(e.Path))
+	for i, p := range e.Path {
+		path[i] = fmt.Sprint(p)
+	}
+	return strings.Join(path, ".") + ": " + e.Message
+}
+
+// GraphQLErrors is the error returned when a GraphQL response has errors. PartialData reports whether data
+// was returned as well, in which case it has been decoded into the destination, with the failed fields
+// null.
+type GraphQLErrors struct {
+	Errors      []GraphQLError
+	PartialData bool
+}
+
+// Error returns the messages of all the errors.
+func (e *GraphQLErrors) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		messages[i] = err.Error()
+	}
+	return "graphql: " + strings.Join(messages, "; ")
+}
+
+// GraphQLClient sends queries to the GraphQL endpoint at URL. It handles the request format, and the
+// errors and partial data of responses, without needing a full GraphQL client library.
+type GraphQLClient struct {
+	URL     string       // the GraphQL endpoint; required
+	Header  http.Header  // sent with every request, for example for authorization
+	Client  *http.Client // used to send requests; Tools.HTTPClient is used if nil
+	MaxSize int          // maximum size of a response, in bytes; defaults to 10MB
+	Tools   *Tools       // a zero Tools is used if nil
+}
+
+// Query sends query with variables, and decodes the "data" of the response into dest, which may be nil.
+// A response with errors returns a *GraphQLErrors; when it has data too, that is still decoded into dest,
+// so that callers can use the fields which succeeded. A failure before GraphQL execution, such as an HTTP
+// error without a GraphQL body, returns an ordinary error.
+func (c *GraphQLClient) Query(ctx context.Context, query string, variables map[string]any, dest any) error {
+	body, err := json.Marshal(struct {
+		Query     string         `json:"query"`
+		Variables map[string]any `json:"variables,omitempty"`
+	}{query, variables})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for key, value := range c.Header {
+		req.Header[key] = value
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/graphql-response+json, application/json;q=0.9")
+
+	client := c.Client
+	if client == nil {
+		client = toolsOrDefault(c.Tools).HTTPClient()
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	maxSize := c.MaxSize
+	if maxSize <= 0 {
+		maxSize = defaultMaxUpload
+	}
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, int64(maxSize)+1))
+	if err != nil {
+		return err
+	}
+	if len(raw) > maxSize {
+		return fmt.Errorf("graphql response is larger than %d bytes", maxSize)
+	}
+
+	// Servers send GraphQL errors with 200 or, for application/graphql-response+json, a 4xx status; any
+	// other response which isn't a GraphQL body is an HTTP failure.
+	var result struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []GraphQLError  `json:"errors"`
+	}
+	if err := json.Unmarshal(raw, &result); err != nil || (result.Data == nil && result.Errors == nil) {
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return fmt.Errorf("graphql request failed with status %d", resp.StatusCode)
+		}
+		return errors.New("graphql response is not valid")
+	}
+
+	hasData := len(result.Data) > 0 && string(result.Data) != "null"
+	if hasData && dest != nil {
+		if err := json.Unmarshal(result.Data, dest); err != nil {
+			return fmt.Errorf("decoding graphql data: %w", err)
+		}
+	}
+	if len(result.Errors) > 0 {
+		return &GraphQLErrors{Errors: result.Errors, PartialData: hasData}
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("graphql request failed with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// GraphQLQuery sends query with variables to the GraphQL endpoint at uri, and decodes the data of the
+// response into dest. See GraphQLClient.Query, and use a GraphQLClient to send headers.
+func (t *Tools) GraphQLQuery(ctx context.Context, uri, query string, variables map[string]any, dest any) error {
+	return (&GraphQLClient{URL: uri, Tools: t}).Query(ctx, query, variables, dest)
+}
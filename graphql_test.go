@@ -0,0 +1,77 @@
+package gohelpertools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGraphQLClient_Query(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Query     string         `json:"query"`
+			Variables map[string]any `json:"variables"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte("unauthorized"))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/graphql-response+json")
+		switch req.Query {
+		case "ok":
+			_, _ = w.Write([]byte(`{"data":{"user":{"id":"` + req.Variables["id"].(string) + `","name":"Ada"}}}`))
+		case "partial":
+			_, _ = w.Write([]byte(`{"data":{"user":{"id":"1","name":null}},"errors":[{"message":"name is private","path":["user","name"],"extensions":{"code":"FORBIDDEN"}}]}`))
+		case "invalid":
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"errors":[{"message":"Cannot query field \"nme\"","locations":[{"line":1,"column":9}]}]}`))
+		default:
+			w.WriteHeader(http.StatusBadGateway)
+			_, _ = w.Write([]byte("<html>bad gateway</html>"))
+		}
+	}))
+	defer srv.Close()
+
+	client := &GraphQLClient{URL: srv.URL, Header: http.Header{"Authorization": {"Bearer token"}}}
+	type user struct {
+		ID   string  `json:"id"`
+		Name *string `json:"name"`
+	}
+
+	var data struct{ User user }
+	if err := client.Query(context.Background(), "ok", map[string]any{"id": "7"}, &data); err != nil || data.User.ID != "7" || *data.User.Name != "Ada" {
+		t.Errorf("unexpected result %+v: %v", data, err)
+	}
+
+	data = struct{ User user }{}
+	err := client.Query(context.Background(), "partial", nil, &data)
+	var gqlErrs *GraphQLErrors
+	if !errors.As(err, &gqlErrs) || !gqlErrs.PartialData || gqlErrs.Errors[0].Extensions["code"] != "FORBIDDEN" {
+		t.Fatalf("expected partial data with errors, got %v", err)
+	}
+	if data.User.ID != "1" || data.User.Name != nil {
+		t.Errorf("expected the partial data to be decoded, got %+v", data)
+	}
+	if err.Error() != "graphql: user.name: name is private" {
+		t.Errorf("unexpected message %q", err.Error())
+	}
+
+	err = client.Query(context.Background(), "invalid", nil, &data)
+	if !errors.As(err, &gqlErrs) || gqlErrs.PartialData || gqlErrs.Errors[0].Locations[0].Column != 9 {
+		t.Errorf("expected a validation error without data, got %v", err)
+	}
+
+	if err := client.Query(context.Background(), "down", nil, &data); err == nil || errors.As(err, &gqlErrs) {
+		t.Errorf("expected an HTTP error, got %v", err)
+	}
+
+	var tools Tools
+	if err := tools.GraphQLQuery(context.Background(), srv.URL, "ok", nil, nil); err == nil || err.Error() != "graphql request failed with status 401" {
+		t.Errorf("expected the request without credentials to fail, got %v", err)
+	}
+}
@@ -0,0 +1,172 @@
+package gohelpertools
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const defaultHealthCheckTimeout = 2 * time.Second
+
+// HealthHandler serves liveness and readiness probes. Liveness only shows that the process is up and
+// serving requests; readiness runs the registered checks (database, cache, downstream services) and fails
+// if any of them does, so that the instance is taken out of rotation. Results are cached briefly, so that
+// frequent probes from several load balancers don't hammer the dependencies.
+type HealthHandler struct {
+	Timeout  time.Duration // default timeout of each check; defaults to 2 seconds
+	CacheTTL time.Duration // how long readiness results are reused; defaults to 1 second, negative disables
+	Drainer  *Drainer      // if set, readiness fails once the server is draining
+	Tools    *Tools        // used to write JSON responses; a zero Tools is used if nil
+
+	mu      sync.Mutex
+	checks  []healthCheck
+	cached  *HealthReport
+	checked time.Time
+}
+
+type healthCheck struct {
+	name    string
+	check   func(ctx context.Context) error
+	timeout time.Duration
+}
+
+// HealthReport is the body of a readiness response.
+type HealthReport struct {
+	Status string        `json:"status"` // "ok" or "fail"
+	Checks []CheckResult `json:"checks"`
+}
+
+// CheckResult is the outcome of one check.
+type CheckResult struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"` // "ok" or "fail"
+	Error     string  `json:"error,omitempty"`
+	LatencyMS float64 `json:"latency_ms"`
+}
+
+// AddCheck registers a readiness check. An optional timeout overrides Timeout for this check.
+func (h *HealthHandler) AddCheck(name string, check func(ctx context.Context) error, timeout ...time.Duration) {
+	d := h.Timeout
+	if len(timeout) > 0 {
+		d = timeout[0]
+	}
+	if d <= 0 {
+		d = defaultHealthCheckTimeout
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checks = append(h.checks, healthCheck{name: name, check: check, timeout: d})
+	h.cached = nil
+}
+
+// Live returns the liveness handler, which always responds 200 OK with {"status":"ok"}.
+func (h *HealthHandler) Live() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = toolsOrDefault(h.Tools).WriteJSON(w, http.StatusOK, HealthReport{Status: "ok", Checks: []CheckResult{}})
+	})
+}
+
+// Ready returns the readiness handler, which responds with a HealthReport, and 200 OK if every check
+// passed or 503 Service Unavailable otherwise.
+func (h *HealthHandler) Ready() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := h.Check(r.Context())
+		status := http.StatusOK
+		if report.Status != "ok" {
+			status = http.StatusServiceUnavailable
+		}
+		w.Header().Set("Cache-Control", "no-store")
+		_ = toolsOrDefault(h.Tools).WriteJSON(w, status, report)
+	})
+}
+
+// Check runs every check concurrently, each with its own timeout, and returns the results in the order
+// the checks were added. A recent result is returned from the cache instead, if there is one.
+func (h *HealthHandler) Check(ctx context.Context) HealthReport {
+	h.mu.Lock()
+	ttl := h.CacheTTL
+	if ttl == 0 {
+		ttl = time.Second
+	}
+	if h.cached != nil && ttl > 0 && time.Since(h.checked) < ttl {
+		report := *h.cached
+		h.mu.Unlock()
+		return h.withDrain(report)
+	}
+	checks := append([]healthCheck(nil), h.checks...)
+	h.mu.Unlock()
+
+	results := make([]CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c healthCheck) {
+			defer wg.Done()
+			results[i] = runCheck(ctx, c)
+		}(i, c)
+	}
+	wg.Wait()
+
+	report := HealthReport{Status: "ok", Checks: results}
+	for _, result := range results {
+		if result.Status != "ok" {
+			report.Status = "fail"
+		}
+	}
+
+	h.mu.Lock()
+	h.cached, h.checked = &report, time.Now()
+	h.mu.Unlock()
+
+	return h.withDrain(report)
+}
+
+// withDrain fails report if the server is draining.
+func (h *HealthHandler) withDrain(report HealthReport) HealthReport {
+	if h.Drainer == nil || !h.Drainer.IsDraining() {
+		return report
+	}
+	report.Status = "fail"
+	report.Checks = append(append([]CheckResult(nil), report.Checks...), CheckResult{
+		Name: "draining", Status: "fail", Error: "the server is shutting down",
+	})
+	return report
+}
+
+// runCheck runs c with its timeout. A check which doesn't return in time fails, even if it ignores its
+// context.
+func runCheck(ctx context.Context, c healthCheck) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				done <- errors.New("check panicked")
+			}
+		}()
+		done <- c.check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = errors.New("check timed out")
+	}
+
+	result := CheckResult{
+		Name:      c.name,
+		Status:    "ok",
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status, result.Error = "fail", err.Error()
+	}
+	return result
+}
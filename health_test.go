@@ -0,0 +1,86 @@
+package gohelpertools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHealthHandler_Ready(t *testing.T) {
+	var dbCalls int32
+	h := &HealthHandler{CacheTTL: time.Minute}
+	h.AddCheck("db", func(ctx context.Context) error {
+		atomic.AddInt32(&dbCalls, 1)
+		return nil
+	})
+	h.AddCheck("cache", func(ctx context.Context) error {
+		return errors.New("connection refused")
+	})
+	h.AddCheck("slow", func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	}, 20*time.Millisecond)
+
+	rr := httptest.NewRecorder()
+	h.Ready().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status 503 but got %d", rr.Code)
+	}
+
+	var report HealthReport
+	if err := json.Unmarshal(rr.Body.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	want := []struct{ name, status, err string }{
+		{"db", "ok", ""},
+		{"cache", "fail", "connection refused"},
+		{"slow", "fail", "check timed out"},
+	}
+	if report.Status != "fail" || len(report.Checks) != len(want) {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	for i, w := range want {
+		c := report.Checks[i]
+		if c.Name != w.name || c.Status != w.status || c.Error != w.err {
+			t.Errorf("check %d: expected %+v but got %+v", i, w, c)
+		}
+	}
+
+	// The result is cached.
+	h.Ready().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if dbCalls != 1 {
+		t.Errorf("expected the cached result to be used, but db was checked %d times", dbCalls)
+	}
+}
+
+func TestHealthHandler_LiveAndDraining(t *testing.T) {
+	var drainer Drainer
+	h := &HealthHandler{Drainer: &drainer}
+	h.AddCheck("db", func(ctx context.Context) error { return nil })
+
+	rr := httptest.NewRecorder()
+	h.Ready().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected status 200 but got %d", rr.Code)
+	}
+
+	drainer.Drain()
+
+	rr = httptest.NewRecorder()
+	h.Ready().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected readiness to fail while draining, got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	h.Live().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/livez", nil))
+	if rr.Code != http.StatusOK || rr.Body.String() != `{"status":"ok","checks":[]}` {
+		t.Errorf("expected liveness to pass, got %d %s", rr.Code, rr.Body.String())
+	}
+}
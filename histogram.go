@@ -0,0 +1,264 @@
+package gohelpertools
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+const defaultHistogramPrecision = 0.01
+
+// Histogram records a stream of non-negative values, such as latencies or response sizes, and answers
+// percentile queries. Like an HDR histogram, values are counted in logarithmic buckets, so memory use
+// depends on the range of values rather than how many are recorded, and every percentile is accurate to
+// within the relative precision. Histograms with the same precision can be merged, for example to combine
+// per-instance or per-minute histograms. A Histogram is safe for concurrent use. The zero value is an
+// empty Histogram with the default precision of 1%.
+type Histogram struct {
+	mu        sync.Mutex
+	precision float64
+	logBase   float64
+	buckets   map[int]uint64
+	zeros     uint64
+	count     uint64
+	sum       float64
+	min       float64
+	max       float64
+}
+
+// NewHistogram returns an empty Histogram whose percentiles are accurate to within precision, a fraction
+// such as 0.01 for 1%. A precision which is zero or not between 0 and 1 means the default of 1%.
+func NewHistogram(precision float64) *Histogram {
+	if precision <= 0 || precision >= 1 {
+		precision = defaultHistogramPrecision
+	}
+	h := &Histogram{precision: precision}
+	h.init()
+	return h
+}
+
+// init sets up a zero Histogram with the default precision. It is called with h.mu held.
+func (h *Histogram) init() {
+	if h.precision == 0 {
+		h.precision = defaultHistogramPrecision
+	}
+	if h.logBase == 0 {
+		h.logBase = math.Log1p(2 * h.precision)
+	}
+	if h.buckets == nil {
+		h.buckets = make(map[int]uint64)
+	}
+}
+
+// Record adds value to the histogram. Negative, NaN and infinite values are ignored.
+func (h *Histogram) Record(value float64) {
+	if value < 0 || math.IsNaN(value) || math.IsInf(value, 0) {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.init()
+
+	if h.count == 0 || value < h.min {
+		h.min = value
+	}
+	if h.count == 0 || value > h.max {
+		h.max = value
+	}
+	h.count++
+	h.sum += value
+
+	if value == 0 {
+		h.zeros++
+		return
+	}
+	h.buckets[h.bucket(value)]++
+}
+
+// RecordDuration adds d to the histogram, in seconds.
+func (h *Histogram) RecordDuration(d time.Duration) {
+	h.Record(d.Seconds())
+}
+
+// Count returns the number of values recorded.
+func (h *Histogram) Count() uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.count
+}
+
+// Min returns the smallest value recorded, or 0 if there are none.
+func (h *Histogram) Min() float64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.min
+}
+
+// Max returns the largest value recorded, or 0 if there are none.
+func (h *Histogram) Max() float64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.max
+}
+
+// Sum returns the total of the values recorded.
+func (h *Histogram) Sum() float64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.sum
+}
+
+// Mean returns the average of the values recorded, or 0 if there are none.
+func (h *Histogram) Mean() float64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.count == 0 {
+		return 0
+	}
+	return h.sum / float64(h.count)
+}
+
+// Percentile returns the value below which p percent (0 to 100) of the recorded values fall, or 0 if
+// nothing has been recorded.
+func (h *Histogram) Percentile(p float64) float64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.count == 0 {
+		return 0
+	}
+	if p <= 0 {
+		return h.min
+	}
+	if p >= 100 {
+		return h.max
+	}
+
+	rank := uint64(math.Ceil(p / 100 * float64(h.count)))
+	seen := h.zeros
+	if seen >= rank {
+		return 0
+	}
+
+	keys := make([]int, 0, len(h.buckets))
+	for key := range h.buckets {
+		keys = append(keys, key)
+	}
+	sort.Ints(keys)
+
+	for _, key := range keys {
+		seen += h.buckets[key]
+		if seen >= rank {
+			return math.Max(h.min, math.Min(h.max, h.value(key)))
+		}
+	}
+	return h.max
+}
+
+// CountAtOrBelow returns the number of recorded values which are at most value. Values in the same bucket
+// as value are all counted, so the answer is accurate to within the relative precision, as percentiles
+// are; this is how Metrics turns histograms into Prometheus buckets.
+func (h *Histogram) CountAtOrBelow(value float64) uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.init()
+
+	switch {
+	case h.count == 0 || value < h.min || math.IsNaN(value):
+		return 0
+	case value >= h.max:
+		return h.count
+	case value <= 0:
+		return h.zeros
+	}
+
+	limit := h.bucket(value)
+	n := h.zeros
+	for key, count := range h.buckets {
+		if key <= limit {
+			n += count
+		}
+	}
+	return n
+}
+
+// Merge adds the values recorded by other to h. Both histograms must have the same precision.
+func (h *Histogram) Merge(other *Histogram) error {
+	if h == other {
+		return errors.New("a histogram cannot be merged into itself")
+	}
+
+	// Copy other under its own lock, so that the two locks are never held together: a.Merge(b) running at
+	// the same time as b.Merge(a) would otherwise deadlock.
+	other = other.snapshot()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.init()
+
+	if h.precision != other.precision {
+		return errors.New("histograms with different precisions cannot be merged")
+	}
+	if other.count == 0 {
+		return nil
+	}
+
+	if h.count == 0 || other.min < h.min {
+		h.min = other.min
+	}
+	if h.count == 0 || other.max > h.max {
+		h.max = other.max
+	}
+	h.count += other.count
+	h.sum += other.sum
+	h.zeros += other.zeros
+	for key, n := range other.buckets {
+		h.buckets[key] += n
+	}
+	return nil
+}
+
+// snapshot returns a copy of h.
+func (h *Histogram) snapshot() *Histogram {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.init()
+
+	buckets := make(map[int]uint64, len(h.buckets))
+	for key, n := range h.buckets {
+		buckets[key] = n
+	}
+	return &Histogram{
+		precision: h.precision,
+		logBase:   h.logBase,
+		buckets:   buckets,
+		zeros:     h.zeros,
+		count:     h.count,
+		sum:       h.sum,
+		min:       h.min,
+		max:       h.max,
+	}
+}
+
+// Reset removes every recorded value.
+func (h *Histogram) Reset() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.buckets = make(map[int]uint64)
+	h.zeros, h.count, h.sum, h.min, h.max = 0, 0, 0, 0, 0
+}
+
+// bucket returns the index of the bucket containing value. Bucket i covers [b^i, b^(i+1)), where
+// b = 1 + 2*precision, so the midpoint of each bucket is within precision of every value in it.
+func (h *Histogram) bucket(value float64) int {
+	return int(math.Floor(math.Log(value) / h.logBase))
+}
+
+// value returns the representative value of bucket key, its midpoint.
+func (h *Histogram) value(key int) float64 {
+	lower := math.Exp(float64(key) * h.logBase)
+	return lower * (1 + h.precision)
+}
@@ -0,0 +1,116 @@
+package gohelpertools
+
+import (
+	"math"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestHistogram_Percentile(t *testing.T) {
+	h := NewHistogram(0.01)
+	for i := 1; i <= 10000; i++ {
+		h.Record(float64(i))
+	}
+
+	for _, p := range []float64{50, 90, 99, 99.9} {
+		expected := p / 100 * 10000
+		if result := h.Percentile(p); math.Abs(result-expected)/expected > 0.01 {
+			t.Errorf("p%v: expected about %v but got %v", p, expected, result)
+		}
+	}
+
+	if h.Count() != 10000 || h.Min() != 1 || h.Max() != 10000 || h.Mean() != 5000.5 {
+		t.Errorf("unexpected summary: count %d, min %v, max %v, mean %v", h.Count(), h.Min(), h.Max(), h.Mean())
+	}
+	if h.Percentile(0) != 1 || h.Percentile(100) != 10000 {
+		t.Error("expected p0 and p100 to be the min and max")
+	}
+
+	h.Reset()
+	if h.Count() != 0 || h.Percentile(50) != 0 {
+		t.Error("expected empty histogram after reset")
+	}
+}
+
+func TestHistogram_Merge(t *testing.T) {
+	a, b := NewHistogram(0), NewHistogram(0)
+	for i := 0; i < 100; i++ {
+		a.RecordDuration(10 * time.Millisecond)
+		b.RecordDuration(time.Second)
+	}
+	b.Record(0)
+	b.Record(-1)
+	b.Record(math.Inf(1))
+	b.Record(math.NaN())
+
+	if err := a.Merge(b); err != nil {
+		t.Fatal(err)
+	}
+	if a.Count() != 201 || a.Min() != 0 || a.Max() != 1 {
+		t.Errorf("unexpected merged summary: count %d, min %v, max %v", a.Count(), a.Min(), a.Max())
+	}
+	if p := a.Percentile(25); math.Abs(p-0.01) > 0.0001 {
+		t.Errorf("expected p25 of about 0.01 but got %v", p)
+	}
+	if p := a.Percentile(75); math.Abs(p-1) > 0.01 {
+		t.Errorf("expected p75 of about 1 but got %v", p)
+	}
+
+	if err := a.Merge(NewHistogram(0.05)); err == nil {
+		t.Error("different precision: error expected, but none received")
+	}
+	if err := a.Merge(a); err == nil {
+		t.Error("merge into itself: error expected, but none received")
+	}
+
+	// Merging two histograms into each other at once doesn't deadlock.
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(2)
+		go func() { defer wg.Done(); _ = a.Merge(b) }()
+		go func() { defer wg.Done(); _ = b.Merge(a) }()
+	}
+	wg.Wait()
+}
+
+func TestHistogram_CountAtOrBelow(t *testing.T) {
+	h := NewHistogram(0.01)
+	if h.CountAtOrBelow(1) != 0 {
+		t.Error("expected no values in an empty histogram")
+	}
+	h.Record(0)
+	for i := 1; i <= 1000; i++ {
+		h.Record(float64(i))
+	}
+
+	tests := map[float64]uint64{-1: 0, 0: 1, 1: 2, 1000: 1001, 5000: 1001}
+	for value, expected := range tests {
+		if n := h.CountAtOrBelow(value); n != expected {
+			t.Errorf("%v: expected %d, got %d", value, expected, n)
+		}
+	}
+	if n := h.CountAtOrBelow(500); math.Abs(float64(n)-501)/501 > 0.02 {
+		t.Errorf("expected about 501 values at or below 500, got %d", n)
+	}
+}
+
+func TestHistogram_ZeroValue(t *testing.T) {
+	var h Histogram
+	for _, v := range []float64{0, 1, 2, 3, 100} {
+		h.Record(v)
+	}
+	if h.Count() != 5 || h.Max() != 100 {
+		t.Errorf("unexpected count %d and max %v", h.Count(), h.Max())
+	}
+	if p := h.Percentile(50); math.Abs(p-2) > 0.02*2 {
+		t.Errorf("expected the median to be about 2, got %v", p)
+	}
+
+	// A zero Histogram has the default precision, so it can be merged with one from NewHistogram.
+	other := NewHistogram(0)
+	other.Record(5)
+	if err := h.Merge(other); err != nil || h.Count() != 6 {
+		t.Errorf("unexpected merge result: count %d, %v", h.Count(), err)
+	}
+}
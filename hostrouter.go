@@ -0,0 +1,124 @@
+package gohelpertools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// ErrDomainNotFound is returned by a DomainStore for a host which doesn't belong to any tenant.
+var ErrDomainNotFound = errors.New("domain not found")
+
+// DomainStore maps customer domains to the tenants which own them.
+type DomainStore interface {
+	// LookupDomain returns the ID of the tenant which owns host, or ErrDomainNotFound.
+	LookupDomain(ctx context.Context, host string) (string, error)
+}
+
+// HostRouter routes requests for customer domains to the tenant which owns them, and decides which
+// domains may be issued TLS certificates.
+type HostRouter struct {
+	Store    DomainStore  // maps domains to tenants; required
+	Fallback http.Handler // handles requests for unknown hosts; if nil, they receive a 404 JSON response
+	Tools    *Tools       // used to write JSON error responses; a zero Tools is used if nil
+}
+
+// Route is middleware which looks up the tenant for the request's host and adds it to the request
+// context, where it can be read with TenantFromContext.
+func (h *HostRouter) Route(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := requestHost(r)
+		tenant, err := h.Store.LookupDomain(r.Context(), host)
+		switch {
+		case errors.Is(err, ErrDomainNotFound):
+			if h.Fallback != nil {
+				h.Fallback.ServeHTTP(w, r)
+				return
+			}
+			_ = toolsOrDefault(h.Tools).ErrorJSON(w, fmt.Errorf("unknown host %s", host), http.StatusNotFound)
+			return
+		case err != nil:
+			_ = toolsOrDefault(h.Tools).ErrorJSON(w, errors.New("unable to look up host"), http.StatusInternalServerError)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(WithTenant(r.Context(), tenant)))
+	})
+}
+
+// HostPolicy only allows certificates for domains in the store. It has the signature of
+// autocert.HostPolicy, so that unknown hosts can't make us request certificates for them.
+func (h *HostRouter) HostPolicy(ctx context.Context, host string) error {
+	if _, err := h.Store.LookupDomain(ctx, normalizeDomain(host)); err != nil {
+		return fmt.Errorf("no certificate for %s: %w", host, err)
+	}
+	return nil
+}
+
+// CertManager returns an ACME (Let's Encrypt) certificate manager which obtains certificates on demand
+// for the domains in the store, caching them in cacheDir. Use its TLSConfig for the HTTPS server, and
+// its HTTPHandler on port 80 to answer HTTP-01 challenges.
+func (h *HostRouter) CertManager(cacheDir, email string) *autocert.Manager {
+	return &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		Cache:      autocert.DirCache(cacheDir),
+		HostPolicy: h.HostPolicy,
+		Email:      email,
+	}
+}
+
+// MemoryDomainStore is a DomainStore held in memory. It is safe for concurrent use.
+type MemoryDomainStore struct {
+	mu      sync.RWMutex
+	domains map[string]string
+}
+
+// NewMemoryDomainStore returns a MemoryDomainStore containing domains, which maps hosts to tenant IDs.
+func NewMemoryDomainStore(domains map[string]string) *MemoryDomainStore {
+	s := &MemoryDomainStore{domains: make(map[string]string)}
+	for host, tenant := range domains {
+		s.domains[normalizeDomain(host)] = tenant
+	}
+	return s
+}
+
+// LookupDomain returns the tenant which owns host.
+func (s *MemoryDomainStore) LookupDomain(ctx context.Context, host string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tenant, ok := s.domains[normalizeDomain(host)]
+	if !ok {
+		return "", ErrDomainNotFound
+	}
+	return tenant, nil
+}
+
+// Add assigns host to tenant, typically once DomainVerifier has confirmed ownership.
+func (s *MemoryDomainStore) Add(host, tenant string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.domains[normalizeDomain(host)] = tenant
+}
+
+// Remove removes host from the store.
+func (s *MemoryDomainStore) Remove(host string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.domains, normalizeDomain(host))
+}
+
+// requestHost returns the host of r, without any port, in lower case.
+func requestHost(r *http.Request) string {
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return normalizeDomain(strings.Trim(host, "[]"))
+}
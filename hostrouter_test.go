@@ -0,0 +1,62 @@
+package gohelpertools
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+var hostRouterTests = []struct {
+	name           string
+	host           string
+	expectedStatus int
+	expectedTenant string
+}{
+	{name: "known host", host: "shop.example.com", expectedStatus: http.StatusOK, expectedTenant: "acme"},
+	{name: "with port and case", host: "Shop.Example.com:8443", expectedStatus: http.StatusOK, expectedTenant: "acme"},
+	{name: "unknown host", host: "evil.example", expectedStatus: http.StatusNotFound},
+}
+
+func TestHostRouter_Route(t *testing.T) {
+	store := NewMemoryDomainStore(map[string]string{"shop.example.com": "acme"})
+	router := HostRouter{Store: store}
+
+	var tenant string
+	handler := router.Route(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenant, _ = TenantFromContext(r.Context())
+	}))
+
+	for _, e := range hostRouterTests {
+		tenant = ""
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Host = e.host
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		if rr.Code != e.expectedStatus {
+			t.Errorf("%s: expected status %d but got %d", e.name, e.expectedStatus, rr.Code)
+		}
+		if tenant != e.expectedTenant {
+			t.Errorf("%s: expected tenant %q but got %q", e.name, e.expectedTenant, tenant)
+		}
+	}
+}
+
+func TestHostRouter_HostPolicy(t *testing.T) {
+	store := NewMemoryDomainStore(nil)
+	router := HostRouter{Store: store}
+
+	if err := router.HostPolicy(context.Background(), "shop.example.com"); err == nil {
+		t.Error("unknown domain: error expected, but none received")
+	}
+
+	store.Add("shop.example.com", "acme")
+	if err := router.HostPolicy(context.Background(), "shop.example.com"); err != nil {
+		t.Errorf("known domain: error not expected, but one received: %s", err)
+	}
+
+	if manager := router.CertManager(t.TempDir(), ""); manager.HostPolicy == nil {
+		t.Error("expected cert manager to use the host policy")
+	}
+}
@@ -0,0 +1,159 @@
+package gohelpertools
+
+import (
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// HTTPClientConfig configures the client returned by Tools.HTTPClient. The zero value gives sensible
+// defaults.
+type HTTPClientConfig struct {
+	Timeout             time.Duration // limit on a whole request, including reading the body; defaults to 10 seconds
+	DialTimeout         time.Duration // limit on establishing a connection; defaults to 5 seconds
+	TLSHandshakeTimeout time.Duration // defaults to 5 seconds
+	IdleConnTimeout     time.Duration // how long idle connections are kept; defaults to 90 seconds
+	MaxIdleConnsPerHost int           // idle connections kept per host; defaults to 10
+	MaxConnsPerHost     int           // limit on connections per host, including those in use; unlimited if zero
+	Metrics             *Metrics      // if set, outbound requests are recorded in it
+}
+
+// transportKey holds the settings which determine a transport, so clients with the same settings share
+// one and its connection pool.
+type transportKey struct {
+	dialTimeout, tlsTimeout, idleTimeout time.Duration
+	maxIdlePerHost, maxPerHost           int
+	public                               bool
+}
+
+// sharedTransports holds the transports of HTTPClient, by transportKey.
+var sharedTransports sync.Map
+
+// HTTPClient returns an HTTP client for calling other services, configured by Tools.HTTPClientConfig: it
+// has timeouts and connection limits, uses the proxy from the HTTP_PROXY, HTTPS_PROXY and NO_PROXY
+// environment variables, and sends the request ID and traceparent from the request context (see
+// RequestID and Tracer), so that calls can be followed across services. Requests are logged at debug
+// level, and failures (errors and 5xx responses) at warning level. Clients from the same configuration
+// share a connection pool, so it's cheap to call HTTPClient for each request. PushJSONToRemote and
+// CachedFetchJSON use it.
+func (t *Tools) HTTPClient() *http.Client {
+	return t.httpClient(false)
+}
+
+// PublicHTTPClient is like HTTPClient, but for requests to URLs supplied by users: it refuses to connect to
+// addresses which aren't public, whatever the DNS says at the time, and doesn't use proxies. See
+// NewPublicHTTPClient. WebhookSender and DomainVerifier use it by default.
+func (t *Tools) PublicHTTPClient() *http.Client {
+	return t.httpClient(true)
+}
+
+func (t *Tools) httpClient(public bool) *http.Client {
+	c := t.HTTPClientConfig
+	key := transportKey{
+		dialTimeout:    durationOrDefault(c.DialTimeout, 5*time.Second),
+		tlsTimeout:     durationOrDefault(c.TLSHandshakeTimeout, 5*time.Second),
+		idleTimeout:    durationOrDefault(c.IdleConnTimeout, 90*time.Second),
+		maxIdlePerHost: c.MaxIdleConnsPerHost,
+		maxPerHost:     c.MaxConnsPerHost,
+		public:         public,
+	}
+	if key.maxIdlePerHost <= 0 {
+		key.maxIdlePerHost = 10
+	}
+
+	transport, ok := sharedTransports.Load(key)
+	if !ok {
+		dialer := &net.Dialer{Timeout: key.dialTimeout, KeepAlive: 30 * time.Second}
+		proxy := http.ProxyFromEnvironment
+		if public {
+			dialer.Control = publicDialControl
+			proxy = nil
+		}
+		transport, _ = sharedTransports.LoadOrStore(key, &http.Transport{
+			Proxy:                 proxy,
+			DialContext:           dialer.DialContext,
+			ForceAttemptHTTP2:     true,
+			TLSHandshakeTimeout:   key.tlsTimeout,
+			IdleConnTimeout:       key.idleTimeout,
+			MaxIdleConns:          100,
+			MaxIdleConnsPerHost:   key.maxIdlePerHost,
+			MaxConnsPerHost:       key.maxPerHost,
+			ExpectContinueTimeout: time.Second,
+		})
+	}
+
+	return &http.Client{
+		Timeout:   durationOrDefault(c.Timeout, 10*time.Second),
+		Transport: t.InstrumentTransport(transport.(http.RoundTripper)),
+	}
+}
+
+// InstrumentTransport wraps base with the request ID and trace propagation, logging and metrics of
+// HTTPClient, for clients which need a transport of their own.
+func (t *Tools) InstrumentTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &instrumentedTransport{base: base, tools: t, metrics: t.HTTPClientConfig.Metrics}
+}
+
+// instrumentedTransport is the http.RoundTripper returned by InstrumentTransport.
+type instrumentedTransport struct {
+	base    http.RoundTripper
+	tools   *Tools
+	metrics *Metrics
+}
+
+func (it *instrumentedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	// A RoundTripper mustn't change the request it's given, so headers are added to a copy.
+	id := RequestIDFromContext(ctx)
+	span := SpanFromContext(ctx)
+	if (id != "" && req.Header.Get("X-Request-Id") == "") || (span != nil && req.Header.Get("traceparent") == "") {
+		req = req.Clone(ctx)
+		if id != "" && req.Header.Get("X-Request-Id") == "" {
+			req.Header.Set("X-Request-Id", id)
+		}
+		if req.Header.Get("traceparent") == "" {
+			InjectTraceparent(ctx, req.Header)
+		}
+	}
+
+	start := time.Now()
+	resp, err := it.base.RoundTrip(req)
+	duration := time.Since(start)
+
+	status := "error"
+	if err == nil {
+		status = strconv.Itoa(resp.StatusCode)
+	}
+	if it.metrics != nil {
+		it.metrics.observeClient(req.Method, req.URL.Host, status, duration)
+	}
+
+	// Query strings and user info often hold credentials, so they aren't logged.
+	logged := *req.URL
+	logged.User, logged.RawQuery = nil, ""
+	args := []any{"method", req.Method, "url", logged.String(), "status", status, "duration", duration}
+	switch {
+	case err != nil:
+		it.tools.logWarn(ctx, "outbound request failed", append(args, "error", err.Error())...)
+	case resp.StatusCode >= 500:
+		it.tools.logWarn(ctx, "outbound request failed", args...)
+	default:
+		it.tools.log(ctx, slog.LevelDebug, "outbound request", args...)
+	}
+	return resp, err
+}
+
+// durationOrDefault returns d, or def if d isn't positive.
+func durationOrDefault(d, def time.Duration) time.Duration {
+	if d <= 0 {
+		return def
+	}
+	return d
+}
@@ -0,0 +1,111 @@
+package gohelpertools
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTools_HTTPClient(t *testing.T) {
+	var headers http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header.Clone()
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	defer srv.Close()
+
+	var logs bytes.Buffer
+	metrics := &Metrics{}
+	tools := New(
+		WithLogger(slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))),
+		WithHTTPClientConfig(HTTPClientConfig{Timeout: 5 * time.Second, Metrics: metrics}),
+	)
+
+	client := tools.HTTPClient()
+	if client.Timeout != 5*time.Second {
+		t.Errorf("expected the configured timeout, got %s", client.Timeout)
+	}
+	if tools.HTTPClient().Transport.(*instrumentedTransport).base != client.Transport.(*instrumentedTransport).base {
+		t.Error("expected clients with the same configuration to share a transport")
+	}
+
+	// Carry a request ID and span in the context, as the RequestID and Tracer middleware would.
+	ctx := context.WithValue(context.Background(), requestIDContextKey, "req-42")
+	ctx, span := (&Tracer{}).StartSpan(ctx, "outbound")
+	defer span.End()
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/ok?token=secret", nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if headers.Get("X-Request-Id") != "req-42" || headers.Get("traceparent") != span.Traceparent() {
+		t.Errorf("expected the request ID and traceparent to be sent, got %v", headers)
+	}
+	if req.Header.Get("X-Request-Id") != "" {
+		t.Error("expected the caller's request not to be changed")
+	}
+
+	resp, err = client.Get(srv.URL + "/fail")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if _, err := client.Get("http://127.0.0.1:1/unreachable"); err == nil {
+		t.Error("expected a request to a closed port to fail")
+	}
+
+	out := logs.String()
+	if !strings.Contains(out, "level=DEBUG msg=\"outbound request\"") || strings.Contains(out, "secret") {
+		t.Errorf("expected the request to be logged without its query string, got %s", out)
+	}
+	if strings.Count(out, "level=WARN msg=\"outbound request failed\"") != 2 {
+		t.Errorf("expected the 502 and the connection error to be logged as failures, got %s", out)
+	}
+
+	rr := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	host := strings.TrimPrefix(srv.URL, "http://")
+	for _, expected := range []string{
+		`http_client_requests_total{method="GET",host="` + host + `",status="200"} 1`,
+		`http_client_requests_total{method="GET",host="` + host + `",status="502"} 1`,
+		`http_client_requests_total{method="GET",host="127.0.0.1:1",status="error"} 1`,
+		`http_client_request_duration_seconds_count{method="GET",host="` + host + `",status="200"} 1`,
+	} {
+		if !strings.Contains(rr.Body.String(), expected) {
+			t.Errorf("expected %s in the metrics, got\n%s", expected, rr.Body.String())
+		}
+	}
+}
+
+func TestTools_PushJSONToRemoteUsesHTTPClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	metrics := &Metrics{}
+	tools := &Tools{HTTPClientConfig: HTTPClientConfig{Metrics: metrics}}
+	resp, status, err := tools.PushJSONToRemote(srv.URL, map[string]int{"a": 1})
+	if err != nil || status != http.StatusAccepted {
+		t.Fatalf("unexpected result %d: %v", status, err)
+	}
+	resp.Body.Close()
+
+	rr := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if !strings.Contains(rr.Body.String(), `http_client_requests_total{method="POST"`) {
+		t.Errorf("expected the push to be recorded, got %s", rr.Body.String())
+	}
+}
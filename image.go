@@ -0,0 +1,168 @@
+package gohelpertools
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+)
+
+// ContactSheetOptions controls the layout and encoding of a contact sheet. Zero values are replaced with
+// sensible defaults.
+type ContactSheetOptions struct {
+	Columns    int         // number of thumbnails per row; defaults to 4
+	CellWidth  int         // width of each cell in pixels; defaults to 160
+	CellHeight int         // height of each cell in pixels; defaults to 120
+	Padding    int         // space between and around cells in pixels; defaults to 4, negative for none
+	Background color.Color // colour behind the thumbnails; defaults to white
+	Format     string      // "png" or "jpeg"; defaults to "png"
+	Quality    int         // JPEG quality, 1-100; defaults to 85
+}
+
+// ContactSheet arranges images in a grid, scaling each one to fit its cell while keeping its aspect ratio,
+// and encodes the result to w as PNG or JPEG. This is useful for gallery previews and video scrubbing sprites.
+func (t *Tools) ContactSheet(w io.Writer, images []image.Image, opts ContactSheetOptions) error {
+	if len(images) == 0 {
+		return errors.New("no images supplied for contact sheet")
+	}
+
+	opts = contactSheetDefaults(opts)
+	if opts.Format != "png" && opts.Format != "jpeg" {
+		return fmt.Errorf("unsupported contact sheet format %q", opts.Format)
+	}
+
+	columns := opts.Columns
+	if len(images) < columns {
+		columns = len(images)
+	}
+	rows := (len(images) + columns - 1) / columns
+
+	width := columns*opts.CellWidth + (columns+1)*opts.Padding
+	height := rows*opts.CellHeight + (rows+1)*opts.Padding
+	sheet := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(sheet, sheet.Bounds(), image.NewUniform(opts.Background), image.Point{}, draw.Src)
+
+	for i, img := range images {
+		thumb, err := scaleToFit(img, opts.CellWidth, opts.CellHeight)
+		if err != nil {
+			return fmt.Errorf("image %d: %w", i, err)
+		}
+
+		// Centre the thumbnail within its cell.
+		col, row := i%columns, i/columns
+		x := opts.Padding + col*(opts.CellWidth+opts.Padding) + (opts.CellWidth-thumb.Bounds().Dx())/2
+		y := opts.Padding + row*(opts.CellHeight+opts.Padding) + (opts.CellHeight-thumb.Bounds().Dy())/2
+		draw.Draw(sheet, thumb.Bounds().Add(image.Pt(x, y)), thumb, image.Point{}, draw.Over)
+	}
+
+	if opts.Format == "jpeg" {
+		return jpeg.Encode(w, sheet, &jpeg.Options{Quality: opts.Quality})
+	}
+	return png.Encode(w, sheet)
+}
+
+// WriteContactSheet streams a contact sheet built from images to the client with the correct Content-Type.
+func (t *Tools) WriteContactSheet(w http.ResponseWriter, images []image.Image, opts ContactSheetOptions) error {
+	opts = contactSheetDefaults(opts)
+	w.Header().Set("Content-Type", "image/"+opts.Format)
+	return t.ContactSheet(w, images, opts)
+}
+
+func contactSheetDefaults(opts ContactSheetOptions) ContactSheetOptions {
+	if opts.Columns <= 0 {
+		opts.Columns = 4
+	}
+	if opts.CellWidth <= 0 {
+		opts.CellWidth = 160
+	}
+	if opts.CellHeight <= 0 {
+		opts.CellHeight = 120
+	}
+	if opts.Padding < 0 {
+		opts.Padding = 0
+	} else if opts.Padding == 0 {
+		opts.Padding = 4
+	}
+	if opts.Background == nil {
+		opts.Background = color.White
+	}
+	if opts.Format == "" {
+		opts.Format = "png"
+	}
+	if opts.Quality <= 0 || opts.Quality > 100 {
+		opts.Quality = 85
+	}
+	return opts
+}
+
+// errEmptyImage is returned when an image with no pixels is scaled.
+var errEmptyImage = errors.New("image has no pixels")
+
+// scaleToFit scales src so that it fits within maxWidth x maxHeight, keeping its aspect ratio. An image
+// with zero width or height can't be scaled, so errEmptyImage is returned for one.
+func scaleToFit(src image.Image, maxWidth, maxHeight int) (*image.RGBA, error) {
+	b := src.Bounds()
+	if b.Empty() {
+		return nil, errEmptyImage
+	}
+	width, height := maxWidth, maxHeight
+	if b.Dx()*maxHeight > b.Dy()*maxWidth {
+		height = b.Dy() * maxWidth / b.Dx()
+	} else {
+		width = b.Dx() * maxHeight / b.Dy()
+	}
+	if width < 1 {
+		width = 1
+	}
+	if height < 1 {
+		height = 1
+	}
+	return scaleImage(src, width, height), nil
+}
+
+// scaleImage resizes src to exactly width x height. Each destination pixel is the average of the source
+// pixels it covers (a box filter), which gives good results when shrinking; when enlarging, this
+// degrades gracefully to nearest-neighbour sampling.
+func scaleImage(src image.Image, width, height int) *image.RGBA {
+	b := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for dy := 0; dy < height; dy++ {
+		y0 := b.Min.Y + dy*b.Dy()/height
+		y1 := b.Min.Y + (dy+1)*b.Dy()/height
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+
+		for dx := 0; dx < width; dx++ {
+			x0 := b.Min.X + dx*b.Dx()/width
+			x1 := b.Min.X + (dx+1)*b.Dx()/width
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+
+			var r, g, bl, a, n uint64
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					cr, cg, cb, ca := src.At(x, y).RGBA()
+					r, g, bl, a = r+uint64(cr), g+uint64(cg), bl+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+
+			dst.SetRGBA64(dx, dy, color.RGBA64{
+				R: uint16(r / n),
+				G: uint16(g / n),
+				B: uint16(bl / n),
+				A: uint16(a / n),
+			})
+		}
+	}
+
+	return dst
+}
@@ -0,0 +1,124 @@
+package gohelpertools
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"net/http/httptest"
+	"testing"
+)
+
+// solidImage returns a w x h image filled with c.
+func solidImage(w, h int, c color.Color) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+var contactSheetTests = []struct {
+	name           string
+	count          int
+	opts           ContactSheetOptions
+	expectedWidth  int
+	expectedHeight int
+	errorExpected  bool
+}{
+	{name: "defaults", count: 6, opts: ContactSheetOptions{}, expectedWidth: 4*160 + 5*4, expectedHeight: 2*120 + 3*4},
+	{name: "fewer images than columns", count: 2, opts: ContactSheetOptions{Columns: 4, CellWidth: 10, CellHeight: 10, Padding: -1}, expectedWidth: 20, expectedHeight: 10},
+	{name: "jpeg", count: 3, opts: ContactSheetOptions{Columns: 3, CellWidth: 20, CellHeight: 20, Padding: 2, Format: "jpeg"}, expectedWidth: 68, expectedHeight: 24},
+	{name: "no images", count: 0, errorExpected: true},
+	{name: "bad format", count: 1, opts: ContactSheetOptions{Format: "gif"}, errorExpected: true},
+}
+
+func TestTools_ContactSheet(t *testing.T) {
+	var testTools Tools
+
+	for _, e := range contactSheetTests {
+		var images []image.Image
+		for i := 0; i < e.count; i++ {
+			images = append(images, solidImage(64, 32, color.RGBA{R: 255, A: 255}))
+		}
+
+		var buf bytes.Buffer
+		err := testTools.ContactSheet(&buf, images, e.opts)
+		if e.errorExpected {
+			if err == nil {
+				t.Errorf("%s: error expected, but none received", e.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: error not expected, but one received: %s", e.name, err)
+			continue
+		}
+
+		var sheet image.Image
+		if e.opts.Format == "jpeg" {
+			sheet, err = jpeg.Decode(&buf)
+		} else {
+			sheet, err = png.Decode(&buf)
+		}
+		if err != nil {
+			t.Errorf("%s: could not decode contact sheet: %s", e.name, err)
+			continue
+		}
+
+		if sheet.Bounds().Dx() != e.expectedWidth || sheet.Bounds().Dy() != e.expectedHeight {
+			t.Errorf("%s: wrong dimensions; expected %dx%d but got %dx%d", e.name, e.expectedWidth, e.expectedHeight, sheet.Bounds().Dx(), sheet.Bounds().Dy())
+		}
+	}
+}
+
+func TestTools_WriteContactSheet(t *testing.T) {
+	var testTools Tools
+
+	rr := httptest.NewRecorder()
+	err := testTools.WriteContactSheet(rr, []image.Image{solidImage(8, 8, color.Black)}, ContactSheetOptions{Format: "jpeg"})
+	if err != nil {
+		t.Error(err)
+	}
+
+	if rr.Header().Get("Content-Type") != "image/jpeg" {
+		t.Errorf("wrong content type; expected image/jpeg but got %s", rr.Header().Get("Content-Type"))
+	}
+}
+
+func TestScaleImage(t *testing.T) {
+	src := solidImage(100, 50, color.RGBA{G: 200, A: 255})
+
+	thumb, err := scaleToFit(src, 20, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if thumb.Bounds().Dx() != 20 || thumb.Bounds().Dy() != 10 {
+		t.Errorf("aspect ratio not preserved; got %dx%d", thumb.Bounds().Dx(), thumb.Bounds().Dy())
+	}
+
+	if r, g, b, _ := thumb.At(5, 5).RGBA(); r != 0 || g>>8 != 200 || b != 0 {
+		t.Errorf("scaled colour is wrong; got %d %d %d", r>>8, g>>8, b>>8)
+	}
+}
+
+func TestScaleImage_Empty(t *testing.T) {
+	var testTools Tools
+
+	empty := image.NewRGBA(image.Rect(0, 0, 0, 10))
+	if _, err := scaleToFit(empty, 20, 20); !errors.Is(err, errEmptyImage) {
+		t.Errorf("expected errEmptyImage, got %v", err)
+	}
+	if _, err := cropToFill(empty, 20, 20); !errors.Is(err, errEmptyImage) {
+		t.Errorf("expected errEmptyImage from cropToFill, got %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := testTools.ContactSheet(&out, []image.Image{empty}, ContactSheetOptions{}); err == nil {
+		t.Error("empty image in contact sheet: error expected, but none received")
+	}
+}
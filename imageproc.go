@@ -0,0 +1,250 @@
+package gohelpertools
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io"
+
+	_ "golang.org/x/image/webp" // registers the WebP decoder with image.Decode
+)
+
+// ImageOptions sets the limits and output of ResizeImage and GenerateThumbnail, and, as Tools.ImageUploads,
+// how UploadFiles processes uploaded images. Zero values are replaced with sensible defaults.
+type ImageOptions struct {
+	MaxBytes        int64 // largest encoded image read; defaults to 20MB
+	MaxPixels       int   // largest width × height decoded, to protect against decompression bombs; defaults to 40 million
+	MaxWidth        int   // for uploads, wider images are scaled down to this width; no limit if zero
+	MaxHeight       int   // for uploads, taller images are scaled down to this height; no limit if zero
+	ThumbnailWidth  int   // for uploads, if set with ThumbnailHeight, a thumbnail of this size is saved too
+	ThumbnailHeight int   // for uploads, the height of the thumbnail
+	Quality         int   // JPEG quality, 1-100; defaults to 85
+}
+
+// DecodeImage decodes a JPEG, PNG or WebP image from r, returning it and its format. The dimensions are
+// checked against MaxPixels before the image is decoded, so a small file claiming to be a huge image is
+// rejected without allocating memory for it. JPEG images are rotated or flipped as their EXIF orientation
+// tag says, so they are the right way up once the EXIF data is gone.
+func (t *Tools) DecodeImage(r io.Reader, opts ...ImageOptions) (image.Image, string, error) {
+	o := imageDefaults(opts)
+
+	data, err := io.ReadAll(io.LimitReader(r, o.MaxBytes+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if int64(len(data)) > o.MaxBytes {
+		return nil, "", fmt.Errorf("image is larger than %d bytes", o.MaxBytes)
+	}
+
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", errors.New("unsupported or corrupt image")
+	}
+	if config.Width <= 0 || config.Height <= 0 {
+		return nil, "", errEmptyImage
+	}
+	if config.Width > o.MaxPixels/config.Height {
+		return nil, "", fmt.Errorf("image dimensions %dx%d exceed the limit of %d pixels", config.Width, config.Height, o.MaxPixels)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", errors.New("unsupported or corrupt image")
+	}
+	if format == "jpeg" {
+		img = orientImage(img, jpegOrientation(data))
+	}
+	return img, format, nil
+}
+
+// ResizeImage decodes an image from r with DecodeImage, scales it down to fit within maxWidth x maxHeight,
+// keeping its aspect ratio, and encodes it to w. Images which already fit aren't enlarged. JPEG images are
+// written as JPEG, and others as PNG; the format written is returned.
+func (t *Tools) ResizeImage(r io.Reader, w io.Writer, maxWidth, maxHeight int, opts ...ImageOptions) (string, error) {
+	img, format, err := t.DecodeImage(r, opts...)
+	if err != nil {
+		return "", err
+	}
+	if img, err = fitImage(img, maxWidth, maxHeight); err != nil {
+		return "", err
+	}
+	return encodeImage(w, img, format, imageDefaults(opts).Quality)
+}
+
+// GenerateThumbnail decodes an image from r with DecodeImage, scales and centre-crops it to exactly width x
+// height, and encodes it to w, as JPEG for JPEG images and PNG for others. The format written is returned.
+func (t *Tools) GenerateThumbnail(r io.Reader, w io.Writer, width, height int, opts ...ImageOptions) (string, error) {
+	if width <= 0 || height <= 0 {
+		return "", errors.New("thumbnail dimensions must be positive")
+	}
+	img, format, err := t.DecodeImage(r, opts...)
+	if err != nil {
+		return "", err
+	}
+	thumb, err := cropToFill(img, width, height)
+	if err != nil {
+		return "", err
+	}
+	return encodeImage(w, thumb, format, imageDefaults(opts).Quality)
+}
+
+func imageDefaults(opts []ImageOptions) ImageOptions {
+	var o ImageOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	if o.MaxBytes <= 0 {
+		o.MaxBytes = 20 << 20
+	}
+	if o.MaxPixels <= 0 {
+		o.MaxPixels = 40_000_000
+	}
+	if o.Quality <= 0 || o.Quality > 100 {
+		o.Quality = 85
+	}
+	return o
+}
+
+// fitImage scales img down to fit within maxWidth x maxHeight, where zero means no limit.
+func fitImage(img image.Image, maxWidth, maxHeight int) (image.Image, error) {
+	b := img.Bounds()
+	if maxWidth <= 0 {
+		maxWidth = b.Dx()
+	}
+	if maxHeight <= 0 {
+		maxHeight = b.Dy()
+	}
+	if b.Dx() <= maxWidth && b.Dy() <= maxHeight {
+		return img, nil
+	}
+	return scaleToFit(img, maxWidth, maxHeight)
+}
+
+// cropToFill scales img to cover width x height, and crops the overflow equally from both sides. Like
+// scaleToFit, it returns errEmptyImage for an image with zero width or height.
+func cropToFill(img image.Image, width, height int) (image.Image, error) {
+	b := img.Bounds()
+	if b.Empty() {
+		return nil, errEmptyImage
+	}
+	scaledWidth, scaledHeight := width, height
+	if b.Dx()*height > b.Dy()*width {
+		scaledWidth = max(width, b.Dx()*height/b.Dy())
+	} else {
+		scaledHeight = max(height, b.Dy()*width/b.Dx())
+	}
+
+	scaled := scaleImage(img, scaledWidth, scaledHeight)
+	offset := image.Pt((scaledWidth-width)/2, (scaledHeight-height)/2)
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(dst, dst.Bounds(), scaled, offset, draw.Src)
+	return dst, nil
+}
+
+func encodeImage(w io.Writer, img image.Image, format string, quality int) (string, error) {
+	if format == "jpeg" {
+		return "jpeg", jpeg.Encode(w, img, &jpeg.Options{Quality: quality})
+	}
+	return "png", png.Encode(w, img)
+}
+
+// jpegOrientation returns the EXIF orientation (1 to 8) of a JPEG image, or 1 if it has none.
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+
+	// Walk the segments before the image data, looking for the APP1 segment holding EXIF data.
+	for i := 2; i+4 <= len(data) && data[i] == 0xFF; {
+		marker := data[i+1]
+		size := int(binary.BigEndian.Uint16(data[i+2:]))
+		if marker == 0xDA || size < 2 || i+2+size > len(data) {
+			return 1
+		}
+		segment := data[i+4 : i+2+size]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return exifOrientation(segment[6:])
+		}
+		i += 2 + size
+	}
+	return 1
+}
+
+// exifOrientation reads the orientation tag (0x0112) from the first IFD of TIFF-formatted EXIF data.
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 1
+	}
+	entries := int(order.Uint16(tiff[ifd:]))
+	for n := 0; n < entries; n++ {
+		entry := ifd + 2 + n*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			if orientation := int(order.Uint16(tiff[entry+8:])); orientation >= 1 && orientation <= 8 {
+				return orientation
+			}
+			return 1
+		}
+	}
+	return 1
+}
+
+// orientImage transforms img so that an image with the given EXIF orientation is displayed upright.
+func orientImage(img image.Image, orientation int) image.Image {
+	if orientation <= 1 || orientation > 8 {
+		return img
+	}
+
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dstWidth, dstHeight := w, h
+	if orientation >= 5 {
+		dstWidth, dstHeight = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2: // flipped horizontally
+				dx, dy = w-1-x, y
+			case 3: // rotated 180°
+				dx, dy = w-1-x, h-1-y
+			case 4: // flipped vertically
+				dx, dy = x, h-1-y
+			case 5: // transposed
+				dx, dy = y, x
+			case 6: // rotated 90° clockwise to display
+				dx, dy = h-1-y, x
+			case 7: // transversed
+				dx, dy = h-1-y, w-1-x
+			case 8: // rotated 90° anticlockwise to display
+				dx, dy = y, w-1-x
+			}
+			dst.Set(dx, dy, img.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return dst
+}
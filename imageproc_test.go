@@ -0,0 +1,152 @@
+package gohelpertools
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/textproto"
+	"testing"
+)
+
+// testImage returns a width x height image, red in its top-left corner and blue elsewhere.
+func testImage(width, height int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			c := color.RGBA{B: 255, A: 255}
+			if x < width/4 && y < height/4 {
+				c = color.RGBA{R: 255, A: 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+func encodePNG(img image.Image) []byte {
+	var buf bytes.Buffer
+	_ = png.Encode(&buf, img)
+	return buf.Bytes()
+}
+
+// jpegWithOrientation encodes img as JPEG, with an EXIF segment holding the given orientation.
+func jpegWithOrientation(img image.Image, orientation uint16) []byte {
+	var buf bytes.Buffer
+	_ = jpeg.Encode(&buf, img, &jpeg.Options{Quality: 95})
+	data := buf.Bytes()
+
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08\x00\x01")
+	entry := make([]byte, 12)
+	binary.BigEndian.PutUint16(entry[0:], 0x0112)
+	binary.BigEndian.PutUint16(entry[2:], 3) // SHORT
+	binary.BigEndian.PutUint32(entry[4:], 1)
+	binary.BigEndian.PutUint16(entry[8:], orientation)
+	tiff = append(append(tiff, entry...), 0, 0, 0, 0)
+
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	app1 := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(app1[2:], uint16(len(segment)+2))
+	app1 = append(app1, segment...)
+
+	return append(append([]byte{0xFF, 0xD8}, app1...), data[2:]...)
+}
+
+func TestTools_ResizeImage(t *testing.T) {
+	var tools Tools
+
+	var out bytes.Buffer
+	format, err := tools.ResizeImage(bytes.NewReader(encodePNG(testImage(400, 200))), &out, 100, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, _, _ := image.Decode(&out)
+	if format != "png" || img.Bounds().Dx() != 100 || img.Bounds().Dy() != 50 {
+		t.Errorf("expected a 100x50 png, got a %dx%d %s", img.Bounds().Dx(), img.Bounds().Dy(), format)
+	}
+
+	out.Reset()
+	_, _ = tools.ResizeImage(bytes.NewReader(encodePNG(testImage(40, 20))), &out, 100, 100)
+	if img, _, _ := image.Decode(&out); img.Bounds().Dx() != 40 {
+		t.Errorf("expected a small image not to be enlarged, got width %d", img.Bounds().Dx())
+	}
+}
+
+func TestTools_GenerateThumbnail(t *testing.T) {
+	var tools Tools
+
+	var out bytes.Buffer
+	if _, err := tools.GenerateThumbnail(bytes.NewReader(encodePNG(testImage(400, 200))), &out, 50, 50); err != nil {
+		t.Fatal(err)
+	}
+	img, _, _ := image.Decode(&out)
+	if img.Bounds().Dx() != 50 || img.Bounds().Dy() != 50 {
+		t.Errorf("expected a 50x50 thumbnail, got %v", img.Bounds())
+	}
+}
+
+func TestTools_DecodeImageLimits(t *testing.T) {
+	var tools Tools
+	data := encodePNG(testImage(100, 100))
+
+	if _, _, err := tools.DecodeImage(bytes.NewReader(data), ImageOptions{MaxPixels: 5000}); err == nil {
+		t.Error("expected an image over MaxPixels to be rejected")
+	}
+	if _, _, err := tools.DecodeImage(bytes.NewReader(data), ImageOptions{MaxBytes: 10}); err == nil {
+		t.Error("expected an image over MaxBytes to be rejected")
+	}
+	if _, _, err := tools.DecodeImage(bytes.NewReader([]byte("not an image"))); err == nil {
+		t.Error("expected a non-image to be rejected")
+	}
+}
+
+func TestTools_DecodeImageOrientation(t *testing.T) {
+	var tools Tools
+
+	// Orientation 6 means the camera was rotated: the stored 80x40 image is displayed as 40x80, with the
+	// stored top-left corner at the top right.
+	img, format, err := tools.DecodeImage(bytes.NewReader(jpegWithOrientation(testImage(80, 40), 6)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if format != "jpeg" || img.Bounds().Dx() != 40 || img.Bounds().Dy() != 80 {
+		t.Fatalf("expected a 40x80 jpeg, got %v %s", img.Bounds(), format)
+	}
+	if r, _, b, _ := img.At(38, 2).RGBA(); r < b {
+		t.Error("expected the red corner to be at the top right")
+	}
+}
+
+func TestTools_UploadImages(t *testing.T) {
+	tools := New(WithImageUploads(ImageOptions{MaxWidth: 100, MaxHeight: 100, ThumbnailWidth: 20, ThumbnailHeight: 20}))
+	fsys := &MemoryFileSystem{}
+	original := encodePNG(testImage(300, 150))
+
+	req := newUploadRequest(t, map[string][]byte{"photo.png": original}, map[string]textproto.MIMEHeader{
+		"photo.png": {"X-Checksum-Sha256": {hexSHA256(string(original))}},
+	})
+	files, err := tools.UploadFilesTo(req, fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file := files[0]
+	if file.SHA256 != hexSHA256(string(original)) || file.ThumbnailName == "" {
+		t.Errorf("unexpected upload %+v", file)
+	}
+	for name, width := range map[string]int{file.NewFileName: 100, file.ThumbnailName: 20} {
+		f, err := fsys.Get(context.Background(), name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(f)
+		config, _, err := image.DecodeConfig(bytes.NewReader(data))
+		if err != nil || config.Width != width {
+			t.Errorf("expected %s to be %d wide, got %d (%v)", name, width, config.Width, err)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package gohelpertools
+
+import "net/http"
+
+// JSONReader reads JSON request bodies. It is implemented by *Tools, and lets code which depends only on
+// reading JSON be tested with a fake.
+type JSONReader interface {
+	ReadJSON(w http.ResponseWriter, r *http.Request, data any, opts ...JSONOption) error
+}
+
+// JSONWriter writes JSON responses and JSON error responses. It is implemented by *Tools.
+type JSONWriter interface {
+	WriteJSON(w http.ResponseWriter, status int, data interface{}, headers ...http.Header) error
+	ErrorJSON(w http.ResponseWriter, err error, status ...int) error
+}
+
+// Slugger creates URL-safe slugs. It is implemented by *Tools.
+type Slugger interface {
+	Slugify(s string) (string, error)
+}
+
+// Randomizer generates random strings. It is implemented by *Tools.
+type Randomizer interface {
+	RandomString(n int) (string, error)
+	RandomStringFrom(n int, charset string) (string, error)
+}
+
+// Uploader saves files uploaded in multipart requests. It is implemented by *Tools.
+type Uploader interface {
+	UploadFiles(r *http.Request, uploadDir string, rename ...bool) ([]*UploadedFile, error)
+	UploadOneFile(r *http.Request, uploadDir string, rename ...bool) (*UploadedFile, error)
+	UploadFilesTo(r *http.Request, fsys FileSystem, rename ...bool) ([]*UploadedFile, error)
+}
+
+// Make sure Tools keeps implementing the interfaces.
+var (
+	_ JSONReader = (*Tools)(nil)
+	_ JSONWriter = (*Tools)(nil)
+	_ Slugger    = (*Tools)(nil)
+	_ Randomizer = (*Tools)(nil)
+	_ Uploader   = (*Tools)(nil)
+)
@@ -0,0 +1,39 @@
+package gohelpertools
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// fakeSlugger is the kind of test double the interfaces allow downstream code to use.
+type fakeSlugger struct{ err error }
+
+func (f fakeSlugger) Slugify(s string) (string, error) { return "fixed-slug", f.err }
+
+func TestInterfaces_Mockable(t *testing.T) {
+	// A handler which depends only on the interfaces it needs.
+	handler := func(slugger Slugger, writer JSONWriter) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			slug, err := slugger.Slugify(r.URL.Query().Get("title"))
+			if err != nil {
+				_ = writer.ErrorJSON(w, err)
+				return
+			}
+			_ = writer.WriteJSON(w, http.StatusOK, slug)
+		}
+	}
+
+	rr := httptest.NewRecorder()
+	handler(fakeSlugger{}, &Tools{})(rr, httptest.NewRequest("GET", "/?title=anything", nil))
+	if rr.Body.String() != `"fixed-slug"` {
+		t.Errorf("expected the fake slug, got %s", rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	handler(fakeSlugger{err: errors.New("boom")}, &Tools{})(rr, httptest.NewRequest("GET", "/", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 from the fake error, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,255 @@
+package gohelpertools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// IPFilter returns middleware which only lets requests through when the client IP is permitted. Both
+// lists accept single IPs and CIDRs. A client matching deny is always rejected; when allow is not empty,
+// the client must also match one of its entries. The client IP is resolved with RealIP, so that
+// forwarding headers are only believed when they were set by one of our own proxies. Rejected requests
+// receive a 403 JSON response.
+func (t *Tools) IPFilter(allow, deny []string) (func(http.Handler) http.Handler, error) {
+	allowed, err := parsePrefixes(allow)
+	if err != nil {
+		return nil, err
+	}
+
+	denied, err := parsePrefixes(deny)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip, err := netip.ParseAddr(t.RealIP(r))
+			if err != nil {
+				_ = t.ErrorJSON(w, errors.New("unable to determine client IP"), http.StatusForbidden)
+				return
+			}
+
+			if containsAddr(denied, ip) || (len(allowed) > 0 && !containsAddr(allowed, ip)) {
+				_ = t.ErrorJSON(w, errors.New("access denied"), http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}, nil
+}
+
+// RealIP returns the IP address of the client which made r. Forwarding headers are only believed when
+// the request arrived from one of TrustedProxies: in that case the Forwarded (RFC 7239) or X-Forwarded-For
+// chain is walked from right to left, skipping our own proxies, and X-Real-IP is used when there is no
+// chain. Otherwise, the host part of the connection's remote address is returned, since anybody can set
+// these headers.
+func (t *Tools) RealIP(r *http.Request) string {
+	remote := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(remote); err == nil {
+		remote = host
+	}
+
+	trusted := t.trustedProxies(r.Context())
+	if !isTrusted(trusted, remote) {
+		return remote
+	}
+
+	hops := forwardedFor(r.Header.Get("Forwarded"))
+	if len(hops) == 0 {
+		for _, hop := range strings.Split(r.Header.Get("X-Forwarded-For"), ",") {
+			if hop = strings.TrimSpace(hop); hop != "" {
+				hops = append(hops, hop)
+			}
+		}
+	}
+
+	if len(hops) > 0 {
+		for i := len(hops) - 1; i >= 0; i-- {
+			if !isTrusted(trusted, hops[i]) {
+				return hops[i]
+			}
+		}
+		// Every hop is one of our proxies, so the leftmost one is as close to the client as we can get.
+		return hops[0]
+	}
+
+	if realIP := strings.TrimSpace(r.Header.Get("X-Real-IP")); realIP != "" {
+		return realIP
+	}
+
+	return remote
+}
+
+// trustedPrefixes caches the parsed TrustedProxies of each configuration, keyed by its entries joined
+// with newlines, so that they are parsed once rather than on every request.
+var trustedPrefixes sync.Map
+
+// trustedProxies returns the parsed TrustedProxies. If they don't parse, no proxy is trusted, and the error
+// is logged the first time.
+func (t *Tools) trustedProxies(ctx context.Context) []netip.Prefix {
+	if len(t.TrustedProxies) == 0 {
+		return nil
+	}
+	key := strings.Join(t.TrustedProxies, "\n")
+	if prefixes, ok := trustedPrefixes.Load(key); ok {
+		return prefixes.([]netip.Prefix)
+	}
+
+	prefixes, err := parsePrefixes(t.TrustedProxies)
+	if err != nil {
+		prefixes = nil
+	}
+	if _, loaded := trustedPrefixes.LoadOrStore(key, prefixes); !loaded && err != nil {
+		t.LogError(ctx, "invalid TrustedProxies; forwarding headers are ignored", err)
+	}
+	return prefixes
+}
+
+// forwardedFor extracts the "for" addresses from an RFC 7239 Forwarded header, in order, with quotes,
+// IPv6 brackets and ports removed.
+func forwardedFor(header string) []string {
+	var hops []string
+	for _, element := range strings.Split(header, ",") {
+		for _, pair := range strings.Split(element, ";") {
+			key, value, found := strings.Cut(strings.TrimSpace(pair), "=")
+			if !found || !strings.EqualFold(key, "for") {
+				continue
+			}
+
+			value = strings.Trim(value, `"`)
+			if host, _, err := net.SplitHostPort(value); err == nil {
+				value = host
+			}
+			value = strings.TrimSuffix(strings.TrimPrefix(value, "["), "]")
+			if value != "" {
+				hops = append(hops, value)
+			}
+		}
+	}
+	return hops
+}
+
+// parsePrefixes converts a list of IPs and CIDRs into prefixes; single IPs become /32 (or /128) prefixes.
+func parsePrefixes(list []string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, entry := range list {
+		entry = strings.TrimSpace(entry)
+		if strings.Contains(entry, "/") {
+			prefix, err := netip.ParsePrefix(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid CIDR %q", entry)
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+
+		addr, err := netip.ParseAddr(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid IP address %q", entry)
+		}
+		prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+	}
+	return prefixes, nil
+}
+
+// containsAddr reports whether ip is inside any of prefixes.
+func containsAddr(prefixes []netip.Prefix, ip netip.Addr) bool {
+	ip = ip.Unmap()
+	for _, prefix := range prefixes {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// isTrusted reports whether the textual IP s is inside any of prefixes.
+func isTrusted(prefixes []netip.Prefix, s string) bool {
+	ip, err := netip.ParseAddr(s)
+	if err != nil {
+		return false
+	}
+	return containsAddr(prefixes, ip)
+}
+
+// nonPublicPrefixes are ranges which aren't covered by the netip.Addr predicates, but aren't reachable on
+// the public internet either.
+var nonPublicPrefixes, _ = parsePrefixes([]string{
+	"0.0.0.0/8",     // "this network"
+	"100.64.0.0/10", // carrier-grade NAT
+	"192.0.0.0/24",  // IETF protocol assignments
+	"198.18.0.0/15", // benchmarking
+	"240.0.0.0/4",   // reserved, and broadcast
+	"64:ff9b::/96",  // NAT64, which can reach private IPv4 addresses
+	"2001:db8::/32", // documentation
+})
+
+// isPublicAddr reports whether ip is a public unicast address, rather than a loopback, private, link-local
+// or otherwise special one which a server shouldn't be made to connect to on someone else's behalf.
+func isPublicAddr(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return ip.IsValid() && ip.IsGlobalUnicast() && !ip.IsPrivate() && !containsAddr(nonPublicPrefixes, ip)
+}
+
+// CheckPublicURL checks that rawURL is an absolute http or https URL whose host resolves, with the Tools
+// resolver, only to public addresses, so that a URL supplied by a user, such as a webhook endpoint, can't
+// be used to reach internal services (server-side request forgery). Since DNS answers can change between
+// the check and the request, also send such requests with NewPublicHTTPClient.
+func (t *Tools) CheckPublicURL(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" || u.User != nil {
+		return errors.New("the URL must be an absolute http or https URL without credentials")
+	}
+
+	host := u.Hostname()
+	if ip, err := netip.ParseAddr(host); err == nil {
+		if !isPublicAddr(ip) {
+			return fmt.Errorf("the URL host %s is not a public address", host)
+		}
+		return nil
+	}
+	addrs, err := t.resolver().LookupHost(ctx, host)
+	if err != nil {
+		return fmt.Errorf("the URL host %s can't be resolved", host)
+	}
+	for _, addr := range addrs {
+		if ip, err := netip.ParseAddr(addr); err != nil || !isPublicAddr(ip) {
+			return fmt.Errorf("the URL host %s resolves to a non-public address", host)
+		}
+	}
+	return nil
+}
+
+// NewPublicHTTPClient returns an HTTP client which refuses to connect to addresses which aren't public,
+// whatever the DNS says at the time, and doesn't use proxies, for requests to URLs supplied by users. See
+// CheckPublicURL.
+func NewPublicHTTPClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: 10 * time.Second, Control: publicDialControl}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// publicDialControl is a net.Dialer Control function which refuses connections to addresses which aren't
+// public.
+func publicDialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip, err := netip.ParseAddr(host); err != nil || !isPublicAddr(ip) {
+		return fmt.Errorf("connecting to %s is not allowed", host)
+	}
+	return nil
+}
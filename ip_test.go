@@ -0,0 +1,115 @@
+package gohelpertools
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var ipFilterTests = []struct {
+	name         string
+	remoteAddr   string
+	forwardedFor string
+	realIP       string
+	allow        []string
+	deny         []string
+	expectedCode int
+}{
+	{name: "no lists", remoteAddr: "203.0.113.5:1234", expectedCode: http.StatusOK},
+	{name: "allowed", remoteAddr: "203.0.113.5:1234", allow: []string{"203.0.113.0/24"}, expectedCode: http.StatusOK},
+	{name: "not in allow list", remoteAddr: "198.51.100.1:1234", allow: []string{"203.0.113.0/24"}, expectedCode: http.StatusForbidden},
+	{name: "denied", remoteAddr: "203.0.113.5:1234", deny: []string{"203.0.113.5"}, expectedCode: http.StatusForbidden},
+	{name: "deny wins over allow", remoteAddr: "203.0.113.5:1234", allow: []string{"203.0.113.0/24"}, deny: []string{"203.0.113.5"}, expectedCode: http.StatusForbidden},
+	{name: "forwarded through trusted proxy", remoteAddr: "10.0.0.1:1234", forwardedFor: "198.51.100.1, 10.0.0.2", deny: []string{"198.51.100.1"}, expectedCode: http.StatusForbidden},
+	{name: "spoofed header from untrusted client", remoteAddr: "203.0.113.5:1234", forwardedFor: "198.51.100.1", allow: []string{"198.51.100.1"}, expectedCode: http.StatusForbidden},
+	{name: "real ip through trusted proxy", remoteAddr: "10.0.0.1:1234", realIP: "198.51.100.1", allow: []string{"198.51.100.1"}, expectedCode: http.StatusOK},
+}
+
+func TestTools_IPFilter(t *testing.T) {
+	testTools := Tools{TrustedProxies: []string{"10.0.0.0/8"}}
+
+	for _, e := range ipFilterTests {
+		middleware, err := testTools.IPFilter(e.allow, e.deny)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", e.name, err)
+		}
+
+		handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.RemoteAddr = e.remoteAddr
+		if e.forwardedFor != "" {
+			req.Header.Set("X-Forwarded-For", e.forwardedFor)
+		}
+		if e.realIP != "" {
+			req.Header.Set("X-Real-IP", e.realIP)
+		}
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		if rr.Code != e.expectedCode {
+			t.Errorf("%s: expected status %d but got %d", e.name, e.expectedCode, rr.Code)
+		}
+	}
+}
+
+func TestTools_IPFilterInvalidCIDR(t *testing.T) {
+	var testTools Tools
+
+	_, err := testTools.IPFilter([]string{"not-an-ip"}, nil)
+	if err == nil {
+		t.Error("expected error for invalid allow list entry, but none received")
+	}
+}
+
+var realIPTests = []struct {
+	name       string
+	remoteAddr string
+	headers    map[string]string
+	expected   string
+}{
+	{name: "direct connection", remoteAddr: "203.0.113.5:1234", expected: "203.0.113.5"},
+	{name: "untrusted client ignores headers", remoteAddr: "203.0.113.5:1234", headers: map[string]string{"X-Forwarded-For": "1.2.3.4", "X-Real-IP": "1.2.3.4"}, expected: "203.0.113.5"},
+	{name: "x-forwarded-for", remoteAddr: "10.0.0.1:1234", headers: map[string]string{"X-Forwarded-For": "1.2.3.4, 198.51.100.1, 10.0.0.2"}, expected: "198.51.100.1"},
+	{name: "all hops trusted", remoteAddr: "10.0.0.1:1234", headers: map[string]string{"X-Forwarded-For": "10.0.0.3, 10.0.0.2"}, expected: "10.0.0.3"},
+	{name: "forwarded", remoteAddr: "10.0.0.1:1234", headers: map[string]string{"Forwarded": `for=198.51.100.1;proto=https, for="[2001:db8::1]:4711"`}, expected: "2001:db8::1"},
+	{name: "x-real-ip", remoteAddr: "10.0.0.1:1234", headers: map[string]string{"X-Real-IP": "198.51.100.7"}, expected: "198.51.100.7"},
+	{name: "trusted proxy without headers", remoteAddr: "10.0.0.1:1234", expected: "10.0.0.1"},
+}
+
+func TestTools_RealIP(t *testing.T) {
+	testTools := Tools{TrustedProxies: []string{"10.0.0.0/8"}}
+
+	for _, e := range realIPTests {
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.RemoteAddr = e.remoteAddr
+		for key, value := range e.headers {
+			req.Header.Set(key, value)
+		}
+
+		if ip := testTools.RealIP(req); ip != e.expected {
+			t.Errorf("%s: expected %s but got %s", e.name, e.expected, ip)
+		}
+	}
+}
+
+func TestTools_RealIPInvalidTrustedProxies(t *testing.T) {
+	var logs bytes.Buffer
+	testTools := Tools{TrustedProxies: []string{"10.0.0.0/8", "10.0.0.300"}, Logger: slog.New(slog.NewTextHandler(&logs, nil))}
+
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		req.Header.Set("X-Forwarded-For", "203.0.113.7")
+		if ip := testTools.RealIP(req); ip != "10.0.0.1" {
+			t.Errorf("expected no proxy to be trusted, got %s", ip)
+		}
+	}
+	if n := strings.Count(logs.String(), "invalid TrustedProxies"); n != 1 {
+		t.Errorf("expected the invalid configuration to be logged once, got %d times:\n%s", n, logs.String())
+	}
+}
@@ -0,0 +1,128 @@
+package gohelpertools
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+// prettyWriter marks a response as one whose JSON should be indented.
+type prettyWriter struct {
+	http.ResponseWriter
+}
+
+// Unwrap returns the original http.ResponseWriter, for use by http.ResponseController.
+func (pw *prettyWriter) Unwrap() http.ResponseWriter {
+	return pw.ResponseWriter
+}
+
+// PrettyQuery is middleware which lets clients ask for indented JSON responses with a "pretty" query
+// parameter, such as ?pretty, ?pretty=1 or ?pretty=true, which is handy when exploring an API with curl.
+func (t *Tools) PrettyQuery(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if query := r.URL.Query(); query.Has("pretty") {
+			if pretty, _ := strconv.ParseBool(query.Get("pretty")); pretty || query.Get("pretty") == "" {
+				w = &prettyWriter{ResponseWriter: w}
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// prettyRequested reports whether w was wrapped by PrettyQuery.
+func prettyRequested(w http.ResponseWriter) bool {
+	for {
+		switch rw := w.(type) {
+		case *prettyWriter:
+			return true
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = rw.Unwrap()
+		default:
+			return false
+		}
+	}
+}
+
+// MarshalCanonicalJSON marshals v to JSON with the keys of every object, including structs, in sorted
+// order, without insignificant whitespace and without escaping HTML characters, so that equal data always
+// produces the same bytes, for signing or as a cache key. Numbers are written as json.Marshal writes them.
+func MarshalCanonicalJSON(v any) ([]byte, error) {
+	out, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return canonicalizeJSON(out, false)
+}
+
+// formatJSON applies the canonical, HTML escaping and indentation settings of o to the JSON text in.
+func formatJSON(in []byte, o jsonOptions) ([]byte, error) {
+	out := in
+	switch {
+	case o.canonical:
+		var err error
+		if out, err = canonicalizeJSON(out, !o.noEscapeHTML); err != nil {
+			return nil, err
+		}
+	case o.noEscapeHTML:
+		out = unescapeHTML(out)
+	}
+
+	if o.indent == "" {
+		return out, nil
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, out, "", o.indent); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// canonicalizeJSON re-encodes the JSON text in with its object keys sorted. Decoding into maps loses the
+// order of struct fields, and encoding maps sorts their keys.
+func canonicalizeJSON(in []byte, escapeHTML bool) ([]byte, error) {
+	value, err := decodeJSONValue(in)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(escapeHTML)
+	if err := enc.Encode(value); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// unescapeHTML turns the \u003c, \u003e and \u0026 escapes which json.Marshal writes for <, > and & in
+// the JSON text in back into the characters themselves. Other escapes are left alone.
+func unescapeHTML(in []byte) []byte {
+	if !bytes.Contains(in, []byte(`\u00`)) {
+		return in
+	}
+
+	out := make([]byte, 0, len(in))
+	for i := 0; i < len(in); i++ {
+		if in[i] != '\\' || i+1 == len(in) {
+			out = append(out, in[i])
+			continue
+		}
+		if in[i+1] == 'u' && i+6 <= len(in) {
+			switch string(in[i+2 : i+6]) {
+			case "003c", "003C":
+				out, i = append(out, '<'), i+5
+				continue
+			case "003e", "003E":
+				out, i = append(out, '>'), i+5
+				continue
+			case "0026":
+				out, i = append(out, '&'), i+5
+				continue
+			}
+		}
+		// Copy any other escape whole, so an escaped backslash isn't mistaken for the start of one.
+		out, i = append(out, in[i], in[i+1]), i+1
+	}
+	return out
+}
@@ -0,0 +1,78 @@
+package gohelpertools
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type formatPayload struct {
+	Zeta  string         `json:"zeta"`
+	Alpha map[string]int `json:"alpha"`
+	Link  string         `json:"link"`
+}
+
+var formatData = formatPayload{Zeta: "z", Alpha: map[string]int{"b": 2, "a": 1}, Link: `<b>&</b> \u003c`}
+
+func TestTools_WriteJSONFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		tools    Tools
+		opts     []JSONOption
+		expected string
+	}{
+		{name: "default", expected: `{"zeta":"z","alpha":{"a":1,"b":2},"link":"\u003cb\u003e\u0026\u003c/b\u003e \\u003c"}`},
+		{name: "no html escape", opts: []JSONOption{WithoutHTMLEscape()}, expected: `{"zeta":"z","alpha":{"a":1,"b":2},"link":"<b>&</b> \\u003c"}`},
+		{name: "canonical", tools: Tools{CanonicalJSON: true, DisableHTMLEscape: true}, expected: `{"alpha":{"a":1,"b":2},"link":"<b>&</b> \\u003c","zeta":"z"}`},
+		{name: "indent", tools: Tools{JSONIndent: "  "}, opts: []JSONOption{WithCanonical(), WithoutHTMLEscape()}, expected: "{\n  \"alpha\": {\n    \"a\": 1,\n    \"b\": 2\n  },\n  \"link\": \"<b>&</b> \\\\u003c\",\n  \"zeta\": \"z\"\n}"},
+		{name: "compact for call", tools: Tools{JSONIndent: "\t", CanonicalJSON: true}, opts: []JSONOption{WithIndent("")}, expected: `{"alpha":{"a":1,"b":2},"link":"\u003cb\u003e\u0026\u003c/b\u003e \\u003c","zeta":"z"}`},
+	}
+
+	for _, e := range tests {
+		rr := httptest.NewRecorder()
+		if err := e.tools.WriteJSONWith(rr, http.StatusOK, formatData, e.opts...); err != nil {
+			t.Errorf("%s: error not expected, but one received: %s", e.name, err)
+			continue
+		}
+		if rr.Body.String() != e.expected {
+			t.Errorf("%s: expected\n%s\ngot\n%s", e.name, e.expected, rr.Body.String())
+		}
+	}
+}
+
+func TestTools_PrettyQuery(t *testing.T) {
+	var tools Tools
+	handler := tools.PrettyQuery(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = tools.WriteJSON(w, http.StatusOK, map[string]int{"a": 1})
+	}))
+
+	tests := map[string]string{
+		"/":               `{"a":1}`,
+		"/?pretty":        "{\n  \"a\": 1\n}",
+		"/?pretty=1":      "{\n  \"a\": 1\n}",
+		"/?pretty=true":   "{\n  \"a\": 1\n}",
+		"/?pretty=0":      `{"a":1}`,
+		"/?pretty=banana": `{"a":1}`,
+	}
+	for target, expected := range tests {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, target, nil))
+		if rr.Body.String() != expected {
+			t.Errorf("%s: expected %q, got %q", target, expected, rr.Body.String())
+		}
+	}
+}
+
+func TestMarshalCanonicalJSON(t *testing.T) {
+	a, err := MarshalCanonicalJSON(formatData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := MarshalCanonicalJSON(map[string]any{"zeta": "z", "link": formatData.Link, "alpha": map[string]any{"b": 2, "a": 1}})
+	if string(a) != string(b) {
+		t.Errorf("expected equal data to marshal identically, got %s and %s", a, b)
+	}
+	if _, err := MarshalCanonicalJSON(func() {}); err == nil {
+		t.Error("error expected, but none received")
+	}
+}
@@ -0,0 +1,104 @@
+package gohelpertools
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strconv"
+)
+
+// maxSafeInteger is the largest integer a JavaScript number (an IEEE 754 double) holds exactly, 2^53 - 1.
+const maxSafeInteger = 1<<53 - 1
+
+// SafeInt64 is an int64 which is written to JSON as a string when it's beyond ±(2^53 - 1), where JavaScript
+// clients would silently round it, and as a plain number otherwise. It can be read from either form, so
+// clients can send back the ID they were given.
+type SafeInt64 int64
+
+func (n SafeInt64) MarshalJSON() ([]byte, error) {
+	s := strconv.FormatInt(int64(n), 10)
+	if n > maxSafeInteger || n < -maxSafeInteger {
+		return []byte(`"` + s + `"`), nil
+	}
+	return []byte(s), nil
+}
+
+func (n *SafeInt64) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	s := string(bytes.Trim(data, `"`))
+	v, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return errors.New("value is not a 64-bit integer")
+	}
+	*n = SafeInt64(v)
+	return nil
+}
+
+// encodeJSON marshals data for a JSON response, quoting large integers and formatting it as o asks.
+func (t *Tools) encodeJSON(data any, o jsonOptions) ([]byte, error) {
+	out, err := t.encode(data)
+	if err != nil {
+		return nil, err
+	}
+	if o.safeIntegers {
+		out = quoteLargeIntegers(out)
+	}
+	return formatJSON(out, o)
+}
+
+// quoteLargeIntegers returns the JSON text in, which must be valid, with every integer beyond
+// ±(2^53 - 1) turned into a string. Other numbers, including large ones with a fraction or exponent, which
+// are floating point anyway, are left alone.
+func quoteLargeIntegers(in []byte) []byte {
+	var out []byte
+	last := 0
+	for i := 0; i < len(in); i++ {
+		switch c := in[i]; {
+		case c == '"':
+			// Skip the string, including escaped quotes.
+			for i++; i < len(in) && in[i] != '"'; i++ {
+				if in[i] == '\\' {
+					i++
+				}
+			}
+		case c == '-' || (c >= '0' && c <= '9'):
+			start := i
+			integer := true
+			for i+1 < len(in) && bytes.IndexByte([]byte("0123456789.eE+-"), in[i+1]) >= 0 {
+				i++
+				if c := in[i]; c == '.' || c == 'e' || c == 'E' {
+					integer = false
+				}
+			}
+			if integer && !safeInteger(in[start:i+1]) {
+				out = append(out, in[last:start]...)
+				out = append(out, '"')
+				out = append(out, in[start:i+1]...)
+				out = append(out, '"')
+				last = i + 1
+			}
+		}
+	}
+	if out == nil {
+		return in
+	}
+	return append(out, in[last:]...)
+}
+
+// safeInteger reports whether the integer literal digits is within ±(2^53 - 1).
+func safeInteger(digits []byte) bool {
+	digits = bytes.TrimPrefix(digits, []byte("-"))
+	if len(digits) < 16 {
+		return true
+	}
+	n, err := strconv.ParseUint(string(digits), 10, 64)
+	return err == nil && n <= maxSafeInteger
+}
+
+// Ensure SafeInt64 satisfies the encoding/json interfaces.
+var (
+	_ json.Marshaler   = SafeInt64(0)
+	_ json.Unmarshaler = (*SafeInt64)(nil)
+)
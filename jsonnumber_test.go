@@ -0,0 +1,111 @@
+package gohelpertools
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var quoteLargeIntegersTests = []struct {
+	name     string
+	in       string
+	expected string
+}{
+	{"safe", `{"id":9007199254740991,"n":-9007199254740991}`, `{"id":9007199254740991,"n":-9007199254740991}`},
+	{"large", `{"id":9007199254740993,"n":-9007199254740993}`, `{"id":"9007199254740993","n":"-9007199254740993"}`},
+	{"uint64", `[18446744073709551615]`, `["18446744073709551615"]`},
+	{"floats", `[1.5e300,12345678901234567890.5]`, `[1.5e300,12345678901234567890.5]`},
+	{"strings", `{"9007199254740993":"a \"9007199254740993\" b"}`, `{"9007199254740993":"a \"9007199254740993\" b"}`},
+	{"nested", `{"a":[{"b":12345678901234567}],"c":true}`, `{"a":[{"b":"12345678901234567"}],"c":true}`},
+}
+
+func TestQuoteLargeIntegers(t *testing.T) {
+	for _, e := range quoteLargeIntegersTests {
+		if out := string(quoteLargeIntegers([]byte(e.in))); out != e.expected {
+			t.Errorf("%s: expected %s, but got %s", e.name, e.expected, out)
+		}
+	}
+}
+
+func TestTools_WriteJSONSafeIntegers(t *testing.T) {
+	payload := struct {
+		ID    int64   `json:"id"`
+		Count int     `json:"count"`
+		Score float64 `json:"score"`
+	}{ID: 1 << 60, Count: 3, Score: 0.5}
+
+	tools := Tools{DisableEnvelope: true}
+	rr := httptest.NewRecorder()
+	if err := tools.WriteJSONWith(rr, http.StatusOK, payload, WithSafeIntegers()); err != nil {
+		t.Fatal(err)
+	}
+	if body := rr.Body.String(); body != `{"id":"1152921504606846976","count":3,"score":0.5}` {
+		t.Errorf("unexpected body %s", body)
+	}
+
+	rr = httptest.NewRecorder()
+	_ = tools.WriteJSON(rr, http.StatusOK, payload)
+	if !strings.Contains(rr.Body.String(), `"id":1152921504606846976`) {
+		t.Errorf("expected integers to be left alone without the option, got %s", rr.Body.String())
+	}
+
+	// The Tools setting also applies to ETags, so CheckIfMatch agrees with ETagJSON.
+	tools.SafeJSONIntegers = true
+	rr = httptest.NewRecorder()
+	_ = tools.ETagJSON(rr, httptest.NewRequest(http.MethodGet, "/", nil), http.StatusOK, payload)
+	if etag, _ := tools.ETag(payload); etag != rr.Header().Get("ETag") || !strings.Contains(rr.Body.String(), `"id":"1152921504606846976"`) {
+		t.Errorf("expected matching ETags and a quoted ID, got %s %s", etag, rr.Header().Get("ETag"))
+	}
+}
+
+func TestTools_ReadJSONUseNumber(t *testing.T) {
+	var tools Tools
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"id":9007199254740993}`))
+
+	var payload map[string]any
+	if err := tools.ReadJSON(httptest.NewRecorder(), req, &payload, WithUseNumber()); err != nil {
+		t.Fatal(err)
+	}
+	if n, ok := payload["id"].(json.Number); !ok || n.String() != "9007199254740993" {
+		t.Errorf("expected a json.Number holding the exact value, got %#v", payload["id"])
+	}
+}
+
+var safeInt64Tests = []struct {
+	name          string
+	in            string
+	expected      SafeInt64
+	out           string
+	errorExpected bool
+}{
+	{name: "small number", in: `42`, expected: 42, out: `42`},
+	{name: "small string", in: `"42"`, expected: 42, out: `42`},
+	{name: "large string", in: `"9007199254740993"`, expected: 9007199254740993, out: `"9007199254740993"`},
+	{name: "large negative", in: `-9007199254740993`, expected: -9007199254740993, out: `"-9007199254740993"`},
+	{name: "not an integer", in: `"4.2"`, errorExpected: true},
+}
+
+func TestSafeInt64(t *testing.T) {
+	for _, e := range safeInt64Tests {
+		var n SafeInt64
+		err := json.Unmarshal([]byte(e.in), &n)
+		if e.errorExpected {
+			if err == nil {
+				t.Errorf("%s: error expected, but none received", e.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: error not expected, but one received: %s", e.name, err)
+			continue
+		}
+		if n != e.expected {
+			t.Errorf("%s: expected %d, but got %d", e.name, e.expected, n)
+		}
+		if out, _ := json.Marshal(n); string(out) != e.out {
+			t.Errorf("%s: expected %s, but got %s", e.name, e.out, out)
+		}
+	}
+}
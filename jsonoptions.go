@@ -0,0 +1,116 @@
+package gohelpertools
+
+import "net/http"
+
+// JSONOption overrides the Tools-level settings for a single call to ReadJSON, WriteJSONWith or the other
+// JSON helpers, so that individual endpoints can deviate from the defaults without a separate Tools.
+type JSONOption func(*jsonOptions)
+
+// jsonOptions holds the settings for one call, after applying the JSONOptions.
+type jsonOptions struct {
+	maxSize      int
+	allowUnknown bool
+	useNumber    bool
+	safeIntegers bool
+	indent       string
+	noEscapeHTML bool
+	canonical    bool
+	flushEvery   int
+	fields       []string
+	headers      http.Header
+}
+
+// WithMaxSize sets the maximum size, in bytes, of the body read by ReadJSON.
+func WithMaxSize(n int) JSONOption {
+	return func(o *jsonOptions) { o.maxSize = n }
+}
+
+// WithAllowUnknown makes ReadJSON accept fields which aren't in the destination.
+func WithAllowUnknown() JSONOption {
+	return func(o *jsonOptions) { o.allowUnknown = true }
+}
+
+// WithDisallowUnknown makes ReadJSON reject fields which aren't in the destination, even if
+// Tools.AllowUnknownFields is set.
+func WithDisallowUnknown() JSONOption {
+	return func(o *jsonOptions) { o.allowUnknown = false }
+}
+
+// WithUseNumber makes ReadJSON decode numbers in interface values, such as map[string]any, as json.Number
+// rather than float64, so large integers keep their precision.
+func WithUseNumber() JSONOption {
+	return func(o *jsonOptions) { o.useNumber = true }
+}
+
+// WithSafeIntegers makes WriteJSONWith write integers beyond ±(2^53 - 1) as strings, so JavaScript clients
+// don't round them.
+func WithSafeIntegers() JSONOption {
+	return func(o *jsonOptions) { o.safeIntegers = true }
+}
+
+// WithIndent makes WriteJSONWith indent the response with indent, such as "  ". An empty indent writes it
+// compactly, even if Tools.JSONIndent is set.
+func WithIndent(indent string) JSONOption {
+	return func(o *jsonOptions) { o.indent = indent }
+}
+
+// WithoutHTMLEscape makes WriteJSONWith write <, > and & in strings as they are, rather than escaping them for
+// safe embedding in HTML.
+func WithoutHTMLEscape() JSONOption {
+	return func(o *jsonOptions) { o.noEscapeHTML = true }
+}
+
+// WithCanonical makes WriteJSONWith write object keys in sorted order, so the same data always produces the
+// same bytes, for signatures and cache keys.
+func WithCanonical() JSONOption {
+	return func(o *jsonOptions) { o.canonical = true }
+}
+
+// WithFlushEvery makes WriteJSONStream flush the response after every n items, rather than every 100.
+func WithFlushEvery(n int) JSONOption {
+	return func(o *jsonOptions) { o.flushEvery = n }
+}
+
+// WithFields makes WriteJSONWith write only the given fields of the response data (see FilterFields).
+func WithFields(fields ...string) JSONOption {
+	return func(o *jsonOptions) { o.fields = fields }
+}
+
+// WithHeaders adds headers to the response written by WriteJSONWith.
+func WithHeaders(headers http.Header) JSONOption {
+	return func(o *jsonOptions) {
+		if o.headers == nil {
+			o.headers = make(http.Header)
+		}
+		for key, value := range headers {
+			o.headers[key] = value
+		}
+	}
+}
+
+// jsonOptions returns the Tools-level settings with opts applied.
+func (t *Tools) jsonOptions(opts []JSONOption) jsonOptions {
+	o := jsonOptions{
+		maxSize:      defaultMaxUpload,
+		allowUnknown: t.AllowUnknownFields,
+		useNumber:    t.UseJSONNumber,
+		safeIntegers: t.SafeJSONIntegers,
+		indent:       t.JSONIndent,
+		noEscapeHTML: t.DisableHTMLEscape,
+		canonical:    t.CanonicalJSON,
+	}
+	if t.MaxJSONSize != 0 {
+		o.maxSize = t.MaxJSONSize
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// setHeaders copies the headers from WithHeaders to w.
+func (o jsonOptions) setHeaders(w http.ResponseWriter) {
+	for key, value := range o.headers {
+		w.Header()[key] = value
+	}
+}
@@ -0,0 +1,53 @@
+package gohelpertools
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var jsonOptionsTests = []struct {
+	name          string
+	tools         Tools
+	body          string
+	opts          []JSONOption
+	errorExpected bool
+}{
+	{name: "defaults", body: `{"foo":"bar"}`},
+	{name: "too large for call", body: `{"foo":"bar"}`, opts: []JSONOption{WithMaxSize(5)}, errorExpected: true},
+	{name: "larger than tools limit", tools: Tools{MaxJSONSize: 5}, body: `{"foo":"bar"}`, opts: []JSONOption{WithMaxSize(1 << 20)}},
+	{name: "unknown field", body: `{"foo":"bar","baz":1}`, errorExpected: true},
+	{name: "allow unknown for call", body: `{"foo":"bar","baz":1}`, opts: []JSONOption{WithAllowUnknown()}},
+	{name: "disallow unknown for call", tools: Tools{AllowUnknownFields: true}, body: `{"foo":"bar","baz":1}`, opts: []JSONOption{WithDisallowUnknown()}, errorExpected: true},
+}
+
+func TestTools_ReadJSONOptions(t *testing.T) {
+	for _, e := range jsonOptionsTests {
+		var decoded struct {
+			Foo string `json:"foo"`
+		}
+
+		req := httptest.NewRequest("POST", "/", strings.NewReader(e.body))
+		err := e.tools.ReadJSON(httptest.NewRecorder(), req, &decoded, e.opts...)
+		if e.errorExpected && err == nil {
+			t.Errorf("%s: error expected, but none received", e.name)
+		}
+		if !e.errorExpected && err != nil {
+			t.Errorf("%s: error not expected, but one received: %s", e.name, err)
+		}
+	}
+}
+
+func TestTools_WriteJSONWithHeaders(t *testing.T) {
+	var tools Tools
+
+	rr := httptest.NewRecorder()
+	err := tools.WriteJSONWith(rr, http.StatusCreated, "ok", WithHeaders(http.Header{"Location": {"/items/1"}}), WithHeaders(http.Header{"X-Id": {"1"}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rr.Header().Get("Location") != "/items/1" || rr.Header().Get("X-Id") != "1" {
+		t.Errorf("expected headers from every option, got %v", rr.Header())
+	}
+}
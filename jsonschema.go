@@ -0,0 +1,562 @@
+package gohelpertools
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// SchemaError is one way in which a JSON value doesn't match a Schema.
+type SchemaError struct {
+	Path    string `json:"path"`    // JSON Pointer to the offending value, such as "/items/0/name"; empty for the whole body
+	Keyword string `json:"keyword"` // the schema keyword which failed, such as "required"
+	Message string `json:"message"`
+}
+
+// Error returns the path and the message.
+func (e SchemaError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return e.Path + ": " + e.Message
+}
+
+// SchemaErrors is the error returned when a JSON value doesn't match a Schema. ErrorJSON sends it as the
+// data of the error response, so clients can show each message next to the field it's for.
+type SchemaErrors []SchemaError
+
+// Error returns the messages of all the errors.
+func (e SchemaErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return "body does not match the schema: " + strings.Join(messages, "; ")
+}
+
+// ParseSchema parses a JSON Schema, checking that its patterns compile and its references resolve. Parse
+// each endpoint's schema once, when the handler is set up, and pass it to ReadJSONWithSchema, or register it
+// with a SchemaRegistry.
+func ParseSchema(data []byte) (*Schema, error) {
+	var s Schema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	if err := s.check(&s, ""); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// check returns an error if s, which is at path in root, has a pattern or reference which can't be used.
+func (s *Schema) check(root *Schema, path string) error {
+	if s == nil {
+		return nil
+	}
+	if s.Pattern != "" {
+		if _, err := compileSchemaPattern(s.Pattern); err != nil {
+			return fmt.Errorf("invalid schema: pattern at %q: %w", path, err)
+		}
+	}
+	if s.Ref != "" {
+		if _, err := root.resolve(s.Ref); err != nil {
+			return fmt.Errorf("invalid schema: %w", err)
+		}
+	}
+
+	children := map[string]*Schema{"/items": s.Items, "/additionalProperties": s.AdditionalProperties, "/not": s.Not}
+	for key, child := range s.Properties {
+		children["/properties/"+escapeJSONPointer(key)] = child
+	}
+	for key, child := range s.Defs {
+		children["/$defs/"+escapeJSONPointer(key)] = child
+	}
+	for keyword, list := range map[string][]*Schema{"allOf": s.AllOf, "anyOf": s.AnyOf, "oneOf": s.OneOf} {
+		for i, child := range list {
+			children["/"+keyword+"/"+strconv.Itoa(i)] = child
+		}
+	}
+	for childPath, child := range children {
+		if err := child.check(root, path+childPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolve returns the schema referred to by ref, which is relative to s.
+func (s *Schema) resolve(ref string) (*Schema, error) {
+	if ref == "#" {
+		return s, nil
+	}
+	if name, ok := strings.CutPrefix(ref, "#/$defs/"); ok {
+		name = strings.ReplaceAll(strings.ReplaceAll(name, "~1", "/"), "~0", "~")
+		if def, ok := s.Defs[name]; ok {
+			return def, nil
+		}
+	}
+	return nil, fmt.Errorf("unresolvable reference %q", ref)
+}
+
+// Validate checks value, decoded from JSON into an any (ideally with json.Decoder.UseNumber), against the
+// schema, and returns every mismatch found, or nil if it matches.
+func (s *Schema) Validate(value any) SchemaErrors {
+	v := schemaValidator{root: s}
+	v.validate(s, value, "")
+	return v.errs
+}
+
+// ValidateJSON checks the JSON document data against the schema, returning a SchemaErrors if it doesn't
+// match.
+func (s *Schema) ValidateJSON(data []byte) error {
+	value, err := decodeJSONValue(data)
+	if err != nil {
+		return fmt.Errorf("body contains badly-formed JSON: %w", err)
+	}
+	if errs := s.Validate(value); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// ReadJSONWithSchema reads the body of r like ReadJSON, but first validates it against schema, so that
+// requests are rejected with a SchemaErrors listing every problem (with its JSON Pointer path) rather than
+// only the first one the decoder comes across. Pass the error to ErrorJSON to send the list to the client.
+func (t *Tools) ReadJSONWithSchema(w http.ResponseWriter, r *http.Request, schema *Schema, data any, opts ...JSONOption) error {
+	var body json.RawMessage
+	if err := t.ReadJSON(w, r, &body, opts...); err != nil {
+		return err
+	}
+	if err := schema.ValidateJSON(body); err != nil {
+		return err
+	}
+
+	// Decode the body again with the usual checks, such as for unknown fields.
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return t.ReadJSON(w, r, data, opts...)
+}
+
+// SchemaRegistry holds the JSON Schema of each endpoint, registered once at startup under a name, such as
+// "POST /users". Its middleware looks up the schema of each request and validates the body against it, so
+// handlers can read the body with ReadJSON rather than pass a schema to ReadJSONWithSchema:
+//
+//	schemas := &gohelpertools.SchemaRegistry{}
+//	if err := schemas.Register("POST /users", createUserSchema); err != nil {
+//		log.Fatal(err)
+//	}
+//	handler = schemas.Middleware(handler)
+//
+// Requests for which no schema is registered are passed on unchecked.
+type SchemaRegistry struct {
+	Route func(r *http.Request) string // returns the name of the schema for r; defaults to the method and path, such as "POST /users"
+	Tools *Tools                       // used to read bodies and write JSON error responses; a zero Tools is used if nil
+
+	mu      sync.RWMutex
+	schemas map[string]*Schema
+}
+
+// Register parses the JSON Schema data, as ParseSchema does, and registers it under name, replacing any
+// schema already registered under it.
+func (sr *SchemaRegistry) Register(name string, data []byte) error {
+	schema, err := ParseSchema(data)
+	if err != nil {
+		return fmt.Errorf("schema %s: %w", name, err)
+	}
+	sr.Add(name, schema)
+	return nil
+}
+
+// Add registers a parsed schema under name, replacing any schema already registered under it.
+func (sr *SchemaRegistry) Add(name string, schema *Schema) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	if sr.schemas == nil {
+		sr.schemas = make(map[string]*Schema)
+	}
+	sr.schemas[name] = schema
+}
+
+// Lookup returns the schema registered under name.
+func (sr *SchemaRegistry) Lookup(name string) (*Schema, bool) {
+	sr.mu.RLock()
+	defer sr.mu.RUnlock()
+	schema, ok := sr.schemas[name]
+	return schema, ok
+}
+
+// Middleware validates the body of each request against the schema registered for its route. A body
+// which can't be read is rejected with a 400 JSON error, and one which doesn't match with a 422 listing
+// every problem; otherwise the body is passed on to next to be read again.
+func (sr *SchemaRegistry) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := r.Method + " " + r.URL.Path
+		if sr.Route != nil {
+			name = sr.Route(r)
+		}
+		schema, ok := sr.Lookup(name)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		t := toolsOrDefault(sr.Tools)
+		var body json.RawMessage
+		if err := t.ReadJSON(w, r, &body); err != nil {
+			_ = t.ErrorJSON(w, err)
+			return
+		}
+		if err := schema.ValidateJSON(body); err != nil {
+			_ = t.ErrorJSON(w, err, http.StatusUnprocessableEntity)
+			return
+		}
+
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		r.ContentLength = int64(len(body))
+		next.ServeHTTP(w, r)
+	})
+}
+
+// schemaValidator collects the errors found while validating one value.
+type schemaValidator struct {
+	root  *Schema
+	errs  SchemaErrors
+	depth int
+}
+
+// maxSchemaDepth limits how deeply references are followed, so that a schema which refers to itself
+// without consuming any of the value can't recurse forever.
+const maxSchemaDepth = 256
+
+func (v *schemaValidator) fail(path, keyword, format string, args ...any) {
+	v.errs = append(v.errs, SchemaError{Path: path, Keyword: keyword, Message: fmt.Sprintf(format, args...)})
+}
+
+// matches reports whether value matches s, without recording any errors.
+func (v *schemaValidator) matches(s *Schema, value any, path string) bool {
+	sub := schemaValidator{root: v.root, depth: v.depth}
+	sub.validate(s, value, path)
+	return len(sub.errs) == 0
+}
+
+func (v *schemaValidator) validate(s *Schema, value any, path string) {
+	if s == nil {
+		return
+	}
+
+	if s.Ref != "" {
+		target, err := v.root.resolve(s.Ref)
+		if err != nil || v.depth >= maxSchemaDepth {
+			v.fail(path, "$ref", "unresolvable reference %q", s.Ref)
+		} else {
+			v.depth++
+			v.validate(target, value, path)
+			v.depth--
+		}
+	}
+
+	if len(s.Type) > 0 && !schemaTypeMatches(s.Type, value) {
+		v.fail(path, "type", "must be of type %s, not %s", strings.Join(s.Type, " or "), jsonTypeOf(value))
+		return
+	}
+	if s.Const != nil {
+		if expected, err := decodeJSONValue(s.Const); err == nil && !jsonEqual(expected, normalizeJSONValue(value)) {
+			v.fail(path, "const", "must be %s", s.Const)
+		}
+	}
+	if len(s.Enum) > 0 {
+		found := false
+		for _, raw := range s.Enum {
+			if option, err := decodeJSONValue(raw); err == nil && jsonEqual(option, normalizeJSONValue(value)) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			options := make([]string, len(s.Enum))
+			for i, raw := range s.Enum {
+				options[i] = string(raw)
+			}
+			v.fail(path, "enum", "must be one of %s", strings.Join(options, ", "))
+		}
+	}
+
+	switch value := value.(type) {
+	case map[string]any:
+		v.validateObject(s, value, path)
+	case []any:
+		v.validateArray(s, value, path)
+	case string:
+		v.validateString(s, value, path)
+	case json.Number, float64:
+		if f, ok := jsonNumberValue(value); ok {
+			v.validateNumber(s, f, path)
+		}
+	}
+
+	for _, sub := range s.AllOf {
+		v.validate(sub, value, path)
+	}
+	if len(s.AnyOf) > 0 {
+		matched := false
+		for _, sub := range s.AnyOf {
+			if v.matches(sub, value, path) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			v.fail(path, "anyOf", "must match at least one of the allowed schemas")
+		}
+	}
+	if len(s.OneOf) > 0 {
+		matched := 0
+		for _, sub := range s.OneOf {
+			if v.matches(sub, value, path) {
+				matched++
+			}
+		}
+		if matched != 1 {
+			v.fail(path, "oneOf", "must match exactly one of the allowed schemas, but matches %d", matched)
+		}
+	}
+	if s.Not != nil && v.matches(s.Not, value, path) {
+		if isEmptySchema(s.Not) {
+			v.fail(path, "not", "is not allowed")
+		} else {
+			v.fail(path, "not", "must not match the schema")
+		}
+	}
+}
+
+func (v *schemaValidator) validateObject(s *Schema, object map[string]any, path string) {
+	for _, key := range s.Required {
+		if _, ok := object[key]; !ok {
+			v.fail(path+"/"+escapeJSONPointer(key), "required", "is required")
+		}
+	}
+	if s.MinProperties != nil && len(object) < *s.MinProperties {
+		v.fail(path, "minProperties", "must have at least %d properties", *s.MinProperties)
+	}
+	if s.MaxProperties != nil && len(object) > *s.MaxProperties {
+		v.fail(path, "maxProperties", "must have at most %d properties", *s.MaxProperties)
+	}
+
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		childPath := path + "/" + escapeJSONPointer(key)
+		if property, ok := s.Properties[key]; ok {
+			v.validate(property, object[key], childPath)
+		} else if s.AdditionalProperties != nil {
+			if isEmptySchema(s.AdditionalProperties.Not) {
+				v.fail(childPath, "additionalProperties", "is not allowed")
+				continue
+			}
+			v.validate(s.AdditionalProperties, object[key], childPath)
+		}
+	}
+}
+
+func (v *schemaValidator) validateArray(s *Schema, array []any, path string) {
+	if s.MinItems != nil && len(array) < *s.MinItems {
+		v.fail(path, "minItems", "must have at least %d items", *s.MinItems)
+	}
+	if s.MaxItems != nil && len(array) > *s.MaxItems {
+		v.fail(path, "maxItems", "must have at most %d items", *s.MaxItems)
+	}
+	if s.UniqueItems {
+	unique:
+		for i := range array {
+			for j := 0; j < i; j++ {
+				if jsonEqual(normalizeJSONValue(array[i]), normalizeJSONValue(array[j])) {
+					v.fail(path, "uniqueItems", "must not contain duplicates, but items %d and %d are equal", j, i)
+					break unique
+				}
+			}
+		}
+	}
+	if s.Items != nil {
+		for i, item := range array {
+			v.validate(s.Items, item, path+"/"+strconv.Itoa(i))
+		}
+	}
+}
+
+func (v *schemaValidator) validateString(s *Schema, str string, path string) {
+	length := utf8.RuneCountInString(str)
+	if s.MinLength != nil && length < *s.MinLength {
+		v.fail(path, "minLength", "must be at least %d characters long", *s.MinLength)
+	}
+	if s.MaxLength != nil && length > *s.MaxLength {
+		v.fail(path, "maxLength", "must be at most %d characters long", *s.MaxLength)
+	}
+	if s.Pattern != "" {
+		re, err := compileSchemaPattern(s.Pattern)
+		if err != nil || !re.MatchString(str) {
+			v.fail(path, "pattern", "must match the pattern %s", s.Pattern)
+		}
+	}
+	if s.Format != "" && !schemaFormatMatches(s.Format, str) {
+		v.fail(path, "format", "must be a valid %s", s.Format)
+	}
+}
+
+func (v *schemaValidator) validateNumber(s *Schema, f float64, path string) {
+	if s.Minimum != nil && f < *s.Minimum {
+		v.fail(path, "minimum", "must be at least %v", *s.Minimum)
+	}
+	if s.Maximum != nil && f > *s.Maximum {
+		v.fail(path, "maximum", "must be at most %v", *s.Maximum)
+	}
+	if s.ExclusiveMinimum != nil && f <= *s.ExclusiveMinimum {
+		v.fail(path, "exclusiveMinimum", "must be greater than %v", *s.ExclusiveMinimum)
+	}
+	if s.ExclusiveMaximum != nil && f >= *s.ExclusiveMaximum {
+		v.fail(path, "exclusiveMaximum", "must be less than %v", *s.ExclusiveMaximum)
+	}
+	if s.MultipleOf != nil && *s.MultipleOf > 0 {
+		// Allow for the rounding of decimal multiples such as 0.01.
+		if q := f / *s.MultipleOf; math.Abs(q-math.Round(q)) > 1e-9 {
+			v.fail(path, "multipleOf", "must be a multiple of %v", *s.MultipleOf)
+		}
+	}
+}
+
+// isEmptySchema reports whether s has no keywords, and so matches any value.
+func isEmptySchema(s *Schema) bool {
+	if s == nil {
+		return false
+	}
+	out, _ := json.Marshal(s)
+	return string(out) == "{}"
+}
+
+// jsonTypeOf returns the JSON Schema type name of a decoded JSON value.
+func jsonTypeOf(value any) string {
+	switch value := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	case json.Number, float64:
+		if f, ok := jsonNumberValue(value); ok && f == math.Trunc(f) && !math.IsInf(f, 0) {
+			return "integer"
+		}
+		return "number"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// schemaTypeMatches reports whether value is of one of types. Integers are numbers too, and numbers with
+// a zero fraction, such as 1.0, are integers.
+func schemaTypeMatches(types SchemaType, value any) bool {
+	actual := jsonTypeOf(value)
+	return types.has(actual) || (actual == "integer" && types.has("number"))
+}
+
+// jsonNumberValue returns a json.Number or float64 as a float64.
+func jsonNumberValue(value any) (float64, bool) {
+	switch value := value.(type) {
+	case json.Number:
+		f, err := value.Float64()
+		return f, err == nil
+	case float64:
+		return value, true
+	}
+	return 0, false
+}
+
+// normalizeJSONValue converts the float64s in a value decoded without UseNumber to json.Number, so it can
+// be compared with jsonEqual.
+func normalizeJSONValue(value any) any {
+	switch value := value.(type) {
+	case float64:
+		return json.Number(strconv.FormatFloat(value, 'f', -1, 64))
+	case map[string]any:
+		out := make(map[string]any, len(value))
+		for key, child := range value {
+			out[key] = normalizeJSONValue(child)
+		}
+		return out
+	case []any:
+		out := make([]any, len(value))
+		for i, child := range value {
+			out[i] = normalizeJSONValue(child)
+		}
+		return out
+	}
+	return value
+}
+
+var schemaUUIDPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// schemaFormatMatches reports whether s is valid for format. Unknown formats are treated as annotations,
+// as the specification requires, and always match.
+func schemaFormatMatches(format, s string) bool {
+	switch format {
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, s)
+		return err == nil
+	case "date":
+		_, err := time.Parse(time.DateOnly, s)
+		return err == nil
+	case "email":
+		address, err := mail.ParseAddress(s)
+		return err == nil && address.Address == s
+	case "uuid":
+		return schemaUUIDPattern.MatchString(s)
+	case "uri":
+		u, err := url.Parse(s)
+		return err == nil && u.Scheme != ""
+	case "ipv4":
+		ip := net.ParseIP(s)
+		return ip != nil && ip.To4() != nil && !strings.Contains(s, ":")
+	case "ipv6":
+		return net.ParseIP(s) != nil && strings.Contains(s, ":")
+	}
+	return true
+}
+
+// schemaPatterns caches the compiled "pattern" regular expressions.
+var schemaPatterns sync.Map
+
+// compileSchemaPattern compiles a "pattern" keyword. Go's regular expressions are RE2 rather than ECMA-262,
+// so lookarounds and backreferences aren't supported.
+func compileSchemaPattern(pattern string) (*regexp.Regexp, error) {
+	if re, ok := schemaPatterns.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	schemaPatterns.Store(pattern, re)
+	return re, nil
+}
+
+// escapeJSONPointer escapes a key for use as a JSON Pointer reference token.
+func escapeJSONPointer(key string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
@@ -0,0 +1,201 @@
+package gohelpertools
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const userSchema = `{
+	"$schema": "https://json-schema.org/draft/2020-12/schema",
+	"type": "object",
+	"required": ["name", "email", "age"],
+	"additionalProperties": false,
+	"properties": {
+		"name": {"type": "string", "minLength": 2, "maxLength": 10},
+		"email": {"type": "string", "format": "email"},
+		"age": {"type": "integer", "minimum": 18, "exclusiveMaximum": 150},
+		"role": {"enum": ["admin", "member"]},
+		"tags": {"type": "array", "items": {"type": "string", "pattern": "^[a-z]+$"}, "uniqueItems": true, "maxItems": 3},
+		"address": {"$ref": "#/$defs/address"},
+		"price": {"type": "number", "multipleOf": 0.01},
+		"contact": {"oneOf": [{"required": ["phone"]}, {"required": ["fax"]}]}
+	},
+	"$defs": {
+		"address": {"type": "object", "required": ["city"], "properties": {"city": {"type": "string"}}}
+	}
+}`
+
+func TestSchema_Validate(t *testing.T) {
+	schema, err := ParseSchema([]byte(userSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		body   string
+		errors []string // path and keyword of each expected error
+	}{
+		{name: "valid", body: `{"name":"Ada","email":"ada@example.com","age":36,"role":"admin","tags":["a","b"],"address":{"city":"London"},"price":19.99}`},
+		{name: "integer with zero fraction", body: `{"name":"Ada","email":"ada@example.com","age":36.0}`},
+		{name: "missing fields", body: `{"name":"Ada"}`, errors: []string{"/email required", "/age required"}},
+		{name: "wrong type", body: `{"name":7,"email":"ada@example.com","age":"36"}`, errors: []string{"/age type", "/name type"}},
+		{name: "ranges", body: `{"name":"A","email":"ada@example.com","age":17}`, errors: []string{"/age minimum", "/name minLength"}},
+		{name: "exclusive maximum", body: `{"name":"Ada","email":"ada@example.com","age":150}`, errors: []string{"/age exclusiveMaximum"}},
+		{name: "not an integer", body: `{"name":"Ada","email":"ada@example.com","age":20.5}`, errors: []string{"/age type"}},
+		{name: "format", body: `{"name":"Ada","email":"not an email","age":36}`, errors: []string{"/email format"}},
+		{name: "enum", body: `{"name":"Ada","email":"ada@example.com","age":36,"role":"owner"}`, errors: []string{"/role enum"}},
+		{name: "array", body: `{"name":"Ada","email":"ada@example.com","age":36,"tags":["a","B","a","c"]}`, errors: []string{"/tags maxItems", "/tags uniqueItems", "/tags/1 pattern"}},
+		{name: "reference", body: `{"name":"Ada","email":"ada@example.com","age":36,"address":{}}`, errors: []string{"/address/city required"}},
+		{name: "multiple of", body: `{"name":"Ada","email":"ada@example.com","age":36,"price":1.005}`, errors: []string{"/price multipleOf"}},
+		{name: "one of", body: `{"name":"Ada","email":"ada@example.com","age":36,"contact":{"phone":"1","fax":"2"}}`, errors: []string{"/contact oneOf"}},
+		{name: "additional property", body: `{"name":"Ada","email":"ada@example.com","age":36,"a/b":1}`, errors: []string{"/a~1b additionalProperties"}},
+		{name: "not an object", body: `[]`, errors: []string{" type"}},
+	}
+
+	for _, e := range tests {
+		err := schema.ValidateJSON([]byte(e.body))
+		if len(e.errors) == 0 {
+			if err != nil {
+				t.Errorf("%s: error not expected, but one received: %s", e.name, err)
+			}
+			continue
+		}
+
+		errs, ok := err.(SchemaErrors)
+		if !ok {
+			t.Errorf("%s: expected SchemaErrors, got %v", e.name, err)
+			continue
+		}
+		var got []string
+		for _, err := range errs {
+			got = append(got, err.Path+" "+err.Keyword)
+		}
+		if strings.Join(got, ", ") != strings.Join(e.errors, ", ") {
+			t.Errorf("%s: expected errors %v, got %v", e.name, e.errors, got)
+		}
+	}
+}
+
+func TestParseSchema(t *testing.T) {
+	tests := []struct {
+		name          string
+		schema        string
+		errorExpected bool
+	}{
+		{name: "boolean schemas", schema: `{"properties":{"a":true,"b":false}}`},
+		{name: "recursive", schema: `{"type":"object","properties":{"children":{"type":"array","items":{"$ref":"#"}}}}`},
+		{name: "bad pattern", schema: `{"properties":{"a":{"pattern":"("}}}`, errorExpected: true},
+		{name: "missing definition", schema: `{"$ref":"#/$defs/missing"}`, errorExpected: true},
+		{name: "remote reference", schema: `{"$ref":"https://example.com/schema.json"}`, errorExpected: true},
+		{name: "invalid json", schema: `{"type":`, errorExpected: true},
+	}
+
+	for _, e := range tests {
+		_, err := ParseSchema([]byte(e.schema))
+		if e.errorExpected && err == nil {
+			t.Errorf("%s: error expected, but none received", e.name)
+		}
+		if !e.errorExpected && err != nil {
+			t.Errorf("%s: error not expected, but one received: %s", e.name, err)
+		}
+	}
+
+	schema, _ := ParseSchema([]byte(`{"type":"object","properties":{"children":{"type":"array","items":{"$ref":"#"}}}}`))
+	if err := schema.ValidateJSON([]byte(`{"children":[{"children":[{"children":"x"}]}]}`)); err == nil || !strings.Contains(err.Error(), "/children/0/children/0/children") {
+		t.Errorf("expected the nested error to be found through the recursive reference, got %v", err)
+	}
+
+	closed, _ := ParseSchema([]byte(`{"properties":{"a":true,"b":false}}`))
+	if err := closed.ValidateJSON([]byte(`{"a":1,"b":2}`)); err == nil || !strings.Contains(err.Error(), "/b: is not allowed") {
+		t.Errorf("expected the false schema to reject b, got %v", err)
+	}
+}
+
+func TestTools_ReadJSONWithSchema(t *testing.T) {
+	schema, _ := ParseSchema([]byte(userSchema))
+	var tools Tools
+
+	var user struct {
+		Name  string `json:"name"`
+		Email string `json:"email"`
+		Age   int    `json:"age"`
+	}
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":"Ada","email":"ada@example.com","age":36}`))
+	if err := tools.ReadJSONWithSchema(httptest.NewRecorder(), req, schema, &user); err != nil || user.Name != "Ada" || user.Age != 36 {
+		t.Errorf("expected a valid body to be decoded, got %+v: %v", user, err)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":"A","age":"x"}`))
+	err := tools.ReadJSONWithSchema(httptest.NewRecorder(), req, schema, &user)
+	if err == nil {
+		t.Fatal("error expected, but none received")
+	}
+
+	rr := httptest.NewRecorder()
+	_ = tools.ErrorJSON(rr, err, http.StatusUnprocessableEntity)
+	var response struct {
+		Error bool `json:"error"`
+		Data  struct {
+			Errors []SchemaError `json:"errors"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	if rr.Code != http.StatusUnprocessableEntity || !response.Error || len(response.Data.Errors) != 3 || response.Data.Errors[0].Path != "/email" {
+		t.Errorf("expected the errors in the envelope, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	_ = tools.ErrorJSON(&envelopeWriter{ResponseWriter: rr}, err)
+	if !strings.Contains(rr.Body.String(), `"errors":[{"path":"/email","keyword":"required"`) {
+		t.Errorf("expected the errors without the envelope too, got %s", rr.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":`))
+	if err := tools.ReadJSONWithSchema(httptest.NewRecorder(), req, schema, &user); err == nil || !strings.Contains(err.Error(), "badly-formed") {
+		t.Errorf("expected badly-formed JSON to be reported as usual, got %v", err)
+	}
+}
+
+func TestSchemaRegistry_Middleware(t *testing.T) {
+	schemas := &SchemaRegistry{}
+	if err := schemas.Register("POST /users", []byte(userSchema)); err != nil {
+		t.Fatal(err)
+	}
+	if err := schemas.Register("POST /broken", []byte(`{"pattern": "("}`)); err == nil {
+		t.Error("invalid schema: error expected, but none received")
+	}
+
+	var tools Tools
+	handler := schemas.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		if err := tools.ReadJSON(w, r, &body); err != nil {
+			t.Errorf("%s: error not expected, but one received: %s", r.URL.Path, err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	tests := []struct {
+		name   string
+		path   string
+		body   string
+		status int
+	}{
+		{"valid", "/users", `{"name":"Ada","email":"ada@example.com","age":36}`, http.StatusNoContent},
+		{"invalid", "/users", `{"name":"A","age":"x"}`, http.StatusUnprocessableEntity},
+		{"not json", "/users", `{"name":`, http.StatusBadRequest},
+		{"no schema", "/other", `{"anything":true}`, http.StatusNoContent},
+	}
+	for _, e := range tests {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, e.path, strings.NewReader(e.body)))
+		if rr.Code != e.status {
+			t.Errorf("%s: expected status %d, got %d: %s", e.name, e.status, rr.Code, rr.Body)
+		}
+	}
+}
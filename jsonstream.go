@@ -0,0 +1,126 @@
+package gohelpertools
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+)
+
+// defaultFlushEvery is how many items WriteJSONStream writes between flushes.
+const defaultFlushEvery = 100
+
+// WriteJSONStream writes the items produced by iter to the client as a JSON array, encoding and sending
+// them as they come rather than marshalling the whole collection into memory, so a response can hold
+// hundreds of thousands of rows. iter calls yield for each item, and should stop when yield returns false,
+// which happens when the client has gone away. The response is flushed every 100 items (see
+// WithFlushEvery); the formatting and field options of WriteJSONWith apply to each item. A channel can be streamed with:
+//
+//	t.WriteJSONStream(w, http.StatusOK, func(yield func(any) bool) {
+//		for row := range rows {
+//			if !yield(row) {
+//				return
+//			}
+//		}
+//	})
+//
+// If the first item can't be encoded, nothing has been sent and the error is returned, so the caller can
+// still respond with ErrorJSON. Once the response has started, an error leaves the array unterminated, so
+// that the client sees the response is incomplete rather than mistaking it for the whole collection.
+func (t *Tools) WriteJSONStream(w http.ResponseWriter, status int, iter func(yield func(any) bool), opts ...JSONOption) error {
+	o := t.jsonOptions(opts)
+	if o.indent == "" && prettyRequested(w) {
+		o.indent = "  "
+	}
+	indent := o.indent
+	o.indent = ""
+	if o.fields == nil {
+		o.fields = requestedFields(w)
+	}
+
+	flushEvery := o.flushEvery
+	if flushEvery <= 0 {
+		flushEvery = defaultFlushEvery
+	}
+	flusher := findFlusher(w)
+	bw := bufio.NewWriterSize(w, 32<<10)
+
+	start := func() {
+		o.setHeaders(w)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+	}
+	flush := func() error {
+		if err := bw.Flush(); err != nil {
+			return err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	}
+
+	count := 0
+	var err error
+	iter(func(item any) bool {
+		if err != nil {
+			return false // iter carried on after being told to stop
+		}
+		if len(o.fields) > 0 {
+			if item, err = t.FilterFields(item, o.fields); err != nil {
+				return false
+			}
+		}
+		var out []byte
+		if out, err = t.encodeJSON(item, o); err != nil {
+			return false
+		}
+		if indent != "" {
+			var buf bytes.Buffer
+			if err = json.Indent(&buf, out, indent, indent); err != nil {
+				return false
+			}
+			out = append([]byte(indent), buf.Bytes()...)
+		}
+
+		switch {
+		case count == 0:
+			start()
+			_ = bw.WriteByte('[')
+		default:
+			_ = bw.WriteByte(',')
+		}
+		if indent != "" {
+			_ = bw.WriteByte('\n')
+		}
+		if _, err = bw.Write(out); err != nil {
+			return false
+		}
+
+		count++
+		if count%flushEvery == 0 {
+			if err = flush(); err != nil {
+				return false
+			}
+		}
+		return true
+	})
+
+	if err != nil {
+		if count > 0 {
+			_ = flush()
+		}
+		return err
+	}
+
+	switch {
+	case count == 0:
+		start()
+		_, _ = bw.WriteString("[]")
+	case indent != "":
+		_, _ = bw.WriteString("\n]")
+	default:
+		_ = bw.WriteByte(']')
+	}
+	return flush()
+}
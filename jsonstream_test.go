@@ -0,0 +1,100 @@
+package gohelpertools
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// countingFlusher records how often the response is flushed.
+type countingFlusher struct {
+	*httptest.ResponseRecorder
+	flushes int
+}
+
+func (f *countingFlusher) Flush() { f.flushes++ }
+
+// failingWriter fails every write, like a connection the client has closed.
+type failingWriter struct {
+	*httptest.ResponseRecorder
+}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("connection reset") }
+
+func numbers(n int) func(yield func(any) bool) {
+	return func(yield func(any) bool) {
+		for i := 0; i < n; i++ {
+			if !yield(map[string]int{"id": i}) {
+				return
+			}
+		}
+	}
+}
+
+func TestTools_WriteJSONStream(t *testing.T) {
+	var tools Tools
+
+	tests := []struct {
+		name     string
+		iter     func(yield func(any) bool)
+		opts     []JSONOption
+		expected string
+	}{
+		{name: "empty", iter: numbers(0), expected: `[]`},
+		{name: "items", iter: numbers(3), expected: `[{"id":0},{"id":1},{"id":2}]`},
+		{name: "indented", iter: numbers(2), opts: []JSONOption{WithIndent("  ")}, expected: "[\n  {\n    \"id\": 0\n  },\n  {\n    \"id\": 1\n  }\n]"},
+	}
+	for _, e := range tests {
+		rr := httptest.NewRecorder()
+		if err := tools.WriteJSONStream(rr, http.StatusOK, e.iter, e.opts...); err != nil {
+			t.Errorf("%s: error not expected, but one received: %s", e.name, err)
+			continue
+		}
+		if rr.Body.String() != e.expected || rr.Header().Get("Content-Type") != "application/json" {
+			t.Errorf("%s: expected %q, got %q", e.name, e.expected, rr.Body.String())
+		}
+	}
+
+	w := &countingFlusher{ResponseRecorder: httptest.NewRecorder()}
+	if err := tools.WriteJSONStream(w, http.StatusOK, numbers(25000), WithFlushEvery(1000)); err != nil {
+		t.Fatal(err)
+	}
+	var items []map[string]int
+	if err := json.Unmarshal(w.Body.Bytes(), &items); err != nil || len(items) != 25000 || items[24999]["id"] != 24999 {
+		t.Errorf("expected 25000 items, got %d: %v", len(items), err)
+	}
+	if w.flushes != 26 {
+		t.Errorf("expected 26 flushes, got %d", w.flushes)
+	}
+}
+
+func TestTools_WriteJSONStreamErrors(t *testing.T) {
+	var tools Tools
+
+	rr := httptest.NewRecorder()
+	err := tools.WriteJSONStream(rr, http.StatusOK, func(yield func(any) bool) { yield(func() {}) })
+	if err == nil || rr.Body.Len() != 0 {
+		t.Errorf("expected an unencodable first item to fail before anything is written, got %v %q", err, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	err = tools.WriteJSONStream(rr, http.StatusOK, func(yield func(any) bool) {
+		if yield(1) {
+			yield(func() {})
+		}
+	})
+	if err == nil || rr.Body.String() != "[1" {
+		t.Errorf("expected the array to be left unterminated, got %v %q", err, rr.Body.String())
+	}
+
+	produced := 0
+	err = tools.WriteJSONStream(failingWriter{httptest.NewRecorder()}, http.StatusOK, func(yield func(any) bool) {
+		for produced = 1; yield(produced); produced++ {
+		}
+	}, WithFlushEvery(10))
+	if err == nil || produced != 10 {
+		t.Errorf("expected the producer to be stopped at the first failed flush, got %v after %d items", err, produced)
+	}
+}
@@ -0,0 +1,229 @@
+package gohelpertools
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Claims holds the claims of a JSON Web Token. Registered claims such as "sub", "exp" and "iss" live
+// alongside any custom claims.
+type Claims map[string]any
+
+// Subject returns the "sub" claim, or an empty string if it is not set.
+func (c Claims) Subject() string {
+	s, _ := c["sub"].(string)
+	return s
+}
+
+// TokenManager issues and validates JSON Web Tokens signed with HS256 or RS256.
+type TokenManager struct {
+	Algorithm  string          // "HS256" (the default) or "RS256"
+	Secret     []byte          // key used for HS256
+	PrivateKey *rsa.PrivateKey // key used to sign RS256 tokens
+	PublicKey  *rsa.PublicKey  // key used to verify RS256 tokens; taken from PrivateKey if nil
+	Issuer     string          // if set, added as "iss" to new tokens and required when validating
+	Audience   string          // if set, added as "aud" to new tokens and required when validating
+	Leeway     time.Duration   // clock skew allowed when checking "exp" and "nbf"
+	Tools      *Tools          // used to write JSON error responses; a zero Tools is used if nil
+}
+
+// GenerateJWT returns a signed token containing claims, which expires after ttl. The "iat", "exp" and, if
+// configured, "iss" and "aud" claims are set automatically; claims itself is not modified.
+func (m *TokenManager) GenerateJWT(claims Claims, ttl time.Duration) (string, error) {
+	now := time.Now()
+	payload := Claims{}
+	for key, value := range claims {
+		payload[key] = value
+	}
+	payload["iat"] = now.Unix()
+	payload["exp"] = now.Add(ttl).Unix()
+	if m.Issuer != "" {
+		payload["iss"] = m.Issuer
+	}
+	if m.Audience != "" {
+		payload["aud"] = m.Audience
+	}
+
+	header, err := json.Marshal(map[string]string{"alg": m.algorithm(), "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(body)
+	signature, err := m.sign(unsigned)
+	if err != nil {
+		return "", err
+	}
+
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// ValidateJWT verifies the signature of token and checks its "exp", "nbf", "iss" and "aud" claims,
+// returning the claims if the token is valid. Tokens signed with any algorithm other than the configured
+// one are rejected, which guards against algorithm confusion attacks (including "alg": "none").
+func (m *TokenManager) ValidateJWT(token string) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("token is malformed")
+	}
+
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, errors.New("token is malformed")
+	}
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := json.Unmarshal(headerJSON, &header); err != nil {
+		return nil, errors.New("token is malformed")
+	}
+	if header.Alg != m.algorithm() {
+		return nil, fmt.Errorf("unexpected signing algorithm %q", header.Alg)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errors.New("token is malformed")
+	}
+	if err := m.verify(parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+
+	body, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, errors.New("token is malformed")
+	}
+	var claims Claims
+	if err := json.Unmarshal(body, &claims); err != nil {
+		return nil, errors.New("token is malformed")
+	}
+
+	now := time.Now()
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return nil, errors.New("token has no expiry")
+	}
+	if now.After(time.Unix(int64(exp), 0).Add(m.Leeway)) {
+		return nil, errors.New("token has expired")
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(m.Leeway).Before(time.Unix(int64(nbf), 0)) {
+		return nil, errors.New("token is not valid yet")
+	}
+	if m.Issuer != "" && claims["iss"] != m.Issuer {
+		return nil, errors.New("token has an invalid issuer")
+	}
+	if m.Audience != "" && !hasAudience(claims["aud"], m.Audience) {
+		return nil, errors.New("token has an invalid audience")
+	}
+
+	return claims, nil
+}
+
+// RequireJWT is middleware which requires a valid Bearer token in the Authorization header. The token's
+// claims are stored in the request context, where handlers can retrieve them with ClaimsFromContext.
+// Requests without a valid token receive a 401 JSON response.
+func (m *TokenManager) RequireJWT(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+		if !found || !strings.EqualFold(scheme, "Bearer") || token == "" {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			_ = toolsOrDefault(m.Tools).ErrorJSON(w, errors.New("missing bearer token"), http.StatusUnauthorized)
+			return
+		}
+
+		claims, err := m.ValidateJWT(strings.TrimSpace(token))
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			_ = toolsOrDefault(m.Tools).ErrorJSON(w, err, http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(WithClaims(r.Context(), claims)))
+	})
+}
+
+func (m *TokenManager) algorithm() string {
+	return valueOrDefault(m.Algorithm, "HS256")
+}
+
+func (m *TokenManager) sign(unsigned string) ([]byte, error) {
+	switch m.algorithm() {
+	case "HS256":
+		if len(m.Secret) == 0 {
+			return nil, errors.New("HS256 requires a secret")
+		}
+		mac := hmac.New(sha256.New, m.Secret)
+		mac.Write([]byte(unsigned))
+		return mac.Sum(nil), nil
+
+	case "RS256":
+		if m.PrivateKey == nil {
+			return nil, errors.New("RS256 requires a private key")
+		}
+		digest := sha256.Sum256([]byte(unsigned))
+		return rsa.SignPKCS1v15(rand.Reader, m.PrivateKey, crypto.SHA256, digest[:])
+
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm %q", m.Algorithm)
+	}
+}
+
+func (m *TokenManager) verify(unsigned string, signature []byte) error {
+	switch m.algorithm() {
+	case "HS256":
+		expected, err := m.sign(unsigned)
+		if err != nil {
+			return err
+		}
+		if !hmac.Equal(signature, expected) {
+			return errors.New("token has an invalid signature")
+		}
+		return nil
+
+	case "RS256":
+		key := m.PublicKey
+		if key == nil && m.PrivateKey != nil {
+			key = &m.PrivateKey.PublicKey
+		}
+		if key == nil {
+			return errors.New("RS256 requires a public key")
+		}
+		digest := sha256.Sum256([]byte(unsigned))
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+			return errors.New("token has an invalid signature")
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("unsupported signing algorithm %q", m.Algorithm)
+	}
+}
+
+// hasAudience reports whether the "aud" claim, which may be a string or an array, contains audience.
+func hasAudience(aud any, audience string) bool {
+	switch v := aud.(type) {
+	case string:
+		return v == audience
+	case []any:
+		for _, item := range v {
+			if item == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,110 @@
+package gohelpertools
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTokenManager_GenerateAndValidate(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	managers := map[string]*TokenManager{
+		"HS256": {Secret: []byte("secret"), Issuer: "tests", Audience: "api"},
+		"RS256": {Algorithm: "RS256", PrivateKey: key, Issuer: "tests", Audience: "api"},
+	}
+
+	for name, manager := range managers {
+		token, err := manager.GenerateJWT(Claims{"sub": "42", "role": "admin"}, time.Minute)
+		if err != nil {
+			t.Errorf("%s: unexpected error generating token: %s", name, err)
+			continue
+		}
+
+		claims, err := manager.ValidateJWT(token)
+		if err != nil {
+			t.Errorf("%s: unexpected error validating token: %s", name, err)
+			continue
+		}
+		if claims.Subject() != "42" || claims["role"] != "admin" {
+			t.Errorf("%s: wrong claims returned: %v", name, claims)
+		}
+
+		if _, err := manager.ValidateJWT(token + "x"); err == nil {
+			t.Errorf("%s: expected error for a tampered token, but none received", name)
+		}
+	}
+}
+
+var jwtValidationTests = []struct {
+	name      string
+	generator TokenManager
+	claims    Claims
+	ttl       time.Duration
+}{
+	{name: "expired", generator: TokenManager{Secret: []byte("secret")}, ttl: -time.Minute},
+	{name: "wrong secret", generator: TokenManager{Secret: []byte("other")}, ttl: time.Minute},
+	{name: "wrong issuer", generator: TokenManager{Secret: []byte("secret"), Issuer: "someone-else"}, ttl: time.Minute},
+	{name: "not valid yet", generator: TokenManager{Secret: []byte("secret")}, claims: Claims{"nbf": time.Now().Add(time.Hour).Unix()}, ttl: 2 * time.Hour},
+}
+
+func TestTokenManager_ValidateJWTErrors(t *testing.T) {
+	validator := TokenManager{Secret: []byte("secret"), Issuer: "tests"}
+
+	for _, e := range jwtValidationTests {
+		generator := e.generator
+		if generator.Issuer == "" {
+			generator.Issuer = "tests"
+		}
+
+		token, err := generator.GenerateJWT(e.claims, e.ttl)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := validator.ValidateJWT(token); err == nil {
+			t.Errorf("%s: error expected, but none received", e.name)
+		}
+	}
+
+	// A token claiming to be unsigned must never be accepted.
+	if _, err := validator.ValidateJWT("eyJhbGciOiJub25lIn0.eyJzdWIiOiIxIn0."); err == nil {
+		t.Error("alg none: error expected, but none received")
+	}
+}
+
+func TestTokenManager_RequireJWT(t *testing.T) {
+	manager := TokenManager{Secret: []byte("secret")}
+	token, _ := manager.GenerateJWT(Claims{"sub": "42"}, time.Minute)
+
+	var subject string
+	handler := manager.RequireJWT(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, _ := ClaimsFromContext(r.Context())
+		subject = claims.Subject()
+	}))
+
+	for _, auth := range []string{"", "Bearer nonsense", "Bearer " + token} {
+		subject = ""
+		req, _ := http.NewRequest("GET", "/", nil)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		valid := auth == "Bearer "+token
+		if valid && (rr.Code != http.StatusOK || subject != "42") {
+			t.Errorf("valid token: expected claims in context, got status %d and subject %q", rr.Code, subject)
+		}
+		if !valid && rr.Code != http.StatusUnauthorized {
+			t.Errorf("%q: expected status 401 but got %d", auth, rr.Code)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package gohelpertools
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Keyring holds named AES keys, one of which is active. Values are encrypted with the active key, and the
+// output records the key's ID, so that after a rotation (adding a key and making it active) values
+// encrypted with older keys can still be decrypted. A Keyring is safe for concurrent use.
+type Keyring struct {
+	mu     sync.RWMutex
+	keys   map[string][]byte
+	active string
+}
+
+// NewKeyring returns a Keyring holding keys, by ID, with active as the active key. Each key must be 16,
+// 24 or 32 bytes long, and IDs must be between 1 and 255 bytes long.
+func NewKeyring(active string, keys map[string][]byte) (*Keyring, error) {
+	k := &Keyring{keys: make(map[string][]byte)}
+	for id, key := range keys {
+		if err := k.Add(id, key); err != nil {
+			return nil, err
+		}
+	}
+	if err := k.SetActive(active); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// Add adds key under id, replacing any key already there.
+func (k *Keyring) Add(id string, key []byte) error {
+	if len(id) == 0 || len(id) > 255 {
+		return errors.New("key ID must be between 1 and 255 bytes long")
+	}
+	if _, err := newGCM(key); err != nil {
+		return err
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.keys == nil {
+		k.keys = make(map[string][]byte)
+	}
+	k.keys[id] = append([]byte(nil), key...)
+	return nil
+}
+
+// SetActive makes id the key used to encrypt.
+func (k *Keyring) SetActive(id string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if _, ok := k.keys[id]; !ok {
+		return fmt.Errorf("no key with ID %q", id)
+	}
+	k.active = id
+	return nil
+}
+
+// Encrypt encrypts plaintext with the active key, as Tools.Encrypt does, prefixed with the key's ID.
+func (k *Keyring) Encrypt(plaintext []byte) ([]byte, error) {
+	k.mu.RLock()
+	id, key := k.active, k.keys[k.active]
+	k.mu.RUnlock()
+	if key == nil {
+		return nil, errors.New("keyring has no active key")
+	}
+
+	var t Tools
+	sealed, err := t.Encrypt(plaintext, key)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 0, 1+len(id)+len(sealed))
+	out = append(out, byte(len(id)))
+	out = append(out, id...)
+	return append(out, sealed...), nil
+}
+
+// Decrypt reverses Encrypt, using whichever key the ciphertext was encrypted with.
+func (k *Keyring) Decrypt(ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) == 0 || len(ciphertext) < 1+int(ciphertext[0]) {
+		return nil, errors.New("ciphertext is too short")
+	}
+	id := string(ciphertext[1 : 1+ciphertext[0]])
+
+	k.mu.RLock()
+	key, ok := k.keys[id]
+	k.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("no key with ID %q", id)
+	}
+
+	var t Tools
+	return t.Decrypt(ciphertext[1+len(id):], key)
+}
+
+// EncryptString encrypts plaintext with Encrypt, and returns the result as URL-safe base64.
+func (k *Keyring) EncryptString(plaintext string) (string, error) {
+	out, err := k.Encrypt([]byte(plaintext))
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(out), nil
+}
+
+// DecryptString reverses EncryptString.
+func (k *Keyring) DecryptString(ciphertext string) (string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", errors.New("ciphertext is not valid base64")
+	}
+
+	plaintext, err := k.Decrypt(data)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
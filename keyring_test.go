@@ -0,0 +1,62 @@
+package gohelpertools
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestKeyring_Rotation(t *testing.T) {
+	oldKey := bytes.Repeat([]byte{1}, 32)
+	newKey := bytes.Repeat([]byte{2}, 32)
+
+	k, err := NewKeyring("2023", map[string][]byte{"2023": oldKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	old, err := k.EncryptString("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := k.Add("2024", newKey); err != nil {
+		t.Fatal(err)
+	}
+	if err := k.SetActive("2024"); err != nil {
+		t.Fatal(err)
+	}
+
+	current, _ := k.EncryptString("secret")
+	for _, ciphertext := range []string{old, current} {
+		plaintext, err := k.DecryptString(ciphertext)
+		if err != nil || plaintext != "secret" {
+			t.Errorf("expected to decrypt %q, got %q (%v)", ciphertext, plaintext, err)
+		}
+	}
+
+	other, _ := NewKeyring("2024", map[string][]byte{"2024": oldKey})
+	if _, err := other.DecryptString(current); err == nil {
+		t.Error("wrong key: error expected, but none received")
+	}
+	if _, err := other.DecryptString(old); err == nil {
+		t.Error("unknown key ID: error expected, but none received")
+	}
+}
+
+var newKeyringTests = []struct {
+	name   string
+	active string
+	keys   map[string][]byte
+}{
+	{name: "missing active key", active: "b", keys: map[string][]byte{"a": make([]byte, 32)}},
+	{name: "bad key length", active: "a", keys: map[string][]byte{"a": make([]byte, 7)}},
+	{name: "empty ID", active: "", keys: map[string][]byte{"": make([]byte, 32)}},
+}
+
+func TestNewKeyring(t *testing.T) {
+	for _, e := range newKeyringTests {
+		if _, err := NewKeyring(e.active, e.keys); err == nil {
+			t.Errorf("%s: error expected, but none received", e.name)
+		}
+	}
+}
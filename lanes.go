@@ -0,0 +1,150 @@
+package gohelpertools
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// The lanes used by the default classifier of PriorityLanes.
+const (
+	LaneHealth      = "health"
+	LaneInternal    = "internal"
+	LaneInteractive = "interactive"
+	LaneBatch       = "batch"
+)
+
+// Lane is the concurrency budget of one class of requests.
+type Lane struct {
+	MaxConcurrent int           // requests handled at once; no limit if zero
+	MaxQueue      int           // requests which may wait for a slot; none if zero
+	QueueTimeout  time.Duration // how long a request may wait for a slot; defaults to 1 second
+}
+
+// PriorityLanes is middleware which sorts requests into lanes, such as health checks, internal calls,
+// user-facing requests and batch jobs, each with its own concurrency budget. A spike in one lane then only
+// queues or rejects requests in that lane: background batch traffic can't starve interactive requests, and
+// health checks keep answering while the server is busy. Rejected requests get a 503 JSON error with a
+// Retry-After header.
+type PriorityLanes struct {
+	Lanes      map[string]Lane              // budgets by lane name; lanes not listed are unlimited
+	Classify   func(r *http.Request) string // returns the lane for r; see DefaultClassify
+	RetryAfter time.Duration                // sent with rejections; defaults to 1 second
+	Tools      *Tools                       // used to write JSON error responses; a zero Tools is used if nil
+
+	once  sync.Once
+	state map[string]*laneState
+}
+
+type laneState struct {
+	slots    chan struct{}
+	waiting  int64
+	inFlight int64
+}
+
+// DefaultClassify puts requests for /health, /healthz, /livez and /readyz in LaneHealth, requests with an
+// "X-Priority: batch" header in LaneBatch, and everything else in LaneInteractive. Since any client can
+// send the header, it can only lower a request's priority; use a custom Classify to recognise internal
+// callers, for example by their RealIP.
+func DefaultClassify(r *http.Request) string {
+	switch strings.TrimSuffix(r.URL.Path, "/") {
+	case "/health", "/healthz", "/livez", "/readyz":
+		return LaneHealth
+	}
+	if strings.EqualFold(r.Header.Get("X-Priority"), LaneBatch) {
+		return LaneBatch
+	}
+	return LaneInteractive
+}
+
+// Middleware admits each request to its lane, waiting for a slot if the lane is busy and its queue has
+// room, or rejecting it otherwise.
+func (p *PriorityLanes) Middleware(next http.Handler) http.Handler {
+	p.init()
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		classify := p.Classify
+		if classify == nil {
+			classify = DefaultClassify
+		}
+		name := classify(r)
+
+		state, ok := p.state[name]
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if err := p.acquire(r, name, state); err != nil {
+			retry := p.RetryAfter
+			if retry <= 0 {
+				retry = time.Second
+			}
+			w.Header().Set("Retry-After", strconv.Itoa(int((retry+time.Second-1)/time.Second)))
+			_ = toolsOrDefault(p.Tools).ErrorJSON(w, err, http.StatusServiceUnavailable)
+			return
+		}
+		atomic.AddInt64(&state.inFlight, 1)
+		defer func() {
+			atomic.AddInt64(&state.inFlight, -1)
+			<-state.slots
+		}()
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// InFlight returns the number of requests being handled in lane.
+func (p *PriorityLanes) InFlight(lane string) int {
+	p.init()
+	if state, ok := p.state[lane]; ok {
+		return int(atomic.LoadInt64(&state.inFlight))
+	}
+	return 0
+}
+
+func (p *PriorityLanes) acquire(r *http.Request, name string, state *laneState) error {
+	select {
+	case state.slots <- struct{}{}:
+		return nil
+	default:
+	}
+
+	lane := p.Lanes[name]
+	if atomic.AddInt64(&state.waiting, 1) > int64(lane.MaxQueue) {
+		atomic.AddInt64(&state.waiting, -1)
+		return errors.New("the server is busy; please try again later")
+	}
+	defer atomic.AddInt64(&state.waiting, -1)
+
+	timeout := lane.QueueTimeout
+	if timeout <= 0 {
+		timeout = time.Second
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case state.slots <- struct{}{}:
+		return nil
+	case <-timer.C:
+		return errors.New("the server is busy; please try again later")
+	case <-r.Context().Done():
+		return r.Context().Err()
+	}
+}
+
+func (p *PriorityLanes) init() {
+	p.once.Do(func() {
+		p.state = make(map[string]*laneState)
+		for name, lane := range p.Lanes {
+			if lane.MaxConcurrent > 0 {
+				p.state[name] = &laneState{slots: make(chan struct{}, lane.MaxConcurrent)}
+			}
+		}
+	})
+}
@@ -0,0 +1,89 @@
+package gohelpertools
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+var classifyTests = []struct {
+	name     string
+	path     string
+	priority string
+	want     string
+}{
+	{name: "health", path: "/healthz", want: LaneHealth},
+	{name: "health with slash", path: "/readyz/", want: LaneHealth},
+	{name: "batch", path: "/export", priority: "batch", want: LaneBatch},
+	{name: "cannot promote", path: "/export", priority: "internal", want: LaneInteractive},
+	{name: "interactive", path: "/users", want: LaneInteractive},
+}
+
+func TestDefaultClassify(t *testing.T) {
+	for _, e := range classifyTests {
+		req := httptest.NewRequest(http.MethodGet, e.path, nil)
+		if e.priority != "" {
+			req.Header.Set("X-Priority", e.priority)
+		}
+		if got := DefaultClassify(req); got != e.want {
+			t.Errorf("%s: expected lane %q but got %q", e.name, e.want, got)
+		}
+	}
+}
+
+func TestPriorityLanes_Middleware(t *testing.T) {
+	lanes := &PriorityLanes{Lanes: map[string]Lane{
+		LaneBatch:       {MaxConcurrent: 1, QueueTimeout: 10 * time.Millisecond},
+		LaneInteractive: {MaxConcurrent: 1, MaxQueue: 1, QueueTimeout: time.Second},
+	}}
+
+	release := make(chan struct{})
+	started := make(chan struct{}, 4)
+	handler := lanes.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		if r.URL.Path == "/slow" {
+			<-release
+		}
+	}))
+
+	serve := func(path, priority string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if priority != "" {
+			req.Header.Set("X-Priority", priority)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// Fill the batch lane.
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		serve("/slow", "batch")
+	}()
+	<-started
+
+	if lanes.InFlight(LaneBatch) != 1 {
+		t.Errorf("expected 1 batch request in flight, got %d", lanes.InFlight(LaneBatch))
+	}
+
+	// Another batch request is rejected, since the batch lane has no queue.
+	if rr := serve("/fast", "batch"); rr.Code != http.StatusServiceUnavailable || rr.Header().Get("Retry-After") != "1" {
+		t.Errorf("expected 503 with Retry-After, got %d %q", rr.Code, rr.Header().Get("Retry-After"))
+	}
+
+	// Interactive and health requests are unaffected.
+	if rr := serve("/fast", ""); rr.Code != http.StatusOK {
+		t.Errorf("expected interactive request to succeed, got %d", rr.Code)
+	}
+	if rr := serve("/healthz", ""); rr.Code != http.StatusOK {
+		t.Errorf("expected health check to succeed, got %d", rr.Code)
+	}
+
+	close(release)
+	wg.Wait()
+}
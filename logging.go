@@ -0,0 +1,84 @@
+package gohelpertools
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+)
+
+const routeContextKey contextKey = "route"
+
+// RequestID is middleware which gives every request an ID, taken from a well-formed X-Request-Id header
+// (as set by a proxy or the calling service) or generated otherwise. The ID is echoed in the X-Request-Id
+// response header and stored in the context, together with the request's route (see DefaultRoute), so
+// that LogError and LogInfo can attach both to every log record.
+func (t *Tools) RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get("X-Request-Id")
+		if !validRequestID(id) {
+			id = randomHexID(16)
+		}
+		w.Header().Set("X-Request-Id", id)
+
+		ctx := WithRequestID(r.Context(), id)
+		ctx = context.WithValue(ctx, routeContextKey, DefaultRoute(r))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// LogError logs msg and err at error level with the Tools logger, or the logger stored in ctx by
+// WithContextLogger, along with args (key-value pairs, as for slog) and the request ID, route, tenant and
+// trace ID from ctx, where there are any.
+func (t *Tools) LogError(ctx context.Context, msg string, err error, args ...any) {
+	if err != nil {
+		args = append(args, "error", err.Error())
+	}
+	t.log(ctx, slog.LevelError, msg, args...)
+}
+
+// LogInfo logs msg at info level, like LogError.
+func (t *Tools) LogInfo(ctx context.Context, msg string, args ...any) {
+	t.log(ctx, slog.LevelInfo, msg, args...)
+}
+
+func (t *Tools) logWarn(ctx context.Context, msg string, args ...any) {
+	t.log(ctx, slog.LevelWarn, msg, args...)
+}
+
+func (t *Tools) log(ctx context.Context, level slog.Level, msg string, args ...any) {
+	logger := LoggerFromContext(ctx)
+	if logger == nil {
+		logger = t.logger()
+	}
+	if !logger.Enabled(ctx, level) {
+		return
+	}
+
+	if id := RequestIDFromContext(ctx); id != "" {
+		args = append(args, "request_id", id)
+	}
+	if route, ok := ctx.Value(routeContextKey).(string); ok {
+		args = append(args, "route", route)
+	}
+	if tenant, ok := TenantFromContext(ctx); ok {
+		args = append(args, "tenant", tenant)
+	}
+	if span := SpanFromContext(ctx); span != nil {
+		args = append(args, "trace_id", span.Record().TraceID)
+	}
+	logger.Log(ctx, level, msg, args...)
+}
+
+// validRequestID reports whether id is safe to use as a request ID: non-empty, at most 128 characters,
+// and printable ASCII without spaces, so that it can't be used to inject anything into logs.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,79 @@
+package gohelpertools
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTools_RequestIDAndLogging(t *testing.T) {
+	var logs bytes.Buffer
+	tools := New(WithLogger(slog.New(slog.NewJSONHandler(&logs, nil))))
+
+	handler := tools.RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tools.LogError(r.Context(), "charge failed", errors.New("card declined"), "amount", 42)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/orders/17", nil)
+	req.Header.Set("X-Request-Id", "abc-123")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	if rr.Header().Get("X-Request-Id") != "abc-123" {
+		t.Errorf("expected the incoming request ID to be echoed, got %q", rr.Header().Get("X-Request-Id"))
+	}
+
+	var record map[string]any
+	if err := json.Unmarshal(logs.Bytes(), &record); err != nil {
+		t.Fatalf("expected a JSON log record, got %q", logs.String())
+	}
+	want := map[string]any{
+		"level":      "ERROR",
+		"msg":        "charge failed",
+		"error":      "card declined",
+		"amount":     float64(42),
+		"request_id": "abc-123",
+		"route":      "/orders/:id",
+	}
+	for key, value := range want {
+		if record[key] != value {
+			t.Errorf("expected %s to be %v, got %v", key, value, record[key])
+		}
+	}
+}
+
+var requestIDTests = []struct {
+	name      string
+	header    string
+	keepsSent bool
+}{
+	{name: "missing", header: "", keepsSent: false},
+	{name: "valid", header: "req-1", keepsSent: true},
+	{name: "log injection", header: "x\ninjected=1", keepsSent: false},
+	{name: "too long", header: strings.Repeat("a", 129), keepsSent: false},
+}
+
+func TestTools_RequestID(t *testing.T) {
+	var tools Tools
+	for _, e := range requestIDTests {
+		var seen string
+		handler := tools.RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			seen = RequestIDFromContext(r.Context())
+		}))
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if e.header != "" {
+			req.Header["X-Request-Id"] = []string{e.header}
+		}
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		if (seen == e.header) != e.keepsSent || seen == "" {
+			t.Errorf("%s: unexpected request ID %q", e.name, seen)
+		}
+	}
+}
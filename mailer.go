@@ -0,0 +1,463 @@
+package gohelpertools
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MailMessage is an email. At least one of Text and HTML should be set; if both are, the message is sent
+// as multipart/alternative, so mail clients can show either.
+type MailMessage struct {
+	From        string // sender address, such as "Shop <orders@example.com>"; defaults to Mailer.From
+	To          []string
+	Cc          []string
+	Bcc         []string // recipients who aren't listed in the headers
+	ReplyTo     string
+	Subject     string
+	Text        string            // plain-text body
+	HTML        string            // HTML body
+	Headers     map[string]string // extra headers, such as List-Unsubscribe; those set from the other fields are refused
+	Attachments []MailAttachment
+}
+
+// MailAttachment is a file attached to a MailMessage.
+type MailAttachment struct {
+	Filename    string
+	ContentType string // detected from the file name's extension if empty
+	Data        []byte
+}
+
+// MailTransport delivers messages. SMTPTransport delivers them over SMTP; transports for APIs such as
+// SendGrid or Amazon SES can be plugged in by implementing it, and MemoryMailTransport captures messages
+// in tests.
+type MailTransport interface {
+	Send(ctx context.Context, msg *MailMessage) error
+}
+
+// Mailer sends email through a MailTransport, filling in the default sender and, with SendTemplate,
+// rendering the HTML body with a Renderer.
+type Mailer struct {
+	Transport MailTransport // delivers messages; required
+	From      string        // sender used when a message has none
+	Renderer  *Renderer     // renders the HTML bodies of SendTemplate
+}
+
+// Send checks msg and delivers it with the Transport.
+func (m *Mailer) Send(ctx context.Context, msg MailMessage) error {
+	if m.Transport == nil {
+		return errors.New("mailer has no transport")
+	}
+
+	msg.From = valueOrDefault(msg.From, m.From)
+	if msg.From == "" {
+		return errors.New("email has no sender")
+	}
+	if len(msg.To)+len(msg.Cc)+len(msg.Bcc) == 0 {
+		return errors.New("email has no recipients")
+	}
+	for _, address := range append(append(append([]string{msg.From}, msg.To...), msg.Cc...), msg.Bcc...) {
+		if _, err := mail.ParseAddress(address); err != nil {
+			return fmt.Errorf("invalid email address %q", address)
+		}
+	}
+	if msg.Text == "" && msg.HTML == "" {
+		return errors.New("email has no body")
+	}
+
+	return m.Transport.Send(ctx, &msg)
+}
+
+// SendTemplate renders the page name with data as the HTML body of msg, using the Renderer, and sends it.
+// If msg has no Text, a plain-text version is made from the HTML.
+func (m *Mailer) SendTemplate(ctx context.Context, msg MailMessage, name string, data any) error {
+	if m.Renderer == nil {
+		return errors.New("mailer has no renderer")
+	}
+
+	var body bytes.Buffer
+	if err := m.Renderer.RenderTo(&body, name, data); err != nil {
+		return err
+	}
+	msg.HTML = body.String()
+	if msg.Text == "" {
+		msg.Text = htmlToText(msg.HTML)
+	}
+	return m.Send(ctx, msg)
+}
+
+// Bytes returns msg in the Internet Message Format (RFC 5322), with MIME parts for the bodies and
+// attachments. Bcc recipients are left out.
+func (msg *MailMessage) Bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	header := func(key, value string) {
+		if value != "" {
+			fmt.Fprintf(&buf, "%s: %s\r\n", key, value)
+		}
+	}
+
+	if err := checkMailHeaders(msg.Headers); err != nil {
+		return nil, err
+	}
+	from, err := formatAddresses([]string{msg.From})
+	if err != nil {
+		return nil, err
+	}
+	to, err := formatAddresses(msg.To)
+	if err != nil {
+		return nil, err
+	}
+	cc, err := formatAddresses(msg.Cc)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := randomToken(16)
+	if err != nil {
+		return nil, err
+	}
+	domain := "localhost"
+	if address, err := mail.ParseAddress(msg.From); err == nil {
+		if _, d, ok := strings.Cut(address.Address, "@"); ok {
+			domain = d
+		}
+	}
+
+	header("From", from)
+	header("To", to)
+	header("Cc", cc)
+	if msg.ReplyTo != "" {
+		replyTo, err := formatAddresses([]string{msg.ReplyTo})
+		if err != nil {
+			return nil, err
+		}
+		header("Reply-To", replyTo)
+	}
+	header("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("Message-ID", "<"+id+"@"+domain+">")
+	header("MIME-Version", "1.0")
+	for key, value := range msg.Headers {
+		header(textproto.CanonicalMIMEHeaderKey(key), mime.QEncoding.Encode("utf-8", value))
+	}
+
+	bodyHeader, body, err := msg.body()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(msg.Attachments) == 0 {
+		for _, key := range []string{"Content-Type", "Content-Transfer-Encoding"} {
+			header(key, bodyHeader.Get(key))
+		}
+		buf.WriteString("\r\n")
+		buf.Write(body)
+		return buf.Bytes(), nil
+	}
+
+	mixed := multipart.NewWriter(&buf)
+	header("Content-Type", "multipart/mixed; boundary="+mixed.Boundary())
+	buf.WriteString("\r\n")
+
+	part, err := mixed.CreatePart(bodyHeader)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := part.Write(body); err != nil {
+		return nil, err
+	}
+
+	for _, attachment := range msg.Attachments {
+		contentType := attachment.ContentType
+		if contentType == "" {
+			contentType = mime.TypeByExtension(path.Ext(attachment.Filename))
+		}
+		part, err := mixed.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {valueOrDefault(contentType, "application/octet-stream")},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename})},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeBase64Lines(part, attachment.Data); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := mixed.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// body returns the text and HTML bodies, encoded as a single MIME part, and the headers of that part.
+func (msg *MailMessage) body() (textproto.MIMEHeader, []byte, error) {
+	var out bytes.Buffer
+
+	if msg.Text == "" || msg.HTML == "" {
+		contentType, content := "text/plain; charset=utf-8", msg.Text
+		if msg.HTML != "" {
+			contentType, content = "text/html; charset=utf-8", msg.HTML
+		}
+		if err := writeQuotedPrintable(&out, content); err != nil {
+			return nil, nil, err
+		}
+		return textproto.MIMEHeader{
+			"Content-Type":              {contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		}, out.Bytes(), nil
+	}
+
+	alternative := multipart.NewWriter(&out)
+	for _, body := range []struct{ contentType, content string }{
+		{"text/plain; charset=utf-8", msg.Text},
+		{"text/html; charset=utf-8", msg.HTML},
+	} {
+		part, err := alternative.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {body.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := writeQuotedPrintable(part, body.content); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := alternative.Close(); err != nil {
+		return nil, nil, err
+	}
+	return textproto.MIMEHeader{
+		"Content-Type": {"multipart/alternative; boundary=" + alternative.Boundary()},
+	}, out.Bytes(), nil
+}
+
+// Recipients returns the addresses msg is delivered to, including Bcc.
+func (msg *MailMessage) Recipients() ([]string, error) {
+	var recipients []string
+	for _, list := range [][]string{msg.To, msg.Cc, msg.Bcc} {
+		for _, address := range list {
+			parsed, err := mail.ParseAddress(address)
+			if err != nil {
+				return nil, fmt.Errorf("invalid email address %q", address)
+			}
+			recipients = append(recipients, parsed.Address)
+		}
+	}
+	return recipients, nil
+}
+
+// SMTPTransport delivers messages to an SMTP server, using STARTTLS when the server offers it.
+type SMTPTransport struct {
+	Addr      string        // host:port of the server, such as "smtp.example.com:587"; required
+	Username  string        // if set, used with Password for PLAIN authentication
+	Password  string        // password for Username
+	TLSConfig *tls.Config   // used for STARTTLS; defaults to verifying the server's host name
+	Timeout   time.Duration // limit on the whole delivery; defaults to 30 seconds
+}
+
+// Send delivers msg.
+func (s *SMTPTransport) Send(ctx context.Context, msg *MailMessage) error {
+	data, err := msg.Bytes()
+	if err != nil {
+		return err
+	}
+	recipients, err := msg.Recipients()
+	if err != nil {
+		return err
+	}
+	from, err := mail.ParseAddress(msg.From)
+	if err != nil {
+		return fmt.Errorf("invalid email address %q", msg.From)
+	}
+
+	timeout := s.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", s.Addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	host, _, err := net.SplitHostPort(s.Addr)
+	if err != nil {
+		return err
+	}
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		config := s.TLSConfig
+		if config == nil {
+			config = &tls.Config{ServerName: host}
+		}
+		if err := client.StartTLS(config); err != nil {
+			return err
+		}
+	}
+	if s.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.Username, s.Password, host)); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(from.Address); err != nil {
+		return err
+	}
+	for _, recipient := range recipients {
+		if err := client.Rcpt(recipient); err != nil {
+			return err
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// MemoryMailTransport keeps the messages it is given instead of delivering them, for tests.
+type MemoryMailTransport struct {
+	mu       sync.Mutex
+	messages []MailMessage
+}
+
+// Send records msg.
+func (m *MemoryMailTransport) Send(ctx context.Context, msg *MailMessage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, *msg)
+	return nil
+}
+
+// Messages returns the messages sent so far, oldest first.
+func (m *MemoryMailTransport) Messages() []MailMessage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]MailMessage(nil), m.messages...)
+}
+
+// Reset forgets the messages sent so far.
+func (m *MemoryMailTransport) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = nil
+}
+
+var (
+	htmlDropRegex  = regexp.MustCompile(`(?is)<(head|style|script)[^>]*>.*?</(head|style|script)>`)
+	htmlBreakRegex = regexp.MustCompile(`(?i)<(br|/p|/div|/h[1-6]|/li|/tr)\s*/?>`)
+	htmlTagRegex   = regexp.MustCompile(`<[^>]*>`)
+	blankRegex     = regexp.MustCompile(`[ \t]+`)
+	newlinesRegex  = regexp.MustCompile(`\n\s*\n\s*`)
+)
+
+// reservedMailHeaders are the headers Bytes sets itself, which MailMessage.Headers may not duplicate or
+// override.
+var reservedMailHeaders = []string{
+	"From", "To", "Cc", "Bcc", "Reply-To", "Subject", "Date", "Message-Id", "Mime-Version",
+	"Content-Type", "Content-Transfer-Encoding", "Content-Disposition",
+}
+
+// checkMailHeaders returns an error if a custom header has a name which isn't a valid header field name
+// (RFC 5322: printable ASCII other than the colon), a reserved name, or a value containing a line break,
+// which would let it add headers of its own.
+func checkMailHeaders(headers map[string]string) error {
+	for key, value := range headers {
+		if key == "" || strings.IndexFunc(key, func(r rune) bool { return r <= ' ' || r > '~' || r == ':' }) >= 0 {
+			return fmt.Errorf("invalid mail header name %q", key)
+		}
+		if contains(reservedMailHeaders, textproto.CanonicalMIMEHeaderKey(key)) {
+			return fmt.Errorf("mail header %s can't be set through Headers", key)
+		}
+		if strings.ContainsAny(value, "\r\n") {
+			return fmt.Errorf("mail header %s must not contain line breaks", key)
+		}
+	}
+	return nil
+}
+
+// htmlToText makes a rough plain-text version of an HTML email: tags are removed, block ends become line
+// breaks and entities are unescaped.
+func htmlToText(s string) string {
+	s = htmlDropRegex.ReplaceAllString(s, "")
+	s = htmlBreakRegex.ReplaceAllString(s, "\n")
+	s = htmlTagRegex.ReplaceAllString(s, "")
+	s = html.UnescapeString(s)
+	s = blankRegex.ReplaceAllString(s, " ")
+
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	return strings.TrimSpace(newlinesRegex.ReplaceAllString(strings.Join(lines, "\n"), "\n\n"))
+}
+
+func formatAddresses(addresses []string) (string, error) {
+	formatted := make([]string, 0, len(addresses))
+	for _, address := range addresses {
+		parsed, err := mail.ParseAddress(address)
+		if err != nil {
+			return "", fmt.Errorf("invalid email address %q", address)
+		}
+		formatted = append(formatted, parsed.String())
+	}
+	return strings.Join(formatted, ", "), nil
+}
+
+func writeQuotedPrintable(w io.Writer, s string) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write([]byte(s)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+// writeBase64Lines writes data as base64 in lines of 76 characters, as MIME requires.
+func writeBase64Lines(w io.Writer, data []byte) error {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 0 {
+		n := min(76, len(encoded))
+		if _, err := w.Write([]byte(encoded[:n] + "\r\n")); err != nil {
+			return err
+		}
+		encoded = encoded[n:]
+	}
+	return nil
+}
@@ -0,0 +1,223 @@
+package gohelpertools
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+var mailerSendTests = []struct {
+	name          string
+	msg           MailMessage
+	errorExpected bool
+}{
+	{name: "valid", msg: MailMessage{To: []string{"jane@example.com"}, Subject: "Hi", Text: "Hello"}},
+	{name: "bcc only", msg: MailMessage{Bcc: []string{"jane@example.com"}, Text: "Hello"}},
+	{name: "no recipients", msg: MailMessage{Text: "Hello"}, errorExpected: true},
+	{name: "invalid recipient", msg: MailMessage{To: []string{"not an address"}, Text: "Hello"}, errorExpected: true},
+	{name: "no body", msg: MailMessage{To: []string{"jane@example.com"}}, errorExpected: true},
+}
+
+func TestMailer_Send(t *testing.T) {
+	transport := &MemoryMailTransport{}
+	mailer := &Mailer{Transport: transport, From: "Shop <shop@example.com>"}
+
+	for _, e := range mailerSendTests {
+		transport.Reset()
+		err := mailer.Send(context.Background(), e.msg)
+		if e.errorExpected {
+			if err == nil {
+				t.Errorf("%s: error expected, but none received", e.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: error not expected, but one received: %s", e.name, err)
+			continue
+		}
+		if sent := transport.Messages(); len(sent) != 1 || sent[0].From != "Shop <shop@example.com>" {
+			t.Errorf("%s: expected one message from the default sender, got %+v", e.name, sent)
+		}
+	}
+}
+
+func TestMailer_SendTemplate(t *testing.T) {
+	transport := &MemoryMailTransport{}
+	mailer := &Mailer{
+		Transport: transport,
+		From:      "shop@example.com",
+		Renderer: &Renderer{FS: fstest.MapFS{
+			"layouts/base.html":  {Data: []byte(`{{define "base"}}<html><head><style>p{}</style></head><body>{{template "content" .}}</body></html>{{end}}`)},
+			"pages/welcome.html": {Data: []byte(`{{define "content"}}<h1>Welcome, {{.Data}}</h1><p>Fish &amp; chips</p>{{end}}`)},
+		}},
+	}
+
+	err := mailer.SendTemplate(context.Background(), MailMessage{To: []string{"jane@example.com"}, Subject: "Welcome"}, "welcome", "Jane")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := transport.Messages()[0]
+	if !strings.Contains(msg.HTML, "<h1>Welcome, Jane</h1>") {
+		t.Errorf("unexpected HTML body %q", msg.HTML)
+	}
+	if msg.Text != "Welcome, Jane\nFish & chips" {
+		t.Errorf("unexpected text body %q", msg.Text)
+	}
+}
+
+func TestMailMessage_Bytes(t *testing.T) {
+	msg := MailMessage{
+		From:        "Shop <shop@example.com>",
+		To:          []string{"jane@example.com"},
+		Bcc:         []string{"audit@example.com"},
+		Subject:     "Your order ✓",
+		Text:        "Thanks",
+		HTML:        "<p>Thanks</p>",
+		Attachments: []MailAttachment{{Filename: "invoice.pdf", Data: []byte("%PDF-1.4")}},
+	}
+
+	data, err := msg.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("audit@example.com")) {
+		t.Error("expected Bcc recipients to be left out of the message")
+	}
+
+	parsed, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, _ := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	if subject != "Your order ✓" {
+		t.Errorf("unexpected subject %q", subject)
+	}
+
+	mediaType, params, _ := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	if mediaType != "multipart/mixed" {
+		t.Fatalf("expected multipart/mixed, got %s", mediaType)
+	}
+	reader := multipart.NewReader(parsed.Body, params["boundary"])
+
+	body, err := reader.NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(body.Header.Get("Content-Type"), "multipart/alternative") {
+		t.Errorf("expected the body to be multipart/alternative, got %s", body.Header.Get("Content-Type"))
+	}
+
+	attachment, err := reader.NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attachment.FileName() != "invoice.pdf" || attachment.Header.Get("Content-Type") != "application/pdf" {
+		t.Errorf("unexpected attachment headers %v", attachment.Header)
+	}
+}
+
+func TestSMTPTransport_Send(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	received := make(chan []string, 1)
+	go fakeSMTPServer(listener, received)
+
+	transport := &SMTPTransport{Addr: listener.Addr().String()}
+	msg := &MailMessage{From: "shop@example.com", To: []string{"jane@example.com"}, Bcc: []string{"audit@example.com"}, Text: "Hello"}
+	if err := transport.Send(context.Background(), msg); err != nil {
+		t.Fatal(err)
+	}
+
+	commands := strings.Join(<-received, "\n")
+	for _, want := range []string{"MAIL FROM:<shop@example.com>", "RCPT TO:<jane@example.com>", "RCPT TO:<audit@example.com>", "Hello"} {
+		if !strings.Contains(commands, want) {
+			t.Errorf("expected the server to receive %q, got:\n%s", want, commands)
+		}
+	}
+}
+
+// fakeSMTPServer accepts one connection, answers every command with success, and sends what it received.
+func fakeSMTPServer(listener net.Listener, received chan<- []string) {
+	conn, err := listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	var lines []string
+	r := bufio.NewReader(conn)
+	_, _ = io.WriteString(conn, "220 localhost ready\r\n")
+	inData := false
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			break
+		}
+		line = strings.TrimRight(line, "\r\n")
+		lines = append(lines, line)
+
+		switch {
+		case inData:
+			if line == "." {
+				inData = false
+				_, _ = io.WriteString(conn, "250 queued\r\n")
+			}
+		case strings.HasPrefix(line, "EHLO"):
+			_, _ = io.WriteString(conn, "250 localhost\r\n")
+		case line == "DATA":
+			inData = true
+			_, _ = io.WriteString(conn, "354 go ahead\r\n")
+		case line == "QUIT":
+			_, _ = io.WriteString(conn, "221 bye\r\n")
+			received <- lines
+			return
+		default:
+			_, _ = io.WriteString(conn, "250 ok\r\n")
+		}
+	}
+	received <- lines
+}
+
+var mailHeaderTests = []struct {
+	name          string
+	headers       map[string]string
+	errorExpected bool
+}{
+	{name: "custom header", headers: map[string]string{"List-Unsubscribe": "<https://example.com/u>"}},
+	{name: "from", headers: map[string]string{"From": "evil@example.com"}, errorExpected: true},
+	{name: "lower-case content type", headers: map[string]string{"content-type": "text/html"}, errorExpected: true},
+	{name: "bcc", headers: map[string]string{"BCC": "evil@example.com"}, errorExpected: true},
+	{name: "space in name", headers: map[string]string{"X Bad": "1"}, errorExpected: true},
+	{name: "colon in name", headers: map[string]string{"X-Bad:": "1"}, errorExpected: true},
+	{name: "empty name", headers: map[string]string{"": "1"}, errorExpected: true},
+	{name: "line break in value", headers: map[string]string{"X-Tag": "a\r\nBcc: evil@example.com"}, errorExpected: true},
+}
+
+func TestMailMessage_BytesHeaders(t *testing.T) {
+	for _, e := range mailHeaderTests {
+		msg := MailMessage{From: "shop@example.com", To: []string{"jane@example.com"}, Text: "Hi", Headers: e.headers}
+		data, err := msg.Bytes()
+		if e.errorExpected && err == nil {
+			t.Errorf("%s: error expected, but none received", e.name)
+		}
+		if !e.errorExpected && err != nil {
+			t.Errorf("%s: error not expected, but one received: %s", e.name, err)
+		}
+		if err == nil && !bytes.Contains(data, []byte("List-Unsubscribe: <https://example.com/u>\r\n")) {
+			t.Errorf("%s: expected the custom header in the message", e.name)
+		}
+	}
+}
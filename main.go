@@ -1,58 +1,96 @@
 package gohelpertools
 
 import (
-	"crypto/rand"
+	"bufio"
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"mime"
 	"net/http"
-	"regexp"
 	"strings"
+	"time"
 )
 
 const randomStringSource = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0987654321_+"
 const defaultMaxUpload = 10485760
 
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
 type Tools struct {
-	MaxJSONSize int // maximum size of JSON file we'll process
-	AllowUnknownFields bool // if set to true, allow unknown fields in JSON
+	MaxJSONSize        int            // maximum size of JSON file we'll process
+	MaxFileSize        int            // maximum size of each file UploadFiles accepts; defaults to 10MB
+	MaxUploadSize      int            // maximum size of a whole request UploadFiles reads; defaults to 10 times MaxFileSize
+	AllowedFileTypes   []string       // content types UploadFiles accepts, such as "image/png"; any type is accepted if empty
+	ImageUploads       *ImageOptions  // if set, UploadFiles checks, orients and resizes uploaded images, and can save thumbnails
+	PDFUploads         *PDFOptions    // if set, UploadFiles checks uploaded PDFs, and can extract their text and save thumbnails
+	AllowUnknownFields bool           // if set to true, allow unknown fields in JSON
+	UseJSONNumber      bool           // if set to true, ReadJSON decodes numbers in interface values as json.Number rather than float64
+	SafeJSONIntegers   bool           // if set to true, WriteJSON writes integers beyond ±(2^53 - 1) as strings, for JavaScript clients
+	TrustedProxies     []string       // IPs or CIDRs of proxies whose forwarding headers we trust; if any is invalid, none are trusted and an error is logged
+	DisableEnvelope    bool           // if set to true, write responses without the JSONResponse envelope
+	Password           PasswordConfig // algorithm and cost parameters used by HashPassword
+	FetchCache         *FetchCache    // cache used by CachedFetchJSON; a shared cache is used if nil
+	Resolver           Resolver       // used for DNS lookups; net.DefaultResolver is used if nil
+	DNSCache           *DNSCache      // cache used by ResolveWithCache; a shared cache is used if nil
+	URLSigningKey      []byte         // key used by SignURL and VerifySignedURL
+	DisposableDomains  *DomainSet     // domains rejected by IsDisposableEmail; the embedded list is used if nil
+	WebhookTolerance   time.Duration  // how old a signed webhook may be in VerifySignature; defaults to 5 minutes
+	Logger             *slog.Logger   // structured logger for warnings and errors which can't be returned to the caller; slog.Default() is used if nil
+	Encoder            JSONEncoder    // marshals responses in WriteJSON; json.Marshal is used if nil
+	AcceptedJSONTypes  []string       // media types ReadJSON accepts besides application/json; "application/*+json" allows any +json suffix
+	ErrorPage          ErrorPageFunc  // writes HTML error pages for WriteError; a minimal built-in page is used if nil
+	Codecs             []Codec        // formats WriteResponse and ReadBody support besides JSON, such as MsgpackCodec and CBORCodec
+	JSONIndent         string         // if set, WriteJSON indents responses with it, such as "  " (for development)
+	DisableHTMLEscape  bool           // if set to true, WriteJSON writes <, > and & in strings as they are, rather than as \u003c and so on
+	CanonicalJSON      bool           // if set to true, WriteJSON writes object keys in sorted order (see MarshalCanonicalJSON)
+
+	ResponseDecorators []ResponseDecorator // called by WriteJSON before each response is written, to add metadata
+	HTTPClientConfig   HTTPClientConfig    // timeouts, connection limits and instrumentation of HTTPClient
 }
 
 type JSONResponse struct {
-	Error   bool   `json:"error"`
-	Message string `json:"message"`
-	Data    any    `json:"data,omitempty"`
+	Error   bool           `json:"error"`
+	Message string         `json:"message"`
+	Data    any            `json:"data,omitempty"`
+	Meta    map[string]any `json:"meta,omitempty"` // set by ResponseDecorators, such as the request ID
 }
 
 // ReadJSON tries to read the body of a request and converts it from JSON to a variable. The third parameter, data,
-// is expected to be a pointer, so that we can read data into it.
-func (t *Tools) ReadJSON(w http.ResponseWriter, r *http.Request, data any) error {
+// is expected to be a pointer, so that we can read data into it. Options such as WithMaxSize override the
+// Tools settings for this call only.
+func (t *Tools) ReadJSON(w http.ResponseWriter, r *http.Request, data any, opts ...JSONOption) error {
+	o := t.jsonOptions(opts)
 
-	// Check content-type header; it should be application/json. If it's not specified,
-	// try to decode the body anyway.
+	// Check content-type header; it should be application/json or one of AcceptedJSONTypes, optionally with
+	// a charset parameter. If it's not specified, try to decode the body anyway.
 	if r.Header.Get("Content-Type") != "" {
-		contentType := r.Header.Get("Content-Type")
-		if strings.ToLower(contentType) != "application/json" {
-			return errors.New("the Content-Type header is not application/json")
+		if err := checkJSONContentType(r.Header.Get("Content-Type"), t.AcceptedJSONTypes); err != nil {
+			return err
 		}
 	}
 
-	// Set a sensible default for the maximum payload size.
-	maxBytes := defaultMaxUpload
+	// The maximum payload size defaults to defaultMaxUpload, unless MaxJSONSize or WithMaxSize is set.
+	maxBytes := o.maxSize
+	r.Body = http.MaxBytesReader(w, r.Body, int64(maxBytes))
 
-	// If MaxJSONSize is set, use that value instead of default.
-	if t.MaxJSONSize != 0 {
-		maxBytes = t.MaxJSONSize
+	// Some clients (notably on Windows) start the body with a UTF-8 byte order mark, which isn't valid JSON.
+	body := bufio.NewReader(r.Body)
+	if bom, err := body.Peek(len(utf8BOM)); err == nil && bytes.Equal(bom, utf8BOM) {
+		_, _ = body.Discard(len(utf8BOM))
 	}
-	r.Body = http.MaxBytesReader(w, r.Body, int64(maxBytes))
 
-	dec := json.NewDecoder(r.Body)
+	dec := json.NewDecoder(body)
 
 	// Should we allow unknown fields?
-	if !t.AllowUnknownFields {
+	if !o.allowUnknown {
 		dec.DisallowUnknownFields()
 	}
+	if o.useNumber {
+		dec.UseNumber()
+	}
 
 	// Attempt to decode the data, and figure out what the error is, if any, to send back a human-readable
 	// response.
@@ -98,19 +136,77 @@ func (t *Tools) ReadJSON(w http.ResponseWriter, r *http.Request, data any) error
 	return nil
 }
 
-// WriteJSON takes a response status code and arbitrary data and writes a JSON response to the client.
-func (t *Tools) WriteJSON(w http.ResponseWriter, status int, data interface{}, headers ...http.Header) error {
-	out, err := json.Marshal(data)
-	if err != nil {
-		return err
+// checkJSONContentType returns an error unless contentType is application/json or one of accepted, with
+// no charset or a UTF-8 one, since JSON exchanged between systems must be UTF-8 (RFC 8259).
+func checkJSONContentType(contentType string, accepted []string) error {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || !isAcceptedJSONType(mediaType, accepted) {
+		return errors.New("the Content-Type header is not application/json")
 	}
 
+	if charset, ok := params["charset"]; ok {
+		if c := strings.ToLower(charset); c != "utf-8" && c != "utf8" {
+			return fmt.Errorf("unsupported charset %q; only utf-8 is accepted", charset)
+		}
+	}
+	return nil
+}
+
+// isAcceptedJSONType reports whether mediaType, which is already lower case, is application/json or matches
+// one of accepted. An entry such as "application/*+json" matches any subtype with a +json structured syntax
+// suffix (RFC 6839), like application/vnd.myapp+json or application/json-patch+json.
+func isAcceptedJSONType(mediaType string, accepted []string) bool {
+	if mediaType == "application/json" {
+		return true
+	}
+
+	for _, a := range accepted {
+		a = strings.ToLower(strings.TrimSpace(a))
+		if a == mediaType {
+			return true
+		}
+		if prefix, suffix, ok := strings.Cut(a, "*"); ok &&
+			len(mediaType) > len(prefix)+len(suffix) &&
+			strings.HasPrefix(mediaType, prefix) && strings.HasSuffix(mediaType, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteJSON takes a response status code and arbitrary data and writes a JSON response to the client.
+func (t *Tools) WriteJSON(w http.ResponseWriter, status int, data interface{}, headers ...http.Header) error {
 	// If we have a value as the last parameter in the function call, then we are setting a custom header.
 	if len(headers) > 0 {
-		for key, value := range headers[0] {
-			w.Header()[key] = value
+		return t.WriteJSONWith(w, status, data, WithHeaders(headers[0]))
+	}
+	return t.WriteJSONWith(w, status, data)
+}
+
+// WriteJSONWith writes a JSON response like WriteJSON, with options overriding the Tools settings for this
+// call only. Custom headers can be set with the WithHeaders option, and the output formatted with
+// WithIndent, WithCanonical and WithoutHTMLEscape.
+func (t *Tools) WriteJSONWith(w http.ResponseWriter, status int, data interface{}, opts ...JSONOption) error {
+	o := t.jsonOptions(opts)
+	if o.indent == "" && prettyRequested(w) {
+		o.indent = "  "
+	}
+	data = t.decorate(w, data)
+	if o.fields == nil {
+		o.fields = requestedFields(w)
+	}
+	if len(o.fields) > 0 {
+		var err error
+		if data, err = t.filterResponse(data, o.fields); err != nil {
+			return err
 		}
 	}
+	out, err := t.encodeJSON(data, o)
+	if err != nil {
+		return err
+	}
+
+	o.setHeaders(w)
 
 	// Set the content type and send response.
 	w.Header().Set("Content-Type", "application/json")
@@ -130,35 +226,34 @@ func (t *Tools) ErrorJSON(w http.ResponseWriter, err error, status ...int) error
 		statusCode = status[0]
 	}
 
+	// Schema validation errors are sent as a list, with the path of each.
+	var schemaErrs SchemaErrors
+	errors.As(err, &schemaErrs)
+
+	// Without the envelope, only the message is sent.
+	if t.rawMode(w) {
+		return t.WriteJSON(w, statusCode, rawError{Message: err.Error(), Errors: schemaErrs})
+	}
+
 	// Build the JSON payload.
 	var payload JSONResponse
 	payload.Error = true
 	payload.Message = err.Error()
+	if len(schemaErrs) > 0 {
+		payload.Data = map[string]any{"errors": schemaErrs}
+	}
 
 	return t.WriteJSON(w, statusCode, payload)
 }
 
-// RandomString returns a random string of letters of length n, using characters specified in randomStringSource.
-func (t *Tools) RandomString(n int) string {
-	s, r := make([]rune, n), []rune(randomStringSource)
-	for i := range s {
-		p, _ := rand.Prime(rand.Reader, len(r))
-		x, y := p.Uint64(), uint64(len(r))
-		s[i] = r[x%y]
-	}
-	return string(s)
+// RandomString returns a random string of length n, using the characters in randomStringSource.
+func (t *Tools) RandomString(n int) (string, error) {
+	return t.RandomStringFrom(n, randomStringSource)
 }
 
-// Slugify is a (very) simple means of creating a slug from a provided string.
+// Slugify is a (very) simple means of creating a slug from a provided string. Accented letters are
+// transliterated to ASCII (é becomes e, ß becomes ss); other characters which aren't letters or digits are
+// removed. Use SlugifyWithOptions for more control.
 func (t *Tools) Slugify(s string) (string, error) {
-	if s == "" {
-		return "", errors.New("empty string not permitted")
-	}
-	var re = regexp.MustCompile(`[^a-z\d]+`)
-	slug := strings.Trim(re.ReplaceAllString(strings.ToLower(s), "-"), "-")
-	if len(slug) == 0 {
-		return "", errors.New("after removing characters, slug is zero length")
-	}
-
-	return slug, nil
+	return t.SlugifyWithOptions(s, SlugOptions{})
 }
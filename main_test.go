@@ -31,6 +31,7 @@ var jsonTests = []struct {
 	maxSize       int
 	allowUnknown  bool
 	contentType   string
+	acceptedTypes []string
 }{
 	{name: "good json", json: `{"foo": "bar"}`, errorExpected: false, maxSize: 1024, allowUnknown: false},
 	{name: "badly formatted json", json: `{"foo":"}`, errorExpected: true, maxSize: 1024, allowUnknown: false},
@@ -43,6 +44,14 @@ var jsonTests = []struct {
 	{name: "allow unknown field in json", json: `{"fooo": "bar"}`, errorExpected: false, maxSize: 1024, allowUnknown: true},
 	{name: "missing field name", json: `{jack: "bar"}`, errorExpected: true, maxSize: 1024, allowUnknown: false},
 	{name: "not json", json: `Hello, world`, errorExpected: true, maxSize: 1024, allowUnknown: false},
+	{name: "charset parameter", json: `{"foo": "bar"}`, errorExpected: false, maxSize: 1024, allowUnknown: false, contentType: "application/json; charset=UTF-8"},
+	{name: "unsupported charset", json: `{"foo": "bar"}`, errorExpected: true, maxSize: 1024, allowUnknown: false, contentType: "application/json; charset=windows-1252"},
+	{name: "wrong content type", json: `{"foo": "bar"}`, errorExpected: true, maxSize: 1024, allowUnknown: false, contentType: "text/plain"},
+	{name: "vendor type not accepted", json: `{"foo": "bar"}`, errorExpected: true, maxSize: 1024, allowUnknown: false, contentType: "application/vnd.myapp+json"},
+	{name: "vendor type accepted", json: `{"foo": "bar"}`, errorExpected: false, maxSize: 1024, allowUnknown: false, contentType: "application/vnd.myapp+json", acceptedTypes: []string{"application/vnd.myapp+json"}},
+	{name: "suffix wildcard", json: `{"foo": "bar"}`, errorExpected: false, maxSize: 1024, allowUnknown: false, contentType: "application/json-patch+json; charset=utf-8", acceptedTypes: []string{"application/*+json"}},
+	{name: "suffix wildcard mismatch", json: `{"foo": "bar"}`, errorExpected: true, maxSize: 1024, allowUnknown: false, contentType: "application/vnd.myapp+xml", acceptedTypes: []string{"application/*+json"}},
+	{name: "utf-8 bom", json: "\xEF\xBB\xBF{\"foo\": \"bar\"}", errorExpected: false, maxSize: 1024, allowUnknown: false},
 }
 
 func TestTools_ReadJSON(t *testing.T) {
@@ -54,6 +63,9 @@ func TestTools_ReadJSON(t *testing.T) {
 		// allow/disallow unknown fields.
 		testTools.AllowUnknownFields = e.allowUnknown
 
+		// accept additional media types.
+		testTools.AcceptedJSONTypes = e.acceptedTypes
+
 		// declare a variable to read the decoded json into.
 		var decodedJSON struct {
 			Foo string `json:"foo"`
@@ -183,7 +195,10 @@ func TestTools_ErrorJSON(t *testing.T) {
 func TestTools_RandomString(t *testing.T) {
 	var testTools Tools
 
-	s := testTools.RandomString(10)
+	s, err := testTools.RandomString(10)
+	if err != nil {
+		t.Error(err)
+	}
 	if len(s) != 10 {
 		t.Error("wrong length random string returned")
 	}
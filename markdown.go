@@ -0,0 +1,133 @@
+package gohelpertools
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	markdownHeadingRegex = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*$`)
+	markdownBulletRegex  = regexp.MustCompile(`^\s*[-*+]\s+(.*)$`)
+	markdownNumberRegex  = regexp.MustCompile(`^\s*\d+[.)]\s+(.*)$`)
+	markdownLinkRegex    = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	markdownStrongRegex  = regexp.MustCompile(`\*\*(.+?)\*\*|__(.+?)__`)
+	markdownEmRegex      = regexp.MustCompile(`\*([^*]+)\*|\b_([^_]+)_\b`)
+)
+
+// Markdown converts a small, safe subset of Markdown to HTML: paragraphs, # headings, bulleted and
+// numbered lists, fenced code blocks, `code`, **bold**, *italics* and [links](https://example.com). Any HTML
+// in the input is escaped, and links other than http, https, mailto and relative ones are dropped, so
+// it is safe for user-written text such as comments and descriptions.
+func (t *Tools) Markdown(s string) string {
+	var out strings.Builder
+	var paragraph []string
+	var list string // "ul" or "ol" while in a list
+
+	flushParagraph := func() {
+		if len(paragraph) > 0 {
+			out.WriteString("<p>" + markdownInline(strings.Join(paragraph, " ")) + "</p>\n")
+			paragraph = nil
+		}
+	}
+	closeList := func() {
+		if list != "" {
+			out.WriteString("</" + list + ">\n")
+			list = ""
+		}
+	}
+	openList := func(kind string) {
+		if list != kind {
+			closeList()
+			out.WriteString("<" + kind + ">\n")
+			list = kind
+		}
+	}
+
+	lines := strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case strings.HasPrefix(trimmed, "```"):
+			flushParagraph()
+			closeList()
+			var code []string
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), "```"); i++ {
+				code = append(code, html.EscapeString(lines[i]))
+			}
+			out.WriteString("<pre><code>" + strings.Join(code, "\n") + "</code></pre>\n")
+
+		case trimmed == "":
+			flushParagraph()
+			closeList()
+
+		case markdownHeadingRegex.MatchString(trimmed):
+			flushParagraph()
+			closeList()
+			m := markdownHeadingRegex.FindStringSubmatch(trimmed)
+			level := string(rune('0' + len(m[1])))
+			out.WriteString("<h" + level + ">" + markdownInline(m[2]) + "</h" + level + ">\n")
+
+		case markdownBulletRegex.MatchString(line):
+			flushParagraph()
+			openList("ul")
+			out.WriteString("<li>" + markdownInline(markdownBulletRegex.FindStringSubmatch(line)[1]) + "</li>\n")
+
+		case markdownNumberRegex.MatchString(line):
+			flushParagraph()
+			openList("ol")
+			out.WriteString("<li>" + markdownInline(markdownNumberRegex.FindStringSubmatch(line)[1]) + "</li>\n")
+
+		default:
+			closeList()
+			paragraph = append(paragraph, trimmed)
+		}
+	}
+	flushParagraph()
+	closeList()
+
+	return strings.TrimSuffix(out.String(), "\n")
+}
+
+// markdownInline converts the inline Markdown in s to HTML, escaping everything else. Text in `code`
+// spans is escaped but not otherwise converted.
+func markdownInline(s string) string {
+	parts := strings.Split(s, "`")
+	for i, part := range parts {
+		part = html.EscapeString(part)
+		if i%2 == 1 && i < len(parts)-1 {
+			parts[i] = "<code>" + part + "</code>"
+			continue
+		}
+		if i%2 == 1 {
+			// An unmatched backtick is kept as text.
+			part = "`" + part
+		}
+
+		part = markdownLinkRegex.ReplaceAllStringFunc(part, func(m string) string {
+			sub := markdownLinkRegex.FindStringSubmatch(m)
+			if !isSafeLink(html.UnescapeString(sub[2])) {
+				return sub[1]
+			}
+			return `<a href="` + sub[2] + `">` + sub[1] + `</a>`
+		})
+		part = markdownStrongRegex.ReplaceAllString(part, "<strong>$1$2</strong>")
+		part = markdownEmRegex.ReplaceAllString(part, "<em>$1$2</em>")
+		parts[i] = part
+	}
+	return strings.Join(parts, "")
+}
+
+// isSafeLink reports whether href is an http, https or mailto URL, or a relative one.
+func isSafeLink(href string) bool {
+	lower := strings.ToLower(href)
+	for _, scheme := range []string{"http://", "https://", "mailto:"} {
+		if strings.HasPrefix(lower, scheme) {
+			return true
+		}
+	}
+	scheme, _, hasColon := strings.Cut(lower, ":")
+	return !hasColon || strings.ContainsAny(scheme, "/?#")
+}
@@ -0,0 +1,108 @@
+package gohelpertools
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+const memoContextKey contextKey = "memo"
+
+// memoStore holds the results computed during one request. An entry's done channel is closed once its
+// value is ready, so concurrent callers wait for the first rather than computing it again.
+type memoStore struct {
+	mu      sync.Mutex
+	entries map[string]*memoEntry
+}
+
+type memoEntry struct {
+	done     chan struct{}
+	value    any
+	err      error
+	panicked bool // compute panicked, so waiters return err rather than trying again
+}
+
+// Memoize is middleware which gives each request a memo store, so that GetOrCompute can share the results
+// of expensive lookups, such as loading the current user or tenant settings, between the middleware and
+// handlers of that request. Results are dropped when the request ends.
+func (t *Tools) Memoize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(WithMemo(r.Context())))
+	})
+}
+
+// WithMemo returns a copy of ctx with an empty memo store, for using GetOrCompute outside of Memoize, such
+// as in a background job.
+func WithMemo(ctx context.Context) context.Context {
+	return context.WithValue(ctx, memoContextKey, &memoStore{entries: make(map[string]*memoEntry)})
+}
+
+// GetOrCompute returns the value stored under key in ctx's memo store, calling compute to produce it the
+// first time. Concurrent calls for the same key wait for the first one. Errors aren't stored, so a failed
+// computation is tried again by the next caller. A panic in compute is recovered and returned as an error
+// to the caller and everyone waiting for it. If ctx has no memo store, compute is simply called.
+//
+// Keys are shared by every caller in the request, so they should be specific, such as "user:42"; asking for
+// a key with a different type than the one stored returns an error.
+func GetOrCompute[V any](ctx context.Context, key string, compute func(ctx context.Context) (V, error)) (V, error) {
+	store, ok := ctx.Value(memoContextKey).(*memoStore)
+	if !ok {
+		return compute(ctx)
+	}
+
+	var zero V
+	for {
+		store.mu.Lock()
+		entry, ok := store.entries[key]
+		if !ok {
+			break
+		}
+		store.mu.Unlock()
+
+		select {
+		case <-entry.done:
+		case <-ctx.Done():
+			return zero, ctx.Err()
+		}
+		if entry.err != nil {
+			if entry.panicked {
+				return zero, entry.err
+			}
+			// The computation failed and was forgotten; try again.
+			continue
+		}
+		value, ok := entry.value.(V)
+		if !ok {
+			return zero, fmt.Errorf("memo key %q holds a %T, not a %T", key, entry.value, zero)
+		}
+		return value, nil
+	}
+
+	entry := &memoEntry{done: make(chan struct{})}
+	store.entries[key] = entry
+	store.mu.Unlock()
+
+	return computeMemo(ctx, store, key, entry, compute)
+}
+
+// computeMemo calls compute for entry, and stores the result, or forgets the entry if it failed, even if
+// compute panics, so that callers waiting for it are always released.
+func computeMemo[V any](ctx context.Context, store *memoStore, key string, entry *memoEntry, compute func(ctx context.Context) (V, error)) (value V, err error) {
+	defer close(entry.done)
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("memo compute panicked: %v", p)
+			entry.panicked = true
+		}
+
+		store.mu.Lock()
+		defer store.mu.Unlock()
+		entry.value, entry.err = value, err
+		if err != nil {
+			delete(store.entries, key)
+		}
+	}()
+
+	return compute(ctx)
+}
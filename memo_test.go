@@ -0,0 +1,128 @@
+package gohelpertools
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGetOrCompute(t *testing.T) {
+	var tools Tools
+	var calls int32
+
+	loadUser := func(r *http.Request) (string, error) {
+		return GetOrCompute(r.Context(), "user:42", func(ctx context.Context) (string, error) {
+			atomic.AddInt32(&calls, 1)
+			time.Sleep(5 * time.Millisecond)
+			return "jane", nil
+		})
+	}
+
+	middleware := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if user, err := loadUser(r); err != nil || user != "jane" {
+				t.Errorf("unexpected user %q, %v", user, err)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var wg sync.WaitGroup
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, _ = loadUser(r)
+			}()
+		}
+		wg.Wait()
+
+		if _, err := GetOrCompute(r.Context(), "user:42", func(ctx context.Context) (int, error) { return 1, nil }); err == nil {
+			t.Error("expected an error for a key of a different type")
+		}
+	})
+
+	h := tools.Memoize(middleware(handler))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if calls != 1 {
+		t.Errorf("expected one computation within the request, got %d", calls)
+	}
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if calls != 2 {
+		t.Errorf("expected a new computation for a new request, got %d calls", calls)
+	}
+}
+
+func TestGetOrCompute_Errors(t *testing.T) {
+	ctx := WithMemo(context.Background())
+	var calls int
+
+	compute := func(ctx context.Context) (int, error) {
+		calls++
+		if calls == 1 {
+			return 0, errors.New("temporary")
+		}
+		return 7, nil
+	}
+
+	if _, err := GetOrCompute(ctx, "n", compute); err == nil {
+		t.Error("expected the first computation to fail")
+	}
+	if n, err := GetOrCompute(ctx, "n", compute); err != nil || n != 7 {
+		t.Errorf("expected the failure not to be stored, got %d, %v", n, err)
+	}
+	if n, _ := GetOrCompute(ctx, "n", compute); n != 7 || calls != 2 {
+		t.Errorf("expected the value to be stored, got %d after %d calls", n, calls)
+	}
+
+	// Without a memo store, compute is always called.
+	if n, _ := GetOrCompute(context.Background(), "n", compute); n != 7 || calls != 3 {
+		t.Errorf("expected compute to be called without a store, got %d calls", calls)
+	}
+}
+
+func TestGetOrCompute_Panic(t *testing.T) {
+	ctx := WithMemo(context.Background())
+	started, release := make(chan struct{}), make(chan struct{})
+
+	computeErr := make(chan error, 1)
+	go func() {
+		_, err := GetOrCompute(ctx, "n", func(ctx context.Context) (int, error) {
+			close(started)
+			<-release
+			panic("boom")
+		})
+		computeErr <- err
+	}()
+	<-started
+
+	waiterErr := make(chan error, 1)
+	go func() {
+		_, err := GetOrCompute(ctx, "n", func(ctx context.Context) (int, error) { return 1, nil })
+		waiterErr <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+
+	for name, ch := range map[string]chan error{"caller": computeErr, "waiter": waiterErr} {
+		select {
+		case err := <-ch:
+			if err == nil {
+				t.Errorf("%s: error expected, but none received", name)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("%s: still waiting after compute panicked", name)
+		}
+	}
+
+	// The panic isn't stored, so the next caller computes the value again.
+	if n, err := GetOrCompute(ctx, "n", func(ctx context.Context) (int, error) { return 2, nil }); err != nil || n != 2 {
+		t.Errorf("expected a fresh computation after the panic, got %d, %v", n, err)
+	}
+}
@@ -0,0 +1,134 @@
+package gohelpertools
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// AutoHead is middleware which answers HEAD requests by running next as if the request were a GET, and
+// discarding the body. The headers are sent as the GET would send them, with a Content-Length matching
+// the body which was discarded, unless the handler set one itself. Other requests are passed through.
+func (t *Tools) AutoHead(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		get := r.Clone(r.Context())
+		get.Method = http.MethodGet
+
+		hw := &headWriter{w: w, code: http.StatusOK}
+		next.ServeHTTP(hw, get)
+		hw.finish()
+	})
+}
+
+// AutoOptions returns middleware which answers OPTIONS requests with 204 No Content and an Allow header
+// listing methods. HEAD is listed if GET is, and OPTIONS is always listed. Other requests are passed
+// through.
+func (t *Tools) AutoOptions(methods ...string) func(http.Handler) http.Handler {
+	allow := allowHeader(methods)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodOptions {
+				next.ServeHTTP(w, r)
+				return
+			}
+			w.Header().Set("Allow", allow)
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
+
+// MethodGuard passes requests using one of methods on to next. HEAD requests are passed on if GET is
+// allowed, and OPTIONS requests are answered as by AutoOptions unless OPTIONS is one of methods. Any other
+// request gets a 405 Method Not Allowed JSON error with an Allow header, rather than the plain-text
+// response from the standard library.
+func (t *Tools) MethodGuard(next http.Handler, methods ...string) http.Handler {
+	allow := allowHeader(methods)
+	allowed := strings.Split(allow, ", ")
+	optionsListed := false
+	for _, method := range methods {
+		if strings.EqualFold(strings.TrimSpace(method), http.MethodOptions) {
+			optionsListed = true
+		}
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodOptions && !optionsListed:
+			w.Header().Set("Allow", allow)
+			w.WriteHeader(http.StatusNoContent)
+		case contains(allowed, r.Method):
+			next.ServeHTTP(w, r)
+		default:
+			w.Header().Set("Allow", allow)
+			_ = t.ErrorJSON(w, fmt.Errorf("method %s is not allowed", r.Method), http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+// allowHeader returns the value of an Allow header for methods, in upper case and without duplicates, with
+// HEAD added if GET is present, and OPTIONS added at the end.
+func allowHeader(methods []string) string {
+	var allowed []string
+	add := func(method string) {
+		if !contains(allowed, method) {
+			allowed = append(allowed, method)
+		}
+	}
+
+	for _, method := range methods {
+		method = strings.ToUpper(strings.TrimSpace(method))
+		if method == "" || method == http.MethodOptions {
+			continue
+		}
+		add(method)
+		if method == http.MethodGet {
+			add(http.MethodHead)
+		}
+	}
+	add(http.MethodOptions)
+
+	return strings.Join(allowed, ", ")
+}
+
+// headWriter is the http.ResponseWriter handed to handlers wrapped by AutoHead. It counts the body instead
+// of sending it, and holds back the status until the handler returns, so that Content-Length can be set.
+type headWriter struct {
+	w           http.ResponseWriter
+	code        int
+	wroteHeader bool
+	size        int64
+}
+
+func (hw *headWriter) Header() http.Header {
+	return hw.w.Header()
+}
+
+func (hw *headWriter) Write(p []byte) (int, error) {
+	hw.wroteHeader = true
+	hw.size += int64(len(p))
+	return len(p), nil
+}
+
+func (hw *headWriter) WriteHeader(code int) {
+	if hw.wroteHeader {
+		return
+	}
+	hw.wroteHeader = true
+	hw.code = code
+}
+
+func (hw *headWriter) finish() {
+	header := hw.w.Header()
+	bodyAllowed := hw.code >= 200 && hw.code != http.StatusNoContent && hw.code != http.StatusNotModified
+	if bodyAllowed && header.Get("Content-Length") == "" && header.Get("Transfer-Encoding") == "" {
+		header.Set("Content-Length", strconv.FormatInt(hw.size, 10))
+	}
+	hw.w.WriteHeader(hw.code)
+}
@@ -0,0 +1,110 @@
+package gohelpertools
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestTools_AutoHead(t *testing.T) {
+	var tools Tools
+	var seenMethod string
+	handler := tools.AutoHead(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seenMethod = r.Method
+		_ = tools.WriteJSON(w, http.StatusOK, JSONResponse{Message: "hello"})
+	}))
+
+	get := httptest.NewRecorder()
+	handler.ServeHTTP(get, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	head := httptest.NewRecorder()
+	handler.ServeHTTP(head, httptest.NewRequest(http.MethodHead, "/", nil))
+
+	if seenMethod != http.MethodGet {
+		t.Errorf("expected handler to see GET but got %s", seenMethod)
+	}
+	if head.Code != http.StatusOK {
+		t.Errorf("expected status 200 but got %d", head.Code)
+	}
+	if head.Body.Len() != 0 {
+		t.Errorf("expected no body but got %q", head.Body.String())
+	}
+	if got, want := head.Header().Get("Content-Length"), strconv.Itoa(get.Body.Len()); got != want {
+		t.Errorf("expected Content-Length %s but got %s", want, got)
+	}
+	if head.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("expected GET headers to be kept but got %v", head.Header())
+	}
+}
+
+var allowHeaderTests = []struct {
+	name    string
+	methods []string
+	want    string
+}{
+	{name: "get adds head", methods: []string{"GET", "POST"}, want: "GET, HEAD, POST, OPTIONS"},
+	{name: "normalized", methods: []string{"post", " put ", "POST", "OPTIONS"}, want: "POST, PUT, OPTIONS"},
+	{name: "none", methods: nil, want: "OPTIONS"},
+}
+
+func TestTools_AutoOptions(t *testing.T) {
+	var tools Tools
+	for _, e := range allowHeaderTests {
+		handler := tools.AutoOptions(e.methods...)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t.Errorf("%s: handler should not be called for OPTIONS", e.name)
+		}))
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodOptions, "/", nil))
+
+		if rr.Code != http.StatusNoContent {
+			t.Errorf("%s: expected status 204 but got %d", e.name, rr.Code)
+		}
+		if got := rr.Header().Get("Allow"); got != e.want {
+			t.Errorf("%s: expected Allow %q but got %q", e.name, e.want, got)
+		}
+	}
+}
+
+var methodGuardTests = []struct {
+	name       string
+	method     string
+	wantStatus int
+	wantCalled bool
+}{
+	{name: "allowed", method: http.MethodPost, wantStatus: http.StatusOK, wantCalled: true},
+	{name: "head with get", method: http.MethodHead, wantStatus: http.StatusOK, wantCalled: true},
+	{name: "options", method: http.MethodOptions, wantStatus: http.StatusNoContent},
+	{name: "not allowed", method: http.MethodDelete, wantStatus: http.StatusMethodNotAllowed},
+}
+
+func TestTools_MethodGuard(t *testing.T) {
+	var tools Tools
+	for _, e := range methodGuardTests {
+		called := false
+		handler := tools.MethodGuard(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			called = true
+		}), "GET", "post")
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(e.method, "/", nil))
+
+		if rr.Code != e.wantStatus {
+			t.Errorf("%s: expected status %d but got %d", e.name, e.wantStatus, rr.Code)
+		}
+		if called != e.wantCalled {
+			t.Errorf("%s: expected handler called to be %v", e.name, e.wantCalled)
+		}
+		if !e.wantCalled && rr.Header().Get("Allow") != "GET, HEAD, POST, OPTIONS" {
+			t.Errorf("%s: unexpected Allow header %q", e.name, rr.Header().Get("Allow"))
+		}
+		if e.wantStatus == http.StatusMethodNotAllowed {
+			var resp JSONResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil || !resp.Error {
+				t.Errorf("%s: expected a JSON error but got %q", e.name, rr.Body.String())
+			}
+		}
+	}
+}
@@ -0,0 +1,350 @@
+package gohelpertools
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode"
+)
+
+// DefaultDurationBuckets are the upper bounds, in seconds, of the request duration histogram buckets.
+var DefaultDurationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// DefaultSizeBuckets are the upper bounds, in bytes, of the response size histogram buckets.
+var DefaultSizeBuckets = []float64{100, 1000, 10000, 100000, 1e6, 1e7}
+
+// Metrics collects HTTP metrics and business counters, and serves them in the Prometheus text exposition
+// format, without depending on the Prometheus client library. Its Middleware records, per route, method
+// and status:
+//
+//	http_requests_total                 counter
+//	http_request_duration_seconds       histogram
+//	http_response_size_bytes            histogram
+//	http_requests_in_flight             gauge (not per route)
+//
+// and, when it's set as HTTPClientConfig.Metrics, outbound requests made by Tools.HTTPClient, per method,
+// host and status:
+//
+//	http_client_requests_total              counter
+//	http_client_request_duration_seconds    histogram
+//
+// Histograms are recorded with Histogram, and their Prometheus buckets are counted to within its default
+// 1% precision. Since every distinct route (or host) makes new series, which any client can cause by
+// requesting made-up paths, at most MaxSeries label sets are kept for each kind of request; requests
+// beyond that are recorded with the route or host "other".
+//
+// The zero value is ready to use.
+type Metrics struct {
+	DurationBuckets []float64                    // defaults to DefaultDurationBuckets
+	SizeBuckets     []float64                    // defaults to DefaultSizeBuckets
+	Route           func(r *http.Request) string // returns the route label for r; see DefaultRoute
+	OnRequest       func(RequestMetric)          // if set, called with every request recorded, such as SLOTracker.Record
+	MaxSeries       int                          // maximum number of label sets for requests, and for outbound requests; defaults to 1000
+
+	once     sync.Once
+	mu       sync.Mutex
+	requests map[string]*uint64
+	duration map[string]*Histogram
+	size     map[string]*Histogram
+	inFlight int64
+	counters []*Counter
+
+	clientRequests map[string]*uint64
+	clientDuration map[string]*Histogram
+}
+
+// defaultMaxSeries is how many label sets Metrics keeps for each kind of request if MaxSeries isn't set.
+const defaultMaxSeries = 1000
+
+// overflowLabel replaces the route or host of requests recorded once MaxSeries is reached.
+const overflowLabel = "other"
+
+// RequestMetric describes one request recorded by Metrics.Middleware.
+type RequestMetric struct {
+	Method   string
+	Route    string
+	Status   int
+	Duration time.Duration
+	Size     int64
+}
+
+// Counter is a business metric registered with Metrics.Counter, such as signups_total.
+type Counter struct {
+	name   string
+	help   string
+	labels []string
+
+	mu     sync.Mutex
+	values map[string]float64
+}
+
+// DefaultRoute returns the request path with each segment containing a digit replaced by ":id", so that
+// /users/42/orders becomes /users/:id/orders. This keeps the number of label values bounded for typical
+// REST paths, but not for made-up paths such as /wp-admin, which Metrics.MaxSeries guards against; set
+// Metrics.Route to use your router's route pattern instead.
+func DefaultRoute(r *http.Request) string {
+	segments := strings.Split(r.URL.Path, "/")
+	for i, segment := range segments {
+		if strings.IndexFunc(segment, unicode.IsDigit) >= 0 {
+			segments[i] = ":id"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// Middleware records metrics for every request.
+func (m *Metrics) Middleware(next http.Handler) http.Handler {
+	m.init()
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&m.inFlight, 1)
+		defer atomic.AddInt64(&m.inFlight, -1)
+
+		start := time.Now()
+		mw := &metricsWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(mw, r)
+		elapsed := time.Since(start)
+
+		route := DefaultRoute
+		if m.Route != nil {
+			route = m.Route
+		}
+		routeName := route(r)
+		names := []string{"method", "route", "status"}
+		labels := formatLabels(names, []string{r.Method, routeName, strconv.Itoa(mw.status)})
+
+		m.mu.Lock()
+		if m.requests[labels] == nil && len(m.requests) >= m.maxSeries() {
+			routeName = overflowLabel
+			labels = formatLabels(names, []string{r.Method, routeName, strconv.Itoa(mw.status)})
+		}
+		if m.requests[labels] == nil {
+			m.requests[labels] = new(uint64)
+			m.duration[labels] = NewHistogram(0)
+			m.size[labels] = NewHistogram(0)
+		}
+		*m.requests[labels]++
+		duration, size := m.duration[labels], m.size[labels]
+		m.mu.Unlock()
+		duration.RecordDuration(elapsed)
+		size.Record(float64(mw.size))
+
+		if m.OnRequest != nil {
+			m.OnRequest(RequestMetric{Method: r.Method, Route: routeName, Status: mw.status, Duration: elapsed, Size: mw.size})
+		}
+	})
+}
+
+// Counter registers a counter called name, with the given label names, and returns it.
+func (m *Metrics) Counter(name, help string, labels ...string) *Counter {
+	m.init()
+
+	c := &Counter{name: name, help: help, labels: labels, values: make(map[string]float64)}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.counters = append(m.counters, c)
+	return c
+}
+
+// Inc adds 1 to the counter for the given label values.
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds v, which must not be negative, to the counter for the given label values, which must match the
+// label names given to Metrics.Counter.
+func (c *Counter) Add(v float64, labelValues ...string) {
+	if v < 0 {
+		return
+	}
+	labels := formatLabels(c.labels, labelValues)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values[labels] += v
+}
+
+// Handler returns a handler serving the metrics in the Prometheus text format, for mounting at /metrics.
+func (m *Metrics) Handler() http.Handler {
+	m.init()
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		bw := bufio.NewWriter(w)
+		m.write(bw)
+		_ = bw.Flush()
+	})
+}
+
+func (m *Metrics) write(w *bufio.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fmt.Fprintln(w, "# HELP http_requests_total Total number of HTTP requests.")
+	fmt.Fprintln(w, "# TYPE http_requests_total counter")
+	for _, labels := range sortedKeys(m.requests) {
+		fmt.Fprintf(w, "http_requests_total%s %d\n", labels, *m.requests[labels])
+	}
+
+	writeHistograms(w, "http_request_duration_seconds", "Duration of HTTP requests in seconds.", m.duration, m.DurationBuckets, DefaultDurationBuckets)
+	writeHistograms(w, "http_response_size_bytes", "Size of HTTP responses in bytes.", m.size, m.SizeBuckets, DefaultSizeBuckets)
+
+	fmt.Fprintln(w, "# HELP http_requests_in_flight Number of HTTP requests being handled.")
+	fmt.Fprintln(w, "# TYPE http_requests_in_flight gauge")
+	fmt.Fprintf(w, "http_requests_in_flight %d\n", atomic.LoadInt64(&m.inFlight))
+
+	if len(m.clientRequests) > 0 {
+		fmt.Fprintln(w, "# HELP http_client_requests_total Total number of outbound HTTP requests.")
+		fmt.Fprintln(w, "# TYPE http_client_requests_total counter")
+		for _, labels := range sortedKeys(m.clientRequests) {
+			fmt.Fprintf(w, "http_client_requests_total%s %d\n", labels, *m.clientRequests[labels])
+		}
+		writeHistograms(w, "http_client_request_duration_seconds", "Duration of outbound HTTP requests in seconds.", m.clientDuration, m.DurationBuckets, DefaultDurationBuckets)
+	}
+
+	for _, c := range m.counters {
+		c.mu.Lock()
+		fmt.Fprintf(w, "# HELP %s %s\n", c.name, escapeHelp(c.help))
+		fmt.Fprintf(w, "# TYPE %s counter\n", c.name)
+		for _, labels := range sortedKeys(c.values) {
+			fmt.Fprintf(w, "%s%s %s\n", c.name, labels, formatFloat(c.values[labels]))
+		}
+		c.mu.Unlock()
+	}
+}
+
+// writeHistograms writes each histogram with cumulative buckets at bounds, or def if bounds is empty.
+func writeHistograms(w *bufio.Writer, name, help string, histograms map[string]*Histogram, bounds, def []float64) {
+	if len(bounds) == 0 {
+		bounds = def
+	}
+	fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(w, "# TYPE %s histogram\n", name)
+	for _, labels := range sortedKeys(histograms) {
+		h := histograms[labels].snapshot()
+		inner := strings.TrimSuffix(strings.TrimPrefix(labels, "{"), "}")
+		if inner != "" {
+			inner += ","
+		}
+		for _, bound := range bounds {
+			fmt.Fprintf(w, "%s_bucket{%sle=\"%s\"} %d\n", name, inner, formatFloat(bound), h.CountAtOrBelow(bound))
+		}
+		fmt.Fprintf(w, "%s_bucket{%sle=\"+Inf\"} %d\n", name, inner, h.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", name, labels, formatFloat(h.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", name, labels, h.count)
+	}
+}
+
+func (m *Metrics) init() {
+	m.once.Do(func() {
+		m.requests = make(map[string]*uint64)
+		m.duration = make(map[string]*Histogram)
+		m.size = make(map[string]*Histogram)
+		m.clientRequests = make(map[string]*uint64)
+		m.clientDuration = make(map[string]*Histogram)
+	})
+}
+
+func (m *Metrics) maxSeries() int {
+	if m.MaxSeries > 0 {
+		return m.MaxSeries
+	}
+	return defaultMaxSeries
+}
+
+// observeClient records an outbound request made by a Tools.HTTPClient, with status "error" if no
+// response was received.
+func (m *Metrics) observeClient(method, host, status string, duration time.Duration) {
+	m.init()
+	names := []string{"method", "host", "status"}
+	labels := formatLabels(names, []string{method, host, status})
+
+	m.mu.Lock()
+	if m.clientRequests[labels] == nil && len(m.clientRequests) >= m.maxSeries() {
+		labels = formatLabels(names, []string{method, overflowLabel, status})
+	}
+	if m.clientRequests[labels] == nil {
+		m.clientRequests[labels] = new(uint64)
+		m.clientDuration[labels] = NewHistogram(0)
+	}
+	*m.clientRequests[labels]++
+	histogram := m.clientDuration[labels]
+	m.mu.Unlock()
+	histogram.RecordDuration(duration)
+}
+
+// formatLabels returns the Prometheus label set for names and values, such as {method="GET"}. Missing
+// values are empty.
+func formatLabels(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		value := ""
+		if i < len(values) {
+			value = values[i]
+		}
+		b.WriteString(name + `="` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value) + `"`)
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+func escapeHelp(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(s)
+}
+
+func formatFloat(f float64) string {
+	if math.IsInf(f, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// metricsWriter records the status and size of a response.
+type metricsWriter struct {
+	http.ResponseWriter
+	status      int
+	size        int64
+	wroteHeader bool
+}
+
+func (mw *metricsWriter) WriteHeader(code int) {
+	if !mw.wroteHeader {
+		mw.status, mw.wroteHeader = code, true
+	}
+	mw.ResponseWriter.WriteHeader(code)
+}
+
+func (mw *metricsWriter) Write(b []byte) (int, error) {
+	mw.wroteHeader = true
+	n, err := mw.ResponseWriter.Write(b)
+	mw.size += int64(n)
+	return n, err
+}
+
+func (mw *metricsWriter) Unwrap() http.ResponseWriter {
+	return mw.ResponseWriter
+}
@@ -0,0 +1,93 @@
+package gohelpertools
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMetrics(t *testing.T) {
+	var m Metrics
+	signups := m.Counter("signups_total", "Number of signups.", "plan")
+
+	handler := m.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte("hello"))
+	}))
+
+	for _, path := range []string{"/users/1", "/users/2", "/missing"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	signups.Inc("pro")
+	signups.Add(2, `free "trial"`)
+
+	rr := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rr.Body.String()
+
+	for _, want := range []string{
+		"# TYPE http_requests_total counter",
+		`http_requests_total{method="GET",route="/users/:id",status="200"} 2`,
+		`http_requests_total{method="GET",route="/missing",status="404"} 1`,
+		`http_request_duration_seconds_bucket{method="GET",route="/users/:id",status="200",le="+Inf"} 2`,
+		`http_request_duration_seconds_count{method="GET",route="/users/:id",status="200"} 2`,
+		`http_response_size_bytes_bucket{method="GET",route="/users/:id",status="200",le="100"} 2`,
+		`http_response_size_bytes_sum{method="GET",route="/users/:id",status="200"} 10`,
+		"http_requests_in_flight 0",
+		"# TYPE signups_total counter",
+		`signups_total{plan="pro"} 1`,
+		`signups_total{plan="free \"trial\""} 2`,
+	} {
+		if !strings.Contains(body, want+"\n") {
+			t.Errorf("expected metrics to contain %q", want)
+		}
+	}
+	if !strings.HasPrefix(rr.Header().Get("Content-Type"), "text/plain; version=0.0.4") {
+		t.Errorf("unexpected Content-Type %q", rr.Header().Get("Content-Type"))
+	}
+}
+
+func TestMetrics_MaxSeries(t *testing.T) {
+	m := Metrics{MaxSeries: 2}
+	handler := m.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	for _, path := range []string{"/", "/users", "/wp-admin", "/.env", "/users"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	rr := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rr.Body.String()
+	for _, want := range []string{
+		`http_requests_total{method="GET",route="/",status="200"} 1`,
+		`http_requests_total{method="GET",route="/users",status="200"} 2`,
+		`http_requests_total{method="GET",route="other",status="200"} 2`,
+	} {
+		if !strings.Contains(body, want+"\n") {
+			t.Errorf("expected metrics to contain %q", want)
+		}
+	}
+	if strings.Contains(body, "wp-admin") {
+		t.Error("expected routes beyond MaxSeries to be grouped")
+	}
+}
+
+var defaultRouteTests = []struct {
+	path string
+	want string
+}{
+	{path: "/", want: "/"},
+	{path: "/users", want: "/users"},
+	{path: "/users/42/orders/9f1c", want: "/users/:id/orders/:id"},
+}
+
+func TestDefaultRoute(t *testing.T) {
+	for _, e := range defaultRouteTests {
+		if got := DefaultRoute(httptest.NewRequest(http.MethodGet, e.path, nil)); got != e.want {
+			t.Errorf("%s: expected %q but got %q", e.path, e.want, got)
+		}
+	}
+}
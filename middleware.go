@@ -0,0 +1,159 @@
+package gohelpertools
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Timeout returns middleware that cancels the request context after d. If the wrapped handler has not
+// finished by then, a JSON error response is sent using ErrorJSON instead of the plain-text body produced
+// by http.TimeoutHandler. The response status defaults to 503 Service Unavailable; an optional status
+// (for example http.StatusGatewayTimeout) may be supplied instead.
+func (t *Tools) Timeout(d time.Duration, status ...int) func(http.Handler) http.Handler {
+	statusCode := http.StatusServiceUnavailable
+	if len(status) > 0 {
+		statusCode = status[0]
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), d)
+			defer cancel()
+			r = r.WithContext(ctx)
+
+			// The handler writes into a buffer, so that nothing reaches the client until we know whether
+			// it finished in time.
+			tw := &timeoutWriter{w: w, header: make(http.Header), code: http.StatusOK}
+			done := make(chan struct{})
+			panicChan := make(chan any, 1)
+
+			go func() {
+				defer func() {
+					if p := recover(); p != nil {
+						panicChan <- p
+					}
+				}()
+				next.ServeHTTP(tw, r)
+				close(done)
+			}()
+
+			select {
+			case p := <-panicChan:
+				panic(p)
+
+			case <-done:
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+				for key, value := range tw.header {
+					w.Header()[key] = value
+				}
+				w.WriteHeader(tw.code)
+				_, _ = w.Write(tw.buf.Bytes())
+
+			case <-ctx.Done():
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+				tw.timedOut = true
+				_ = t.ErrorJSON(w, errors.New("the request timed out"), statusCode)
+			}
+		})
+	}
+}
+
+// timeoutWriter is the buffered http.ResponseWriter handed to handlers wrapped by Timeout.
+type timeoutWriter struct {
+	w           http.ResponseWriter
+	mu          sync.Mutex
+	header      http.Header
+	buf         bytes.Buffer
+	code        int
+	wroteHeader bool
+	timedOut    bool
+}
+
+// Unwrap returns the underlying ResponseWriter, so that settings made by outer middleware, such as
+// SparseFields, can be found through the Timeout middleware.
+func (tw *timeoutWriter) Unwrap() http.ResponseWriter {
+	return tw.w
+}
+
+// Flush does nothing: the response is buffered until the handler returns, and the underlying writer must
+// not be touched from the handler's goroutine, which Timeout may abandon.
+func (tw *timeoutWriter) Flush() {}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) Write(p []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	tw.wroteHeader = true
+	return tw.buf.Write(p)
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut || tw.wroteHeader {
+		return
+	}
+	tw.wroteHeader = true
+	tw.code = code
+}
+
+// SecureHeadersConfig holds the header values sent by the SecureHeaders middleware. Any field left empty
+// falls back to a sensible default.
+type SecureHeadersConfig struct {
+	ContentTypeOptions    string // X-Content-Type-Options; defaults to "nosniff"
+	FrameOptions          string // X-Frame-Options; defaults to "DENY"
+	ReferrerPolicy        string // Referrer-Policy; defaults to "strict-origin-when-cross-origin"
+	HSTS                  string // Strict-Transport-Security; defaults to "max-age=31536000; includeSubDomains"
+	ContentSecurityPolicy string // Content-Security-Policy; defaults to "default-src 'self'"
+	DisableHSTS           bool   // if set to true, don't send Strict-Transport-Security (useful in local development)
+	DisableCSP            bool   // if set to true, don't send Content-Security-Policy
+}
+
+// SecureHeaders returns middleware which sets common security headers on every response. An optional
+// SecureHeadersConfig may be passed to override the defaults.
+func (t *Tools) SecureHeaders(config ...SecureHeadersConfig) func(http.Handler) http.Handler {
+	var cfg SecureHeadersConfig
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+
+	headers := make(http.Header)
+	headers.Set("X-Content-Type-Options", valueOrDefault(cfg.ContentTypeOptions, "nosniff"))
+	headers.Set("X-Frame-Options", valueOrDefault(cfg.FrameOptions, "DENY"))
+	headers.Set("Referrer-Policy", valueOrDefault(cfg.ReferrerPolicy, "strict-origin-when-cross-origin"))
+	if !cfg.DisableHSTS {
+		headers.Set("Strict-Transport-Security", valueOrDefault(cfg.HSTS, "max-age=31536000; includeSubDomains"))
+	}
+	if !cfg.DisableCSP {
+		headers.Set("Content-Security-Policy", valueOrDefault(cfg.ContentSecurityPolicy, "default-src 'self'"))
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for key, value := range headers {
+				w.Header()[key] = value
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// valueOrDefault returns value, or def if value is empty.
+func valueOrDefault(value, def string) string {
+	if value == "" {
+		return def
+	}
+	return value
+}
@@ -0,0 +1,120 @@
+package gohelpertools
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var timeoutTests = []struct {
+	name           string
+	sleep          time.Duration
+	status         []int
+	expectedStatus int
+}{
+	{name: "fast handler", sleep: 0, expectedStatus: http.StatusOK},
+	{name: "slow handler", sleep: 50 * time.Millisecond, expectedStatus: http.StatusServiceUnavailable},
+	{name: "slow handler custom status", sleep: 50 * time.Millisecond, status: []int{http.StatusGatewayTimeout}, expectedStatus: http.StatusGatewayTimeout},
+}
+
+func TestTools_Timeout(t *testing.T) {
+	var testTools Tools
+
+	for _, e := range timeoutTests {
+		sleep := e.sleep
+		handler := testTools.Timeout(10*time.Millisecond, e.status...)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-time.After(sleep):
+			case <-r.Context().Done():
+				return
+			}
+			w.Header().Set("X-Test", "yes")
+			_, _ = w.Write([]byte("ok"))
+		}))
+
+		rr := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		handler.ServeHTTP(rr, req)
+
+		if rr.Code != e.expectedStatus {
+			t.Errorf("%s: expected status %d but got %d", e.name, e.expectedStatus, rr.Code)
+		}
+
+		if e.expectedStatus == http.StatusOK {
+			if rr.Body.String() != "ok" || rr.Header().Get("X-Test") != "yes" {
+				t.Errorf("%s: buffered response was not copied to the client", e.name)
+			}
+			continue
+		}
+
+		var payload JSONResponse
+		if err := json.NewDecoder(rr.Body).Decode(&payload); err != nil {
+			t.Errorf("%s: timeout response is not valid JSON: %s", e.name, err)
+		}
+		if !payload.Error {
+			t.Errorf("%s: error should be set to true in the timeout response", e.name)
+		}
+	}
+}
+
+var secureHeadersTests = []struct {
+	name     string
+	config   []SecureHeadersConfig
+	expected map[string]string
+}{
+	{
+		name:   "defaults",
+		config: nil,
+		expected: map[string]string{
+			"X-Content-Type-Options":    "nosniff",
+			"X-Frame-Options":           "DENY",
+			"Referrer-Policy":           "strict-origin-when-cross-origin",
+			"Strict-Transport-Security": "max-age=31536000; includeSubDomains",
+			"Content-Security-Policy":   "default-src 'self'",
+		},
+	},
+	{
+		name:   "overrides",
+		config: []SecureHeadersConfig{{FrameOptions: "SAMEORIGIN", ContentSecurityPolicy: "default-src 'none'", DisableHSTS: true}},
+		expected: map[string]string{
+			"X-Frame-Options":           "SAMEORIGIN",
+			"Strict-Transport-Security": "",
+			"Content-Security-Policy":   "default-src 'none'",
+		},
+	},
+}
+
+func TestTools_SecureHeaders(t *testing.T) {
+	var testTools Tools
+
+	for _, e := range secureHeadersTests {
+		handler := testTools.SecureHeaders(e.config...)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+		rr := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		handler.ServeHTTP(rr, req)
+
+		for key, value := range e.expected {
+			if rr.Header().Get(key) != value {
+				t.Errorf("%s: wrong value for %s; expected %q but got %q", e.name, key, value, rr.Header().Get(key))
+			}
+		}
+	}
+}
+
+func TestTools_TimeoutUnwrap(t *testing.T) {
+	var testTools Tools
+
+	// SparseFields sits outside Timeout, so its setting must be found through the timeout writer.
+	handler := testTools.SparseFields(testTools.Timeout(time.Second)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = testTools.WriteJSON(w, http.StatusOK, map[string]any{"id": 1, "title": "One"})
+	})))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/?fields=title", nil))
+	if rr.Body.String() != `{"title":"One"}` {
+		t.Errorf("expected the fields to be filtered through Timeout, got %s", rr.Body.String())
+	}
+}
@@ -0,0 +1,332 @@
+package gohelpertools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrRatesStale is returned when the only exchange rates available are older than the provider allows.
+var ErrRatesStale = errors.New("exchange rates are out of date")
+
+// threeDecimalCurrencies have a minor unit of a thousandth.
+var threeDecimalCurrencies = []string{"BHD", "IQD", "JOD", "KWD", "LYD", "OMR", "TND"}
+
+// Money is an amount in a currency's minor unit, such as cents, so that arithmetic on it is exact.
+type Money struct {
+	Amount   int64  `json:"amount"`   // in the currency's minor unit, so 1999 USD is $19.99
+	Currency string `json:"currency"` // ISO 4217 code, such as "USD"
+}
+
+// NewMoney returns amount minor units of currency.
+func NewMoney(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: strings.ToUpper(currency)}
+}
+
+// String formats m as FormatMoney does, such as "$19.99".
+func (m Money) String() string {
+	return formatMoney(m.Amount, m.Currency)
+}
+
+// Add returns m + other. Both must be in the same currency.
+func (m Money) Add(other Money) (Money, error) {
+	if !strings.EqualFold(m.Currency, other.Currency) {
+		return Money{}, fmt.Errorf("can't add %s to %s", other.Currency, m.Currency)
+	}
+	sum := m.Amount + other.Amount
+	if (sum > m.Amount) != (other.Amount > 0) {
+		return Money{}, errors.New("amount out of range")
+	}
+	return NewMoney(sum, m.Currency), nil
+}
+
+// Convert returns m in the currency rate converts to, rounded to the nearest minor unit (halves away from
+// zero). The rate must convert from m's currency.
+func (m Money) Convert(rate ExchangeRate) (Money, error) {
+	if !strings.EqualFold(rate.From, m.Currency) {
+		return Money{}, fmt.Errorf("can't convert %s with a rate from %s", m.Currency, rate.From)
+	}
+	scale := math.Pow10(minorUnitDigits(rate.To) - minorUnitDigits(m.Currency))
+	amount := math.Round(float64(m.Amount) * rate.Rate * scale)
+	if math.IsNaN(amount) || math.Abs(amount) >= math.MaxInt64 {
+		return Money{}, errors.New("amount out of range")
+	}
+	return NewMoney(int64(amount), rate.To), nil
+}
+
+// ConvertTo returns m in the currency to, at the rate given by provider.
+func (m Money) ConvertTo(ctx context.Context, provider RatesProvider, to string) (Money, error) {
+	rate, err := provider.Rate(ctx, m.Currency, to)
+	if err != nil {
+		return Money{}, err
+	}
+	return m.Convert(rate)
+}
+
+// minorUnitDigits returns the number of digits in the minor unit of currency.
+func minorUnitDigits(currency string) int {
+	currency = strings.ToUpper(currency)
+	switch {
+	case contains(zeroDecimalCurrencies, currency):
+		return 0
+	case contains(threeDecimalCurrencies, currency):
+		return 3
+	default:
+		return 2
+	}
+}
+
+// ExchangeRate is the price of one unit of From in To.
+type ExchangeRate struct {
+	From string    `json:"from"`
+	To   string    `json:"to"`
+	Rate float64   `json:"rate"`
+	AsOf time.Time `json:"as_of"` // when the rate was published
+}
+
+// RatesProvider provides exchange rates, for Money.ConvertTo.
+type RatesProvider interface {
+	Rate(ctx context.Context, from, to string) (ExchangeRate, error)
+}
+
+// Rates is a table of exchange rates against a base currency. It is itself a RatesProvider, which is
+// useful for fixed rates and in tests, and for converting every price in a response at the same rates:
+//
+//	rates, err := provider.Rates(ctx)
+//	...
+//	price, err := item.Price.ConvertTo(ctx, rates, "EUR")
+type Rates struct {
+	Base  string             `json:"base"`
+	Rates map[string]float64 `json:"rates"` // units of each currency per unit of Base
+	AsOf  time.Time          `json:"as_of"`
+}
+
+// Rate returns the rate from one currency to another, through the base currency if neither is it.
+func (r Rates) Rate(ctx context.Context, from, to string) (ExchangeRate, error) {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	fromRate, err := r.baseRate(from)
+	if err != nil {
+		return ExchangeRate{}, err
+	}
+	toRate, err := r.baseRate(to)
+	if err != nil {
+		return ExchangeRate{}, err
+	}
+	return ExchangeRate{From: from, To: to, Rate: toRate / fromRate, AsOf: r.AsOf}, nil
+}
+
+func (r Rates) baseRate(currency string) (float64, error) {
+	if strings.EqualFold(currency, r.Base) {
+		return 1, nil
+	}
+	rate, ok := r.Rates[currency]
+	if !ok || rate <= 0 {
+		return 0, fmt.Errorf("no exchange rate for %s", currency)
+	}
+	return rate, nil
+}
+
+// HTTPRatesProvider fetches exchange rates from an HTTP API, and keeps them for RefreshAfter. If a refresh
+// fails, the rates it has are used until MaxAge after they were last fetched, after which conversions fail
+// with ErrRatesStale rather than use badly outdated rates. The age is counted from the fetch rather than the
+// publication date, since sources such as the ECB publish nothing at weekends.
+//
+// By default the response is expected to look like {"base": "USD", "date": "2024-05-01", "rates": {"EUR":
+// 0.93, ...}}, as returned by Frankfurter and similar services; a "timestamp" in Unix seconds may be given
+// instead of a date. Set Parse for other formats.
+type HTTPRatesProvider struct {
+	URL          string                           // URL of the rates, with "{base}" replaced by Base; required
+	Base         string                           // base currency requested; defaults to "USD"
+	Headers      http.Header                      // extra request headers, such as an API key
+	Parse        func(body []byte) (Rates, error) // decodes a response; the format above is used if nil
+	RefreshAfter time.Duration                    // how long rates are used before they are fetched again; defaults to 1 hour
+	MaxAge       time.Duration                    // how long after the last successful fetch rates may be used; defaults to 48 hours
+	Client       *http.Client                     // used to fetch rates; Tools.HTTPClient is used if nil
+	Tools        *Tools                           // used to log failed refreshes; a zero Tools is used if nil
+
+	mu        sync.Mutex
+	rates     *Rates
+	fetchedAt time.Time
+	nextFetch time.Time
+	fetching  chan struct{} // closed when the fetch in progress, if any, finishes
+	now       func() time.Time
+}
+
+// Rate returns the exchange rate from one currency to another.
+func (p *HTTPRatesProvider) Rate(ctx context.Context, from, to string) (ExchangeRate, error) {
+	rates, err := p.Rates(ctx)
+	if err != nil {
+		return ExchangeRate{}, err
+	}
+	return rates.Rate(ctx, from, to)
+}
+
+// Rates returns the current table of rates, fetching it if it's due for a refresh. After a failed refresh,
+// the next attempt is made a minute later at the soonest, so a source which is down isn't called on every
+// conversion.
+//
+// Only one caller fetches at a time, without holding up the others: while a refresh is in progress, the
+// rates already fetched are returned, and only callers with no rates at all wait for it.
+func (p *HTTPRatesProvider) Rates(ctx context.Context) (Rates, error) {
+	for {
+		p.mu.Lock()
+		now := p.clock()
+		due := p.rates == nil || !now.Before(p.nextFetch)
+		switch {
+		case due && p.fetching == nil:
+			p.fetching = make(chan struct{})
+			p.mu.Unlock()
+			if err := p.refresh(ctx, now); err != nil {
+				return Rates{}, err
+			}
+			continue
+		case p.rates == nil:
+			fetching := p.fetching
+			p.mu.Unlock()
+			select {
+			case <-fetching:
+				continue
+			case <-ctx.Done():
+				return Rates{}, ctx.Err()
+			}
+		}
+
+		rates := *p.rates
+		stale := now.Sub(p.fetchedAt) > p.maxAge()
+		p.mu.Unlock()
+		if stale {
+			return Rates{}, ErrRatesStale
+		}
+		return rates, nil
+	}
+}
+
+// refresh fetches the rates, without holding p.mu, and stores them. An error is returned only if there are
+// no rates to fall back on; otherwise it's logged and the old rates are kept.
+func (p *HTTPRatesProvider) refresh(ctx context.Context, now time.Time) error {
+	defer func() {
+		p.mu.Lock()
+		close(p.fetching)
+		p.fetching = nil
+		p.mu.Unlock()
+	}()
+
+	rates, err := p.fetch(ctx, now)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	switch {
+	case err == nil:
+		p.rates, p.fetchedAt, p.nextFetch = &rates, now, now.Add(p.refreshAfter())
+	case p.rates == nil:
+		return err
+	default:
+		toolsOrDefault(p.Tools).logWarn(ctx, "unable to refresh exchange rates", "url", p.URL, "error", err.Error())
+		p.nextFetch = now.Add(min(p.refreshAfter(), time.Minute))
+	}
+	return nil
+}
+
+func (p *HTTPRatesProvider) clock() time.Time {
+	if p.now != nil {
+		return p.now()
+	}
+	return time.Now()
+}
+
+func (p *HTTPRatesProvider) fetch(ctx context.Context, now time.Time) (Rates, error) {
+	base := strings.ToUpper(valueOrDefault(p.Base, "USD"))
+	uri := strings.ReplaceAll(p.URL, "{base}", base)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return Rates{}, err
+	}
+	req.Header.Set("Accept", "application/json")
+	for key, values := range p.Headers {
+		req.Header[key] = values
+	}
+
+	client := p.Client
+	if client == nil {
+		client = toolsOrDefault(p.Tools).HTTPClient()
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return Rates{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Rates{}, fmt.Errorf("fetching exchange rates returned status %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, defaultMaxUpload))
+	if err != nil {
+		return Rates{}, err
+	}
+
+	parse := p.Parse
+	if parse == nil {
+		parse = parseRates
+	}
+	rates, err := parse(body)
+	if err != nil {
+		return Rates{}, fmt.Errorf("invalid exchange rates: %w", err)
+	}
+	rates.Base = strings.ToUpper(valueOrDefault(rates.Base, base))
+	if rates.AsOf.IsZero() {
+		rates.AsOf = now
+	}
+	return rates, nil
+}
+
+func (p *HTTPRatesProvider) refreshAfter() time.Duration {
+	if p.RefreshAfter <= 0 {
+		return time.Hour
+	}
+	return p.RefreshAfter
+}
+
+func (p *HTTPRatesProvider) maxAge() time.Duration {
+	if p.MaxAge <= 0 {
+		return 48 * time.Hour
+	}
+	return p.MaxAge
+}
+
+// parseRates decodes the default response format of HTTPRatesProvider.
+func parseRates(body []byte) (Rates, error) {
+	var response struct {
+		Base      string             `json:"base"`
+		Date      string             `json:"date"`
+		Timestamp int64              `json:"timestamp"`
+		Rates     map[string]float64 `json:"rates"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return Rates{}, err
+	}
+	if len(response.Rates) == 0 {
+		return Rates{}, errors.New("no rates in response")
+	}
+
+	rates := Rates{Base: response.Base, Rates: make(map[string]float64, len(response.Rates))}
+	for currency, rate := range response.Rates {
+		rates.Rates[strings.ToUpper(currency)] = rate
+	}
+	switch {
+	case response.Timestamp > 0:
+		rates.AsOf = time.Unix(response.Timestamp, 0)
+	case response.Date != "":
+		date, err := time.Parse(time.DateOnly, response.Date)
+		if err != nil {
+			return Rates{}, fmt.Errorf("invalid date %q", response.Date)
+		}
+		rates.AsOf = date
+	}
+	return rates, nil
+}
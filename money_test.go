@@ -0,0 +1,165 @@
+package gohelpertools
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var testRates = Rates{Base: "USD", Rates: map[string]float64{"EUR": 0.92, "JPY": 155.5, "KWD": 0.307, "GBP": 0.79}}
+
+var convertTests = []struct {
+	name          string
+	money         Money
+	to            string
+	expected      Money
+	errorExpected bool
+}{
+	{name: "usd to eur", money: NewMoney(1999, "usd"), to: "EUR", expected: Money{1839, "EUR"}},
+	{name: "usd to jpy", money: NewMoney(1999, "USD"), to: "JPY", expected: Money{3108, "JPY"}},
+	{name: "jpy to usd", money: NewMoney(3108, "JPY"), to: "USD", expected: Money{1999, "USD"}},
+	{name: "usd to kwd", money: NewMoney(10000, "USD"), to: "KWD", expected: Money{30700, "KWD"}},
+	{name: "through base", money: NewMoney(10000, "EUR"), to: "GBP", expected: Money{8587, "GBP"}},
+	{name: "same currency", money: NewMoney(-250, "EUR"), to: "eur", expected: Money{-250, "EUR"}},
+	{name: "unknown currency", money: NewMoney(100, "USD"), to: "XYZ", errorExpected: true},
+}
+
+func TestMoney_ConvertTo(t *testing.T) {
+	for _, e := range convertTests {
+		converted, err := e.money.ConvertTo(context.Background(), testRates, e.to)
+		if e.errorExpected {
+			if err == nil {
+				t.Errorf("%s: error expected, but none received", e.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: error not expected, but one received: %s", e.name, err)
+			continue
+		}
+		if converted != e.expected {
+			t.Errorf("%s: expected %v, but got %v", e.name, e.expected, converted)
+		}
+	}
+}
+
+func TestMoney_AddAndString(t *testing.T) {
+	sum, err := NewMoney(1999, "USD").Add(NewMoney(1, "usd"))
+	if err != nil || sum.String() != "$20.00" {
+		t.Errorf("expected $20.00, got %s (%v)", sum, err)
+	}
+	if _, err := NewMoney(1, "USD").Add(NewMoney(1, "EUR")); err == nil {
+		t.Error("expected an error adding different currencies")
+	}
+	if _, err := NewMoney(1<<62, "USD").Add(NewMoney(1<<62, "USD")); err == nil {
+		t.Error("expected an error on overflow")
+	}
+	if _, err := NewMoney(1, "USD").Convert(ExchangeRate{From: "EUR", To: "USD", Rate: 1}); err == nil {
+		t.Error("expected an error converting with a rate from another currency")
+	}
+}
+
+func TestHTTPRatesProvider(t *testing.T) {
+	var calls atomic.Int32
+	var failing atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if failing.Load() || r.Header.Get("X-Api-Key") != "secret" || r.URL.Query().Get("base") != "EUR" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"base":"EUR","date":"2024-05-01","rates":{"usd":1.08,"GBP":0.86}}`))
+	}))
+	defer srv.Close()
+
+	clock := &fakeClock{t: time.Date(2024, 5, 1, 16, 0, 0, 0, time.UTC)}
+	provider := &HTTPRatesProvider{
+		URL:     srv.URL + "/latest?base={base}",
+		Base:    "eur",
+		Headers: http.Header{"X-Api-Key": {"secret"}},
+		MaxAge:  36 * time.Hour,
+		now:     clock.now,
+	}
+	ctx := context.Background()
+
+	rate, err := provider.Rate(ctx, "EUR", "USD")
+	if err != nil || rate.Rate != 1.08 || !rate.AsOf.Equal(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected rate %+v (%v)", rate, err)
+	}
+	if price, _ := NewMoney(1000, "USD").ConvertTo(ctx, provider, "GBP"); price != (Money{796, "GBP"}) {
+		t.Errorf("expected 796 GBP, got %v", price)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("expected the rates to be fetched once, got %d", calls.Load())
+	}
+
+	// When the source fails, the rates it has are used while they're recent enough, without calling the
+	// source again for a minute.
+	failing.Store(true)
+	clock.advance(2 * time.Hour)
+	if _, err := provider.Rate(ctx, "EUR", "GBP"); err != nil {
+		t.Errorf("expected the previous rates to be used, got %v", err)
+	}
+	_, _ = provider.Rate(ctx, "EUR", "GBP")
+	if calls.Load() != 2 {
+		t.Errorf("expected one failed refresh, got %d calls", calls.Load())
+	}
+
+	// The age of the rates is counted from when they were fetched, not their publication date.
+	clock.advance(30 * time.Hour)
+	if _, err := provider.Rate(ctx, "EUR", "GBP"); err != nil {
+		t.Errorf("expected rates fetched 32 hours ago to be used, got %v", err)
+	}
+	clock.advance(6 * time.Hour)
+	if _, err := provider.Rate(ctx, "EUR", "GBP"); !errors.Is(err, ErrRatesStale) {
+		t.Errorf("expected ErrRatesStale, got %v", err)
+	}
+
+	if _, err := (&HTTPRatesProvider{URL: srv.URL}).Rates(ctx); err == nil {
+		t.Error("expected an error when the first fetch fails")
+	}
+}
+
+func TestHTTPRatesProvider_RefreshDoesNotBlock(t *testing.T) {
+	release := make(chan struct{})
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) > 1 {
+			<-release
+		}
+		_, _ = w.Write([]byte(`{"base":"EUR","date":"2024-05-01","rates":{"USD":1.08}}`))
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	clock := &fakeClock{t: time.Date(2024, 5, 1, 16, 0, 0, 0, time.UTC)}
+	provider := &HTTPRatesProvider{URL: srv.URL, now: clock.now}
+	ctx := context.Background()
+	if _, err := provider.Rates(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	clock.advance(2 * time.Hour)
+	go func() { _, _ = provider.Rates(ctx) }()
+	for calls.Load() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := provider.Rate(ctx, "EUR", "USD")
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("expected the previous rates during the refresh, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Error("expected Rates not to wait for the refresh in progress")
+	}
+}
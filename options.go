@@ -0,0 +1,167 @@
+package gohelpertools
+
+import (
+	"encoding/json"
+	"log/slog"
+	"time"
+)
+
+// JSONEncoder marshals a value to JSON. It allows a faster or differently configured encoder to be used
+// in place of json.Marshal.
+type JSONEncoder func(v any) ([]byte, error)
+
+// Option configures a Tools created by New.
+type Option func(*Tools)
+
+// New returns a Tools configured by opts. A zero Tools is still ready to use, so New is optional; it
+// is a more readable alternative to setting fields on the struct, as the number of settings grows.
+func New(opts ...Option) *Tools {
+	t := &Tools{}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// WithMaxJSONSize sets the maximum size, in bytes, of JSON bodies read by ReadJSON.
+func WithMaxJSONSize(n int) Option {
+	return func(t *Tools) { t.MaxJSONSize = n }
+}
+
+// WithAllowUnknownFields makes ReadJSON accept fields which aren't in the destination struct.
+func WithAllowUnknownFields() Option {
+	return func(t *Tools) { t.AllowUnknownFields = true }
+}
+
+// WithTrustedProxies sets the IPs or CIDRs of proxies whose forwarding headers RealIP trusts.
+func WithTrustedProxies(proxies ...string) Option {
+	return func(t *Tools) { t.TrustedProxies = append([]string(nil), proxies...) }
+}
+
+// WithRawBody writes responses without the JSONResponse envelope, by setting DisableEnvelope. To do so
+// only for some routes, use the Tools.WithoutEnvelope middleware instead.
+func WithRawBody() Option {
+	return func(t *Tools) { t.DisableEnvelope = true }
+}
+
+// WithUseJSONNumber makes ReadJSON decode numbers in interface values as json.Number.
+func WithUseJSONNumber() Option {
+	return func(t *Tools) { t.UseJSONNumber = true }
+}
+
+// WithSafeJSONIntegers makes WriteJSON write integers beyond ±(2^53 - 1) as strings.
+func WithSafeJSONIntegers() Option {
+	return func(t *Tools) { t.SafeJSONIntegers = true }
+}
+
+// WithPasswordConfig sets the algorithm and cost parameters used by HashPassword.
+func WithPasswordConfig(config PasswordConfig) Option {
+	return func(t *Tools) { t.Password = config }
+}
+
+// WithFetchCache sets the cache used by CachedFetchJSON.
+func WithFetchCache(cache *FetchCache) Option {
+	return func(t *Tools) { t.FetchCache = cache }
+}
+
+// WithResolver sets the resolver used for DNS lookups.
+func WithResolver(resolver Resolver) Option {
+	return func(t *Tools) { t.Resolver = resolver }
+}
+
+// WithDNSCache sets the cache used by ResolveWithCache.
+func WithDNSCache(cache *DNSCache) Option {
+	return func(t *Tools) { t.DNSCache = cache }
+}
+
+// WithURLSigningKey sets the key used by SignURL and VerifySignedURL.
+func WithURLSigningKey(key []byte) Option {
+	return func(t *Tools) { t.URLSigningKey = key }
+}
+
+// WithDisposableDomains sets the domains rejected by IsDisposableEmail.
+func WithDisposableDomains(domains *DomainSet) Option {
+	return func(t *Tools) { t.DisposableDomains = domains }
+}
+
+// WithWebhookTolerance sets how old a signed webhook may be in VerifySignature.
+func WithWebhookTolerance(d time.Duration) Option {
+	return func(t *Tools) { t.WebhookTolerance = d }
+}
+
+// WithLogger sets the structured logger used to report warnings and errors which can't be returned to the
+// caller.
+func WithLogger(logger *slog.Logger) Option {
+	return func(t *Tools) { t.Logger = logger }
+}
+
+// WithEncoder sets the function WriteJSON uses to marshal responses.
+func WithEncoder(encoder JSONEncoder) Option {
+	return func(t *Tools) { t.Encoder = encoder }
+}
+
+// WithAcceptedJSONTypes sets the media types ReadJSON accepts besides application/json, such as
+// "application/vnd.myapp+json", or "application/*+json" for any +json suffix.
+func WithAcceptedJSONTypes(types ...string) Option {
+	return func(t *Tools) { t.AcceptedJSONTypes = types }
+}
+
+// WithErrorPage sets the function WriteError uses to write HTML error pages.
+func WithErrorPage(page ErrorPageFunc) Option {
+	return func(t *Tools) { t.ErrorPage = page }
+}
+
+// WithImageUploads makes UploadFiles process uploaded JPEG, PNG and WebP images as opts describes.
+func WithImageUploads(opts ImageOptions) Option {
+	return func(t *Tools) { t.ImageUploads = &opts }
+}
+
+// WithPDFUploads makes UploadFiles check uploaded PDF documents, and process them as opts describes.
+func WithPDFUploads(opts PDFOptions) Option {
+	return func(t *Tools) { t.PDFUploads = &opts }
+}
+
+// WithCodecs adds formats, such as XMLCodec, MsgpackCodec or CBORCodec, which WriteResponse and ReadBody
+// support besides JSON.
+func WithCodecs(codecs ...Codec) Option {
+	return func(t *Tools) { t.Codecs = append(t.Codecs, codecs...) }
+}
+
+// WithJSONIndent makes WriteJSON indent responses with indent, such as "  ".
+func WithJSONIndent(indent string) Option {
+	return func(t *Tools) { t.JSONIndent = indent }
+}
+
+// WithoutJSONHTMLEscape makes WriteJSON write <, > and & in strings as they are.
+func WithoutJSONHTMLEscape() Option {
+	return func(t *Tools) { t.DisableHTMLEscape = true }
+}
+
+// WithCanonicalJSON makes WriteJSON write object keys in sorted order.
+func WithCanonicalJSON() Option {
+	return func(t *Tools) { t.CanonicalJSON = true }
+}
+
+// WithResponseDecorators adds decorators which WriteJSON calls before writing each response.
+func WithResponseDecorators(decorators ...ResponseDecorator) Option {
+	return func(t *Tools) { t.ResponseDecorators = append(t.ResponseDecorators, decorators...) }
+}
+
+// WithHTTPClientConfig sets the timeouts, connection limits and instrumentation of HTTPClient.
+func WithHTTPClientConfig(config HTTPClientConfig) Option {
+	return func(t *Tools) { t.HTTPClientConfig = config }
+}
+
+func (t *Tools) logger() *slog.Logger {
+	if t.Logger != nil {
+		return t.Logger
+	}
+	return slog.Default()
+}
+
+func (t *Tools) encode(v any) ([]byte, error) {
+	if t.Encoder != nil {
+		return t.Encoder(v)
+	}
+	return json.Marshal(v)
+}
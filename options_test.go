@@ -0,0 +1,47 @@
+package gohelpertools
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNew(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+
+	tools := New(
+		WithMaxJSONSize(1024),
+		WithAllowUnknownFields(),
+		WithTrustedProxies("10.0.0.0/8"),
+		WithRawBody(),
+		WithURLSigningKey([]byte("key")),
+		WithWebhookTolerance(time.Minute),
+		WithLogger(logger),
+	)
+
+	if tools.MaxJSONSize != 1024 || !tools.AllowUnknownFields || len(tools.TrustedProxies) != 1 || !tools.DisableEnvelope {
+		t.Errorf("options not applied: %+v", tools)
+	}
+	if string(tools.URLSigningKey) != "key" || tools.WebhookTolerance != time.Minute || tools.logger() != logger {
+		t.Errorf("options not applied: %+v", tools)
+	}
+
+	if (&Tools{}).logger() != slog.Default() {
+		t.Error("expected the zero Tools to use the default logger")
+	}
+}
+
+func TestWithEncoder(t *testing.T) {
+	tools := New(WithEncoder(func(v any) ([]byte, error) { return []byte(`"custom"`), nil }))
+
+	rr := httptest.NewRecorder()
+	if err := tools.WriteJSON(rr, 200, map[string]string{"a": "b"}); err != nil {
+		t.Fatal(err)
+	}
+	if rr.Body.String() != `"custom"` {
+		t.Errorf("expected custom encoder to be used, got %q", rr.Body.String())
+	}
+}
@@ -0,0 +1,163 @@
+package gohelpertools
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Parameters for the password hashing helpers; the defaults apply when a PasswordConfig field is not set.
+const (
+	defaultBcryptCost    = 12
+	defaultArgon2Time    = 1
+	defaultArgon2Memory  = 64 * 1024
+	defaultArgon2Threads = 4
+	argon2KeyLength      = 32
+	argon2SaltLength     = 16
+	maxArgon2Time        = 64
+	maxArgon2Memory      = 4 * 1024 * 1024
+	maxArgon2KeyLength   = 1024
+	passwordAlgoBcrypt   = "bcrypt"
+	passwordAlgoArgon2id = "argon2id"
+)
+
+// PasswordConfig holds the algorithm and cost parameters used to hash passwords.
+type PasswordConfig struct {
+	Algorithm     string // "bcrypt" (the default) or "argon2id"
+	BcryptCost    int    // bcrypt cost; defaults to 12
+	Argon2Time    uint32 // argon2id iterations; defaults to 1
+	Argon2Memory  uint32 // argon2id memory in KiB; defaults to 64 MiB
+	Argon2Threads uint8  // argon2id parallelism; defaults to 4
+}
+
+// HashPassword hashes plain with the algorithm and parameters in t.Password. The result is
+// self-describing (a bcrypt hash, or an argon2id hash in the standard $argon2id$v=19$... format), so it
+// can be stored as is and checked later with VerifyPassword.
+func (t *Tools) HashPassword(plain string) (string, error) {
+	cfg := t.passwordConfig()
+
+	switch cfg.Algorithm {
+	case passwordAlgoBcrypt:
+		hash, err := bcrypt.GenerateFromPassword([]byte(plain), cfg.BcryptCost)
+		if err != nil {
+			return "", err
+		}
+		return string(hash), nil
+
+	case passwordAlgoArgon2id:
+		salt := make([]byte, argon2SaltLength)
+		if _, err := rand.Read(salt); err != nil {
+			return "", err
+		}
+		key := argon2.IDKey([]byte(plain), salt, cfg.Argon2Time, cfg.Argon2Memory, cfg.Argon2Threads, argon2KeyLength)
+		return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, cfg.Argon2Memory, cfg.Argon2Time,
+			cfg.Argon2Threads, base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+
+	default:
+		return "", fmt.Errorf("unsupported password hashing algorithm %q", cfg.Algorithm)
+	}
+}
+
+// VerifyPassword reports whether plain matches hash, which may have been produced with either algorithm.
+// An error is only returned if hash can't be parsed.
+func (t *Tools) VerifyPassword(plain, hash string) (bool, error) {
+	if strings.HasPrefix(hash, "$argon2id$") {
+		params, salt, key, err := parseArgon2Hash(hash)
+		if err != nil {
+			return false, err
+		}
+		other := argon2.IDKey([]byte(plain), salt, params.Argon2Time, params.Argon2Memory, params.Argon2Threads, uint32(len(key)))
+		return subtle.ConstantTimeCompare(key, other) == 1, nil
+	}
+
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(plain))
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, bcrypt.ErrMismatchedHashAndPassword):
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+// NeedsRehash reports whether hash was made with a different algorithm or different cost parameters than
+// those currently configured. It is typically called after a successful login, and if it returns true,
+// the password is hashed again and the stored hash replaced.
+func (t *Tools) NeedsRehash(hash string) bool {
+	cfg := t.passwordConfig()
+
+	if strings.HasPrefix(hash, "$argon2id$") {
+		params, _, _, err := parseArgon2Hash(hash)
+		return err != nil || cfg.Algorithm != passwordAlgoArgon2id || params.Argon2Time != cfg.Argon2Time ||
+			params.Argon2Memory != cfg.Argon2Memory || params.Argon2Threads != cfg.Argon2Threads
+	}
+
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cfg.Algorithm != passwordAlgoBcrypt || cost != cfg.BcryptCost
+}
+
+// passwordConfig returns t.Password with defaults filled in.
+func (t *Tools) passwordConfig() PasswordConfig {
+	cfg := t.Password
+	cfg.Algorithm = valueOrDefault(cfg.Algorithm, passwordAlgoBcrypt)
+	if cfg.BcryptCost == 0 {
+		cfg.BcryptCost = defaultBcryptCost
+	}
+	if cfg.Argon2Time == 0 {
+		cfg.Argon2Time = defaultArgon2Time
+	}
+	if cfg.Argon2Memory == 0 {
+		cfg.Argon2Memory = defaultArgon2Memory
+	}
+	if cfg.Argon2Threads == 0 {
+		cfg.Argon2Threads = defaultArgon2Threads
+	}
+	return cfg
+}
+
+// parseArgon2Hash splits an encoded argon2id hash into its parameters, salt and key. Stored hashes are
+// untrusted input, so parameters which would make argon2 panic, or take an unreasonable amount of time or
+// memory, are rejected.
+func parseArgon2Hash(hash string) (PasswordConfig, []byte, []byte, error) {
+	var params PasswordConfig
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return params, nil, nil, errors.New("invalid argon2id hash")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, errors.New("unsupported argon2 version")
+	}
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Argon2Memory, &params.Argon2Time, &params.Argon2Threads); err != nil {
+		return params, nil, nil, errors.New("invalid argon2id parameters")
+	}
+	switch {
+	case params.Argon2Time == 0 || params.Argon2Time > maxArgon2Time:
+		return params, nil, nil, fmt.Errorf("argon2id iterations must be between 1 and %d", maxArgon2Time)
+	case params.Argon2Memory == 0 || params.Argon2Memory > maxArgon2Memory:
+		return params, nil, nil, fmt.Errorf("argon2id memory must be between 1 and %d KiB", maxArgon2Memory)
+	case params.Argon2Threads == 0:
+		return params, nil, nil, errors.New("argon2id parallelism must be at least 1")
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil || len(salt) == 0 {
+		return params, nil, nil, errors.New("invalid argon2id salt")
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 || len(key) > maxArgon2KeyLength {
+		return params, nil, nil, errors.New("invalid argon2id key")
+	}
+
+	params.Algorithm = passwordAlgoArgon2id
+	return params, salt, key, nil
+}
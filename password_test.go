@@ -0,0 +1,85 @@
+package gohelpertools
+
+import (
+	"strings"
+	"testing"
+)
+
+var passwordTests = []struct {
+	name   string
+	config PasswordConfig
+	prefix string
+}{
+	{name: "bcrypt", config: PasswordConfig{BcryptCost: 4}, prefix: "$2a$04$"},
+	{name: "argon2id", config: PasswordConfig{Algorithm: "argon2id", Argon2Memory: 1024, Argon2Threads: 1}, prefix: "$argon2id$v=19$m=1024,t=1,p=1$"},
+}
+
+func TestTools_HashAndVerifyPassword(t *testing.T) {
+	for _, e := range passwordTests {
+		testTools := Tools{Password: e.config}
+
+		hash, err := testTools.HashPassword("correct horse")
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", e.name, err)
+			continue
+		}
+		if !strings.HasPrefix(hash, e.prefix) {
+			t.Errorf("%s: expected hash to start with %s, got %s", e.name, e.prefix, hash)
+		}
+
+		if ok, err := testTools.VerifyPassword("correct horse", hash); !ok || err != nil {
+			t.Errorf("%s: correct password was rejected (%v)", e.name, err)
+		}
+		if ok, _ := testTools.VerifyPassword("wrong horse", hash); ok {
+			t.Errorf("%s: wrong password was accepted", e.name)
+		}
+		if testTools.NeedsRehash(hash) {
+			t.Errorf("%s: fresh hash should not need rehashing", e.name)
+		}
+	}
+
+	if _, err := (&Tools{Password: PasswordConfig{Algorithm: "md5"}}).HashPassword("x"); err == nil {
+		t.Error("expected error for unsupported algorithm, but none received")
+	}
+}
+
+func TestTools_NeedsRehash(t *testing.T) {
+	old := Tools{Password: PasswordConfig{BcryptCost: 4}}
+	hash, _ := old.HashPassword("secret")
+
+	// Raising the cost, or switching algorithm, means existing hashes should be upgraded.
+	if !(&Tools{Password: PasswordConfig{BcryptCost: 5}}).NeedsRehash(hash) {
+		t.Error("expected rehash after cost change")
+	}
+	if !(&Tools{Password: PasswordConfig{Algorithm: "argon2id"}}).NeedsRehash(hash) {
+		t.Error("expected rehash after algorithm change")
+	}
+}
+
+var malformedArgon2Hashes = []struct {
+	name string
+	hash string
+}{
+	{name: "empty key", hash: "$argon2id$v=19$m=1024,t=1,p=1$c2FsdHNhbHQ$"},
+	{name: "empty salt", hash: "$argon2id$v=19$m=1024,t=1,p=1$$a2V5a2V5"},
+	{name: "zero time", hash: "$argon2id$v=19$m=1024,t=0,p=1$c2FsdHNhbHQ$a2V5a2V5"},
+	{name: "zero memory", hash: "$argon2id$v=19$m=0,t=1,p=1$c2FsdHNhbHQ$a2V5a2V5"},
+	{name: "zero threads", hash: "$argon2id$v=19$m=1024,t=1,p=0$c2FsdHNhbHQ$a2V5a2V5"},
+	{name: "huge time", hash: "$argon2id$v=19$m=1024,t=100000,p=1$c2FsdHNhbHQ$a2V5a2V5"},
+	{name: "huge memory", hash: "$argon2id$v=19$m=4294967295,t=1,p=1$c2FsdHNhbHQ$a2V5a2V5"},
+	{name: "wrong version", hash: "$argon2id$v=16$m=1024,t=1,p=1$c2FsdHNhbHQ$a2V5a2V5"},
+	{name: "missing parts", hash: "$argon2id$v=19$m=1024,t=1,p=1"},
+}
+
+func TestTools_VerifyPasswordMalformedHash(t *testing.T) {
+	var testTools Tools
+
+	for _, e := range malformedArgon2Hashes {
+		if ok, err := testTools.VerifyPassword("secret", e.hash); ok || err == nil {
+			t.Errorf("%s: error expected, but none received", e.name)
+		}
+		if !testTools.NeedsRehash(e.hash) {
+			t.Errorf("%s: expected a malformed hash to need rehashing", e.name)
+		}
+	}
+}
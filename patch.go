@@ -0,0 +1,434 @@
+package gohelpertools
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// The media types of the patch documents ReadPatch accepts.
+const (
+	JSONPatchType  = "application/json-patch+json"  // RFC 6902
+	MergePatchType = "application/merge-patch+json" // RFC 7386
+)
+
+// Patch is a patch document read by ReadPatch.
+type Patch struct {
+	Type     string // JSONPatchType or MergePatchType
+	Document json.RawMessage
+}
+
+// Apply applies the patch to the JSON document original, and returns the result.
+func (p *Patch) Apply(original []byte) ([]byte, error) {
+	if p.Type == JSONPatchType {
+		return applyJSONPatch(original, p.Document)
+	}
+	return applyMergePatch(original, p.Document)
+}
+
+// ApplyTo applies the patch to v, which must be a pointer, through its JSON form. Fields the patch
+// removes are left at their zero value. If the patch fails, v is unchanged.
+func (p *Patch) ApplyTo(v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return errors.New("ApplyTo needs a non-nil pointer")
+	}
+	original, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	patched, err := p.Apply(original)
+	if err != nil {
+		return err
+	}
+
+	result := reflect.New(rv.Elem().Type())
+	if err := json.Unmarshal(patched, result.Interface()); err != nil {
+		return fmt.Errorf("the patched document doesn't fit: %w", err)
+	}
+	rv.Elem().Set(result.Elem())
+	return nil
+}
+
+// ReadPatch reads the patch document in the body of a PATCH request, and checks that it is well formed.
+// A JSON Patch (RFC 6902) is expected with a Content-Type of application/json-patch+json, and a JSON Merge
+// Patch (RFC 7386) with application/merge-patch+json or application/json. The body is limited in size as
+// for ReadJSON.
+func (t *Tools) ReadPatch(w http.ResponseWriter, r *http.Request, opts ...JSONOption) (*Patch, error) {
+	mediaType, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch {
+	case err != nil:
+		return nil, errors.New("the Content-Type header must name a patch format")
+	case mediaType == "application/json":
+		mediaType = MergePatchType
+	case mediaType != JSONPatchType && mediaType != MergePatchType:
+		return nil, fmt.Errorf("unsupported patch format %s; use %s or %s", mediaType, JSONPatchType, MergePatchType)
+	}
+	if charset, ok := params["charset"]; ok && !strings.EqualFold(charset, "utf-8") && !strings.EqualFold(charset, "utf8") {
+		return nil, fmt.Errorf("unsupported charset %q; only utf-8 is accepted", charset)
+	}
+
+	maxBytes := t.jsonOptions(opts).maxSize
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, int64(maxBytes)))
+	if err != nil {
+		return nil, fmt.Errorf("body must not be larger than %d bytes", maxBytes)
+	}
+	body = bytes.TrimPrefix(body, utf8BOM)
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil, errors.New("body must not be empty")
+	}
+
+	if mediaType == JSONPatchType {
+		if _, err := parseJSONPatch(body); err != nil {
+			return nil, err
+		}
+	} else if !json.Valid(body) {
+		return nil, errors.New("body contains badly-formed JSON")
+	}
+	return &Patch{Type: mediaType, Document: body}, nil
+}
+
+// ApplyJSONPatch applies the JSON Patch (RFC 6902) patch to the JSON document original, and returns the
+// result. The operations are applied in order, and if one fails, the whole patch fails.
+func (t *Tools) ApplyJSONPatch(original []byte, patch []byte) ([]byte, error) {
+	return applyJSONPatch(original, patch)
+}
+
+// ApplyMergePatch applies the JSON Merge Patch (RFC 7386) patch to the JSON document original, and
+// returns the result: members of patch replace those of original, and members set to null are removed.
+func (t *Tools) ApplyMergePatch(original []byte, patch []byte) ([]byte, error) {
+	return applyMergePatch(original, patch)
+}
+
+// patchOperation is one operation of a JSON Patch.
+type patchOperation struct {
+	Op    string          `json:"op"`
+	Path  *string         `json:"path"`
+	From  *string         `json:"from"`
+	Value json.RawMessage `json:"value"`
+
+	path, from []string
+	value      any
+}
+
+// parseJSONPatch decodes and checks a JSON Patch.
+func parseJSONPatch(patch []byte) ([]patchOperation, error) {
+	var ops []patchOperation
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return nil, errors.New("a JSON Patch must be an array of operations")
+	}
+	for i := range ops {
+		op := &ops[i]
+		if op.Path == nil {
+			return nil, fmt.Errorf("operation %d has no path", i)
+		}
+		path, err := parseJSONPointer(*op.Path)
+		if err != nil {
+			return nil, fmt.Errorf("operation %d: %w", i, err)
+		}
+		op.path = path
+
+		switch op.Op {
+		case "add", "replace", "test":
+			if op.Value == nil {
+				return nil, fmt.Errorf("operation %d (%s) has no value", i, op.Op)
+			}
+			if op.value, err = decodeJSONValue(op.Value); err != nil {
+				return nil, fmt.Errorf("operation %d has an invalid value", i)
+			}
+		case "move", "copy":
+			if op.From == nil {
+				return nil, fmt.Errorf("operation %d (%s) has no from", i, op.Op)
+			}
+			if op.from, err = parseJSONPointer(*op.From); err != nil {
+				return nil, fmt.Errorf("operation %d: %w", i, err)
+			}
+			if op.Op == "move" && len(op.from) < len(op.path) && reflect.DeepEqual(op.from, op.path[:len(op.from)]) {
+				return nil, fmt.Errorf("operation %d moves a value into itself", i)
+			}
+		case "remove":
+		default:
+			return nil, fmt.Errorf("operation %d has an unknown op %q", i, op.Op)
+		}
+	}
+	return ops, nil
+}
+
+func applyJSONPatch(original, patch []byte) ([]byte, error) {
+	ops, err := parseJSONPatch(patch)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := decodeJSONValue(original)
+	if err != nil {
+		return nil, errors.New("the document is not valid JSON")
+	}
+
+	for i, op := range ops {
+		switch op.Op {
+		case "add":
+			doc, err = patchAdd(doc, op.path, op.value)
+		case "remove":
+			doc, _, err = patchRemove(doc, op.path)
+		case "replace":
+			if _, err = jsonPointerGet(doc, op.path); err == nil {
+				doc, _, _ = patchRemove(doc, op.path)
+				doc, err = patchAdd(doc, op.path, op.value)
+			}
+		case "move":
+			var value any
+			if doc, value, err = patchRemove(doc, op.from); err == nil {
+				doc, err = patchAdd(doc, op.path, value)
+			}
+		case "copy":
+			var value any
+			if value, err = jsonPointerGet(doc, op.from); err == nil {
+				doc, err = patchAdd(doc, op.path, deepCopyJSON(value))
+			}
+		case "test":
+			var value any
+			if value, err = jsonPointerGet(doc, op.path); err == nil && !jsonEqual(value, op.value) {
+				err = fmt.Errorf("test failed at %s", *op.Path)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("operation %d (%s): %w", i, op.Op, err)
+		}
+	}
+	return json.Marshal(doc)
+}
+
+func applyMergePatch(original, patch []byte) ([]byte, error) {
+	doc, err := decodeJSONValue(original)
+	if err != nil {
+		return nil, errors.New("the document is not valid JSON")
+	}
+	p, err := decodeJSONValue(patch)
+	if err != nil {
+		return nil, errors.New("the patch is not valid JSON")
+	}
+	return json.Marshal(mergePatch(doc, p))
+}
+
+func mergePatch(target, patch any) any {
+	members, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	object, ok := target.(map[string]any)
+	if !ok {
+		object = make(map[string]any)
+	}
+	for key, value := range members {
+		if value == nil {
+			delete(object, key)
+		} else {
+			object[key] = mergePatch(object[key], value)
+		}
+	}
+	return object
+}
+
+// decodeJSONValue decodes data into generic values, keeping numbers as json.Number so they round-trip
+// exactly.
+func decodeJSONValue(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return nil, errors.New("unexpected data after the JSON value")
+	}
+	return v, nil
+}
+
+// parseJSONPointer splits a JSON Pointer (RFC 6901), such as "/items/0/name", into its reference tokens.
+func parseJSONPointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer %q", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// arrayIndex parses token as an index into an array of length n; end allows n itself, for adding.
+func arrayIndex(token string, n int, end bool) (int, error) {
+	if token == "-" && end {
+		return n, nil
+	}
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || (len(token) > 1 && token[0] == '0') || i > n || (i == n && !end) {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	return i, nil
+}
+
+func jsonPointerGet(doc any, path []string) (any, error) {
+	for _, token := range path {
+		switch node := doc.(type) {
+		case map[string]any:
+			value, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("no member %q", token)
+			}
+			doc = value
+		case []any:
+			i, err := arrayIndex(token, len(node), false)
+			if err != nil {
+				return nil, err
+			}
+			doc = node[i]
+		default:
+			return nil, fmt.Errorf("can't look up %q in a scalar", token)
+		}
+	}
+	return doc, nil
+}
+
+// patchAdd adds value at path, inserting it into arrays, and returns the new document.
+func patchAdd(doc any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	token, last := path[0], len(path) == 1
+	switch node := doc.(type) {
+	case map[string]any:
+		if last {
+			node[token] = value
+			return node, nil
+		}
+		child, ok := node[token]
+		if !ok {
+			return nil, fmt.Errorf("no member %q", token)
+		}
+		child, err := patchAdd(child, path[1:], value)
+		node[token] = child
+		return node, err
+	case []any:
+		i, err := arrayIndex(token, len(node), last)
+		if err != nil {
+			return nil, err
+		}
+		if last {
+			node = append(node, nil)
+			copy(node[i+1:], node[i:])
+			node[i] = value
+			return node, nil
+		}
+		node[i], err = patchAdd(node[i], path[1:], value)
+		return node, err
+	default:
+		return nil, fmt.Errorf("can't add %q to a scalar", token)
+	}
+}
+
+// patchRemove removes the value at path, and returns the new document and the value removed.
+func patchRemove(doc any, path []string) (any, any, error) {
+	if len(path) == 0 {
+		return nil, nil, errors.New("can't remove the whole document")
+	}
+	token, last := path[0], len(path) == 1
+	switch node := doc.(type) {
+	case map[string]any:
+		child, ok := node[token]
+		if !ok {
+			return nil, nil, fmt.Errorf("no member %q", token)
+		}
+		if last {
+			delete(node, token)
+			return node, child, nil
+		}
+		child, removed, err := patchRemove(child, path[1:])
+		node[token] = child
+		return node, removed, err
+	case []any:
+		i, err := arrayIndex(token, len(node), false)
+		if err != nil {
+			return nil, nil, err
+		}
+		if last {
+			removed := node[i]
+			return append(node[:i], node[i+1:]...), removed, nil
+		}
+		child, removed, err := patchRemove(node[i], path[1:])
+		node[i] = child
+		return node, removed, err
+	default:
+		return nil, nil, fmt.Errorf("can't remove %q from a scalar", token)
+	}
+}
+
+func deepCopyJSON(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for key, value := range v {
+			out[key] = deepCopyJSON(value)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, value := range v {
+			out[i] = deepCopyJSON(value)
+		}
+		return out
+	}
+	return v
+}
+
+// jsonEqual reports whether two generic JSON values are equal, comparing numbers by value, so 1 and 1.0
+// are equal.
+func jsonEqual(a, b any) bool {
+	switch a := a.(type) {
+	case json.Number:
+		bn, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		if a == bn {
+			return true
+		}
+		x, errA := strconv.ParseFloat(string(a), 64)
+		y, errB := strconv.ParseFloat(string(bn), 64)
+		return errA == nil && errB == nil && x == y
+	case map[string]any:
+		bm, ok := b.(map[string]any)
+		if !ok || len(a) != len(bm) {
+			return false
+		}
+		for key, value := range a {
+			other, ok := bm[key]
+			if !ok || !jsonEqual(value, other) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		bs, ok := b.([]any)
+		if !ok || len(a) != len(bs) {
+			return false
+		}
+		for i := range a {
+			if !jsonEqual(a[i], bs[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return a == b
+}
@@ -0,0 +1,146 @@
+package gohelpertools
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Most cases are from the examples in RFC 6902, appendix A.
+var jsonPatchTests = []struct {
+	name          string
+	doc           string
+	patch         string
+	expected      string
+	errorExpected bool
+}{
+	{name: "add member", doc: `{"foo":"bar"}`, patch: `[{"op":"add","path":"/baz","value":"qux"}]`, expected: `{"baz":"qux","foo":"bar"}`},
+	{name: "add element", doc: `{"foo":["bar","baz"]}`, patch: `[{"op":"add","path":"/foo/1","value":"qux"}]`, expected: `{"foo":["bar","qux","baz"]}`},
+	{name: "append", doc: `{"foo":[1]}`, patch: `[{"op":"add","path":"/foo/-","value":{"a":2}}]`, expected: `{"foo":[1,{"a":2}]}`},
+	{name: "remove member", doc: `{"baz":"qux","foo":"bar"}`, patch: `[{"op":"remove","path":"/baz"}]`, expected: `{"foo":"bar"}`},
+	{name: "remove element", doc: `{"foo":["bar","qux","baz"]}`, patch: `[{"op":"remove","path":"/foo/1"}]`, expected: `{"foo":["bar","baz"]}`},
+	{name: "replace", doc: `{"baz":"qux","foo":"bar"}`, patch: `[{"op":"replace","path":"/baz","value":"boo"}]`, expected: `{"baz":"boo","foo":"bar"}`},
+	{name: "move member", doc: `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`, patch: `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`, expected: `{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+	{name: "move element", doc: `{"foo":["all","grass","cows","eat"]}`, patch: `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`, expected: `{"foo":["all","cows","eat","grass"]}`},
+	{name: "copy", doc: `{"a":{"b":1}}`, patch: `[{"op":"copy","from":"/a","path":"/c"},{"op":"replace","path":"/c/b","value":2}]`, expected: `{"a":{"b":1},"c":{"b":2}}`},
+	{name: "test passes", doc: `{"baz":"qux","foo":["a",2,"c"]}`, patch: `[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2.0}]`, expected: `{"baz":"qux","foo":["a",2,"c"]}`},
+	{name: "escaped pointer", doc: `{"a/b":{"m~n":1}}`, patch: `[{"op":"replace","path":"/a~1b/m~0n","value":8}]`, expected: `{"a/b":{"m~n":8}}`},
+	{name: "large number kept", doc: `{"id":12345678901234567890}`, patch: `[{"op":"add","path":"/x","value":1}]`, expected: `{"id":12345678901234567890,"x":1}`},
+	{name: "replace root", doc: `{"a":1}`, patch: `[{"op":"replace","path":"","value":[1]}]`, expected: `[1]`},
+	{name: "test fails", doc: `{"baz":"qux"}`, patch: `[{"op":"test","path":"/baz","value":"bar"}]`, errorExpected: true},
+	{name: "missing parent", doc: `{"foo":"bar"}`, patch: `[{"op":"add","path":"/baz/bat","value":"qux"}]`, errorExpected: true},
+	{name: "remove missing", doc: `{"foo":"bar"}`, patch: `[{"op":"remove","path":"/baz"}]`, errorExpected: true},
+	{name: "replace missing", doc: `{"foo":"bar"}`, patch: `[{"op":"replace","path":"/baz","value":1}]`, errorExpected: true},
+	{name: "index out of range", doc: `{"foo":[1]}`, patch: `[{"op":"add","path":"/foo/2","value":1}]`, errorExpected: true},
+	{name: "leading zero", doc: `{"foo":[1,2]}`, patch: `[{"op":"remove","path":"/foo/01"}]`, errorExpected: true},
+	{name: "move into itself", doc: `{"a":{"b":1}}`, patch: `[{"op":"move","from":"/a","path":"/a/c"}]`, errorExpected: true},
+	{name: "unknown op", doc: `{}`, patch: `[{"op":"merge","path":"/a"}]`, errorExpected: true},
+	{name: "no value", doc: `{}`, patch: `[{"op":"add","path":"/a"}]`, errorExpected: true},
+	{name: "not an array", doc: `{}`, patch: `{"op":"add"}`, errorExpected: true},
+}
+
+func TestTools_ApplyJSONPatch(t *testing.T) {
+	var tools Tools
+	for _, e := range jsonPatchTests {
+		out, err := tools.ApplyJSONPatch([]byte(e.doc), []byte(e.patch))
+		if e.errorExpected {
+			if err == nil {
+				t.Errorf("%s: error expected, but none received", e.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: error not expected, but one received: %s", e.name, err)
+			continue
+		}
+		if string(out) != e.expected {
+			t.Errorf("%s: expected %s, got %s", e.name, e.expected, out)
+		}
+	}
+}
+
+func TestTools_ApplyMergePatch(t *testing.T) {
+	// From RFC 7386, appendix A.
+	tests := []struct{ doc, patch, expected string }{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	var tools Tools
+	for _, e := range tests {
+		out, err := tools.ApplyMergePatch([]byte(e.doc), []byte(e.patch))
+		if err != nil || string(out) != e.expected {
+			t.Errorf("%s + %s: expected %s, got %s (%v)", e.doc, e.patch, e.expected, out, err)
+		}
+	}
+}
+
+func TestTools_ReadPatch(t *testing.T) {
+	tests := []struct {
+		name          string
+		contentType   string
+		body          string
+		patchType     string
+		errorExpected bool
+	}{
+		{name: "json patch", contentType: JSONPatchType, body: `[{"op":"remove","path":"/name"}]`, patchType: JSONPatchType},
+		{name: "merge patch", contentType: MergePatchType + "; charset=utf-8", body: `{"name":null}`, patchType: MergePatchType},
+		{name: "plain json", contentType: "application/json", body: `{"name":"x"}`, patchType: MergePatchType},
+		{name: "invalid operation", contentType: JSONPatchType, body: `[{"op":"remove"}]`, errorExpected: true},
+		{name: "invalid json", contentType: MergePatchType, body: `{"name":`, errorExpected: true},
+		{name: "empty", contentType: MergePatchType, body: ``, errorExpected: true},
+		{name: "wrong type", contentType: "text/plain", body: `{}`, errorExpected: true},
+	}
+
+	var tools Tools
+	for _, e := range tests {
+		req := httptest.NewRequest(http.MethodPatch, "/", strings.NewReader(e.body))
+		req.Header.Set("Content-Type", e.contentType)
+		patch, err := tools.ReadPatch(httptest.NewRecorder(), req)
+		if e.errorExpected {
+			if err == nil {
+				t.Errorf("%s: error expected, but none received", e.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: error not expected, but one received: %s", e.name, err)
+			continue
+		}
+		if patch.Type != e.patchType {
+			t.Errorf("%s: expected type %s, got %s", e.name, e.patchType, patch.Type)
+		}
+	}
+}
+
+func TestPatch_ApplyTo(t *testing.T) {
+	type user struct {
+		Name  string   `json:"name"`
+		Email string   `json:"email,omitempty"`
+		Tags  []string `json:"tags"`
+	}
+	u := user{Name: "Ada", Email: "ada@example.com", Tags: []string{"a"}}
+
+	patch := &Patch{Type: JSONPatchType, Document: []byte(`[{"op":"remove","path":"/email"},{"op":"add","path":"/tags/-","value":"b"}]`)}
+	if err := patch.ApplyTo(&u); err != nil {
+		t.Fatal(err)
+	}
+	if u.Name != "Ada" || u.Email != "" || strings.Join(u.Tags, ",") != "a,b" {
+		t.Errorf("unexpected result %+v", u)
+	}
+
+	bad := &Patch{Type: MergePatchType, Document: []byte(`{"tags":"not a list"}`)}
+	if err := bad.ApplyTo(&u); err == nil || strings.Join(u.Tags, ",") != "a,b" {
+		t.Errorf("expected a patch which doesn't fit to fail and leave u unchanged, got %v %+v", err, u)
+	}
+}
@@ -0,0 +1,332 @@
+package gohelpertools
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxPDFStreamSize caps how much data a single decompressed PDF stream may expand to, to protect against
+// decompression bombs.
+const maxPDFStreamSize = 50 << 20
+
+// maxPDFSize caps the size of a PDF document read by ExtractPDFText and PDFThumbnail.
+const maxPDFSize = 100 << 20
+
+var pdfStreamRegex = regexp.MustCompile(`(?s)<<(.*?)>>\s*stream\r?\n`)
+var pdfLengthRegex = regexp.MustCompile(`/Length\s+(\d+)(\s*[^\d\sR]|\s*$)`)
+
+// pdfStream is a raw stream object found in a PDF file, with its dictionary.
+type pdfStream struct {
+	dict string
+	data []byte
+}
+
+// ExtractPDFText returns the text drawn by the content streams of a PDF document, for example to feed a
+// search index. Support is deliberately partial: uncompressed and Flate-compressed streams are read, and
+// text shown with the Tj, TJ, ' and " operators is collected. Text in fonts with custom encodings (such
+// as CID fonts without a simple encoding) may come out garbled, and encrypted documents are not supported.
+func (t *Tools) ExtractPDFText(r io.Reader) (string, error) {
+	streams, err := readPDFStreams(r)
+	if err != nil {
+		return "", err
+	}
+	return pdfText(streams), nil
+}
+
+// pdfText returns the text drawn by the content streams among streams.
+func pdfText(streams []pdfStream) string {
+	var text strings.Builder
+	for _, stream := range streams {
+		if strings.Contains(stream.dict, "/Subtype/Image") || strings.Contains(stream.dict, "/Subtype /Image") {
+			continue
+		}
+
+		content, err := decodePDFStream(stream)
+		if err != nil || !bytes.Contains(content, []byte("BT")) {
+			continue
+		}
+		extractPDFContentText(content, &text)
+	}
+
+	return strings.TrimSpace(text.String())
+}
+
+// PDFThumbnail returns a thumbnail no larger than maxWidth x maxHeight for a PDF document. Rendering PDF
+// pages is out of scope for this package, so the thumbnail is made from the first embedded JPEG image in
+// the document, which for scanned documents is the first page. An error is returned if there is none.
+// The image's dimensions are checked against ImageOptions.MaxPixels before it is decoded, as in
+// DecodeImage.
+func (t *Tools) PDFThumbnail(r io.Reader, maxWidth, maxHeight int, opts ...ImageOptions) (image.Image, error) {
+	streams, err := readPDFStreams(r)
+	if err != nil {
+		return nil, err
+	}
+	return pdfThumbnail(streams, maxWidth, maxHeight, imageDefaults(opts).MaxPixels)
+}
+
+// errNoPDFThumbnail is returned by pdfThumbnail for a document without an embedded JPEG image.
+var errNoPDFThumbnail = errors.New("pdf does not contain an embedded image to use as a thumbnail")
+
+// pdfThumbnail scales the first embedded JPEG image among streams to fit maxWidth x maxHeight. An image
+// larger than maxPixels is an error, since a small stream can claim to hold a huge image.
+func pdfThumbnail(streams []pdfStream, maxWidth, maxHeight, maxPixels int) (image.Image, error) {
+	for _, stream := range streams {
+		if !strings.Contains(stream.dict, "/DCTDecode") {
+			continue
+		}
+
+		config, err := jpeg.DecodeConfig(bytes.NewReader(stream.data))
+		if err != nil {
+			continue
+		}
+		if config.Width <= 0 || config.Height <= 0 {
+			return nil, errEmptyImage
+		}
+		if config.Width > maxPixels/config.Height {
+			return nil, fmt.Errorf("embedded image dimensions %dx%d exceed the limit of %d pixels", config.Width, config.Height, maxPixels)
+		}
+
+		img, err := jpeg.Decode(bytes.NewReader(stream.data))
+		if err != nil {
+			continue
+		}
+		return scaleToFit(img, maxWidth, maxHeight)
+	}
+
+	return nil, errNoPDFThumbnail
+}
+
+// readPDFStreams reads a whole PDF document, of at most maxPDFSize bytes, and returns every stream object
+// in it.
+func readPDFStreams(r io.Reader) ([]pdfStream, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxPDFSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxPDFSize {
+		return nil, fmt.Errorf("pdf is larger than %d bytes", maxPDFSize)
+	}
+	if !isPDF(data) {
+		return nil, errors.New("file is not a pdf")
+	}
+	return parsePDFStreams(data), nil
+}
+
+func isPDF(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimLeft(data, "\x00\t\r\n "), []byte("%PDF-"))
+}
+
+// checkPDF reports whether data looks like a whole PDF document: one with a PDF header, and an end of file
+// marker near the end, which a truncated upload lacks.
+func checkPDF(data []byte) error {
+	if !isPDF(data) {
+		return errors.New("file is not a pdf")
+	}
+	if !bytes.Contains(data[max(0, len(data)-1024):], []byte("%%EOF")) {
+		return errors.New("pdf is incomplete")
+	}
+	return nil
+}
+
+// parsePDFStreams returns every stream object in the PDF document data.
+func parsePDFStreams(data []byte) []pdfStream {
+	var streams []pdfStream
+	for _, loc := range pdfStreamRegex.FindAllSubmatchIndex(data, -1) {
+		start := loc[1]
+		end := bytes.Index(data[start:], []byte("endstream"))
+		if end < 0 {
+			break
+		}
+
+		// Use /Length when it is a direct value, since binary data may itself contain "endstream".
+		dict := string(data[loc[2]:loc[3]])
+		length := start + end
+		if m := pdfLengthRegex.FindStringSubmatch(dict); m != nil {
+			if n, err := strconv.Atoi(m[1]); err == nil && start+n <= len(data) {
+				length = start + n
+			}
+		}
+
+		streams = append(streams, pdfStream{dict: dict, data: bytes.TrimRight(data[start:length], "\r\n")})
+	}
+
+	return streams
+}
+
+// PDFOptions sets what UploadFiles does with uploaded PDF documents, as Tools.PDFUploads. Every PDF is
+// checked to be a whole document; the text and thumbnail are made as by ExtractPDFText and PDFThumbnail.
+type PDFOptions struct {
+	ExtractText     bool // if set to true, the text of each document is extracted into UploadedFile.Text, such as for a search index
+	ThumbnailWidth  int  // if above zero, with ThumbnailHeight, a JPEG thumbnail is saved for documents with an embedded image
+	ThumbnailHeight int
+}
+
+// decodePDFStream returns the decoded contents of a stream; only FlateDecode is supported.
+func decodePDFStream(stream pdfStream) ([]byte, error) {
+	if !strings.Contains(stream.dict, "/Filter") {
+		return stream.data, nil
+	}
+	if !strings.Contains(stream.dict, "/FlateDecode") {
+		return nil, errors.New("unsupported pdf stream filter")
+	}
+
+	zr, err := zlib.NewReader(bytes.NewReader(stream.data))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	// A truncated stream still yields useful text, so unexpected EOFs are ignored.
+	content, err := io.ReadAll(io.LimitReader(zr, maxPDFStreamSize))
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return content, err
+	}
+	return content, nil
+}
+
+// extractPDFContentText scans a page content stream and writes the strings shown by text operators to out.
+func extractPDFContentText(content []byte, out *strings.Builder) {
+	var operands []string
+	for i := 0; i < len(content); {
+		c := content[i]
+		switch {
+		case c == '(':
+			s, next := readPDFLiteralString(content, i)
+			operands = append(operands, s)
+			i = next
+
+		case c == '<' && i+1 < len(content) && content[i+1] != '<':
+			end := bytes.IndexByte(content[i:], '>')
+			if end < 0 {
+				return
+			}
+			operands = append(operands, decodePDFHexString(string(content[i+1:i+end])))
+			i += end + 1
+
+		case c == '%':
+			for i < len(content) && content[i] != '\n' && content[i] != '\r' {
+				i++
+			}
+
+		case isPDFRegularChar(c):
+			start := i
+			for i < len(content) && isPDFRegularChar(content[i]) {
+				i++
+			}
+			op := string(content[start:i])
+
+			switch op {
+			case "Tj", "TJ":
+				for _, s := range operands {
+					out.WriteString(s)
+				}
+			case "'", `"`:
+				out.WriteString("\n")
+				for _, s := range operands {
+					out.WriteString(s)
+				}
+			case "T*", "Td", "TD", "ET":
+				if out.Len() > 0 && !strings.HasSuffix(out.String(), "\n") {
+					out.WriteString("\n")
+				}
+			}
+
+			// Numbers are operands rather than operators, so they don't reset the list.
+			if !isPDFOperand(op) {
+				operands = operands[:0]
+			}
+
+		default:
+			i++
+		}
+	}
+}
+
+// readPDFLiteralString reads a (...) string starting at content[start], handling escapes and balanced
+// parentheses, and returns it along with the index just after it.
+func readPDFLiteralString(content []byte, start int) (string, int) {
+	var s strings.Builder
+	depth := 0
+	i := start
+	for ; i < len(content); i++ {
+		c := content[i]
+		switch {
+		case c == '\\' && i+1 < len(content):
+			i++
+			switch e := content[i]; e {
+			case 'n':
+				s.WriteByte('\n')
+			case 'r':
+				s.WriteByte('\r')
+			case 't':
+				s.WriteByte('\t')
+			case 'b', 'f':
+			case '\r', '\n':
+				// A backslash at the end of a line continues the string on the next line.
+			default:
+				if e >= '0' && e <= '7' {
+					end := i
+					for end < len(content) && end < i+3 && content[end] >= '0' && content[end] <= '7' {
+						end++
+					}
+					n, _ := strconv.ParseUint(string(content[i:end]), 8, 8)
+					s.WriteByte(byte(n))
+					i = end - 1
+				} else {
+					s.WriteByte(e)
+				}
+			}
+		case c == '(':
+			if depth > 0 {
+				s.WriteByte(c)
+			}
+			depth++
+		case c == ')':
+			depth--
+			if depth == 0 {
+				return s.String(), i + 1
+			}
+			s.WriteByte(c)
+		default:
+			s.WriteByte(c)
+		}
+	}
+	return s.String(), i
+}
+
+// decodePDFHexString decodes the contents of a <...> string; an odd final digit is padded with zero.
+func decodePDFHexString(hex string) string {
+	hex = strings.Join(strings.Fields(hex), "")
+	if len(hex)%2 == 1 {
+		hex += "0"
+	}
+
+	var s strings.Builder
+	for i := 0; i+1 < len(hex); i += 2 {
+		n, err := strconv.ParseUint(hex[i:i+2], 16, 8)
+		if err != nil {
+			return s.String()
+		}
+		s.WriteByte(byte(n))
+	}
+	return s.String()
+}
+
+// isPDFRegularChar reports whether c can be part of a PDF token (anything but whitespace and delimiters).
+func isPDFRegularChar(c byte) bool {
+	return !strings.ContainsRune(" \t\r\n\f\x00()<>[]{}/%", rune(c))
+}
+
+// isPDFOperand reports whether token is a number (an operand) rather than an operator.
+func isPDFOperand(token string) bool {
+	_, err := strconv.ParseFloat(token, 64)
+	return err == nil
+}
@@ -0,0 +1,111 @@
+package gohelpertools
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image/color"
+	"image/jpeg"
+	"strings"
+	"testing"
+)
+
+// buildTestPDF assembles a minimal PDF document from the given stream objects (dictionary body, data).
+func buildTestPDF(streams ...[2]string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	for i, s := range streams {
+		fmt.Fprintf(&buf, "%d 0 obj\n<<%s /Length %d>>\nstream\n%s\nendstream\nendobj\n", i+1, s[0], len(s[1]), s[1])
+	}
+	buf.WriteString("%%EOF\n")
+	return buf.Bytes()
+}
+
+func TestTools_ExtractPDFText(t *testing.T) {
+	var testTools Tools
+
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	_, _ = zw.Write([]byte("BT /F1 12 Tf 72 712 Td [(Wor) -20 (ld)] TJ ET"))
+	_ = zw.Close()
+
+	pdf := buildTestPDF(
+		[2]string{"", `BT /F1 12 Tf 72 720 Td (Hello \(PDF\)) Tj T* <21> Tj ET`},
+		[2]string{"/Filter /FlateDecode", compressed.String()},
+	)
+
+	text, err := testTools.ExtractPDFText(bytes.NewReader(pdf))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "Hello (PDF)\n!\nWorld"
+	if text != expected {
+		t.Errorf("wrong text extracted; expected %q but got %q", expected, text)
+	}
+
+	_, err = testTools.ExtractPDFText(strings.NewReader("not a pdf"))
+	if err == nil {
+		t.Error("expected error for a file which is not a pdf, but none received")
+	}
+}
+
+func TestTools_PDFThumbnail(t *testing.T) {
+	var testTools Tools
+
+	var img bytes.Buffer
+	_ = jpeg.Encode(&img, solidImage(200, 100, color.White), nil)
+
+	pdf := buildTestPDF([2]string{"/Type /XObject /Subtype /Image /Filter /DCTDecode", img.String()})
+
+	thumb, err := testTools.PDFThumbnail(bytes.NewReader(pdf), 50, 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if thumb.Bounds().Dx() != 50 || thumb.Bounds().Dy() != 25 {
+		t.Errorf("wrong thumbnail size; got %dx%d", thumb.Bounds().Dx(), thumb.Bounds().Dy())
+	}
+
+	_, err = testTools.PDFThumbnail(bytes.NewReader(buildTestPDF([2]string{"", "BT (x) Tj ET"})), 50, 50)
+	if err == nil {
+		t.Error("expected error for a pdf without images, but none received")
+	}
+}
+
+func TestTools_PDFThumbnailMaxPixels(t *testing.T) {
+	var testTools Tools
+
+	var img bytes.Buffer
+	_ = jpeg.Encode(&img, solidImage(200, 100, color.White), nil)
+	pdf := buildTestPDF([2]string{"/Type /XObject /Subtype /Image /Filter /DCTDecode", img.String()})
+
+	// The dimensions are checked before the image is decoded.
+	if _, err := testTools.PDFThumbnail(bytes.NewReader(pdf), 50, 50, ImageOptions{MaxPixels: 1000}); err == nil {
+		t.Error("image over MaxPixels: error expected, but none received")
+	}
+}
+
+func TestTools_UploadFilesPDF(t *testing.T) {
+	tools := Tools{PDFUploads: &PDFOptions{ExtractText: true, ThumbnailWidth: 20, ThumbnailHeight: 20}}
+
+	var jpg bytes.Buffer
+	_ = jpeg.Encode(&jpg, solidImage(40, 80, color.RGBA{B: 255, A: 255}), nil)
+	pdf := buildTestPDF(
+		[2]string{"", "BT /F1 12 Tf (Invoice 42) Tj ET"},
+		[2]string{"/Subtype /Image /Filter /DCTDecode", jpg.String()},
+	)
+
+	dir := t.TempDir()
+	files, err := tools.UploadFiles(newUploadRequest(t, map[string][]byte{"invoice.pdf": pdf}, nil), dir, false)
+	if err != nil {
+		t.Fatalf("error not expected, but one received: %s", err)
+	}
+	if files[0].Text != "Invoice 42" || files[0].ThumbnailName != "invoice_thumb.jpg" {
+		t.Errorf("expected the text and a thumbnail, got %+v", files[0])
+	}
+
+	truncated := pdf[:len(pdf)-10]
+	if _, err := tools.UploadFiles(newUploadRequest(t, map[string][]byte{"broken.pdf": truncated}, nil), t.TempDir(), false); err == nil {
+		t.Error("truncated pdf: error expected, but none received")
+	}
+}
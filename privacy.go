@@ -0,0 +1,174 @@
+package gohelpertools
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// PrivacyAction is what a data subject request asks for.
+type PrivacyAction string
+
+const (
+	PrivacyExport    PrivacyAction = "export"    // return a copy of the subject's data
+	PrivacyAnonymize PrivacyAction = "anonymize" // keep records, but remove what identifies the subject
+	PrivacyDelete    PrivacyAction = "delete"    // remove the subject's data
+)
+
+// The statuses of a PrivacyStep.
+const (
+	PrivacyStepDone    = "done"
+	PrivacyStepFailed  = "failed"
+	PrivacyStepSkipped = "skipped" // the module has no handler for the action
+)
+
+// DataHandlers are one module's handlers for data subject requests. Handlers may be nil if the module
+// doesn't support an action.
+type DataHandlers struct {
+	Export    func(ctx context.Context, subjectID string) (any, error)
+	Anonymize func(ctx context.Context, subjectID string) error
+	Delete    func(ctx context.Context, subjectID string) error
+}
+
+// PrivacyOps coordinates data subject access requests (DSARs) under the GDPR and similar laws. Each
+// module which holds personal data registers its handlers, and Run calls every module's handler for the
+// requested action, in registration order, recording progress and the outcome of each step in an
+// auditable report. A failing module doesn't stop the others, so a retry only needs to repeat the failed
+// steps.
+type PrivacyOps struct {
+	OnProgress func(PrivacyProgress) // if set, called after each module is handled
+	Audit      func(PrivacyReport)   // if set, called with the report of every request, for the audit log
+
+	mu      sync.Mutex
+	modules []privacyModule
+}
+
+type privacyModule struct {
+	name     string
+	handlers DataHandlers
+}
+
+// PrivacyProgress reports how far a request has got.
+type PrivacyProgress struct {
+	RequestID string
+	Module    string // the module just handled
+	Done      int
+	Total     int
+}
+
+// PrivacyReport records what was done for one request.
+type PrivacyReport struct {
+	RequestID string         `json:"request_id"`
+	SubjectID string         `json:"subject_id"`
+	Action    PrivacyAction  `json:"action"`
+	Started   time.Time      `json:"started"`
+	Finished  time.Time      `json:"finished"`
+	Steps     []PrivacyStep  `json:"steps"`
+	Data      map[string]any `json:"-"` // exported data by module, for PrivacyExport; kept out of the audit log
+}
+
+// PrivacyStep is the outcome of one module's handler.
+type PrivacyStep struct {
+	Module     string  `json:"module"`
+	Status     string  `json:"status"`
+	Error      string  `json:"error,omitempty"`
+	DurationMS float64 `json:"duration_ms"`
+}
+
+// Failed returns the names of the modules whose handlers failed.
+func (r PrivacyReport) Failed() []string {
+	var failed []string
+	for _, step := range r.Steps {
+		if step.Status == PrivacyStepFailed {
+			failed = append(failed, step.Module)
+		}
+	}
+	return failed
+}
+
+// Register adds the handlers of the module name. Registering a name again replaces its handlers.
+func (p *PrivacyOps) Register(name string, handlers DataHandlers) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for i, m := range p.modules {
+		if m.name == name {
+			p.modules[i].handlers = handlers
+			return
+		}
+	}
+	p.modules = append(p.modules, privacyModule{name: name, handlers: handlers})
+}
+
+// Run carries out action for subjectID in every registered module, and returns the report. The error is
+// non-nil if any module failed; the report says which.
+func (p *PrivacyOps) Run(ctx context.Context, subjectID string, action PrivacyAction) (PrivacyReport, error) {
+	switch action {
+	case PrivacyExport, PrivacyAnonymize, PrivacyDelete:
+	default:
+		return PrivacyReport{}, fmt.Errorf("unknown privacy action %q", action)
+	}
+
+	id, err := randomToken(12)
+	if err != nil {
+		return PrivacyReport{}, err
+	}
+
+	p.mu.Lock()
+	modules := append([]privacyModule(nil), p.modules...)
+	p.mu.Unlock()
+
+	report := PrivacyReport{
+		RequestID: id,
+		SubjectID: subjectID,
+		Action:    action,
+		Started:   time.Now(),
+		Steps:     make([]PrivacyStep, 0, len(modules)),
+	}
+	if action == PrivacyExport {
+		report.Data = make(map[string]any)
+	}
+
+	for i, m := range modules {
+		start := time.Now()
+		step := PrivacyStep{Module: m.name, Status: PrivacyStepDone}
+
+		var err error
+		switch {
+		case ctx.Err() != nil:
+			err = ctx.Err()
+		case action == PrivacyExport && m.handlers.Export != nil:
+			var data any
+			if data, err = m.handlers.Export(ctx, subjectID); err == nil {
+				report.Data[m.name] = data
+			}
+		case action == PrivacyAnonymize && m.handlers.Anonymize != nil:
+			err = m.handlers.Anonymize(ctx, subjectID)
+		case action == PrivacyDelete && m.handlers.Delete != nil:
+			err = m.handlers.Delete(ctx, subjectID)
+		default:
+			step.Status = PrivacyStepSkipped
+		}
+		if err != nil {
+			step.Status, step.Error = PrivacyStepFailed, err.Error()
+		}
+
+		step.DurationMS = float64(time.Since(start).Microseconds()) / 1000
+		report.Steps = append(report.Steps, step)
+
+		if p.OnProgress != nil {
+			p.OnProgress(PrivacyProgress{RequestID: id, Module: m.name, Done: i + 1, Total: len(modules)})
+		}
+	}
+	report.Finished = time.Now()
+
+	if p.Audit != nil {
+		p.Audit(report)
+	}
+
+	if failed := report.Failed(); len(failed) > 0 {
+		return report, fmt.Errorf("%s failed in %d of %d modules: %v", action, len(failed), len(modules), failed)
+	}
+	return report, nil
+}
@@ -0,0 +1,71 @@
+package gohelpertools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestPrivacyOps_Run(t *testing.T) {
+	var deleted []string
+	var progress []PrivacyProgress
+	var audited []PrivacyReport
+
+	p := &PrivacyOps{
+		OnProgress: func(pr PrivacyProgress) { progress = append(progress, pr) },
+		Audit:      func(r PrivacyReport) { audited = append(audited, r) },
+	}
+	p.Register("orders", DataHandlers{
+		Export: func(ctx context.Context, id string) (any, error) { return []string{"order-1"}, nil },
+		Delete: func(ctx context.Context, id string) error {
+			deleted = append(deleted, "orders:"+id)
+			return nil
+		},
+	})
+	p.Register("analytics", DataHandlers{
+		Anonymize: func(ctx context.Context, id string) error { return nil },
+	})
+	p.Register("billing", DataHandlers{
+		Export: func(ctx context.Context, id string) (any, error) { return nil, errors.New("billing is down") },
+		Delete: func(ctx context.Context, id string) error {
+			deleted = append(deleted, "billing:"+id)
+			return nil
+		},
+	})
+
+	report, err := p.Run(context.Background(), "user-1", PrivacyExport)
+	if err == nil || !strings.Contains(err.Error(), "billing") {
+		t.Errorf("expected an error naming billing, got %v", err)
+	}
+	wantStatus := []string{PrivacyStepDone, PrivacyStepSkipped, PrivacyStepFailed}
+	for i, step := range report.Steps {
+		if step.Status != wantStatus[i] {
+			t.Errorf("step %s: expected %s but got %s", step.Module, wantStatus[i], step.Status)
+		}
+	}
+	if _, ok := report.Data["orders"]; !ok || len(report.Data) != 1 {
+		t.Errorf("unexpected exported data %v", report.Data)
+	}
+	if len(progress) != 3 || progress[2].Done != 3 || progress[2].Total != 3 {
+		t.Errorf("unexpected progress %+v", progress)
+	}
+
+	out, _ := json.Marshal(audited[0])
+	if strings.Contains(string(out), "order-1") {
+		t.Errorf("exported data should not be in the audit record: %s", out)
+	}
+
+	report, err = p.Run(context.Background(), "user-1", PrivacyDelete)
+	if err != nil {
+		t.Errorf("error not expected, but one received: %s", err)
+	}
+	if len(deleted) != 2 || deleted[0] != "orders:user-1" || report.RequestID == audited[0].RequestID {
+		t.Errorf("unexpected deletions %v", deleted)
+	}
+
+	if _, err := p.Run(context.Background(), "user-1", "shred"); err == nil {
+		t.Error("unknown action: error expected, but none received")
+	}
+}
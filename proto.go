@@ -0,0 +1,124 @@
+package gohelpertools
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const protobufContentType = "application/x-protobuf"
+
+// ReadProto reads a binary protocol buffer from the body of a request into msg. Like ReadJSON, it checks
+// the Content-Type header, if one is sent, limits the size of the body with MaxJSONSize or WithMaxSize, and
+// returns human-readable errors. Both application/x-protobuf and application/protobuf are accepted. An
+// empty body is valid, since it is how an empty message is encoded.
+func (t *Tools) ReadProto(w http.ResponseWriter, r *http.Request, msg proto.Message, opts ...JSONOption) error {
+	if ct := r.Header.Get("Content-Type"); ct != "" {
+		if mediaType, _, err := mime.ParseMediaType(ct); err != nil || !isProtobufType(mediaType) {
+			return errors.New("the Content-Type header is not application/x-protobuf")
+		}
+	}
+
+	body, err := t.readLimitedBody(w, r, opts)
+	if err != nil {
+		return err
+	}
+
+	if err := proto.Unmarshal(body, msg); err != nil {
+		return errors.New("body contains badly-formed protobuf")
+	}
+	return nil
+}
+
+// WriteProto writes msg to the client as a binary protocol buffer, with the given status. Custom headers
+// can be set with the WithHeaders option.
+func (t *Tools) WriteProto(w http.ResponseWriter, status int, msg proto.Message, opts ...JSONOption) error {
+	out, err := proto.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	t.jsonOptions(opts).setHeaders(w)
+	w.Header().Set("Content-Type", protobufContentType)
+	w.WriteHeader(status)
+	_, _ = w.Write(out)
+
+	return nil
+}
+
+// ReadJSONOrProto reads msg from the body of a request as a protocol buffer if the Content-Type is
+// application/x-protobuf, and otherwise as JSON, using the protobuf JSON mapping (protojson), so that one
+// handler can serve clients of either kind.
+func (t *Tools) ReadJSONOrProto(w http.ResponseWriter, r *http.Request, msg proto.Message, opts ...JSONOption) error {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if isProtobufType(mediaType) {
+		return t.ReadProto(w, r, msg, opts...)
+	}
+
+	if r.Header.Get("Content-Type") != "" {
+		if err := checkJSONContentType(r.Header.Get("Content-Type"), t.AcceptedJSONTypes); err != nil {
+			return err
+		}
+	}
+
+	body, err := t.readLimitedBody(w, r, opts)
+	if err != nil {
+		return err
+	}
+	if len(body) == 0 {
+		return errors.New("body must not be empty")
+	}
+
+	unmarshal := protojson.UnmarshalOptions{DiscardUnknown: t.jsonOptions(opts).allowUnknown}
+	if err := unmarshal.Unmarshal(body, msg); err != nil {
+		return fmt.Errorf("body contains badly-formed JSON: %s", err)
+	}
+	return nil
+}
+
+// WriteJSONOrProto writes msg as a protocol buffer if the client's Accept header prefers
+// application/x-protobuf to application/json, and otherwise as JSON, using the protobuf JSON mapping.
+func (t *Tools) WriteJSONOrProto(w http.ResponseWriter, r *http.Request, status int, msg proto.Message, opts ...JSONOption) error {
+	w.Header().Add("Vary", "Accept")
+
+	accept := r.Header.Get("Accept")
+	protoQuality := max(acceptQuality(accept, protobufContentType), acceptQuality(accept, "application/protobuf"))
+	if protoQuality > 0 && protoQuality > acceptQuality(accept, "application/json") {
+		return t.WriteProto(w, status, msg, opts...)
+	}
+
+	out, err := protojson.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	t.jsonOptions(opts).setHeaders(w)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(out)
+
+	return nil
+}
+
+// readLimitedBody reads the whole body of r, up to the maximum size set by MaxJSONSize or WithMaxSize.
+func (t *Tools) readLimitedBody(w http.ResponseWriter, r *http.Request, opts []JSONOption) ([]byte, error) {
+	maxBytes := t.jsonOptions(opts).maxSize
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, int64(maxBytes)))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return nil, fmt.Errorf("body must not be larger than %d bytes", maxBytes)
+		}
+		return nil, err
+	}
+	return body, nil
+}
+
+func isProtobufType(mediaType string) bool {
+	return mediaType == protobufContentType || mediaType == "application/protobuf"
+}
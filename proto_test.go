@@ -0,0 +1,130 @@
+package gohelpertools
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+var readProtoTests = []struct {
+	name          string
+	contentType   string
+	body          []byte
+	maxSize       int
+	errorExpected bool
+}{
+	{name: "valid", contentType: "application/x-protobuf", body: mustMarshalProto(structpb.NewStringValue("hello"))},
+	{name: "alternative type", contentType: "application/protobuf", body: mustMarshalProto(structpb.NewStringValue("hello"))},
+	{name: "no content type", body: mustMarshalProto(structpb.NewStringValue("hello"))},
+	{name: "wrong content type", contentType: "application/json", body: []byte(`"hello"`), errorExpected: true},
+	{name: "malformed", contentType: "application/x-protobuf", body: []byte{0xff, 0xff}, errorExpected: true},
+	{name: "too large", contentType: "application/x-protobuf", body: mustMarshalProto(structpb.NewStringValue("hello")), maxSize: 2, errorExpected: true},
+}
+
+func TestTools_ReadProto(t *testing.T) {
+	for _, e := range readProtoTests {
+		tools := Tools{MaxJSONSize: e.maxSize}
+
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(e.body))
+		if e.contentType != "" {
+			req.Header.Set("Content-Type", e.contentType)
+		}
+
+		var msg structpb.Value
+		err := tools.ReadProto(httptest.NewRecorder(), req, &msg)
+		if e.errorExpected && err == nil {
+			t.Errorf("%s: error expected, but none received", e.name)
+		}
+		if !e.errorExpected {
+			if err != nil {
+				t.Errorf("%s: error not expected, but one received: %s", e.name, err)
+			} else if msg.GetStringValue() != "hello" {
+				t.Errorf("%s: expected hello, got %v", e.name, msg.GetStringValue())
+			}
+		}
+	}
+}
+
+func TestTools_ReadJSONOrProto(t *testing.T) {
+	var tools Tools
+
+	for _, contentType := range []string{"application/json", "application/x-protobuf"} {
+		body := []byte(`"hello"`)
+		if contentType == "application/x-protobuf" {
+			body = mustMarshalProto(structpb.NewStringValue("hello"))
+		}
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+
+		var msg structpb.Value
+		if err := tools.ReadJSONOrProto(httptest.NewRecorder(), req, &msg); err != nil {
+			t.Errorf("%s: %s", contentType, err)
+		} else if msg.GetStringValue() != "hello" {
+			t.Errorf("%s: expected hello, got %v", contentType, msg.GetStringValue())
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(""))
+	req.Header.Set("Content-Type", "application/json")
+	if err := tools.ReadJSONOrProto(httptest.NewRecorder(), req, &structpb.Value{}); err == nil {
+		t.Error("expected an error for an empty JSON body")
+	}
+}
+
+var writeJSONOrProtoTests = []struct {
+	name     string
+	accept   string
+	expected string
+}{
+	{name: "no accept", accept: "", expected: "application/json"},
+	{name: "json", accept: "application/json", expected: "application/json"},
+	{name: "protobuf", accept: "application/x-protobuf", expected: "application/x-protobuf"},
+	{name: "json preferred", accept: "application/x-protobuf;q=0.5, application/json", expected: "application/json"},
+	{name: "protobuf preferred", accept: "application/protobuf, application/json;q=0.9", expected: "application/x-protobuf"},
+}
+
+func TestTools_WriteJSONOrProto(t *testing.T) {
+	var tools Tools
+	msg := structpb.NewStringValue("hello")
+
+	for _, e := range writeJSONOrProtoTests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if e.accept != "" {
+			req.Header.Set("Accept", e.accept)
+		}
+		rr := httptest.NewRecorder()
+
+		if err := tools.WriteJSONOrProto(rr, req, http.StatusOK, msg); err != nil {
+			t.Errorf("%s: %s", e.name, err)
+			continue
+		}
+		if ct := rr.Header().Get("Content-Type"); ct != e.expected {
+			t.Errorf("%s: expected %s, got %s", e.name, e.expected, ct)
+		}
+		if rr.Header().Get("Vary") != "Accept" {
+			t.Errorf("%s: expected Vary: Accept", e.name)
+		}
+
+		var got structpb.Value
+		if e.expected == "application/json" {
+			if strings.TrimSpace(rr.Body.String()) != `"hello"` {
+				t.Errorf("%s: unexpected JSON body %s", e.name, rr.Body.String())
+			}
+		} else if err := proto.Unmarshal(rr.Body.Bytes(), &got); err != nil || got.GetStringValue() != "hello" {
+			t.Errorf("%s: unexpected protobuf body: %v", e.name, err)
+		}
+	}
+}
+
+func mustMarshalProto(msg proto.Message) []byte {
+	b, err := proto.Marshal(msg)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
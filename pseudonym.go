@@ -0,0 +1,171 @@
+package gohelpertools
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+var fakeFirstNames = []string{
+	"Ada", "Ben", "Chloe", "David", "Emeka", "Fatima", "Grace", "Hiro", "Ines", "James", "Kemi", "Liam",
+	"Maria", "Noah", "Olivia", "Priya", "Quinn", "Rosa", "Samuel", "Tara", "Uche", "Vera", "Wei", "Yusuf",
+}
+
+var fakeLastNames = []string{
+	"Adeyemi", "Brown", "Chen", "Diaz", "Evans", "Fischer", "Garcia", "Hughes", "Ito", "Johnson", "Kim",
+	"Lopez", "Martin", "Nakamura", "Okafor", "Patel", "Quinn", "Rossi", "Smith", "Taylor", "Usman", "Wright",
+}
+
+// Pseudonymizer replaces personal data with stable stand-ins derived from a keyed hash (HMAC-SHA256), so
+// the same input always gives the same output, and joins and counts still work across tables, but the
+// original can't be recovered without the key. It can produce opaque pseudonyms, or realistic fake names,
+// email addresses and phone numbers, for staging datasets built from production exports.
+type Pseudonymizer struct {
+	Key         []byte // secret key; required, and should differ between environments
+	EmailDomain string // domain of fake email addresses; defaults to "example.com"
+}
+
+// Pseudonym returns a 32 character hex pseudonym for value.
+func (p *Pseudonymizer) Pseudonym(value string) string {
+	return hex.EncodeToString(p.sum("pseudonym", value)[:16])
+}
+
+// Name returns a fake "First Last" name for value.
+func (p *Pseudonymizer) Name(value string) string {
+	first, last := p.names(value)
+	return first + " " + last
+}
+
+// Email returns a fake email address for value, such as "grace.okafor.3f2a@example.com". Pass normalized
+// addresses (see NormalizeEmail), so that variants of one address get the same fake.
+func (p *Pseudonymizer) Email(value string) string {
+	first, last := p.names(value)
+	tag := hex.EncodeToString(p.sum("email", value)[:2])
+	return strings.ToLower(first+"."+last) + "." + tag + "@" + valueOrDefault(p.EmailDomain, "example.com")
+}
+
+// Phone returns a fake phone number for value, in the range 555-0100 to 555-0199, which is reserved for
+// fiction in North America, so that staging data can never call a real person.
+func (p *Pseudonymizer) Phone(value string) string {
+	n := binary.BigEndian.Uint16(p.sum("phone", value)) % 100
+	return fmt.Sprintf("+1 555-01%02d", n)
+}
+
+// Anonymize replaces the string fields of the struct pointed to by v according to their anonymize tags:
+//
+//	Name  string `anonymize:"name"`      // a fake name
+//	Email string `anonymize:"email"`     // a fake email address
+//	Phone string `anonymize:"phone"`     // a fake phone number
+//	SSN   string `anonymize:"pseudonym"` // an opaque pseudonym
+//	Notes string `anonymize:"redact"`    // the empty string
+//
+// Nested structs, pointers to structs and slices of them are anonymized too. Empty strings are left
+// empty.
+func (p *Pseudonymizer) Anonymize(v any) error {
+	if len(p.Key) == 0 {
+		return errors.New("pseudonymizer has no key")
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return errors.New("anonymize needs a non-nil pointer")
+	}
+	return p.anonymize(rv.Elem())
+}
+
+func (p *Pseudonymizer) anonymize(v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if !v.IsNil() {
+			return p.anonymize(v.Elem())
+		}
+	case reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		// A struct or array held by value in an interface can't be modified in place, so it is anonymized
+		// as a copy, which is stored back in the interface.
+		elem := v.Elem()
+		if elem.Kind() != reflect.Struct && elem.Kind() != reflect.Array {
+			return p.anonymize(elem)
+		}
+		if !v.CanSet() {
+			return fmt.Errorf("can't anonymize a %s held in an unsettable interface", elem.Type())
+		}
+		copied := reflect.New(elem.Type()).Elem()
+		copied.Set(elem)
+		if err := p.anonymize(copied); err != nil {
+			return err
+		}
+		v.Set(copied)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := p.anonymize(v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+
+			tag := field.Tag.Get("anonymize")
+			if tag == "" {
+				if err := p.anonymize(v.Field(i)); err != nil {
+					return err
+				}
+				continue
+			}
+			if field.Type.Kind() != reflect.String {
+				return fmt.Errorf("field %s has an anonymize tag but is not a string", field.Name)
+			}
+
+			value := v.Field(i).String()
+			if value == "" {
+				continue
+			}
+			if !v.Field(i).CanSet() {
+				return fmt.Errorf("field %s can't be set", field.Name)
+			}
+			switch tag {
+			case "name":
+				value = p.Name(value)
+			case "email":
+				value = p.Email(value)
+			case "phone":
+				value = p.Phone(value)
+			case "pseudonym":
+				value = p.Pseudonym(value)
+			case "redact":
+				value = ""
+			default:
+				return fmt.Errorf("field %s has unknown anonymize tag %q", field.Name, tag)
+			}
+			v.Field(i).SetString(value)
+		}
+	}
+	return nil
+}
+
+func (p *Pseudonymizer) names(value string) (string, string) {
+	sum := p.sum("name", value)
+	first := fakeFirstNames[binary.BigEndian.Uint32(sum[0:4])%uint32(len(fakeFirstNames))]
+	last := fakeLastNames[binary.BigEndian.Uint32(sum[4:8])%uint32(len(fakeLastNames))]
+	return first, last
+}
+
+// sum returns the HMAC of value, with purpose mixed in so that, for example, the name and email derived
+// from one value are independent.
+func (p *Pseudonymizer) sum(purpose, value string) []byte {
+	mac := hmac.New(sha256.New, p.Key)
+	mac.Write([]byte(purpose))
+	mac.Write([]byte{0})
+	mac.Write([]byte(value))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,111 @@
+package gohelpertools
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestPseudonymizer(t *testing.T) {
+	p := &Pseudonymizer{Key: []byte("staging-key")}
+	other := &Pseudonymizer{Key: []byte("another-key")}
+
+	if p.Pseudonym("alice@example.org") != p.Pseudonym("alice@example.org") {
+		t.Error("expected pseudonyms to be deterministic")
+	}
+	if p.Pseudonym("alice@example.org") == other.Pseudonym("alice@example.org") {
+		t.Error("expected pseudonyms to depend on the key")
+	}
+	if len(p.Pseudonym("x")) != 32 {
+		t.Errorf("expected a 32 character pseudonym, got %q", p.Pseudonym("x"))
+	}
+
+	if !regexp.MustCompile(`^[A-Z][a-z]+ [A-Z][a-z]+$`).MatchString(p.Name("Alice Smith")) {
+		t.Errorf("unexpected fake name %q", p.Name("Alice Smith"))
+	}
+	if email := p.Email("alice@example.org"); !regexp.MustCompile(`^[a-z]+\.[a-z]+\.[0-9a-f]{4}@example\.com$`).MatchString(email) {
+		t.Errorf("unexpected fake email %q", email)
+	}
+	if phone := p.Phone("+44 20 7946 0000"); !regexp.MustCompile(`^\+1 555-01\d\d$`).MatchString(phone) {
+		t.Errorf("unexpected fake phone %q", phone)
+	}
+}
+
+type anonymizeContact struct {
+	Phone string `anonymize:"phone"`
+}
+
+type anonymizeUser struct {
+	ID       int
+	Name     string `anonymize:"name"`
+	Email    string `anonymize:"email"`
+	SSN      string `anonymize:"pseudonym"`
+	Notes    string `anonymize:"redact"`
+	Nickname string `anonymize:"name"`
+	Contacts []anonymizeContact
+	Backup   *anonymizeContact
+}
+
+func TestPseudonymizer_Anonymize(t *testing.T) {
+	p := &Pseudonymizer{Key: []byte("k"), EmailDomain: "staging.test"}
+	user := anonymizeUser{
+		ID:       7,
+		Name:     "Alice Smith",
+		Email:    "alice@example.org",
+		SSN:      "123-45-6789",
+		Notes:    "called about her divorce",
+		Contacts: []anonymizeContact{{Phone: "07700 900000"}},
+		Backup:   &anonymizeContact{Phone: "07700 900001"},
+	}
+
+	if err := p.Anonymize(&user); err != nil {
+		t.Fatal(err)
+	}
+
+	if user.ID != 7 || user.Name == "Alice Smith" || user.Name != p.Name("Alice Smith") {
+		t.Errorf("unexpected name %q", user.Name)
+	}
+	if !strings.HasSuffix(user.Email, "@staging.test") || user.SSN != p.Pseudonym("123-45-6789") || user.Notes != "" {
+		t.Errorf("unexpected anonymized user %+v", user)
+	}
+	if user.Nickname != "" {
+		t.Errorf("expected empty fields to stay empty, got %q", user.Nickname)
+	}
+	if !strings.HasPrefix(user.Contacts[0].Phone, "+1 555-01") || !strings.HasPrefix(user.Backup.Phone, "+1 555-01") {
+		t.Errorf("expected nested fields to be anonymized, got %+v %+v", user.Contacts, user.Backup)
+	}
+
+	bad := struct {
+		Age int `anonymize:"name"`
+	}{Age: 3}
+	if err := p.Anonymize(&bad); err == nil {
+		t.Error("non-string field: error expected, but none received")
+	}
+	if err := p.Anonymize(user); err == nil {
+		t.Error("non-pointer: error expected, but none received")
+	}
+}
+
+func TestPseudonymizer_AnonymizeInterface(t *testing.T) {
+	p := &Pseudonymizer{Key: []byte("k")}
+	wrapper := struct {
+		Data  any
+		Items []any
+	}{
+		Data:  anonymizeContact{Phone: "07700 900000"},
+		Items: []any{anonymizeContact{Phone: "07700 900001"}, &anonymizeContact{Phone: "07700 900002"}},
+	}
+
+	if err := p.Anonymize(&wrapper); err != nil {
+		t.Fatalf("error not expected, but one received: %s", err)
+	}
+	if got := wrapper.Data.(anonymizeContact).Phone; !strings.HasPrefix(got, "+1 555-01") {
+		t.Errorf("expected struct held in an interface to be anonymized, got %q", got)
+	}
+	if got := wrapper.Items[0].(anonymizeContact).Phone; !strings.HasPrefix(got, "+1 555-01") {
+		t.Errorf("expected struct held in a slice of interfaces to be anonymized, got %q", got)
+	}
+	if got := wrapper.Items[1].(*anonymizeContact).Phone; !strings.HasPrefix(got, "+1 555-01") {
+		t.Errorf("expected pointer held in an interface to be anonymized, got %q", got)
+	}
+}
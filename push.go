@@ -0,0 +1,43 @@
+package gohelpertools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+// PushJSONToRemote posts data, marshalled to JSON, to uri, and returns the response, its status code,
+// and any error. An optional http.Client may be given; otherwise HTTPClient is used. The caller is
+// responsible for closing the response body.
+func (t *Tools) PushJSONToRemote(uri string, data any, client ...*http.Client) (*http.Response, int, error) {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	httpClient := t.HTTPClient()
+	if len(client) > 0 && client[0] != nil {
+		httpClient = client[0]
+	}
+
+	resp, err := postJSON(context.Background(), httpClient, uri, body, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	return resp, resp.StatusCode, nil
+}
+
+// postJSON posts an already-encoded JSON body to uri, with any extra headers.
+func postJSON(ctx context.Context, client *http.Client, uri string, body []byte, headers http.Header) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, uri, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range headers {
+		req.Header[key] = value
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	return client.Do(req)
+}
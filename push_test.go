@@ -0,0 +1,36 @@
+package gohelpertools
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTools_PushJSONToRemote(t *testing.T) {
+	var received map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_ = json.NewDecoder(r.Body).Decode(&received)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	var tools Tools
+	resp, status, err := tools.PushJSONToRemote(srv.URL, map[string]string{"foo": "bar"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if status != http.StatusAccepted || received["foo"] != "bar" {
+		t.Errorf("expected payload to be posted, got status %d and %v", status, received)
+	}
+
+	if _, _, err := tools.PushJSONToRemote(srv.URL, make(chan int)); err == nil {
+		t.Error("unmarshalable data: error expected, but none received")
+	}
+}
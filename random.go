@@ -0,0 +1,118 @@
+package gohelpertools
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"math/big"
+)
+
+const digitCharset = "0123456789"
+
+// nanoIDAlphabet is the default alphabet used by NanoID: 64 URL-safe characters.
+const nanoIDAlphabet = "useandom-26T198340PX75pxJACKVERYMINDBUSHWOLF_GQZbfghjklqvwyzrict"
+
+// RandomStringFrom returns a random string of n characters chosen uniformly from charset, using
+// crypto/rand. Random bytes outside the largest multiple of len(charset) are rejected rather than
+// reduced modulo len(charset), so no character is more likely than any other.
+func (t *Tools) RandomStringFrom(n int, charset string) (string, error) {
+	if n < 0 {
+		return "", errors.New("length must not be negative")
+	}
+
+	chars := []rune(charset)
+	if len(chars) == 0 {
+		return "", errors.New("charset must not be empty")
+	}
+
+	s := make([]rune, n)
+
+	// Large charsets can't be indexed by a single byte.
+	if len(chars) > 256 {
+		max := big.NewInt(int64(len(chars)))
+		for i := range s {
+			x, err := rand.Int(rand.Reader, max)
+			if err != nil {
+				return "", err
+			}
+			s[i] = chars[x.Int64()]
+		}
+		return string(s), nil
+	}
+
+	limit := 256 - 256%len(chars)
+	buf := make([]byte, n+n/4+8)
+	for i := 0; i < n; {
+		if _, err := rand.Read(buf); err != nil {
+			return "", err
+		}
+		for _, b := range buf {
+			if int(b) >= limit {
+				continue
+			}
+			s[i] = chars[int(b)%len(chars)]
+			i++
+			if i == n {
+				break
+			}
+		}
+	}
+	return string(s), nil
+}
+
+// RandomHex returns n random bytes, hex-encoded (so the result is 2n characters long).
+func (t *Tools) RandomHex(n int) (string, error) {
+	b, err := randomBytes(n)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// RandomBase64URL returns n random bytes, encoded as unpadded URL-safe base64, which is suitable for
+// tokens in URLs and cookies.
+func (t *Tools) RandomBase64URL(n int) (string, error) {
+	return randomToken(n)
+}
+
+// RandomDigits returns a string of n random decimal digits, such as a one-time code. Leading zeros are
+// kept, so treat the result as a string rather than a number.
+func (t *Tools) RandomDigits(n int) (string, error) {
+	return t.RandomStringFrom(n, digitCharset)
+}
+
+// NanoID returns a random, URL-safe ID of length characters, in the style of Nano ID, for use as a public
+// resource identifier or short link. A length of zero or less gives the usual 21 characters, which are as
+// unlikely to collide as a UUID. An alternative alphabet may be given; characters are sampled uniformly.
+func (t *Tools) NanoID(length int, alphabet ...string) (string, error) {
+	if length <= 0 {
+		length = 21
+	}
+
+	chars := nanoIDAlphabet
+	if len(alphabet) > 0 {
+		chars = alphabet[0]
+	}
+	return t.RandomStringFrom(length, chars)
+}
+
+// randomToken returns n random bytes, base64url encoded, for IDs and secrets which don't need a Tools.
+func randomToken(n int) (string, error) {
+	b, err := randomBytes(n)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func randomBytes(n int) ([]byte, error) {
+	if n < 0 {
+		return nil, errors.New("length must not be negative")
+	}
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
@@ -0,0 +1,93 @@
+package gohelpertools
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+var randomStringFromTests = []struct {
+	name          string
+	n             int
+	charset       string
+	errorExpected bool
+}{
+	{name: "letters", n: 50, charset: "abc"},
+	{name: "unicode", n: 20, charset: "äöü€"},
+	{name: "large charset", n: 20, charset: strings.Repeat("abcdefghij", 30)},
+	{name: "zero length", n: 0, charset: "abc"},
+	{name: "empty charset", n: 5, charset: "", errorExpected: true},
+	{name: "negative length", n: -1, charset: "abc", errorExpected: true},
+}
+
+func TestTools_RandomStringFrom(t *testing.T) {
+	var tools Tools
+
+	for _, e := range randomStringFromTests {
+		s, err := tools.RandomStringFrom(e.n, e.charset)
+		if e.errorExpected {
+			if err == nil {
+				t.Errorf("%s: error expected, but none received", e.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: error not expected, but one received: %s", e.name, err)
+			continue
+		}
+
+		if utf8.RuneCountInString(s) != e.n {
+			t.Errorf("%s: expected %d characters but got %d", e.name, e.n, utf8.RuneCountInString(s))
+		}
+		for _, r := range s {
+			if !strings.ContainsRune(e.charset, r) {
+				t.Errorf("%s: unexpected character %q", e.name, r)
+			}
+		}
+	}
+}
+
+func TestTools_RandomVariants(t *testing.T) {
+	var tools Tools
+
+	hex, err := tools.RandomHex(16)
+	if err != nil || len(hex) != 32 || strings.Trim(hex, "0123456789abcdef") != "" {
+		t.Errorf("RandomHex: unexpected result %q (%v)", hex, err)
+	}
+
+	b64, err := tools.RandomBase64URL(32)
+	if err != nil || len(b64) != 43 || strings.ContainsAny(b64, "+/=") {
+		t.Errorf("RandomBase64URL: unexpected result %q (%v)", b64, err)
+	}
+
+	digits, err := tools.RandomDigits(6)
+	if err != nil || len(digits) != 6 || strings.Trim(digits, "0123456789") != "" {
+		t.Errorf("RandomDigits: unexpected result %q (%v)", digits, err)
+	}
+
+	// Every digit should turn up in a long enough string; a biased or broken generator would miss some.
+	digits, _ = tools.RandomDigits(1000)
+	for _, d := range "0123456789" {
+		if !strings.ContainsRune(digits, d) {
+			t.Errorf("RandomDigits: digit %q never generated", d)
+		}
+	}
+}
+
+func TestTools_NanoID(t *testing.T) {
+	var tools Tools
+
+	id, err := tools.NanoID(0)
+	if err != nil || len(id) != 21 || strings.Trim(id, nanoIDAlphabet) != "" {
+		t.Errorf("unexpected default ID %q (%v)", id, err)
+	}
+
+	id, err = tools.NanoID(8, "abc")
+	if err != nil || len(id) != 8 || strings.Trim(id, "abc") != "" {
+		t.Errorf("unexpected custom ID %q (%v)", id, err)
+	}
+
+	if _, err := tools.NanoID(8, ""); err == nil {
+		t.Error("empty alphabet: error expected, but none received")
+	}
+}
@@ -0,0 +1,179 @@
+package gohelpertools
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrRefreshTokenNotFound is returned by a TokenStore when a refresh token is unknown.
+var ErrRefreshTokenNotFound = errors.New("refresh token not found")
+
+// TokenPair is an access token together with the opaque refresh token used to obtain the next pair. It
+// can be sent to the client as is; Record is not serialised, and is what should be persisted.
+type TokenPair struct {
+	AccessToken  string             `json:"access_token"`
+	RefreshToken string             `json:"refresh_token"`
+	TokenType    string             `json:"token_type"`
+	ExpiresIn    int64              `json:"expires_in"`
+	Record       RefreshTokenRecord `json:"-"`
+}
+
+// RefreshTokenRecord is what a TokenStore keeps for each refresh token. Only a hash of the token is
+// stored, so a leaked database does not leak usable tokens.
+type RefreshTokenRecord struct {
+	Hash      string    // SHA-256 of the refresh token, hex encoded
+	Family    string    // shared by every token descended from the same login, for reuse detection
+	Claims    Claims    // claims copied into each new access token
+	ExpiresAt time.Time // when the refresh token stops being accepted
+	Used      bool      // set once the token has been exchanged for a new pair
+}
+
+// TokenStore persists refresh token records. Use must be atomic: it marks the record as used and returns
+// it as it was before, so two concurrent refreshes with the same token can't both succeed.
+type TokenStore interface {
+	Save(ctx context.Context, record RefreshTokenRecord) error
+	Use(ctx context.Context, hash string) (RefreshTokenRecord, error)
+	RevokeFamily(ctx context.Context, family string) error
+}
+
+// GenerateTokenPair returns an access token containing claims, valid for accessTTL, and a random refresh
+// token valid for refreshTTL. The caller should save pair.Record in a TokenStore (typically at login) and
+// then use RotateRefreshToken in the refresh endpoint.
+func (m *TokenManager) GenerateTokenPair(claims Claims, accessTTL, refreshTTL time.Duration) (TokenPair, error) {
+	family, err := randomToken(16)
+	if err != nil {
+		return TokenPair{}, err
+	}
+	return m.generateTokenPair(claims, family, accessTTL, refreshTTL)
+}
+
+// RotateRefreshToken exchanges refreshToken for a new token pair, saving the new refresh token in store
+// and invalidating the old one. If a refresh token is presented a second time, it has probably been
+// stolen, so every token in its family is revoked and the user has to log in again.
+func (m *TokenManager) RotateRefreshToken(ctx context.Context, store TokenStore, refreshToken string, accessTTL, refreshTTL time.Duration) (TokenPair, error) {
+	record, err := store.Use(ctx, HashRefreshToken(refreshToken))
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	if record.Used {
+		if err := store.RevokeFamily(ctx, record.Family); err != nil {
+			return TokenPair{}, err
+		}
+		return TokenPair{}, errors.New("refresh token reuse detected")
+	}
+
+	if time.Now().After(record.ExpiresAt) {
+		return TokenPair{}, errors.New("refresh token has expired")
+	}
+
+	pair, err := m.generateTokenPair(record.Claims, record.Family, accessTTL, refreshTTL)
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	if err := store.Save(ctx, pair.Record); err != nil {
+		return TokenPair{}, err
+	}
+	return pair, nil
+}
+
+// HashRefreshToken returns the hash under which a refresh token is stored.
+func HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func (m *TokenManager) generateTokenPair(claims Claims, family string, accessTTL, refreshTTL time.Duration) (TokenPair, error) {
+	access, err := m.GenerateJWT(claims, accessTTL)
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	refresh, err := randomToken(32)
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	return TokenPair{
+		AccessToken:  access,
+		RefreshToken: refresh,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(accessTTL.Seconds()),
+		Record: RefreshTokenRecord{
+			Hash:      HashRefreshToken(refresh),
+			Family:    family,
+			Claims:    claims,
+			ExpiresAt: time.Now().Add(refreshTTL),
+		},
+	}, nil
+}
+
+// MemoryTokenStore is a TokenStore which keeps refresh tokens in memory. It is suitable for tests and
+// single-instance services; tokens are lost on restart.
+type MemoryTokenStore struct {
+	mu        sync.Mutex
+	records   map[string]RefreshTokenRecord
+	nextSweep time.Time
+}
+
+// memoryTokenSweepInterval is how often Save clears out expired records, so that the cost of scanning
+// every record is shared between many saves.
+const memoryTokenSweepInterval = time.Minute
+
+// NewMemoryTokenStore returns an empty MemoryTokenStore.
+func NewMemoryTokenStore() *MemoryTokenStore {
+	return &MemoryTokenStore{records: make(map[string]RefreshTokenRecord)}
+}
+
+// Save stores record, replacing any record with the same hash. At most once a minute, it also clears out
+// expired records, which RotateRefreshToken would refuse anyway. Used records are kept until they expire,
+// so that reuse can still be detected.
+func (s *MemoryTokenStore) Save(ctx context.Context, record RefreshTokenRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now := time.Now(); now.After(s.nextSweep) {
+		for hash, old := range s.records {
+			if now.After(old.ExpiresAt) {
+				delete(s.records, hash)
+			}
+		}
+		s.nextSweep = now.Add(memoryTokenSweepInterval)
+	}
+	s.records[record.Hash] = record
+	return nil
+}
+
+// Use marks the record for hash as used, returning it as it was before.
+func (s *MemoryTokenStore) Use(ctx context.Context, hash string) (RefreshTokenRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.records[hash]
+	if !ok {
+		return RefreshTokenRecord{}, ErrRefreshTokenNotFound
+	}
+
+	used := record
+	used.Used = true
+	s.records[hash] = used
+	return record, nil
+}
+
+// RevokeFamily deletes every record in family.
+func (s *MemoryTokenStore) RevokeFamily(ctx context.Context, family string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for hash, record := range s.records {
+		if record.Family == family {
+			delete(s.records, hash)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package gohelpertools
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTokenManager_RotateRefreshToken(t *testing.T) {
+	ctx := context.Background()
+	manager := TokenManager{Secret: []byte("secret")}
+	store := NewMemoryTokenStore()
+
+	pair, err := manager.GenerateTokenPair(Claims{"sub": "42"}, time.Minute, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pair.Record.Hash == pair.RefreshToken || pair.Record.Hash != HashRefreshToken(pair.RefreshToken) {
+		t.Error("refresh token should be stored as a hash")
+	}
+	_ = store.Save(ctx, pair.Record)
+
+	// A normal rotation returns a new pair with the same claims.
+	next, err := manager.RotateRefreshToken(ctx, store, pair.RefreshToken, time.Minute, time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error rotating refresh token: %s", err)
+	}
+	claims, err := manager.ValidateJWT(next.AccessToken)
+	if err != nil || claims.Subject() != "42" {
+		t.Errorf("rotated access token is not valid: %v", err)
+	}
+
+	// Reusing the old token revokes the whole family, including the new token.
+	if _, err := manager.RotateRefreshToken(ctx, store, pair.RefreshToken, time.Minute, time.Hour); err == nil {
+		t.Error("expected error when reusing a refresh token, but none received")
+	}
+	if _, err := manager.RotateRefreshToken(ctx, store, next.RefreshToken, time.Minute, time.Hour); !errors.Is(err, ErrRefreshTokenNotFound) {
+		t.Errorf("expected descendant token to be revoked after reuse, got %v", err)
+	}
+}
+
+func TestTokenManager_RotateExpiredRefreshToken(t *testing.T) {
+	ctx := context.Background()
+	manager := TokenManager{Secret: []byte("secret")}
+	store := NewMemoryTokenStore()
+
+	pair, _ := manager.GenerateTokenPair(Claims{"sub": "42"}, time.Minute, -time.Minute)
+	_ = store.Save(ctx, pair.Record)
+
+	if _, err := manager.RotateRefreshToken(ctx, store, pair.RefreshToken, time.Minute, time.Hour); err == nil {
+		t.Error("expected error for an expired refresh token, but none received")
+	}
+}
+
+func TestMemoryTokenStore_Sweep(t *testing.T) {
+	store := NewMemoryTokenStore()
+	ctx := context.Background()
+	_ = store.Save(ctx, RefreshTokenRecord{Hash: "old", ExpiresAt: time.Now().Add(-time.Second)})
+	_ = store.Save(ctx, RefreshTokenRecord{Hash: "expired", ExpiresAt: time.Now().Add(-time.Second)})
+	if len(store.records) != 2 {
+		t.Errorf("expected expired records to be kept until the next sweep, got %d", len(store.records))
+	}
+
+	store.nextSweep = time.Now().Add(-time.Second)
+	_ = store.Save(ctx, RefreshTokenRecord{Hash: "used", Used: true, ExpiresAt: time.Now().Add(time.Hour)})
+	if _, ok := store.records["expired"]; ok || len(store.records) != 1 {
+		t.Errorf("expected the sweep to remove expired records, got %d", len(store.records))
+	}
+}
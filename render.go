@@ -0,0 +1,209 @@
+package gohelpertools
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"net/http"
+	"path"
+	"sync"
+	"text/template/parse"
+)
+
+// Renderer renders server-side HTML pages from html/template files in an fs.FS, such as an embed.FS or
+// os.DirFS("templates"). Each page in the Pages directory is parsed together with every layout and
+// partial, so pages can use them without listing them. Parsed templates are cached, unless Reload is set,
+// in which case they are parsed again on every render so that changes show up without a restart.
+//
+// A typical layout, layouts/base.html, defines "base" and calls {{template "content" .}}, and each page
+// defines "content". If a page has nothing outside its {{define}} blocks, Render executes the Layout
+// template; otherwise it executes the page itself, which may call a layout explicitly or stand alone.
+type Renderer struct {
+	FS          fs.FS                                // the template files; required
+	Pages       string                               // directory of pages, named by Render without the .html extension; defaults to "pages"
+	Layouts     string                               // glob matching layout files; defaults to "layouts/*.html"
+	Partials    string                               // glob matching partial files; defaults to "partials/*.html"
+	Layout      string                               // template executed for pages with only {{define}} blocks; defaults to "base"
+	Reload      bool                                 // if set to true, templates are parsed on every render (for development)
+	Funcs       template.FuncMap                     // extra template functions, added to those of Tools.TemplateFuncs
+	Feature     func(name string) bool               // if set, templates can call {{if feature "new-checkout"}} to check feature flags
+	Static      *StaticServer                        // if set, templates can call {{asset "css/app.css"}} for fingerprinted asset paths
+	CSRFToken   func(r *http.Request) string         // if set, its result is passed to templates as .CSRFToken
+	DefaultData func(r *http.Request) map[string]any // if set, its result is passed to templates as .Values
+	Tools       *Tools                               // used to log errors; a zero Tools is used if nil
+
+	mu    sync.Mutex
+	cache map[string]*template.Template
+}
+
+// TemplateData is the value passed to templates by Render. The data given to Render is in Data.
+type TemplateData struct {
+	Data      any
+	CSRFToken string
+	Flash     []string       // messages from Session.AddFlash, if the request has a session
+	RequestID string         // from the RequestID middleware, or else the X-Request-Id header
+	Values    map[string]any // from Renderer.DefaultData
+}
+
+// Render renders the page name (such as "home", for pages/home.html) with data and writes it with status,
+// which defaults to 200 OK. The page is rendered into a buffer first, so that a template error results in
+// an error being returned rather than half a page being sent.
+func (rd *Renderer) Render(w http.ResponseWriter, r *http.Request, name string, data any, status ...int) error {
+	tmpl, err := rd.template(name)
+	if err != nil {
+		return err
+	}
+
+	td := TemplateData{
+		Data:      data,
+		RequestID: RequestIDFromContext(r.Context()),
+	}
+	if td.RequestID == "" {
+		td.RequestID = r.Header.Get("X-Request-Id")
+	}
+	if rd.CSRFToken != nil {
+		td.CSRFToken = rd.CSRFToken(r)
+	}
+	if rd.DefaultData != nil {
+		td.Values = rd.DefaultData(r)
+	}
+	if session := GetSession(r); session != nil {
+		td.Flash = session.PopFlash()
+	}
+
+	var buf bytes.Buffer
+	if err := rd.execute(&buf, tmpl, td); err != nil {
+		return err
+	}
+
+	statusCode := http.StatusOK
+	if len(status) > 0 {
+		statusCode = status[0]
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(statusCode)
+	_, _ = buf.WriteTo(w)
+	return nil
+}
+
+// RenderTo renders the page name with data to w, outside of an HTTP request, for example for the body of
+// an email. Only the Data field of the TemplateData passed to the page is set.
+func (rd *Renderer) RenderTo(w io.Writer, name string, data any) error {
+	tmpl, err := rd.template(name)
+	if err != nil {
+		return err
+	}
+	return rd.execute(w, tmpl, TemplateData{Data: data})
+}
+
+// execute executes tmpl, or the Layout template if tmpl only defines blocks for it.
+func (rd *Renderer) execute(w io.Writer, tmpl *template.Template, td TemplateData) error {
+	entry := tmpl.Name()
+	if onlyDefinitions(tmpl) {
+		entry = valueOrDefault(rd.Layout, "base")
+	}
+	return tmpl.ExecuteTemplate(w, entry, td)
+}
+
+// ErrorPage returns an ErrorPageFunc which renders the page name, with a data value holding the Status,
+// StatusText and Message, for use as Tools.ErrorPage. If the page can't be rendered, the error is logged
+// and a plain-text response is sent.
+func (rd *Renderer) ErrorPage(name string) ErrorPageFunc {
+	return func(w http.ResponseWriter, r *http.Request, status int, message string) {
+		data := struct {
+			Status     int
+			StatusText string
+			Message    string
+		}{status, http.StatusText(status), message}
+
+		if err := rd.Render(w, r, name, data, status); err != nil {
+			toolsOrDefault(rd.Tools).LogError(r.Context(), "unable to render error page", err, "page", name)
+			http.Error(w, message, status)
+		}
+	}
+}
+
+// template returns the parsed template set for the page name.
+func (rd *Renderer) template(name string) (*template.Template, error) {
+	if rd.FS == nil {
+		return nil, errors.New("renderer has no template FS")
+	}
+
+	if !rd.Reload {
+		rd.mu.Lock()
+		tmpl, ok := rd.cache[name]
+		rd.mu.Unlock()
+		if ok {
+			return tmpl, nil
+		}
+	}
+
+	tmpl, err := rd.parse(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if !rd.Reload {
+		rd.mu.Lock()
+		if rd.cache == nil {
+			rd.cache = make(map[string]*template.Template)
+		}
+		rd.cache[name] = tmpl
+		rd.mu.Unlock()
+	}
+	return tmpl, nil
+}
+
+// parse parses the page name together with the layouts and partials.
+func (rd *Renderer) parse(name string) (*template.Template, error) {
+	page := path.Join(valueOrDefault(rd.Pages, "pages"), name+".html")
+	if !fs.ValidPath(page) {
+		return nil, fmt.Errorf("invalid page name %q", name)
+	}
+
+	funcs := toolsOrDefault(rd.Tools).TemplateFuncs()
+	if rd.Static != nil {
+		funcs["asset"] = rd.Static.AssetPath
+	}
+	funcs["feature"] = func(name string) bool { return rd.Feature != nil && rd.Feature(name) }
+	for key, fn := range rd.Funcs {
+		funcs[key] = fn
+	}
+
+	tmpl, err := template.New(path.Base(page)).Funcs(funcs).ParseFS(rd.FS, page)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pattern := range []string{valueOrDefault(rd.Layouts, "layouts/*.html"), valueOrDefault(rd.Partials, "partials/*.html")} {
+		matches, err := fs.Glob(rd.FS, pattern)
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) == 0 {
+			continue
+		}
+		if tmpl, err = tmpl.ParseFS(rd.FS, matches...); err != nil {
+			return nil, err
+		}
+	}
+	return tmpl, nil
+}
+
+// onlyDefinitions reports whether tmpl has no content of its own, besides whitespace, outside the
+// templates it defines.
+func onlyDefinitions(tmpl *template.Template) bool {
+	if tmpl.Tree == nil || tmpl.Tree.Root == nil {
+		return true
+	}
+	for _, node := range tmpl.Tree.Root.Nodes {
+		text, ok := node.(*parse.TextNode)
+		if !ok || len(bytes.TrimSpace(text.Text)) > 0 {
+			return false
+		}
+	}
+	return true
+}
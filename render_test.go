@@ -0,0 +1,121 @@
+package gohelpertools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+var renderFS = fstest.MapFS{
+	"layouts/base.html":  {Data: []byte(`{{define "base"}}<html>{{template "nav" .}}{{template "content" .}}</html>{{end}}`)},
+	"partials/nav.html":  {Data: []byte(`{{define "nav"}}<nav>{{range .Flash}}[{{.}}]{{end}}</nav>{{end}}`)},
+	"pages/home.html":    {Data: []byte(`{{define "content"}}<p>{{.Data}} {{.CSRFToken}} {{.RequestID}} {{.Values.app}} {{shout "hi"}}</p>{{end}}`)},
+	"pages/error.html":   {Data: []byte(`{{define "content"}}<h1>{{.Data.Status}} {{.Data.Message}}</h1>{{end}}`)},
+	"pages/broken.html":  {Data: []byte(`{{define "content"}}{{.Data.Missing.Field}}{{end}}`)},
+	"pages/assets.html":  {Data: []byte(`{{define "content"}}{{asset "app.css"}}{{end}}`)},
+	"pages/no-base.html": {Data: []byte(`plain {{.Data}}`)},
+}
+
+func TestRenderer_Render(t *testing.T) {
+	rd := &Renderer{
+		FS:          renderFS,
+		Funcs:       map[string]any{"shout": strings.ToUpper},
+		CSRFToken:   func(r *http.Request) string { return "token123" },
+		DefaultData: func(r *http.Request) map[string]any { return map[string]any{"app": "demo"} },
+	}
+
+	session := &Session{values: map[string]json.RawMessage{}}
+	_ = session.AddFlash("saved")
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Request-Id", "req-1")
+	req = req.WithContext(context.WithValue(req.Context(), sessionContextKey, session))
+
+	rr := httptest.NewRecorder()
+	if err := rd.Render(rr, req, "home", "<b>hello</b>"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "<html><nav>[saved]</nav><p>&lt;b&gt;hello&lt;/b&gt; token123 req-1 demo HI</p></html>"
+	if rr.Body.String() != want {
+		t.Errorf("expected %q but got %q", want, rr.Body.String())
+	}
+	if rr.Header().Get("Content-Type") != "text/html; charset=utf-8" {
+		t.Errorf("unexpected Content-Type %q", rr.Header().Get("Content-Type"))
+	}
+	if flash := session.PopFlash(); len(flash) != 0 {
+		t.Errorf("expected flash messages to be consumed, got %v", flash)
+	}
+
+	rr = httptest.NewRecorder()
+	if err := rd.Render(rr, httptest.NewRequest(http.MethodGet, "/", nil), "no-base", "x", http.StatusAccepted); err != nil {
+		t.Fatal(err)
+	}
+	if rr.Code != http.StatusAccepted || rr.Body.String() != "plain x" {
+		t.Errorf("expected the page itself to be executed, got %d %q", rr.Code, rr.Body.String())
+	}
+}
+
+func TestRenderer_Errors(t *testing.T) {
+	rd := &Renderer{FS: renderFS}
+
+	for _, name := range []string{"missing", "broken", "../layouts/base"} {
+		rr := httptest.NewRecorder()
+		if err := rd.Render(rr, httptest.NewRequest(http.MethodGet, "/", nil), name, nil); err == nil {
+			t.Errorf("%s: error expected, but none received", name)
+		}
+		if rr.Body.Len() != 0 {
+			t.Errorf("%s: expected nothing to be written, got %q", name, rr.Body.String())
+		}
+	}
+}
+
+func TestRenderer_Cache(t *testing.T) {
+	files := fstest.MapFS{"pages/p.html": {Data: []byte("one")}}
+
+	for _, reload := range []bool{false, true} {
+		files["pages/p.html"] = &fstest.MapFile{Data: []byte("one")}
+		rd := &Renderer{FS: files, Reload: reload}
+		_ = rd.Render(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), "p", nil)
+
+		files["pages/p.html"] = &fstest.MapFile{Data: []byte("two")}
+		rr := httptest.NewRecorder()
+		_ = rd.Render(rr, httptest.NewRequest(http.MethodGet, "/", nil), "p", nil)
+
+		want := "one"
+		if reload {
+			want = "two"
+		}
+		if rr.Body.String() != want {
+			t.Errorf("reload %v: expected %q but got %q", reload, want, rr.Body.String())
+		}
+	}
+}
+
+func TestRenderer_ErrorPageAndAssets(t *testing.T) {
+	static, err := NewStaticServer(fstest.MapFS{"app.css": {Data: []byte("body{}")}}, "/static/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rd := &Renderer{FS: renderFS, Static: static}
+	tools := New(WithErrorPage(rd.ErrorPage("error")))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept", "text/html")
+	rr := httptest.NewRecorder()
+	tools.WriteError(rr, req, http.StatusNotFound, errors.New("gone"))
+
+	if rr.Code != http.StatusNotFound || !strings.Contains(rr.Body.String(), "<h1>404 gone</h1>") {
+		t.Errorf("expected the rendered error page, got %d %q", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	_ = rd.Render(rr, httptest.NewRequest(http.MethodGet, "/", nil), "assets", nil)
+	if !strings.Contains(rr.Body.String(), static.AssetPath("app.css")) {
+		t.Errorf("expected the fingerprinted asset path, got %q", rr.Body.String())
+	}
+}
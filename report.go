@@ -0,0 +1,611 @@
+package gohelpertools
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ReportFormat is an output format of a Report.
+type ReportFormat string
+
+// The formats a Report can be written in.
+const (
+	ReportJSON ReportFormat = "json"
+	ReportCSV  ReportFormat = "csv"
+	ReportXLSX ReportFormat = "xlsx"
+	ReportPDF  ReportFormat = "pdf"
+)
+
+var reportContentTypes = map[ReportFormat]string{
+	ReportJSON: "application/json",
+	ReportCSV:  "text/csv; charset=utf-8",
+	ReportXLSX: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	ReportPDF:  "application/pdf",
+}
+
+// Aggregate is a column computed over each group of a Report's rows.
+type Aggregate struct {
+	Field string // column aggregated; if empty, count counts every row
+	Func  string // "sum", "avg", "count", "min" or "max"
+	As    string // name of the resulting column; defaults to Func and Field, such as "sum_amount"
+}
+
+func (a Aggregate) name() string {
+	if a.As != "" {
+		return a.As
+	}
+	if a.Field == "" {
+		return a.Func
+	}
+	return a.Func + "_" + a.Field
+}
+
+// Report turns the rows returned by a query into a table, optionally grouped and aggregated, and writes
+// it as JSON, CSV, XLSX or PDF: for example, sales by region, with the sum and average of each order.
+// Serve it with Handler, run it directly with Run or Write, or in the background with Export.
+type Report struct {
+	Title      string                                                                        // shown at the top of PDFs
+	Query      func(ctx context.Context, params map[string]string) ([]map[string]any, error) // returns the rows; required
+	GroupBy    []string                                                                      // columns rows are grouped by; with Aggregates and no GroupBy, the rows are totalled
+	Aggregates []Aggregate                                                                   // columns computed for each group
+	Columns    []string                                                                      // columns written, in order; defaults to GroupBy then Aggregates, or every column sorted by name
+	Tools      *Tools                                                                        // used to write errors and log failures; a zero Tools is used if nil
+}
+
+// ReportResult is the table produced by a Report.
+type ReportResult struct {
+	Columns []string
+	Rows    [][]any
+}
+
+// Run queries and aggregates the report.
+func (rep *Report) Run(ctx context.Context, params map[string]string) (*ReportResult, error) {
+	if rep.Query == nil {
+		return nil, errors.New("report has no query")
+	}
+	for _, a := range rep.Aggregates {
+		switch a.Func {
+		case "sum", "avg", "count", "min", "max":
+		default:
+			return nil, fmt.Errorf("unknown aggregate function %q", a.Func)
+		}
+	}
+
+	rows, err := rep.Query(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	if len(rep.GroupBy) > 0 || len(rep.Aggregates) > 0 {
+		rows = aggregateRows(rows, rep.GroupBy, rep.Aggregates)
+	}
+
+	result := &ReportResult{Columns: rep.columns(rows)}
+	for _, row := range rows {
+		values := make([]any, len(result.Columns))
+		for i, column := range result.Columns {
+			values[i] = row[column]
+		}
+		result.Rows = append(result.Rows, values)
+	}
+	return result, nil
+}
+
+// Write runs the report and writes it to w in format.
+func (rep *Report) Write(ctx context.Context, w io.Writer, format ReportFormat, params map[string]string) error {
+	if _, ok := reportContentTypes[format]; !ok {
+		return fmt.Errorf("unknown report format %q", format)
+	}
+	result, err := rep.Run(ctx, params)
+	if err != nil {
+		return err
+	}
+	switch format {
+	case ReportCSV:
+		return result.writeCSV(w)
+	case ReportXLSX:
+		return result.writeXLSX(w)
+	case ReportPDF:
+		return result.writePDF(w, rep.Title)
+	default:
+		return result.writeJSON(w)
+	}
+}
+
+// Handler returns a handler which serves the report as an attachment named fileName plus the format's
+// extension. The format is taken from the "format" query parameter, or failing that the Accept header,
+// and is JSON by default; the other query parameters are passed to Query.
+func (rep *Report) Handler(fileName string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t := toolsOrDefault(rep.Tools)
+		format := reportFormatFor(r)
+		if _, ok := reportContentTypes[format]; !ok {
+			_ = t.ErrorJSON(w, fmt.Errorf("unknown report format %q", format))
+			return
+		}
+		params := make(map[string]string)
+		for key, values := range r.URL.Query() {
+			if key != "format" && len(values) > 0 {
+				params[key] = values[0]
+			}
+		}
+
+		// Build the report first, so that an error can still be reported to the client as JSON.
+		var out bytes.Buffer
+		if err := rep.Write(r.Context(), &out, format, params); err != nil {
+			t.LogError(r.Context(), "unable to build report", err, "report", fileName)
+			_ = t.ErrorJSON(w, errors.New("unable to build the report"), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", reportContentTypes[format])
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": fileName + "." + string(format)}))
+		_, _ = out.WriteTo(w)
+	})
+}
+
+// Export runs the report on pool and saves it to fsys as name, in the format named by its extension, such
+// as "reports/sales-2024.xlsx". If done is set, it is called when the job has finished, with its error.
+func (rep *Report) Export(pool *WorkerPool, fsys FileSystem, name string, params map[string]string, done func(error)) error {
+	format := ReportFormat(strings.TrimPrefix(strings.ToLower(path.Ext(name)), "."))
+	if _, ok := reportContentTypes[format]; !ok {
+		return fmt.Errorf("unknown report format %q", format)
+	}
+	return pool.Submit(func(ctx context.Context) error {
+		var out bytes.Buffer
+		err := rep.Write(ctx, &out, format, params)
+		if err == nil {
+			err = fsys.Put(ctx, name, &out)
+		}
+		if done != nil {
+			done(err)
+		}
+		return err
+	})
+}
+
+func (rep *Report) columns(rows []map[string]any) []string {
+	if len(rep.Columns) > 0 {
+		return rep.Columns
+	}
+	if len(rep.GroupBy) > 0 || len(rep.Aggregates) > 0 {
+		columns := append([]string(nil), rep.GroupBy...)
+		for _, a := range rep.Aggregates {
+			columns = append(columns, a.name())
+		}
+		return columns
+	}
+
+	seen := make(map[string]bool)
+	var columns []string
+	for _, row := range rows {
+		for key := range row {
+			if !seen[key] {
+				seen[key] = true
+				columns = append(columns, key)
+			}
+		}
+	}
+	sort.Strings(columns)
+	return columns
+}
+
+func reportFormatFor(r *http.Request) ReportFormat {
+	if format := r.URL.Query().Get("format"); format != "" {
+		return ReportFormat(strings.ToLower(format))
+	}
+	accept := strings.ToLower(r.Header.Get("Accept"))
+	for _, format := range []ReportFormat{ReportCSV, ReportXLSX, ReportPDF} {
+		mediaType, _, _ := strings.Cut(reportContentTypes[format], ";")
+		if strings.Contains(accept, mediaType) {
+			return format
+		}
+	}
+	return ReportJSON
+}
+
+// reportGroup accumulates the aggregates of one group.
+type reportGroup struct {
+	row    map[string]any
+	sums   []float64
+	counts []int
+	mins   []float64
+	maxes  []float64
+}
+
+// aggregateRows groups rows by the groupBy columns, in the order each group first appears, and computes
+// aggs for each group. Values which aren't numbers are left out of sum, avg, min and max.
+func aggregateRows(rows []map[string]any, groupBy []string, aggs []Aggregate) []map[string]any {
+	var groups []*reportGroup
+	index := make(map[string]*reportGroup)
+	for _, row := range rows {
+		key := make([]string, len(groupBy))
+		for i, column := range groupBy {
+			key[i] = fmt.Sprint(row[column])
+		}
+		g, ok := index[strings.Join(key, "\x00")]
+		if !ok {
+			g = &reportGroup{
+				row:    make(map[string]any),
+				sums:   make([]float64, len(aggs)),
+				counts: make([]int, len(aggs)),
+				mins:   make([]float64, len(aggs)),
+				maxes:  make([]float64, len(aggs)),
+			}
+			for _, column := range groupBy {
+				g.row[column] = row[column]
+			}
+			index[strings.Join(key, "\x00")] = g
+			groups = append(groups, g)
+		}
+
+		for i, a := range aggs {
+			if a.Func == "count" {
+				if a.Field == "" || row[a.Field] != nil {
+					g.counts[i]++
+				}
+				continue
+			}
+			n, ok := reportNumber(row[a.Field])
+			if !ok {
+				continue
+			}
+			if g.counts[i] == 0 || n < g.mins[i] {
+				g.mins[i] = n
+			}
+			if g.counts[i] == 0 || n > g.maxes[i] {
+				g.maxes[i] = n
+			}
+			g.sums[i] += n
+			g.counts[i]++
+		}
+	}
+
+	out := make([]map[string]any, len(groups))
+	for i, g := range groups {
+		for j, a := range aggs {
+			var value any
+			switch a.Func {
+			case "count":
+				value = g.counts[j]
+			case "sum":
+				value = g.sums[j]
+			case "avg":
+				if g.counts[j] > 0 {
+					value = g.sums[j] / float64(g.counts[j])
+				}
+			case "min":
+				if g.counts[j] > 0 {
+					value = g.mins[j]
+				}
+			case "max":
+				if g.counts[j] > 0 {
+					value = g.maxes[j]
+				}
+			}
+			g.row[a.name()] = value
+		}
+		out[i] = g.row
+	}
+	return out
+}
+
+// reportNumber returns v as a float64, if it's a number or a string holding one.
+func reportNumber(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, !math.IsNaN(n)
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(n), 64)
+		return f, err == nil && !math.IsNaN(f)
+	}
+	return 0, false
+}
+
+// reportCell formats a value for CSV, XLSX and PDF.
+func reportCell(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case time.Time:
+		return v.Format(time.RFC3339)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+// writeJSON writes the rows as an array of objects, with their keys in column order.
+func (res *ReportResult) writeJSON(w io.Writer) error {
+	var out bytes.Buffer
+	out.WriteByte('[')
+	for i, row := range res.Rows {
+		if i > 0 {
+			out.WriteByte(',')
+		}
+		out.WriteByte('{')
+		for j, column := range res.Columns {
+			if j > 0 {
+				out.WriteByte(',')
+			}
+			key, _ := json.Marshal(column)
+			value, err := json.Marshal(row[j])
+			if err != nil {
+				return err
+			}
+			out.Write(key)
+			out.WriteByte(':')
+			out.Write(value)
+		}
+		out.WriteByte('}')
+	}
+	out.WriteByte(']')
+	_, err := out.WriteTo(w)
+	return err
+}
+
+func (res *ReportResult) writeCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvSafeRecord(res.Columns)); err != nil {
+		return err
+	}
+	for _, row := range res.Rows {
+		record := make([]string, len(row))
+		for i, v := range row {
+			record[i] = csvSafe(reportCell(v))
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeXLSX writes the table as a workbook with a single sheet, with numbers in numeric cells.
+func (res *ReportResult) writeXLSX(w io.Writer) error {
+	files := []struct{ name, body string }{
+		{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`},
+		{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`},
+		{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Report" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+		{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`},
+		{"xl/worksheets/sheet1.xml", res.sheetXML()},
+	}
+
+	zw := zip.NewWriter(w)
+	for _, f := range files {
+		fw, err := zw.Create(f.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(fw, f.body); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func (res *ReportResult) sheetXML() string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	b.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+
+	writeRow := func(number int, values []any) {
+		fmt.Fprintf(&b, `<row r="%d">`, number)
+		for i, v := range values {
+			ref := xlsxColumn(i) + strconv.Itoa(number)
+			if n, ok := v.(string); ok || v == nil {
+				fmt.Fprintf(&b, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, xmlEscape(n))
+			} else if f, ok := reportNumber(v); ok && !math.IsInf(f, 0) {
+				fmt.Fprintf(&b, `<c r="%s"><v>%s</v></c>`, ref, strconv.FormatFloat(f, 'g', -1, 64))
+			} else {
+				fmt.Fprintf(&b, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, xmlEscape(reportCell(v)))
+			}
+		}
+		b.WriteString(`</row>`)
+	}
+
+	header := make([]any, len(res.Columns))
+	for i, column := range res.Columns {
+		header[i] = column
+	}
+	writeRow(1, header)
+	for i, row := range res.Rows {
+		writeRow(i+2, row)
+	}
+	b.WriteString(`</sheetData></worksheet>`)
+	return b.String()
+}
+
+// xlsxColumn returns the letters of the zero-based column i, such as "A", "Z" or "AA".
+func xlsxColumn(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+func xmlEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '&':
+			b.WriteString("&amp;")
+		case r == '<':
+			b.WriteString("&lt;")
+		case r == '>':
+			b.WriteString("&gt;")
+		case r == '"':
+			b.WriteString("&quot;")
+		case r < 0x20 && r != '\t' && r != '\n' && r != '\r':
+			// Not allowed in XML 1.0.
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// PDF layout: landscape A4 in points, with the table in 8 point Courier, so columns line up.
+const (
+	pdfPageWidth   = 842
+	pdfPageHeight  = 595
+	pdfMargin      = 36
+	pdfFontSize    = 8
+	pdfLineHeight  = 10
+	pdfMaxColWidth = 40
+)
+
+// writePDF writes the table as a plain PDF, over as many pages as it needs. Columns are cut at 40
+// characters, and characters outside Latin-1 are replaced with "?".
+func (res *ReportResult) writePDF(w io.Writer, title string) error {
+	widths := make([]int, len(res.Columns))
+	cells := make([][]string, len(res.Rows))
+	for i, column := range res.Columns {
+		widths[i] = min(len([]rune(column)), pdfMaxColWidth)
+	}
+	for i, row := range res.Rows {
+		cells[i] = make([]string, len(row))
+		for j, v := range row {
+			cells[i][j] = reportCell(v)
+			widths[j] = min(max(widths[j], len([]rune(cells[i][j]))), pdfMaxColWidth)
+		}
+	}
+	line := func(values []string) string {
+		parts := make([]string, len(values))
+		for i, v := range values {
+			runes := []rune(v)
+			if len(runes) > widths[i] {
+				runes = append(runes[:widths[i]-1], '~')
+			}
+			parts[i] = string(runes) + strings.Repeat(" ", widths[i]-len(runes))
+		}
+		return strings.TrimRight(strings.Join(parts, "  "), " ")
+	}
+
+	var lines []string
+	if title != "" {
+		lines = append(lines, title, "")
+	}
+	header := line(res.Columns)
+	lines = append(lines, header, strings.Repeat("-", len([]rune(header))))
+	for _, row := range cells {
+		lines = append(lines, line(row))
+	}
+
+	maxChars := (pdfPageWidth - 2*pdfMargin) * 10 / (6 * pdfFontSize) // Courier is 0.6 em wide
+	perPage := (pdfPageHeight - 2*pdfMargin) / pdfLineHeight
+	var pages [][]string
+	for len(lines) > 0 {
+		n := min(perPage, len(lines))
+		pages = append(pages, lines[:n])
+		lines = lines[n:]
+	}
+
+	var contents []string
+	for _, page := range pages {
+		var b strings.Builder
+		fmt.Fprintf(&b, "BT /F1 %d Tf %d TL %d %d Td\n", pdfFontSize, pdfLineHeight, pdfMargin, pdfPageHeight-pdfMargin-pdfFontSize)
+		for _, text := range page {
+			if runes := []rune(text); len(runes) > maxChars {
+				text = string(runes[:maxChars])
+			}
+			b.WriteString("(" + pdfString(text) + ") Tj T*\n")
+		}
+		b.WriteString("ET")
+		contents = append(contents, b.String())
+	}
+	return writePDFDocument(w, contents)
+}
+
+// writePDFDocument writes a PDF with one page for each content stream, using Courier as font F1.
+func writePDFDocument(w io.Writer, contents []string) error {
+	// Objects: 1 catalog, 2 pages, 3 font, then a page and its content for each page.
+	var objects []string
+	kids := make([]string, len(contents))
+	for i := range contents {
+		kids[i] = fmt.Sprintf("%d 0 R", 4+2*i)
+	}
+	objects = append(objects,
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(contents)),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>",
+	)
+	for i, content := range contents {
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>", pdfPageWidth, pdfPageHeight, 5+2*i),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content),
+		)
+	}
+
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	_, err := b.WriteTo(w)
+	return err
+}
+
+// pdfString escapes s for a PDF literal string in WinAnsiEncoding.
+func pdfString(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 0x20:
+			b.WriteByte(' ')
+		case r < 0x80:
+			b.WriteRune(r)
+		case r >= 0xA0 && r <= 0xFF:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
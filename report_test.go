@@ -0,0 +1,184 @@
+package gohelpertools
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func salesReport() *Report {
+	return &Report{
+		Title: "Sales by region",
+		Query: func(ctx context.Context, params map[string]string) ([]map[string]any, error) {
+			if params["fail"] != "" {
+				return nil, errors.New("query failed")
+			}
+			return []map[string]any{
+				{"region": "north", "amount": 10, "customer": "a"},
+				{"region": "south", "amount": 5.5, "customer": "b"},
+				{"region": "north", "amount": json.Number("20"), "customer": "c"},
+				{"region": "south", "amount": "n/a", "customer": "d"},
+			}, nil
+		},
+		GroupBy: []string{"region"},
+		Aggregates: []Aggregate{
+			{Func: "count", As: "orders"},
+			{Field: "amount", Func: "sum"},
+			{Field: "amount", Func: "avg"},
+			{Field: "amount", Func: "max"},
+		},
+	}
+}
+
+func TestReport_Run(t *testing.T) {
+	result, err := salesReport().Run(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(result.Columns, ",") != "region,orders,sum_amount,avg_amount,max_amount" {
+		t.Errorf("unexpected columns %v", result.Columns)
+	}
+	expected := [][]any{
+		{"north", 2, 30.0, 15.0, 20.0},
+		{"south", 2, 5.5, 5.5, 5.5},
+	}
+	if len(result.Rows) != len(expected) {
+		t.Fatalf("unexpected rows %v", result.Rows)
+	}
+	for i, row := range expected {
+		for j, v := range row {
+			if result.Rows[i][j] != v {
+				t.Errorf("row %d column %s: expected %v, got %v", i, result.Columns[j], v, result.Rows[i][j])
+			}
+		}
+	}
+
+	totals := &Report{Query: salesReport().Query, Aggregates: []Aggregate{{Field: "amount", Func: "sum", As: "total"}}}
+	if result, _ := totals.Run(context.Background(), nil); len(result.Rows) != 1 || result.Rows[0][0] != 35.5 {
+		t.Errorf("unexpected totals %v", result.Rows)
+	}
+
+	plain := &Report{Query: salesReport().Query}
+	if result, _ := plain.Run(context.Background(), nil); strings.Join(result.Columns, ",") != "amount,customer,region" || len(result.Rows) != 4 {
+		t.Errorf("unexpected plain report %v %v", result.Columns, result.Rows)
+	}
+
+	if _, err := (&Report{Query: plain.Query, Aggregates: []Aggregate{{Func: "median"}}}).Run(context.Background(), nil); err == nil {
+		t.Error("expected an unknown aggregate to be rejected")
+	}
+}
+
+func TestReport_Formats(t *testing.T) {
+	report := salesReport()
+	ctx := context.Background()
+
+	var out bytes.Buffer
+	if err := report.Write(ctx, &out, ReportJSON, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(out.String(), `[{"region":"north","orders":2,"sum_amount":30,`) {
+		t.Errorf("unexpected JSON %s", out.String())
+	}
+
+	out.Reset()
+	if err := report.Write(ctx, &out, ReportCSV, nil); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "region,orders,sum_amount,avg_amount,max_amount\nnorth,2,30,15,20\nsouth,2,5.5,5.5,5.5\n" {
+		t.Errorf("unexpected CSV %q", out.String())
+	}
+
+	out.Reset()
+	if err := report.Write(ctx, &out, ReportXLSX, nil); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(out.Bytes()), int64(out.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range zr.File {
+		if f.Name != "xl/worksheets/sheet1.xml" {
+			continue
+		}
+		rc, _ := f.Open()
+		sheet, _ := io.ReadAll(rc)
+		rc.Close()
+		if !strings.Contains(string(sheet), `<c r="A2" t="inlineStr"><is><t xml:space="preserve">north</t></is></c><c r="B2"><v>2</v></c>`) {
+			t.Errorf("unexpected sheet %s", sheet)
+		}
+	}
+	if contentType, _ := (&Tools{}).DetectContentType(bytes.NewReader(out.Bytes())); contentType != reportContentTypes[ReportXLSX] {
+		t.Errorf("expected the workbook to be detected as XLSX, got %s", contentType)
+	}
+
+	out.Reset()
+	if err := report.Write(ctx, &out, ReportPDF, nil); err != nil {
+		t.Fatal(err)
+	}
+	text, err := (&Tools{}).ExtractPDFText(bytes.NewReader(out.Bytes()))
+	if err != nil || !strings.Contains(text, "Sales by region") || !strings.Contains(text, "north") {
+		t.Errorf("unexpected PDF text %q: %v", text, err)
+	}
+
+	if err := report.Write(ctx, &out, "docx", nil); err == nil {
+		t.Error("expected an unknown format to be rejected")
+	}
+}
+
+func TestReport_Handler(t *testing.T) {
+	handler := salesReport().Handler("sales")
+
+	tests := []struct {
+		target      string
+		accept      string
+		status      int
+		contentType string
+	}{
+		{"/sales", "", http.StatusOK, "application/json"},
+		{"/sales?format=csv", "", http.StatusOK, "text/csv; charset=utf-8"},
+		{"/sales", "application/pdf", http.StatusOK, "application/pdf"},
+		{"/sales?format=doc", "", http.StatusBadRequest, "application/json"},
+		{"/sales?fail=1", "", http.StatusInternalServerError, "application/json"},
+	}
+	for _, e := range tests {
+		req := httptest.NewRequest(http.MethodGet, e.target, nil)
+		req.Header.Set("Accept", e.accept)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		if rr.Code != e.status || rr.Header().Get("Content-Type") != e.contentType {
+			t.Errorf("%s: unexpected response %d %s", e.target, rr.Code, rr.Header().Get("Content-Type"))
+		}
+	}
+}
+
+func TestReport_Export(t *testing.T) {
+	pool := &WorkerPool{}
+	fsys := &MemoryFileSystem{}
+	done := make(chan error, 1)
+	if err := salesReport().Export(pool, fsys, "reports/sales.csv", nil, func(err error) { done <- err }); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	_ = pool.Close(context.Background())
+
+	f, err := fsys.Get(context.Background(), "reports/sales.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(f)
+	if !strings.HasPrefix(string(data), "region,orders") {
+		t.Errorf("unexpected export %q", data)
+	}
+	if err := salesReport().Export(pool, fsys, "sales.txt", nil, nil); err == nil {
+		t.Error("expected an unknown format to be rejected")
+	}
+}
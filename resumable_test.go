@@ -0,0 +1,191 @@
+package gohelpertools
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func tusRequest(method, target, body string, headers map[string]string) *http.Request {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Tus-Resumable", "1.0.0")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	return req
+}
+
+func TestResumableUploads(t *testing.T) {
+	stores := map[string]ResumableUploadStore{
+		"file":   &FileResumableStore{Dir: t.TempDir()},
+		"memory": NewMemoryResumableStore(),
+	}
+
+	for name, store := range stores {
+		fsys := &MemoryFileSystem{}
+		var completed ResumableUpload
+		uploads := &ResumableUploads{
+			Store:      store,
+			MaxSize:    100,
+			FileSystem: fsys,
+			OnComplete: func(ctx context.Context, upload ResumableUpload) error {
+				completed = upload
+				return nil
+			},
+		}
+		handler := uploads.Handler()
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, tusRequest(http.MethodPost, "/files/", "", map[string]string{
+			"Upload-Length":   "11",
+			"Upload-Metadata": "filename aGVsbG8udHh0,private",
+		}))
+		location := rr.Header().Get("Location")
+		if rr.Code != http.StatusCreated || !strings.HasPrefix(location, "/files/") {
+			t.Fatalf("%s: unexpected create response %d %q", name, rr.Code, location)
+		}
+
+		patch := func(offset, body string) *httptest.ResponseRecorder {
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, tusRequest(http.MethodPatch, location, body, map[string]string{
+				"Content-Type":  "application/offset+octet-stream",
+				"Upload-Offset": offset,
+			}))
+			return rr
+		}
+
+		if rr := patch("0", "hello "); rr.Code != http.StatusNoContent || rr.Header().Get("Upload-Offset") != "6" {
+			t.Errorf("%s: unexpected first chunk response %d %s", name, rr.Code, rr.Header().Get("Upload-Offset"))
+		}
+		if rr := patch("3", "lo wo"); rr.Code != http.StatusConflict {
+			t.Errorf("%s: expected a wrong offset to conflict, got %d", name, rr.Code)
+		}
+
+		rr = httptest.NewRecorder()
+		handler.ServeHTTP(rr, tusRequest(http.MethodHead, location, "", nil))
+		if rr.Header().Get("Upload-Offset") != "6" || rr.Header().Get("Upload-Length") != "11" ||
+			rr.Header().Get("Upload-Metadata") != "filename aGVsbG8udHh0,private" {
+			t.Errorf("%s: unexpected status %v", name, rr.Header())
+		}
+
+		if rr := patch("6", "world and more"); rr.Code != http.StatusRequestEntityTooLarge {
+			t.Errorf("%s: expected a chunk beyond the length to be rejected, got %d", name, rr.Code)
+		}
+		if rr := patch("6", "world"); rr.Code != http.StatusNoContent {
+			t.Errorf("%s: unexpected last chunk response %d: %s", name, rr.Code, rr.Body)
+		}
+
+		id := strings.TrimPrefix(location, "/files/")
+		if completed.ID != id || completed.Metadata["filename"] != "hello.txt" {
+			t.Errorf("%s: OnComplete not called with the upload: %+v", name, completed)
+		}
+		f, err := fsys.Get(context.Background(), id)
+		if err != nil {
+			t.Fatalf("%s: completed upload not saved: %s", name, err)
+		}
+		data, _ := io.ReadAll(f)
+		if string(data) != "hello world" {
+			t.Errorf("%s: unexpected content %q", name, data)
+		}
+		if _, err := store.Info(context.Background(), id); err != ErrUploadNotFound {
+			t.Errorf("%s: expected the completed upload to be removed from the store, got %v", name, err)
+		}
+	}
+}
+
+func TestResumableUploads_Errors(t *testing.T) {
+	store := NewMemoryResumableStore()
+	uploads := &ResumableUploads{Store: store, MaxSize: 10}
+	handler := uploads.Handler()
+
+	_ = store.Create(context.Background(), ResumableUpload{ID: "expired", Size: 5, Expires: time.Now().Add(-time.Minute)})
+	_ = store.Create(context.Background(), ResumableUpload{ID: "live", Size: 5, Expires: time.Now().Add(time.Hour)})
+
+	tests := []struct {
+		name   string
+		req    *http.Request
+		status int
+	}{
+		{"options", httptest.NewRequest(http.MethodOptions, "/files/", nil), http.StatusNoContent},
+		{"no version", httptest.NewRequest(http.MethodPost, "/files/", nil), http.StatusPreconditionFailed},
+		{"too big", tusRequest(http.MethodPost, "/files/", "", map[string]string{"Upload-Length": "11"}), http.StatusRequestEntityTooLarge},
+		{"no length", tusRequest(http.MethodPost, "/files/", "", nil), http.StatusBadRequest},
+		{"bad metadata", tusRequest(http.MethodPost, "/files/", "", map[string]string{"Upload-Length": "1", "Upload-Metadata": "name !!"}), http.StatusBadRequest},
+		{"missing", tusRequest(http.MethodHead, "/files/nope", "", nil), http.StatusNotFound},
+		{"expired", tusRequest(http.MethodHead, "/files/expired", "", nil), http.StatusNotFound},
+		{"wrong content type", tusRequest(http.MethodPatch, "/files/live", "abc", map[string]string{"Upload-Offset": "0"}), http.StatusUnsupportedMediaType},
+		{"terminate", tusRequest(http.MethodDelete, "/files/live", "", nil), http.StatusNoContent},
+		{"terminated", tusRequest(http.MethodHead, "/files/live", "", nil), http.StatusNotFound},
+	}
+	for _, e := range tests {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, e.req)
+		if rr.Code != e.status {
+			t.Errorf("%s: expected status %d, got %d", e.name, e.status, rr.Code)
+		}
+	}
+}
+
+func TestResumableUploads_RetryCompletion(t *testing.T) {
+	for _, retry := range []string{http.MethodHead, http.MethodPatch} {
+		store := NewMemoryResumableStore()
+		failures, completions := 1, 0
+		uploads := &ResumableUploads{
+			Store: store,
+			Tools: &Tools{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))},
+			OnComplete: func(ctx context.Context, upload ResumableUpload) error {
+				if failures > 0 {
+					failures--
+					return errors.New("backend down")
+				}
+				completions++
+				return nil
+			},
+		}
+		handler := uploads.Handler()
+		_ = store.Create(context.Background(), ResumableUpload{ID: "up", Size: 5, Expires: time.Now().Add(time.Hour)})
+		patchHeaders := map[string]string{"Content-Type": "application/offset+octet-stream", "Upload-Offset": "0"}
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, tusRequest(http.MethodPatch, "/files/up", "hello", patchHeaders))
+		if rr.Code != http.StatusInternalServerError {
+			t.Fatalf("%s: expected the failed completion to be reported, got %d", retry, rr.Code)
+		}
+
+		rr = httptest.NewRecorder()
+		patchHeaders["Upload-Offset"] = "5"
+		handler.ServeHTTP(rr, tusRequest(retry, "/files/up", "", patchHeaders))
+		if rr.Code >= 300 || completions != 1 {
+			t.Errorf("%s: expected completing the upload to be retried, got %d and %d completions", retry, rr.Code, completions)
+		}
+
+		rr = httptest.NewRecorder()
+		handler.ServeHTTP(rr, tusRequest(http.MethodHead, "/files/up", "", nil))
+		if rr.Code != http.StatusNotFound || completions != 1 {
+			t.Errorf("%s: expected the completed upload to be removed, got %d and %d completions", retry, rr.Code, completions)
+		}
+	}
+}
+
+func TestFileResumableStore_RemoveExpired(t *testing.T) {
+	ctx := context.Background()
+	store := &FileResumableStore{Dir: t.TempDir()}
+	_ = store.Create(ctx, ResumableUpload{ID: "old", Size: 1, Expires: time.Now().Add(-time.Minute)})
+	_ = store.Create(ctx, ResumableUpload{ID: "new", Size: 1, Expires: time.Now().Add(time.Minute)})
+
+	if removed, err := store.RemoveExpired(ctx); removed != 1 || err != nil {
+		t.Errorf("expected one upload to be removed, got %d: %v", removed, err)
+	}
+	if _, err := store.Info(ctx, "new"); err != nil {
+		t.Errorf("expected the live upload to be kept: %v", err)
+	}
+	if _, err := store.Info(ctx, "../old"); err != ErrUploadNotFound {
+		t.Errorf("expected an invalid ID to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,151 @@
+package gohelpertools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const defaultRetentionBatchSize = 500
+
+// RetentionPolicy says how long one kind of record is kept, and how to find and purge expired records.
+type RetentionPolicy struct {
+	Name      string        // identifies the policy in results and audit records; required
+	MaxAge    time.Duration // records older than this are purged; required
+	BatchSize int           // records found and purged at a time; defaults to 500
+
+	// Find returns the IDs of up to limit records created before cutoff which should be purged, in
+	// ascending order and after the ID after (empty for the first batch). Any further conditions, such as
+	// "and the account is closed", belong here.
+	Find func(ctx context.Context, cutoff time.Time, after string, limit int) ([]string, error)
+
+	// Purge deletes or anonymizes the records with ids.
+	Purge func(ctx context.Context, ids []string) error
+}
+
+// RetentionResult is what one policy purged (or, in a dry run, would have purged) in one run.
+type RetentionResult struct {
+	Policy string    `json:"policy"`
+	Cutoff time.Time `json:"cutoff"`
+	DryRun bool      `json:"dry_run"`
+	IDs    []string  `json:"ids"`
+	Error  string    `json:"error,omitempty"`
+}
+
+// Retention enforces data retention promises in code. Stores register purge policies, and each Run finds
+// and purges expired records in batches, reporting exactly which records were purged to the Audit hook.
+// With DryRun set, records are found and reported but not purged, to check a new policy safely.
+type Retention struct {
+	DryRun bool                  // if set to true, nothing is purged
+	Audit  func(RetentionResult) // if set, called with every policy's result; otherwise a summary is logged
+	Tools  *Tools                // used to log results and errors; a zero Tools is used if nil
+
+	mu       sync.Mutex
+	policies []RetentionPolicy
+	now      func() time.Time
+}
+
+// Register adds policy.
+func (rt *Retention) Register(policy RetentionPolicy) error {
+	if policy.Name == "" || policy.MaxAge <= 0 || policy.Find == nil || policy.Purge == nil {
+		return errors.New("a retention policy needs a name, a positive MaxAge, Find and Purge")
+	}
+
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	rt.policies = append(rt.policies, policy)
+	return nil
+}
+
+// Run applies every policy once, and returns their results. A failing policy doesn't stop the others;
+// the returned error lists the policies which failed.
+func (rt *Retention) Run(ctx context.Context) ([]RetentionResult, error) {
+	rt.mu.Lock()
+	policies := append([]RetentionPolicy(nil), rt.policies...)
+	now := time.Now
+	if rt.now != nil {
+		now = rt.now
+	}
+	rt.mu.Unlock()
+
+	var results []RetentionResult
+	var failed []string
+	for _, policy := range policies {
+		result := rt.apply(ctx, policy, now().Add(-policy.MaxAge))
+		if result.Error != "" {
+			failed = append(failed, policy.Name)
+		}
+
+		if rt.Audit != nil {
+			rt.Audit(result)
+		} else {
+			toolsOrDefault(rt.Tools).LogInfo(ctx, "retention policy applied", "policy", result.Policy,
+				"purged", len(result.IDs), "cutoff", result.Cutoff, "dry_run", result.DryRun, "error", result.Error)
+		}
+		results = append(results, result)
+	}
+
+	if len(failed) > 0 {
+		return results, fmt.Errorf("retention policies failed: %v", failed)
+	}
+	return results, nil
+}
+
+// Start calls Run every interval until ctx is cancelled. It is meant to be run in its own goroutine, or
+// Run can be called from an existing scheduler instead.
+func (rt *Retention) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := rt.Run(ctx); err != nil {
+				toolsOrDefault(rt.Tools).LogError(ctx, "retention run failed", err)
+			}
+		}
+	}
+}
+
+func (rt *Retention) apply(ctx context.Context, policy RetentionPolicy, cutoff time.Time) RetentionResult {
+	result := RetentionResult{Policy: policy.Name, Cutoff: cutoff, DryRun: rt.DryRun, IDs: []string{}}
+
+	batchSize := policy.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultRetentionBatchSize
+	}
+
+	after := ""
+	for {
+		if err := ctx.Err(); err != nil {
+			result.Error = err.Error()
+			return result
+		}
+
+		ids, err := policy.Find(ctx, cutoff, after, batchSize)
+		if err != nil {
+			result.Error = err.Error()
+			return result
+		}
+		if len(ids) == 0 {
+			return result
+		}
+
+		if !rt.DryRun {
+			if err := policy.Purge(ctx, ids); err != nil {
+				result.Error = err.Error()
+				return result
+			}
+		}
+		result.IDs = append(result.IDs, ids...)
+
+		if len(ids) < batchSize {
+			return result
+		}
+		after = ids[len(ids)-1]
+	}
+}
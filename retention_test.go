@@ -0,0 +1,118 @@
+package gohelpertools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+)
+
+// retentionTable is a fake store of records with creation times.
+type retentionTable struct {
+	created map[string]time.Time
+	batches int
+}
+
+func (tb *retentionTable) find(ctx context.Context, cutoff time.Time, after string, limit int) ([]string, error) {
+	var ids []string
+	for id, created := range tb.created {
+		if created.Before(cutoff) && id > after {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+	return ids, nil
+}
+
+func (tb *retentionTable) purge(ctx context.Context, ids []string) error {
+	tb.batches++
+	for _, id := range ids {
+		delete(tb.created, id)
+	}
+	return nil
+}
+
+func newRetentionTable(now time.Time) *retentionTable {
+	tb := &retentionTable{created: make(map[string]time.Time)}
+	for i := 0; i < 7; i++ {
+		tb.created[fmt.Sprintf("old-%d", i)] = now.Add(-48 * time.Hour)
+	}
+	tb.created["new"] = now
+	return tb
+}
+
+func TestRetention_Run(t *testing.T) {
+	now := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+
+	for _, dryRun := range []bool{true, false} {
+		table := newRetentionTable(now)
+		var audited []RetentionResult
+		rt := &Retention{DryRun: dryRun, Audit: func(r RetentionResult) { audited = append(audited, r) }, now: func() time.Time { return now }}
+		_ = rt.Register(RetentionPolicy{Name: "logs", MaxAge: 24 * time.Hour, BatchSize: 3, Find: table.find, Purge: table.purge})
+
+		results, err := rt.Run(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results) != 1 || len(results[0].IDs) != 7 || results[0].DryRun != dryRun {
+			t.Errorf("dry run %v: unexpected results %+v", dryRun, results)
+		}
+		if len(audited) != 1 {
+			t.Errorf("dry run %v: expected one audit record, got %d", dryRun, len(audited))
+		}
+
+		wantLeft, wantBatches := 8, 0
+		if !dryRun {
+			wantLeft, wantBatches = 1, 3
+		}
+		if len(table.created) != wantLeft || table.batches != wantBatches {
+			t.Errorf("dry run %v: expected %d records left after %d batches, got %d after %d",
+				dryRun, wantLeft, wantBatches, len(table.created), table.batches)
+		}
+	}
+}
+
+func TestRetention_Errors(t *testing.T) {
+	var rt Retention
+	if err := rt.Register(RetentionPolicy{Name: "incomplete"}); err == nil {
+		t.Error("incomplete policy: error expected, but none received")
+	}
+
+	purged := false
+	_ = rt.Register(RetentionPolicy{
+		Name:   "broken",
+		MaxAge: time.Hour,
+		Find: func(context.Context, time.Time, string, int) ([]string, error) {
+			return nil, errors.New("database unavailable")
+		},
+		Purge: func(context.Context, []string) error { return nil },
+	})
+	_ = rt.Register(RetentionPolicy{
+		Name:   "working",
+		MaxAge: time.Hour,
+		Find: func(ctx context.Context, cutoff time.Time, after string, limit int) ([]string, error) {
+			if after != "" || purged {
+				return nil, nil
+			}
+			return []string{"a"}, nil
+		},
+		Purge: func(context.Context, []string) error {
+			purged = true
+			return nil
+		},
+	})
+	rt.Audit = func(RetentionResult) {}
+
+	results, err := rt.Run(context.Background())
+	if err == nil {
+		t.Error("failing policy: error expected, but none received")
+	}
+	if len(results) != 2 || results[0].Error == "" || !purged {
+		t.Errorf("expected the working policy to run despite the failure, got %+v", results)
+	}
+}
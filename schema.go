@@ -0,0 +1,353 @@
+package gohelpertools
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Schema is the subset of JSON Schema (draft 2020-12) used by this package. InferSchema only produces the
+// structural keywords; the others are used by Validate. References may only point to the schema itself
+// ("#") or to one of its $defs ("#/$defs/name").
+type Schema struct {
+	SchemaURI  string             `json:"$schema,omitempty"`
+	Type       SchemaType         `json:"type,omitempty"`
+	Properties map[string]*Schema `json:"properties,omitempty"`
+	Required   []string           `json:"required,omitempty"`
+	Items      *Schema            `json:"items,omitempty"`
+
+	Ref                  string             `json:"$ref,omitempty"`
+	Defs                 map[string]*Schema `json:"$defs,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Enum                 []json.RawMessage  `json:"enum,omitempty"`
+	Const                json.RawMessage    `json:"const,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	ExclusiveMinimum     *float64           `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum     *float64           `json:"exclusiveMaximum,omitempty"`
+	MultipleOf           *float64           `json:"multipleOf,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Format               string             `json:"format,omitempty"` // date-time, date, email, uuid, uri, ipv4 and ipv6 are checked
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	UniqueItems          bool               `json:"uniqueItems,omitempty"`
+	MinProperties        *int               `json:"minProperties,omitempty"`
+	MaxProperties        *int               `json:"maxProperties,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+	Not                  *Schema            `json:"not,omitempty"`
+}
+
+// UnmarshalJSON accepts the boolean schemas true, which allows anything, and false, which allows nothing,
+// as well as schema objects.
+func (s *Schema) UnmarshalJSON(data []byte) error {
+	switch string(bytes.TrimSpace(data)) {
+	case "true":
+		*s = Schema{}
+		return nil
+	case "false":
+		*s = Schema{Not: &Schema{}}
+		return nil
+	}
+
+	type plain Schema
+	return json.Unmarshal(data, (*plain)(s))
+}
+
+// SchemaType is the value of the "type" keyword, which may be a single type or a list of types.
+type SchemaType []string
+
+// MarshalJSON writes a single type as a plain string, and several types as an array.
+func (st SchemaType) MarshalJSON() ([]byte, error) {
+	if len(st) == 1 {
+		return json.Marshal(st[0])
+	}
+	return json.Marshal([]string(st))
+}
+
+// UnmarshalJSON accepts either a string or an array of strings.
+func (st *SchemaType) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*st = SchemaType{single}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return errors.New("schema type must be a string or an array of strings")
+	}
+	*st = list
+	return nil
+}
+
+// has reports whether t is one of the types in st.
+func (st SchemaType) has(t string) bool {
+	for _, v := range st {
+		if v == t {
+			return true
+		}
+	}
+	return false
+}
+
+// InferSchema produces a JSON Schema describing the example JSON payloads in samples. Properties that
+// appear in every sample are marked as required, integers and decimals seen for the same field widen to
+// "number", and fields which are sometimes null become nullable. This is useful as a starting point when
+// integrating third-party webhooks with poor documentation.
+func (t *Tools) InferSchema(samples ...[]byte) (*Schema, error) {
+	if len(samples) == 0 {
+		return nil, errors.New("at least one sample is required")
+	}
+
+	var schema *Schema
+	for i, sample := range samples {
+		dec := json.NewDecoder(bytes.NewReader(sample))
+		dec.UseNumber()
+
+		var value any
+		if err := dec.Decode(&value); err != nil {
+			return nil, fmt.Errorf("sample %d is not valid json: %s", i+1, err.Error())
+		}
+
+		schema = mergeSchemas(schema, inferValue(value))
+	}
+
+	schema.SchemaURI = "https://json-schema.org/draft/2020-12/schema"
+	return schema, nil
+}
+
+// GoStruct returns Go source code declaring a struct type called name which matches the schema, with
+// nested objects declared as their own types. The schema must describe an object.
+func (s *Schema) GoStruct(name string) (string, error) {
+	if !s.Type.has("object") {
+		return "", errors.New("only object schemas can be converted to a struct")
+	}
+
+	var buf bytes.Buffer
+	declared := make(map[string]bool)
+	writeGoStruct(&buf, exportedGoName(name), s, declared)
+
+	out, err := format.Source(buf.Bytes())
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// inferValue returns the schema for a single decoded JSON value.
+func inferValue(value any) *Schema {
+	switch v := value.(type) {
+	case map[string]any:
+		s := &Schema{Type: SchemaType{"object"}, Properties: make(map[string]*Schema)}
+		for key, child := range v {
+			s.Properties[key] = inferValue(child)
+			s.Required = append(s.Required, key)
+		}
+		sort.Strings(s.Required)
+		return s
+
+	case []any:
+		s := &Schema{Type: SchemaType{"array"}}
+		for _, item := range v {
+			s.Items = mergeSchemas(s.Items, inferValue(item))
+		}
+		return s
+
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return &Schema{Type: SchemaType{"integer"}}
+		}
+		return &Schema{Type: SchemaType{"number"}}
+
+	case string:
+		return &Schema{Type: SchemaType{"string"}}
+
+	case bool:
+		return &Schema{Type: SchemaType{"boolean"}}
+
+	default:
+		return &Schema{Type: SchemaType{"null"}}
+	}
+}
+
+// mergeSchemas combines two inferred schemas into one which accepts values matching either.
+func mergeSchemas(a, b *Schema) *Schema {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+
+	merged := &Schema{}
+	for _, t := range append(append(SchemaType{}, a.Type...), b.Type...) {
+		if !merged.Type.has(t) {
+			merged.Type = append(merged.Type, t)
+		}
+	}
+
+	// An integer in one sample and a decimal in another is just a number.
+	if merged.Type.has("integer") && merged.Type.has("number") {
+		var types SchemaType
+		for _, t := range merged.Type {
+			if t != "integer" {
+				types = append(types, t)
+			}
+		}
+		merged.Type = types
+	}
+	sort.Strings(merged.Type)
+
+	if a.Properties != nil || b.Properties != nil {
+		merged.Properties = make(map[string]*Schema)
+		for key, s := range a.Properties {
+			merged.Properties[key] = s
+		}
+		for key, s := range b.Properties {
+			merged.Properties[key] = mergeSchemas(merged.Properties[key], s)
+		}
+
+		// Only properties required by both schemas remain required. A schema without properties (for
+		// example, null) doesn't remove any requirements.
+		switch {
+		case a.Properties == nil:
+			merged.Required = b.Required
+		case b.Properties == nil:
+			merged.Required = a.Required
+		default:
+			for _, key := range a.Required {
+				for _, other := range b.Required {
+					if key == other {
+						merged.Required = append(merged.Required, key)
+					}
+				}
+			}
+		}
+	}
+
+	merged.Items = mergeSchemas(a.Items, b.Items)
+	return merged
+}
+
+// writeGoStruct writes the declaration of a struct called name for s, followed by any nested types.
+func writeGoStruct(buf *bytes.Buffer, name string, s *Schema, declared map[string]bool) {
+	declared[name] = true
+
+	keys := make([]string, 0, len(s.Properties))
+	for key := range s.Properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	type nested struct {
+		name   string
+		schema *Schema
+	}
+	var pending []nested
+
+	fmt.Fprintf(buf, "type %s struct {\n", name)
+	for _, key := range keys {
+		field := exportedGoName(key)
+		goType, child, childName := goTypeFor(name+field, s.Properties[key])
+		if child != nil && !declared[childName] {
+			pending = append(pending, nested{name: childName, schema: child})
+			declared[childName] = true
+		}
+
+		tag := key
+		if !contains(s.Required, key) {
+			tag += ",omitempty"
+		}
+		fmt.Fprintf(buf, "%s %s `json:%q`\n", field, goType, tag)
+	}
+	buf.WriteString("}\n\n")
+
+	for _, n := range pending {
+		writeGoStruct(buf, n.name, n.schema, declared)
+	}
+}
+
+// goTypeFor returns the Go type for s. If s (or its array items) is an object, the object schema and the
+// name of the struct type to declare for it are returned too.
+func goTypeFor(name string, s *Schema) (string, *Schema, string) {
+	var types SchemaType
+	for _, t := range s.Type {
+		if t != "null" {
+			types = append(types, t)
+		}
+	}
+	nullable := len(types) < len(s.Type)
+
+	if len(types) != 1 {
+		return "any", nil, ""
+	}
+
+	var goType string
+	var child *Schema
+	childName := ""
+	switch types[0] {
+	case "string":
+		goType = "string"
+	case "integer":
+		goType = "int64"
+	case "number":
+		goType = "float64"
+	case "boolean":
+		goType = "bool"
+	case "object":
+		goType, child, childName = name, s, name
+	case "array":
+		if s.Items == nil {
+			return "[]any", nil, ""
+		}
+		item, itemChild, itemName := goTypeFor(name+"Item", s.Items)
+		return "[]" + item, itemChild, itemName
+	}
+
+	if nullable {
+		goType = "*" + goType
+	}
+	return goType, child, childName
+}
+
+// exportedGoName converts a JSON key such as "user_id" or "created-at" into an exported Go identifier.
+func exportedGoName(key string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range key {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+
+	name := b.String()
+	if name == "" || unicode.IsDigit([]rune(name)[0]) {
+		name = "Field" + name
+	}
+	return name
+}
+
+// contains reports whether list contains s.
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+package gohelpertools
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTools_InferSchema(t *testing.T) {
+	var testTools Tools
+
+	schema, err := testTools.InferSchema(
+		[]byte(`{"id": 1, "name": "a", "amount": 10, "meta": {"tags": ["x"]}, "note": null}`),
+		[]byte(`{"id": 2, "amount": 10.5, "meta": {"tags": []}, "note": "hi"}`),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedTypes := map[string]SchemaType{
+		"id":     {"integer"},
+		"name":   {"string"},
+		"amount": {"number"},
+		"meta":   {"object"},
+		"note":   {"null", "string"},
+	}
+	for key, expected := range expectedTypes {
+		if !reflect.DeepEqual(schema.Properties[key].Type, expected) {
+			t.Errorf("%s: expected type %v but got %v", key, expected, schema.Properties[key].Type)
+		}
+	}
+
+	if !reflect.DeepEqual(schema.Required, []string{"amount", "id", "meta", "note"}) {
+		t.Errorf("wrong required properties: %v", schema.Required)
+	}
+
+	if schema.Properties["meta"].Properties["tags"].Items.Type[0] != "string" {
+		t.Error("array item type not inferred")
+	}
+
+	out, _ := json.Marshal(schema.Properties["id"])
+	if string(out) != `{"type":"integer"}` {
+		t.Errorf("single types should marshal as a string, got %s", out)
+	}
+
+	if _, err := testTools.InferSchema([]byte(`{`)); err == nil {
+		t.Error("expected error for invalid sample, but none received")
+	}
+}
+
+func TestSchema_GoStruct(t *testing.T) {
+	var testTools Tools
+
+	schema, _ := testTools.InferSchema(
+		[]byte(`{"user_id": 1, "profile": {"display-name": "a"}, "items": [{"sku": "x"}], "note": null}`),
+		[]byte(`{"user_id": 2, "profile": {"display-name": "b"}, "items": [], "note": "y", "extra": true}`),
+	)
+
+	code, err := schema.GoStruct("webhook")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Compare with whitespace collapsed, so that gofmt's field alignment doesn't matter.
+	normalized := strings.Join(strings.Fields(code), " ")
+	for _, expected := range []string{
+		"type Webhook struct",
+		"UserId int64 `json:\"user_id\"`",
+		"Extra bool `json:\"extra,omitempty\"`",
+		"Note *string `json:\"note\"`",
+		"Items []WebhookItemsItem `json:\"items\"`",
+		"type WebhookProfile struct",
+		"DisplayName string `json:\"display-name\"`",
+		"type WebhookItemsItem struct",
+	} {
+		if !strings.Contains(normalized, expected) {
+			t.Errorf("generated code is missing %q:\n%s", expected, code)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package gohelpertools
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrSequenceReleased is returned by Commit for a reservation which was already committed or released.
+var ErrSequenceReleased = errors.New("sequence reservation already committed or released")
+
+// SequenceStore keeps the last number allocated for each key of a SequentialAllocator. Lock must exclude
+// every other caller of Lock for the same key, in all instances of the service, until the returned unlock
+// is called: in a database, for example, by starting a transaction and selecting the key's row FOR
+// UPDATE, with Set writing to it in that transaction, and unlock committing it if commit is true or
+// rolling it back otherwise.
+type SequenceStore interface {
+	// Lock waits until it holds the lock for key, or ctx is done. The number set while holding it is kept
+	// only if unlock is called with commit set to true and returns no error.
+	Lock(ctx context.Context, key string) (unlock func(commit bool) error, err error)
+	// Get returns the last number allocated for key, or 0 if there is none. The caller holds the lock.
+	Get(ctx context.Context, key string) (int64, error)
+	// Set records n as the last number allocated for key. The caller holds the lock.
+	Set(ctx context.Context, key string, n int64) error
+}
+
+// SequentialAllocator hands out increasing numbers for each key, such as "invoice" or "order-2024", for
+// numbers people read and auditors check, where random IDs won't do.
+//
+// Next is quick, but a number is used up even if whatever it was for then fails, which leaves a gap.
+// Where the law requires gapless numbering, as for invoices in many countries, use Reserve: it holds the
+// key until the number is committed or released, so a released number is given to the next caller. That
+// makes allocations for one key take turns, so keep the work between Reserve and Commit short.
+type SequentialAllocator struct {
+	Store SequenceStore // where the numbers are kept; required
+	Start int64         // first number of each key; defaults to 1
+}
+
+// Next allocates the next number for key.
+func (a *SequentialAllocator) Next(ctx context.Context, key string) (int64, error) {
+	reservation, err := a.Reserve(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	defer reservation.Release()
+	if err := reservation.Commit(ctx); err != nil {
+		return 0, err
+	}
+	return reservation.Number, nil
+}
+
+// Reserve holds the next number for key until Commit or Release is called; nobody else can allocate for
+// key meanwhile. Release it with defer, which does nothing once it's committed:
+//
+//	reservation, err := numbers.Reserve(ctx, "invoice")
+//	if err != nil { ... }
+//	defer reservation.Release()
+//	invoice.Number = reservation.Number
+//	if err := saveInvoice(ctx, invoice); err != nil { ... }
+//	return reservation.Commit(ctx)
+func (a *SequentialAllocator) Reserve(ctx context.Context, key string) (*SequenceReservation, error) {
+	if a.Store == nil {
+		return nil, errors.New("sequential allocator has no store")
+	}
+	unlock, err := a.Store.Lock(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	last, err := a.Store.Get(ctx, key)
+	if err != nil {
+		_ = unlock(false)
+		return nil, err
+	}
+
+	start := a.Start
+	if start == 0 {
+		start = 1
+	}
+	n := max(last+1, start)
+	if n <= last {
+		_ = unlock(false)
+		return nil, errors.New("sequence is exhausted")
+	}
+	return &SequenceReservation{Number: n, key: key, store: a.Store, unlock: unlock}, nil
+}
+
+// SequenceReservation is a number held by SequentialAllocator.Reserve.
+type SequenceReservation struct {
+	Number int64
+
+	key    string
+	store  SequenceStore
+	once   sync.Once
+	unlock func(commit bool) error
+}
+
+// Commit records the number as used, and lets the next allocation for the key go ahead. If it fails,
+// including when the store fails to commit, the number is released and mustn't be used.
+func (r *SequenceReservation) Commit(ctx context.Context) error {
+	err := ErrSequenceReleased
+	r.once.Do(func() {
+		if err = r.store.Set(ctx, r.key, r.Number); err != nil {
+			_ = r.unlock(false)
+			return
+		}
+		err = r.unlock(true)
+	})
+	return err
+}
+
+// Release gives the number back, to be allocated next, unless it has been committed.
+func (r *SequenceReservation) Release() {
+	r.once.Do(func() { _ = r.unlock(false) })
+}
+
+// MemorySequenceStore is a SequenceStore which keeps numbers in memory, for tests and single-instance
+// services. Numbers start again on restart.
+type MemorySequenceStore struct {
+	mu      sync.Mutex
+	last    map[string]int64
+	pending map[string]int64 // numbers Set under a lock which hasn't been committed yet
+	locks   map[string]chan struct{}
+}
+
+// NewMemorySequenceStore returns an empty MemorySequenceStore.
+func NewMemorySequenceStore() *MemorySequenceStore {
+	return &MemorySequenceStore{
+		last:    make(map[string]int64),
+		pending: make(map[string]int64),
+		locks:   make(map[string]chan struct{}),
+	}
+}
+
+// Lock waits for the lock of key. Numbers Set while holding it are kept when it's unlocked with commit
+// set to true, and discarded otherwise.
+func (s *MemorySequenceStore) Lock(ctx context.Context, key string) (func(commit bool) error, error) {
+	s.mu.Lock()
+	lock, ok := s.locks[key]
+	if !ok {
+		lock = make(chan struct{}, 1)
+		s.locks[key] = lock
+	}
+	s.mu.Unlock()
+
+	select {
+	case lock <- struct{}{}:
+		return func(commit bool) error {
+			s.mu.Lock()
+			if n, ok := s.pending[key]; ok && commit {
+				s.last[key] = n
+			}
+			delete(s.pending, key)
+			s.mu.Unlock()
+			<-lock
+			return nil
+		}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Get returns the last number allocated for key.
+func (s *MemorySequenceStore) Get(ctx context.Context, key string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.last[key], nil
+}
+
+// Set records n as the last number allocated for key, once the lock is unlocked with commit set to true.
+func (s *MemorySequenceStore) Set(ctx context.Context, key string, n int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pending[key] = n
+	return nil
+}
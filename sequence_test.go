@@ -0,0 +1,110 @@
+package gohelpertools
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSequentialAllocator_Next(t *testing.T) {
+	ctx := context.Background()
+	alloc := &SequentialAllocator{Store: NewMemorySequenceStore(), Start: 1000}
+
+	var mu sync.Mutex
+	var got []int64
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			n, err := alloc.Next(ctx, "invoice")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			mu.Lock()
+			got = append(got, n)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	sort.Slice(got, func(i, j int) bool { return got[i] < got[j] })
+	for i, n := range got {
+		if n != int64(1000+i) {
+			t.Fatalf("expected numbers 1000 to 1049 without duplicates, got %v", got)
+		}
+	}
+	if n, _ := alloc.Next(ctx, "order"); n != 1000 {
+		t.Errorf("expected each key to have its own sequence, got %d", n)
+	}
+}
+
+func TestSequentialAllocator_Reserve(t *testing.T) {
+	ctx := context.Background()
+	alloc := &SequentialAllocator{Store: NewMemorySequenceStore()}
+
+	first, err := alloc.Reserve(ctx, "invoice")
+	if err != nil || first.Number != 1 {
+		t.Fatalf("expected to reserve 1, got %v %v", first, err)
+	}
+
+	// The key is held until the reservation is committed or released.
+	waitCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, err := alloc.Reserve(waitCtx, "invoice"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected a second reservation to wait, got %v", err)
+	}
+
+	first.Release()
+	second, err := alloc.Reserve(ctx, "invoice")
+	if err != nil || second.Number != 1 {
+		t.Fatalf("expected the released number to be reserved again, got %v %v", second, err)
+	}
+	if err := second.Commit(ctx); err != nil {
+		t.Fatal(err)
+	}
+	second.Release()
+	if err := second.Commit(ctx); !errors.Is(err, ErrSequenceReleased) {
+		t.Errorf("expected a second commit to fail, got %v", err)
+	}
+
+	if n, err := alloc.Next(ctx, "invoice"); n != 2 || err != nil {
+		t.Errorf("expected 2 after the committed reservation, got %d %v", n, err)
+	}
+}
+
+// failingCommitStore is a SequenceStore whose commits fail, like a database transaction which is rolled
+// back.
+type failingCommitStore struct {
+	*MemorySequenceStore
+}
+
+func (s failingCommitStore) Lock(ctx context.Context, key string) (func(commit bool) error, error) {
+	unlock, err := s.MemorySequenceStore.Lock(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return func(bool) error {
+		_ = unlock(false)
+		return errors.New("commit failed")
+	}, nil
+}
+
+func TestSequentialAllocator_CommitFails(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemorySequenceStore()
+	failing := &SequentialAllocator{Store: failingCommitStore{store}}
+
+	if n, err := failing.Next(ctx, "invoice"); err == nil {
+		t.Errorf("failed commit: error expected, but none received (got %d)", n)
+	}
+
+	alloc := &SequentialAllocator{Store: store}
+	if n, err := alloc.Next(ctx, "invoice"); n != 1 || err != nil {
+		t.Errorf("expected 1 to be allocated again after the failed commit, got %d %v", n, err)
+	}
+}
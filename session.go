@@ -0,0 +1,500 @@
+package gohelpertools
+
+import (
+	"context"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const sessionContextKey contextKey = "session"
+const sessionFlashKey = "_flash"
+
+const defaultSessionIdleTimeout = 30 * time.Minute
+const defaultSessionLifetime = 24 * time.Hour
+
+// SessionStore keeps session data on the server, so that the cookie only has to carry the session ID.
+type SessionStore interface {
+	// Load returns the data for id; found is false if there is no such session or it has expired.
+	Load(ctx context.Context, id string) (data []byte, found bool, err error)
+	// Save stores data for id until expiry.
+	Save(ctx context.Context, id string, data []byte, expiry time.Time) error
+	// Delete removes the session id.
+	Delete(ctx context.Context, id string) error
+}
+
+// SessionManager provides cookie-based sessions. Cookies are encrypted and authenticated with AES-GCM,
+// so clients can neither read nor tamper with them. Without a Store, all session data lives in the
+// cookie itself (so it should be kept small); with a Store, the cookie only carries the session ID.
+type SessionManager struct {
+	Key         []byte        // AES key, which must be 16, 24 or 32 bytes long; required
+	Store       SessionStore  // where session data is kept; if nil, data is kept in the cookie
+	CookieName  string        // defaults to "session"
+	IdleTimeout time.Duration // a session expires after this long without a request; defaults to 30 minutes
+	Lifetime    time.Duration // a session expires this long after it was created, regardless of activity; defaults to 24 hours
+	Path        string        // cookie path; defaults to "/"
+	Domain      string        // cookie domain
+	Insecure    bool          // if set to true, the cookie is also sent over plain HTTP (for local development)
+	SameSite    http.SameSite // defaults to http.SameSiteLaxMode
+	Tools       *Tools        // used to write JSON error responses and log errors; a zero Tools is used if nil
+}
+
+// Session holds the values for one client. It is safe for concurrent use.
+type Session struct {
+	mu        sync.Mutex
+	id        string
+	oldID     string
+	created   time.Time
+	values    map[string]json.RawMessage
+	destroyed bool
+}
+
+// sessionPayload is the serialised form of a Session.
+type sessionPayload struct {
+	ID      string                     `json:"id"`
+	Created int64                      `json:"c"`
+	Seen    int64                      `json:"s"`
+	Values  map[string]json.RawMessage `json:"v"`
+}
+
+// LoadAndSave is middleware which loads the session for each request (starting a new one if there is
+// no valid session), makes it available to handlers via GetSession, and saves it before the response is
+// written. Expired sessions, by either the idle or the absolute timeout, are replaced with new ones.
+func (m *SessionManager) LoadAndSave(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		session, err := m.load(r)
+		if err != nil {
+			_ = toolsOrDefault(m.Tools).ErrorJSON(w, errors.New("unable to load session"), http.StatusInternalServerError)
+			return
+		}
+
+		sw := &sessionWriter{ResponseWriter: w, manager: m, session: session, request: r}
+		next.ServeHTTP(sw, r.WithContext(context.WithValue(r.Context(), sessionContextKey, session)))
+		sw.commit()
+	})
+}
+
+// GetSession returns the session loaded by SessionManager.LoadAndSave, or nil if there isn't one.
+func GetSession(r *http.Request) *Session {
+	session, _ := r.Context().Value(sessionContextKey).(*Session)
+	return session
+}
+
+// SessionGet returns the value stored in s under key, decoded into a T. The second return value is false
+// if there is no such key, or its value can't be decoded into a T.
+func SessionGet[T any](s *Session, key string) (T, bool) {
+	var value T
+	if s == nil {
+		return value, false
+	}
+
+	s.mu.Lock()
+	raw, ok := s.values[key]
+	s.mu.Unlock()
+	if !ok {
+		return value, false
+	}
+
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return value, false
+	}
+	return value, true
+}
+
+// Set stores value, which must be possible to marshal to JSON, under key.
+func (s *Session) Set(key string, value any) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values[key] = raw
+	return nil
+}
+
+// Delete removes key from the session.
+func (s *Session) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.values, key)
+}
+
+// Destroy removes every value from the session and expires its cookie.
+func (s *Session) Destroy() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values = make(map[string]json.RawMessage)
+	s.destroyed = true
+}
+
+// AddFlash queues a one-time message, such as "Your changes were saved", to be shown on the next page
+// rendered (see PopFlash and Renderer).
+func (s *Session) AddFlash(message string) error {
+	messages, _ := SessionGet[[]string](s, sessionFlashKey)
+	return s.Set(sessionFlashKey, append(messages, message))
+}
+
+// PopFlash returns the messages queued by AddFlash, and removes them from the session.
+func (s *Session) PopFlash() []string {
+	messages, _ := SessionGet[[]string](s, sessionFlashKey)
+	s.Delete(sessionFlashKey)
+	return messages
+}
+
+// RenewID gives the session a new ID while keeping its values. Call it whenever the privilege level
+// changes, such as at login, to prevent session fixation.
+func (s *Session) RenewID() error {
+	id, err := randomToken(32)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.oldID == "" {
+		s.oldID = s.id
+	}
+	s.id = id
+	return nil
+}
+
+// load returns the session for r, or a new one if r has no valid session cookie.
+func (m *SessionManager) load(r *http.Request) (*Session, error) {
+	if cookie, err := r.Cookie(m.cookieName()); err == nil {
+		payload, ok := m.decodeCookie(r.Context(), cookie.Value)
+		now := time.Now()
+		if ok && now.Before(time.Unix(payload.Created, 0).Add(m.lifetime())) && now.Before(time.Unix(payload.Seen, 0).Add(m.idleTimeout())) {
+			if payload.Values == nil {
+				payload.Values = make(map[string]json.RawMessage)
+			}
+			return &Session{id: payload.ID, created: time.Unix(payload.Created, 0), values: payload.Values}, nil
+		}
+		if ok && m.Store != nil {
+			_ = m.Store.Delete(r.Context(), payload.ID)
+		}
+	}
+
+	id, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	return &Session{id: id, created: time.Now(), values: make(map[string]json.RawMessage)}, nil
+}
+
+// save persists the session and returns the cookie which should be sent to the client.
+func (m *SessionManager) save(ctx context.Context, s *Session) (*http.Cookie, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cookie := &http.Cookie{
+		Name:     m.cookieName(),
+		Path:     valueOrDefault(m.Path, "/"),
+		Domain:   m.Domain,
+		Secure:   !m.Insecure,
+		HttpOnly: true,
+		SameSite: m.SameSite,
+	}
+	if cookie.SameSite == 0 {
+		cookie.SameSite = http.SameSiteLaxMode
+	}
+
+	if m.Store != nil && s.oldID != "" {
+		if err := m.Store.Delete(ctx, s.oldID); err != nil {
+			return nil, err
+		}
+	}
+
+	if s.destroyed {
+		if m.Store != nil {
+			if err := m.Store.Delete(ctx, s.id); err != nil {
+				return nil, err
+			}
+		}
+		cookie.MaxAge = -1
+		return cookie, nil
+	}
+
+	now := time.Now()
+	expiry := now.Add(m.idleTimeout())
+	if absolute := s.created.Add(m.lifetime()); absolute.Before(expiry) {
+		expiry = absolute
+	}
+
+	data, err := json.Marshal(sessionPayload{ID: s.id, Created: s.created.Unix(), Seen: now.Unix(), Values: s.values})
+	if err != nil {
+		return nil, err
+	}
+
+	// With a store, the cookie only carries the ID (and the timestamps needed to check the timeouts).
+	if m.Store != nil {
+		if err := m.Store.Save(ctx, s.id, data, expiry); err != nil {
+			return nil, err
+		}
+		data, _ = json.Marshal(sessionPayload{ID: s.id, Created: s.created.Unix(), Seen: now.Unix()})
+	}
+
+	value, err := m.seal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	cookie.Value = value
+	cookie.Expires = expiry
+	return cookie, nil
+}
+
+// decodeCookie decrypts a cookie value and, when a store is used, loads the session data it refers to.
+func (m *SessionManager) decodeCookie(ctx context.Context, value string) (sessionPayload, bool) {
+	var payload sessionPayload
+
+	data, err := m.open(value)
+	if err != nil || json.Unmarshal(data, &payload) != nil {
+		return payload, false
+	}
+
+	if m.Store != nil {
+		stored, found, err := m.Store.Load(ctx, payload.ID)
+		if err != nil || !found {
+			return payload, false
+		}
+
+		var full sessionPayload
+		if json.Unmarshal(stored, &full) != nil || full.ID != payload.ID {
+			return payload, false
+		}
+		payload.Values = full.Values
+	}
+
+	return payload, true
+}
+
+// seal encrypts data, returning a value suitable for a cookie: base64url(nonce || ciphertext).
+func (m *SessionManager) seal(data []byte) (string, error) {
+	gcm, err := m.aead()
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	// The cookie name is authenticated too, so a value can't be moved to a different cookie.
+	sealed := gcm.Seal(nonce, nonce, data, []byte(m.cookieName()))
+	return base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// open reverses seal.
+func (m *SessionManager) open(value string) ([]byte, error) {
+	gcm, err := m.aead()
+	if err != nil {
+		return nil, err
+	}
+
+	sealed, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(sealed) < gcm.NonceSize() {
+		return nil, errors.New("invalid session cookie")
+	}
+
+	return gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], []byte(m.cookieName()))
+}
+
+func (m *SessionManager) aead() (cipher.AEAD, error) {
+	return newGCM(m.Key)
+}
+
+func (m *SessionManager) cookieName() string {
+	return valueOrDefault(m.CookieName, "session")
+}
+
+func (m *SessionManager) idleTimeout() time.Duration {
+	if m.IdleTimeout == 0 {
+		return defaultSessionIdleTimeout
+	}
+	return m.IdleTimeout
+}
+
+func (m *SessionManager) lifetime() time.Duration {
+	if m.Lifetime == 0 {
+		return defaultSessionLifetime
+	}
+	return m.Lifetime
+}
+
+// sessionWriter saves the session just before the response headers are sent, since the cookie can't be
+// set after that.
+type sessionWriter struct {
+	http.ResponseWriter
+	manager   *SessionManager
+	session   *Session
+	request   *http.Request
+	committed bool
+}
+
+func (sw *sessionWriter) commit() {
+	if sw.committed {
+		return
+	}
+	sw.committed = true
+
+	cookie, err := sw.manager.save(sw.request.Context(), sw.session)
+	if err != nil {
+		// The handler's response is still sent, but the session change is lost.
+		toolsOrDefault(sw.manager.Tools).LogError(sw.request.Context(), "unable to save session", err)
+		return
+	}
+	http.SetCookie(sw.ResponseWriter, cookie)
+	sw.ResponseWriter.Header().Add("Vary", "Cookie")
+	sw.ResponseWriter.Header().Set("Cache-Control", `no-cache="Set-Cookie"`)
+}
+
+func (sw *sessionWriter) WriteHeader(code int) {
+	sw.commit()
+	sw.ResponseWriter.WriteHeader(code)
+}
+
+func (sw *sessionWriter) Write(b []byte) (int, error) {
+	sw.commit()
+	return sw.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the original http.ResponseWriter, for use by http.ResponseController.
+func (sw *sessionWriter) Unwrap() http.ResponseWriter {
+	return sw.ResponseWriter
+}
+
+// MemorySessionStore is a SessionStore which keeps sessions in memory. Sessions are lost on restart, and
+// are not shared between instances.
+type MemorySessionStore struct {
+	mu        sync.Mutex
+	sessions  map[string]memorySession
+	nextSweep time.Time
+}
+
+// memorySessionSweepInterval is how often Save clears out expired sessions, so that the cost of scanning
+// every session is shared between many saves.
+const memorySessionSweepInterval = time.Minute
+
+type memorySession struct {
+	data   []byte
+	expiry time.Time
+}
+
+// NewMemorySessionStore returns an empty MemorySessionStore.
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{sessions: make(map[string]memorySession)}
+}
+
+// Load returns the data for id, if it exists and has not expired.
+func (s *MemorySessionStore) Load(ctx context.Context, id string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[id]
+	if !ok || time.Now().After(session.expiry) {
+		delete(s.sessions, id)
+		return nil, false, nil
+	}
+	return session.data, true, nil
+}
+
+// Save stores data for id until expiry. At most once a minute, it also clears out any expired sessions;
+// Load ignores them in the meantime.
+func (s *MemorySessionStore) Save(ctx context.Context, id string, data []byte, expiry time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now := time.Now(); now.After(s.nextSweep) {
+		for key, session := range s.sessions {
+			if now.After(session.expiry) {
+				delete(s.sessions, key)
+			}
+		}
+		s.nextSweep = now.Add(memorySessionSweepInterval)
+	}
+	s.sessions[id] = memorySession{data: data, expiry: expiry}
+	return nil
+}
+
+// Delete removes the session id.
+func (s *MemorySessionStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, id)
+	return nil
+}
+
+// FileSessionStore is a SessionStore which keeps each session in a file in Dir. File names are hashes of
+// the session IDs, so the IDs themselves are never written to disk.
+type FileSessionStore struct {
+	Dir string
+}
+
+// Load returns the data for id, if it exists and has not expired.
+func (s *FileSessionStore) Load(ctx context.Context, id string) ([]byte, bool, error) {
+	content, err := os.ReadFile(s.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	expiry, data, found := strings.Cut(string(content), "\n")
+	unix, err := strconv.ParseInt(expiry, 10, 64)
+	if !found || err != nil || time.Now().After(time.Unix(unix, 0)) {
+		_ = os.Remove(s.path(id))
+		return nil, false, nil
+	}
+	return []byte(data), true, nil
+}
+
+// Save writes data for id to disk, along with its expiry time.
+func (s *FileSessionStore) Save(ctx context.Context, id string, data []byte, expiry time.Time) error {
+	if err := os.MkdirAll(s.Dir, 0700); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first, so that a concurrent Load never sees a partial file.
+	tmp, err := os.CreateTemp(s.Dir, "session-*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.WriteString(strconv.FormatInt(expiry.Unix(), 10) + "\n" + string(data))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path(id))
+}
+
+// Delete removes the file for session id.
+func (s *FileSessionStore) Delete(ctx context.Context, id string) error {
+	err := os.Remove(s.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (s *FileSessionStore) path(id string) string {
+	sum := sha256.Sum256([]byte(id))
+	return filepath.Join(s.Dir, hex.EncodeToString(sum[:])+".session")
+}
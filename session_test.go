@@ -0,0 +1,157 @@
+package gohelpertools
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// sessionRequest runs one request through handler, sending cookie if it isn't nil, and returns the
+// session cookie set in the response.
+func sessionRequest(t *testing.T, handler http.Handler, cookie *http.Cookie) *http.Cookie {
+	t.Helper()
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	for _, c := range rr.Result().Cookies() {
+		if c.Name == "session" {
+			return c
+		}
+	}
+	t.Fatal("no session cookie set")
+	return nil
+}
+
+var sessionStoreTests = []struct {
+	name  string
+	store func(t *testing.T) SessionStore
+}{
+	{name: "cookie", store: func(t *testing.T) SessionStore { return nil }},
+	{name: "memory", store: func(t *testing.T) SessionStore { return NewMemorySessionStore() }},
+	{name: "file", store: func(t *testing.T) SessionStore { return &FileSessionStore{Dir: t.TempDir()} }},
+}
+
+func TestSessionManager_LoadAndSave(t *testing.T) {
+	for _, e := range sessionStoreTests {
+		manager := SessionManager{Key: []byte("0123456789abcdef0123456789abcdef"), Store: e.store(t)}
+
+		var count int
+		var found bool
+		handler := manager.LoadAndSave(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			session := GetSession(r)
+			count, found = SessionGet[int](session, "count")
+			_ = session.Set("count", count+1)
+			_, _ = w.Write([]byte("ok"))
+		}))
+
+		cookie := sessionRequest(t, handler, nil)
+		if found {
+			t.Errorf("%s: expected a new session to be empty", e.name)
+		}
+
+		cookie = sessionRequest(t, handler, cookie)
+		cookie = sessionRequest(t, handler, cookie)
+		if !found || count != 2 {
+			t.Errorf("%s: expected count 2 from the session, but got %d (found %v)", e.name, count, found)
+		}
+
+		// A tampered cookie starts a new session. The first character is always changed to a different one.
+		tampered := byte('x')
+		if cookie.Value[0] == tampered {
+			tampered = 'y'
+		}
+		cookie.Value = string(tampered) + cookie.Value[1:]
+		sessionRequest(t, handler, cookie)
+		if found {
+			t.Errorf("%s: expected tampered cookie to be rejected", e.name)
+		}
+	}
+}
+
+func TestSessionManager_Timeouts(t *testing.T) {
+	manager := SessionManager{Key: []byte("0123456789abcdef"), IdleTimeout: time.Hour, Lifetime: time.Hour}
+
+	var found bool
+	handler := manager.LoadAndSave(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, found = SessionGet[string](GetSession(r), "user")
+		_ = GetSession(r).Set("user", "42")
+	}))
+
+	cookie := sessionRequest(t, handler, nil)
+
+	// Forge a cookie whose session was created long ago, as if the absolute lifetime had passed.
+	old, _ := manager.seal([]byte(`{"id":"old","c":1,"s":` + strconv.FormatInt(time.Now().Unix(), 10) + `,"v":{"user":"42"}}`))
+	sessionRequest(t, handler, &http.Cookie{Name: "session", Value: old})
+	if found {
+		t.Error("expected expired session to be replaced")
+	}
+
+	sessionRequest(t, handler, cookie)
+	if !found {
+		t.Error("expected current session to be loaded")
+	}
+}
+
+func TestSession_DestroyAndRenewID(t *testing.T) {
+	store := NewMemorySessionStore()
+	manager := SessionManager{Key: []byte("0123456789abcdef"), Store: store}
+
+	action := ""
+	handler := manager.LoadAndSave(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		session := GetSession(r)
+		switch action {
+		case "renew":
+			_ = session.RenewID()
+		case "destroy":
+			session.Destroy()
+		default:
+			_ = session.Set("user", "42")
+		}
+	}))
+
+	cookie := sessionRequest(t, handler, nil)
+	firstID := ""
+	for id := range store.sessions {
+		firstID = id
+	}
+
+	action = "renew"
+	cookie = sessionRequest(t, handler, cookie)
+	if _, ok := store.sessions[firstID]; ok || len(store.sessions) != 1 {
+		t.Errorf("expected old session ID to be removed after RenewID, got %d sessions", len(store.sessions))
+	}
+
+	action = "destroy"
+	cookie = sessionRequest(t, handler, cookie)
+	if cookie.MaxAge >= 0 || len(store.sessions) != 0 {
+		t.Errorf("expected destroyed session to be deleted and its cookie expired")
+	}
+}
+
+func TestMemorySessionStore_Sweep(t *testing.T) {
+	store := NewMemorySessionStore()
+	ctx := context.Background()
+	_ = store.Save(ctx, "old", []byte("a"), time.Now().Add(-time.Second))
+	_ = store.Save(ctx, "new", []byte("b"), time.Now().Add(time.Hour))
+	if len(store.sessions) != 2 {
+		t.Errorf("expected expired sessions to be kept until the next sweep, got %d", len(store.sessions))
+	}
+	if _, found, _ := store.Load(ctx, "old"); found {
+		t.Error("expected an expired session not to be loaded")
+	}
+
+	_ = store.Save(ctx, "expired", []byte("c"), time.Now().Add(-time.Second))
+	store.nextSweep = time.Now().Add(-time.Second)
+	_ = store.Save(ctx, "newer", []byte("d"), time.Now().Add(time.Hour))
+	if _, ok := store.sessions["expired"]; ok || len(store.sessions) != 2 {
+		t.Errorf("expected the sweep to remove expired sessions, got %d", len(store.sessions))
+	}
+}
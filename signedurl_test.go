@@ -0,0 +1,61 @@
+package gohelpertools
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+var signedURLTests = []struct {
+	name          string
+	ttl           time.Duration
+	tamper        func(string) string
+	errorExpected bool
+}{
+	{name: "valid", ttl: time.Minute, tamper: func(s string) string { return s }, errorExpected: false},
+	{name: "expired", ttl: -time.Minute, tamper: func(s string) string { return s }, errorExpected: true},
+	{name: "changed path", ttl: time.Minute, tamper: func(s string) string { return strings.Replace(s, "report.pdf", "other.pdf", 1) }, errorExpected: true},
+	{name: "changed expiry", ttl: time.Minute, tamper: func(s string) string { return strings.Replace(s, "expires=", "expires=9", 1) }, errorExpected: true},
+	{name: "added parameter", ttl: time.Minute, tamper: func(s string) string { return s + "&user=admin" }, errorExpected: true},
+	{name: "unsigned", ttl: time.Minute, tamper: func(s string) string { return s[:strings.Index(s, "signature=")] }, errorExpected: true},
+}
+
+func TestTools_SignURL(t *testing.T) {
+	tools := Tools{URLSigningKey: []byte("secret")}
+
+	for _, e := range signedURLTests {
+		signed, err := tools.SignURL("https://example.com/files/report.pdf?version=2", e.ttl)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req := httptest.NewRequest("GET", e.tamper(signed), nil)
+		err = tools.VerifySignedURL(req)
+		if e.errorExpected && err == nil {
+			t.Errorf("%s: error expected, but none received", e.name)
+		}
+		if !e.errorExpected && err != nil {
+			t.Errorf("%s: error not expected, but one received: %s", e.name, err)
+		}
+	}
+
+	if _, err := (&Tools{}).SignURL("/files", time.Minute); err == nil {
+		t.Error("no key: error expected, but none received")
+	}
+}
+
+func TestTools_RequireSignedURL(t *testing.T) {
+	tools := Tools{URLSigningKey: []byte("secret")}
+	handler := tools.RequireSignedURL(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	signed, _ := tools.SignURL("/upload", time.Minute)
+	for url, status := range map[string]int{signed: http.StatusOK, "/upload": http.StatusForbidden} {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("PUT", url, nil))
+		if rr.Code != status {
+			t.Errorf("%s: expected status %d but got %d", url, status, rr.Code)
+		}
+	}
+}
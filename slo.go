@@ -0,0 +1,240 @@
+package gohelpertools
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// burnRateWindows are the periods over which SLOTracker reports burn rates, and the alert rules which use
+// them. A page-worthy (fast) burn spends 2% of a 30-day budget in an hour, and a ticket-worthy (slow) burn
+// 5% in six hours; each rule needs both its long and short window to be burning, so that an alert stops
+// soon after the problem does (see the Google SRE workbook, "Alerting on SLOs").
+var burnRateWindows = []struct {
+	name string
+	d    time.Duration
+}{
+	{"5m", 5 * time.Minute},
+	{"30m", 30 * time.Minute},
+	{"1h", time.Hour},
+	{"6h", 6 * time.Hour},
+}
+
+var burnRateAlerts = []struct {
+	severity    string
+	long, short string
+	threshold   float64
+}{
+	{"page", "1h", "5m", 14.4},
+	{"ticket", "6h", "30m", 6},
+}
+
+// Objective is a service level objective for one route, or for every route.
+type Objective struct {
+	Name         string        // identifies the objective in the status and alerts; required
+	Route        string        // route, as labelled by Metrics, the objective covers; every route if empty
+	Availability float64       // target fraction of good requests, such as 0.999
+	Latency      time.Duration // if above zero, requests slower than this are bad too
+	Window       time.Duration // period over which the budget is measured, in whole minutes and at least one; defaults to 7 days
+}
+
+// SLOStatus is the state of an Objective, as reported by SLOTracker.Status.
+type SLOStatus struct {
+	Name            string             `json:"name"`
+	Route           string             `json:"route,omitempty"`
+	Target          float64            `json:"target"`
+	LatencyMS       int64              `json:"latency_ms,omitempty"`
+	Total           int64              `json:"total"`              // requests in the window
+	Bad             int64              `json:"bad"`                // requests which failed the objective
+	SLI             float64            `json:"sli"`                // fraction of good requests; 1 if there were none
+	BudgetRemaining float64            `json:"budget_remaining"`   // fraction of the error budget left; negative once overspent
+	BurnRates       map[string]float64 `json:"burn_rates"`         // rate of spending the budget, where 1 spends it exactly over the window
+	Alerting        []string           `json:"alerting,omitempty"` // severities ("page", "ticket") currently firing
+}
+
+// SLOAlert is passed to SLOTracker.OnAlert when an objective starts or stops burning its error budget too
+// fast.
+type SLOAlert struct {
+	Objective string
+	Severity  string  // "page" for a fast burn, "ticket" for a slow one
+	BurnRate  float64 // over the rule's long window
+	Resolved  bool    // true when the burn rate has dropped back below the threshold
+}
+
+// SLOTracker tracks service level objectives from the request stream, and computes their error budgets
+// and burn rates. Feed it by setting Metrics.OnRequest to its Record method, or by calling Record
+// directly. A request is bad if it has a 5xx status, or is slower than the objective's Latency. Counts
+// are kept in one-minute buckets, so memory use depends on the window, not on traffic.
+type SLOTracker struct {
+	Objectives []Objective
+	OnAlert    func(SLOAlert) // if set, called when an alert starts or is resolved; checked at most once a minute
+	Tools      *Tools         // used to write the status as JSON; a zero Tools is used if nil
+
+	once     sync.Once
+	mu       sync.Mutex
+	now      func() time.Time
+	trackers []*sloCounts
+}
+
+type sloCounts struct {
+	objective Objective
+	buckets   []sloBucket
+	checked   int64           // the last minute alerts were checked
+	firing    map[string]bool // alert severities currently firing
+}
+
+type sloBucket struct {
+	minute int64
+	total  int64
+	bad    int64
+}
+
+// Record counts one request against every objective covering its route.
+func (s *SLOTracker) Record(m RequestMetric) {
+	s.init()
+	minute := s.now().Unix() / 60
+
+	var alerts []SLOAlert
+	s.mu.Lock()
+	for _, c := range s.trackers {
+		if c.objective.Route != "" && c.objective.Route != m.Route {
+			continue
+		}
+
+		bucket := &c.buckets[minute%int64(len(c.buckets))]
+		if bucket.minute != minute {
+			*bucket = sloBucket{minute: minute}
+		}
+		bucket.total++
+		if m.Status >= 500 || (c.objective.Latency > 0 && m.Duration > c.objective.Latency) {
+			bucket.bad++
+		}
+
+		if c.checked != minute {
+			c.checked = minute
+			alerts = append(alerts, c.checkAlerts(minute)...)
+		}
+	}
+	s.mu.Unlock()
+
+	if s.OnAlert != nil {
+		for _, alert := range alerts {
+			s.OnAlert(alert)
+		}
+	}
+}
+
+// Status returns the state of every objective, in the order they were given.
+func (s *SLOTracker) Status() []SLOStatus {
+	s.init()
+	minute := s.now().Unix() / 60
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	statuses := make([]SLOStatus, 0, len(s.trackers))
+	for _, c := range s.trackers {
+		total, bad := c.counts(minute, c.window())
+		status := SLOStatus{
+			Name:            c.objective.Name,
+			Route:           c.objective.Route,
+			Target:          c.objective.Availability,
+			LatencyMS:       c.objective.Latency.Milliseconds(),
+			Total:           total,
+			Bad:             bad,
+			SLI:             1,
+			BudgetRemaining: 1,
+			BurnRates:       make(map[string]float64, len(burnRateWindows)),
+		}
+		if total > 0 {
+			status.SLI = float64(total-bad) / float64(total)
+			if budget := 1 - c.objective.Availability; budget > 0 {
+				status.BudgetRemaining = 1 - (1-status.SLI)/budget
+			}
+		}
+		for _, w := range burnRateWindows {
+			status.BurnRates[w.name] = c.burnRate(minute, w.d)
+		}
+		for severity, firing := range c.firing {
+			if firing {
+				status.Alerting = append(status.Alerting, severity)
+			}
+		}
+		sort.Strings(status.Alerting)
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// Handler returns a handler which writes Status as JSON, for an internal status endpoint.
+func (s *SLOTracker) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = toolsOrDefault(s.Tools).WriteJSON(w, http.StatusOK, s.Status())
+	})
+}
+
+func (s *SLOTracker) init() {
+	s.once.Do(func() {
+		if s.now == nil {
+			s.now = time.Now
+		}
+		for _, objective := range s.Objectives {
+			c := &sloCounts{objective: objective, firing: make(map[string]bool)}
+			c.buckets = make([]sloBucket, int(c.window()/time.Minute))
+			s.trackers = append(s.trackers, c)
+		}
+	})
+}
+
+func (c *sloCounts) window() time.Duration {
+	if c.objective.Window <= 0 {
+		return 7 * 24 * time.Hour
+	}
+	// Requests are counted in buckets of a minute, so a shorter window would have none.
+	return max(c.objective.Window, time.Minute)
+}
+
+// counts returns the total and bad requests in the period d up to and including minute.
+func (c *sloCounts) counts(minute int64, d time.Duration) (total, bad int64) {
+	minutes := int64(d / time.Minute)
+	if minutes > int64(len(c.buckets)) {
+		minutes = int64(len(c.buckets))
+	}
+	for m := minute - minutes + 1; m <= minute; m++ {
+		if bucket := c.buckets[m%int64(len(c.buckets))]; bucket.minute == m {
+			total += bucket.total
+			bad += bucket.bad
+		}
+	}
+	return total, bad
+}
+
+// burnRate returns the error rate over the period d divided by the error rate the objective allows.
+func (c *sloCounts) burnRate(minute int64, d time.Duration) float64 {
+	budget := 1 - c.objective.Availability
+	total, bad := c.counts(minute, d)
+	if total == 0 || budget <= 0 {
+		return 0
+	}
+	return float64(bad) / float64(total) / budget
+}
+
+// checkAlerts evaluates the burn rate alert rules, returning the alerts which started or were resolved.
+func (c *sloCounts) checkAlerts(minute int64) []SLOAlert {
+	windows := make(map[string]time.Duration, len(burnRateWindows))
+	for _, w := range burnRateWindows {
+		windows[w.name] = w.d
+	}
+
+	var alerts []SLOAlert
+	for _, rule := range burnRateAlerts {
+		long := c.burnRate(minute, windows[rule.long])
+		firing := long >= rule.threshold && c.burnRate(minute, windows[rule.short]) >= rule.threshold
+		if firing != c.firing[rule.severity] {
+			c.firing[rule.severity] = firing
+			alerts = append(alerts, SLOAlert{Objective: c.objective.Name, Severity: rule.severity, BurnRate: long, Resolved: !firing})
+		}
+	}
+	return alerts
+}
@@ -0,0 +1,109 @@
+package gohelpertools
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSLOTracker(t *testing.T) {
+	clock := &fakeClock{t: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	var alerts []SLOAlert
+	slo := &SLOTracker{
+		Objectives: []Objective{
+			{Name: "checkout", Route: "/checkout", Availability: 0.99, Latency: 500 * time.Millisecond},
+			{Name: "all", Availability: 0.9},
+		},
+		OnAlert: func(a SLOAlert) { alerts = append(alerts, a) },
+		now:     clock.now,
+	}
+
+	// An hour of healthy traffic.
+	for i := 0; i < 60; i++ {
+		for j := 0; j < 10; j++ {
+			slo.Record(RequestMetric{Route: "/checkout", Status: http.StatusOK, Duration: 100 * time.Millisecond})
+		}
+		clock.advance(time.Minute)
+	}
+	if len(alerts) != 0 {
+		t.Fatalf("expected no alerts for healthy traffic, got %+v", alerts)
+	}
+
+	// Then, for 10 minutes, every checkout request fails or is too slow.
+	for i := 0; i < 10; i++ {
+		for j := 0; j < 10; j++ {
+			status, duration := http.StatusBadGateway, 100*time.Millisecond
+			if j%2 == 0 {
+				status, duration = http.StatusOK, time.Second
+			}
+			slo.Record(RequestMetric{Route: "/checkout", Status: status, Duration: duration})
+		}
+		slo.Record(RequestMetric{Route: "/other", Status: http.StatusOK})
+		clock.advance(time.Minute)
+	}
+
+	paged := false
+	for _, a := range alerts {
+		if a.Objective == "checkout" && a.Severity == "page" && !a.Resolved {
+			paged = true
+		}
+	}
+	if !paged {
+		t.Errorf("expected a page for the checkout objective, got %+v", alerts)
+	}
+
+	statuses := slo.Status()
+	checkout := statuses[0]
+	if checkout.Total != 700 || checkout.Bad != 100 {
+		t.Errorf("expected 100 bad of 700 checkout requests, got %d of %d", checkout.Bad, checkout.Total)
+	}
+	if checkout.BudgetRemaining >= 0 {
+		t.Errorf("expected the checkout budget to be overspent, got %f", checkout.BudgetRemaining)
+	}
+	if checkout.BurnRates["5m"] < 14.4 {
+		t.Errorf("expected a fast 5m burn rate, got %f", checkout.BurnRates["5m"])
+	}
+	if all := statuses[1]; all.Total != 710 || all.Bad != 50 {
+		t.Errorf("expected the catch-all objective to count 5xx only across routes, got %d of %d", all.Bad, all.Total)
+	}
+
+	// Recovery resolves the page once the short window is healthy again.
+	for i := 0; i < 10; i++ {
+		slo.Record(RequestMetric{Route: "/checkout", Status: http.StatusOK})
+		clock.advance(time.Minute)
+	}
+	if last := alerts[len(alerts)-1]; !last.Resolved {
+		t.Errorf("expected the alert to be resolved, got %+v", last)
+	}
+
+	rr := httptest.NewRecorder()
+	slo.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/slo", nil))
+	var body []SLOStatus
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil || len(body) != 2 {
+		t.Errorf("unexpected status response %s", rr.Body.String())
+	}
+}
+
+func TestSLOTracker_ShortWindow(t *testing.T) {
+	slo := &SLOTracker{Objectives: []Objective{{Name: "short", Availability: 0.99, Window: 30 * time.Second}}}
+	slo.Record(RequestMetric{Route: "/", Status: http.StatusOK})
+	if status := slo.Status()[0]; status.Total != 1 {
+		t.Errorf("expected a window under a minute to count requests, got %+v", status)
+	}
+}
+
+func TestMetrics_OnRequest(t *testing.T) {
+	var got RequestMetric
+	m := &Metrics{OnRequest: func(r RequestMetric) { got = r }}
+
+	h := m.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/42", nil))
+
+	if got.Route != "/users/:id" || got.Status != http.StatusTeapot || got.Method != http.MethodGet {
+		t.Errorf("unexpected request metric %+v", got)
+	}
+}
@@ -0,0 +1,132 @@
+package gohelpertools
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+var slugInvalidRegex = regexp.MustCompile(`[^a-z\d]+`)
+
+// slugTransliterations covers letters which don't decompose into an ASCII letter plus accents.
+var slugTransliterations = map[rune]string{
+	'ß': "ss", 'ẞ': "ss", 'æ': "ae", 'Æ': "ae", 'œ': "oe", 'Œ': "oe", 'ø': "o", 'Ø': "o",
+	'đ': "d", 'Đ': "d", 'ð': "d", 'Ð': "d", 'þ': "th", 'Þ': "th", 'ł': "l", 'Ł': "l",
+	'ı': "i", 'ħ': "h", 'Ħ': "h", 'ŋ': "ng", 'Ŋ': "ng", 'ĸ': "k", 'ŀ': "l", 'Ŀ': "l",
+}
+
+// SlugOptions controls SlugifyWithOptions.
+type SlugOptions struct {
+	Separator     string                      // placed between words; defaults to "-"
+	MaxLength     int                         // if above zero, the slug is cut at a word boundary to at most this many bytes
+	StopWords     []string                    // words to leave out, such as "a", "the" and "of", compared in lower case
+	Transliterate func(r rune) (string, bool) // if set, tried first for every rune, for example to romanise CJK text; include spaces in the result to separate words
+}
+
+// SlugifyWithOptions creates a slug from s, as Slugify does, using the options in opts.
+func (t *Tools) SlugifyWithOptions(s string, opts SlugOptions) (string, error) {
+	if s == "" {
+		return "", errors.New("empty string not permitted")
+	}
+
+	separator := valueOrDefault(opts.Separator, "-")
+	words := strings.Fields(slugInvalidRegex.ReplaceAllString(transliterate(s, opts.Transliterate), " "))
+
+	kept := words[:0]
+	for _, word := range words {
+		if !contains(opts.StopWords, word) {
+			kept = append(kept, word)
+		}
+	}
+
+	slug := strings.Join(kept, separator)
+	if opts.MaxLength > 0 && len(slug) > opts.MaxLength {
+		slug = ""
+		for _, word := range kept {
+			if slug != "" && len(slug)+len(separator)+len(word) > opts.MaxLength {
+				break
+			}
+			if slug != "" {
+				slug += separator
+			}
+			slug += word
+		}
+		// A single word longer than the limit is cut.
+		if len(slug) > opts.MaxLength {
+			slug = slug[:opts.MaxLength]
+		}
+	}
+
+	if len(slug) == 0 {
+		return "", errors.New("after removing characters, slug is zero length")
+	}
+	return slug, nil
+}
+
+// UniqueSlug creates a slug from s and asks exists whether it is already taken. If it is, a short random
+// suffix is appended and the check repeated, up to 10 times.
+func (t *Tools) UniqueSlug(s string, exists func(slug string) (bool, error), opts ...SlugOptions) (string, error) {
+	var o SlugOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+
+	base, err := t.SlugifyWithOptions(s, o)
+	if err != nil {
+		return "", err
+	}
+
+	separator := valueOrDefault(o.Separator, "-")
+	slug := base
+	for i := 0; i < 10; i++ {
+		taken, err := exists(slug)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return slug, nil
+		}
+
+		suffix, err := t.RandomStringFrom(6, "abcdefghijklmnopqrstuvwxyz0123456789")
+		if err != nil {
+			return "", err
+		}
+
+		// Stay within MaxLength by shortening the base, rather than cutting off the suffix.
+		prefix := base
+		if keep := o.MaxLength - len(separator) - len(suffix); o.MaxLength > 0 && keep < len(prefix) {
+			if keep < 1 {
+				keep = 1
+			}
+			prefix = strings.TrimSuffix(prefix[:keep], separator)
+		}
+		slug = prefix + separator + suffix
+	}
+	return "", errors.New("unable to find a unique slug")
+}
+
+// transliterate lower-cases s and converts it to ASCII where possible, removing accents from letters.
+// Characters with no ASCII equivalent are left as they are, to be removed later.
+func transliterate(s string, custom func(rune) (string, bool)) string {
+	var b strings.Builder
+	for _, original := range s {
+		if custom != nil {
+			if replacement, ok := custom(original); ok {
+				b.WriteString(strings.ToLower(replacement))
+				continue
+			}
+		}
+
+		for _, r := range norm.NFKD.String(string(original)) {
+			if replacement, ok := slugTransliterations[r]; ok {
+				b.WriteString(replacement)
+			} else if !unicode.Is(unicode.Mn, r) {
+				b.WriteRune(unicode.ToLower(r))
+			}
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,73 @@
+package gohelpertools
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+var slugOptionsTests = []struct {
+	name          string
+	s             string
+	opts          SlugOptions
+	expected      string
+	errorExpected bool
+}{
+	{name: "accents", s: "Crème Brûlée à la carte", expected: "creme-brulee-a-la-carte"},
+	{name: "special letters", s: "Straße Ærø Łódź", expected: "strasse-aero-lodz"},
+	{name: "separator", s: "Hello World", opts: SlugOptions{Separator: "_"}, expected: "hello_world"},
+	{name: "stop words", s: "The Lord of the Rings", opts: SlugOptions{StopWords: []string{"the", "of"}}, expected: "lord-rings"},
+	{name: "max length at word boundary", s: "now is the time for all", opts: SlugOptions{MaxLength: 14}, expected: "now-is-the"},
+	{name: "max length long word", s: "supercalifragilistic", opts: SlugOptions{MaxLength: 5}, expected: "super"},
+	{name: "custom transliteration", s: "こんにちは", opts: SlugOptions{Transliterate: func(r rune) (string, bool) {
+		romaji := map[rune]string{'こ': "ko", 'ん': "n", 'に': "ni", 'ち': "chi", 'は': "wa"}
+		s, ok := romaji[r]
+		return s, ok
+	}}, expected: "konnichiwa"},
+	{name: "only stop words", s: "the of", opts: SlugOptions{StopWords: []string{"the", "of"}}, errorExpected: true},
+}
+
+func TestTools_SlugifyWithOptions(t *testing.T) {
+	var tools Tools
+
+	for _, e := range slugOptionsTests {
+		slug, err := tools.SlugifyWithOptions(e.s, e.opts)
+		if e.errorExpected && err == nil {
+			t.Errorf("%s: error expected, but none received", e.name)
+		}
+		if !e.errorExpected && err != nil {
+			t.Errorf("%s: error not expected, but one received: %s", e.name, err)
+		}
+		if slug != e.expected {
+			t.Errorf("%s: expected %q but got %q", e.name, e.expected, slug)
+		}
+	}
+}
+
+func TestTools_UniqueSlug(t *testing.T) {
+	var tools Tools
+	taken := map[string]bool{"hello-world": true}
+	exists := func(slug string) (bool, error) { return taken[slug], nil }
+
+	slug, err := tools.UniqueSlug("Hello World", exists)
+	if err != nil || !strings.HasPrefix(slug, "hello-world-") || len(slug) != len("hello-world-")+6 {
+		t.Errorf("expected suffixed slug, got %q (%v)", slug, err)
+	}
+
+	slug, err = tools.UniqueSlug("Hello World", exists, SlugOptions{MaxLength: 12})
+	if err != nil || len(slug) > 12 {
+		t.Errorf("expected slug within max length, got %q (%v)", slug, err)
+	}
+
+	slug, _ = tools.UniqueSlug("Fresh Title", exists)
+	if slug != "fresh-title" {
+		t.Errorf("expected unchanged slug, got %q", slug)
+	}
+
+	if _, err := tools.UniqueSlug("Hello", func(string) (bool, error) { return true, nil }); err == nil {
+		t.Error("always taken: error expected, but none received")
+	}
+	if _, err := tools.UniqueSlug("Hello", func(string) (bool, error) { return false, errors.New("db down") }); err == nil {
+		t.Error("callback error: error expected, but none received")
+	}
+}
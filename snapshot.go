@@ -0,0 +1,246 @@
+package gohelpertools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// snapshotVersion is the version of the snapshot format written by Cache and the window counters.
+const snapshotVersion = 1
+
+// Snapshotter is an in-memory store whose state can be saved and restored, so that it survives a restart.
+// Cache implements it, as do the counters returned by NewFixedWindow and NewSlidingWindow.
+type Snapshotter interface {
+	Snapshot(w io.Writer) error // writes the current state to w
+	Restore(r io.Reader) error  // adds the state written by Snapshot to the store
+}
+
+// Snapshots saves the state of in-memory stores to a FileSystem and restores it, so that a single-instance
+// deployment doesn't lose its cache, rate limit counters and the like on every deploy. Register the stores,
+// call Restore at startup before serving requests, and Save after http.Server.Shutdown has returned:
+//
+//	snapshots := &gohelpertools.Snapshots{FileSystem: gohelpertools.NewLocalFileSystem("/var/lib/app", "")}
+//	snapshots.Register("users", usersCache)
+//	snapshots.Register("login-attempts", loginAttempts)
+//	if err := snapshots.Restore(ctx); err != nil {
+//		log.Println(err) // the stores start empty
+//	}
+//	...
+//	_ = srv.Shutdown(ctx)
+//	_ = snapshots.Save(ctx)
+//
+// Each store is saved to its own file, named after it, so one corrupt or incompatible snapshot doesn't
+// affect the others.
+type Snapshots struct {
+	FileSystem FileSystem // where the snapshots are kept; required
+	Prefix     string     // prepended to the names of the snapshot files; defaults to "snapshots/"
+	Tools      *Tools     // used to log stores which can't be restored; a zero Tools is used if nil
+
+	mu     sync.Mutex
+	stores map[string]Snapshotter
+}
+
+// Register adds store to the stores saved and restored under name, which must be a valid file name.
+func (s *Snapshots) Register(name string, store Snapshotter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stores == nil {
+		s.stores = make(map[string]Snapshotter)
+	}
+	s.stores[name] = store
+}
+
+// Save writes a snapshot of every registered store. A store which fails doesn't stop the others from being
+// saved; the errors are returned together.
+func (s *Snapshots) Save(ctx context.Context) error {
+	if s.FileSystem == nil {
+		return errors.New("snapshots have no file system")
+	}
+
+	var errs []error
+	for _, name := range s.names() {
+		var buf bytes.Buffer
+		if err := s.store(name).Snapshot(&buf); err != nil {
+			errs = append(errs, fmt.Errorf("unable to snapshot %s: %w", name, err))
+			continue
+		}
+		if err := s.FileSystem.Put(ctx, s.fileName(name), &buf); err != nil {
+			errs = append(errs, fmt.Errorf("unable to save snapshot of %s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Restore loads the snapshot of every registered store which has one. A missing snapshot isn't an error,
+// since there is none on the first start. A snapshot which can't be read or restored is logged, and its
+// error returned together with any others, after the remaining stores have been restored.
+func (s *Snapshots) Restore(ctx context.Context) error {
+	if s.FileSystem == nil {
+		return errors.New("snapshots have no file system")
+	}
+
+	var errs []error
+	for _, name := range s.names() {
+		err := s.restore(ctx, name)
+		if err == nil || errors.Is(err, ErrFileNotFound) {
+			continue
+		}
+		toolsOrDefault(s.Tools).logWarn(ctx, "unable to restore snapshot", "store", name, "error", err.Error())
+		errs = append(errs, fmt.Errorf("unable to restore %s: %w", name, err))
+	}
+	return errors.Join(errs...)
+}
+
+func (s *Snapshots) restore(ctx context.Context, name string) error {
+	file, err := s.FileSystem.Get(ctx, s.fileName(name))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return s.store(name).Restore(file)
+}
+
+func (s *Snapshots) names() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	names := make([]string, 0, len(s.stores))
+	for name := range s.stores {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (s *Snapshots) store(name string) Snapshotter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stores[name]
+}
+
+func (s *Snapshots) fileName(name string) string {
+	prefix := s.Prefix
+	if prefix == "" {
+		prefix = "snapshots/"
+	}
+	return prefix + name + ".json"
+}
+
+// cacheSnapshot is the JSON form of a Cache snapshot. Entries are listed from least to most recently used.
+type cacheSnapshot[K comparable, V any] struct {
+	Version int                        `json:"version"`
+	Entries []cacheSnapshotEntry[K, V] `json:"entries"`
+}
+
+type cacheSnapshotEntry[K comparable, V any] struct {
+	Key     K         `json:"key"`
+	Value   V         `json:"value"`
+	Expires time.Time `json:"expires,omitempty"`
+}
+
+// Snapshot writes the cache's unexpired entries to w as JSON, so both keys and values must be types which
+// round-trip through encoding/json.
+func (c *Cache[K, V]) Snapshot(w io.Writer) error {
+	c.mu.Lock()
+	snapshot := cacheSnapshot[K, V]{Version: snapshotVersion, Entries: make([]cacheSnapshotEntry[K, V], 0, c.order.Len())}
+	now := c.now()
+	for element := c.order.Back(); element != nil; element = element.Prev() {
+		entry := element.Value.(*cacheEntry[K, V])
+		if !entry.expires.IsZero() && now.After(entry.expires) {
+			continue
+		}
+		snapshot.Entries = append(snapshot.Entries, cacheSnapshotEntry[K, V]{Key: entry.key, Value: entry.value, Expires: entry.expires})
+	}
+	c.mu.Unlock()
+
+	return json.NewEncoder(w).Encode(snapshot)
+}
+
+// Restore adds the entries written by Snapshot to the cache, keeping their expiry times and the order in
+// which they were used. Entries which have expired since are skipped.
+func (c *Cache[K, V]) Restore(r io.Reader) error {
+	var snapshot cacheSnapshot[K, V]
+	if err := json.NewDecoder(r).Decode(&snapshot); err != nil {
+		return err
+	}
+	if snapshot.Version != snapshotVersion {
+		return fmt.Errorf("unsupported snapshot version %d", snapshot.Version)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	for _, entry := range snapshot.Entries {
+		var ttl time.Duration
+		if !entry.Expires.IsZero() {
+			if ttl = entry.Expires.Sub(now); ttl <= 0 {
+				continue
+			}
+		}
+		c.set(entry.Key, entry.Value, ttl)
+	}
+	return nil
+}
+
+// windowSnapshot is the JSON form of a window counter snapshot.
+type windowSnapshot struct {
+	Version int                             `json:"version"`
+	Window  time.Duration                   `json:"window"`
+	Buckets map[string]windowSnapshotBucket `json:"buckets"`
+}
+
+type windowSnapshotBucket struct {
+	Start    time.Time `json:"start"`
+	Current  int64     `json:"current"`
+	Previous int64     `json:"previous"`
+}
+
+func (c *windowCounter) Snapshot(w io.Writer) error {
+	c.mu.Lock()
+	snapshot := windowSnapshot{Version: snapshotVersion, Window: c.window, Buckets: make(map[string]windowSnapshotBucket, len(c.buckets))}
+	expiry := c.now().Truncate(c.window).Add(-c.window)
+	for key, bucket := range c.buckets {
+		if bucket.start.Before(expiry) {
+			continue
+		}
+		snapshot.Buckets[key] = windowSnapshotBucket{Start: bucket.start, Current: bucket.current, Previous: bucket.previous}
+	}
+	c.mu.Unlock()
+
+	return json.NewEncoder(w).Encode(snapshot)
+}
+
+// Restore replaces the counts of the keys in the snapshot. It fails if the snapshot was taken with a
+// different window length, since its counts wouldn't mean the same thing.
+func (c *windowCounter) Restore(r io.Reader) error {
+	var snapshot windowSnapshot
+	if err := json.NewDecoder(r).Decode(&snapshot); err != nil {
+		return err
+	}
+	if snapshot.Version != snapshotVersion {
+		return fmt.Errorf("unsupported snapshot version %d", snapshot.Version)
+	}
+	if snapshot.Window != c.window {
+		return fmt.Errorf("snapshot window %s doesn't match counter window %s", snapshot.Window, c.window)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	for key, b := range snapshot.Buckets {
+		bucket, ok := c.buckets[key]
+		if !ok {
+			bucket = &windowBucket{}
+			c.insert(key, bucket)
+		}
+		bucket.start, bucket.current, bucket.previous = b.Start, b.Current, b.Previous
+		c.advance(bucket, now)
+	}
+	return nil
+}
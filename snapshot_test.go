@@ -0,0 +1,95 @@
+package gohelpertools
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSnapshots_SaveAndRestore(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeClock{t: time.Date(2024, 1, 1, 12, 0, 30, 0, time.UTC)}
+	fsys := &MemoryFileSystem{}
+
+	cache := NewCache[string, int](3, time.Minute)
+	cache.now = clock.now
+	cache.Set("a", 1)
+	cache.Set("b", 2, time.Hour)
+	cache.Set("short", 3, time.Second)
+	cache.Get("a") // "b" is now the least recently used entry
+
+	counter := NewFixedWindow(time.Minute, 0).(*windowCounter)
+	counter.now = clock.now
+	counter.Add("login:1.2.3.4", 4)
+
+	saved := &Snapshots{FileSystem: fsys}
+	saved.Register("cache", cache)
+	saved.Register("attempts", counter)
+	if err := saved.Save(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// The next process starts a little later.
+	clock.advance(5 * time.Second)
+	restoredCache := NewCache[string, int](2, time.Minute)
+	restoredCache.now = clock.now
+	restoredCounter := NewFixedWindow(time.Minute, 0).(*windowCounter)
+	restoredCounter.now = clock.now
+
+	restored := &Snapshots{FileSystem: fsys}
+	restored.Register("cache", restoredCache)
+	restored.Register("attempts", restoredCounter)
+	restored.Register("new", NewCache[string, string](0, 0))
+	if err := restored.Restore(ctx); err != nil {
+		t.Fatalf("error not expected, but one received: %s", err)
+	}
+
+	if _, ok := restoredCache.Get("short"); ok {
+		t.Error("expected the expired entry not to be restored")
+	}
+	if value, ok := restoredCache.Get("a"); !ok || value != 1 {
+		t.Errorf("expected a=1 to be restored, got %d %v", value, ok)
+	}
+	restoredCache.Set("c", 3)
+	if _, ok := restoredCache.Get("b"); ok {
+		t.Error("expected the least recently used entry to be evicted first")
+	}
+	clock.advance(time.Minute)
+	if _, ok := restoredCache.Get("a"); ok {
+		t.Error("expected the restored entry to keep its expiry time")
+	}
+
+	clock.advance(-time.Minute)
+	if count := restoredCounter.Add("login:1.2.3.4", 1); count != 5 {
+		t.Errorf("expected the restored count to continue at 5, got %d", count)
+	}
+}
+
+func TestSnapshots_RestoreErrors(t *testing.T) {
+	ctx := context.Background()
+	fsys := &MemoryFileSystem{}
+	_ = fsys.Put(ctx, "snapshots/broken.json", strings.NewReader("{not json"))
+
+	saved := &Snapshots{FileSystem: fsys}
+	saved.Register("counter", NewSlidingWindow(time.Minute, 0))
+	if err := saved.Save(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	cache := NewCache[string, int](0, 0)
+	snapshots := &Snapshots{FileSystem: fsys}
+	snapshots.Register("broken", cache)
+	snapshots.Register("counter", NewSlidingWindow(time.Hour, 0))
+	err := snapshots.Restore(ctx)
+	if err == nil {
+		t.Fatal("error expected, but none received")
+	}
+	if !strings.Contains(err.Error(), "broken") || !strings.Contains(err.Error(), "doesn't match counter window") {
+		t.Errorf("expected errors for both stores, got %s", err)
+	}
+
+	if err := (&Snapshots{}).Save(ctx); err == nil {
+		t.Error("expected an error without a file system")
+	}
+}
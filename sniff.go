@@ -0,0 +1,212 @@
+package gohelpertools
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// sniffLen is how much of a file DetectContentType reads; tar headers put their magic at offset 257, and
+// the first entry of an OpenDocument or EPUB archive names its type within the first hundred bytes.
+const sniffLen = 3072
+
+// magicSignature matches a type by the bytes at an offset from the start of a file.
+type magicSignature struct {
+	offset      int
+	magic       string
+	contentType string
+}
+
+// magicSignatures are checked in order, so more specific signatures come before general ones.
+var magicSignatures = []magicSignature{
+	{0, "%PDF-", "application/pdf"},
+	{0, "\x89PNG\r\n\x1a\n", "image/png"},
+	{0, "\xff\xd8\xff", "image/jpeg"},
+	{0, "GIF87a", "image/gif"},
+	{0, "GIF89a", "image/gif"},
+	{0, "BM", "image/bmp"},
+	{0, "II*\x00", "image/tiff"},
+	{0, "MM\x00*", "image/tiff"},
+	{0, "\x00\x00\x01\x00", "image/x-icon"},
+	{8, "WEBP", "image/webp"},
+	{8, "WAVE", "audio/wav"},
+	{8, "AVI ", "video/x-msvideo"},
+	{0, "ID3", "audio/mpeg"},
+	{0, "OggS", "audio/ogg"},
+	{0, "fLaC", "audio/flac"},
+	{0, "MThd", "audio/midi"},
+	{0, "#!AMR", "audio/amr"},
+	{0, "\x1a\x45\xdf\xa3", "video/webm"},
+	{0, "\x1f\x8b", "application/gzip"},
+	{0, "BZh", "application/x-bzip2"},
+	{0, "\xfd7zXZ\x00", "application/x-xz"},
+	{0, "7z\xbc\xaf\x27\x1c", "application/x-7z-compressed"},
+	{0, "Rar!\x1a\x07", "application/vnd.rar"},
+	{257, "ustar", "application/x-tar"},
+	{0, "\xd0\xcf\x11\xe0\xa1\xb1\x1a\xe1", "application/x-ole-storage"},
+	{0, "{\\rtf", "application/rtf"},
+	{0, "\x00asm", "application/wasm"},
+	{0, "SQLite format 3\x00", "application/vnd.sqlite3"},
+}
+
+// isoBrands maps the major brand of an ISO base media file (the "ftyp" box) to its type.
+var isoBrands = map[string]string{
+	"heic": "image/heic", "heix": "image/heic", "mif1": "image/heif", "msf1": "image/heif",
+	"avif": "image/avif", "avis": "image/avif",
+	"M4A ": "audio/mp4", "M4B ": "audio/mp4",
+	"qt  ": "video/quicktime",
+	"3gp4": "video/3gpp", "3gp5": "video/3gpp", "3g2a": "video/3gpp2",
+}
+
+// officeDirs maps the top-level directory of an Office Open XML archive to its type.
+var officeDirs = map[string]string{
+	"word/": "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	"xl/":   "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	"ppt/":  "application/vnd.openxmlformats-officedocument.presentationml.presentation",
+}
+
+// DetectContentType returns the media type of file from its content. Unlike http.DetectContentType, which
+// it falls back to, it recognizes PDFs, Office documents (DOCX, XLSX and PPTX, and the older OLE formats
+// as application/x-ole-storage), OpenDocument files, archives, and common image, audio and video formats
+// by their magic bytes, whatever the file is called. To tell Office documents from other ZIP files it
+// reads the archive's directory if file is also an io.ReaderAt, as multipart.File is. File is left
+// positioned at its start.
+func (t *Tools) DetectContentType(file io.ReadSeeker) (string, error) {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	head = head[:n]
+
+	contentType := sniffMagic(head)
+	if contentType == "application/zip" {
+		contentType, err = sniffZip(file, head)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	return contentType, nil
+}
+
+// sniffMagic identifies head by its signature, falling back to http.DetectContentType.
+func sniffMagic(head []byte) string {
+	// ISO base media files start with the size of their "ftyp" box, which could look like another signature.
+	if len(head) >= 12 && string(head[4:8]) == "ftyp" {
+		if contentType, ok := isoBrands[string(head[8:12])]; ok {
+			return contentType
+		}
+		return "video/mp4"
+	}
+
+	for _, sig := range magicSignatures {
+		if len(head) >= sig.offset+len(sig.magic) && string(head[sig.offset:sig.offset+len(sig.magic)]) == sig.magic {
+			return sig.contentType
+		}
+	}
+
+	// MPEG audio without an ID3 tag starts with a frame sync: eleven set bits, then a layer which isn't 0.
+	if len(head) >= 2 && head[0] == 0xff && head[1]&0xe0 == 0xe0 && head[1]&0x06 != 0 {
+		return "audio/mpeg"
+	}
+
+	if bytes.HasPrefix(head, []byte("PK\x03\x04")) {
+		return "application/zip"
+	}
+
+	contentType := http.DetectContentType(head)
+	if strings.HasPrefix(contentType, "text/xml") || strings.HasPrefix(contentType, "text/plain") {
+		if bytes.Contains(bytes.ToLower(head), []byte("<svg")) {
+			return "image/svg+xml"
+		}
+	}
+	return contentType
+}
+
+// sniffZip tells Office documents, OpenDocument files, EPUBs and JARs from other ZIP archives.
+func sniffZip(file io.ReadSeeker, head []byte) (string, error) {
+	// OpenDocument and EPUB archives start with an uncompressed "mimetype" entry holding their type.
+	if len(head) > 38 && string(head[30:38]) == "mimetype" && binary.LittleEndian.Uint16(head[8:10]) == 0 {
+		size := int(binary.LittleEndian.Uint32(head[18:22]))
+		start := 30 + int(binary.LittleEndian.Uint16(head[26:28])) + int(binary.LittleEndian.Uint16(head[28:30]))
+		if size == 0 && start < len(head) {
+			// The size is in a data descriptor after the content, when the archive was written as a stream.
+			size = bytes.Index(head[start:], []byte("PK"))
+		}
+		if size > 0 && size < 128 && start+size <= len(head) {
+			return string(head[start : start+size]), nil
+		}
+	}
+
+	names, err := zipNames(file, head)
+	if err != nil {
+		return "", err
+	}
+	hasContentTypes := false
+	for _, name := range names {
+		if name == "[Content_Types].xml" {
+			hasContentTypes = true
+		}
+	}
+	for _, name := range names {
+		if name == "META-INF/MANIFEST.MF" {
+			return "application/java-archive", nil
+		}
+		if !hasContentTypes {
+			continue
+		}
+		for dir, contentType := range officeDirs {
+			if strings.HasPrefix(name, dir) {
+				return contentType, nil
+			}
+		}
+	}
+	return "application/zip", nil
+}
+
+// zipNames returns the names of the entries in a ZIP archive: all of them from its central directory if
+// file is an io.ReaderAt, or else those whose local headers fall within head.
+func zipNames(file io.ReadSeeker, head []byte) ([]string, error) {
+	var names []string
+	if ra, ok := file.(io.ReaderAt); ok {
+		size, err := file.Seek(0, io.SeekEnd)
+		if err != nil {
+			return nil, err
+		}
+		if zr, err := zip.NewReader(ra, size); err == nil {
+			for _, f := range zr.File {
+				names = append(names, f.Name)
+			}
+			return names, nil
+		}
+	}
+
+	for i := 0; i+30 <= len(head); {
+		at := bytes.Index(head[i:], []byte("PK\x03\x04"))
+		if at < 0 {
+			break
+		}
+		i += at
+		if i+30 > len(head) {
+			break
+		}
+		nameLen := int(binary.LittleEndian.Uint16(head[i+26 : i+28]))
+		if i+30+nameLen > len(head) {
+			break
+		}
+		names = append(names, string(head[i+30:i+30+nameLen]))
+		i += 30 + nameLen
+	}
+	return names, nil
+}
@@ -0,0 +1,92 @@
+package gohelpertools
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"testing"
+)
+
+// zipOf returns a ZIP archive holding an empty file for each name, with the first one stored uncompressed
+// and holding content, as in OpenDocument files.
+func zipOf(t *testing.T, content string, names ...string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for i, name := range names {
+		method := zip.Deflate
+		if i == 0 {
+			method = zip.Store
+		}
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: method})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 {
+			_, _ = io.WriteString(w, content)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// seekOnly hides the io.ReaderAt of a bytes.Reader.
+type seekOnly struct{ io.ReadSeeker }
+
+func TestTools_DetectContentType(t *testing.T) {
+	docx := zipOf(t, "<Types/>", "[Content_Types].xml", "_rels/.rels", "word/document.xml")
+	tar := make([]byte, 512)
+	copy(tar[257:], "ustar")
+
+	tests := []struct {
+		name     string
+		content  []byte
+		expected string
+	}{
+		{"pdf", []byte("%PDF-1.4\n%âãÏÓ"), "application/pdf"},
+		{"png", append(pngHeader, "data"...), "image/png"},
+		{"jpeg", []byte("\xff\xd8\xff\xe0\x00\x10JFIF"), "image/jpeg"},
+		{"webp", []byte("RIFF\x24\x00\x00\x00WEBPVP8 "), "image/webp"},
+		{"heic", []byte("\x00\x00\x00\x18ftypheic\x00\x00\x00\x00"), "image/heic"},
+		{"mp4 with box size like an icon", []byte("\x00\x00\x01\x00ftypisom\x00\x00\x02\x00"), "video/mp4"},
+		{"m4a", []byte("\x00\x00\x00\x20ftypM4A \x00\x00\x00\x00"), "audio/mp4"},
+		{"mp3 with id3", []byte("ID3\x04\x00\x00\x00\x00\x00\x00"), "audio/mpeg"},
+		{"mp3 frame", []byte("\xff\xfb\x90\x64\x00"), "audio/mpeg"},
+		{"wav", []byte("RIFF\x24\x00\x00\x00WAVEfmt "), "audio/wav"},
+		{"flac", []byte("fLaC\x00\x00\x00\x22"), "audio/flac"},
+		{"ogg", []byte("OggS\x00\x02"), "audio/ogg"},
+		{"zip", zipOf(t, "hello", "hello.txt"), "application/zip"},
+		{"docx", docx, "application/vnd.openxmlformats-officedocument.wordprocessingml.document"},
+		{"xlsx", zipOf(t, "<Types/>", "[Content_Types].xml", "xl/workbook.xml"), "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"},
+		{"odt", zipOf(t, "application/vnd.oasis.opendocument.text", "mimetype", "content.xml"), "application/vnd.oasis.opendocument.text"},
+		{"ole", []byte("\xd0\xcf\x11\xe0\xa1\xb1\x1a\xe1\x00"), "application/x-ole-storage"},
+		{"tar", tar, "application/x-tar"},
+		{"svg", []byte(`<?xml version="1.0"?><svg xmlns="http://www.w3.org/2000/svg"></svg>`), "image/svg+xml"},
+		{"text", []byte("plain text"), "text/plain; charset=utf-8"},
+		{"empty", nil, "text/plain; charset=utf-8"},
+	}
+
+	var tools Tools
+	for _, e := range tests {
+		file := bytes.NewReader(e.content)
+		_, _ = file.Seek(3, io.SeekStart)
+		contentType, err := tools.DetectContentType(file)
+		if err != nil {
+			t.Errorf("%s: error not expected, but one received: %s", e.name, err)
+			continue
+		}
+		if contentType != e.expected {
+			t.Errorf("%s: expected %s, but got %s", e.name, e.expected, contentType)
+		}
+		if pos, _ := file.Seek(0, io.SeekCurrent); pos != 0 {
+			t.Errorf("%s: expected the file to be rewound, but it is at %d", e.name, pos)
+		}
+	}
+
+	// Without io.ReaderAt, the local file headers at the start of the archive are used.
+	contentType, err := tools.DetectContentType(seekOnly{bytes.NewReader(docx)})
+	if err != nil || contentType != "application/vnd.openxmlformats-officedocument.wordprocessingml.document" {
+		t.Errorf("expected a docx without io.ReaderAt, but got %s (%v)", contentType, err)
+	}
+}
@@ -0,0 +1,244 @@
+package gohelpertools
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const (
+	soap11Namespace = "http://schemas.xmlsoap.org/soap/envelope/"
+	soap12Namespace = "http://www.w3.org/2003/05/soap-envelope"
+)
+
+// SOAPFault is the error returned when a SOAP endpoint responds with a fault. SOAP 1.1 and 1.2 faults are
+// both read into it.
+type SOAPFault struct {
+	Code       string // faultcode, or the value of Code in SOAP 1.2, such as "soap:Server"
+	Message    string // faultstring, or the first Text of Reason in SOAP 1.2
+	Actor      string // faultactor, or Role in SOAP 1.2; usually empty
+	Detail     string // the raw XML inside the detail element, which may be unmarshalled into a type of its own
+	StatusCode int    // the HTTP status of the response, usually 500
+}
+
+// Error returns the fault code and message.
+func (f *SOAPFault) Error() string {
+	return fmt.Sprintf("soap fault %s: %s", f.Code, f.Message)
+}
+
+// SOAPClient calls the SOAP endpoint at URL. It builds the envelope around request bodies, sets the action
+// headers, and reads the body or fault out of responses, which is enough to talk to most legacy services
+// without generated code. Bodies are marshalled and unmarshalled with encoding/xml, so their types need
+// an XMLName or struct tags naming the operation's elements.
+type SOAPClient struct {
+	URL     string       // the SOAP endpoint; required
+	SOAP12  bool         // if set to true, send SOAP 1.2 envelopes; SOAP 1.1 is used by default
+	Header  any          // if set, marshalled into the soap:Header element of every request, such as a WS-Security token
+	Client  *http.Client // used to send requests; Tools.HTTPClient is used if nil
+	MaxSize int          // maximum size of a response, in bytes; defaults to 10MB
+	Tools   *Tools       // a zero Tools is used if nil
+}
+
+// Call sends reqBody, in an envelope, as the operation action, and unmarshals the element in the body of
+// the response into respBody, which may be nil for operations with no response. A fault returns a
+// *SOAPFault; any other response which isn't a SOAP envelope returns an ordinary error.
+func (c *SOAPClient) Call(ctx context.Context, action string, reqBody, respBody any) error {
+	envelope, err := c.envelope(reqBody)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL, bytes.NewReader(envelope))
+	if err != nil {
+		return err
+	}
+	if c.SOAP12 {
+		contentType := "application/soap+xml; charset=utf-8"
+		if action != "" {
+			contentType += `; action="` + action + `"`
+		}
+		req.Header.Set("Content-Type", contentType)
+		req.Header.Set("Accept", "application/soap+xml")
+	} else {
+		req.Header.Set("Content-Type", "text/xml; charset=utf-8")
+		req.Header.Set("Accept", "text/xml")
+		req.Header.Set("SOAPAction", `"`+action+`"`)
+	}
+
+	client := c.Client
+	if client == nil {
+		client = toolsOrDefault(c.Tools).HTTPClient()
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	maxSize := c.MaxSize
+	if maxSize <= 0 {
+		maxSize = defaultMaxUpload
+	}
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, int64(maxSize)+1))
+	if err != nil {
+		return err
+	}
+	if len(raw) > maxSize {
+		return fmt.Errorf("soap response is larger than %d bytes", maxSize)
+	}
+
+	ok := resp.StatusCode >= 200 && resp.StatusCode <= 299
+	if ok && len(bytes.TrimSpace(raw)) == 0 {
+		// One-way operations may be answered with 202 Accepted and no envelope.
+		return nil
+	}
+	err = readSOAPBody(raw, respBody)
+
+	var fault *SOAPFault
+	switch {
+	case errors.As(err, &fault):
+		fault.StatusCode = resp.StatusCode
+		return fault
+	case !ok:
+		return fmt.Errorf("soap request failed with status %d", resp.StatusCode)
+	}
+	return err
+}
+
+// envelope marshals body, and the client's Header if there is one, into a SOAP envelope.
+func (c *SOAPClient) envelope(body any) ([]byte, error) {
+	namespace := soap11Namespace
+	if c.SOAP12 {
+		namespace = soap12Namespace
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	buf.WriteString(`<soap:Envelope xmlns:soap="` + namespace + `">`)
+	if c.Header != nil {
+		header, err := xml.Marshal(c.Header)
+		if err != nil {
+			return nil, fmt.Errorf("encoding soap header: %w", err)
+		}
+		buf.WriteString("<soap:Header>")
+		buf.Write(header)
+		buf.WriteString("</soap:Header>")
+	}
+	buf.WriteString("<soap:Body>")
+	if body != nil {
+		out, err := xml.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("encoding soap body: %w", err)
+		}
+		buf.Write(out)
+	}
+	buf.WriteString("</soap:Body></soap:Envelope>")
+	return buf.Bytes(), nil
+}
+
+// soapFaultXML holds the elements of both SOAP 1.1 and SOAP 1.2 faults.
+type soapFaultXML struct {
+	FaultCode   string `xml:"faultcode"`
+	FaultString string `xml:"faultstring"`
+	FaultActor  string `xml:"faultactor"`
+	Detail      struct {
+		Inner string `xml:",innerxml"`
+	} `xml:"detail"`
+
+	Code struct {
+		Value string `xml:"Value"`
+	} `xml:"Code"`
+	Reason struct {
+		Text []string `xml:"Text"`
+	} `xml:"Reason"`
+	Role     string `xml:"Role"`
+	Detail12 struct {
+		Inner string `xml:",innerxml"`
+	} `xml:"Detail"`
+}
+
+// readSOAPBody finds the Body of the SOAP envelope in raw, and unmarshals its first element into dest, or
+// returns a *SOAPFault if it's a Fault. The element is decoded in place, so namespace prefixes declared on
+// the envelope still resolve.
+func readSOAPBody(raw []byte, dest any) error {
+	dec := xml.NewDecoder(bytes.NewReader(raw))
+	depth := 0
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return errors.New("soap response is not a valid envelope")
+		}
+		if err != nil {
+			return fmt.Errorf("soap response is not valid xml: %w", err)
+		}
+
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			switch {
+			case depth == 0 && tok.Name.Local != "Envelope":
+				return errors.New("soap response is not a valid envelope")
+			case depth == 2:
+				if tok.Name.Local == "Fault" && (tok.Name.Space == soap11Namespace || tok.Name.Space == soap12Namespace) {
+					var f soapFaultXML
+					if err := dec.DecodeElement(&f, &tok); err != nil {
+						return fmt.Errorf("decoding soap fault: %w", err)
+					}
+					return f.fault()
+				}
+				if dest == nil {
+					return nil
+				}
+				if err := dec.DecodeElement(dest, &tok); err != nil {
+					return fmt.Errorf("decoding soap body: %w", err)
+				}
+				return nil
+			case depth == 1 && tok.Name.Local != "Body":
+				// Skip the soap:Header of the response.
+				if err := dec.Skip(); err != nil {
+					return fmt.Errorf("soap response is not valid xml: %w", err)
+				}
+				continue
+			}
+			depth++
+		case xml.EndElement:
+			if depth == 2 {
+				// An empty Body.
+				return nil
+			}
+			depth--
+		}
+	}
+}
+
+// fault converts f into a SOAPFault, using the SOAP 1.1 elements if they're present.
+func (f soapFaultXML) fault() *SOAPFault {
+	if f.FaultCode != "" || f.FaultString != "" {
+		return &SOAPFault{
+			Code:    strings.TrimSpace(f.FaultCode),
+			Message: strings.TrimSpace(f.FaultString),
+			Actor:   strings.TrimSpace(f.FaultActor),
+			Detail:  strings.TrimSpace(f.Detail.Inner),
+		}
+	}
+
+	fault := &SOAPFault{
+		Code:   strings.TrimSpace(f.Code.Value),
+		Actor:  strings.TrimSpace(f.Role),
+		Detail: strings.TrimSpace(f.Detail12.Inner),
+	}
+	if len(f.Reason.Text) > 0 {
+		fault.Message = strings.TrimSpace(f.Reason.Text[0])
+	}
+	return fault
+}
+
+// CallSOAP sends reqBody to the SOAP 1.1 endpoint as the operation action, and unmarshals the body of the
+// response into respBody. See SOAPClient.Call, and use a SOAPClient for SOAP 1.2 or to send a soap:Header.
+func (t *Tools) CallSOAP(ctx context.Context, endpoint, action string, reqBody, respBody any) error {
+	return (&SOAPClient{URL: endpoint, Tools: t}).Call(ctx, action, reqBody, respBody)
+}
@@ -0,0 +1,97 @@
+package gohelpertools
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type addRequest struct {
+	XMLName xml.Name `xml:"http://example.com/calc Add"`
+	A       int      `xml:"a"`
+	B       int      `xml:"b"`
+}
+
+type addResponse struct {
+	XMLName xml.Name `xml:"http://example.com/calc AddResponse"`
+	Result  int      `xml:"result"`
+}
+
+func TestSOAPClient_Call(t *testing.T) {
+	var contentType, soapAction, body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := io.ReadAll(r.Body)
+		contentType, soapAction, body = r.Header.Get("Content-Type"), r.Header.Get("SOAPAction"), string(raw)
+
+		switch r.URL.Path {
+		case "/add":
+			_, _ = w.Write([]byte(`<?xml version="1.0"?>
+<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/" xmlns:c="http://example.com/calc">
+	<soap:Header><c:Trace>abc</c:Trace></soap:Header>
+	<soap:Body><c:AddResponse><c:result>5</c:result></c:AddResponse></soap:Body>
+</soap:Envelope>`))
+		case "/fault":
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(`<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body><soap:Fault>
+	<faultcode>soap:Client</faultcode><faultstring>b must be positive</faultstring>
+	<detail><code>42</code></detail>
+</soap:Fault></soap:Body></soap:Envelope>`))
+		case "/fault12":
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(`<env:Envelope xmlns:env="http://www.w3.org/2003/05/soap-envelope"><env:Body><env:Fault>
+	<env:Code><env:Value>env:Receiver</env:Value></env:Code>
+	<env:Reason><env:Text xml:lang="en">database unavailable</env:Text></env:Reason>
+</env:Fault></env:Body></env:Envelope>`))
+		case "/oneway":
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			w.WriteHeader(http.StatusBadGateway)
+			_, _ = w.Write([]byte("<html>bad gateway</html>"))
+		}
+	}))
+	defer srv.Close()
+
+	var tools Tools
+	var resp addResponse
+	if err := tools.CallSOAP(context.Background(), srv.URL+"/add", "http://example.com/calc/Add", addRequest{A: 2, B: 3}, &resp); err != nil || resp.Result != 5 {
+		t.Fatalf("unexpected result %+v: %v", resp, err)
+	}
+	if contentType != "text/xml; charset=utf-8" || soapAction != `"http://example.com/calc/Add"` {
+		t.Errorf("unexpected headers %q and %q", contentType, soapAction)
+	}
+	if !strings.Contains(body, `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body><Add xmlns="http://example.com/calc"><a>2</a><b>3</b></Add></soap:Body>`) {
+		t.Errorf("unexpected envelope %s", body)
+	}
+
+	err := tools.CallSOAP(context.Background(), srv.URL+"/fault", "Add", addRequest{}, &resp)
+	var fault *SOAPFault
+	if !errors.As(err, &fault) || fault.Code != "soap:Client" || fault.Message != "b must be positive" || fault.Detail != "<code>42</code>" || fault.StatusCode != http.StatusInternalServerError {
+		t.Errorf("expected a SOAP 1.1 fault, got %#v", err)
+	}
+
+	client := &SOAPClient{URL: srv.URL + "/fault12", SOAP12: true, Header: struct {
+		XMLName xml.Name `xml:"Token"`
+		Value   string   `xml:",chardata"`
+	}{Value: "secret"}}
+	err = client.Call(context.Background(), "Add", addRequest{}, nil)
+	if !errors.As(err, &fault) || fault.Code != "env:Receiver" || fault.Message != "database unavailable" {
+		t.Errorf("expected a SOAP 1.2 fault, got %#v", err)
+	}
+	if contentType != `application/soap+xml; charset=utf-8; action="Add"` || soapAction != "" || !strings.Contains(body, "<soap:Header><Token>secret</Token></soap:Header>") {
+		t.Errorf("unexpected SOAP 1.2 request %q %q %s", contentType, soapAction, body)
+	}
+
+	if err := tools.CallSOAP(context.Background(), srv.URL+"/oneway", "Notify", addRequest{}, nil); err != nil {
+		t.Errorf("error not expected, but one received: %s", err)
+	}
+
+	err = tools.CallSOAP(context.Background(), srv.URL+"/down", "Add", addRequest{}, &resp)
+	if err == nil || errors.As(err, &fault) || err.Error() != "soap request failed with status 502" {
+		t.Errorf("expected an HTTP error, got %v", err)
+	}
+}
@@ -0,0 +1,182 @@
+package gohelpertools
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultStaticMaxAge = 365 * 24 * time.Hour
+
+// StaticServer serves the files of an fs.FS, such as an embed.FS, under a URL prefix. Each file is also
+// available under a fingerprinted name containing a hash of its content (css/app.css as
+// css/app.3f2a1b9c.css), which is served with a long-lived, immutable Cache-Control header, since the name
+// changes whenever the content does. Templates link to the fingerprinted name with AssetPath. If a client
+// accepts brotli or gzip, and the FS contains a pre-compressed copy (app.css.br or app.css.gz), that is
+// served instead.
+type StaticServer struct {
+	MaxAge time.Duration // Cache-Control max-age of fingerprinted files; defaults to one year
+	Tools  *Tools        // used to write JSON error responses; a zero Tools is used if nil
+
+	fsys     fs.FS
+	prefix   string
+	hashes   map[string]string // file name to content hash
+	hashed   map[string]string // fingerprinted name to file name
+	compress map[string]bool   // names of pre-compressed copies, such as app.css.gz
+}
+
+// NewStaticServer hashes every file in fsys, and returns a StaticServer serving them under prefix, such
+// as "/static/".
+func NewStaticServer(fsys fs.FS, prefix string) (*StaticServer, error) {
+	s := &StaticServer{
+		fsys:     fsys,
+		prefix:   "/" + strings.Trim(prefix, "/") + "/",
+		hashes:   make(map[string]string),
+		hashed:   make(map[string]string),
+		compress: make(map[string]bool),
+	}
+	if s.prefix == "//" {
+		s.prefix = "/"
+	}
+
+	// A .br or .gz file is a pre-compressed copy only if the uncompressed original is there too; otherwise,
+	// such as data.tar.gz, it's a file of its own.
+	var compressed []string
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		if strings.HasSuffix(name, ".br") || strings.HasSuffix(name, ".gz") {
+			compressed = append(compressed, name)
+			return nil
+		}
+		return s.add(name)
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range compressed {
+		if _, ok := s.hashes[name[:len(name)-3]]; ok {
+			s.compress[name] = true
+		} else if err := s.add(name); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// add hashes the file name, so that it's served.
+func (s *StaticServer) add(name string) error {
+	data, err := fs.ReadFile(s.fsys, name)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:4])
+	s.hashes[name] = hash
+	s.hashed[fingerprint(name, hash)] = name
+	return nil
+}
+
+// AssetPath returns the URL path of the fingerprinted copy of name, such as "/static/css/app.3f2a1b9c.css"
+// for "css/app.css". If there is no such file, the unfingerprinted path is returned.
+func (s *StaticServer) AssetPath(name string) string {
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	if hash, ok := s.hashes[name]; ok {
+		return s.prefix + fingerprint(name, hash)
+	}
+	return s.prefix + name
+}
+
+// ServeHTTP serves the file named by the request path.
+func (s *StaticServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	t := toolsOrDefault(s.Tools)
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		_ = t.ErrorJSON(w, errors.New("method not allowed"), http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := path.Clean("/" + r.URL.Path)
+	if !strings.HasPrefix(name, s.prefix) {
+		t.NotFoundHandler().ServeHTTP(w, r)
+		return
+	}
+	name = strings.TrimPrefix(name, s.prefix)
+
+	immutable := false
+	if original, ok := s.hashed[name]; ok {
+		name, immutable = original, true
+	}
+	hash, ok := s.hashes[name]
+	if !ok {
+		t.NotFoundHandler().ServeHTTP(w, r)
+		return
+	}
+
+	if immutable {
+		maxAge := s.MaxAge
+		if maxAge == 0 {
+			maxAge = defaultStaticMaxAge
+		}
+		w.Header().Set("Cache-Control", "public, max-age="+strconv.FormatInt(int64(maxAge.Seconds()), 10)+", immutable")
+	} else {
+		w.Header().Set("Cache-Control", "no-cache")
+	}
+	if ctype := mime.TypeByExtension(path.Ext(name)); ctype != "" {
+		w.Header().Set("Content-Type", ctype)
+	}
+
+	file, etag := name, `"`+hash+`"`
+	if s.hasCompressed(name) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		for _, encoding := range []struct{ name, ext string }{{"br", ".br"}, {"gzip", ".gz"}} {
+			if s.compress[name+encoding.ext] && acceptQuality(r.Header.Get("Accept-Encoding"), encoding.name) > 0 {
+				file, etag = name+encoding.ext, `"`+hash+"-"+encoding.name+`"`
+				w.Header().Set("Content-Encoding", encoding.name)
+				break
+			}
+		}
+	}
+	w.Header().Set("ETag", etag)
+
+	f, err := s.fsys.Open(file)
+	if err != nil {
+		_ = t.ErrorJSON(w, errors.New("the file could not be read"), http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+
+	// http.ServeContent needs to seek, to answer range requests. Files in an embed.FS or os.DirFS can, but
+	// other file systems' files may need to be read into memory.
+	content, ok := f.(io.ReadSeeker)
+	if !ok {
+		data, err := io.ReadAll(f)
+		if err != nil {
+			_ = t.ErrorJSON(w, errors.New("the file could not be read"), http.StatusInternalServerError)
+			return
+		}
+		content = bytes.NewReader(data)
+	}
+	http.ServeContent(w, r, "", time.Time{}, content)
+}
+
+func (s *StaticServer) hasCompressed(name string) bool {
+	return s.compress[name+".br"] || s.compress[name+".gz"]
+}
+
+// fingerprint inserts hash before the extension of name.
+func fingerprint(name, hash string) string {
+	ext := path.Ext(name)
+	return strings.TrimSuffix(name, ext) + "." + hash + ext
+}
@@ -0,0 +1,116 @@
+package gohelpertools
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestStaticServer(t *testing.T) {
+	files := fstest.MapFS{
+		"css/app.css":    {Data: []byte("body { color: red; }")},
+		"css/app.css.gz": {Data: []byte("gzipped")},
+		"css/app.css.br": {Data: []byte("brotli")},
+		"js/app.js":      {Data: []byte("console.log('hi')")},
+	}
+
+	s, err := NewStaticServer(files, "/static")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	asset := s.AssetPath("css/app.css")
+	if !strings.HasPrefix(asset, "/static/css/app.") || !strings.HasSuffix(asset, ".css") || asset == "/static/css/app.css" {
+		t.Fatalf("unexpected fingerprinted path %q", asset)
+	}
+	if got := s.AssetPath("missing.png"); got != "/static/missing.png" {
+		t.Errorf("expected unknown assets to be unfingerprinted, got %q", got)
+	}
+
+	var tests = []struct {
+		name         string
+		path         string
+		encoding     string
+		wantStatus   int
+		wantBody     string
+		wantCache    string
+		wantEncoding string
+	}{
+		{name: "fingerprinted", path: asset, wantStatus: http.StatusOK, wantBody: "body { color: red; }", wantCache: "public, max-age=31536000, immutable"},
+		{name: "plain name", path: "/static/css/app.css", wantStatus: http.StatusOK, wantBody: "body { color: red; }", wantCache: "no-cache"},
+		{name: "brotli preferred", path: asset, encoding: "gzip, br", wantStatus: http.StatusOK, wantBody: "brotli", wantEncoding: "br"},
+		{name: "gzip", path: asset, encoding: "gzip", wantStatus: http.StatusOK, wantBody: "gzipped", wantEncoding: "gzip"},
+		{name: "no compressed copy", path: "/static/js/app.js", encoding: "gzip", wantStatus: http.StatusOK, wantBody: "console.log('hi')"},
+		{name: "missing", path: "/static/nope.css", wantStatus: http.StatusNotFound},
+		{name: "outside prefix", path: "/css/app.css", wantStatus: http.StatusNotFound},
+		{name: "traversal", path: "/static/../static/css/../../etc/passwd", wantStatus: http.StatusNotFound},
+	}
+
+	for _, e := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.URL.Path = e.path
+		if e.encoding != "" {
+			req.Header.Set("Accept-Encoding", e.encoding)
+		}
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, req)
+
+		if rr.Code != e.wantStatus {
+			t.Errorf("%s: expected status %d but got %d", e.name, e.wantStatus, rr.Code)
+			continue
+		}
+		if e.wantBody != "" && rr.Body.String() != e.wantBody {
+			t.Errorf("%s: expected body %q but got %q", e.name, e.wantBody, rr.Body.String())
+		}
+		if e.wantCache != "" && rr.Header().Get("Cache-Control") != e.wantCache {
+			t.Errorf("%s: expected Cache-Control %q but got %q", e.name, e.wantCache, rr.Header().Get("Cache-Control"))
+		}
+		if rr.Header().Get("Content-Encoding") != e.wantEncoding {
+			t.Errorf("%s: expected Content-Encoding %q but got %q", e.name, e.wantEncoding, rr.Header().Get("Content-Encoding"))
+		}
+		if e.wantStatus == http.StatusOK && !strings.HasPrefix(rr.Header().Get("Content-Type"), "text/") {
+			t.Errorf("%s: unexpected Content-Type %q", e.name, rr.Header().Get("Content-Type"))
+		}
+	}
+}
+
+func TestStaticServer_NotModified(t *testing.T) {
+	s, err := NewStaticServer(fstest.MapFS{"a.txt": {Data: []byte("hello")}}, "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/a.txt", nil))
+
+	req := httptest.NewRequest(http.MethodGet, "/a.txt", nil)
+	req.Header.Set("If-None-Match", rr.Header().Get("ETag"))
+	second := httptest.NewRecorder()
+	s.ServeHTTP(second, req)
+
+	if second.Code != http.StatusNotModified {
+		t.Errorf("expected status 304 but got %d", second.Code)
+	}
+}
+
+func TestStaticServer_CompressedFiles(t *testing.T) {
+	s, err := NewStaticServer(fstest.MapFS{
+		"data.tar.gz": {Data: []byte("archive")},
+		"font.br":     {Data: []byte("font")},
+	}, "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"data.tar.gz", "font.br"} {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/"+name, nil)
+		req.Header.Set("Accept-Encoding", "gzip, br")
+		s.ServeHTTP(rr, req)
+		if rr.Code != http.StatusOK || rr.Header().Get("Content-Encoding") != "" {
+			t.Errorf("%s: expected a file without an original to be served as it is, got %d %v", name, rr.Code, rr.Header())
+		}
+	}
+}
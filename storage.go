@@ -0,0 +1,270 @@
+package gohelpertools
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrFileNotFound is returned by a FileSystem when a file doesn't exist.
+var ErrFileNotFound = errors.New("file not found")
+
+// FileSystem stores files by name, such as "avatars/42.png". UploadFilesTo and DownloadFile work through
+// it, so that handlers don't change when files move from local disk to object storage such as S3 or
+// MinIO. Names use forward slashes and must be valid fs.FS paths.
+type FileSystem interface {
+	Put(ctx context.Context, name string, r io.Reader) error     // creates or replaces the file
+	Get(ctx context.Context, name string) (io.ReadCloser, error) // opens the file; ErrFileNotFound if it doesn't exist
+	Delete(ctx context.Context, name string) error               // removes the file; deleting a missing file isn't an error
+	List(ctx context.Context, prefix string) ([]StoredFile, error)
+	URL(ctx context.Context, name string) (string, error) // a URL clients can fetch the file from
+}
+
+// StoredFile describes a file in a FileSystem.
+type StoredFile struct {
+	Name    string
+	Size    int64
+	ModTime time.Time
+}
+
+// LocalFileSystem is a FileSystem in a directory on local disk.
+type LocalFileSystem struct {
+	Root    string // directory the files are stored in; created when the first file is put
+	BaseURL string // URL the directory is served at, used by URL, such as "/uploads/"
+}
+
+// NewLocalFileSystem returns a LocalFileSystem storing files in root.
+func NewLocalFileSystem(root, baseURL string) *LocalFileSystem {
+	return &LocalFileSystem{Root: root, BaseURL: baseURL}
+}
+
+// Put writes r to the file name, through a temporary file, so readers never see a partial file.
+func (l *LocalFileSystem) Put(ctx context.Context, name string, r io.Reader) error {
+	target, err := l.path(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), target)
+}
+
+// Get opens the file name.
+func (l *LocalFileSystem) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	target, err := l.path(name)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(target)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrFileNotFound
+	}
+	return f, err
+}
+
+// Delete removes the file name.
+func (l *LocalFileSystem) Delete(ctx context.Context, name string) error {
+	target, err := l.path(name)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(target); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// List returns the files whose names start with prefix, sorted by name.
+func (l *LocalFileSystem) List(ctx context.Context, prefix string) ([]StoredFile, error) {
+	var files []StoredFile
+	err := filepath.WalkDir(l.Root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".upload-") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(l.Root, p)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+		if !strings.HasPrefix(name, prefix) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		files = append(files, StoredFile{Name: name, Size: info.Size(), ModTime: info.ModTime()})
+		return nil
+	})
+	return files, err
+}
+
+// URL returns the file's URL under BaseURL.
+func (l *LocalFileSystem) URL(ctx context.Context, name string) (string, error) {
+	if _, err := l.path(name); err != nil {
+		return "", err
+	}
+	return joinFileURL(l.BaseURL, name), nil
+}
+
+func (l *LocalFileSystem) path(name string) (string, error) {
+	if !fs.ValidPath(name) || name == "." {
+		return "", fmt.Errorf("invalid file name %q", name)
+	}
+	return filepath.Join(l.Root, filepath.FromSlash(name)), nil
+}
+
+// MemoryFileSystem is a FileSystem held in memory, for tests.
+type MemoryFileSystem struct {
+	BaseURL string // URL prefix used by URL
+
+	mu    sync.Mutex
+	files map[string]memoryFile
+}
+
+type memoryFile struct {
+	data    []byte
+	modTime time.Time
+}
+
+// Put stores the contents of r as name.
+func (m *MemoryFileSystem) Put(ctx context.Context, name string, r io.Reader) error {
+	if !fs.ValidPath(name) || name == "." {
+		return fmt.Errorf("invalid file name %q", name)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.files == nil {
+		m.files = make(map[string]memoryFile)
+	}
+	m.files[name] = memoryFile{data: data, modTime: time.Now()}
+	return nil
+}
+
+// Get returns a reader for name.
+func (m *MemoryFileSystem) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	file, ok := m.files[name]
+	if !ok {
+		return nil, ErrFileNotFound
+	}
+	return io.NopCloser(bytes.NewReader(file.data)), nil
+}
+
+// Delete removes name.
+func (m *MemoryFileSystem) Delete(ctx context.Context, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.files, name)
+	return nil
+}
+
+// List returns the files whose names start with prefix, sorted by name.
+func (m *MemoryFileSystem) List(ctx context.Context, prefix string) ([]StoredFile, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var files []StoredFile
+	for name, file := range m.files {
+		if strings.HasPrefix(name, prefix) {
+			files = append(files, StoredFile{Name: name, Size: int64(len(file.data)), ModTime: file.modTime})
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+	return files, nil
+}
+
+// URL returns name under BaseURL.
+func (m *MemoryFileSystem) URL(ctx context.Context, name string) (string, error) {
+	return joinFileURL(m.BaseURL, name), nil
+}
+
+// DownloadFile sends the file name from fsys to the client as an attachment called displayName (the base
+// of name if empty), so browsers save it rather than display it.
+func (t *Tools) DownloadFile(w http.ResponseWriter, r *http.Request, fsys FileSystem, name, displayName string) error {
+	f, err := fsys.Get(r.Context(), name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	displayName = valueOrDefault(displayName, path.Base(name))
+	contentType := mime.TypeByExtension(path.Ext(displayName))
+	w.Header().Set("Content-Type", valueOrDefault(contentType, "application/octet-stream"))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": displayName}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	// Local files support range requests and conditional requests, with an ETag from a fast hash of the
+	// content unless the caller has set one.
+	if file, ok := f.(*os.File); ok {
+		if info, err := file.Stat(); err == nil {
+			if w.Header().Get("ETag") == "" {
+				if sum, err := t.StreamChecksum(file, ChecksumXXHash); err == nil {
+					w.Header().Set("ETag", sum.ETag())
+				}
+				if _, err := file.Seek(0, io.SeekStart); err != nil {
+					return err
+				}
+			}
+			http.ServeContent(w, r, displayName, info.ModTime(), file)
+			return nil
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, err = io.Copy(w, f)
+	return err
+}
+
+func joinFileURL(base, name string) string {
+	segments := strings.Split(name, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.TrimSuffix(base, "/") + "/" + strings.Join(segments, "/")
+}
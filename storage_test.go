@@ -0,0 +1,89 @@
+package gohelpertools
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"testing"
+)
+
+func TestFileSystems(t *testing.T) {
+	ctx := context.Background()
+	systems := map[string]FileSystem{
+		"local":  NewLocalFileSystem(t.TempDir(), "/uploads/"),
+		"memory": &MemoryFileSystem{BaseURL: "/uploads"},
+	}
+
+	for name, fsys := range systems {
+		for _, file := range []string{"a.txt", "docs/b.txt", "docs/c d.txt"} {
+			if err := fsys.Put(ctx, file, strings.NewReader("content of "+file)); err != nil {
+				t.Fatalf("%s: %s", name, err)
+			}
+		}
+		if err := fsys.Put(ctx, "../escape.txt", strings.NewReader("x")); err == nil {
+			t.Errorf("%s: expected an invalid name to be rejected", name)
+		}
+
+		f, err := fsys.Get(ctx, "docs/b.txt")
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		data, _ := io.ReadAll(f)
+		f.Close()
+		if string(data) != "content of docs/b.txt" {
+			t.Errorf("%s: unexpected content %q", name, data)
+		}
+
+		files, err := fsys.List(ctx, "docs/")
+		if err != nil || len(files) != 2 || files[0].Name != "docs/b.txt" || files[1].Size != int64(len("content of docs/c d.txt")) {
+			t.Errorf("%s: unexpected listing %+v, %v", name, files, err)
+		}
+
+		if u, _ := fsys.URL(ctx, "docs/c d.txt"); u != "/uploads/docs/c%20d.txt" {
+			t.Errorf("%s: unexpected URL %s", name, u)
+		}
+
+		if err := fsys.Delete(ctx, "a.txt"); err != nil {
+			t.Errorf("%s: %s", name, err)
+		}
+		if _, err := fsys.Get(ctx, "a.txt"); !errors.Is(err, ErrFileNotFound) {
+			t.Errorf("%s: expected ErrFileNotFound after deleting, got %v", name, err)
+		}
+		if err := fsys.Delete(ctx, "a.txt"); err != nil {
+			t.Errorf("%s: expected deleting a missing file to succeed, got %v", name, err)
+		}
+	}
+}
+
+func TestTools_UploadFilesToAndDownloadFile(t *testing.T) {
+	var tools Tools
+	fsys := &MemoryFileSystem{}
+
+	req := newUploadRequest(t, map[string][]byte{"report.csv": []byte("a,b\n1,2\n")}, map[string]textproto.MIMEHeader{})
+	files, err := tools.UploadFilesTo(req, fsys, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if files[0].NewFileName != "report.csv" || files[0].FileSize != 8 {
+		t.Errorf("unexpected upload %+v", files[0])
+	}
+
+	rr := httptest.NewRecorder()
+	if err := tools.DownloadFile(rr, httptest.NewRequest(http.MethodGet, "/", nil), fsys, "report.csv", "My Report.csv"); err != nil {
+		t.Fatal(err)
+	}
+	if rr.Body.String() != "a,b\n1,2\n" {
+		t.Errorf("unexpected body %q", rr.Body.String())
+	}
+	if cd := rr.Header().Get("Content-Disposition"); cd != `attachment; filename="My Report.csv"` {
+		t.Errorf("unexpected Content-Disposition %s", cd)
+	}
+
+	if err := tools.DownloadFile(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), fsys, "missing.csv", ""); !errors.Is(err, ErrFileNotFound) {
+		t.Errorf("expected ErrFileNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,296 @@
+package gohelpertools
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrStreamClosed is returned by Stream.Send once the stream has been closed, either by the server or
+// because the client went away or fell too far behind.
+var ErrStreamClosed = errors.New("stream is closed")
+
+// StreamFormat is the wire format of a Stream.
+type StreamFormat int
+
+const (
+	StreamSSE    StreamFormat = iota // text/event-stream (server-sent events)
+	StreamNDJSON                     // application/x-ndjson, one JSON value per line
+)
+
+// SlowClientPolicy decides what happens when a client's queue is full.
+type SlowClientPolicy int
+
+const (
+	DropOldest  SlowClientPolicy = iota // discard the oldest queued event to make room
+	DropNewest                          // discard the event being sent
+	CloseClient                         // close the stream
+)
+
+// StreamOptions configures a Stream.
+type StreamOptions struct {
+	Format       StreamFormat      // defaults to StreamSSE
+	QueueSize    int               // events which may be waiting for a client; defaults to 64
+	Policy       SlowClientPolicy  // what to do when the queue is full; defaults to DropOldest
+	MaxDropped   int               // if above zero, the stream is closed once this many events have been dropped
+	Heartbeat    time.Duration     // interval between SSE keep-alive comments; defaults to 15 seconds, negative disables
+	OnSlowClient func(dropped int) // if set, called each time an event is dropped or the client is closed for being slow
+	Drainer      *Drainer          // if set, the stream is sent a notice and closed when the server drains
+}
+
+// StreamEvent is one message on a Stream. Event, ID and Retry are only used by the SSE format.
+type StreamEvent struct {
+	Event string
+	ID    string
+	Retry time.Duration // if set, tells the client how long to wait before reconnecting
+	Data  any
+}
+
+// Stream sends events to one client as server-sent events or NDJSON. Producers call Send, which never
+// blocks: events are queued per client, and when a client can't keep up the queue limit and policy decide
+// what is dropped, so one stuck client can't make memory grow without bound or hold up the producers.
+// Serve writes queued events to the client, and must run in the handler's goroutine.
+type Stream struct {
+	w       http.ResponseWriter
+	r       *http.Request
+	flusher http.Flusher
+	opts    StreamOptions
+
+	mu      sync.Mutex
+	queue   []StreamEvent
+	closed  bool
+	notify  chan struct{}
+	done    chan struct{}
+	dropped int64
+}
+
+// NewStream prepares w for streaming and returns a Stream for it. It fails if w can't be flushed, since
+// events would then be buffered rather than sent.
+func (t *Tools) NewStream(w http.ResponseWriter, r *http.Request, opts ...StreamOptions) (*Stream, error) {
+	var o StreamOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	if o.QueueSize <= 0 {
+		o.QueueSize = 64
+	}
+	if o.Heartbeat == 0 {
+		o.Heartbeat = 15 * time.Second
+	}
+
+	flusher := findFlusher(w)
+	if flusher == nil {
+		return nil, errors.New("streaming is not supported by this response writer")
+	}
+
+	if o.Format == StreamNDJSON {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+	} else {
+		w.Header().Set("Content-Type", "text/event-stream")
+	}
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+
+	stream := &Stream{
+		w:       w,
+		r:       r,
+		flusher: flusher,
+		opts:    o,
+		notify:  make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+	if o.Drainer != nil {
+		o.Drainer.track(stream)
+	}
+	return stream, nil
+}
+
+// Send queues event for the client. It returns ErrStreamClosed if the stream has been closed, including
+// when the CloseClient policy (or MaxDropped) closes it because of this event.
+func (s *Stream) Send(event StreamEvent) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return ErrStreamClosed
+	}
+
+	if len(s.queue) >= s.opts.QueueSize {
+		if s.opts.Policy == CloseClient {
+			s.mu.Unlock()
+			s.slowClient()
+			s.Close()
+			return ErrStreamClosed
+		}
+
+		dropped := atomic.AddInt64(&s.dropped, 1)
+		if s.opts.Policy == DropOldest {
+			s.queue = append(s.queue[1:], event)
+		}
+		s.mu.Unlock()
+		s.slowClient()
+
+		if s.opts.MaxDropped > 0 && dropped >= int64(s.opts.MaxDropped) {
+			s.Close()
+			return ErrStreamClosed
+		}
+		return nil
+	}
+
+	s.queue = append(s.queue, event)
+	s.mu.Unlock()
+
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Serve writes queued events to the client until the stream is closed or the client disconnects, and
+// returns any write error.
+func (s *Stream) Serve() error {
+	defer s.Close()
+	if s.opts.Drainer != nil {
+		defer s.opts.Drainer.untrack(s)
+	}
+
+	s.w.WriteHeader(http.StatusOK)
+	s.flusher.Flush()
+
+	var heartbeat <-chan time.Time
+	if s.opts.Heartbeat > 0 && s.opts.Format == StreamSSE {
+		ticker := time.NewTicker(s.opts.Heartbeat)
+		defer ticker.Stop()
+		heartbeat = ticker.C
+	}
+
+	for {
+		select {
+		case <-s.notify:
+			if err := s.writeQueued(); err != nil {
+				return err
+			}
+		case <-heartbeat:
+			if _, err := fmt.Fprint(s.w, ": ping\n\n"); err != nil {
+				return err
+			}
+			s.flusher.Flush()
+		case <-s.done:
+			// Send anything that was queued before the stream was closed by the server.
+			return s.writeQueued()
+		case <-s.r.Context().Done():
+			return nil
+		}
+	}
+}
+
+// Close closes the stream. Events already queued are still written by Serve.
+func (s *Stream) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.closed {
+		s.closed = true
+		close(s.done)
+	}
+}
+
+// goAway queues event regardless of the queue limit, and closes the stream, so that it is the last event
+// the client receives.
+func (s *Stream) goAway(event StreamEvent) {
+	s.mu.Lock()
+	if !s.closed {
+		s.queue = append(s.queue, event)
+	}
+	s.mu.Unlock()
+	s.Close()
+}
+
+// Done returns a channel which is closed when the stream closes, so producers can stop.
+func (s *Stream) Done() <-chan struct{} {
+	return s.done
+}
+
+// Dropped returns how many events have been dropped because the client was too slow.
+func (s *Stream) Dropped() int {
+	return int(atomic.LoadInt64(&s.dropped))
+}
+
+// writeQueued writes and flushes every queued event.
+func (s *Stream) writeQueued() error {
+	s.mu.Lock()
+	events := s.queue
+	s.queue = nil
+	s.mu.Unlock()
+
+	if len(events) == 0 {
+		return nil
+	}
+
+	for _, event := range events {
+		if err := s.write(event); err != nil {
+			return err
+		}
+	}
+	s.flusher.Flush()
+	return nil
+}
+
+func (s *Stream) write(event StreamEvent) error {
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		return err
+	}
+
+	if s.opts.Format == StreamNDJSON {
+		_, err = s.w.Write(append(data, '\n'))
+		return err
+	}
+
+	var b strings.Builder
+	if event.ID != "" {
+		b.WriteString("id: " + singleLine(event.ID) + "\n")
+	}
+	if event.Event != "" {
+		b.WriteString("event: " + singleLine(event.Event) + "\n")
+	}
+	if event.Retry > 0 {
+		b.WriteString("retry: " + strconv.FormatInt(event.Retry.Milliseconds(), 10) + "\n")
+	}
+	b.WriteString("data: ")
+	b.Write(data)
+	b.WriteString("\n\n")
+
+	_, err = s.w.Write([]byte(b.String()))
+	return err
+}
+
+func (s *Stream) slowClient() {
+	if s.opts.OnSlowClient != nil {
+		s.opts.OnSlowClient(s.Dropped())
+	}
+}
+
+// singleLine removes line breaks, which would end an SSE field early.
+func singleLine(s string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(s)
+}
+
+// findFlusher returns the http.Flusher for w, looking through any wrapping response writers.
+func findFlusher(w http.ResponseWriter) http.Flusher {
+	for {
+		if flusher, ok := w.(http.Flusher); ok {
+			return flusher
+		}
+		unwrapper, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return nil
+		}
+		w = unwrapper.Unwrap()
+	}
+}
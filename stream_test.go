@@ -0,0 +1,93 @@
+package gohelpertools
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var streamPolicyTests = []struct {
+	name           string
+	policy         SlowClientPolicy
+	maxDropped     int
+	expectedBody   string
+	expectedClosed bool
+}{
+	{name: "drop oldest", policy: DropOldest, expectedBody: "3\n4\n5\n"},
+	{name: "drop newest", policy: DropNewest, expectedBody: "1\n2\n3\n"},
+	{name: "close client", policy: CloseClient, expectedBody: "1\n2\n3\n", expectedClosed: true},
+	{name: "max dropped", policy: DropOldest, maxDropped: 1, expectedBody: "2\n3\n4\n", expectedClosed: true},
+}
+
+func TestStream_Policies(t *testing.T) {
+	var tools Tools
+
+	for _, e := range streamPolicyTests {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/events", nil)
+
+		slow := 0
+		stream, err := tools.NewStream(rr, req, StreamOptions{
+			Format:       StreamNDJSON,
+			QueueSize:    3,
+			Policy:       e.policy,
+			MaxDropped:   e.maxDropped,
+			OnSlowClient: func(int) { slow++ },
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Nobody is serving the stream yet, so it behaves like a client which has stopped reading.
+		closed := false
+		for i := 1; i <= 5; i++ {
+			if err := stream.Send(StreamEvent{Data: i}); err == ErrStreamClosed {
+				closed = true
+			}
+		}
+		stream.Close()
+
+		if err := stream.Serve(); err != nil {
+			t.Errorf("%s: error not expected, but one received: %s", e.name, err)
+		}
+		if rr.Body.String() != e.expectedBody {
+			t.Errorf("%s: expected body %q but got %q", e.name, e.expectedBody, rr.Body.String())
+		}
+		if closed != e.expectedClosed {
+			t.Errorf("%s: expected closed to be %v", e.name, e.expectedClosed)
+		}
+		if slow == 0 {
+			t.Errorf("%s: expected slow client to be reported", e.name)
+		}
+		if rr.Header().Get("Content-Type") != "application/x-ndjson" {
+			t.Errorf("%s: wrong content type %q", e.name, rr.Header().Get("Content-Type"))
+		}
+	}
+}
+
+func TestStream_SSE(t *testing.T) {
+	var tools Tools
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		stream, err := tools.NewStream(w, r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		go func() {
+			_ = stream.Send(StreamEvent{Event: "greeting", ID: "1\n2", Data: map[string]string{"hello": "world"}})
+			stream.Close()
+		}()
+		_ = stream.Serve()
+	})
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/events", nil))
+
+	expected := "id: 12\nevent: greeting\ndata: {\"hello\":\"world\"}\n\n"
+	if !strings.Contains(rr.Body.String(), expected) {
+		t.Errorf("expected %q in body, got %q", expected, rr.Body.String())
+	}
+	if rr.Header().Get("Content-Type") != "text/event-stream" {
+		t.Errorf("wrong content type %q", rr.Header().Get("Content-Type"))
+	}
+}
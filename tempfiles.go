@@ -0,0 +1,203 @@
+package gohelpertools
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ErrTempFilesClosed is returned by TempFiles when it is used after Close.
+var ErrTempFilesClosed = errors.New("temporary file manager is closed")
+
+// TempFiles creates temporary files and directories for processing within a request or job, and makes sure
+// they are removed: each one is removed when the context it was created with is done, and a janitor
+// removes anything in the namespace older than MaxAge, which catches files left behind by a crash or a
+// context that never ends. For example, in a handler:
+//
+//	f, err := app.temp.CreateFile(r.Context(), "resize-*.jpg")
+//
+// and f is removed once the handler returns. The caller should still close the file.
+type TempFiles struct {
+	Dir             string        // directory the namespace is created in; defaults to os.TempDir()
+	Namespace       string        // name of the directory holding this manager's files; defaults to "gohelpertools"
+	MaxAge          time.Duration // age after which the janitor removes a file or directory; defaults to 1 hour
+	JanitorInterval time.Duration // how often the janitor runs; defaults to MaxAge / 4
+	Tools           *Tools        // used to log files which can't be removed; a zero Tools is used if nil
+
+	startOnce sync.Once
+	startErr  error
+	root      string
+	quit      chan struct{}
+	done      chan struct{}
+	mu        sync.Mutex
+	paths     map[string]func() bool // live paths, with the function which stops their cleanup
+	closed    bool
+}
+
+// CreateFile creates a new temporary file, named after pattern as with os.CreateTemp, which is removed when
+// ctx is done.
+func (m *TempFiles) CreateFile(ctx context.Context, pattern string) (*os.File, error) {
+	if err := m.start(); err != nil {
+		return nil, err
+	}
+	f, err := os.CreateTemp(m.root, pattern)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.track(ctx, f.Name()); err != nil {
+		f.Close()
+		_ = os.Remove(f.Name())
+		return nil, err
+	}
+	return f, nil
+}
+
+// CreateDir creates a new temporary directory, named after pattern as with os.MkdirTemp, which is removed
+// with everything in it when ctx is done.
+func (m *TempFiles) CreateDir(ctx context.Context, pattern string) (string, error) {
+	if err := m.start(); err != nil {
+		return "", err
+	}
+	dir, err := os.MkdirTemp(m.root, pattern)
+	if err != nil {
+		return "", err
+	}
+	if err := m.track(ctx, dir); err != nil {
+		_ = os.RemoveAll(dir)
+		return "", err
+	}
+	return dir, nil
+}
+
+// Remove removes a file or directory created by m straight away, rather than waiting for its context.
+func (m *TempFiles) Remove(path string) error {
+	m.mu.Lock()
+	stop, ok := m.paths[path]
+	delete(m.paths, path)
+	m.mu.Unlock()
+	if ok {
+		stop()
+	}
+	return os.RemoveAll(path)
+}
+
+// RemoveExpired removes everything in the namespace last modified more than MaxAge ago, and returns how
+// many files and directories it removed. The janitor calls it every JanitorInterval.
+func (m *TempFiles) RemoveExpired() (int, error) {
+	if err := m.start(); err != nil {
+		return 0, err
+	}
+	entries, err := os.ReadDir(m.root)
+	if err != nil {
+		return 0, err
+	}
+
+	cutoff := time.Now().Add(-m.maxAge())
+	removed := 0
+	var errs []error
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || !info.ModTime().Before(cutoff) {
+			continue
+		}
+		if err := m.Remove(filepath.Join(m.root, entry.Name())); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		removed++
+	}
+	return removed, errors.Join(errs...)
+}
+
+// Close stops the janitor and removes every file and directory m created which hasn't been removed yet.
+// Afterwards, CreateFile and CreateDir return ErrTempFilesClosed.
+func (m *TempFiles) Close() error {
+	if err := m.start(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return nil
+	}
+	m.closed = true
+	paths := m.paths
+	m.paths = nil
+	m.mu.Unlock()
+
+	close(m.quit)
+	<-m.done
+
+	var errs []error
+	for path, stop := range paths {
+		stop()
+		if err := os.RemoveAll(path); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// track arranges for path to be removed when ctx is done.
+func (m *TempFiles) track(ctx context.Context, path string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return ErrTempFilesClosed
+	}
+
+	m.paths[path] = context.AfterFunc(ctx, func() {
+		if err := m.Remove(path); err != nil {
+			toolsOrDefault(m.Tools).logWarn(context.Background(), "unable to remove temporary file", "path", path, "error", err.Error())
+		}
+	})
+	return nil
+}
+
+// start creates the namespace directory and starts the janitor, the first time it's called.
+func (m *TempFiles) start() error {
+	m.startOnce.Do(func() {
+		m.root = filepath.Join(valueOrDefault(m.Dir, os.TempDir()), valueOrDefault(m.Namespace, "gohelpertools"))
+		if m.startErr = os.MkdirAll(m.root, 0o700); m.startErr != nil {
+			return
+		}
+		m.paths = make(map[string]func() bool)
+		m.quit = make(chan struct{})
+		m.done = make(chan struct{})
+		go m.janitor()
+	})
+	return m.startErr
+}
+
+func (m *TempFiles) janitor() {
+	defer close(m.done)
+
+	interval := m.JanitorInterval
+	if interval <= 0 {
+		interval = m.maxAge() / 4
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if _, err := m.RemoveExpired(); err != nil {
+				toolsOrDefault(m.Tools).logWarn(context.Background(), "unable to remove expired temporary files", "error", err.Error())
+			}
+		case <-m.quit:
+			return
+		}
+	}
+}
+
+func (m *TempFiles) maxAge() time.Duration {
+	if m.MaxAge <= 0 {
+		return time.Hour
+	}
+	return m.MaxAge
+}
@@ -0,0 +1,101 @@
+package gohelpertools
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// waitForRemoval fails the test unless path is removed within a second.
+func waitForRemoval(t *testing.T, path string) {
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Errorf("expected %s to be removed", path)
+}
+
+func TestTempFiles_RemovedWithContext(t *testing.T) {
+	temp := &TempFiles{Dir: t.TempDir()}
+	defer temp.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	f, err := temp.CreateFile(ctx, "upload-*.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.WriteString("data")
+	f.Close()
+
+	dir, err := temp.CreateDir(ctx, "work-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = os.WriteFile(filepath.Join(dir, "part.txt"), []byte("x"), 0o644)
+
+	if filepath.Dir(f.Name()) != filepath.Join(temp.Dir, "gohelpertools") {
+		t.Errorf("expected the file in the namespace, got %s", f.Name())
+	}
+	if _, err := os.Stat(f.Name()); err != nil {
+		t.Fatalf("expected the file to exist until the context is done: %s", err)
+	}
+
+	cancel()
+	waitForRemoval(t, f.Name())
+	waitForRemoval(t, dir)
+}
+
+func TestTempFiles_RemoveExpired(t *testing.T) {
+	temp := &TempFiles{Dir: t.TempDir(), Namespace: "jobs", MaxAge: time.Minute}
+	defer temp.Close()
+
+	// An orphan from an earlier run, and a fresh file which must be kept.
+	root := filepath.Join(temp.Dir, "jobs")
+	_ = os.MkdirAll(root, 0o700)
+	orphan := filepath.Join(root, "orphan.tmp")
+	_ = os.WriteFile(orphan, []byte("x"), 0o600)
+	old := time.Now().Add(-2 * time.Minute)
+	_ = os.Chtimes(orphan, old, old)
+
+	fresh, err := temp.CreateFile(context.Background(), "fresh-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fresh.Close()
+
+	removed, err := temp.RemoveExpired()
+	if err != nil || removed != 1 {
+		t.Errorf("expected 1 file removed, got %d (%v)", removed, err)
+	}
+	if _, err := os.Stat(orphan); !errors.Is(err, os.ErrNotExist) {
+		t.Error("expected the orphan to be removed")
+	}
+	if _, err := os.Stat(fresh.Name()); err != nil {
+		t.Error("expected the fresh file to be kept")
+	}
+}
+
+func TestTempFiles_Close(t *testing.T) {
+	temp := &TempFiles{Dir: t.TempDir()}
+	f, err := temp.CreateFile(context.Background(), "x-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	if err := temp.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(f.Name()); !errors.Is(err, os.ErrNotExist) {
+		t.Error("expected Close to remove the file")
+	}
+	if _, err := temp.CreateDir(context.Background(), "y-*"); !errors.Is(err, ErrTempFilesClosed) {
+		t.Errorf("expected ErrTempFilesClosed, got %v", err)
+	}
+}
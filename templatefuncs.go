@@ -0,0 +1,38 @@
+package gohelpertools
+
+import (
+	"html/template"
+	"time"
+)
+
+// TemplateFuncs returns template functions which format values with the same Tools methods used for JSON
+// responses, so a value looks the same in a page as in the API. Renderer adds them to every template. The
+// functions take the value last, so they can end a pipeline, as in {{.Title | truncate 40}}:
+//
+//	humanize       {{humanize "createdAt"}}                Humanize
+//	slugify        {{slugify .Title}}                     Slugify, or "" if it fails
+//	truncate       {{truncate 40 .Title}}                 Truncate, with "…"
+//	truncateWords  {{truncateWords 20 .Body}}             TruncateWords, with "…"
+//	money          {{money "USD" .PriceCents}}            FormatMoney
+//	date           {{date "date" .CreatedAt}}             FormatTime
+//	timeAgo        {{timeAgo .CreatedAt}}                 TimeAgo
+//	markdown       {{markdown .Description}}              Markdown, as trusted HTML
+//	csrfField      {{csrfField .CSRFToken}}               a hidden csrf_token input
+func (t *Tools) TemplateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"humanize": t.Humanize,
+		"slugify": func(s string) string {
+			slug, _ := t.Slugify(s)
+			return slug
+		},
+		"truncate":      func(n int, s string) string { return t.Truncate(s, n, "…") },
+		"truncateWords": func(n int, s string) string { return t.TruncateWords(s, n, "…") },
+		"money":         func(currency string, minorUnits int64) string { return t.FormatMoney(minorUnits, currency) },
+		"date":          func(layout string, v time.Time) string { return t.FormatTime(v, layout) },
+		"timeAgo":       t.TimeAgo,
+		"markdown":      func(s string) template.HTML { return template.HTML(t.Markdown(s)) },
+		"csrfField": func(token string) template.HTML {
+			return template.HTML(`<input type="hidden" name="csrf_token" value="` + template.HTMLEscapeString(token) + `">`)
+		},
+	}
+}
@@ -0,0 +1,99 @@
+package gohelpertools
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+var formatMoneyTests = []struct {
+	name     string
+	amount   int64
+	currency string
+	expected string
+}{
+	{name: "dollars", amount: 123456, currency: "USD", expected: "$1,234.56"},
+	{name: "cents", amount: 5, currency: "usd", expected: "$0.05"},
+	{name: "negative", amount: -150000, currency: "EUR", expected: "-€1,500.00"},
+	{name: "zero decimal", amount: 1234567, currency: "JPY", expected: "¥1,234,567"},
+	{name: "no symbol", amount: 100, currency: "CHF", expected: "1.00 CHF"},
+	{name: "three decimals", amount: 12345, currency: "KWD", expected: "12.345 KWD"},
+}
+
+func TestTools_FormatMoney(t *testing.T) {
+	var tools Tools
+	for _, e := range formatMoneyTests {
+		if got := tools.FormatMoney(e.amount, e.currency); got != e.expected {
+			t.Errorf("%s: expected %s but got %s", e.name, e.expected, got)
+		}
+	}
+}
+
+var timeAgoTests = []struct {
+	ago      time.Duration
+	expected string
+}{
+	{ago: 10 * time.Second, expected: "just now"},
+	{ago: time.Minute, expected: "1 minute ago"},
+	{ago: 5 * time.Hour, expected: "5 hours ago"},
+	{ago: 30 * time.Hour, expected: "yesterday"},
+	{ago: -30 * time.Hour, expected: "tomorrow"},
+	{ago: -72 * time.Hour, expected: "in 3 days"},
+	{ago: 800 * 24 * time.Hour, expected: "2 years ago"},
+}
+
+func TestTimeAgo(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	for _, e := range timeAgoTests {
+		if got := timeAgo(now.Add(-e.ago), now); got != e.expected {
+			t.Errorf("%s: expected %q but got %q", e.ago, e.expected, got)
+		}
+	}
+}
+
+var markdownTests = []struct {
+	name     string
+	input    string
+	expected string
+}{
+	{name: "paragraphs", input: "one\ntwo\n\nthree", expected: "<p>one two</p>\n<p>three</p>"},
+	{name: "heading", input: "## Title ##", expected: "<h2>Title</h2>"},
+	{name: "inline", input: "**bold**, *em* and `a*b*`", expected: "<p><strong>bold</strong>, <em>em</em> and <code>a*b*</code></p>"},
+	{name: "list", input: "- a\n- b\n1. c", expected: "<ul>\n<li>a</li>\n<li>b</li>\n</ul>\n<ol>\n<li>c</li>\n</ol>"},
+	{name: "code block", input: "```\n<b>x</b>\n```", expected: "<pre><code>&lt;b&gt;x&lt;/b&gt;</code></pre>"},
+	{name: "html escaped", input: "<script>alert(1)</script>", expected: "<p>&lt;script&gt;alert(1)&lt;/script&gt;</p>"},
+	{name: "link", input: "[docs](https://example.com/a?b=1&c=2)", expected: `<p><a href="https://example.com/a?b=1&amp;c=2">docs</a></p>`},
+	{name: "unsafe link", input: "[click](javascript:alert(1))", expected: "<p>click)</p>"},
+	{name: "snake case", input: "some_variable_name", expected: "<p>some_variable_name</p>"},
+}
+
+func TestTools_Markdown(t *testing.T) {
+	var tools Tools
+	for _, e := range markdownTests {
+		if got := tools.Markdown(e.input); got != e.expected {
+			t.Errorf("%s: expected %q but got %q", e.name, e.expected, got)
+		}
+	}
+}
+
+func TestRenderer_TemplateFuncs(t *testing.T) {
+	rd := &Renderer{
+		FS: fstest.MapFS{"pages/funcs.html": {Data: []byte(
+			`{{humanize "createdAt"}}|{{"A long title here" | truncate 8}}|{{money "USD" 1999}}|{{date "date" .Data}}|` +
+				`{{markdown "**hi**"}}|{{csrfField "t<1>"}}|{{if feature "beta"}}beta{{end}}{{if feature "other"}}other{{end}}`,
+		)}},
+		Feature: func(name string) bool { return name == "beta" },
+	}
+
+	rr := httptest.NewRecorder()
+	if err := rd.Render(rr, httptest.NewRequest(http.MethodGet, "/", nil), "funcs", time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `Created at|A long…|$19.99|5 Mar 2024|<p><strong>hi</strong></p>|<input type="hidden" name="csrf_token" value="t&lt;1&gt;">|beta`
+	if got := rr.Body.String(); got != expected {
+		t.Errorf("expected %q but got %q", expected, got)
+	}
+}
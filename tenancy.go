@@ -0,0 +1,132 @@
+package gohelpertools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrTenantNotFound is returned by a Tenancy's Resolve function for a key which doesn't belong to any
+// tenant.
+var ErrTenantNotFound = errors.New("tenant not found")
+
+// Tenancy is middleware for multi-tenant applications. It finds a tenant key in each request, from a
+// subdomain, a header or a path prefix, asks Resolve which tenant it belongs to, and stores the tenant in
+// the request context, where it can be read with TenantFromContext. Requests which already have a tenant,
+// such as those for customer domains routed by HostRouter.Route, are left as they are.
+//
+// Each tenant can also have its own rate limit, and its own logger, which LogError and LogInfo then use.
+type Tenancy struct {
+	Subdomain  string // if set, the base domain whose subdomains are tenant keys, such as "example.com" for acme.example.com
+	Header     string // if set, the header holding the tenant key, such as "X-Tenant-ID"
+	PathPrefix string // if set, the prefix of paths starting with a tenant key, such as "/t/" for /t/acme/users; the prefix and key are removed from the path
+
+	// Resolve returns the ID of the tenant with key, or ErrTenantNotFound; required. Keys are taken from
+	// the subdomain, header and path prefix, in that order, whichever is found first.
+	Resolve  func(ctx context.Context, key string) (string, error)
+	Optional bool // if set to true, requests without a tenant key are passed on without a tenant, rather than rejected
+
+	RateLimit  func(tenant string) int64        // requests each tenant may make per RateWindow; no limit if nil, or if it returns zero or less
+	RateWindow time.Duration                    // defaults to 1 minute
+	Counter    WindowCounter                    // counts requests per tenant; a sliding window of RateWindow is used if nil
+	Logger     func(tenant string) *slog.Logger // if set, returns the logger for each tenant's requests, stored with WithContextLogger
+	Tools      *Tools                           // used to write JSON error responses; a zero Tools is used if nil
+
+	once sync.Once
+}
+
+// Middleware resolves the tenant of each request, rejecting requests for unknown tenants with a 404 JSON
+// error, requests without a tenant key with a 400 one (unless Optional is set), and requests over the
+// tenant's rate limit with a 429 one.
+func (tn *Tenancy) Middleware(next http.Handler) http.Handler {
+	tn.init()
+	tools := toolsOrDefault(tn.Tools)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenant, ok := TenantFromContext(r.Context())
+		if !ok {
+			var key string
+			key, r = tn.key(r)
+			if key == "" {
+				if tn.Optional {
+					next.ServeHTTP(w, r)
+					return
+				}
+				_ = tools.ErrorJSON(w, errors.New("missing tenant"), http.StatusBadRequest)
+				return
+			}
+
+			var err error
+			tenant, err = tn.Resolve(r.Context(), key)
+			switch {
+			case errors.Is(err, ErrTenantNotFound):
+				_ = tools.ErrorJSON(w, fmt.Errorf("unknown tenant %s", key), http.StatusNotFound)
+				return
+			case err != nil:
+				tools.LogError(r.Context(), "resolving tenant", err, "key", key)
+				_ = tools.ErrorJSON(w, errors.New("unable to resolve tenant"), http.StatusInternalServerError)
+				return
+			}
+		}
+
+		if tn.RateLimit != nil {
+			if limit := tn.RateLimit(tenant); limit > 0 && tn.Counter.Add(tenant, 1) > limit {
+				window := durationOrDefault(tn.RateWindow, time.Minute)
+				w.Header().Set("Retry-After", strconv.Itoa(int((window+time.Second-1)/time.Second)))
+				_ = tools.ErrorJSON(w, errors.New("rate limit exceeded; please try again later"), http.StatusTooManyRequests)
+				return
+			}
+		}
+
+		ctx := WithTenant(r.Context(), tenant)
+		if tn.Logger != nil {
+			if logger := tn.Logger(tenant); logger != nil {
+				ctx = WithContextLogger(ctx, logger)
+			}
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// key returns the tenant key of r, or "" if it has none. If the key comes from the path prefix, the
+// request returned has the prefix and key removed from its path.
+func (tn *Tenancy) key(r *http.Request) (string, *http.Request) {
+	if tn.Subdomain != "" {
+		host := requestHost(r)
+		if label, ok := strings.CutSuffix(host, "."+normalizeDomain(tn.Subdomain)); ok && label != "" && !strings.Contains(label, ".") {
+			return label, r
+		}
+	}
+
+	if tn.Header != "" {
+		if key := strings.TrimSpace(r.Header.Get(tn.Header)); key != "" {
+			return key, r
+		}
+	}
+
+	if tn.PathPrefix != "" {
+		if rest, ok := strings.CutPrefix(r.URL.Path, tn.PathPrefix); ok {
+			key, path, _ := strings.Cut(rest, "/")
+			if key != "" {
+				r2 := r.Clone(r.Context())
+				r2.URL.Path, r2.URL.RawPath = "/"+path, ""
+				return key, r2
+			}
+		}
+	}
+	return "", r
+}
+
+func (tn *Tenancy) init() {
+	tn.once.Do(func() {
+		if tn.Counter == nil {
+			tn.Counter = NewSlidingWindow(durationOrDefault(tn.RateWindow, time.Minute), 0)
+		}
+	})
+}
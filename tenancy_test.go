@@ -0,0 +1,119 @@
+package gohelpertools
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTenancy_Middleware(t *testing.T) {
+	tenants := map[string]string{"acme": "tenant-1", "globex": "tenant-2"}
+	var logs bytes.Buffer
+	tenancy := &Tenancy{
+		Subdomain:  "example.com",
+		Header:     "X-Tenant-ID",
+		PathPrefix: "/t/",
+		Resolve: func(ctx context.Context, key string) (string, error) {
+			if key == "broken" {
+				return "", errors.New("database down")
+			}
+			if id, ok := tenants[key]; ok {
+				return id, nil
+			}
+			return "", ErrTenantNotFound
+		},
+		RateLimit: func(tenant string) int64 {
+			if tenant == "tenant-2" {
+				return 2
+			}
+			return 0
+		},
+		Logger: func(tenant string) *slog.Logger {
+			return slog.New(slog.NewTextHandler(&logs, nil))
+		},
+		Tools: &Tools{Logger: slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))},
+	}
+
+	var tools Tools
+	handler := tenancy.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenant, _ := TenantFromContext(r.Context())
+		tools.LogInfo(r.Context(), "handled")
+		_, _ = w.Write([]byte(tenant + " " + r.URL.Path))
+	}))
+
+	tests := []struct {
+		name     string
+		host     string
+		target   string
+		header   string
+		status   int
+		expected string
+	}{
+		{name: "subdomain", host: "acme.example.com:8080", target: "/users", status: http.StatusOK, expected: "tenant-1 /users"},
+		{name: "header", host: "api.example.org", target: "/users", header: "globex", status: http.StatusOK, expected: "tenant-2 /users"},
+		{name: "path prefix", host: "api.example.org", target: "/t/acme/users/7", status: http.StatusOK, expected: "tenant-1 /users/7"},
+		{name: "subdomain first", host: "acme.example.com", target: "/", header: "globex", status: http.StatusOK, expected: "tenant-1 /"},
+		{name: "nested subdomain", host: "a.acme.example.com", target: "/", status: http.StatusBadRequest},
+		{name: "missing", host: "example.com", target: "/users", status: http.StatusBadRequest},
+		{name: "unknown", host: "initech.example.com", target: "/", status: http.StatusNotFound},
+		{name: "resolve error", host: "api.example.org", target: "/", header: "broken", status: http.StatusInternalServerError},
+	}
+
+	for _, e := range tests {
+		req := httptest.NewRequest(http.MethodGet, e.target, nil)
+		req.Host = e.host
+		if e.header != "" {
+			req.Header.Set("X-Tenant-ID", e.header)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		if rr.Code != e.status {
+			t.Errorf("%s: expected status %d, got %d: %s", e.name, e.status, rr.Code, rr.Body.String())
+			continue
+		}
+		if e.expected != "" && rr.Body.String() != e.expected {
+			t.Errorf("%s: expected %q, got %q", e.name, e.expected, rr.Body.String())
+		}
+	}
+
+	if !strings.Contains(logs.String(), "msg=handled tenant=tenant-1") {
+		t.Errorf("expected the tenant logger to be used, with the tenant, got %s", logs.String())
+	}
+
+	// globex was allowed two requests in the window, and has used one.
+	for i, expected := range []int{http.StatusOK, http.StatusTooManyRequests} {
+		req := httptest.NewRequest(http.MethodGet, "/t/globex/", nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		if rr.Code != expected {
+			t.Errorf("request %d: expected status %d, got %d", i, expected, rr.Code)
+		}
+		if expected == http.StatusTooManyRequests && rr.Header().Get("Retry-After") != "60" {
+			t.Errorf("expected Retry-After 60, got %q", rr.Header().Get("Retry-After"))
+		}
+	}
+
+	// A tenant already resolved, such as by HostRouter, is kept.
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req.WithContext(WithTenant(req.Context(), "tenant-9")))
+	if rr.Body.String() != "tenant-9 /" {
+		t.Errorf("expected the existing tenant to be kept, got %q", rr.Body.String())
+	}
+
+	optional := &Tenancy{Header: "X-Tenant-ID", Optional: true, Resolve: tenancy.Resolve}
+	rr = httptest.NewRecorder()
+	optional.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := TenantFromContext(r.Context()); !ok {
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rr.Code != http.StatusNoContent {
+		t.Errorf("expected the request to be passed on without a tenant, got %d", rr.Code)
+	}
+}
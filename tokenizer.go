@@ -0,0 +1,144 @@
+package gohelpertools
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+)
+
+// ErrTokenNotFound is returned by Detokenize for tokens which aren't in the vault.
+var ErrTokenNotFound = errors.New("token not found")
+
+// ErrDetokenizeDenied is returned by Detokenize when the caller isn't permitted to see the value.
+var ErrDetokenizeDenied = errors.New("not permitted to detokenize")
+
+// TokenVault stores the values behind tokens.
+type TokenVault interface {
+	// Put stores value under token.
+	Put(ctx context.Context, token string, value []byte) error
+	// Get returns the value stored under token; found is false if there is none.
+	Get(ctx context.Context, token string) (value []byte, found bool, err error)
+	// Delete removes token.
+	Delete(ctx context.Context, token string) error
+}
+
+// Tokenizer swaps sensitive values, such as card numbers or social security numbers, for random tokens,
+// keeping the values in a vault. Downstream services only ever see tokens, which reduces their compliance
+// scope; the few that need the real value call Detokenize, which is only allowed if Permit says so.
+type Tokenizer struct {
+	Vault    TokenVault                                            // where values are kept; required
+	Keyring  *Keyring                                              // if set, values are encrypted in the vault
+	Prefix   string                                                // start of every token; defaults to "tok_"
+	KeepLast int                                                   // if above zero, tokens end with this many of the value's last characters, such as a card's last four digits
+	Permit   func(ctx context.Context, token string) bool          // decides whether the caller, identified from ctx, may detokenize; if nil, nobody may
+	Audit    func(ctx context.Context, token string, allowed bool) // if set, called for every Detokenize
+}
+
+// Tokenize stores value in the vault and returns a new random token for it.
+func (tk *Tokenizer) Tokenize(ctx context.Context, value string) (string, error) {
+	if tk.Vault == nil {
+		return "", errors.New("tokenizer has no vault")
+	}
+
+	random, err := randomToken(18)
+	if err != nil {
+		return "", err
+	}
+	token := valueOrDefault(tk.Prefix, "tok_") + random
+	if tk.KeepLast > 0 {
+		runes := []rune(value)
+		if n := tk.KeepLast; n < len(runes) {
+			token += "_" + string(runes[len(runes)-n:])
+		}
+	}
+
+	stored := []byte(value)
+	if tk.Keyring != nil {
+		if stored, err = tk.Keyring.Encrypt(stored); err != nil {
+			return "", err
+		}
+	}
+
+	if err := tk.Vault.Put(ctx, token, stored); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// Detokenize returns the value behind token, if Permit allows it.
+func (tk *Tokenizer) Detokenize(ctx context.Context, token string) (string, error) {
+	allowed := tk.Permit != nil && tk.Permit(ctx, token)
+	if tk.Audit != nil {
+		tk.Audit(ctx, token, allowed)
+	}
+	if !allowed {
+		return "", ErrDetokenizeDenied
+	}
+	if tk.Vault == nil {
+		return "", errors.New("tokenizer has no vault")
+	}
+
+	stored, found, err := tk.Vault.Get(ctx, token)
+	if err != nil {
+		return "", err
+	}
+	if !found {
+		return "", ErrTokenNotFound
+	}
+
+	if tk.Keyring != nil {
+		if stored, err = tk.Keyring.Decrypt(stored); err != nil {
+			return "", err
+		}
+	}
+	return string(stored), nil
+}
+
+// Revoke removes token from the vault, so it can no longer be detokenized.
+func (tk *Tokenizer) Revoke(ctx context.Context, token string) error {
+	if tk.Vault == nil {
+		return errors.New("tokenizer has no vault")
+	}
+	return tk.Vault.Delete(ctx, token)
+}
+
+// IsToken reports whether s looks like a token from this Tokenizer.
+func (tk *Tokenizer) IsToken(s string) bool {
+	return strings.HasPrefix(s, valueOrDefault(tk.Prefix, "tok_"))
+}
+
+// MemoryTokenVault is a TokenVault which keeps values in memory, for tests and single-instance services.
+type MemoryTokenVault struct {
+	mu     sync.RWMutex
+	values map[string][]byte
+}
+
+// NewMemoryTokenVault returns an empty MemoryTokenVault.
+func NewMemoryTokenVault() *MemoryTokenVault {
+	return &MemoryTokenVault{values: make(map[string][]byte)}
+}
+
+// Put stores a copy of value under token.
+func (v *MemoryTokenVault) Put(ctx context.Context, token string, value []byte) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.values[token] = append([]byte(nil), value...)
+	return nil
+}
+
+// Get returns the value stored under token.
+func (v *MemoryTokenVault) Get(ctx context.Context, token string) ([]byte, bool, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	value, ok := v.values[token]
+	return value, ok, nil
+}
+
+// Delete removes token.
+func (v *MemoryTokenVault) Delete(ctx context.Context, token string) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	delete(v.values, token)
+	return nil
+}
@@ -0,0 +1,75 @@
+package gohelpertools
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type roleKey struct{}
+
+func TestTokenizer(t *testing.T) {
+	keyring, _ := NewKeyring("k", map[string][]byte{"k": bytes.Repeat([]byte{3}, 32)})
+	vault := NewMemoryTokenVault()
+
+	var audited []bool
+	tk := &Tokenizer{
+		Vault:    vault,
+		Keyring:  keyring,
+		KeepLast: 4,
+		Permit: func(ctx context.Context, token string) bool {
+			return ctx.Value(roleKey{}) == "payments"
+		},
+		Audit: func(ctx context.Context, token string, allowed bool) { audited = append(audited, allowed) },
+	}
+
+	ctx := context.Background()
+	token, err := tk.Tokenize(ctx, "4242424242424242")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(token, "tok_") || !strings.HasSuffix(token, "_4242") || !tk.IsToken(token) {
+		t.Errorf("unexpected token %q", token)
+	}
+
+	other, _ := tk.Tokenize(ctx, "4242424242424242")
+	if other == token {
+		t.Error("expected each call to return a new token")
+	}
+
+	stored, _, _ := vault.Get(ctx, token)
+	if bytes.Contains(stored, []byte("4242424242424242")) {
+		t.Error("expected the value to be encrypted in the vault")
+	}
+
+	if _, err := tk.Detokenize(ctx, token); !errors.Is(err, ErrDetokenizeDenied) {
+		t.Errorf("expected ErrDetokenizeDenied, got %v", err)
+	}
+
+	allowed := context.WithValue(ctx, roleKey{}, "payments")
+	value, err := tk.Detokenize(allowed, token)
+	if err != nil || value != "4242424242424242" {
+		t.Errorf("expected the original value, got %q (%v)", value, err)
+	}
+
+	if err := tk.Revoke(ctx, token); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tk.Detokenize(allowed, token); !errors.Is(err, ErrTokenNotFound) {
+		t.Errorf("expected ErrTokenNotFound after revoking, got %v", err)
+	}
+
+	if len(audited) != 3 || audited[0] || !audited[1] {
+		t.Errorf("unexpected audit trail %v", audited)
+	}
+}
+
+func TestTokenizer_NoPermit(t *testing.T) {
+	tk := &Tokenizer{Vault: NewMemoryTokenVault()}
+	token, _ := tk.Tokenize(context.Background(), "123-45-6789")
+	if _, err := tk.Detokenize(context.Background(), token); !errors.Is(err, ErrDetokenizeDenied) {
+		t.Errorf("expected detokenizing to be denied without Permit, got %v", err)
+	}
+}
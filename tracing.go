@@ -0,0 +1,228 @@
+package gohelpertools
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const spanContextKey contextKey = "span"
+
+// SpanExporter receives finished spans. Implement it to bridge to OpenTelemetry, or any other tracing
+// system, without this package importing it.
+type SpanExporter interface {
+	ExportSpan(span SpanRecord)
+}
+
+// SpanExporterFunc adapts a function to a SpanExporter.
+type SpanExporterFunc func(span SpanRecord)
+
+// ExportSpan calls f(span).
+func (f SpanExporterFunc) ExportSpan(span SpanRecord) {
+	f(span)
+}
+
+// SpanRecord is a finished span: a named, timed operation within a trace.
+type SpanRecord struct {
+	Name       string
+	TraceID    string // 32 hex digits
+	SpanID     string // 16 hex digits
+	ParentID   string // empty for a root span
+	Sampled    bool
+	Start      time.Time
+	End        time.Time
+	Attributes map[string]string
+	Error      string
+}
+
+// Span is an operation in progress. Call End when it's done. A Span is safe for concurrent use.
+type Span struct {
+	tracer *Tracer
+
+	mu     sync.Mutex
+	record SpanRecord
+	ended  bool
+}
+
+// Tracer creates spans, propagating trace context with the W3C traceparent header, and passes them to
+// Exporter when they end. Its Middleware starts a span for every request, continuing the caller's trace if
+// the request has a valid traceparent header.
+type Tracer struct {
+	Exporter SpanExporter                 // receives finished spans; if nil, spans are timed but discarded
+	SpanName func(r *http.Request) string // names request spans; defaults to the method and DefaultRoute
+}
+
+// Middleware starts a span for each request, makes it available through SpanFromContext, and ends it
+// when the handler returns, recording the method, route and status.
+func (tr *Tracer) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := r.Method + " " + DefaultRoute(r)
+		if tr.SpanName != nil {
+			name = tr.SpanName(r)
+		}
+
+		parent, _ := ParseTraceparent(r.Header.Get("traceparent"))
+		ctx, span := tr.start(r.Context(), name, parent)
+		span.SetAttribute("http.method", r.Method)
+		span.SetAttribute("http.route", DefaultRoute(r))
+
+		mw := &metricsWriter{ResponseWriter: w, status: http.StatusOK}
+		defer func() {
+			span.SetAttribute("http.status_code", strconv.Itoa(mw.status))
+			if mw.status >= 500 {
+				span.RecordError(errors.New(http.StatusText(mw.status)))
+			}
+			span.End()
+		}()
+
+		next.ServeHTTP(mw, r.WithContext(ctx))
+	})
+}
+
+// StartSpan starts a span called name, as a child of the span in ctx if there is one, and returns a
+// context carrying the new span.
+func (tr *Tracer) StartSpan(ctx context.Context, name string) (context.Context, *Span) {
+	var parent SpanRecord
+	if span := SpanFromContext(ctx); span != nil {
+		parent = span.Record()
+	}
+	return tr.start(ctx, name, parent)
+}
+
+func (tr *Tracer) start(ctx context.Context, name string, parent SpanRecord) (context.Context, *Span) {
+	record := SpanRecord{
+		Name:       name,
+		TraceID:    parent.TraceID,
+		ParentID:   parent.SpanID,
+		Sampled:    parent.Sampled || parent.TraceID == "",
+		Start:      time.Now(),
+		Attributes: make(map[string]string),
+	}
+	if record.TraceID == "" {
+		record.TraceID = randomHexID(16)
+	}
+	record.SpanID = randomHexID(8)
+
+	span := &Span{tracer: tr, record: record}
+	return context.WithValue(ctx, spanContextKey, span), span
+}
+
+// SpanFromContext returns the span in ctx, or nil if there isn't one.
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanContextKey).(*Span)
+	return span
+}
+
+// SetAttribute records a key-value pair on the span.
+func (s *Span) SetAttribute(key, value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.record.Attributes[key] = value
+}
+
+// RecordError marks the span as failed with err.
+func (s *Span) RecordError(err error) {
+	if err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.record.Error = err.Error()
+}
+
+// End finishes the span and passes it to the exporter. Calls after the first do nothing.
+func (s *Span) End() {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.record.End = time.Now()
+	record := s.copyRecord()
+	s.mu.Unlock()
+
+	if s.tracer.Exporter != nil && record.Sampled {
+		s.tracer.Exporter.ExportSpan(record)
+	}
+}
+
+// Record returns a copy of the span as it is now.
+func (s *Span) Record() SpanRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.copyRecord()
+}
+
+// Traceparent returns the W3C traceparent header value identifying the span.
+func (s *Span) Traceparent() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	flags := "00"
+	if s.record.Sampled {
+		flags = "01"
+	}
+	return "00-" + s.record.TraceID + "-" + s.record.SpanID + "-" + flags
+}
+
+func (s *Span) copyRecord() SpanRecord {
+	record := s.record
+	record.Attributes = make(map[string]string, len(s.record.Attributes))
+	for key, value := range s.record.Attributes {
+		record.Attributes[key] = value
+	}
+	return record
+}
+
+// InjectTraceparent sets the traceparent header of an outbound request to the span in ctx, so that the
+// remote service continues the trace. It does nothing if ctx has no span.
+func InjectTraceparent(ctx context.Context, header http.Header) {
+	if span := SpanFromContext(ctx); span != nil {
+		header.Set("traceparent", span.Traceparent())
+	}
+}
+
+// ParseTraceparent parses a W3C traceparent header value, such as
+// "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", into a SpanRecord holding the trace ID,
+// span ID and sampled flag.
+func ParseTraceparent(value string) (SpanRecord, error) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return SpanRecord{}, errors.New("invalid traceparent")
+	}
+	traceID, spanID, flags := parts[1], parts[2], parts[3]
+	if !isLowerHex(traceID, 32) || !isLowerHex(spanID, 16) || !isLowerHex(flags, 2) ||
+		traceID == strings.Repeat("0", 32) || spanID == strings.Repeat("0", 16) {
+		return SpanRecord{}, errors.New("invalid traceparent")
+	}
+
+	flagBits, _ := hex.DecodeString(flags)
+	return SpanRecord{TraceID: traceID, SpanID: spanID, Sampled: flagBits[0]&1 == 1}, nil
+}
+
+func isLowerHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// randomHexID returns n random bytes as hex. It panics if the system's random source fails, since trace
+// IDs must not repeat.
+func randomHexID(n int) string {
+	b, err := randomBytes(n)
+	if err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,104 @@
+package gohelpertools
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+type recordingExporter struct {
+	mu    sync.Mutex
+	spans []SpanRecord
+}
+
+func (e *recordingExporter) ExportSpan(span SpanRecord) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, span)
+}
+
+func TestTracer_Middleware(t *testing.T) {
+	exporter := &recordingExporter{}
+	tracer := &Tracer{Exporter: exporter}
+
+	var outbound http.Header
+	handler := tracer.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracer.StartSpan(r.Context(), "db.query")
+		span.SetAttribute("db.table", "users")
+		outbound = make(http.Header)
+		InjectTraceparent(ctx, outbound)
+		span.End()
+		span.End()
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/users/7", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if len(exporter.spans) != 2 {
+		t.Fatalf("expected 2 spans but got %d", len(exporter.spans))
+	}
+	child, server := exporter.spans[0], exporter.spans[1]
+
+	if server.Name != "GET /users/:id" || server.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || server.ParentID != "00f067aa0ba902b7" {
+		t.Errorf("unexpected server span %+v", server)
+	}
+	if server.Attributes["http.status_code"] != "500" || server.Error == "" {
+		t.Errorf("expected the server span to record the 500, got %+v", server)
+	}
+	if child.TraceID != server.TraceID || child.ParentID != server.SpanID || child.Attributes["db.table"] != "users" {
+		t.Errorf("unexpected child span %+v", child)
+	}
+	if want := "00-" + child.TraceID + "-" + child.SpanID + "-01"; outbound.Get("traceparent") != want {
+		t.Errorf("expected outbound traceparent %q but got %q", want, outbound.Get("traceparent"))
+	}
+}
+
+func TestTracer_NotSampled(t *testing.T) {
+	exporter := &recordingExporter{}
+	tracer := &Tracer{Exporter: exporter}
+
+	handler := tracer.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if len(exporter.spans) != 0 {
+		t.Errorf("expected unsampled spans not to be exported, got %d", len(exporter.spans))
+	}
+
+	_, span := tracer.StartSpan(context.Background(), "job")
+	span.End()
+	if len(exporter.spans) != 1 || exporter.spans[0].ParentID != "" || len(exporter.spans[0].TraceID) != 32 {
+		t.Errorf("expected a new root span, got %+v", exporter.spans)
+	}
+}
+
+var traceparentTests = []struct {
+	name          string
+	value         string
+	errorExpected bool
+}{
+	{name: "valid", value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+	{name: "future version with extra fields", value: "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra"},
+	{name: "upper case", value: "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", errorExpected: true},
+	{name: "zero trace ID", value: "00-00000000000000000000000000000000-00f067aa0ba902b7-01", errorExpected: true},
+	{name: "invalid version", value: "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", errorExpected: true},
+	{name: "short span ID", value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa-01", errorExpected: true},
+	{name: "empty", value: "", errorExpected: true},
+}
+
+func TestParseTraceparent(t *testing.T) {
+	for _, e := range traceparentTests {
+		_, err := ParseTraceparent(e.value)
+		if e.errorExpected && err == nil {
+			t.Errorf("%s: error expected, but none received", e.name)
+		}
+		if !e.errorExpected && err != nil {
+			t.Errorf("%s: error not expected, but one received: %s", e.name, err)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package gohelpertools
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Truncate shortens s to at most n characters (runes, so multi-byte characters are never split),
+// including suffix, such as "…", which is appended when s is cut. Trailing space before the suffix is
+// removed. If n is too small to fit the suffix, s is cut to n characters without it.
+func (t *Tools) Truncate(s string, n int, suffix string) string {
+	if n <= 0 {
+		return ""
+	}
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+
+	keep := n - utf8.RuneCountInString(suffix)
+	if keep <= 0 {
+		return truncateRunes(s, n)
+	}
+	return strings.TrimRightFunc(truncateRunes(s, keep), unicode.IsSpace) + suffix
+}
+
+// TruncateWords shortens s to its first n words, appending suffix if any words were removed. Spacing
+// between the words which are kept is left as it was.
+func (t *Tools) TruncateWords(s string, n int, suffix string) string {
+	if n <= 0 {
+		return ""
+	}
+
+	words := 0
+	inWord := false
+	for i, r := range s {
+		switch {
+		case unicode.IsSpace(r):
+			if inWord && words == n {
+				if strings.TrimSpace(s[i:]) == "" {
+					return s
+				}
+				return s[:i] + suffix
+			}
+			inWord = false
+		case !inWord:
+			inWord = true
+			words++
+		}
+	}
+	return s
+}
+
+// truncateRunes returns the first n runes of s.
+func truncateRunes(s string, n int) string {
+	count := 0
+	for i := range s {
+		if count == n {
+			return s[:i]
+		}
+		count++
+	}
+	return s
+}
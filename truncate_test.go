@@ -0,0 +1,53 @@
+package gohelpertools
+
+import "testing"
+
+var truncateTests = []struct {
+	name     string
+	s        string
+	n        int
+	suffix   string
+	expected string
+}{
+	{name: "short enough", s: "hello", n: 5, suffix: "…", expected: "hello"},
+	{name: "cut with suffix", s: "hello world", n: 8, suffix: "...", expected: "hello..."},
+	{name: "trailing space removed", s: "hello world", n: 7, suffix: "…", expected: "hello…"},
+	{name: "multi-byte runes", s: "héllo wörld", n: 6, suffix: "…", expected: "héllo…"},
+	{name: "cjk", s: "こんにちは世界", n: 4, suffix: "…", expected: "こんに…"},
+	{name: "suffix too long", s: "hello world", n: 2, suffix: "...", expected: "he"},
+	{name: "zero", s: "hello", n: 0, suffix: "…", expected: ""},
+}
+
+func TestTools_Truncate(t *testing.T) {
+	var tools Tools
+
+	for _, e := range truncateTests {
+		if result := tools.Truncate(e.s, e.n, e.suffix); result != e.expected {
+			t.Errorf("%s: expected %q but got %q", e.name, e.expected, result)
+		}
+	}
+}
+
+var truncateWordsTests = []struct {
+	name     string
+	s        string
+	n        int
+	expected string
+}{
+	{name: "fewer words", s: "one two", n: 3, expected: "one two"},
+	{name: "exact with trailing space", s: "one two  ", n: 2, expected: "one two  "},
+	{name: "cut", s: "one two three four", n: 2, expected: "one two…"},
+	{name: "spacing kept", s: "  one\ttwo   three", n: 2, expected: "  one\ttwo…"},
+	{name: "unicode", s: "crème brûlée à la carte", n: 2, expected: "crème brûlée…"},
+	{name: "zero", s: "one", n: 0, expected: ""},
+}
+
+func TestTools_TruncateWords(t *testing.T) {
+	var tools Tools
+
+	for _, e := range truncateWordsTests {
+		if result := tools.TruncateWords(e.s, e.n, "…"); result != e.expected {
+			t.Errorf("%s: expected %q but got %q", e.name, e.expected, result)
+		}
+	}
+}
@@ -0,0 +1,614 @@
+package gohelpertools
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// maxUploadFieldSize is the most UploadFilesTo reads of a form field which isn't a file.
+const maxUploadFieldSize = 1 << 20
+
+// errUploadTooLarge is the error of a file which was cut short because the request exceeded MaxUploadSize.
+var errUploadTooLarge = errors.New("the upload is too big")
+
+// UploadedFile describes a file saved by UploadFiles. MD5 and SHA256 are hex digests of the content as it
+// was uploaded, computed while it was saved.
+type UploadedFile struct {
+	FieldName        string // name of the form field the file was sent in
+	NewFileName      string
+	OriginalFileName string
+	FileSize         int64  // size of the saved file, which is smaller than the upload if an image was resized
+	ContentType      string // detected from the content, not taken from the client
+	MD5              string
+	SHA256           string
+	ThumbnailName    string // name of the thumbnail saved with an image or PDF, if ImageUploads or PDFUploads asks for one
+	Text             string // text extracted from a PDF, if PDFUploads asks for it
+
+	stored []storedUpload // what has been put in the FileSystem for this file so far
+}
+
+// storedUpload is a file put in a FileSystem during an upload. A file which would replace one that may
+// already exist is put under a temporary name, and only moved to final once the upload has been verified.
+type storedUpload struct {
+	name  string
+	final string // empty if name is already the final name
+}
+
+// ChecksumError is returned by UploadFiles when a file doesn't match the checksum the client sent for it,
+// which usually means it was corrupted in transit. The file is not kept.
+type ChecksumError struct {
+	FileName  string // the original name of the file
+	Algorithm string // "md5", "sha1", "sha256" or "xxhash"
+	Expected  string // hex digest the client sent
+	Actual    string // hex digest of what was received
+}
+
+func (e *ChecksumError) Error() string {
+	return fmt.Sprintf("checksum mismatch for %s: expected %s %s, got %s", e.FileName, e.Algorithm, e.Expected, e.Actual)
+}
+
+// UploadError is why one file, or form field, of an upload wasn't saved.
+type UploadError struct {
+	FieldName string // name of the form field
+	FileName  string // original name of the file; empty for a form field which isn't a file
+	Err       error
+}
+
+func (e *UploadError) Error() string { return e.Err.Error() }
+
+func (e *UploadError) Unwrap() error { return e.Err }
+
+// UploadErrors is the error UploadFiles returns when some files couldn't be saved. The files which could be
+// are returned with it, so a handler can report on each file; errors.As finds a *ChecksumError among them.
+type UploadErrors []*UploadError
+
+func (e UploadErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+func (e UploadErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+	return errs
+}
+
+// UploadOneFile is a convenience method which calls UploadFiles, and expects exactly one file. If the
+// request has more than one, none of them are kept.
+func (t *Tools) UploadOneFile(r *http.Request, uploadDir string, rename ...bool) (*UploadedFile, error) {
+	fsys := NewLocalFileSystem(uploadDir, "")
+	files, err := t.UploadFilesTo(r, fsys, rename...)
+	if err != nil || len(files) != 1 {
+		for _, f := range files {
+			removeUploadedFile(r.Context(), fsys, f)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("expected one uploaded file, got %d", len(files))
+	}
+	return files[0], nil
+}
+
+// UploadFiles saves the files of a multipart/form-data request to uploadDir, which is created if it
+// doesn't exist, and returns what was saved. It is UploadFilesTo with a LocalFileSystem.
+func (t *Tools) UploadFiles(r *http.Request, uploadDir string, rename ...bool) ([]*UploadedFile, error) {
+	return t.UploadFilesTo(r, NewLocalFileSystem(uploadDir, ""), rename...)
+}
+
+// UploadFilesTo saves the files of a multipart/form-data request to fsys, and returns what was saved.
+// Files are given random names, keeping their extension, unless rename is false. Files keeping the client's
+// name are saved under a temporary name first, and only replace an existing file of that name once all the
+// checks have passed, so a failed upload never loses an existing file.
+//
+// The request is streamed: each file is written to fsys as it arrives, so neither memory nor temporary
+// files hold whole uploads. Each file must be at most MaxFileSize bytes and, if AllowedFileTypes is set, of
+// one of those types, as detected from its content; the request as a whole must be at most MaxUploadSize
+// bytes. A file which fails a check is removed, and the others are still saved: the files which were
+// saved are returned with an UploadErrors listing those which weren't. Once the request exceeds
+// MaxUploadSize, the rest of it is not read. Form fields which aren't files are available afterwards from
+// r.FormValue and r.PostFormValue, up to 1MB each.
+//
+// A client can send checksums to protect against corruption: a Content-MD5 (base64, as in RFC 1864),
+// X-Checksum-Sha1, X-Checksum-Sha256 or X-Checksum-Xxhash (hex or base64) header on a file's part, or on
+// the request itself when it has only one file. The digests are computed as the file is written, and if
+// they don't match, the file is removed and its error is a *ChecksumError.
+//
+// If ImageUploads is set, JPEG, PNG and WebP images are decoded within its limits, turned upright according
+// to their EXIF orientation, scaled down to MaxWidth x MaxHeight and re-encoded, which also strips their
+// metadata; WebP images are saved as PNG. A thumbnail is saved too if ThumbnailWidth and ThumbnailHeight
+// are set.
+//
+// If PDFUploads is set, PDF documents which are incomplete are rejected, and their text is extracted and a
+// thumbnail saved if it asks for them.
+func (t *Tools) UploadFilesTo(r *http.Request, fsys FileSystem, rename ...bool) ([]*UploadedFile, error) {
+	renameFile := true
+	if len(rename) > 0 {
+		renameFile = rename[0]
+	}
+
+	maxFileSize := int64(t.MaxFileSize)
+	if maxFileSize <= 0 {
+		maxFileSize = defaultMaxUpload
+	}
+	maxUploadSize := int64(t.MaxUploadSize)
+	if maxUploadSize <= 0 {
+		maxUploadSize = 10 * maxFileSize
+	}
+
+	body := &uploadLimitReader{r: r.Body, remaining: maxUploadSize, err: errUploadTooLarge}
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{body, r.Body}
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, errors.New("the request isn't multipart/form-data")
+	}
+
+	var (
+		uploaded  []*UploadedFile
+		digests   []uploadDigests
+		failed    UploadErrors
+		fileParts int
+		values    = make(map[string][]string)
+	)
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			if body.exceeded {
+				err = fmt.Errorf("the upload is too big; the maximum is %d bytes", maxUploadSize)
+			}
+			failed = append(failed, &UploadError{Err: err})
+			break
+		}
+
+		if part.FileName() == "" {
+			value, err := io.ReadAll(&uploadLimitReader{r: part, remaining: maxUploadFieldSize, err: errors.New("form value is too long")})
+			if err != nil {
+				failed = append(failed, &UploadError{FieldName: part.FormName(), Err: err})
+			} else {
+				values[part.FormName()] = append(values[part.FormName()], string(value))
+			}
+			continue
+		}
+
+		fileParts++
+		file, digest, err := t.saveUploadedFile(r.Context(), fsys, part, renameFile, maxFileSize, mergeChecksumHeaders(part.Header, r.Header))
+		if err != nil {
+			if body.exceeded {
+				err = fmt.Errorf("the upload is too big; the maximum is %d bytes", maxUploadSize)
+			}
+			failed = append(failed, &UploadError{FieldName: part.FormName(), FileName: part.FileName(), Err: err})
+			if body.exceeded {
+				break
+			}
+			continue
+		}
+		uploaded = append(uploaded, file)
+		digests = append(digests, digest)
+	}
+	setUploadFormValues(r, values)
+
+	// Checksums sent on the request itself apply only when it has a single file.
+	if fileParts == 1 && len(uploaded) == 1 {
+		if err := digests[0].verify(uploaded[0], mergeChecksumHeaders(nil, r.Header)); err != nil {
+			removeUploadedFile(r.Context(), fsys, uploaded[0])
+			failed = append(failed, &UploadError{FieldName: uploaded[0].FieldName, FileName: uploaded[0].OriginalFileName, Err: err})
+			uploaded = nil
+		}
+	}
+
+	// Only now that every check has passed are files moved to the names which may replace existing ones.
+	committed := uploaded[:0]
+	for _, file := range uploaded {
+		if err := commitUploadedFile(r.Context(), fsys, file); err != nil {
+			removeUploadedFile(r.Context(), fsys, file)
+			failed = append(failed, &UploadError{FieldName: file.FieldName, FileName: file.OriginalFileName, Err: err})
+			continue
+		}
+		committed = append(committed, file)
+	}
+	uploaded = committed
+
+	if len(failed) > 0 {
+		return uploaded, failed
+	}
+	return uploaded, nil
+}
+
+// saveUploadedFile checks and saves one file, verifying the checksums in the part's own headers. The
+// digests are computed for every algorithm in checksums, and returned so that the caller can verify the
+// request's checksums too.
+func (t *Tools) saveUploadedFile(ctx context.Context, fsys FileSystem, part *multipart.Part, rename bool, maxSize int64, checksums map[string][]string) (*UploadedFile, uploadDigests, error) {
+	in := &uploadLimitReader{
+		r:         part,
+		remaining: maxSize,
+		err:       fmt.Errorf("the uploaded file %s is too big; the maximum is %d bytes", part.FileName(), maxSize),
+	}
+
+	// Detect the type from the content; the Content-Type of the part and the file name are up to the client.
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(in, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, nil, err
+	}
+	head = head[:n]
+	contentType, err := t.DetectContentType(bytes.NewReader(head))
+	if err != nil {
+		return nil, nil, err
+	}
+	if !t.allowedFileType(contentType) {
+		return nil, nil, fmt.Errorf("the uploaded file type %s is not permitted", contentType)
+	}
+
+	file := &UploadedFile{
+		FieldName:        part.FormName(),
+		OriginalFileName: part.FileName(),
+		NewFileName:      filepath.Base(part.FileName()),
+		ContentType:      contentType,
+	}
+	if rename {
+		name, err := randomToken(12)
+		if err != nil {
+			return nil, nil, err
+		}
+		file.NewFileName = name + filepath.Ext(part.FileName())
+	}
+	if file.NewFileName == "." || file.NewFileName == ".." || file.NewFileName == string(filepath.Separator) {
+		return nil, nil, fmt.Errorf("invalid file name %q", part.FileName())
+	}
+
+	digests := newUploadDigests(checksums)
+	content := io.MultiReader(bytes.NewReader(head), in)
+	if t.ImageUploads != nil && isProcessableImage(contentType) {
+		file, err := t.saveUploadedImage(ctx, fsys, file, io.TeeReader(content, digests.writer()), digests, part.Header, rename)
+		return file, digests, err
+	}
+	if t.PDFUploads != nil && contentType == "application/pdf" {
+		file, err := t.saveUploadedPDF(ctx, fsys, file, io.TeeReader(content, digests.writer()), digests, part.Header, rename)
+		return file, digests, err
+	}
+
+	counter := &countingWriter{}
+	if err := storeUploadedFile(ctx, fsys, file, file.NewFileName, io.TeeReader(content, io.MultiWriter(digests.writer(), counter)), rename); err != nil {
+		return nil, nil, err
+	}
+	file.FileSize = counter.n
+
+	if err := digests.verify(file, part.Header); err != nil {
+		removeUploadedFile(ctx, fsys, file)
+		return nil, nil, err
+	}
+	return file, digests, nil
+}
+
+// saveUploadedImage verifies, processes and saves an image, and its thumbnail if one is wanted. The caller
+// has arranged for in to be hashed into digests.
+func (t *Tools) saveUploadedImage(ctx context.Context, fsys FileSystem, file *UploadedFile, in io.Reader, digests uploadDigests, checksums map[string][]string, rename bool) (*UploadedFile, error) {
+	opts := *t.ImageUploads
+	img, format, err := t.DecodeImage(in, opts)
+	if err != nil {
+		return nil, fmt.Errorf("the uploaded image %s can't be used: %w", file.OriginalFileName, err)
+	}
+	if err := digests.verify(file, checksums); err != nil {
+		return nil, err
+	}
+
+	fitted, err := fitImage(img, opts.MaxWidth, opts.MaxHeight)
+	if err != nil {
+		return nil, fmt.Errorf("the uploaded image %s can't be used: %w", file.OriginalFileName, err)
+	}
+	var out bytes.Buffer
+	quality := imageDefaults([]ImageOptions{opts}).Quality
+	if format, err = encodeImage(&out, fitted, format, quality); err != nil {
+		return nil, err
+	}
+	file.NewFileName = imageFileName(file.NewFileName, format)
+	file.ContentType = "image/" + format
+	file.FileSize = int64(out.Len())
+	if err := storeUploadedFile(ctx, fsys, file, file.NewFileName, &out, rename); err != nil {
+		return nil, err
+	}
+
+	if opts.ThumbnailWidth > 0 && opts.ThumbnailHeight > 0 {
+		cropped, err := cropToFill(img, opts.ThumbnailWidth, opts.ThumbnailHeight)
+		if err != nil {
+			removeUploadedFile(ctx, fsys, file)
+			return nil, err
+		}
+		var thumb bytes.Buffer
+		if _, err := encodeImage(&thumb, cropped, format, quality); err != nil {
+			removeUploadedFile(ctx, fsys, file)
+			return nil, err
+		}
+		ext := filepath.Ext(file.NewFileName)
+		file.ThumbnailName = strings.TrimSuffix(file.NewFileName, ext) + "_thumb" + ext
+		if err := storeUploadedFile(ctx, fsys, file, file.ThumbnailName, &thumb, rename); err != nil {
+			removeUploadedFile(ctx, fsys, file)
+			return nil, err
+		}
+	}
+	return file, nil
+}
+
+// saveUploadedPDF checks and saves a PDF document, extracting its text and saving its thumbnail if
+// PDFUploads asks for them. The caller has arranged for in to be hashed into digests, and limited to the
+// maximum file size.
+func (t *Tools) saveUploadedPDF(ctx context.Context, fsys FileSystem, file *UploadedFile, in io.Reader, digests uploadDigests, checksums map[string][]string, rename bool) (*UploadedFile, error) {
+	opts := *t.PDFUploads
+	data, err := io.ReadAll(in)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkPDF(data); err != nil {
+		return nil, fmt.Errorf("the uploaded pdf %s can't be used: %w", file.OriginalFileName, err)
+	}
+	if err := digests.verify(file, checksums); err != nil {
+		return nil, err
+	}
+
+	streams := parsePDFStreams(data)
+	if opts.ExtractText {
+		file.Text = pdfText(streams)
+	}
+	file.FileSize = int64(len(data))
+	if err := storeUploadedFile(ctx, fsys, file, file.NewFileName, bytes.NewReader(data), rename); err != nil {
+		return nil, err
+	}
+
+	if opts.ThumbnailWidth > 0 && opts.ThumbnailHeight > 0 {
+		var imageOpts []ImageOptions
+		if t.ImageUploads != nil {
+			imageOpts = append(imageOpts, *t.ImageUploads)
+		}
+		img, err := pdfThumbnail(streams, opts.ThumbnailWidth, opts.ThumbnailHeight, imageDefaults(imageOpts).MaxPixels)
+		if errors.Is(err, errNoPDFThumbnail) {
+			return file, nil
+		}
+		var thumb bytes.Buffer
+		if err == nil {
+			_, err = encodeImage(&thumb, img, "jpeg", imageDefaults(nil).Quality)
+		}
+		if err != nil {
+			removeUploadedFile(ctx, fsys, file)
+			return nil, err
+		}
+		file.ThumbnailName = strings.TrimSuffix(file.NewFileName, filepath.Ext(file.NewFileName)) + "_thumb.jpg"
+		if err := storeUploadedFile(ctx, fsys, file, file.ThumbnailName, &thumb, rename); err != nil {
+			removeUploadedFile(ctx, fsys, file)
+			return nil, err
+		}
+	}
+	return file, nil
+}
+
+// allowedFileType reports whether contentType is in AllowedFileTypes, with or without its parameters, or
+// AllowedFileTypes is empty.
+func (t *Tools) allowedFileType(contentType string) bool {
+	if len(t.AllowedFileTypes) == 0 {
+		return true
+	}
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	return contains(t.AllowedFileTypes, contentType) || contains(t.AllowedFileTypes, strings.TrimSpace(mediaType))
+}
+
+func isProcessableImage(contentType string) bool {
+	return contentType == "image/jpeg" || contentType == "image/png" || contentType == "image/webp"
+}
+
+// imageFileName gives name the extension of format, unless it already has a matching one.
+func imageFileName(name, format string) string {
+	ext := strings.ToLower(filepath.Ext(name))
+	if (format == "jpeg" && (ext == ".jpg" || ext == ".jpeg")) || (format == "png" && ext == ".png") {
+		return name
+	}
+	if format == "jpeg" {
+		format = "jpg"
+	}
+	return strings.TrimSuffix(name, filepath.Ext(name)) + "." + format
+}
+
+// uploadDigests hashes an upload with MD5 and SHA-256, and with any other algorithm the client sent a
+// checksum for.
+type uploadDigests map[ChecksumAlgorithm]hash.Hash
+
+func newUploadDigests(headers map[string][]string) uploadDigests {
+	digests := uploadDigests{ChecksumMD5: md5.New(), ChecksumSHA256: sha256.New()}
+	for _, check := range checksumHeaders {
+		if _, ok := digests[check.algorithm]; !ok && len(headers[check.header]) > 0 {
+			digests[check.algorithm], _ = newChecksumHash(check.algorithm)
+		}
+	}
+	return digests
+}
+
+func (d uploadDigests) writer() io.Writer {
+	writers := make([]io.Writer, 0, len(d))
+	for _, h := range d {
+		writers = append(writers, h)
+	}
+	return io.MultiWriter(writers...)
+}
+
+// verify sets the digests of file, and compares them with the checksums in headers.
+func (d uploadDigests) verify(file *UploadedFile, headers map[string][]string) error {
+	file.MD5 = hex.EncodeToString(d[ChecksumMD5].Sum(nil))
+	file.SHA256 = hex.EncodeToString(d[ChecksumSHA256].Sum(nil))
+
+	for _, check := range checksumHeaders {
+		values := headers[check.header]
+		if len(values) == 0 || values[0] == "" {
+			continue
+		}
+
+		actual := d[check.algorithm].Sum(nil)
+		expected, err := decodeChecksum(values[0], len(actual))
+		if err != nil {
+			return fmt.Errorf("invalid %s header for %s", check.header, file.OriginalFileName)
+		}
+		if subtle.ConstantTimeCompare(expected, actual) != 1 {
+			return &ChecksumError{
+				FileName:  file.OriginalFileName,
+				Algorithm: string(check.algorithm),
+				Expected:  hex.EncodeToString(expected),
+				Actual:    hex.EncodeToString(actual),
+			}
+		}
+	}
+	return nil
+}
+
+// decodeChecksum decodes a digest of size bytes sent as hex or base64.
+func decodeChecksum(value string, size int) ([]byte, error) {
+	value = strings.TrimSpace(value)
+	if len(value) == size*2 {
+		if b, err := hex.DecodeString(value); err == nil {
+			return b, nil
+		}
+	}
+	b, err := base64.StdEncoding.DecodeString(value)
+	if err != nil || len(b) != size {
+		return nil, errors.New("invalid checksum")
+	}
+	return b, nil
+}
+
+// mergeChecksumHeaders returns the checksum headers of a part, falling back to those of the request.
+func mergeChecksumHeaders(part, request map[string][]string) map[string][]string {
+	merged := map[string][]string{}
+	for _, check := range checksumHeaders {
+		if values := part[check.header]; len(values) > 0 {
+			merged[check.header] = values
+		} else if values := request[check.header]; len(values) > 0 {
+			merged[check.header] = values
+		}
+	}
+	return merged
+}
+
+// storeUploadedFile puts r in fsys for file under name. A random name can't belong to an existing file, so
+// it is used at once; otherwise the file is put under a temporary name, and moved to name by
+// commitUploadedFile, so that an upload which fails never replaces or removes an existing file. Put is
+// atomic, so nothing needs removing if it fails.
+func storeUploadedFile(ctx context.Context, fsys FileSystem, file *UploadedFile, name string, r io.Reader, random bool) error {
+	stored := storedUpload{name: name}
+	if !random {
+		token, err := randomToken(12)
+		if err != nil {
+			return err
+		}
+		dir := path.Dir(name)
+		stored = storedUpload{name: path.Join(dir, ".upload-"+token+".tmp"), final: name}
+	}
+
+	if err := fsys.Put(ctx, stored.name, r); err != nil {
+		return err
+	}
+	file.stored = append(file.stored, stored)
+	return nil
+}
+
+// commitUploadedFile moves the parts of file which were put under temporary names to their final names.
+func commitUploadedFile(ctx context.Context, fsys FileSystem, file *UploadedFile) error {
+	for i, stored := range file.stored {
+		if stored.final == "" {
+			continue
+		}
+		in, err := fsys.Get(ctx, stored.name)
+		if err != nil {
+			return err
+		}
+		err = fsys.Put(ctx, stored.final, in)
+		in.Close()
+		if err != nil {
+			return err
+		}
+		_ = fsys.Delete(ctx, stored.name)
+		file.stored[i] = storedUpload{name: stored.final}
+	}
+	return nil
+}
+
+// removeUploadedFile removes what has been saved for file, and its thumbnail. Files at their final names
+// are only removed if this upload put them there.
+func removeUploadedFile(ctx context.Context, fsys FileSystem, file *UploadedFile) {
+	for _, stored := range file.stored {
+		_ = fsys.Delete(ctx, stored.name)
+	}
+	file.stored = nil
+}
+
+// setUploadFormValues makes the form values read by UploadFilesTo available from r, as ParseMultipartForm
+// would have.
+func setUploadFormValues(r *http.Request, values map[string][]string) {
+	r.MultipartForm = &multipart.Form{Value: values}
+	if r.PostForm == nil {
+		r.PostForm = make(url.Values)
+	}
+	if r.Form == nil {
+		r.Form = r.URL.Query()
+	}
+	for key, vs := range values {
+		r.PostForm[key] = append(r.PostForm[key], vs...)
+		r.Form[key] = append(vs, r.Form[key]...)
+	}
+}
+
+// uploadLimitReader reads at most remaining bytes from r, and fails with err if there are more.
+type uploadLimitReader struct {
+	r         io.Reader
+	remaining int64
+	err       error
+	exceeded  bool
+}
+
+func (l *uploadLimitReader) Read(p []byte) (int, error) {
+	if l.exceeded {
+		return 0, l.err
+	}
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+	n, err := l.r.Read(p)
+	if int64(n) > l.remaining {
+		l.exceeded = true
+		n = int(l.remaining)
+		err = l.err
+	}
+	l.remaining -= int64(n)
+	return n, err
+}
+
+// countingWriter counts the bytes written to it.
+type countingWriter struct {
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	c.n += int64(len(p))
+	return len(p), nil
+}
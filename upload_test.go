@@ -0,0 +1,233 @@
+package gohelpertools
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n")
+
+// newUploadRequest builds a multipart request with one file part per entry in files, each with the given
+// part headers.
+func newUploadRequest(t *testing.T, files map[string][]byte, partHeaders map[string]textproto.MIMEHeader) *http.Request {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	for name, content := range files {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Disposition", `form-data; name="file"; filename="`+name+`"`)
+		header.Set("Content-Type", "application/octet-stream")
+		for key, values := range partHeaders[name] {
+			header[key] = values
+		}
+		part, err := w.CreatePart(header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = part.Write(content)
+	}
+	_ = w.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/upload", &body)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	return req
+}
+
+var uploadTests = []struct {
+	name          string
+	content       []byte
+	allowedTypes  []string
+	partHeaders   textproto.MIMEHeader
+	requestHeader http.Header
+	rename        bool
+	errorExpected bool
+	checksumError bool
+}{
+	{name: "allowed", content: append(pngHeader, "data"...), allowedTypes: []string{"image/png"}, rename: true},
+	{name: "not allowed", content: []byte("plain text"), allowedTypes: []string{"image/png"}, errorExpected: true},
+	{name: "no rename", content: []byte("plain text")},
+	{name: "disguised pdf", content: []byte("%PDF-1.7\n"), allowedTypes: []string{"image/png"}, errorExpected: true},
+	{name: "allowed without parameters", content: []byte("plain text"), allowedTypes: []string{"text/plain"}},
+	{name: "part md5", content: []byte("plain text"), partHeaders: textproto.MIMEHeader{"Content-Md5": {b64MD5("plain text")}}},
+	{name: "part md5 mismatch", content: []byte("plain text"), partHeaders: textproto.MIMEHeader{"Content-Md5": {b64MD5("plain texT")}}, errorExpected: true, checksumError: true},
+	{name: "request sha256 hex", content: []byte("plain text"), requestHeader: http.Header{"X-Checksum-Sha256": {hexSHA256("plain text")}}},
+	{name: "request sha256 mismatch", content: []byte("plain text"), requestHeader: http.Header{"X-Checksum-Sha256": {hexSHA256("other")}}, errorExpected: true, checksumError: true},
+	{name: "part xxhash", content: []byte("hello"), partHeaders: textproto.MIMEHeader{"X-Checksum-Xxhash": {"26c7827d889f6da3"}}},
+	{name: "part xxhash mismatch", content: []byte("hellO"), partHeaders: textproto.MIMEHeader{"X-Checksum-Xxhash": {"26c7827d889f6da3"}}, errorExpected: true, checksumError: true},
+	{name: "request sha1 base64", content: []byte("hello"), requestHeader: http.Header{"X-Checksum-Sha1": {"qvTGHdzF6KLavt4PO0gs2a6pQ00="}}},
+	{name: "invalid checksum", content: []byte("plain text"), partHeaders: textproto.MIMEHeader{"Content-Md5": {"nope"}}, errorExpected: true},
+}
+
+func TestTools_UploadFiles(t *testing.T) {
+	for _, e := range uploadTests {
+		dir := t.TempDir()
+		tools := Tools{AllowedFileTypes: e.allowedTypes}
+
+		req := newUploadRequest(t, map[string][]byte{"file.png": e.content}, map[string]textproto.MIMEHeader{"file.png": e.partHeaders})
+		for key, values := range e.requestHeader {
+			req.Header[key] = values
+		}
+
+		files, err := tools.UploadFiles(req, dir, e.rename)
+		if e.errorExpected {
+			if err == nil {
+				t.Errorf("%s: error expected, but none received", e.name)
+			}
+			var checksumErr *ChecksumError
+			if errors.As(err, &checksumErr) != e.checksumError {
+				t.Errorf("%s: expected a ChecksumError: %v, got %v", e.name, e.checksumError, err)
+			}
+			if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+				t.Errorf("%s: expected the rejected file to be removed", e.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: error not expected, but one received: %s", e.name, err)
+			continue
+		}
+
+		file := files[0]
+		if e.rename == (file.NewFileName == "file.png") {
+			t.Errorf("%s: unexpected file name %s", e.name, file.NewFileName)
+		}
+		if file.MD5 != hex.EncodeToString(md5Sum(e.content)) || file.SHA256 != hexSHA256(string(e.content)) {
+			t.Errorf("%s: unexpected digests %s %s", e.name, file.MD5, file.SHA256)
+		}
+		saved, err := os.ReadFile(filepath.Join(dir, file.NewFileName))
+		if err != nil || !bytes.Equal(saved, e.content) || file.FileSize != int64(len(e.content)) {
+			t.Errorf("%s: file not saved correctly: %v", e.name, err)
+		}
+	}
+}
+
+func TestTools_UploadFilesPartialResults(t *testing.T) {
+	dir := t.TempDir()
+	tools := Tools{MaxFileSize: 10, MaxUploadSize: 10000}
+
+	req := newUploadRequest(t,
+		map[string][]byte{"a.txt": []byte("aaa"), "b.txt": []byte("bbb"), "c.txt": []byte("far too long for the limit")},
+		map[string]textproto.MIMEHeader{"b.txt": {"Content-Md5": {b64MD5("wrong")}}},
+	)
+	files, err := tools.UploadFiles(req, dir, false)
+	var uploadErrs UploadErrors
+	if !errors.As(err, &uploadErrs) || len(uploadErrs) != 2 {
+		t.Fatalf("expected two upload errors, got %v", err)
+	}
+	var checksumErr *ChecksumError
+	if !errors.As(err, &checksumErr) || checksumErr.FileName != "b.txt" {
+		t.Errorf("expected a ChecksumError for b.txt, got %v", err)
+	}
+	if len(files) != 1 || files[0].OriginalFileName != "a.txt" || files[0].FieldName != "file" {
+		t.Fatalf("expected only a.txt to be saved, got %v", files)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("expected one file to be kept, got %d", len(entries))
+	}
+
+	if _, err := tools.UploadOneFile(newUploadRequest(t, map[string][]byte{"a.txt": []byte("a"), "b.txt": []byte("b")}, nil), t.TempDir()); err == nil {
+		t.Error("expected UploadOneFile to reject two files")
+	}
+}
+
+func TestTools_UploadFilesKeepsExistingFile(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "report.txt"), []byte("original"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var tools Tools
+
+	// A failed upload under the same name leaves the existing file alone.
+	req := newUploadRequest(t, map[string][]byte{"report.txt": []byte("corrupted")}, map[string]textproto.MIMEHeader{"report.txt": {"Content-Md5": {b64MD5("replacement")}}})
+	if _, err := tools.UploadFiles(req, dir, false); err == nil {
+		t.Fatal("error expected, but none received")
+	}
+	if saved, _ := os.ReadFile(filepath.Join(dir, "report.txt")); string(saved) != "original" {
+		t.Errorf("expected the existing file to be kept, got %q", saved)
+	}
+
+	req = newUploadRequest(t, map[string][]byte{"report.txt": []byte("replacement")}, nil)
+	req.Header.Set("X-Checksum-Sha256", hexSHA256("other"))
+	if _, err := tools.UploadFiles(req, dir, false); err == nil {
+		t.Fatal("error expected, but none received")
+	}
+	if saved, _ := os.ReadFile(filepath.Join(dir, "report.txt")); string(saved) != "original" {
+		t.Errorf("expected the existing file to be kept after a request checksum mismatch, got %q", saved)
+	}
+
+	// A successful one replaces it, and leaves no temporary files behind.
+	req = newUploadRequest(t, map[string][]byte{"report.txt": []byte("replacement")}, nil)
+	if _, err := tools.UploadFiles(req, dir, false); err != nil {
+		t.Fatal(err)
+	}
+	if saved, _ := os.ReadFile(filepath.Join(dir, "report.txt")); string(saved) != "replacement" {
+		t.Errorf("expected the file to be replaced, got %q", saved)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("expected only the uploaded file, got %d entries", len(entries))
+	}
+}
+
+func TestTools_UploadFilesTotalLimit(t *testing.T) {
+	dir := t.TempDir()
+	tools := Tools{MaxFileSize: 1000, MaxUploadSize: 1000}
+
+	req := newUploadRequest(t, map[string][]byte{"big.txt": bytes.Repeat([]byte("x"), 900), "more.txt": bytes.Repeat([]byte("y"), 900)}, nil)
+	files, err := tools.UploadFiles(req, dir)
+	if err == nil || !strings.Contains(err.Error(), "the maximum is 1000 bytes") {
+		t.Errorf("expected the request to be too big, got %v", err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != len(files) || len(files) > 1 {
+		t.Errorf("expected at most the first file to be kept, got %d saved and %d on disk", len(files), len(entries))
+	}
+}
+
+func TestTools_UploadFilesFormValues(t *testing.T) {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	_ = w.WriteField("title", "holiday")
+	part, _ := w.CreateFormFile("photo", "notes.txt")
+	_, _ = part.Write([]byte("plain text"))
+	_ = w.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/upload?album=7", &body)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+
+	var tools Tools
+	files, err := tools.UploadFiles(req, t.TempDir())
+	if err != nil || len(files) != 1 {
+		t.Fatalf("expected one file, got %d: %v", len(files), err)
+	}
+	if req.FormValue("title") != "holiday" || req.PostFormValue("title") != "holiday" || req.FormValue("album") != "7" {
+		t.Errorf("form values not available: %v", req.Form)
+	}
+	if files[0].FieldName != "photo" {
+		t.Errorf("expected field name photo, got %s", files[0].FieldName)
+	}
+}
+
+func md5Sum(s []byte) []byte {
+	sum := md5.Sum(s)
+	return sum[:]
+}
+
+func b64MD5(s string) string {
+	return base64.StdEncoding.EncodeToString(md5Sum([]byte(s)))
+}
+
+func hexSHA256(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
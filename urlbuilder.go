@@ -0,0 +1,272 @@
+package gohelpertools
+
+import (
+	"encoding"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// URLBuilder builds URLs from a base, escaped path segments, query parameters and a fragment, instead of
+// joining strings with fmt.Sprintf:
+//
+//	uri, err := NewURLBuilder("https://api.example.com/v1").
+//		Path("users", userID, "orders").
+//		Query(ListOptions{Status: "open", Limit: 20}).
+//		Param("expand", "items").
+//		Build()
+//
+// The first error, such as an invalid base, is returned by Build.
+type URLBuilder struct {
+	u     *url.URL
+	query url.Values
+	err   error
+}
+
+// NewURLBuilder returns a URLBuilder starting from base, which may already have a path and query.
+func NewURLBuilder(base string) *URLBuilder {
+	u, err := url.Parse(base)
+	if err != nil {
+		return &URLBuilder{u: &url.URL{}, query: url.Values{}, err: err}
+	}
+	return &URLBuilder{u: u, query: u.Query()}
+}
+
+// Path appends segments to the path, escaping each one, so that a value such as "a/b?c" is a single
+// segment rather than changing the meaning of the URL. Empty segments are skipped.
+func (b *URLBuilder) Path(segments ...string) *URLBuilder {
+	escaped := strings.TrimSuffix(b.u.EscapedPath(), "/")
+	for _, segment := range segments {
+		if segment != "" {
+			escaped += "/" + url.PathEscape(segment)
+		}
+	}
+
+	path, err := url.PathUnescape(escaped)
+	if err != nil {
+		b.fail(err)
+		return b
+	}
+	b.u.Path, b.u.RawPath = path, escaped
+	return b
+}
+
+// Param adds a query parameter. The value is formatted as by EncodeQuery: a slice adds the parameter once
+// for each element, and nil adds nothing.
+func (b *URLBuilder) Param(key string, value any) *URLBuilder {
+	values, err := queryValues(reflect.ValueOf(value), false)
+	if err != nil {
+		b.fail(fmt.Errorf("query parameter %s: %w", key, err))
+		return b
+	}
+	if len(values) > 0 {
+		b.query[key] = append(b.query[key], values...)
+	}
+	return b
+}
+
+// Query adds the query parameters encoded from v by EncodeQuery.
+func (b *URLBuilder) Query(v any) *URLBuilder {
+	query, err := EncodeQuery(v)
+	if err != nil {
+		b.fail(err)
+		return b
+	}
+	for key, values := range query {
+		b.query[key] = append(b.query[key], values...)
+	}
+	return b
+}
+
+// Fragment sets the fragment, the part after #.
+func (b *URLBuilder) Fragment(fragment string) *URLBuilder {
+	b.u.Fragment, b.u.RawFragment = fragment, ""
+	return b
+}
+
+// Build returns the URL, with the query parameters sorted by key, or the first error from building it.
+func (b *URLBuilder) Build() (string, error) {
+	if b.err != nil {
+		return "", b.err
+	}
+	u := *b.u
+	u.RawQuery = b.query.Encode()
+	return u.String(), nil
+}
+
+func (b *URLBuilder) fail(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
+
+// EncodeQuery encodes v, a struct, a map with string keys, or a pointer to either, as query parameters.
+// Struct fields are named by their url tags, or by the field name if they have none:
+//
+//	type ListOptions struct {
+//		Status string    `url:"status,omitempty"`
+//		Limit  int       `url:"limit"`
+//		IDs    []int     `url:"id"`          // id=1&id=2
+//		Tags   []string  `url:"tags,comma"`  // tags=a,b
+//		Since  time.Time `url:"since,omitempty"`
+//		Secret string    `url:"-"`
+//	}
+//
+// Strings, bools, numbers, slices of these, and types implementing encoding.TextMarshaler (such as
+// time.Time) are supported; embedded structs are flattened. Fields tagged omitempty are left out when they
+// have their zero value, and nil pointers and map values are always left out.
+func EncodeQuery(v any) (url.Values, error) {
+	query := url.Values{}
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return query, nil
+		}
+		rv = rv.Elem()
+	}
+
+	switch {
+	case !rv.IsValid():
+		return query, nil
+	case rv.Type() == reflect.TypeOf(url.Values{}):
+		for key, values := range rv.Interface().(url.Values) {
+			query[key] = append(query[key], values...)
+		}
+		return query, nil
+	case rv.Kind() == reflect.Map && rv.Type().Key().Kind() == reflect.String:
+		iter := rv.MapRange()
+		for iter.Next() {
+			values, err := queryValues(iter.Value(), false)
+			if err != nil {
+				return nil, fmt.Errorf("query parameter %s: %w", iter.Key().String(), err)
+			}
+			if len(values) > 0 {
+				query[iter.Key().String()] = values
+			}
+		}
+		return query, nil
+	case rv.Kind() == reflect.Struct:
+		if err := encodeQueryStruct(rv, query); err != nil {
+			return nil, err
+		}
+		return query, nil
+	}
+	return nil, fmt.Errorf("cannot encode %s as query parameters", rv.Type())
+}
+
+func encodeQueryStruct(v reflect.Value, query url.Values) error {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		tag := field.Tag.Get("url")
+		if tag == "-" {
+			continue
+		}
+
+		if field.Anonymous && tag == "" {
+			embedded := v.Field(i)
+			if embedded.Kind() == reflect.Pointer {
+				if embedded.IsNil() {
+					continue
+				}
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct && !implementsTextMarshaler(embedded) {
+				if err := encodeQueryStruct(embedded, query); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		name, flags, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = field.Name
+		}
+		omitEmpty := contains(strings.Split(flags, ","), "omitempty")
+		comma := contains(strings.Split(flags, ","), "comma")
+
+		value := v.Field(i)
+		if omitEmpty && value.IsZero() {
+			continue
+		}
+		values, err := queryValues(value, comma)
+		if err != nil {
+			return fmt.Errorf("query parameter %s: %w", name, err)
+		}
+		if len(values) > 0 {
+			query[name] = append(query[name], values...)
+		}
+	}
+	return nil
+}
+
+// queryValues formats v as the values of one query parameter. Slices and arrays have a value for each
+// element, or a single comma-separated value if comma is set; nil pointers and interfaces have none.
+func queryValues(v reflect.Value, comma bool) ([]string, error) {
+	for v.IsValid() && (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return nil, nil
+	}
+
+	if (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) && !implementsTextMarshaler(v) {
+		values := make([]string, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			elem, err := queryValues(v.Index(i), false)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, elem...)
+		}
+		if comma && len(values) > 0 {
+			return []string{strings.Join(values, ",")}, nil
+		}
+		return values, nil
+	}
+
+	value, err := formatQueryValue(v)
+	if err != nil {
+		return nil, err
+	}
+	return []string{value}, nil
+}
+
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+func implementsTextMarshaler(v reflect.Value) bool {
+	return v.Type().Implements(textMarshalerType) || (v.CanAddr() && v.Addr().Type().Implements(textMarshalerType))
+}
+
+func formatQueryValue(v reflect.Value) (string, error) {
+	if implementsTextMarshaler(v) {
+		m, ok := v.Interface().(encoding.TextMarshaler)
+		if !ok {
+			m = v.Addr().Interface().(encoding.TextMarshaler)
+		}
+		text, err := m.MarshalText()
+		return string(text), err
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits()), nil
+	}
+	return "", fmt.Errorf("unsupported type %s", v.Type())
+}
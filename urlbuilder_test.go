@@ -0,0 +1,97 @@
+package gohelpertools
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+type pageOptions struct {
+	Page int `url:"page,omitempty"`
+}
+
+type listOptions struct {
+	pageOptions
+	Status  string    `url:"status,omitempty"`
+	Limit   int       `url:"limit"`
+	IDs     []int     `url:"id"`
+	Tags    []string  `url:"tags,comma,omitempty"`
+	Since   time.Time `url:"since,omitempty"`
+	Owner   *string   `url:"owner"`
+	Archive bool
+	Secret  string `url:"-"`
+	hidden  string
+}
+
+func TestEncodeQuery(t *testing.T) {
+	owner := "ada"
+	tests := []struct {
+		name          string
+		value         any
+		expected      string
+		errorExpected bool
+	}{
+		{name: "zero struct", value: listOptions{}, expected: "Archive=false&limit=0"},
+		{name: "struct", value: &listOptions{
+			pageOptions: pageOptions{Page: 2}, Status: "open & active", Limit: 20, IDs: []int{1, 2}, Tags: []string{"a", "b"},
+			Since: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC), Owner: &owner, Archive: true, Secret: "s", hidden: "h",
+		}, expected: "Archive=true&id=1&id=2&limit=20&owner=ada&page=2&since=2024-05-01T12%3A00%3A00Z&status=open+%26+active&tags=a%2Cb"},
+		{name: "map", value: map[string]any{"q": "go", "n": 1.5, "missing": nil, "ids": []string{"x", "y"}}, expected: "ids=x&ids=y&n=1.5&q=go"},
+		{name: "values", value: url.Values{"a": {"1", "2"}}, expected: "a=1&a=2"},
+		{name: "nil", value: nil, expected: ""},
+		{name: "unsupported field", value: struct{ F func() }{}, errorExpected: true},
+		{name: "unsupported type", value: []string{"a"}, errorExpected: true},
+	}
+
+	for _, e := range tests {
+		query, err := EncodeQuery(e.value)
+		if e.errorExpected {
+			if err == nil {
+				t.Errorf("%s: error expected, but none received", e.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: error not expected, but one received: %s", e.name, err)
+			continue
+		}
+		if query.Encode() != e.expected {
+			t.Errorf("%s: expected %q, got %q", e.name, e.expected, query.Encode())
+		}
+	}
+}
+
+func TestURLBuilder(t *testing.T) {
+	tests := []struct {
+		name          string
+		builder       *URLBuilder
+		expected      string
+		errorExpected bool
+	}{
+		{name: "base only", builder: NewURLBuilder("https://api.example.com"), expected: "https://api.example.com"},
+		{name: "path segments", builder: NewURLBuilder("https://api.example.com/v1/").Path("users", "a/b?c", "", "orders"), expected: "https://api.example.com/v1/users/a%2Fb%3Fc/orders"},
+		{name: "query", builder: NewURLBuilder("https://api.example.com/search?lang=en").
+			Query(listOptions{Status: "open", Limit: 10}).Param("lang", "fr").Param("id", []int{3, 4}).Param("skip", nil),
+			expected: "https://api.example.com/search?Archive=false&id=3&id=4&lang=en&lang=fr&limit=10&status=open"},
+		{name: "fragment", builder: NewURLBuilder("/docs").Path("guide").Fragment("getting started"), expected: "/docs/guide#getting%20started"},
+		{name: "invalid base", builder: NewURLBuilder("http://[::1"), errorExpected: true},
+		{name: "invalid query", builder: NewURLBuilder("/").Param("f", func() {}), errorExpected: true},
+	}
+
+	for _, e := range tests {
+		uri, err := e.builder.Build()
+		if e.errorExpected {
+			if err == nil {
+				t.Errorf("%s: error expected, but none received", e.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: error not expected, but one received: %s", e.name, err)
+			continue
+		}
+		if uri != e.expected {
+			t.Errorf("%s: expected %q, got %q", e.name, e.expected, uri)
+		}
+	}
+}
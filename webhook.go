@@ -0,0 +1,118 @@
+package gohelpertools
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultWebhookTolerance = 5 * time.Minute
+
+// SignPayload returns a signature header value for body, in the form "t=<unix time>,v1=<hex HMAC-SHA256>"
+// (as used by Stripe). The timestamp is part of the signed content, so old deliveries can't be replayed.
+func (t *Tools) SignPayload(body []byte, secret string) string {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	return "t=" + timestamp + ",v1=" + hex.EncodeToString(webhookMAC(secret, timestamp, body))
+}
+
+// signPayloadWith is SignPayload with a v1 signature for each of secrets, as VerifySignature accepts while
+// a secret is being rotated.
+func signPayloadWith(body []byte, secrets []string) string {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	value := "t=" + timestamp
+	for _, secret := range secrets {
+		value += ",v1=" + hex.EncodeToString(webhookMAC(secret, timestamp, body))
+	}
+	return value
+}
+
+// VerifySignature checks the signature in header of r against its body. Both the timestamped format
+// written by SignPayload, and GitHub's "sha256=<hex>" format (which has no timestamp), are accepted.
+// Timestamped signatures must be within Tools.WebhookTolerance (5 minutes by default) of the current
+// time. The body is restored afterwards, so it can still be read by the handler. An empty secret is an
+// error, since anybody could compute a signature with it.
+func (t *Tools) VerifySignature(r *http.Request, secret, header string) error {
+	if secret == "" {
+		return errors.New("webhook secret must not be empty")
+	}
+
+	value := r.Header.Get(header)
+	if value == "" {
+		return fmt.Errorf("the %s header is missing", header)
+	}
+
+	maxBytes := defaultMaxUpload
+	if t.MaxJSONSize != 0 {
+		maxBytes = t.MaxJSONSize
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, int64(maxBytes)+1))
+	if err != nil {
+		return err
+	}
+	if len(body) > maxBytes {
+		return fmt.Errorf("body must not be larger than %d bytes", maxBytes)
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	if strings.HasPrefix(value, "sha256=") {
+		expected := webhookMAC(secret, "", body)
+		if sig, err := hex.DecodeString(strings.TrimPrefix(value, "sha256=")); err == nil && hmac.Equal(sig, expected) {
+			return nil
+		}
+		return errors.New("webhook signature does not match")
+	}
+
+	var timestamp string
+	var signatures [][]byte
+	for _, part := range strings.Split(value, ",") {
+		key, val, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			timestamp = val
+		case "v1":
+			if sig, err := hex.DecodeString(val); err == nil {
+				signatures = append(signatures, sig)
+			}
+		}
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || len(signatures) == 0 {
+		return errors.New("webhook signature is malformed")
+	}
+
+	tolerance := t.WebhookTolerance
+	if tolerance == 0 {
+		tolerance = defaultWebhookTolerance
+	}
+	if age := time.Since(time.Unix(unix, 0)); age > tolerance || age < -tolerance {
+		return errors.New("webhook signature timestamp is outside the tolerance")
+	}
+
+	// Several v1 signatures may be sent while a secret is being rotated; any one of them will do.
+	expected := webhookMAC(secret, timestamp, body)
+	for _, sig := range signatures {
+		if hmac.Equal(sig, expected) {
+			return nil
+		}
+	}
+	return errors.New("webhook signature does not match")
+}
+
+// webhookMAC signs "timestamp.body", or just body if there is no timestamp.
+func webhookMAC(secret, timestamp string, body []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	if timestamp != "" {
+		mac.Write([]byte(timestamp + "."))
+	}
+	mac.Write(body)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,72 @@
+package gohelpertools
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+var verifySignatureTests = []struct {
+	name          string
+	body          string
+	header        func(body string) string
+	errorExpected bool
+}{
+	{name: "valid", body: `{"id":1}`, header: func(body string) string { return (&Tools{}).SignPayload([]byte(body), "secret") }},
+	{name: "github style", body: `{"id":1}`, header: func(body string) string {
+		mac := hmac.New(sha256.New, []byte("secret"))
+		mac.Write([]byte(body))
+		return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	}},
+	{name: "rotated secret", body: `{"id":1}`, header: func(body string) string {
+		sig := (&Tools{}).SignPayload([]byte(body), "secret")
+		return strings.Replace(sig, ",v1=", ",v1=00ff,v1=", 1)
+	}},
+	{name: "wrong secret", body: `{"id":1}`, header: func(body string) string { return (&Tools{}).SignPayload([]byte(body), "other") }, errorExpected: true},
+	{name: "modified body", body: `{"id":2}`, header: func(string) string { return (&Tools{}).SignPayload([]byte(`{"id":1}`), "secret") }, errorExpected: true},
+	{name: "too old", body: `{"id":1}`, header: func(body string) string {
+		ts := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+		return "t=" + ts + ",v1=" + hex.EncodeToString(webhookMAC("secret", ts, []byte(body)))
+	}, errorExpected: true},
+	{name: "missing", body: `{"id":1}`, header: func(string) string { return "" }, errorExpected: true},
+	{name: "malformed", body: `{"id":1}`, header: func(string) string { return "nonsense" }, errorExpected: true},
+}
+
+func TestTools_VerifySignature(t *testing.T) {
+	var tools Tools
+
+	for _, e := range verifySignatureTests {
+		req := httptest.NewRequest("POST", "/webhook", strings.NewReader(e.body))
+		if header := e.header(e.body); header != "" {
+			req.Header.Set("X-Signature", header)
+		}
+
+		err := tools.VerifySignature(req, "secret", "X-Signature")
+		if e.errorExpected && err == nil {
+			t.Errorf("%s: error expected, but none received", e.name)
+		}
+		if !e.errorExpected && err != nil {
+			t.Errorf("%s: error not expected, but one received: %s", e.name, err)
+		}
+
+		if body, _ := io.ReadAll(req.Body); err == nil && string(body) != e.body {
+			t.Errorf("%s: expected body to be restored, got %q", e.name, body)
+		}
+	}
+}
+
+func TestTools_VerifySignatureEmptySecret(t *testing.T) {
+	var tools Tools
+
+	req := httptest.NewRequest("POST", "/webhook", strings.NewReader("{}"))
+	req.Header.Set("X-Signature", tools.SignPayload([]byte("{}"), ""))
+	if err := tools.VerifySignature(req, "", "X-Signature"); err == nil {
+		t.Error("empty secret: error expected, but none received")
+	}
+}
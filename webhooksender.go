@@ -0,0 +1,351 @@
+package gohelpertools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrSenderClosed is returned by WebhookSender.Send after Close has been called.
+var ErrSenderClosed = errors.New("webhook sender is closed")
+
+// WebhookDelivery is one webhook to be sent to one endpoint.
+type WebhookDelivery struct {
+	ID             string          `json:"id"`
+	URL            string          `json:"url"`
+	Event          string          `json:"event"`
+	Payload        json.RawMessage `json:"payload"`
+	Attempts       int             `json:"attempts"` // number of attempts made so far
+	CreatedAt      time.Time       `json:"created_at"`
+	SubscriptionID string          `json:"subscription_id,omitempty"` // the WebhookSubscription it's for, if any
+}
+
+// WebhookAttempt records the outcome of one attempt to send a delivery.
+type WebhookAttempt struct {
+	DeliveryID string        `json:"delivery_id"`
+	URL        string        `json:"url"`
+	Attempt    int           `json:"attempt"`
+	StatusCode int           `json:"status_code,omitempty"`
+	Error      string        `json:"error,omitempty"`
+	Duration   time.Duration `json:"duration"`
+	Time       time.Time     `json:"time"`
+}
+
+// WebhookSender delivers outbound webhooks in the background. Each delivery is signed with SignPayload
+// and posted to its URL; a response other than 2xx is retried with exponential backoff, and a delivery
+// which still fails after MaxAttempts is passed to OnDeadLetter. The hooks can be used to persist
+// deliveries and attempts, and Resend to re-queue deliveries loaded from storage, for example at startup.
+type WebhookSender struct {
+	Secret          string                          // key used to sign payloads; required
+	SignatureHeader string                          // header carrying the signature; defaults to "X-Webhook-Signature"
+	MaxAttempts     int                             // attempts before a delivery is dead-lettered; defaults to 5
+	Backoff         func(attempt int) time.Duration // delay before retrying after the given attempt; defaults to 1s doubling up to 1h
+	Workers         int                             // number of concurrent senders; defaults to 4
+	QueueSize       int                             // capacity of the queue; defaults to 1000
+	Client          *http.Client                    // used to send deliveries; Tools.PublicHTTPClient is used if nil
+	OnEnqueue       func(WebhookDelivery)           // if set, called when a delivery is queued
+	OnAttempt       func(WebhookAttempt)            // if set, called after every attempt
+	OnDelivered     func(WebhookDelivery)           // if set, called when a delivery succeeds
+	OnDeadLetter    func(WebhookDelivery, error)    // if set, called when a delivery is abandoned
+	Events          *EventRegistry                  // if set, payloads are checked against it and sent as an EventEnvelope
+	Tools           *Tools                          // provides the HTTP client and logs abandoned deliveries; a zero Tools is used if nil
+
+	// Secrets, if set, returns the keys to sign a delivery with instead of Secret, such as those of its
+	// subscription; WebhookSubscriptions.Secrets does this. Each key adds a signature, so that receivers
+	// can rotate keys. Secret is used if it returns none.
+	Secrets func(ctx context.Context, delivery WebhookDelivery) ([]string, error)
+
+	startOnce sync.Once
+	queue     chan WebhookDelivery
+	quit      chan struct{}
+	pending   sync.WaitGroup
+	workers   sync.WaitGroup
+	mu        sync.Mutex
+	closed    bool
+	retries   map[*time.Timer]WebhookDelivery // deliveries waiting for their backoff
+	retrying  sync.WaitGroup                  // retries scheduled and not yet re-queued or dead-lettered
+}
+
+// Send marshals payload to JSON and queues it for delivery to url, returning the delivery ID. It fails if
+// the queue is full, rather than blocking the caller. With Events set, it also fails if payload isn't the
+// registered type of the event's latest version.
+func (s *WebhookSender) Send(url, event string, payload any) (string, error) {
+	delivery, err := s.newDelivery(url, event, payload)
+	if err != nil {
+		return "", err
+	}
+	if err := s.Resend(delivery); err != nil {
+		return "", err
+	}
+	return delivery.ID, nil
+}
+
+// newDelivery builds a delivery of payload, checking it against Events if set.
+func (s *WebhookSender) newDelivery(url, event string, payload any) (WebhookDelivery, error) {
+	if s.Events != nil {
+		env, err := s.Events.Encode(event, payload)
+		if err != nil {
+			return WebhookDelivery{}, err
+		}
+		payload = env
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return WebhookDelivery{}, err
+	}
+
+	id, err := randomToken(16)
+	if err != nil {
+		return WebhookDelivery{}, err
+	}
+	return WebhookDelivery{ID: id, URL: url, Event: event, Payload: body, CreatedAt: time.Now()}, nil
+}
+
+// Resend queues an existing delivery, keeping its ID and attempt count.
+func (s *WebhookSender) Resend(delivery WebhookDelivery) error {
+	s.start()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrSenderClosed
+	}
+
+	s.pending.Add(1)
+	select {
+	case s.queue <- delivery:
+	default:
+		s.pending.Done()
+		return errors.New("webhook queue is full")
+	}
+
+	if s.OnEnqueue != nil {
+		s.OnEnqueue(delivery)
+	}
+	return nil
+}
+
+// Close stops accepting deliveries, and waits until every queued delivery (including those waiting to be
+// retried) has been delivered or dead-lettered, or until ctx is done. Deliveries still outstanding when
+// ctx is done, including those waiting for a retry, are dead-lettered with ctx's error, so that every
+// delivery ends up either delivered or dead-lettered. Calling Close again does nothing.
+func (s *WebhookSender) Close(ctx context.Context) error {
+	s.start()
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.pending.Wait()
+		close(done)
+	}()
+
+	var err error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	close(s.quit)
+	s.workers.Wait()
+
+	// No more retries can be scheduled now that the workers have stopped. Cancel those still waiting, and
+	// wait for any whose timer has already fired to re-queue or dead-letter their delivery.
+	s.mu.Lock()
+	var cancelled []WebhookDelivery
+	for timer, delivery := range s.retries {
+		if timer.Stop() {
+			delete(s.retries, timer)
+			cancelled = append(cancelled, delivery)
+			s.retrying.Done()
+		}
+	}
+	s.mu.Unlock()
+	for _, delivery := range cancelled {
+		s.deadLetter(delivery, ctx.Err())
+	}
+	s.retrying.Wait()
+
+	// Anything left in the queue was never attempted.
+	for {
+		select {
+		case delivery := <-s.queue:
+			s.deadLetter(delivery, ctx.Err())
+		default:
+			return err
+		}
+	}
+}
+
+func (s *WebhookSender) start() {
+	s.startOnce.Do(func() {
+		queueSize := s.QueueSize
+		if queueSize <= 0 {
+			queueSize = 1000
+		}
+		workers := s.Workers
+		if workers <= 0 {
+			workers = 4
+		}
+
+		s.queue = make(chan WebhookDelivery, queueSize)
+		s.quit = make(chan struct{})
+		s.retries = make(map[*time.Timer]WebhookDelivery)
+		for i := 0; i < workers; i++ {
+			s.workers.Add(1)
+			go s.work()
+		}
+	})
+}
+
+func (s *WebhookSender) work() {
+	defer s.workers.Done()
+	for {
+		select {
+		case delivery := <-s.queue:
+			s.attempt(delivery)
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// attempt makes one attempt to send delivery, and schedules a retry or dead-letters it on failure.
+func (s *WebhookSender) attempt(delivery WebhookDelivery) {
+	delivery.Attempts++
+	record := WebhookAttempt{DeliveryID: delivery.ID, URL: delivery.URL, Attempt: delivery.Attempts, Time: time.Now()}
+
+	err := s.post(delivery, &record)
+	record.Duration = time.Since(record.Time)
+	if err != nil {
+		record.Error = err.Error()
+	}
+	if s.OnAttempt != nil {
+		s.OnAttempt(record)
+	}
+
+	if err == nil {
+		if s.OnDelivered != nil {
+			s.OnDelivered(delivery)
+		}
+		s.pending.Done()
+		return
+	}
+
+	maxAttempts := s.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 5
+	}
+	if delivery.Attempts >= maxAttempts {
+		s.deadLetter(delivery, err)
+		return
+	}
+
+	// Wait for the backoff without holding up a worker. The timer is tracked so that Close can cancel it.
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var timer *time.Timer
+	timer = time.AfterFunc(s.backoff(delivery.Attempts), func() {
+		s.retry(&timer, delivery, err)
+	})
+	s.retries[timer] = delivery
+	s.retrying.Add(1)
+}
+
+// retry re-queues delivery once its backoff timer has fired, or dead-letters it with its last error if the
+// sender has been closed in the meantime.
+// timer is read under the lock, since the timer may fire before attempt has stored it.
+func (s *WebhookSender) retry(timer **time.Timer, delivery WebhookDelivery, err error) {
+	defer s.retrying.Done()
+
+	s.mu.Lock()
+	delete(s.retries, *timer)
+	s.mu.Unlock()
+
+	select {
+	case <-s.quit:
+		s.deadLetter(delivery, err)
+		return
+	default:
+	}
+	// If Close stops the workers while this waits, the delivery is still dead-lettered: either here, or by
+	// Close, which drains the queue once every retry has finished.
+	select {
+	case s.queue <- delivery:
+	case <-s.quit:
+		s.deadLetter(delivery, err)
+	}
+}
+
+// post sends delivery once, recording the status code in record.
+func (s *WebhookSender) post(delivery WebhookDelivery, record *WebhookAttempt) error {
+	secrets := []string{s.Secret}
+	if s.Secrets != nil {
+		keys, err := s.Secrets(context.Background(), delivery)
+		if err != nil {
+			return err
+		}
+		if len(keys) > 0 {
+			secrets = keys
+		}
+	}
+
+	headers := http.Header{}
+	headers.Set(valueOrDefault(s.SignatureHeader, "X-Webhook-Signature"), signPayloadWith(delivery.Payload, secrets))
+	headers.Set("X-Webhook-ID", delivery.ID)
+	headers.Set("X-Webhook-Event", delivery.Event)
+
+	// Webhook URLs usually come from users, so by default only public addresses are reached, even after
+	// a redirect or a change to the DNS.
+	client := s.Client
+	if client == nil {
+		client = toolsOrDefault(s.Tools).PublicHTTPClient()
+	}
+
+	resp, err := postJSON(context.Background(), client, delivery.URL, delivery.Payload, headers)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	record.StatusCode = resp.StatusCode
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("endpoint returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (s *WebhookSender) deadLetter(delivery WebhookDelivery, err error) {
+	toolsOrDefault(s.Tools).logWarn(context.Background(), "webhook delivery abandoned", "id", delivery.ID,
+		"url", delivery.URL, "event", delivery.Event, "error", err.Error())
+	if s.OnDeadLetter != nil {
+		s.OnDeadLetter(delivery, err)
+	}
+	s.pending.Done()
+}
+
+func (s *WebhookSender) backoff(attempt int) time.Duration {
+	if s.Backoff != nil {
+		return s.Backoff(attempt)
+	}
+
+	delay := time.Second << (attempt - 1)
+	if delay > time.Hour || delay <= 0 {
+		delay = time.Hour
+	}
+	return delay
+}
@@ -0,0 +1,177 @@
+package gohelpertools
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWebhookSender(t *testing.T) {
+	var calls int32
+	tools := &Tools{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := tools.VerifySignature(r, "secret", "X-Webhook-Signature"); err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		// Fail the first attempt of every delivery, to exercise retries.
+		if r.URL.Path == "/flaky" && atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.URL.Path == "/broken" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	var mu sync.Mutex
+	var attempts []WebhookAttempt
+	delivered := map[string]bool{}
+	dead := map[string]bool{}
+
+	sender := &WebhookSender{
+		Secret:      "secret",
+		MaxAttempts: 3,
+		Backoff:     func(int) time.Duration { return time.Millisecond },
+		Client:      srv.Client(),
+		Tools:       tools,
+		OnAttempt: func(a WebhookAttempt) {
+			mu.Lock()
+			defer mu.Unlock()
+			attempts = append(attempts, a)
+		},
+		OnDelivered: func(d WebhookDelivery) {
+			mu.Lock()
+			defer mu.Unlock()
+			delivered[d.URL] = true
+		},
+		OnDeadLetter: func(d WebhookDelivery, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			dead[d.URL] = true
+		},
+	}
+
+	for _, path := range []string{"/ok", "/flaky", "/broken"} {
+		if _, err := sender.Send(srv.URL+path, "order.created", map[string]int{"id": 1}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := sender.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if !delivered[srv.URL+"/ok"] || !delivered[srv.URL+"/flaky"] {
+		t.Errorf("expected /ok and /flaky to be delivered, got %v", delivered)
+	}
+	if !dead[srv.URL+"/broken"] || len(dead) != 1 {
+		t.Errorf("expected only /broken to be dead-lettered, got %v", dead)
+	}
+	if len(attempts) != 1+2+3 {
+		t.Errorf("expected 6 attempts but got %d", len(attempts))
+	}
+
+	if _, err := sender.Send(srv.URL, "order.created", nil); err != ErrSenderClosed {
+		t.Errorf("expected ErrSenderClosed after Close, got %v", err)
+	}
+}
+
+func TestWebhookSender_DefaultClientRefusesPrivateAddresses(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("expected the loopback endpoint not to be reached")
+	}))
+	defer srv.Close()
+
+	dead := make(chan error, 1)
+	sender := &WebhookSender{
+		Secret:       "secret",
+		MaxAttempts:  1,
+		Tools:        &Tools{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))},
+		OnDeadLetter: func(_ WebhookDelivery, err error) { dead <- err },
+	}
+	if _, err := sender.Send(srv.URL, "order.created", map[string]int{"id": 1}); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_ = sender.Close(ctx)
+
+	select {
+	case err := <-dead:
+		if !strings.Contains(err.Error(), "not allowed") {
+			t.Errorf("expected the connection to be refused, got %v", err)
+		}
+	default:
+		t.Error("expected the delivery to be dead-lettered")
+	}
+}
+
+func TestWebhookSender_CloseCancelsRetries(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	attempted := make(chan struct{}, 1)
+	var deadLettered int32
+	var deadErr error
+	sender := &WebhookSender{
+		Secret:       "secret",
+		MaxAttempts:  3,
+		Backoff:      func(int) time.Duration { return time.Hour },
+		Client:       srv.Client(),
+		Tools:        &Tools{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))},
+		OnAttempt:    func(WebhookAttempt) { attempted <- struct{}{} },
+		OnDeadLetter: func(_ WebhookDelivery, err error) { atomic.AddInt32(&deadLettered, 1); deadErr = err },
+	}
+	if _, err := sender.Send(srv.URL, "order.created", map[string]int{"id": 1}); err != nil {
+		t.Fatal(err)
+	}
+	<-attempted
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := sender.Close(ctx); err == nil {
+		t.Error("expected Close to report the timeout")
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Error("expected Close to return once ctx is done")
+	}
+	if atomic.LoadInt32(&deadLettered) != 1 || !errors.Is(deadErr, context.DeadlineExceeded) {
+		t.Errorf("expected the waiting retry to be dead-lettered once with the ctx error, got %d: %v", deadLettered, deadErr)
+	}
+	if len(sender.retries) != 0 {
+		t.Errorf("expected no retries to be left, got %d", len(sender.retries))
+	}
+}
+
+func TestWebhookSender_CloseTwice(t *testing.T) {
+	sender := &WebhookSender{Secret: "secret"}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := sender.Close(ctx); err != nil {
+		t.Fatalf("error not expected, but one received: %s", err)
+	}
+	if err := sender.Close(ctx); err != nil {
+		t.Errorf("second close: error not expected, but one received: %s", err)
+	}
+	if _, err := sender.Send("https://example.com/hook", "order.created", nil); !errors.Is(err, ErrSenderClosed) {
+		t.Errorf("expected ErrSenderClosed after close, got %v", err)
+	}
+}
@@ -0,0 +1,400 @@
+package gohelpertools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrSubscriptionNotFound is returned by a WebhookSubscriptionStore for a subscription which doesn't exist.
+var ErrSubscriptionNotFound = errors.New("webhook subscription not found")
+
+// webhookPingEvent is the event of the test deliveries sent to new and pinged subscriptions.
+const webhookPingEvent = "webhook.ping"
+
+// WebhookSubscription is an endpoint registered by a partner to receive events.
+type WebhookSubscription struct {
+	ID        string    `json:"id"`
+	Owner     string    `json:"-"` // the partner who registered it
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`           // events it receives; all events if empty
+	Secret    string    `json:"secret,omitempty"` // key deliveries are signed with; only returned when it's created or rotated
+	CreatedAt time.Time `json:"created_at"`
+
+	PreviousSecret        string    `json:"-"` // key which is still used, alongside Secret, after a rotation
+	PreviousSecretExpires time.Time `json:"-"` // when PreviousSecret stops being used
+}
+
+// Receives reports whether the subscription is for event.
+func (s WebhookSubscription) Receives(event string) bool {
+	return len(s.Events) == 0 || contains(s.Events, event)
+}
+
+// WebhookSubscriptionStore keeps webhook subscriptions.
+type WebhookSubscriptionStore interface {
+	// Save creates or replaces sub.
+	Save(ctx context.Context, sub WebhookSubscription) error
+	// Get returns the subscription id, or ErrSubscriptionNotFound.
+	Get(ctx context.Context, id string) (WebhookSubscription, error)
+	// List returns the subscriptions of owner, or every subscription if owner is empty.
+	List(ctx context.Context, owner string) ([]WebhookSubscription, error)
+	// Delete removes the subscription id; deleting a missing subscription isn't an error.
+	Delete(ctx context.Context, id string) error
+}
+
+// WebhookSubscriptions lets partners manage their own webhook endpoints, and sends them events through a
+// WebhookSender. Each subscription has its own signing secret; set the sender's Secrets to s.Secrets so
+// deliveries are signed with it. Mount Handler below a prefix with http.StripPrefix:
+//
+//	sender.Secrets = subs.Secrets
+//	mux.Handle("/webhooks/", http.StripPrefix("/webhooks", subs.Handler()))
+//
+// which serves:
+//
+//	POST   /webhooks                        register {"url": ..., "events": [...]}; responds with the secret
+//	GET    /webhooks                        list the partner's subscriptions
+//	GET    /webhooks/{id}                   show a subscription
+//	POST   /webhooks/{id}/rotate-secret     replace the secret; the old one keeps working for RotationGrace
+//	POST   /webhooks/{id}/ping              send a test delivery
+//	DELETE /webhooks/{id}                   delete a subscription
+//
+// URLs must be https and resolve to public addresses (see CheckPublicURL); the sender's default client
+// checks the addresses again when it connects, so that the check can't be sidestepped by a redirect or by
+// changing the DNS afterwards. With AllowPrivateURL, give the sender a Client which allows them too. A
+// "webhook.ping" delivery is sent to each new subscription so partners can check their endpoint.
+type WebhookSubscriptions struct {
+	Store           WebhookSubscriptionStore     // where subscriptions are kept; required
+	Sender          *WebhookSender               // sends events and pings; required
+	Owner           func(r *http.Request) string // identifies the partner making a request; required
+	Events          []string                     // events which may be subscribed to; if empty, any event may be
+	MaxPerOwner     int                          // maximum number of subscriptions per partner; defaults to 10
+	RotationGrace   time.Duration                // how long a rotated secret keeps being used; defaults to 24 hours
+	AllowPrivateURL bool                         // if set to true, http URLs and private addresses are accepted (for local development)
+	Tools           *Tools                       // used to resolve URLs and read and write JSON; a zero Tools is used if nil
+}
+
+// Handler returns the handler for the subscription management endpoints.
+func (s *WebhookSubscriptions) Handler() http.Handler {
+	t := toolsOrDefault(s.Tools)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		owner := ""
+		if s.Owner != nil {
+			owner = s.Owner(r)
+		}
+		if owner == "" {
+			_ = t.ErrorJSON(w, errors.New("unauthorized"), http.StatusUnauthorized)
+			return
+		}
+
+		parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+		switch {
+		case parts[0] == "" && r.Method == http.MethodPost:
+			s.register(w, r, owner)
+		case parts[0] == "" && r.Method == http.MethodGet:
+			s.list(w, r, owner)
+		case parts[0] == "":
+			w.Header().Set("Allow", "GET, POST")
+			_ = t.ErrorJSON(w, errors.New("method not allowed"), http.StatusMethodNotAllowed)
+		case len(parts) == 1 && (r.Method == http.MethodGet || r.Method == http.MethodDelete):
+			s.show(w, r, owner, parts[0])
+		case len(parts) == 2 && r.Method == http.MethodPost && (parts[1] == "rotate-secret" || parts[1] == "ping"):
+			s.action(w, r, owner, parts[0], parts[1])
+		case len(parts) <= 2:
+			_ = t.ErrorJSON(w, errors.New("method not allowed"), http.StatusMethodNotAllowed)
+		default:
+			_ = t.ErrorJSON(w, ErrSubscriptionNotFound, http.StatusNotFound)
+		}
+	})
+}
+
+func (s *WebhookSubscriptions) register(w http.ResponseWriter, r *http.Request, owner string) {
+	t := toolsOrDefault(s.Tools)
+	var input struct {
+		URL    string   `json:"url"`
+		Events []string `json:"events"`
+	}
+	if err := t.ReadJSON(w, r, &input); err != nil {
+		_ = t.ErrorJSON(w, err)
+		return
+	}
+	if err := s.checkURL(r.Context(), input.URL); err != nil {
+		_ = t.ErrorJSON(w, err)
+		return
+	}
+	for _, event := range input.Events {
+		if len(s.Events) > 0 && !contains(s.Events, event) {
+			_ = t.ErrorJSON(w, fmt.Errorf("unknown event %q", event))
+			return
+		}
+	}
+
+	existing, err := s.Store.List(r.Context(), owner)
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	maxPerOwner := s.MaxPerOwner
+	if maxPerOwner <= 0 {
+		maxPerOwner = 10
+	}
+	if len(existing) >= maxPerOwner {
+		_ = t.ErrorJSON(w, fmt.Errorf("no more than %d webhook subscriptions are allowed", maxPerOwner), http.StatusConflict)
+		return
+	}
+
+	id, err := randomToken(12)
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	secret, err := randomToken(32)
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	sub := WebhookSubscription{
+		ID:        "wh_" + id,
+		Owner:     owner,
+		URL:       input.URL,
+		Events:    input.Events,
+		Secret:    "whsec_" + secret,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := s.Store.Save(r.Context(), sub); err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	if _, err := s.Ping(sub); err != nil {
+		t.logWarn(r.Context(), "unable to queue webhook ping", "subscription", sub.ID, "error", err.Error())
+	}
+	_ = t.WriteJSON(w, http.StatusCreated, sub)
+}
+
+func (s *WebhookSubscriptions) list(w http.ResponseWriter, r *http.Request, owner string) {
+	subs, err := s.Store.List(r.Context(), owner)
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	for i := range subs {
+		subs[i].Secret = ""
+	}
+	if subs == nil {
+		subs = []WebhookSubscription{}
+	}
+	_ = toolsOrDefault(s.Tools).WriteJSON(w, http.StatusOK, subs)
+}
+
+func (s *WebhookSubscriptions) show(w http.ResponseWriter, r *http.Request, owner, id string) {
+	t := toolsOrDefault(s.Tools)
+	sub, ok := s.get(w, r, owner, id)
+	if !ok {
+		return
+	}
+	if r.Method == http.MethodDelete {
+		if err := s.Store.Delete(r.Context(), sub.ID); err != nil {
+			s.fail(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	sub.Secret = ""
+	_ = t.WriteJSON(w, http.StatusOK, sub)
+}
+
+func (s *WebhookSubscriptions) action(w http.ResponseWriter, r *http.Request, owner, id, action string) {
+	t := toolsOrDefault(s.Tools)
+	sub, ok := s.get(w, r, owner, id)
+	if !ok {
+		return
+	}
+
+	if action == "ping" {
+		deliveryID, err := s.Ping(sub)
+		if err != nil {
+			s.fail(w, r, err)
+			return
+		}
+		_ = t.WriteJSON(w, http.StatusAccepted, map[string]string{"delivery_id": deliveryID})
+		return
+	}
+
+	secret, err := randomToken(32)
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	grace := s.RotationGrace
+	if grace <= 0 {
+		grace = 24 * time.Hour
+	}
+	sub.PreviousSecret, sub.PreviousSecretExpires = sub.Secret, time.Now().Add(grace)
+	sub.Secret = "whsec_" + secret
+	if err := s.Store.Save(r.Context(), sub); err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	_ = t.WriteJSON(w, http.StatusOK, sub)
+}
+
+// get loads the subscription id, responding with a 404 if it doesn't exist or belongs to someone else.
+func (s *WebhookSubscriptions) get(w http.ResponseWriter, r *http.Request, owner, id string) (WebhookSubscription, bool) {
+	sub, err := s.Store.Get(r.Context(), id)
+	if errors.Is(err, ErrSubscriptionNotFound) || (err == nil && sub.Owner != owner) {
+		_ = toolsOrDefault(s.Tools).ErrorJSON(w, ErrSubscriptionNotFound, http.StatusNotFound)
+		return WebhookSubscription{}, false
+	}
+	if err != nil {
+		s.fail(w, r, err)
+		return WebhookSubscription{}, false
+	}
+	return sub, true
+}
+
+func (s *WebhookSubscriptions) fail(w http.ResponseWriter, r *http.Request, err error) {
+	t := toolsOrDefault(s.Tools)
+	t.LogError(r.Context(), "webhook subscription request failed", err, "path", r.URL.Path)
+	_ = t.ErrorJSON(w, errors.New("unable to process the request"), http.StatusInternalServerError)
+}
+
+func (s *WebhookSubscriptions) checkURL(ctx context.Context, rawURL string) error {
+	if rawURL == "" {
+		return errors.New("url is required")
+	}
+	if s.AllowPrivateURL {
+		if !strings.HasPrefix(rawURL, "https://") && !strings.HasPrefix(rawURL, "http://") {
+			return errors.New("the URL must be an absolute http or https URL")
+		}
+		return nil
+	}
+	if !strings.HasPrefix(rawURL, "https://") {
+		return errors.New("the URL must use https")
+	}
+	return toolsOrDefault(s.Tools).CheckPublicURL(ctx, rawURL)
+}
+
+// Publish sends payload to every subscription which receives event, and returns the delivery IDs.
+// Subscriptions which can't be queued are skipped, and their errors returned together.
+func (s *WebhookSubscriptions) Publish(ctx context.Context, event string, payload any) ([]string, error) {
+	subs, err := s.Store.List(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	var errs []error
+	for _, sub := range subs {
+		if !sub.Receives(event) {
+			continue
+		}
+		delivery, err := s.Sender.newDelivery(sub.URL, event, payload)
+		if err != nil {
+			return ids, err
+		}
+		delivery.SubscriptionID = sub.ID
+		if err := s.Sender.Resend(delivery); err != nil {
+			errs = append(errs, fmt.Errorf("subscription %s: %w", sub.ID, err))
+			continue
+		}
+		ids = append(ids, delivery.ID)
+	}
+	return ids, errors.Join(errs...)
+}
+
+// Ping queues a "webhook.ping" test delivery to sub, and returns its ID. It bypasses the sender's event
+// registry, since the ping isn't an application event.
+func (s *WebhookSubscriptions) Ping(sub WebhookSubscription) (string, error) {
+	body, err := json.Marshal(map[string]any{"event": webhookPingEvent, "subscription_id": sub.ID, "time": time.Now().UTC()})
+	if err != nil {
+		return "", err
+	}
+	id, err := randomToken(16)
+	if err != nil {
+		return "", err
+	}
+	delivery := WebhookDelivery{ID: id, URL: sub.URL, Event: webhookPingEvent, Payload: body, CreatedAt: time.Now(), SubscriptionID: sub.ID}
+	return id, s.Sender.Resend(delivery)
+}
+
+// Secrets returns the keys a delivery for a subscription is signed with: its secret, and its previous one
+// for a while after a rotation. It has the signature of WebhookSender.Secrets.
+func (s *WebhookSubscriptions) Secrets(ctx context.Context, delivery WebhookDelivery) ([]string, error) {
+	if delivery.SubscriptionID == "" {
+		return nil, nil
+	}
+	sub, err := s.Store.Get(ctx, delivery.SubscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	secrets := []string{sub.Secret}
+	if sub.PreviousSecret != "" && time.Now().Before(sub.PreviousSecretExpires) {
+		secrets = append(secrets, sub.PreviousSecret)
+	}
+	return secrets, nil
+}
+
+// MemoryWebhookSubscriptionStore is a WebhookSubscriptionStore which keeps subscriptions in memory, for
+// tests and single-instance services.
+type MemoryWebhookSubscriptionStore struct {
+	mu   sync.RWMutex
+	subs map[string]WebhookSubscription
+}
+
+// NewMemoryWebhookSubscriptionStore returns an empty MemoryWebhookSubscriptionStore.
+func NewMemoryWebhookSubscriptionStore() *MemoryWebhookSubscriptionStore {
+	return &MemoryWebhookSubscriptionStore{subs: make(map[string]WebhookSubscription)}
+}
+
+// Save creates or replaces sub.
+func (m *MemoryWebhookSubscriptionStore) Save(ctx context.Context, sub WebhookSubscription) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	sub.Events = append([]string(nil), sub.Events...)
+	m.subs[sub.ID] = sub
+	return nil
+}
+
+// Get returns the subscription id.
+func (m *MemoryWebhookSubscriptionStore) Get(ctx context.Context, id string) (WebhookSubscription, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	sub, ok := m.subs[id]
+	if !ok {
+		return WebhookSubscription{}, ErrSubscriptionNotFound
+	}
+	return sub, nil
+}
+
+// List returns the subscriptions of owner, or all of them if owner is empty, oldest first.
+func (m *MemoryWebhookSubscriptionStore) List(ctx context.Context, owner string) ([]WebhookSubscription, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var subs []WebhookSubscription
+	for _, sub := range m.subs {
+		if owner == "" || sub.Owner == owner {
+			subs = append(subs, sub)
+		}
+	}
+	sort.Slice(subs, func(i, j int) bool {
+		if !subs[i].CreatedAt.Equal(subs[j].CreatedAt) {
+			return subs[i].CreatedAt.Before(subs[j].CreatedAt)
+		}
+		return subs[i].ID < subs[j].ID
+	})
+	return subs, nil
+}
+
+// Delete removes the subscription id.
+func (m *MemoryWebhookSubscriptionStore) Delete(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.subs, id)
+	return nil
+}
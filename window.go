@@ -0,0 +1,158 @@
+package gohelpertools
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// defaultWindowMaxKeys is how many keys a window counter tracks if no limit is given.
+const defaultWindowMaxKeys = 100000
+
+// WindowCounter counts events per key over a period of time. It is the building block for rate limiting,
+// quotas and metering.
+type WindowCounter interface {
+	// Add records n events for key and returns the count for the current window, including them.
+	Add(key string, n int64) int64
+	// Count returns the count for key in the current window.
+	Count(key string) int64
+	// Reset forgets key.
+	Reset(key string)
+	// Snapshot and Restore save and load the counts, so that limits hold across restarts; see Snapshots.
+	Snapshotter
+}
+
+// windowCounter implements both fixed and sliding windows. Each key keeps only the counts for the current
+// and previous fixed windows, so memory use per key is constant, and the number of keys is capped.
+type windowCounter struct {
+	window  time.Duration
+	maxKeys int
+	sliding bool
+	now     func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*windowBucket
+	order   *list.List // keys, most recently used at the front
+}
+
+// windowBucket holds the counts for one key.
+type windowBucket struct {
+	start    time.Time // start of the current fixed window
+	current  int64
+	previous int64
+	element  *list.Element // the key's element in the counter's order
+}
+
+// NewFixedWindow returns a WindowCounter which counts events in consecutive, non-overlapping windows of
+// length window, so counts drop to zero at each window boundary. At most maxKeys keys are tracked (100,000
+// if maxKeys is zero or less); when the limit is reached, the least recently used keys are dropped. It
+// panics if window isn't positive.
+func NewFixedWindow(window time.Duration, maxKeys int) WindowCounter {
+	if window <= 0 {
+		panic("gohelpertools: non-positive window for NewFixedWindow")
+	}
+	return newWindowCounter(window, maxKeys, false)
+}
+
+// NewSlidingWindow returns a WindowCounter which approximates the number of events in the last window
+// period, by weighting the previous fixed window's count by how much of it overlaps the sliding window.
+// This avoids the burst of twice the limit that fixed windows allow around a boundary, while using the
+// same constant memory per key. maxKeys is as for NewFixedWindow. It panics if window isn't positive.
+func NewSlidingWindow(window time.Duration, maxKeys int) WindowCounter {
+	if window <= 0 {
+		panic("gohelpertools: non-positive window for NewSlidingWindow")
+	}
+	return newWindowCounter(window, maxKeys, true)
+}
+
+func newWindowCounter(window time.Duration, maxKeys int, sliding bool) *windowCounter {
+	if maxKeys <= 0 {
+		maxKeys = defaultWindowMaxKeys
+	}
+	return &windowCounter{
+		window:  window,
+		maxKeys: maxKeys,
+		sliding: sliding,
+		now:     time.Now,
+		buckets: make(map[string]*windowBucket),
+		order:   list.New(),
+	}
+}
+
+func (c *windowCounter) Add(key string, n int64) int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	bucket, ok := c.buckets[key]
+	if !ok {
+		bucket = &windowBucket{start: now.Truncate(c.window)}
+		c.insert(key, bucket)
+	}
+
+	c.order.MoveToFront(bucket.element)
+	c.advance(bucket, now)
+	bucket.current += n
+	return c.count(bucket, now)
+}
+
+func (c *windowCounter) Count(key string) int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	bucket, ok := c.buckets[key]
+	if !ok {
+		return 0
+	}
+
+	now := c.now()
+	c.order.MoveToFront(bucket.element)
+	c.advance(bucket, now)
+	return c.count(bucket, now)
+}
+
+func (c *windowCounter) Reset(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if bucket, ok := c.buckets[key]; ok {
+		c.order.Remove(bucket.element)
+		delete(c.buckets, key)
+	}
+}
+
+// insert adds bucket for key, which isn't tracked yet, as the most recently used, dropping the least
+// recently used key if the counter is full.
+func (c *windowCounter) insert(key string, bucket *windowBucket) {
+	if len(c.buckets) >= c.maxKeys {
+		if oldest := c.order.Back(); oldest != nil {
+			delete(c.buckets, oldest.Value.(string))
+			c.order.Remove(oldest)
+		}
+	}
+	bucket.element = c.order.PushFront(key)
+	c.buckets[key] = bucket
+}
+
+// advance moves bucket on to the fixed window containing now.
+func (c *windowCounter) advance(bucket *windowBucket, now time.Time) {
+	start := now.Truncate(c.window)
+	switch {
+	case !start.After(bucket.start):
+		return
+	case start.Sub(bucket.start) == c.window:
+		bucket.previous = bucket.current
+	default:
+		bucket.previous = 0
+	}
+	bucket.current = 0
+	bucket.start = start
+}
+
+// count returns the count for an up-to-date bucket.
+func (c *windowCounter) count(bucket *windowBucket, now time.Time) int64 {
+	if !c.sliding {
+		return bucket.current
+	}
+	overlap := 1 - float64(now.Sub(bucket.start))/float64(c.window)
+	return bucket.current + int64(float64(bucket.previous)*overlap)
+}
@@ -0,0 +1,99 @@
+package gohelpertools
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// fakeClock is a time source for tests which only moves when told to.
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func TestFixedWindow(t *testing.T) {
+	clock := &fakeClock{t: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	counter := NewFixedWindow(time.Minute, 0).(*windowCounter)
+	counter.now = clock.now
+
+	counter.Add("a", 3)
+	if count := counter.Add("a", 2); count != 5 {
+		t.Errorf("expected 5 but got %d", count)
+	}
+	if count := counter.Count("b"); count != 0 {
+		t.Errorf("expected 0 for unknown key but got %d", count)
+	}
+
+	clock.advance(time.Minute)
+	if count := counter.Count("a"); count != 0 {
+		t.Errorf("expected count to reset in the next window, but got %d", count)
+	}
+
+	counter.Add("a", 1)
+	counter.Reset("a")
+	if count := counter.Count("a"); count != 0 {
+		t.Errorf("expected 0 after reset but got %d", count)
+	}
+}
+
+func TestSlidingWindow(t *testing.T) {
+	clock := &fakeClock{t: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	counter := NewSlidingWindow(time.Minute, 0).(*windowCounter)
+	counter.now = clock.now
+
+	counter.Add("a", 10)
+
+	// A quarter of the way into the next window, three quarters of the previous count still applies.
+	clock.advance(75 * time.Second)
+	if count := counter.Add("a", 1); count != 8 {
+		t.Errorf("expected 8 but got %d", count)
+	}
+
+	clock.advance(2 * time.Minute)
+	if count := counter.Count("a"); count != 0 {
+		t.Errorf("expected 0 after two idle windows but got %d", count)
+	}
+}
+
+func TestWindowCounter_MaxKeys(t *testing.T) {
+	clock := &fakeClock{t: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	counter := NewFixedWindow(time.Minute, 3).(*windowCounter)
+	counter.now = clock.now
+
+	for i := 0; i < 3; i++ {
+		counter.Add(fmt.Sprintf("old-%d", i), 1)
+	}
+	clock.advance(3 * time.Minute)
+	for i := 0; i < 5; i++ {
+		counter.Add(fmt.Sprintf("new-%d", i), 1)
+	}
+
+	if len(counter.buckets) > 3 {
+		t.Errorf("expected at most 3 keys but got %d", len(counter.buckets))
+	}
+	if counter.Count("new-4") != 1 {
+		t.Error("expected the newest key to be kept")
+	}
+}
+
+func TestWindowCounter_EvictsLeastRecentlyUsed(t *testing.T) {
+	counter := NewSlidingWindow(time.Minute, 2)
+	counter.Add("a", 1)
+	counter.Add("b", 1)
+	counter.Count("a")
+	counter.Add("c", 1)
+
+	if counter.Count("a") != 1 || counter.Count("b") != 0 || counter.Count("c") != 1 {
+		t.Errorf("expected b, the least recently used key, to be dropped")
+	}
+}
+
+func TestNewSlidingWindow_InvalidWindow(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic for a zero window")
+		}
+	}()
+	NewSlidingWindow(0, 0)
+}
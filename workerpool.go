@@ -0,0 +1,220 @@
+package gohelpertools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrPoolClosed is returned by WorkerPool.Submit after Close has been called.
+var ErrPoolClosed = errors.New("worker pool is closed")
+
+// ErrPoolFull is returned by WorkerPool.Submit when the queue is full.
+var ErrPoolFull = errors.New("worker pool queue is full")
+
+// WorkerPool runs jobs, such as sending emails or calling webhooks, in the background with bounded
+// concurrency, so handlers can respond without waiting for them. Each attempt at a job can be given a
+// timeout, a panicking job is recovered and treated as failed, and failed jobs can be retried with
+// exponential backoff. Close stops accepting jobs and waits for those already submitted to finish.
+type WorkerPool struct {
+	Workers     int                             // number of jobs run at once; defaults to 4
+	QueueSize   int                             // jobs which may be waiting for a worker; defaults to 1000
+	Timeout     time.Duration                   // if above zero, the context of each attempt is cancelled after this long
+	MaxAttempts int                             // attempts before a job is given up on; defaults to 1, which means no retries
+	Backoff     func(attempt int) time.Duration // delay before retrying after the given attempt; defaults to 1s doubling up to 1m
+	Retryable   func(error) bool                // if set, only errors for which it returns true are retried
+	OnError     func(err error)                 // if set, called when a job is given up on
+	Tools       *Tools                          // used to log failed jobs; a zero Tools is used if nil
+
+	startOnce sync.Once
+	queue     chan poolJob
+	quit      chan struct{}
+	ctx       context.Context
+	cancel    context.CancelFunc
+	pending   sync.WaitGroup
+	workers   sync.WaitGroup
+	mu        sync.Mutex
+	closed    bool // set when Close is called; no more jobs are accepted
+	stopped   bool // set when the workers are told to stop; no more retries are queued
+}
+
+type poolJob struct {
+	run      func(ctx context.Context) error
+	attempts int
+}
+
+// Submit queues job to be run by a worker. It fails with ErrPoolFull if the queue is full, rather than
+// blocking the caller, and with ErrPoolClosed once Close has been called. The context passed to job is
+// cancelled when its Timeout expires, or when Close gives up waiting for it.
+func (p *WorkerPool) Submit(job func(ctx context.Context) error) error {
+	p.start()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return ErrPoolClosed
+	}
+
+	p.pending.Add(1)
+	select {
+	case p.queue <- poolJob{run: job}:
+		return nil
+	default:
+		p.pending.Done()
+		return ErrPoolFull
+	}
+}
+
+// Close stops accepting jobs, and waits until every submitted job (including those waiting to be retried)
+// has finished or been given up on, or until ctx is done. When ctx is done, the contexts of running jobs
+// are cancelled, and jobs which haven't started are given up on with ctx's error. Calling Close again
+// does nothing.
+func (p *WorkerPool) Close(ctx context.Context) error {
+	p.start()
+
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.pending.Wait()
+		close(done)
+	}()
+
+	var err error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	p.cancel()
+	p.mu.Lock()
+	p.stopped = true
+	p.mu.Unlock()
+	close(p.quit)
+	p.workers.Wait()
+
+	// Anything left in the queue was never run.
+	for {
+		select {
+		case job := <-p.queue:
+			p.fail(job, ctx.Err())
+		default:
+			return err
+		}
+	}
+}
+
+func (p *WorkerPool) start() {
+	p.startOnce.Do(func() {
+		queueSize := p.QueueSize
+		if queueSize <= 0 {
+			queueSize = 1000
+		}
+		workers := p.Workers
+		if workers <= 0 {
+			workers = 4
+		}
+
+		p.queue = make(chan poolJob, queueSize)
+		p.quit = make(chan struct{})
+		p.ctx, p.cancel = context.WithCancel(context.Background())
+		for i := 0; i < workers; i++ {
+			p.workers.Add(1)
+			go p.work()
+		}
+	})
+}
+
+func (p *WorkerPool) work() {
+	defer p.workers.Done()
+	for {
+		select {
+		case job := <-p.queue:
+			p.attempt(job)
+		case <-p.quit:
+			return
+		}
+	}
+}
+
+// attempt runs job once, and schedules a retry or gives up on it if it fails.
+func (p *WorkerPool) attempt(job poolJob) {
+	job.attempts++
+
+	err := p.run(job)
+	if err == nil {
+		p.pending.Done()
+		return
+	}
+
+	maxAttempts := p.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 1
+	}
+	if job.attempts >= maxAttempts || p.ctx.Err() != nil || (p.Retryable != nil && !p.Retryable(err)) {
+		p.fail(job, err)
+		return
+	}
+
+	// Wait for the backoff without holding up a worker. The job is queued under the lock, so that it is
+	// either queued before Close stops the workers, and so is run or drained by Close, or given up on here.
+	time.AfterFunc(p.backoff(job.attempts), func() {
+		p.mu.Lock()
+		if p.stopped {
+			p.mu.Unlock()
+			p.fail(job, err)
+			return
+		}
+		p.queue <- job
+		p.mu.Unlock()
+	})
+}
+
+// run calls the job, turning a panic into an error.
+func (p *WorkerPool) run(job poolJob) (err error) {
+	ctx := p.ctx
+	if p.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.Timeout)
+		defer cancel()
+	}
+
+	defer func() {
+		if v := recover(); v != nil {
+			err = fmt.Errorf("job panicked: %v", v)
+		}
+	}()
+	return job.run(ctx)
+}
+
+func (p *WorkerPool) fail(job poolJob, err error) {
+	if err == nil {
+		err = ErrPoolClosed
+	}
+	toolsOrDefault(p.Tools).LogError(context.Background(), "background job failed", err, "attempts", job.attempts)
+	if p.OnError != nil {
+		p.OnError(err)
+	}
+	p.pending.Done()
+}
+
+func (p *WorkerPool) backoff(attempt int) time.Duration {
+	if p.Backoff != nil {
+		return p.Backoff(attempt)
+	}
+
+	delay := time.Second << (attempt - 1)
+	if delay > time.Minute || delay <= 0 {
+		delay = time.Minute
+	}
+	return delay
+}
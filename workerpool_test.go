@@ -0,0 +1,162 @@
+package gohelpertools
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWorkerPool(t *testing.T) {
+	var failures []error
+	var mu sync.Mutex
+	pool := &WorkerPool{
+		Workers:     2,
+		MaxAttempts: 3,
+		Backoff:     func(int) time.Duration { return time.Millisecond },
+		Retryable:   func(err error) bool { return !strings.Contains(err.Error(), "permanent") },
+		OnError: func(err error) {
+			mu.Lock()
+			failures = append(failures, err)
+			mu.Unlock()
+		},
+	}
+
+	var running, maxRunning, done int32
+	for i := 0; i < 6; i++ {
+		err := pool.Submit(func(ctx context.Context) error {
+			n := atomic.AddInt32(&running, 1)
+			defer atomic.AddInt32(&running, -1)
+			for {
+				m := atomic.LoadInt32(&maxRunning)
+				if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			atomic.AddInt32(&done, 1)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var flakyCalls int32
+	_ = pool.Submit(func(ctx context.Context) error {
+		if atomic.AddInt32(&flakyCalls, 1) < 3 {
+			return errors.New("temporary")
+		}
+		return nil
+	})
+
+	var permanentCalls int32
+	_ = pool.Submit(func(ctx context.Context) error {
+		atomic.AddInt32(&permanentCalls, 1)
+		return errors.New("permanent")
+	})
+	_ = pool.Submit(func(ctx context.Context) error {
+		panic("boom")
+	})
+
+	if err := pool.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := pool.Submit(func(ctx context.Context) error { return nil }); err != ErrPoolClosed {
+		t.Errorf("expected ErrPoolClosed after closing, got %v", err)
+	}
+
+	if done != 6 {
+		t.Errorf("expected 6 jobs to be done, got %d", done)
+	}
+	if maxRunning > 2 {
+		t.Errorf("expected at most 2 jobs at once, got %d", maxRunning)
+	}
+	if flakyCalls != 3 {
+		t.Errorf("expected the flaky job to be tried 3 times, got %d", flakyCalls)
+	}
+	if permanentCalls != 1 {
+		t.Errorf("expected the permanent failure not to be retried, got %d calls", permanentCalls)
+	}
+	if len(failures) != 2 {
+		t.Fatalf("expected 2 failures, got %v", failures)
+	}
+	panicked := false
+	for _, err := range failures {
+		panicked = panicked || strings.Contains(err.Error(), "panicked: boom")
+	}
+	if !panicked {
+		t.Errorf("expected the panic to be reported, got %v", failures)
+	}
+}
+
+func TestWorkerPool_TimeoutAndClose(t *testing.T) {
+	pool := &WorkerPool{Workers: 1, QueueSize: 1, Timeout: 10 * time.Millisecond}
+
+	timedOut := make(chan error, 1)
+	_ = pool.Submit(func(ctx context.Context) error {
+		<-ctx.Done()
+		timedOut <- ctx.Err()
+		return nil
+	})
+	if timeoutErr := <-timedOut; !errors.Is(timeoutErr, context.DeadlineExceeded) {
+		t.Errorf("expected the job to time out, got %v", timeoutErr)
+	}
+
+	// A job which ignores the timeout holds the only worker, so the queue fills up.
+	release := make(chan struct{})
+	_ = pool.Submit(func(ctx context.Context) error {
+		<-release
+		return nil
+	})
+	time.Sleep(5 * time.Millisecond)
+	_ = pool.Submit(func(ctx context.Context) error { return nil })
+	if err := pool.Submit(func(ctx context.Context) error { return nil }); err != ErrPoolFull {
+		t.Errorf("expected ErrPoolFull, got %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		close(release)
+	}()
+	if err := pool.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected Close to give up waiting, got %v", err)
+	}
+}
+
+func TestWorkerPool_RetryAfterClose(t *testing.T) {
+	failed := make(chan error, 1)
+	pool := &WorkerPool{
+		MaxAttempts: 3,
+		Backoff:     func(int) time.Duration { return 20 * time.Millisecond },
+		OnError:     func(err error) { failed <- err },
+		Tools:       &Tools{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))},
+	}
+	attempted := make(chan struct{}, 1)
+	_ = pool.Submit(func(ctx context.Context) error {
+		attempted <- struct{}{}
+		return errors.New("boom")
+	})
+	<-attempted
+
+	// Close gives up before the retry is due, so the retry must be given up on rather than queued.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	_ = pool.Close(ctx)
+
+	select {
+	case <-failed:
+	case <-time.After(time.Second):
+		t.Error("expected the pending retry to be given up on")
+	}
+	if err := pool.Close(context.Background()); err != nil {
+		t.Errorf("second close: error not expected, but one received: %s", err)
+	}
+}